@@ -1,26 +1,217 @@
 package controller
 
 import (
+	"client/plugin"
 	"client/usecase"
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
+	"shared/command"
+	"shared/utility"
+	"time"
 )
 
-func (c *Controller) HandleScanDevices(u usecase.ScanDevices) {
+func (c *Controller) ScanDevicesPlugin(u usecase.ScanDevices, scans *usecase.RunningScans) plugin.ScannerPlugin {
 
-	c.SSEClient.AddEventHandler("scan_icmp", func(data []byte) error {
+	return plugin.NewCommandPlugin("icmp", command.ScanICMP, func(ctx context.Context, env plugin.Env, request command.ScanRequest) error {
 
-		var payload usecase.ScanDevicesReq
-		if err := json.Unmarshal(data, &payload); err != nil {
-			return fmt.Errorf("error parsing request payload: %v", err)
+		payload := usecase.ScanDevicesReq{ScanRequest: request, ClientID: c.SSEClient.GetClientID()}
+
+		// job dicatat sebelum ack, scan_cancel yang langsung menyusul sudah menemukannya
+		ctx, done := scans.Start(ctx, payload.JobID)
+
+		// scan berjalan di background: handler cepat selesai sehingga ack terkirim
+		// saat perintah diterima dan stream SSE tidak tertahan selama scan
+		go func() {
+			defer done()
+
+			// span job mencakup probe, upload dan laporan, semuanya dalam trace trigger
+			ctx, span := utility.StartSpan(ctx, "scan_icmp "+payload.JobID)
+			defer span.End()
+
+			_, err := u(ctx, payload)
+			span.SetError(err)
+			switch {
+			case errors.Is(context.Cause(ctx), usecase.ErrScanCancelled):
+				utility.Infof("Scan job %s dibatalkan", payload.JobID)
+			case err != nil:
+				utility.Errorf("Scan job %s gagal: %v", payload.JobID, err)
+			}
+		}()
+
+		return nil
+	})
+
+}
+
+// ScanNmapPlugin menjalankan job scan_nmap di background, terpisah dari scan_icmp karena scan nmap jauh lebih berat
+func (c *Controller) ScanNmapPlugin(u usecase.ScanNmap, scans *usecase.RunningScans) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("nmap", command.ScanNmap, func(ctx context.Context, env plugin.Env, request command.ScanRequest) error {
+
+		payload := usecase.ScanNmapReq{ScanRequest: request, ClientID: c.SSEClient.GetClientID()}
+
+		ctx, done := scans.Start(ctx, payload.JobID)
+
+		go func() {
+			defer done()
+
+			ctx, span := utility.StartSpan(ctx, "scan_nmap "+payload.JobID)
+			defer span.End()
+
+			_, err := u(ctx, payload)
+			span.SetError(err)
+			switch {
+			case errors.Is(context.Cause(ctx), usecase.ErrScanCancelled):
+				utility.Infof("Scan job nmap %s dibatalkan", payload.JobID)
+			case err != nil:
+				utility.Errorf("Scan job nmap %s gagal: %v", payload.JobID, err)
+			}
+		}()
+
+		return nil
+	})
+
+}
+
+// ScanCancelPlugin menghentikan job scan_icmp atau scan_nmap yang sedang berjalan, job melaporkan dirinya cancelled
+func (c *Controller) ScanCancelPlugin(scans *usecase.RunningScans) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("scan-cancel", command.ScanCancel, func(ctx context.Context, env plugin.Env, request command.ScanCancelRequest) error {
+		return scans.Cancel(request)
+	})
+
+}
+
+// DiscoverServicesPlugin mendengarkan pengumuman mDNS dan SSDP di background, seperti scan ack terkirim saat perintah diterima
+func (c *Controller) DiscoverServicesPlugin(u usecase.DiscoverServices) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("service-discovery", command.DiscoverServices, func(ctx context.Context, env plugin.Env, request command.DiscoverServicesRequest) error {
+
+		payload := usecase.DiscoverServicesReq{DiscoverServicesRequest: request, ClientID: c.SSEClient.GetClientID()}
+
+		go func() {
+			ctx, span := utility.StartSpan(ctx, "discover_services")
+			defer span.End()
+
+			res, err := u(ctx, payload)
+			span.SetError(err)
+			if err != nil {
+				utility.Errorf("Discovery service gagal: %v", err)
+				return
+			}
+			utility.Infof("Discovery service dilaporkan: %d service, %d baru", res.Found, res.Created)
+		}()
+
+		return nil
+	})
+
+}
+
+// TraceroutePlugin menelusuri jalur ke target di background, hasilnya dilaporkan ke server
+func (c *Controller) TraceroutePlugin(u usecase.Traceroute) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("traceroute", command.Traceroute, func(ctx context.Context, env plugin.Env, request command.TracerouteRequest) error {
+
+		payload := usecase.TracerouteReq{TracerouteRequest: request, ClientID: c.SSEClient.GetClientID()}
+
+		go func() {
+			ctx, span := utility.StartSpan(ctx, "traceroute "+payload.TraceID)
+			defer span.End()
+
+			_, err := u(ctx, payload)
+			span.SetError(err)
+			if err != nil {
+				utility.Errorf("Traceroute %s gagal: %v", payload.TraceID, err)
+			}
+		}()
+
+		return nil
+	})
+
+}
+
+// ResourceLimitsPlugin menerima batas resource yang dikirim server dan langsung menerapkannya
+func (c *Controller) ResourceLimitsPlugin(guard *usecase.ResourceGuard) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("resource-guard", command.SetResourceLimits, func(ctx context.Context, env plugin.Env, limits command.ResourceLimits) error {
+
+		guard.SetLimits(limits)
+		utility.Infof("Batas resource diperbarui: CPU %.0f%%, memory %d MB, %d probe/detik", limits.MaxCPUPercent, limits.MaxMemoryMB, limits.MaxProbesPerSecond)
+
+		return nil
+	})
+
+}
+
+// LogLevelPlugin mengubah level log agent tanpa restart, level sebelumnya kembali sendiri jika durasi diisi
+func (c *Controller) LogLevelPlugin(logger *utility.Logger) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("log-level", command.SetLogLevel, func(ctx context.Context, env plugin.Env, request command.SetLogLevelRequest) error {
+
+		// level yang tidak dikenal gagal tanpa ack, server melaporkan agent ini belum menerapkannya
+		level, err := utility.ParseLogLevel(request.Level)
+		if err != nil {
+			return err
 		}
 
-		if _, err := u(context.Background(), payload); err != nil {
+		logger.SetLevelFor(level, time.Duration(request.DurationSeconds)*time.Second)
+
+		return nil
+	})
+
+}
+
+// ScanSchedulePlugin menerima jadwal scan dari server yang menggantikan jadwal lokal agent
+func (c *Controller) ScanSchedulePlugin(scheduler *usecase.ScanScheduler) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("scheduler", command.UpdateSchedule, func(ctx context.Context, env plugin.Env, override command.ScheduleUpdate) error {
+
+		// jadwal yang tidak valid ditolak utuh, tanpa ack server tahu jadwal lama masih berlaku
+		if err := scheduler.SetOverride(override.Schedules); err != nil {
 			return err
 		}
 
+		if override.Schedules == nil {
+			utility.Infof("Jadwal scan dari server dilepas, kembali ke jadwal lokal")
+			return nil
+		}
+		utility.Infof("Jadwal scan dari server dipasang: %d jadwal", len(override.Schedules))
+
 		return nil
 	})
 
 }
+
+// AgentControlPlugin menerima perintah restart atau shutdown, ack terkirim sebelum agent berhenti
+func (c *Controller) AgentControlPlugin(control *usecase.AgentControl, action string) plugin.ScannerPlugin {
+
+	agentCommand := command.AgentRestart
+	if action == usecase.AgentShutdown {
+		agentCommand = command.AgentShutdown
+	}
+
+	return plugin.NewCommandPlugin("agent-"+action, agentCommand, func(ctx context.Context, env plugin.Env, request command.AgentControlRequest) error {
+		return control.Request(action, request)
+	})
+
+}
+
+// SecretSetPlugin menyimpan credential yang dikirim server, ack hanya terkirim jika tersimpan di disk
+func (c *Controller) SecretSetPlugin(u usecase.SecretSet) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("secret-set", command.SetSecret, func(ctx context.Context, env plugin.Env, request command.SecretSetRequest) error {
+		_, err := u(ctx, request)
+		return err
+	})
+
+}
+
+// SecretDeletePlugin menghapus credential dari secrets store agent
+func (c *Controller) SecretDeletePlugin(u usecase.SecretDelete) plugin.ScannerPlugin {
+
+	return plugin.NewCommandPlugin("secret-delete", command.DeleteSecret, func(ctx context.Context, env plugin.Env, request command.SecretDeleteRequest) error {
+		_, err := u(ctx, request)
+		return err
+	})
+
+}
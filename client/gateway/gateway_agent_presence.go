@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"shared/core"
+	"time"
+)
+
+// AgentIdentity adalah identitas yang dikirim agent saat mendaftar ke server
+type AgentIdentity struct {
+	ClientID string `json:"client_id"`
+	Version  string `json:"version"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Hostname string `json:"hostname"`
+}
+
+type AgentPresenceReq struct {
+	Identity AgentIdentity
+}
+
+type AgentPresenceRes struct {
+	HeartbeatInterval time.Duration // interval heartbeat dari server, 0 jika server tidak menyebutnya
+}
+
+// ErrAgentNotRegistered dikembalikan heartbeat saat server tidak mengenal agent, agent harus mendaftar lagi
+var ErrAgentNotRegistered = errors.New("agent is not registered")
+
+type RegisterAgent = core.ActionHandler[AgentPresenceReq, AgentPresenceRes]
+
+type SendHeartbeat = core.ActionHandler[AgentPresenceReq, AgentPresenceRes]
+
+func ImplRegisterAgent(callServer CallServer) RegisterAgent {
+	return func(ctx context.Context, req AgentPresenceReq) (*AgentPresenceRes, error) {
+		return callPresence(ctx, callServer, "/api/clients/register", req.Identity, "register agent")
+	}
+}
+
+func ImplSendHeartbeat(callServer CallServer) SendHeartbeat {
+	return func(ctx context.Context, req AgentPresenceReq) (*AgentPresenceRes, error) {
+		res, err := callPresence(ctx, callServer, "/api/clients/heartbeat", map[string]any{"client_id": req.Identity.ClientID}, "send heartbeat")
+		var statusErr presenceStatusError
+		// hanya 404 yang berarti agent tidak dikenal, 400 lain (misalnya token tidak cocok) tidak diperbaiki dengan mendaftar lagi
+		if errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %v", ErrAgentNotRegistered, err)
+		}
+		return res, err
+	}
+}
+
+// presenceStatusError adalah jawaban server selain 200
+type presenceStatusError struct {
+	statusCode int
+	message    string
+}
+
+func (e presenceStatusError) Error() string { return e.message }
+
+func callPresence(ctx context.Context, callServer CallServer, path string, payload any, action string) (*AgentPresenceRes, error) {
+
+	res, err := callServer(ctx, CallServerReq{
+		Method:  http.MethodPost,
+		Path:    path,
+		Payload: payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope serverResponse
+	decodeErr := json.Unmarshal(res.Body, &envelope)
+
+	if res.StatusCode != http.StatusOK {
+		if decodeErr == nil && envelope.Error != nil {
+			return nil, presenceStatusError{statusCode: res.StatusCode, message: fmt.Sprintf("%s: %s", action, *envelope.Error)}
+		}
+		return nil, presenceStatusError{statusCode: res.StatusCode, message: fmt.Sprintf("%s: server responded with status %d", action, res.StatusCode)}
+	}
+
+	var data struct {
+		HeartbeatIntervalSeconds int `json:"heartbeat_interval_seconds"`
+	}
+	if decodeErr == nil && len(envelope.Data) > 0 {
+		_ = json.Unmarshal(envelope.Data, &data)
+	}
+
+	return &AgentPresenceRes{HeartbeatInterval: time.Duration(data.HeartbeatIntervalSeconds) * time.Second}, nil
+}
@@ -2,19 +2,22 @@ package gateway
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"shared/core"
+	"shared/utility"
 	"time"
 )
 
 type CallServerReq struct {
-	Method  string
-	Path    string
-	Payload any
+	Method   string
+	Path     string
+	Payload  any
+	Compress bool // gzip the request body, the server inflates it transparently
 }
 
 type CallServerRes struct {
@@ -25,7 +28,31 @@ type CallServerRes struct {
 
 type CallServer = core.ActionHandler[CallServerReq, CallServerRes]
 
-func ImplCallServer() CallServer {
+type CallServerConfig struct {
+	BaseURL  func() string // resolved per request so uploads follow server failover
+	SPKIPins []string      // optional, pins the server TLS public key
+	// Stats counts the requests sent to the server, optional
+	Stats *utility.HTTPStats
+	// BearerToken is sent as Authorization: Bearer <token> when the server authorizes its routes, optional
+	BearerToken string
+}
+
+func ImplCallServer(config CallServerConfig) (CallServer, error) {
+
+	transport, err := utility.NewHTTPTransport(config.SPKIPins)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create HTTP client with timeout
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}
+	if config.Stats != nil {
+		client.Transport = config.Stats.RoundTripper(transport)
+	}
+
 	return func(ctx context.Context, req CallServerReq) (*CallServerRes, error) {
 
 		// Set default values if needed
@@ -33,8 +60,14 @@ func ImplCallServer() CallServer {
 			req.Method = "GET"
 		}
 
-		baseURL := "http://localhost:8080"
-		fullURL := fmt.Sprintf("%s%s", baseURL, req.Path)
+		// a traced caller gets a client span whose traceparent the server continues
+		if _, traced := utility.TraceFromContext(ctx); traced {
+			var span *utility.Span
+			ctx, span = utility.StartSpan(ctx, req.Method+" "+req.Path)
+			defer span.End()
+		}
+
+		fullURL := fmt.Sprintf("%s%s", config.BaseURL(), req.Path)
 
 		var bodyReader io.Reader
 		if req.Payload != nil {
@@ -42,6 +75,19 @@ func ImplCallServer() CallServer {
 			if err != nil {
 				return nil, fmt.Errorf("failed to marshal payload: %w", err)
 			}
+
+			if req.Compress {
+				var compressed bytes.Buffer
+				gz := gzip.NewWriter(&compressed)
+				if _, err := gz.Write(jsonData); err != nil {
+					return nil, fmt.Errorf("failed to compress payload: %w", err)
+				}
+				if err := gz.Close(); err != nil {
+					return nil, fmt.Errorf("failed to compress payload: %w", err)
+				}
+				jsonData = compressed.Bytes()
+			}
+
 			bodyReader = bytes.NewReader(jsonData)
 		}
 
@@ -52,13 +98,17 @@ func ImplCallServer() CallServer {
 		}
 
 		// Set headers
+		if trace, traced := utility.TraceFromContext(ctx); traced {
+			httpReq.Header.Set(utility.TraceparentHeader, trace.Traceparent())
+		}
+		if config.BearerToken != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+config.BearerToken)
+		}
 		if req.Payload != nil {
 			httpReq.Header.Set("Content-Type", "application/json")
-		}
-
-		// Create HTTP client with timeout
-		client := &http.Client{
-			Timeout: 30 * time.Second,
+			if req.Compress {
+				httpReq.Header.Set("Content-Encoding", "gzip")
+			}
 		}
 
 		// Execute the request
@@ -82,5 +132,5 @@ func ImplCallServer() CallServer {
 		}
 
 		return &result, nil
-	}
+	}, nil
 }
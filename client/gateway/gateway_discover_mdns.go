@@ -0,0 +1,257 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"shared/command"
+	"shared/core"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DiscoveredService is one service a device announced on the agent's segment
+type DiscoveredService = command.DiscoveredService
+
+type ListenServicesReq struct {
+	Window time.Duration
+}
+
+type ListenServicesRes struct {
+	Services []DiscoveredService
+}
+
+type ListenMDNS = core.ActionHandler[ListenServicesReq, ListenServicesRes]
+
+var (
+	mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+	// the enumeration finds every type, the common ones are asked directly for responders that skip it
+	mdnsBrowseTypes = []string{
+		"_services._dns-sd._udp.local.",
+		"_ipp._tcp.local.",
+		"_printer._tcp.local.",
+		"_pdl-datastream._tcp.local.",
+		"_http._tcp.local.",
+		"_smb._tcp.local.",
+		"_afpovertcp._tcp.local.",
+		"_rtsp._tcp.local.",
+		"_onvif._tcp.local.",
+		"_googlecast._tcp.local.",
+		"_airplay._tcp.local.",
+	}
+)
+
+const mdnsServiceEnumeration = "_services._dns-sd._udp.local."
+
+// ImplListenMDNS listens to the mDNS group for the window and asks for the services on the segment,
+// the answers to the queries arrive unicast on an ephemeral socket so port 5353 being taken by a
+// local responder only costs the unsolicited announcements
+func ImplListenMDNS() ListenMDNS {
+	return func(ctx context.Context, req ListenServicesReq) (*ListenServicesRes, error) {
+
+		query, err := net.ListenUDP("udp4", &net.UDPAddr{})
+		if err != nil {
+			return nil, err
+		}
+		defer query.Close()
+
+		packets := make(chan mdnsPacket, 64)
+		ctx, cancel := context.WithTimeout(ctx, req.Window)
+		defer cancel()
+
+		go readMDNS(ctx, query, packets)
+
+		// avahi atau bonjour di host yang sama bisa memegang port 5353, query tetap jalan tanpa listener ini
+		if group, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup); err == nil {
+			defer group.Close()
+			go readMDNS(ctx, group, packets)
+		}
+
+		asked := map[string]bool{}
+		ask := func(names ...string) {
+			var fresh []string
+			for _, name := range names {
+				if !asked[name] {
+					asked[name] = true
+					fresh = append(fresh, name)
+				}
+			}
+			if len(fresh) == 0 {
+				return
+			}
+			if packet, err := mdnsQuery(fresh); err == nil {
+				query.WriteToUDP(packet, mdnsGroup)
+			}
+		}
+		ask(mdnsBrowseTypes...)
+
+		records := newMDNSRecords()
+		for {
+			select {
+			case <-ctx.Done():
+				return &ListenServicesRes{Services: records.services()}, nil
+			case packet := <-packets:
+				// tipe baru dari enumerasi ditanyakan instance-nya
+				ask(records.add(packet)...)
+			}
+		}
+	}
+}
+
+type mdnsPacket struct {
+	from net.IP
+	msg  dnsmessage.Message
+	at   time.Time
+}
+
+func readMDNS(ctx context.Context, conn *net.UDPConn, packets chan<- mdnsPacket) {
+	buf := make([]byte, 9000)
+	for {
+		deadline, _ := ctx.Deadline()
+		conn.SetReadDeadline(deadline)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buf[:n]); err != nil || !msg.Header.Response {
+			continue
+		}
+
+		select {
+		case packets <- mdnsPacket{from: from.IP, msg: msg, at: time.Now()}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func mdnsQuery(names []string) ([]byte, error) {
+	msg := dnsmessage.Message{}
+	for _, name := range names {
+		msg.Questions = append(msg.Questions, dnsmessage.Question{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		})
+	}
+	return msg.Pack()
+}
+
+// mdnsInstance is what the records of all packets say about one service instance
+type mdnsInstance struct {
+	serviceType string
+	target      string // host name from SRV
+	port        int
+	txt         map[string]string
+	from        net.IP // sender of the PTR, used when no A record names the host
+	seenAt      time.Time
+}
+
+type mdnsRecords struct {
+	instances map[string]*mdnsInstance
+	hosts     map[string]net.IP
+}
+
+func newMDNSRecords() *mdnsRecords {
+	return &mdnsRecords{instances: map[string]*mdnsInstance{}, hosts: map[string]net.IP{}}
+}
+
+func (r *mdnsRecords) instance(name string) *mdnsInstance {
+	instance, ok := r.instances[name]
+	if !ok {
+		instance = &mdnsInstance{}
+		r.instances[name] = instance
+	}
+	return instance
+}
+
+// add keeps the records of packet and returns the service types found by the enumeration
+func (r *mdnsRecords) add(packet mdnsPacket) []string {
+	var types []string
+
+	resources := append(append(packet.msg.Answers, packet.msg.Authorities...), packet.msg.Additionals...)
+	for _, resource := range resources {
+		owner := strings.ToLower(resource.Header.Name.String())
+
+		switch body := resource.Body.(type) {
+		case *dnsmessage.PTRResource:
+			target := body.PTR.String()
+			if owner == mdnsServiceEnumeration {
+				types = append(types, strings.ToLower(target))
+				continue
+			}
+			instance := r.instance(target)
+			instance.serviceType = owner
+			instance.from = packet.from
+			instance.seenAt = packet.at
+		case *dnsmessage.SRVResource:
+			instance := r.instance(resource.Header.Name.String())
+			instance.target = strings.ToLower(body.Target.String())
+			instance.port = int(body.Port)
+		case *dnsmessage.TXTResource:
+			instance := r.instance(resource.Header.Name.String())
+			instance.txt = parseTXT(body.TXT)
+		case *dnsmessage.AResource:
+			r.hosts[owner] = net.IP(body.A[:])
+		}
+	}
+
+	return types
+}
+
+// services returns the instances a PTR announced, SRV and TXT without PTR are answers to other queries
+func (r *mdnsRecords) services() []DiscoveredService {
+	var services []DiscoveredService
+	for name, instance := range r.instances {
+		if instance.serviceType == "" {
+			continue
+		}
+
+		ip := instance.from
+		if host, ok := r.hosts[instance.target]; ok {
+			ip = host
+		}
+
+		details := map[string]string{}
+		for key, value := range instance.txt {
+			details[key] = value
+		}
+		if instance.target != "" {
+			details["host"] = strings.TrimSuffix(instance.target, ".")
+		}
+
+		services = append(services, DiscoveredService{
+			Protocol:    "mdns",
+			IP:          ip.String(),
+			Port:        instance.port,
+			ServiceType: strings.TrimSuffix(strings.TrimSuffix(instance.serviceType, "."), ".local"),
+			Name:        instanceLabel(name, instance.serviceType),
+			Details:     details,
+			SeenAt:      instance.seenAt,
+		})
+	}
+	return services
+}
+
+// instanceLabel strips the service type from "Office Printer._ipp._tcp.local."
+func instanceLabel(name, serviceType string) string {
+	if len(name) > len(serviceType) && strings.EqualFold(name[len(name)-len(serviceType):], serviceType) {
+		return strings.TrimSuffix(name[:len(name)-len(serviceType)], ".")
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+func parseTXT(entries []string) map[string]string {
+	txt := map[string]string{}
+	for _, entry := range entries {
+		key, value, _ := strings.Cut(entry, "=")
+		if key != "" {
+			txt[strings.ToLower(key)] = value
+		}
+	}
+	return txt
+}
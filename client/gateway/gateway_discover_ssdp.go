@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"shared/core"
+	"strconv"
+	"time"
+)
+
+type ListenSSDP = core.ActionHandler[ListenServicesReq, ListenServicesRes]
+
+var ssdpGroup = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+
+// ImplListenSSDP sends an M-SEARCH for every UPnP device and collects the replies and the NOTIFY
+// announcements heard during the window
+func ImplListenSSDP() ListenSSDP {
+	return func(ctx context.Context, req ListenServicesReq) (*ListenServicesRes, error) {
+
+		search, err := net.ListenUDP("udp4", &net.UDPAddr{})
+		if err != nil {
+			return nil, err
+		}
+		defer search.Close()
+
+		packets := make(chan ssdpPacket, 64)
+		ctx, cancel := context.WithTimeout(ctx, req.Window)
+		defer cancel()
+
+		go readSSDP(ctx, search, packets)
+
+		// port 1900 bisa dipegang service UPnP lokal, balasan M-SEARCH tetap diterima
+		if group, err := net.ListenMulticastUDP("udp4", nil, ssdpGroup); err == nil {
+			defer group.Close()
+			go readSSDP(ctx, group, packets)
+		}
+
+		// MX paling lama 5 detik agar balasan datang di dalam window
+		mx := int(req.Window / 2 / time.Second)
+		mx = max(1, min(mx, 5))
+		searchMsg := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: 239.255.255.250:1900\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: " + strconv.Itoa(mx) + "\r\n" +
+			"ST: ssdp:all\r\n\r\n"
+		// UDP bisa hilang, dikirim dua kali
+		for range 2 {
+			if _, err := search.WriteToUDP([]byte(searchMsg), ssdpGroup); err != nil {
+				return nil, err
+			}
+		}
+
+		found := map[string]DiscoveredService{}
+		for {
+			select {
+			case <-ctx.Done():
+				services := make([]DiscoveredService, 0, len(found))
+				for _, service := range found {
+					services = append(services, service)
+				}
+				return &ListenServicesRes{Services: services}, nil
+			case packet := <-packets:
+				service, ok := ssdpService(packet)
+				if !ok {
+					continue
+				}
+				found[service.ServiceType+"|"+service.Name+"|"+service.IP] = service
+			}
+		}
+	}
+}
+
+type ssdpPacket struct {
+	from   net.IP
+	header http.Header
+	notify bool
+	at     time.Time
+}
+
+func readSSDP(ctx context.Context, conn *net.UDPConn, packets chan<- ssdpPacket) {
+	buf := make([]byte, 4096)
+	for {
+		deadline, _ := ctx.Deadline()
+		conn.SetReadDeadline(deadline)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		packet := ssdpPacket{from: from.IP, at: time.Now()}
+		reader := bufio.NewReader(bytes.NewReader(buf[:n]))
+		if bytes.HasPrefix(buf[:n], []byte("HTTP/")) {
+			res, err := http.ReadResponse(reader, nil)
+			if err != nil {
+				continue
+			}
+			packet.header = res.Header
+		} else {
+			msg, err := http.ReadRequest(reader)
+			// M-SEARCH dari agent lain tidak berisi service
+			if err != nil || msg.Method != "NOTIFY" {
+				continue
+			}
+			packet.header = msg.Header
+			packet.notify = true
+		}
+
+		select {
+		case packets <- packet:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func ssdpService(packet ssdpPacket) (DiscoveredService, bool) {
+	header := packet.header
+
+	serviceType := header.Get("ST")
+	if packet.notify {
+		// perangkat yang pamit tidak dilaporkan
+		if header.Get("NTS") == "ssdp:byebye" {
+			return DiscoveredService{}, false
+		}
+		serviceType = header.Get("NT")
+	}
+	usn := header.Get("USN")
+	if serviceType == "" || usn == "" {
+		return DiscoveredService{}, false
+	}
+
+	service := DiscoveredService{
+		Protocol:    "ssdp",
+		IP:          packet.from.String(),
+		ServiceType: serviceType,
+		Name:        usn,
+		Details:     map[string]string{},
+		SeenAt:      packet.at,
+	}
+
+	if location := header.Get("LOCATION"); location != "" {
+		service.Details["location"] = location
+		if parsed, err := url.Parse(location); err == nil {
+			service.Port, _ = strconv.Atoi(parsed.Port())
+			if service.Port == 0 && parsed.Scheme == "http" {
+				service.Port = 80
+			}
+		}
+	}
+	if server := header.Get("SERVER"); server != "" {
+		service.Details["server"] = server
+	}
+
+	return service, true
+}
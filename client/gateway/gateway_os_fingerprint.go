@@ -0,0 +1,70 @@
+package gateway
+
+import (
+	"context"
+	"shared/core"
+)
+
+// OSFingerprintReq holds what the probes of a scan already observed, nothing is sent to the host again
+type OSFingerprintReq struct {
+	TTL       int // TTL of the ICMP echo reply, 0 when the host was not pinged
+	TCPWindow int // window of the SYN-ACK of an open port, 0 when unknown
+}
+
+type OSFingerprintRes struct {
+	OSGuess string // empty when neither value points anywhere
+}
+
+type OSFingerprint = core.ActionHandler[OSFingerprintReq, OSFingerprintRes]
+
+// ImplOSFingerprint guesses the OS family from the initial TTL of its IP stack, rounded up from the TTL
+// seen after the hops, and refines it with the SYN-ACK window sizes stacks ship with. It is a best
+// effort: both values are tunable and middleboxes rewrite them, so the guess only narrows the family.
+func ImplOSFingerprint() OSFingerprint {
+	return func(ctx context.Context, req OSFingerprintReq) (*OSFingerprintRes, error) {
+		return &OSFingerprintRes{OSGuess: guessOS(req.TTL, req.TCPWindow)}, nil
+	}
+}
+
+func guessOS(ttl, window int) string {
+	switch initialTTL(ttl) {
+	case 64:
+		switch window {
+		case 5840, 14600, 29200, 64240, 65160:
+			return "Linux"
+		case 65535:
+			return "macOS/BSD"
+		}
+		return "Linux/Unix"
+	case 128:
+		return "Windows"
+	case 255:
+		if window == 4128 {
+			return "Cisco IOS"
+		}
+		return "Network device/Solaris"
+	case 32:
+		return "Embedded/legacy Windows"
+	}
+
+	// tanpa TTL, hanya window yang khas satu keluarga yang dipakai
+	switch window {
+	case 8192:
+		return "Windows"
+	case 4128:
+		return "Cisco IOS"
+	case 29200, 65160:
+		return "Linux"
+	}
+	return ""
+}
+
+// initialTTL is the default TTL the sender most likely started from, 0 for an unset TTL
+func initialTTL(ttl int) int {
+	for _, initial := range []int{32, 64, 128, 255} {
+		if ttl > 0 && ttl <= initial {
+			return initial
+		}
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOSFingerprintGuess(t *testing.T) {
+	fingerprint := ImplOSFingerprint()
+
+	tests := []struct {
+		name   string
+		ttl    int
+		window int
+		want   string
+	}{
+		{name: "nothing observed", want: ""},
+		{name: "ttl above any initial value", ttl: 300, want: ""},
+		{name: "unknown window without ttl", window: 1024, want: ""},
+
+		{name: "ttl 32", ttl: 32, want: "Embedded/legacy Windows"},
+		{name: "ttl 33 starts from 64", ttl: 33, want: "Linux/Unix"},
+		{name: "ttl 64", ttl: 64, want: "Linux/Unix"},
+		{name: "ttl 64 linux window", ttl: 64, window: 29200, want: "Linux"},
+		{name: "ttl after hops linux window", ttl: 57, window: 64240, want: "Linux"},
+		{name: "ttl 64 bsd window", ttl: 64, window: 65535, want: "macOS/BSD"},
+		{name: "ttl 65 starts from 128", ttl: 65, want: "Windows"},
+		{name: "ttl 128", ttl: 128, window: 8192, want: "Windows"},
+		{name: "ttl 129 starts from 255", ttl: 129, want: "Network device/Solaris"},
+		{name: "ttl 255", ttl: 255, want: "Network device/Solaris"},
+		{name: "ttl 255 cisco window", ttl: 255, window: 4128, want: "Cisco IOS"},
+
+		{name: "windows window without ttl", window: 8192, want: "Windows"},
+		{name: "cisco window without ttl", window: 4128, want: "Cisco IOS"},
+		{name: "linux window without ttl", window: 65160, want: "Linux"},
+		{name: "shared window without ttl", window: 65535, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := fingerprint(context.Background(), OSFingerprintReq{TTL: tt.ttl, TCPWindow: tt.window})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.OSGuess != tt.want {
+				t.Fatalf("ttl %d window %d: got %q, want %q", tt.ttl, tt.window, res.OSGuess, tt.want)
+			}
+		})
+	}
+}
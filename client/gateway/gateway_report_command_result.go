@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"shared/core"
+	"shared/utility"
+)
+
+type ReportCommandResultReq[Res any] struct {
+	ID     string // replaces {id} in the result path of the command
+	Result Res
+}
+
+type ReportCommandResultRes struct {
+	Data json.RawMessage // data of the server response, for commands whose report returns something
+}
+
+type ReportCommandResult[Res any] = core.ActionHandler[ReportCommandResultReq[Res], ReportCommandResultRes]
+
+// ImplReportCommandResult posts the result of a command declared in shared/command to its result path
+func ImplReportCommandResult[Req, Res any](callServer CallServer, command utility.Command[Req, Res]) ReportCommandResult[Res] {
+	return func(ctx context.Context, req ReportCommandResultReq[Res]) (*ReportCommandResultRes, error) {
+
+		res, err := callServer(ctx, CallServerReq{
+			Method:  http.MethodPost,
+			Path:    command.ResultURL(req.ID),
+			Payload: req.Result,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var envelope serverResponse
+		decodeErr := json.Unmarshal(res.Body, &envelope)
+
+		if res.StatusCode != http.StatusOK {
+			if decodeErr == nil && envelope.Error != nil {
+				return nil, fmt.Errorf("report %s %s: %s", command.Event, req.ID, *envelope.Error)
+			}
+			return nil, fmt.Errorf("report %s %s: server responded with status %d", command.Event, req.ID, res.StatusCode)
+		}
+
+		return &ReportCommandResultRes{Data: envelope.Data}, nil
+	}
+}
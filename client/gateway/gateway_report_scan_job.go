@@ -0,0 +1,85 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"shared/core"
+	"time"
+)
+
+// ProbeStats summarises how long single probes took, in milliseconds
+type ProbeStats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min_ms"`
+	Avg   float64 `json:"avg_ms"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// ScanJobMetrics is the timing breakdown of one scan job on this agent
+type ScanJobMetrics struct {
+	ExpandMs          float64    `json:"expand_ms"`
+	ProbeMs           float64    `json:"probe_ms"`
+	UploadMs          float64    `json:"upload_ms"`
+	TotalMs           float64    `json:"total_ms"`
+	Probe             ProbeStats `json:"probe"`
+	WorkerUtilization []float64  `json:"worker_utilization"` // busy time / probe phase wall time, per worker
+	HostsPerSecond    float64    `json:"hosts_per_second"`
+}
+
+type ReportScanJobReq struct {
+	JobID      string
+	ClientID   string
+	Status     string // completed, failed or planned (dry run)
+	Error      string
+	TotalHosts int
+	Workers    int
+	TimeoutMs  int64
+	// EstimatedMs is the worst case scan duration, reported by dry runs
+	EstimatedMs int64
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Metrics     ScanJobMetrics
+}
+
+type ReportScanJobRes struct{}
+
+type ReportScanJob = core.ActionHandler[ReportScanJobReq, ReportScanJobRes]
+
+func ImplReportScanJob(callServer CallServer) ReportScanJob {
+	return func(ctx context.Context, req ReportScanJobReq) (*ReportScanJobRes, error) {
+
+		res, err := callServer(ctx, CallServerReq{
+			Method: http.MethodPost,
+			Path:   fmt.Sprintf("/api/scan-jobs/%s/report", req.JobID),
+			Payload: map[string]any{
+				"client_id":    req.ClientID,
+				"status":       req.Status,
+				"error":        req.Error,
+				"total_hosts":  req.TotalHosts,
+				"workers":      req.Workers,
+				"timeout_ms":   req.TimeoutMs,
+				"estimated_ms": req.EstimatedMs,
+				"started_at":   req.StartedAt,
+				"finished_at":  req.FinishedAt,
+				"metrics":      req.Metrics,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			var envelope serverResponse
+			if err := json.Unmarshal(res.Body, &envelope); err == nil && envelope.Error != nil {
+				return nil, fmt.Errorf("report scan job %s: %s", req.JobID, *envelope.Error)
+			}
+			return nil, fmt.Errorf("report scan job %s: server responded with status %d", req.JobID, res.StatusCode)
+		}
+
+		return &ReportScanJobRes{}, nil
+	}
+}
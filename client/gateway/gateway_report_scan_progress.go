@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"shared/core"
+	"time"
+)
+
+type ReportScanProgressReq struct {
+	ClientID string
+	JobID    string // kosong untuk scan terjadwal
+	IPRange  string
+	Scanned  int
+	Total    int
+	At       time.Time
+}
+
+type ReportScanProgressRes struct{}
+
+type ReportScanProgress = core.ActionHandler[ReportScanProgressReq, ReportScanProgressRes]
+
+func ImplReportScanProgress(callServer CallServer) ReportScanProgress {
+	return func(ctx context.Context, req ReportScanProgressReq) (*ReportScanProgressRes, error) {
+
+		res, err := callServer(ctx, CallServerReq{
+			Method: http.MethodPost,
+			Path:   fmt.Sprintf("/api/clients/%s/scan-progress", req.ClientID),
+			Payload: map[string]any{
+				"job_id":   req.JobID,
+				"ip_range": req.IPRange,
+				"scanned":  req.Scanned,
+				"total":    req.Total,
+				"at":       req.At,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			var envelope serverResponse
+			if err := json.Unmarshal(res.Body, &envelope); err == nil && envelope.Error != nil {
+				return nil, fmt.Errorf("report scan progress: %s", *envelope.Error)
+			}
+			return nil, fmt.Errorf("report scan progress: server responded with status %d", res.StatusCode)
+		}
+
+		return &ReportScanProgressRes{}, nil
+	}
+}
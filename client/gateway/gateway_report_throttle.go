@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"shared/core"
+	"time"
+)
+
+type ReportThrottleReq struct {
+	ClientID       string
+	Action         string // throttled, paused or resumed
+	Reason         string
+	CPUPercent     float64
+	MemoryMB       float64
+	WorkersPercent int // share of the workers allowed to probe after the action
+	At             time.Time
+}
+
+type ReportThrottleRes struct{}
+
+type ReportThrottle = core.ActionHandler[ReportThrottleReq, ReportThrottleRes]
+
+func ImplReportThrottle(callServer CallServer) ReportThrottle {
+	return func(ctx context.Context, req ReportThrottleReq) (*ReportThrottleRes, error) {
+
+		res, err := callServer(ctx, CallServerReq{
+			Method: http.MethodPost,
+			Path:   fmt.Sprintf("/api/clients/%s/throttle-events", req.ClientID),
+			Payload: map[string]any{
+				"action":          req.Action,
+				"reason":          req.Reason,
+				"cpu_percent":     req.CPUPercent,
+				"memory_mb":       req.MemoryMB,
+				"workers_percent": req.WorkersPercent,
+				"at":              req.At,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			var envelope serverResponse
+			if err := json.Unmarshal(res.Body, &envelope); err == nil && envelope.Error != nil {
+				return nil, fmt.Errorf("report throttle event: %s", *envelope.Error)
+			}
+			return nil, fmt.Errorf("report throttle event: server responded with status %d", res.StatusCode)
+		}
+
+		return &ReportThrottleRes{}, nil
+	}
+}
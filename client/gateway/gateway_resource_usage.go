@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"context"
+	"runtime/metrics"
+	"shared/core"
+	"sync"
+)
+
+type ReadResourceUsageReq struct{}
+
+type ReadResourceUsageRes struct {
+	// CPUPercent is the CPU time used since the previous read as a share of GOMAXPROCS, 0-100
+	CPUPercent float64
+	// MemoryBytes is the memory mapped by the Go runtime minus what it already returned to the OS
+	MemoryBytes uint64
+}
+
+type ReadResourceUsage = core.ActionHandler[ReadResourceUsageReq, ReadResourceUsageRes]
+
+// ImplReadResourceUsage reads the agent's own usage from runtime/metrics, portable across platforms without cgo
+func ImplReadResourceUsage() ReadResourceUsage {
+
+	samples := []metrics.Sample{
+		{Name: "/cpu/classes/total:cpu-seconds"},
+		{Name: "/cpu/classes/idle:cpu-seconds"},
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+
+	var mu sync.Mutex
+	var lastTotal, lastIdle float64
+
+	return func(ctx context.Context, req ReadResourceUsageReq) (*ReadResourceUsageRes, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		metrics.Read(samples)
+
+		total := samples[0].Value.Float64()
+		idle := samples[1].Value.Float64()
+
+		res := &ReadResourceUsageRes{
+			MemoryBytes: samples[2].Value.Uint64() - samples[3].Value.Uint64(),
+		}
+
+		// the first read has no previous sample and reports zero CPU
+		if elapsed := total - lastTotal; lastTotal > 0 && elapsed > 0 {
+			res.CPUPercent = 100 * (elapsed - (idle - lastIdle)) / elapsed
+		}
+		lastTotal, lastIdle = total, idle
+
+		return res, nil
+	}
+}
@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"shared/core"
+	"sync"
+)
+
+// ResultSource is anything that can replay results one by one without holding them all in memory
+type ResultSource interface {
+	Each(fn func(item json.RawMessage) error) error
+	Count() int
+}
+
+// ResultSpool buffers results in memory and spills them to a temp file once the buffer is full
+type ResultSpool interface {
+	ResultSource
+	Add(item any) error
+	Close() error
+}
+
+type CreateResultSpoolReq struct {
+	MaxInMemory int
+}
+
+type CreateResultSpoolRes struct {
+	Spool ResultSpool
+}
+
+type CreateResultSpool = core.ActionHandler[CreateResultSpoolReq, CreateResultSpoolRes]
+
+func ImplCreateResultSpoolOnDisk(dir string) CreateResultSpool {
+	return func(ctx context.Context, req CreateResultSpoolReq) (*CreateResultSpoolRes, error) {
+
+		if req.MaxInMemory <= 0 {
+			req.MaxInMemory = 1000
+		}
+
+		return &CreateResultSpoolRes{Spool: &fileSpool{
+			dir:         dir,
+			maxInMemory: req.MaxInMemory,
+			buffer:      make([]json.RawMessage, 0, req.MaxInMemory),
+		}}, nil
+	}
+}
+
+type fileSpool struct {
+	mu          sync.Mutex
+	dir         string
+	maxInMemory int
+	buffer      []json.RawMessage
+	file        *os.File
+	writer      *bufio.Writer
+	onDisk      int
+}
+
+func (s *fileSpool) Add(item any) error {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer = append(s.buffer, raw)
+	if len(s.buffer) >= s.maxInMemory {
+		return s.spillLocked()
+	}
+	return nil
+}
+
+// spillLocked appends the in memory buffer to the spill file as NDJSON
+func (s *fileSpool) spillLocked() error {
+	if s.file == nil {
+		file, err := os.CreateTemp(s.dir, "scan-results-*.ndjson")
+		if err != nil {
+			return fmt.Errorf("failed to create spill file: %w", err)
+		}
+		s.file = file
+		s.writer = bufio.NewWriter(file)
+	}
+
+	for _, raw := range s.buffer {
+		if _, err := s.writer.Write(raw); err != nil {
+			return err
+		}
+		if err := s.writer.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	s.onDisk += len(s.buffer)
+	s.buffer = s.buffer[:0]
+	return nil
+}
+
+func (s *fileSpool) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.onDisk + len(s.buffer)
+}
+
+// Each replays spilled results first, then the ones still in memory, in insertion order
+func (s *fileSpool) Each(fn func(item json.RawMessage) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		if err := s.writer.Flush(); err != nil {
+			return err
+		}
+
+		reader, err := os.Open(s.file.Name())
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if err := fn(append(json.RawMessage(nil), scanner.Bytes()...)); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, raw := range s.buffer {
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close removes the spill file
+func (s *fileSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer = nil
+	if s.file == nil {
+		return nil
+	}
+
+	name := s.file.Name()
+	s.file.Close()
+	s.file = nil
+	return os.Remove(name)
+}
+
+// SliceSource adapts an in memory slice to ResultSource
+func SliceSource(items any) (ResultSource, error) {
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed []json.RawMessage
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("items must be an array: %w", err)
+	}
+
+	return sliceSource(parsed), nil
+}
+
+type sliceSource []json.RawMessage
+
+func (s sliceSource) Count() int { return len(s) }
+
+func (s sliceSource) Each(fn func(item json.RawMessage) error) error {
+	for _, raw := range s {
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
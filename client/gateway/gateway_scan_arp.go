@@ -0,0 +1,180 @@
+package gateway
+
+import (
+	"client/platform"
+	"context"
+	"fmt"
+	"net"
+	"shared/core"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ScanARPReq struct {
+	IP      string
+	Timeout time.Duration
+}
+
+type ScanARP = core.ActionHandler[ScanARPReq, ScanICMPRes]
+
+// arpConfirmWait covers the kernel confirming a stale entry again, first delay_first_probe_time (5s)
+// then a unicast probe, linux defaults
+const arpConfirmWait = 7 * time.Second
+
+// ImplScanARP finds hosts on the agent's own L2 segment, including those dropping ICMP. Without raw
+// sockets the kernel does the ARP exchange: a datagram to the address makes it resolve the neighbour,
+// the result is read back from the OS ARP table. Only a confirmed entry counts as online, a stale one
+// is what a host that left the segment leaves behind until the kernel confirms it again.
+func ImplScanARP(capabilities platform.Capabilities) ScanARP {
+
+	table := &arpTableCache{maxAge: 100 * time.Millisecond}
+
+	return func(ctx context.Context, req ScanARPReq) (*ScanICMPRes, error) {
+
+		if !capabilities.ARPTable {
+			return nil, fmt.Errorf("arp discovery is not available on %s: %s", capabilities.OS, strings.Join(capabilities.Notes, "; "))
+		}
+
+		ip := net.ParseIP(req.IP).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("arp discovery only supports IPv4, got %s", req.IP)
+		}
+
+		local, err := localSegment(ip)
+		if err != nil {
+			return nil, err
+		}
+
+		result := ScanICMPRes{
+			IP:        req.IP,
+			Timestamp: time.Now(),
+			Protocol:  "ARP",
+			Status:    "Failed",
+		}
+
+		// alamat agent sendiri tidak pernah masuk tabel ARP
+		if local.own {
+			result.Status = "Online"
+			result.MAC = local.mac
+			return &result, nil
+		}
+
+		start := time.Now()
+
+		// isi datagram tidak penting, port discard cukup untuk memicu resolusi ARP
+		conn, err := net.Dial("udp4", net.JoinHostPort(ip.String(), "9"))
+		if err != nil {
+			return nil, err
+		}
+		_, err = conn.Write([]byte{0})
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		deadline := time.NewTimer(req.Timeout)
+		defer deadline.Stop()
+		extended := false
+		poll := time.NewTicker(50 * time.Millisecond)
+		defer poll.Stop()
+
+		for {
+			entries, err := table.read()
+			if err != nil {
+				return nil, err
+			}
+			if entry, ok := entries[ip.String()]; ok {
+				if entry.Confirmed() {
+					result.Status = "Online"
+					result.MAC = entry.MAC
+					result.ResponseTime = float64(time.Since(start)) / float64(time.Millisecond)
+					return &result, nil
+				}
+				// datagram tadi membuat kernel memastikan ulang entry stale, tunggu sampai selesai
+				if !extended && time.Since(start) < arpConfirmWait && req.Timeout < arpConfirmWait {
+					deadline.Reset(arpConfirmWait - time.Since(start))
+				}
+				extended = true
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-deadline.C:
+				return &result, nil
+			case <-poll.C:
+			}
+		}
+	}
+}
+
+type segment struct {
+	own bool   // ip is an address of the agent itself
+	mac string // MAC of that interface when own
+}
+
+// localSegment checks ip belongs to a directly connected subnet, ARP does not cross routers
+func localSegment(ip net.IP) (segment, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return segment{}, err
+	}
+
+	for _, netInterface := range interfaces {
+		if netInterface.Flags&net.FlagUp == 0 || netInterface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := netInterface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			network, ok := addr.(*net.IPNet)
+			if !ok || network.IP.To4() == nil || !network.Contains(ip) {
+				continue
+			}
+			return segment{
+				own: network.IP.Equal(ip),
+				mac: netInterface.HardwareAddr.String(),
+			}, nil
+		}
+	}
+
+	return segment{}, fmt.Errorf("%s is not on a directly connected subnet, arp only reaches the local segment", ip)
+}
+
+// arpTableCache shares one read of the ARP table between workers, on some systems a read runs `arp`
+type arpTableCache struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	readAt  time.Time
+	entries map[string]platform.ARPEntry // per ip, MAC sudah dinormalkan
+}
+
+func (c *arpTableCache) read() (map[string]platform.ARPEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries != nil && time.Since(c.readAt) < c.maxAge {
+		return c.entries, nil
+	}
+
+	table, err := platform.ReadARPTable()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]platform.ARPEntry, len(table))
+	for _, entry := range table {
+		// windows menulis MAC dengan tanda minus
+		if hw, err := net.ParseMAC(entry.MAC); err == nil {
+			entry.MAC = hw.String()
+			entries[entry.IP] = entry
+		}
+	}
+	c.entries = entries
+	c.readAt = time.Now()
+	return entries, nil
+}
@@ -1,8 +1,16 @@
 package gateway
 
 import (
+	"client/platform"
 	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
 	"shared/core"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	probing "github.com/prometheus-community/pro-bing"
@@ -13,20 +21,49 @@ type ScanICMPReq struct {
 	Timeout time.Duration
 }
 
+// ScanICMPRes dikirim apa adanya ke /api/scan-devices-result, nama field JSON mengikuti model ScanResult di server
 type ScanICMPRes struct {
-	IP           string
-	Timestamp    time.Time
-	Protocol     string
-	Status       string
-	ResponseTime float64
-	SNMPData     string
+	IP           string    `json:"ip"`
+	Timestamp    time.Time `json:"timestamp"`
+	Protocol     string    `json:"protocol"`
+	MAC          string    `json:"mac,omitempty"` // hanya diisi oleh scan ARP
+	Status       string    `json:"status"`
+	ResponseTime float64   `json:"response_time"`
+	SNMPData     string    `json:"snmp_data"`
+
+	// Port adalah port UDP yang menjawab, hanya diisi oleh scan UDP
+	Port int `json:"port,omitempty"`
+	// OpenPorts adalah port TCP job yang menerima koneksi, dengan bannernya jika diminta
+	OpenPorts []OpenPort `json:"open_ports,omitempty"`
+	// TTL adalah TTL balasan ICMP pertama, OSGuess tebakan OS dari TTL dan window TCP
+	TTL     int    `json:"ttl,omitempty"`
+	OSGuess string `json:"os_guess,omitempty"`
+	// ICMPMode adalah socket yang mengirim ping: privileged (raw) atau unprivileged (datagram/UDP)
+	ICMPMode string `json:"icmp_mode,omitempty"`
 }
 
 type ScanICMP = core.ActionHandler[ScanICMPReq, ScanICMPRes]
 
-func ImplScanICMP() ScanICMP {
+// ImplScanICMP picks raw or datagram ICMP sockets from the detected capabilities and fails fast when neither works.
+// IPv6 targets are pinged with ICMPv6 echo over the same kind of socket, an IPv4-mapped address as IPv4.
+// When raw sockets are refused at scan time, e.g. CAP_NET_RAW dropped after startup, the ping is retried on
+// datagram sockets and every later ping of the process goes there directly.
+func ImplScanICMP(capabilities platform.Capabilities) ScanICMP {
+
+	var rawRefused atomic.Bool
+
 	return func(ctx context.Context, req ScanICMPReq) (*ScanICMPRes, error) {
 
+		if !capabilities.ICMP() {
+			return nil, fmt.Errorf("icmp probing is not available on %s: %s", capabilities.OS, strings.Join(capabilities.Notes, "; "))
+		}
+
+		addr, err := netip.ParseAddr(req.IP)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP %s", req.IP)
+		}
+		addr = addr.Unmap()
+
 		result := ScanICMPRes{
 			IP:        req.IP,
 			Timestamp: time.Now(),
@@ -34,26 +71,44 @@ func ImplScanICMP() ScanICMP {
 			Status:    "Failed",
 		}
 
+		network := "ip4"
+		if addr.Is6() {
+			if !capabilities.ICMPv6 {
+				return nil, fmt.Errorf("icmpv6 probing is not available on %s: %s", capabilities.OS, strings.Join(capabilities.Notes, "; "))
+			}
+			network = "ip6"
+			result.Protocol = "ICMPv6"
+		}
+
 		// logger.Info("Memulai scan ICMP untuk %s", ip)
 
-		pinger, err := probing.NewPinger(req.IP)
+		privileged := capabilities.ICMPPrivileged() && !rawRefused.Load()
+		pinger, err := runPinger(addr.String(), network, req.Timeout, privileged)
+		if err != nil && privileged && isPermissionError(err) {
+			// raw socket ditolak, ping diulang lewat socket datagram yang tidak butuh hak root;
+			// diingat hanya jika datagram berhasil, selain itu error asli yang dilaporkan
+			if retry, retryErr := runPinger(addr.String(), network, req.Timeout, false); retryErr == nil {
+				rawRefused.Store(true)
+				privileged = false
+				pinger, err = retry, nil
+			}
+		}
 		if err != nil {
-			// logger.Error("Gagal membuat pinger untuk %s: %v", ip, err)
 			return nil, err
 		}
 
-		pinger.Count = 3
-		pinger.Timeout = req.Timeout
-		pinger.SetPrivileged(true)
-
-		if err = pinger.Run(); err != nil {
-			return nil, err
+		result.ICMPMode = "unprivileged"
+		if privileged {
+			result.ICMPMode = "privileged"
 		}
 
 		stats := pinger.Statistics()
 		if stats.PacketsRecv > 0 {
 			result.Status = "Online"
 			result.ResponseTime = float64(stats.AvgRtt) / float64(time.Millisecond)
+			if len(stats.TTLs) > 0 {
+				result.TTL = int(stats.TTLs[0])
+			}
 			// logger.Info("ICMP berhasil untuk %s: response time %.2f ms", ip, result.ResponseTime)
 		} else {
 			// logger.Info("ICMP gagal untuk %s: tidak ada respons", ip)
@@ -63,3 +118,26 @@ func ImplScanICMP() ScanICMP {
 
 	}
 }
+
+// runPinger pings an address literal, network is ip4 or ip6 so the pinger never resolves a name
+func runPinger(ip, network string, timeout time.Duration, privileged bool) (*probing.Pinger, error) {
+	pinger := probing.New("")
+	pinger.SetNetwork(network)
+	if err := pinger.SetAddr(ip); err != nil {
+		return nil, err
+	}
+
+	pinger.Count = 3
+	pinger.Timeout = timeout
+	pinger.SetPrivileged(privileged)
+
+	if err := pinger.Run(); err != nil {
+		return nil, err
+	}
+	return pinger, nil
+}
+
+// isPermissionError recognises a refused raw socket, EPERM on linux and EACCES on macOS and windows
+func isPermissionError(err error) bool {
+	return errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES)
+}
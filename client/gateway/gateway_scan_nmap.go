@@ -0,0 +1,216 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os/exec"
+	"shared/core"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NmapConfig tells the agent how to run nmap, the server only picks the targets and the ports
+type NmapConfig struct {
+	Path string // nmap binary, defaults to nmap from PATH
+	// Args is a text/template of the options, .Ports is the comma separated port list of the job, empty
+	// when the job has none. Targets and -oX - are appended by the gateway. Defaults to DefaultNmapArgs.
+	Args string
+}
+
+// DefaultNmapArgs finds hosts and, when the job names ports, identifies the services behind them
+const DefaultNmapArgs = `-T4 {{if .Ports}}-sV -p {{.Ports}}{{else}}-sn{{end}}`
+
+type ScanNmapReq struct {
+	Targets []string // addresses, CIDR ranges or start-end ranges, as nmap reads them
+	Ports   []int
+	// MaxRate and ScanDelay pace the scan with --max-rate and --scan-delay, zero leaves the timing of the template
+	MaxRate   int
+	ScanDelay time.Duration
+}
+
+type ScanNmapRes struct {
+	Hosts []ScanICMPRes // hosts nmap reported, an address missing from the list was down
+	Args  []string      // the command line that ran, for the job log
+}
+
+type ScanNmap = core.ActionHandler[ScanNmapReq, ScanNmapRes]
+
+// ErrNmapNotFound is returned when the agent host has no nmap binary
+var ErrNmapNotFound = errors.New("nmap is not installed on this agent")
+
+// NmapAvailable tells whether the configured nmap binary can be found, for the capability metadata
+func NmapAvailable(config NmapConfig) bool {
+	_, err := exec.LookPath(nmapPath(config))
+	return err == nil
+}
+
+// ImplScanNmap runs nmap over the targets and maps its XML report onto the scan result of the other
+// methods: the host status and MAC, the open TCP ports with the service nmap identified, and the best
+// OS match when the template asks for -O. The whole job runs in one nmap process, nmap schedules its
+// own probes.
+func ImplScanNmap(config NmapConfig) (ScanNmap, error) {
+
+	argsTemplate := config.Args
+	if argsTemplate == "" {
+		argsTemplate = DefaultNmapArgs
+	}
+	tmpl, err := template.New("nmap").Parse(argsTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nmap argument template: %w", err)
+	}
+
+	return func(ctx context.Context, req ScanNmapReq) (*ScanNmapRes, error) {
+
+		path, err := exec.LookPath(nmapPath(config))
+		if err != nil {
+			return nil, ErrNmapNotFound
+		}
+
+		ports := make([]string, len(req.Ports))
+		for i, port := range req.Ports {
+			ports[i] = strconv.Itoa(port)
+		}
+		var rendered strings.Builder
+		if err := tmpl.Execute(&rendered, struct{ Ports string }{Ports: strings.Join(ports, ",")}); err != nil {
+			return nil, fmt.Errorf("nmap argument template: %w", err)
+		}
+
+		// target dipisah dari opsi dengan --, alamat tidak pernah terbaca sebagai opsi
+		args := strings.Fields(rendered.String())
+		if req.MaxRate > 0 {
+			args = append(args, "--max-rate", strconv.Itoa(req.MaxRate))
+		}
+		if req.ScanDelay > 0 {
+			args = append(args, "--scan-delay", strconv.FormatInt(req.ScanDelay.Milliseconds(), 10)+"ms")
+		}
+		args = append(args, "-oX", "-", "--")
+		args = append(args, req.Targets...)
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("nmap failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		hosts, err := parseNmapXML(stdout.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		return &ScanNmapRes{Hosts: hosts, Args: args}, nil
+	}, nil
+}
+
+func nmapPath(config NmapConfig) string {
+	if config.Path == "" {
+		return "nmap"
+	}
+	return config.Path
+}
+
+// nmapRun is the part of the nmap XML report the agent reads
+type nmapRun struct {
+	Start int64      `xml:"start,attr"`
+	Hosts []nmapHost `xml:"host"`
+}
+
+type nmapHost struct {
+	StartTime int64 `xml:"starttime,attr"`
+	Status    struct {
+		State string `xml:"state,attr"`
+	} `xml:"status"`
+	Addresses []struct {
+		Addr     string `xml:"addr,attr"`
+		AddrType string `xml:"addrtype,attr"`
+	} `xml:"address"`
+	Ports []struct {
+		Protocol string `xml:"protocol,attr"`
+		PortID   int    `xml:"portid,attr"`
+		State    struct {
+			State string `xml:"state,attr"`
+		} `xml:"state"`
+		Service struct {
+			Name      string `xml:"name,attr"`
+			Product   string `xml:"product,attr"`
+			Version   string `xml:"version,attr"`
+			ExtraInfo string `xml:"extrainfo,attr"`
+		} `xml:"service"`
+	} `xml:"ports>port"`
+	OSMatches []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"os>osmatch"`
+	Times struct {
+		SRTT int64 `xml:"srtt,attr"` // microseconds
+	} `xml:"times"`
+}
+
+func parseNmapXML(data []byte) ([]ScanICMPRes, error) {
+	var run nmapRun
+	if err := xml.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("unreadable nmap report: %w", err)
+	}
+
+	hosts := make([]ScanICMPRes, 0, len(run.Hosts))
+	for _, host := range run.Hosts {
+		result := ScanICMPRes{
+			Protocol: "NMAP",
+			Status:   "Failed",
+		}
+
+		// waktu host dari nmap jika ada, selain itu waktu mulai scan
+		started := host.StartTime
+		if started == 0 {
+			started = run.Start
+		}
+		result.Timestamp = time.Now()
+		if started > 0 {
+			result.Timestamp = time.Unix(started, 0)
+		}
+
+		for _, address := range host.Addresses {
+			switch address.AddrType {
+			case "ipv4", "ipv6":
+				result.IP = address.Addr
+			case "mac":
+				result.MAC = address.Addr
+			}
+		}
+		if result.IP == "" {
+			continue
+		}
+
+		if host.Status.State == "up" {
+			result.Status = "Online"
+		}
+		result.ResponseTime = float64(host.Times.SRTT) / 1000
+
+		for _, port := range host.Ports {
+			if port.Protocol != "tcp" || port.State.State != "open" {
+				continue
+			}
+			banner := strings.Fields(port.Service.Product + " " + port.Service.Version + " " + port.Service.ExtraInfo)
+			result.OpenPorts = append(result.OpenPorts, OpenPort{
+				Port:    port.PortID,
+				Service: port.Service.Name,
+				Banner:  strings.Join(banner, " "),
+			})
+		}
+
+		// osmatch sudah diurutkan nmap dari yang paling cocok
+		if len(host.OSMatches) > 0 {
+			result.OSGuess = host.OSMatches[0].Name
+		}
+
+		hosts = append(hosts, result)
+	}
+	return hosts, nil
+}
@@ -0,0 +1,222 @@
+package gateway
+
+import (
+	"client/platform"
+	"context"
+	"fmt"
+	"net"
+	"shared/core"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ScanTCPPortsReq struct {
+	IP          string
+	Ports       []int
+	Timeout     time.Duration // per port, for the connect and for the banner
+	Banner      bool          // read what each open port says, or its answer to an HTTP HEAD
+	BannerBytes int           // most bytes kept per banner, defaults to 256
+	// Pace is called before every connect and blocks until the job may send another SYN, nil does not pace
+	Pace func(ctx context.Context) error
+}
+
+type ScanTCPPortsRes struct {
+	OpenPorts []OpenPort
+	TCPWindow int // SYN-ACK window of the lowest open port, 0 where the platform does not expose it
+}
+
+// OpenPort is one TCP port that accepted a connection, the JSON names follow the OpenPort model of the server
+type OpenPort struct {
+	Port    int    `json:"port"`
+	Service string `json:"service,omitempty"`
+	Banner  string `json:"banner,omitempty"`
+}
+
+type ScanTCPPorts = core.ActionHandler[ScanTCPPortsReq, ScanTCPPortsRes]
+
+// tcpPortWorkers bounds the connections opened at once to one host, many hosts are checked in parallel already
+const tcpPortWorkers = 16
+
+// ImplScanTCPPorts connects to every port of the request and, when asked, grabs a banner for basic service
+// identification: services such as SSH, SMTP and FTP greet first, the others get an HTTP HEAD, which web
+// servers answer and most other services ignore or close on.
+func ImplScanTCPPorts() ScanTCPPorts {
+	return func(ctx context.Context, req ScanTCPPortsReq) (*ScanTCPPortsRes, error) {
+
+		if net.ParseIP(req.IP) == nil {
+			return nil, fmt.Errorf("invalid IP %s", req.IP)
+		}
+		if req.Timeout <= 0 {
+			req.Timeout = time.Second
+		}
+		if req.BannerBytes <= 0 {
+			req.BannerBytes = 256
+		}
+
+		ports := make(chan int)
+		found := make(chan openPort, len(req.Ports))
+
+		var wg sync.WaitGroup
+		for i := 0; i < min(tcpPortWorkers, len(req.Ports)); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for port := range ports {
+					if open, ok := checkTCPPort(ctx, req, port); ok {
+						found <- open
+					}
+				}
+			}()
+		}
+
+	feed:
+		for _, port := range req.Ports {
+			select {
+			case ports <- port:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(ports)
+		wg.Wait()
+		close(found)
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var opened []openPort
+		for open := range found {
+			opened = append(opened, open)
+		}
+		// port selesai dicek tidak berurutan, hasil diurutkan agar mudah dibandingkan antar scan
+		sort.Slice(opened, func(i, j int) bool { return opened[i].Port < opened[j].Port })
+
+		res := ScanTCPPortsRes{}
+		for _, open := range opened {
+			res.OpenPorts = append(res.OpenPorts, open.OpenPort)
+			if res.TCPWindow == 0 {
+				res.TCPWindow = open.window
+			}
+		}
+		return &res, nil
+	}
+}
+
+// openPort keeps the SYN-ACK window next to the reported port for the OS fingerprint
+type openPort struct {
+	OpenPort
+	window int
+}
+
+// checkTCPPort is false when the port refused or did not answer in time
+func checkTCPPort(ctx context.Context, req ScanTCPPortsReq, port int) (openPort, bool) {
+	if req.Pace != nil {
+		if err := req.Pace(ctx); err != nil {
+			return openPort{}, false
+		}
+	}
+
+	dialer := net.Dialer{Timeout: req.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(req.IP, strconv.Itoa(port)))
+	if err != nil {
+		return openPort{}, false
+	}
+	defer conn.Close()
+
+	open := openPort{OpenPort: OpenPort{Port: port}}
+	// window dibaca sebelum banner, data dari peer bisa mengubahnya
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		open.window, _ = platform.PeerTCPWindow(tcpConn)
+	}
+	if !req.Banner {
+		return open, true
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	banner := readBanner(conn, req.Timeout, req.BannerBytes)
+	if banner == "" && ctx.Err() == nil {
+		// layanan yang diam menunggu client, HTTP menjawab HEAD tanpa mengirim body
+		conn.SetWriteDeadline(time.Now().Add(req.Timeout))
+		if _, err := conn.Write([]byte("HEAD / HTTP/1.0\r\n\r\n")); err == nil {
+			banner = readBanner(conn, req.Timeout, req.BannerBytes)
+		}
+	}
+	open.Banner = banner
+	open.Service = identifyService(banner)
+	return open, true
+}
+
+// readBanner returns what the peer sent within timeout up to limit bytes, as printable text
+func readBanner(conn net.Conn, timeout time.Duration, limit int) string {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+
+	buf := make([]byte, limit)
+	n := 0
+	for n < limit {
+		read, err := conn.Read(buf[n:])
+		n += read
+		if err != nil {
+			break
+		}
+		// salam layanan cukup satu baris, jawaban HTTP lengkap setelah header selesai
+		banner := string(buf[:n])
+		if strings.Contains(banner, "\r\n\r\n") || (!strings.HasPrefix(banner, "HTTP/") && strings.Contains(banner, "\n")) {
+			break
+		}
+	}
+	return printable(buf[:n])
+}
+
+// printable keeps the banner readable in a JSON payload and a table, line breaks become spaces
+func printable(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		switch {
+		case c == '\r':
+		case c == '\n' || c == '\t':
+			b.WriteByte(' ')
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('.')
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// identifyService guesses the service from the first words of its banner, empty when unknown
+func identifyService(banner string) string {
+	upper := strings.ToUpper(banner)
+	switch {
+	case banner == "":
+		return ""
+	case strings.HasPrefix(upper, "SSH-"):
+		return "ssh"
+	case strings.HasPrefix(upper, "HTTP/"):
+		return "http"
+	case strings.HasPrefix(upper, "+OK"):
+		return "pop3"
+	case strings.HasPrefix(upper, "* OK"):
+		return "imap"
+	case strings.HasPrefix(upper, "220"):
+		// FTP dan SMTP sama-sama menyapa dengan 220, biasanya salamnya menyebut protokolnya
+		if strings.Contains(upper, "SMTP") || strings.Contains(upper, "MAIL") {
+			return "smtp"
+		}
+		if strings.Contains(upper, "FTP") {
+			return "ftp"
+		}
+		return "smtp"
+	case strings.Contains(upper, "MYSQL") || strings.Contains(upper, "MARIADB"):
+		return "mysql"
+	case strings.HasPrefix(upper, "RFB "):
+		return "vnc"
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"shared/core"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type ScanUDPReq struct {
+	IP        string
+	Timeout   time.Duration
+	Community string // community of the SNMP probe, defaults to the configured one, without any SNMP is not probed
+}
+
+type ScanUDP = core.ActionHandler[ScanUDPReq, ScanICMPRes]
+
+// udpProbe is a request a service answers even from an unknown client, an empty UDP datagram is
+// silently dropped by most of them
+type udpProbe struct {
+	port          int
+	payload       func(community string) ([]byte, error)
+	needCommunity bool // guessing a community would send it to every host of the range
+}
+
+var udpProbes = []udpProbe{
+	{port: 53, payload: dnsProbe},
+	{port: 123, payload: ntpProbe},
+	{port: 161, payload: snmpProbe, needCommunity: true},
+}
+
+// ImplScanUDP finds hosts dropping ICMP and TCP by asking DNS, NTP and SNMP at once. A host is online
+// when a service answers, or when the host itself refuses a port with ICMP port unreachable; plain
+// UDP sockets are enough, no privilege is needed. SNMP is only asked with a configured community.
+func ImplScanUDP(snmp SNMPConfig) ScanUDP {
+	return func(ctx context.Context, req ScanUDPReq) (*ScanICMPRes, error) {
+
+		ip := net.ParseIP(req.IP).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("udp probing only supports IPv4, got %s", req.IP)
+		}
+
+		community := req.Community
+		if community == "" {
+			community = snmp.Community
+		}
+
+		probes := make([]udpProbe, 0, len(udpProbes))
+		for _, probe := range udpProbes {
+			if !probe.needCommunity || community != "" {
+				probes = append(probes, probe)
+			}
+		}
+
+		result := ScanICMPRes{
+			IP:        req.IP,
+			Timestamp: time.Now(),
+			Protocol:  "UDP",
+			Status:    "Failed",
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+
+		type answer struct {
+			port     int
+			refused  bool
+			duration time.Duration
+		}
+		answers := make(chan answer, len(probes))
+		errs := make(chan error, len(probes))
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for _, probe := range probes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				refused, err := sendUDPProbe(probeCtx, ip, probe, community)
+				switch {
+				case err == nil:
+					answers <- answer{port: probe.port, refused: refused, duration: time.Since(start)}
+				case !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled):
+					errs <- err
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(answers)
+		}()
+
+		// jawaban service menyebut port, penolakan hanya membuktikan host ada, jadi jawaban didahulukan
+		var refusal *answer
+		for a := range answers {
+			if !a.refused {
+				cancel()
+				result.Status = "Online"
+				result.Port = a.port
+				result.ResponseTime = float64(a.duration) / float64(time.Millisecond)
+				return &result, nil
+			}
+			if refusal == nil {
+				refusal = &a
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if refusal != nil {
+			result.Status = "Online"
+			result.ResponseTime = float64(refusal.duration) / float64(time.Millisecond)
+			return &result, nil
+		}
+
+		// semua probe gagal sebelum terkirim, mis. tidak ada route ke subnet
+		if len(errs) == len(probes) {
+			return nil, <-errs
+		}
+		return &result, nil
+	}
+}
+
+// sendUDPProbe sends one probe and waits for any datagram back, refused is true when the host
+// answered with ICMP port unreachable instead
+func sendUDPProbe(ctx context.Context, ip net.IP, probe udpProbe, community string) (refused bool, err error) {
+	payload, err := probe.payload(community)
+	if err != nil {
+		return false, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp4", net.JoinHostPort(ip.String(), strconv.Itoa(probe.port)))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// read di bawah hanya berhenti karena deadline, jadi context yang memajukannya
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+
+	if _, err := conn.Write(payload); err != nil {
+		return false, err
+	}
+
+	buf := make([]byte, 1500)
+	if _, err := conn.Read(buf); err != nil {
+		// socket UDP yang connect melaporkan ICMP port unreachable dari host sebagai read yang ditolak
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return true, nil
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return false, context.DeadlineExceeded
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// dnsProbe asks for the NS records of the root zone, resolvers and most authoritative servers answer it
+func dnsProbe(string) ([]byte, error) {
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: binary.BigEndian.Uint16(id[:]), RecursionDesired: true})
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName("."),
+		Type:  dnsmessage.TypeNS,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		return nil, err
+	}
+	return builder.Finish()
+}
+
+// ntpProbe is an NTPv3 client request, 48 bytes with only the mode and version set
+func ntpProbe(string) ([]byte, error) {
+	packet := make([]byte, 48)
+	packet[0] = 0x1b // leap 0, version 3, mode 3 (client)
+	return packet, nil
+}
+
+// snmpProbe asks for sysDescr, a wrong community gets no answer from most agents, a refusal still shows the host
+func snmpProbe(community string) ([]byte, error) {
+	requestID, err := snmpRequestID()
+	if err != nil {
+		return nil, err
+	}
+	return snmpGetRequest(1, community, requestID, oidSysDescr), nil
+}
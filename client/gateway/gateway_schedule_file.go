@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"shared/core"
+)
+
+type LoadScheduleFileReq struct{}
+
+type LoadScheduleFileRes struct {
+	Data []byte // nil when no schedule was saved
+}
+
+type LoadScheduleFile = core.ActionHandler[LoadScheduleFileReq, LoadScheduleFileRes]
+
+// ImplLoadScheduleFile reads the schedules the server pushed before a restart, an empty path disables it
+func ImplLoadScheduleFile(path string) LoadScheduleFile {
+	return func(ctx context.Context, req LoadScheduleFileReq) (*LoadScheduleFileRes, error) {
+
+		if path == "" {
+			return &LoadScheduleFileRes{}, nil
+		}
+
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return &LoadScheduleFileRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoadScheduleFileRes{Data: data}, nil
+	}
+}
+
+type SaveScheduleFileReq struct {
+	Data []byte // nil removes the file
+}
+
+type SaveScheduleFileRes struct{}
+
+type SaveScheduleFile = core.ActionHandler[SaveScheduleFileReq, SaveScheduleFileRes]
+
+// ImplSaveScheduleFile keeps the pushed schedules on disk so they run after a restart even while the server
+// is unreachable, an empty path disables it
+func ImplSaveScheduleFile(path string) SaveScheduleFile {
+	return func(ctx context.Context, req SaveScheduleFileReq) (*SaveScheduleFileRes, error) {
+
+		if path == "" {
+			return &SaveScheduleFileRes{}, nil
+		}
+
+		if req.Data == nil {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			return &SaveScheduleFileRes{}, nil
+		}
+
+		if err := writeFileAtomic(path, req.Data, 0o600); err != nil {
+			return nil, err
+		}
+
+		return &SaveScheduleFileRes{}, nil
+	}
+}
+
+// writeFileAtomic writes to a temporary file first and renames it, the old file stays whole when the
+// agent dies in the middle of the write
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,262 @@
+package gateway
+
+import (
+	"client/platform"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"shared/core"
+	"sort"
+	"sync"
+)
+
+// types of credentials kept in the secrets store
+const (
+	SecretSNMP = "snmp"
+	SecretSSH  = "ssh"
+	SecretWMI  = "wmi"
+)
+
+// Secret is one credential the agent uses against the devices it scans
+type Secret struct {
+	Type       string `json:"type"`
+	Community  string `json:"community,omitempty"` // snmp
+	Username   string `json:"username,omitempty"`  // ssh, wmi
+	Password   string `json:"password,omitempty"`
+	PrivateKey string `json:"private_key,omitempty"` // ssh, PEM
+}
+
+// ErrSecretNotFound is returned for a credential id the store does not hold
+var ErrSecretNotFound = errors.New("secret not found")
+
+const (
+	secretStoreVersion = 1
+	secretStoreInfo    = "sse-agent secrets v1"
+)
+
+// secretFile is the layout on disk, only the salt and nonce are readable without the key
+type secretFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// SecretStore keeps credentials encrypted with AES-256-GCM in one file, so commands name a
+// credential by id instead of carrying it. The key comes from material set at enrollment or,
+// without it, from the machine identity: a copied file or backup does not open on another host,
+// but anyone able to read the machine id on this host can derive the key.
+type SecretStore struct {
+	path     string
+	material []byte
+
+	mu      sync.Mutex
+	salt    []byte
+	secrets map[string]Secret
+}
+
+// OpenSecretStore reads the store at path, a missing file starts empty and is created on the first save.
+// An empty key uses the machine identity.
+func OpenSecretStore(path string, key string) (*SecretStore, error) {
+	material := []byte(key)
+	if key == "" {
+		machineID, err := platform.MachineID()
+		if err != nil {
+			return nil, fmt.Errorf("no secrets key given and the machine identity is unavailable: %w", err)
+		}
+		material = []byte(machineID)
+	}
+
+	store := &SecretStore{path: path, material: material, secrets: map[string]Secret{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		store.salt = make([]byte, 16)
+		if _, err := rand.Read(store.salt); err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file secretFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("secrets file %s is corrupt: %w", path, err)
+	}
+	if file.Version != secretStoreVersion {
+		return nil, fmt.Errorf("secrets file %s has version %d, expected %d", path, file.Version, secretStoreVersion)
+	}
+
+	aead, err := store.cipher(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, file.Nonce, file.Data, []byte(secretStoreInfo))
+	if err != nil {
+		return nil, fmt.Errorf("secrets file %s cannot be decrypted, it was written with another key or machine", path)
+	}
+	if err := json.Unmarshal(plain, &store.secrets); err != nil {
+		return nil, err
+	}
+	store.salt = file.Salt
+
+	return store, nil
+}
+
+func (s *SecretStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, s.material, salt, secretStoreInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IDs lists the stored credential ids, never their values
+func (s *SecretStore) IDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.secrets))
+	for id := range s.secrets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *SecretStore) get(id string) (Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secret, ok := s.secrets[id]
+	if !ok {
+		return Secret{}, fmt.Errorf("%w: %s", ErrSecretNotFound, id)
+	}
+	return secret, nil
+}
+
+// update changes the secrets and writes the file, the change is dropped when the write fails
+func (s *SecretStore) update(change func(secrets map[string]Secret)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets := make(map[string]Secret, len(s.secrets)+1)
+	for id, secret := range s.secrets {
+		secrets[id] = secret
+	}
+	change(secrets)
+
+	if err := s.write(secrets); err != nil {
+		return err
+	}
+	s.secrets = secrets
+	return nil
+}
+
+func (s *SecretStore) write(secrets map[string]Secret) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	aead, err := s.cipher(s.salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(secretFile{
+		Version: secretStoreVersion,
+		Salt:    s.salt,
+		Nonce:   nonce,
+		Data:    aead.Seal(nil, nonce, plain, []byte(secretStoreInfo)),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data, 0o600)
+}
+
+type GetSecretReq struct {
+	ID string
+}
+
+type GetSecretRes struct {
+	Secret Secret
+}
+
+type GetSecret = core.ActionHandler[GetSecretReq, GetSecretRes]
+
+func ImplGetSecret(store *SecretStore) GetSecret {
+	return func(ctx context.Context, req GetSecretReq) (*GetSecretRes, error) {
+
+		secret, err := store.get(req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &GetSecretRes{Secret: secret}, nil
+	}
+}
+
+type SaveSecretReq struct {
+	ID     string
+	Secret Secret
+}
+
+type SaveSecretRes struct{}
+
+type SaveSecret = core.ActionHandler[SaveSecretReq, SaveSecretRes]
+
+func ImplSaveSecret(store *SecretStore) SaveSecret {
+	return func(ctx context.Context, req SaveSecretReq) (*SaveSecretRes, error) {
+
+		if err := store.update(func(secrets map[string]Secret) { secrets[req.ID] = req.Secret }); err != nil {
+			return nil, err
+		}
+
+		return &SaveSecretRes{}, nil
+	}
+}
+
+type DeleteSecretReq struct {
+	ID string
+}
+
+type DeleteSecretRes struct {
+	Deleted bool // false when the id was not stored
+}
+
+type DeleteSecret = core.ActionHandler[DeleteSecretReq, DeleteSecretRes]
+
+func ImplDeleteSecret(store *SecretStore) DeleteSecret {
+	return func(ctx context.Context, req DeleteSecretReq) (*DeleteSecretRes, error) {
+
+		deleted := false
+		if err := store.update(func(secrets map[string]Secret) {
+			_, deleted = secrets[req.ID]
+			delete(secrets, req.ID)
+		}); err != nil {
+			return nil, err
+		}
+
+		return &DeleteSecretRes{Deleted: deleted}, nil
+	}
+}
@@ -0,0 +1,317 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"shared/core"
+	"strconv"
+	"time"
+)
+
+// OID yang dibaca untuk identifikasi perangkat, bagian dari MIB-II system group
+var (
+	oidSysDescr = []int{1, 3, 6, 1, 2, 1, 1, 1, 0}
+	oidSysName  = []int{1, 3, 6, 1, 2, 1, 1, 5, 0}
+)
+
+// SNMPConfig adalah cara agent bertanya ke perangkat, community kosong berarti SNMP hanya dipakai
+// untuk scan yang menyebut credential dari secrets store
+type SNMPConfig struct {
+	Community string
+	Version   string // "1" atau "2c", default 2c
+	Port      int    // default 161
+	Timeout   time.Duration
+	Retries   int // percobaan ulang setelah timeout, UDP bisa hilang di jalan
+}
+
+type QuerySNMPReq struct {
+	IP        string
+	Community string // overrides the configured community, e.g. a credential from the secrets store
+}
+
+type QuerySNMPRes struct {
+	SysDescr string `json:"sys_descr,omitempty"`
+	SysName  string `json:"sys_name,omitempty"`
+}
+
+type QuerySNMP = core.ActionHandler[QuerySNMPReq, QuerySNMPRes]
+
+// ImplQuerySNMP reads sysDescr and sysName with a single SNMP GET, a request without a community
+// when none is configured returns an empty result without asking the device
+func ImplQuerySNMP(config SNMPConfig) (QuerySNMP, error) {
+	var version int
+	switch config.Version {
+	case "", "2c":
+		version = 1
+	case "1":
+		version = 0
+	default:
+		return nil, fmt.Errorf("snmp version %q is not supported, use 1 or 2c", config.Version)
+	}
+	if config.Port == 0 {
+		config.Port = 161
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+
+	return func(ctx context.Context, req QuerySNMPReq) (*QuerySNMPRes, error) {
+
+		community := req.Community
+		if community == "" {
+			community = config.Community
+		}
+		if community == "" {
+			return &QuerySNMPRes{}, nil
+		}
+
+		requestID, err := snmpRequestID()
+		if err != nil {
+			return nil, err
+		}
+		packet := snmpGetRequest(version, community, requestID, oidSysDescr, oidSysName)
+
+		conn, err := (&net.Dialer{}).DialContext(ctx, "udp", net.JoinHostPort(req.IP, strconv.Itoa(config.Port)))
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		buf := make([]byte, 65535)
+		for attempt := 0; attempt <= config.Retries; attempt++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			if err := conn.SetDeadline(time.Now().Add(config.Timeout)); err != nil {
+				return nil, err
+			}
+			if _, err := conn.Write(packet); err != nil {
+				return nil, err
+			}
+
+			for {
+				n, err := conn.Read(buf)
+				if err != nil {
+					var netErr net.Error
+					if errors.As(err, &netErr) && netErr.Timeout() {
+						break // coba kirim ulang
+					}
+					return nil, err
+				}
+
+				values, err := snmpParseResponse(buf[:n], requestID)
+				if errors.Is(err, errSNMPOtherRequest) {
+					continue // jawaban terlambat untuk request sebelumnya
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				return &QuerySNMPRes{
+					SysDescr: values[oidString(oidSysDescr)],
+					SysName:  values[oidString(oidSysName)],
+				}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("snmp %s: no response after %d attempt(s)", req.IP, config.Retries+1)
+	}, nil
+}
+
+var errSNMPOtherRequest = errors.New("snmp response for another request")
+
+func snmpRequestID() (int, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	// positif dan muat di INTEGER 32 bit
+	return int(binary.BigEndian.Uint32(b[:]) & 0x7fffffff), nil
+}
+
+func snmpGetRequest(version int, community string, requestID int, oids ...[]int) []byte {
+	var varbinds []byte
+	for _, oid := range oids {
+		varbinds = append(varbinds, berTLV(0x30, append(berTLV(0x06, berOID(oid)), 0x05, 0x00))...)
+	}
+
+	pdu := append(berTLV(0x02, berInt(requestID)), berTLV(0x02, berInt(0))...) // error-status
+	pdu = append(pdu, berTLV(0x02, berInt(0))...)                              // error-index
+	pdu = append(pdu, berTLV(0x30, varbinds)...)
+
+	message := append(berTLV(0x02, berInt(version)), berTLV(0x04, []byte(community))...)
+	message = append(message, berTLV(0xa0, pdu)...) // GetRequest-PDU
+	return berTLV(0x30, message)
+}
+
+// snmpParseResponse returns the string values of a GetResponse keyed by dotted OID, missing objects are left out
+func snmpParseResponse(packet []byte, requestID int) (map[string]string, error) {
+	tag, message, _, err := berRead(packet)
+	if err != nil || tag != 0x30 {
+		return nil, fmt.Errorf("snmp response is not a message")
+	}
+
+	// version dan community dilewati, agent menjawab dengan yang kita kirim
+	for range 2 {
+		if _, _, message, err = berRead(message); err != nil {
+			return nil, err
+		}
+	}
+
+	tag, pdu, _, err := berRead(message)
+	if err != nil || tag != 0xa2 {
+		return nil, fmt.Errorf("snmp response is not a GetResponse")
+	}
+
+	fields := make([][]byte, 3)
+	for i := range fields {
+		if _, fields[i], pdu, err = berRead(pdu); err != nil {
+			return nil, err
+		}
+	}
+	if berParseInt(fields[0]) != requestID {
+		return nil, errSNMPOtherRequest
+	}
+	if status := berParseInt(fields[1]); status != 0 {
+		return nil, fmt.Errorf("snmp error status %d at index %d", status, berParseInt(fields[2]))
+	}
+
+	_, varbinds, _, err := berRead(pdu)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for len(varbinds) > 0 {
+		var varbind []byte
+		if _, varbind, varbinds, err = berRead(varbinds); err != nil {
+			return nil, err
+		}
+
+		_, oid, rest, err := berRead(varbind)
+		if err != nil {
+			return nil, err
+		}
+		valueTag, value, _, err := berRead(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		// noSuchObject, noSuchInstance dan tipe lain selain OCTET STRING dilewati
+		if valueTag == 0x04 {
+			values[oidString(berParseOID(oid))] = string(value)
+		}
+	}
+	return values, nil
+}
+
+func berTLV(tag byte, value []byte) []byte {
+	out := append([]byte{tag}, berLength(len(value))...)
+	return append(out, value...)
+}
+
+func berLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// berInt encodes a non negative integer in the fewest bytes, with a leading zero when the high bit is set
+func berInt(n int) []byte {
+	b := []byte{byte(n)}
+	for n >>= 8; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+func berParseInt(b []byte) int {
+	n := 0
+	for i, c := range b {
+		if i == 0 && c&0x80 != 0 {
+			n = -1 // bilangan negatif, two's complement
+		}
+		n = n<<8 | int(c)
+	}
+	return n
+}
+
+func berOID(oid []int) []byte {
+	b := []byte{byte(40*oid[0] + oid[1])}
+	for _, sub := range oid[2:] {
+		chunk := []byte{byte(sub & 0x7f)}
+		for sub >>= 7; sub > 0; sub >>= 7 {
+			chunk = append([]byte{byte(sub&0x7f) | 0x80}, chunk...)
+		}
+		b = append(b, chunk...)
+	}
+	return b
+}
+
+func berParseOID(b []byte) []int {
+	if len(b) == 0 {
+		return nil
+	}
+	oid := []int{int(b[0]) / 40, int(b[0]) % 40}
+	sub := 0
+	for _, c := range b[1:] {
+		sub = sub<<7 | int(c&0x7f)
+		if c&0x80 == 0 {
+			oid = append(oid, sub)
+			sub = 0
+		}
+	}
+	return oid
+}
+
+func oidString(oid []int) string {
+	s := ""
+	for i, sub := range oid {
+		if i > 0 {
+			s += "."
+		}
+		s += strconv.Itoa(sub)
+	}
+	return s
+}
+
+// berRead splits the first TLV off b
+func berRead(b []byte) (tag byte, value, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, fmt.Errorf("ber: truncated")
+	}
+	tag = b[0]
+	length := int(b[1])
+	offset := 2
+	if length&0x80 != 0 {
+		size := length & 0x7f
+		if size == 0 || size > 4 || len(b) < 2+size {
+			return 0, nil, nil, fmt.Errorf("ber: invalid length")
+		}
+		length = 0
+		for _, c := range b[2 : 2+size] {
+			length = length<<8 | int(c)
+		}
+		offset += size
+	}
+	if len(b) < offset+length {
+		return 0, nil, nil, fmt.Errorf("ber: truncated")
+	}
+	return tag, b[offset : offset+length], b[offset+length:], nil
+}
@@ -0,0 +1,229 @@
+package gateway
+
+import (
+	"client/platform"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"shared/command"
+	"shared/core"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+type TracerouteReq struct {
+	Target  string // ip or host name
+	MaxHops int
+	Probes  int           // probes per hop
+	Timeout time.Duration // per probe
+}
+
+// TracerouteHop is one TTL of the path, IP is empty when no probe of that TTL was answered
+type TracerouteHop = command.TracerouteHop
+
+type TracerouteRes struct {
+	ResolvedIP string
+	Hops       []TracerouteHop
+	// Reached is true when the target answered, false when the trace ran out of hops or was refused on the way
+	Reached bool
+}
+
+type Traceroute = core.ActionHandler[TracerouteReq, TracerouteRes]
+
+// ImplTraceroute sends ICMP echo requests with growing TTL and records the routers answering with
+// time exceeded. Only raw ICMP sockets receive those errors, datagram ICMP sockets never see them.
+func ImplTraceroute(capabilities platform.Capabilities) Traceroute {
+	return func(ctx context.Context, req TracerouteReq) (*TracerouteRes, error) {
+
+		if !capabilities.RawICMP {
+			return nil, fmt.Errorf("traceroute needs raw icmp sockets on %s: %s", capabilities.OS, strings.Join(capabilities.Notes, "; "))
+		}
+
+		target, err := resolveIPv4(ctx, req.Target)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		packetConn := conn.IPv4PacketConn()
+
+		// id acak memisahkan balasan trace ini dari trace lain yang berjalan bersamaan
+		var idBytes [2]byte
+		if _, err := rand.Read(idBytes[:]); err != nil {
+			return nil, err
+		}
+		id := int(binary.BigEndian.Uint16(idBytes[:]))
+
+		res := TracerouteRes{ResolvedIP: target.String()}
+		seq := 0
+		buf := make([]byte, 1500)
+
+		for ttl := 1; ttl <= req.MaxHops && !res.Reached; ttl++ {
+			hop := TracerouteHop{TTL: ttl, RTTMs: []float64{}}
+			refused := false
+
+			for range req.Probes {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				seq++
+
+				if err := packetConn.SetTTL(ttl); err != nil {
+					return nil, err
+				}
+				echo, err := (&icmp.Message{
+					Type: ipv4.ICMPTypeEcho,
+					Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("sse-agent-traceroute")},
+				}).Marshal(nil)
+				if err != nil {
+					return nil, err
+				}
+
+				sentAt := time.Now()
+				if _, err := conn.WriteTo(echo, &net.IPAddr{IP: target}); err != nil {
+					return nil, err
+				}
+
+				from, kind, err := readTraceReply(conn, buf, id, seq, sentAt.Add(req.Timeout))
+				if err != nil {
+					return nil, err
+				}
+				if kind == traceLost {
+					hop.Lost++
+					continue
+				}
+
+				hop.IP = from.String()
+				hop.RTTMs = append(hop.RTTMs, float64(time.Since(sentAt))/float64(time.Millisecond))
+				switch kind {
+				case traceReached:
+					res.Reached = true
+				case traceUnreachable:
+					refused = true
+				}
+			}
+
+			res.Hops = append(res.Hops, hop)
+			if refused {
+				break
+			}
+		}
+
+		lookupHostnames(ctx, res.Hops)
+
+		return &res, nil
+	}
+}
+
+type traceReplyKind int
+
+const (
+	traceLost traceReplyKind = iota
+	traceHop
+	traceReached
+	traceUnreachable
+)
+
+// readTraceReply waits for the answer to the probe id/seq, other ICMP traffic on the raw socket is skipped
+func readTraceReply(conn *icmp.PacketConn, buf []byte, id, seq int, deadline time.Time) (net.IP, traceReplyKind, error) {
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, traceLost, err
+	}
+
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil, traceLost, nil
+		}
+		if err != nil {
+			return nil, traceLost, err
+		}
+
+		msg, err := icmp.ParseMessage(1, buf[:n])
+		if err != nil {
+			continue
+		}
+		from := peer.(*net.IPAddr).IP
+
+		switch body := msg.Body.(type) {
+		case *icmp.Echo:
+			if msg.Type == ipv4.ICMPTypeEchoReply && body.ID == id && body.Seq == seq {
+				return from, traceReached, nil
+			}
+		case *icmp.TimeExceeded:
+			if quotesProbe(body.Data, id, seq) {
+				return from, traceHop, nil
+			}
+		case *icmp.DstUnreach:
+			if quotesProbe(body.Data, id, seq) {
+				return from, traceUnreachable, nil
+			}
+		}
+	}
+}
+
+// quotesProbe checks the original datagram quoted by an ICMP error is our echo: IP header then the ICMP header
+func quotesProbe(data []byte, id, seq int) bool {
+	if len(data) < ipv4.HeaderLen {
+		return false
+	}
+	headerLen := int(data[0]&0x0f) * 4
+	if len(data) < headerLen+8 {
+		return false
+	}
+	quoted := data[headerLen:]
+	return quoted[0] == byte(ipv4.ICMPTypeEcho) &&
+		int(binary.BigEndian.Uint16(quoted[4:6])) == id &&
+		int(binary.BigEndian.Uint16(quoted[6:8])) == seq
+}
+
+func resolveIPv4(ctx context.Context, target string) (net.IP, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("traceroute only supports IPv4, got %s", target)
+		}
+		return ip.To4(), nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIP(ctx, "ip4", target)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%s has no IPv4 address", target)
+	}
+	return addrs[0].To4(), nil
+}
+
+// lookupHostnames fills the reverse DNS name of every hop, a router without PTR record keeps only its IP
+func lookupHostnames(ctx context.Context, hops []TracerouteHop) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := range hops {
+		if hops[i].IP == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(hop *TracerouteHop) {
+			defer wg.Done()
+			if names, err := net.DefaultResolver.LookupAddr(ctx, hop.IP); err == nil && len(names) > 0 {
+				hop.Hostname = strings.TrimSuffix(names[0], ".")
+			}
+		}(&hops[i])
+	}
+	wg.Wait()
+}
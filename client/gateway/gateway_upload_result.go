@@ -0,0 +1,214 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"shared/core"
+	"time"
+)
+
+type UploadResultReq struct {
+	Path   string       // endpoint that finally receives the reassembled array
+	Source ResultSource // items to upload, see SliceSource for plain slices
+}
+
+type UploadResultRes struct {
+	UploadID   string
+	ChunksSent int
+}
+
+type UploadResult = core.ActionHandler[UploadResultReq, UploadResultRes]
+
+type UploadResultConfig struct {
+	ChunkItems int // number of items per chunk
+	MaxRetries int // retries per chunk before giving up
+	Backoff    time.Duration
+	Clock      core.Clock // Defaults to the real clock, waits out the backoff between retries
+}
+
+type serverResponse struct {
+	Status string          `json:"status"`
+	Error  *string         `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// ImplUploadResultChunked uploads a large result array as gzip compressed chunks. The upload id is
+// the checksum of the payload, so calling it again after a failure resumes and re-sends only missing chunks.
+func ImplUploadResultChunked(callServer CallServer, config UploadResultConfig) UploadResult {
+
+	if config.ChunkItems <= 0 {
+		config.ChunkItems = 500
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 5
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 2 * time.Second
+	}
+	if config.Clock == nil {
+		config.Clock = core.RealClock{}
+	}
+
+	call := func(ctx context.Context, req CallServerReq, out any) error {
+		res, err := callServer(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		var envelope serverResponse
+		if err := json.Unmarshal(res.Body, &envelope); err != nil {
+			return fmt.Errorf("unexpected response from %s (status %d)", req.Path, res.StatusCode)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			if envelope.Error != nil {
+				return fmt.Errorf("%s: %s", req.Path, *envelope.Error)
+			}
+			return fmt.Errorf("%s responded with status %d", req.Path, res.StatusCode)
+		}
+
+		if out != nil {
+			return json.Unmarshal(envelope.Data, out)
+		}
+		return nil
+	}
+
+	withRetry := func(ctx context.Context, f func() error) error {
+		backoff := config.Backoff
+		var err error
+		for attempt := 1; attempt <= config.MaxRetries; attempt++ {
+			if err = f(); err == nil {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-config.Clock.After(backoff):
+				backoff *= 2
+			}
+		}
+		return err
+	}
+
+	return func(ctx context.Context, req UploadResultReq) (*UploadResultRes, error) {
+
+		// first pass only keeps checksums, chunks are rebuilt on the second pass so memory stays flat
+		var checksums []string
+		payloadHash := sha256.New()
+		payloadHash.Write([]byte(req.Path))
+
+		if err := forEachChunk(req.Source, config.ChunkItems, func(index int, chunk []byte) error {
+			sum := sha256.Sum256(chunk)
+			checksums = append(checksums, hex.EncodeToString(sum[:]))
+			payloadHash.Write(chunk)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		uploadID := hex.EncodeToString(payloadHash.Sum(nil))
+		totalChunks := len(checksums)
+
+		var started struct {
+			Status         string `json:"status"`
+			ReceivedChunks []int  `json:"received_chunks"`
+		}
+
+		if err := withRetry(ctx, func() error {
+			return call(ctx, CallServerReq{
+				Method: http.MethodPost,
+				Path:   "/api/uploads",
+				Payload: map[string]any{
+					"upload_id":    uploadID,
+					"target_path":  req.Path,
+					"total_chunks": totalChunks,
+					"total_items":  req.Source.Count(),
+					"checksums":    checksums,
+				},
+			}, &started)
+		}); err != nil {
+			return nil, err
+		}
+
+		if started.Status == "completed" {
+			return &UploadResultRes{UploadID: uploadID}, nil
+		}
+
+		received := make(map[int]bool, len(started.ReceivedChunks))
+		for _, index := range started.ReceivedChunks {
+			received[index] = true
+		}
+
+		sent := 0
+		if err := forEachChunk(req.Source, config.ChunkItems, func(index int, chunk []byte) error {
+			if received[index] {
+				return nil
+			}
+
+			if err := withRetry(ctx, func() error {
+				return call(ctx, CallServerReq{
+					Method:   http.MethodPut,
+					Path:     fmt.Sprintf("/api/uploads/%s/chunks/%d", uploadID, index),
+					Payload:  json.RawMessage(chunk),
+					Compress: true,
+				}, nil)
+			}); err != nil {
+				return fmt.Errorf("failed to upload chunk %d/%d: %w", index+1, totalChunks, err)
+			}
+			sent++
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		if err := withRetry(ctx, func() error {
+			return call(ctx, CallServerReq{
+				Method: http.MethodPost,
+				Path:   fmt.Sprintf("/api/uploads/%s/complete", uploadID),
+			}, nil)
+		}); err != nil {
+			return nil, err
+		}
+
+		return &UploadResultRes{UploadID: uploadID, ChunksSent: sent}, nil
+	}
+}
+
+// forEachChunk groups items from source into JSON arrays of chunkItems, an empty source yields one empty chunk
+func forEachChunk(source ResultSource, chunkItems int, fn func(index int, chunk []byte) error) error {
+	index := 0
+	buffer := make([]json.RawMessage, 0, chunkItems)
+
+	emit := func() error {
+		chunk, err := json.Marshal(buffer)
+		if err != nil {
+			return err
+		}
+		if err := fn(index, chunk); err != nil {
+			return err
+		}
+		index++
+		buffer = buffer[:0]
+		return nil
+	}
+
+	if err := source.Each(func(item json.RawMessage) error {
+		buffer = append(buffer, item)
+		if len(buffer) == chunkItems {
+			return emit()
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(buffer) > 0 || index == 0 {
+		return emit()
+	}
+	return nil
+}
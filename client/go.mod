@@ -2,11 +2,13 @@ module client
 
 go 1.24.0
 
-require github.com/prometheus-community/pro-bing v0.6.1
+require (
+	github.com/prometheus-community/pro-bing v0.6.1
+	golang.org/x/net v0.34.0
+	golang.org/x/sys v0.29.0
+)
 
 require (
 	github.com/google/uuid v1.6.0 // indirect
-	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.29.0 // indirect
 )
@@ -2,22 +2,57 @@ package main
 
 import (
 	"bufio"
+	"client/gateway"
+	"client/platform"
+	"client/usecase"
 	"client/wiring"
-	"fmt"
+	"context"
+	"errors"
 	"log"
 	"os"
+	"os/signal"
+	"runtime"
 	"shared/utility"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// agentVersion dilaporkan saat mendaftar ke server, diisi saat build dengan -ldflags "-X main.agentVersion=1.2.3"
+var agentVersion = "dev"
+
 func main() {
 
 	// Default config
-	configServerURL := "http://localhost:8080"
+	configServerURL := ""
 	configClientID := ""
+	configServerSRV := ""
+	configPluginDir := "plugins"
+	var configSPKIPins []string
+	configSpoolDir := ""
+	configServerToken := ""
+	var configEvents []string
+	configTransport := ""
 
-	// Baca dari environment variable jika ada
+	// Baca dari environment variable jika ada, beberapa URL dipisah koma untuk failover
+	var configServerURLs []string
 	if serverURL := os.Getenv("SERVER_URL"); serverURL != "" {
-		configServerURL = serverURL
+		urls := strings.Split(serverURL, ",")
+		configServerURL = urls[0]
+		configServerURLs = urls[1:]
+	}
+
+	// Baca nama record SRV untuk discovery server jika ada
+	if srv := os.Getenv("SERVER_SRV"); srv != "" {
+		configServerSRV = srv
+	}
+
+	// localhost hanya dipakai jika server tidak dikonfigurasi sama sekali, agent yang mencari server lewat
+	// SRV tidak boleh diam-diam tersambung ke server lokal saat record SRV gagal di-resolve
+	if configServerURL == "" && configServerSRV == "" {
+		configServerURL = "http://localhost:8080"
 	}
 
 	// Baca client ID dari environment jika ada
@@ -25,31 +60,360 @@ func main() {
 		configClientID = cID
 	}
 
-	fmt.Printf("Using server URL: %s\n", configServerURL)
+	// Baca direktori plugin dari environment jika ada
+	if pluginDir := os.Getenv("PLUGIN_DIR"); pluginDir != "" {
+		configPluginDir = pluginDir
+	}
+
+	// Baca direktori spool hasil scan dari environment jika ada
+	if spoolDir := os.Getenv("SPOOL_DIR"); spoolDir != "" {
+		configSpoolDir = spoolDir
+	}
+
+	// Baca pin SPKI (dipisah koma) dari environment jika ada
+	if pins := os.Getenv("SPKI_PINS"); pins != "" {
+		configSPKIPins = strings.Split(pins, ",")
+	}
+
+	// Baca token untuk server yang dilindungi jika ada, dipakai stream SSE dan request REST
+	if token := os.Getenv("SERVER_TOKEN"); token != "" {
+		configServerToken = token
+	}
+
+	// Transport stream, websocket dipakai jika proxy di jaringan menahan atau memutus SSE
+	if transport := os.Getenv("SSE_TRANSPORT"); transport != "" {
+		configTransport = transport
+	}
+
+	// Tipe event yang diminta dari server (dipisah koma), kosong berarti semua event dikirim
+	if events := os.Getenv("AGENT_EVENTS"); events != "" {
+		configEvents = strings.Split(events, ",")
+	}
+
+	// Batas resource agent, 0 atau kosong berarti tanpa batas
+	var configResourceLimits usecase.ResourceLimits
+	if cpu, err := strconv.ParseFloat(os.Getenv("MAX_CPU_PERCENT"), 64); err == nil {
+		configResourceLimits.MaxCPUPercent = cpu
+	}
+	if memory, err := strconv.Atoi(os.Getenv("MAX_MEMORY_MB")); err == nil {
+		configResourceLimits.MaxMemoryMB = memory
+	}
+	if rate, err := strconv.Atoi(os.Getenv("MAX_PROBES_PER_SECOND")); err == nil {
+		configResourceLimits.MaxProbesPerSecond = rate
+	}
+
+	// Scan terjadwal lokal, format "<cron>|<ip range>" dipisah ";", tetap jalan walau server jarang mengirim trigger
+	var configScanSchedules []usecase.ScanSchedule
+	if schedules := os.Getenv("SCAN_SCHEDULES"); schedules != "" {
+		parsed, err := usecase.ParseScanSchedules(schedules)
+		if err != nil {
+			log.Fatalf("SCAN_SCHEDULES tidak valid: %v", err)
+		}
+		configScanSchedules = parsed
+	}
+
+	// Jadwal dari server disimpan di SCHEDULE_FILE (default schedules.json), SCHEDULE_FILE=off tidak menyimpannya
+	configScheduleFile := os.Getenv("SCHEDULE_FILE")
+	switch configScheduleFile {
+	case "":
+		configScheduleFile = "schedules.json"
+	case "off":
+		configScheduleFile = ""
+	}
+
+	// SNMP untuk identifikasi perangkat yang menjawab ping, hanya dipakai jika community diisi
+	configSNMP := gateway.SNMPConfig{
+		Community: os.Getenv("SNMP_COMMUNITY"),
+		Version:   os.Getenv("SNMP_VERSION"),
+		Retries:   1,
+	}
+	if timeoutMs, err := strconv.Atoi(os.Getenv("SNMP_TIMEOUT_MS")); err == nil {
+		configSNMP.Timeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+
+	// nmap untuk event scan_nmap, NMAP_ARGS adalah template opsi dengan {{.Ports}}, target ditambahkan agent
+	configNmap := gateway.NmapConfig{
+		Path: os.Getenv("NMAP_PATH"),
+		Args: os.Getenv("NMAP_ARGS"),
+	}
+
+	// Log agent ke stdout seperti sebelumnya, LOG_LEVEL menentukan level awal dan server bisa mengubahnya lewat set_log_level
+	configLogLevel := utility.LogInfo
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		parsed, err := utility.ParseLogLevel(level)
+		if err != nil {
+			log.Fatalf("LOG_LEVEL tidak valid: %v", err)
+		}
+		configLogLevel = parsed
+	}
+	utility.SetDefaultLogger(utility.NewLogger(log.New(os.Stdout, "", 0), configLogLevel))
+
+	// Span ditulis ke log jika diminta, trace dari server tetap diteruskan ke request balik
+	if os.Getenv("TRACE_EXPORTER") == "log" {
+		utility.SetSpanExporter(utility.LogSpanExporter{})
+	}
+
+	// Deteksi operasi yang diizinkan di OS ini, agent tetap jalan walau sebagian tidak tersedia
+	configCapabilities := platform.Detect()
+	for _, note := range configCapabilities.Notes {
+		utility.Warnf("Peringatan platform: %s", note)
+	}
+
+	// Metadata agent yang bisa dipakai server untuk memilih target perintah
+	configMetadata := configCapabilities.Metadata()
+	configMetadata["os"] = runtime.GOOS
+	configMetadata["arch"] = runtime.GOARCH
+	configMetadata["cap_nmap"] = strconv.FormatBool(gateway.NmapAvailable(configNmap))
+	if hostname, err := os.Hostname(); err == nil {
+		configMetadata["hostname"] = hostname
+	}
+
+	// Identitas yang didaftarkan ke server lewat /api/clients/register
+	configIdentity := gateway.AgentIdentity{
+		Version:  agentVersion,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Hostname: configMetadata["hostname"],
+	}
+
+	// Kelas QoS agent (mis. satellite), server memakai write timeout, antrian dan keepalive dari kelas itu
+	if qos := os.Getenv("AGENT_QOS"); qos != "" {
+		configMetadata[utility.QoSMetaKey] = qos
+	}
+
+	// Secrets store untuk credential scan, SECRETS_KEY dari enrollment atau identitas mesin jika kosong.
+	// SECRETS_FILE=off mematikannya, perintah yang menyebut credential lalu gagal
+	var configSecrets *gateway.SecretStore
+	if secretsFile := os.Getenv("SECRETS_FILE"); secretsFile != "off" {
+		configured := secretsFile != "" || os.Getenv("SECRETS_KEY") != ""
+		if secretsFile == "" {
+			secretsFile = "secrets.json"
+		}
+		secrets, err := gateway.OpenSecretStore(secretsFile, os.Getenv("SECRETS_KEY"))
+		switch {
+		case err == nil:
+			configSecrets = secrets
+			if ids := secrets.IDs(); len(ids) > 0 {
+				utility.Infof("Secrets tersedia: %s", strings.Join(ids, ", "))
+			}
+		case configured:
+			log.Fatalf("Secrets store tidak bisa dibuka: %v", err)
+		default:
+			// agent tanpa konfigurasi secrets tetap jalan, hanya secret_set yang tidak tersedia
+			utility.Warnf("Peringatan secrets store: %v", err)
+		}
+	}
+
+	// Perintah yang sanggup dijalankan agent dan worker maksimal per scan (SCAN_WORKERS, default 10)
+	// diumumkan saat connect, server tidak mengirim job yang butuh perintah lain ke agent ini
+	configScanWorkers := 10
+	if workers, err := strconv.Atoi(os.Getenv("SCAN_WORKERS")); err == nil && workers > 0 {
+		configScanWorkers = workers
+	}
+	configCommands := []string{"udp", "tcp", "discover"}
+	if configCapabilities.ICMP() {
+		configCommands = append(configCommands, "icmp")
+	}
+	if configCapabilities.RawICMP {
+		configCommands = append(configCommands, "traceroute")
+	}
+	if configCapabilities.ARPTable {
+		configCommands = append(configCommands, "arp")
+	}
+	if configSNMP.Community != "" || configSecrets != nil {
+		configCommands = append(configCommands, "snmp")
+	}
+	if configMetadata["cap_nmap"] == "true" {
+		configCommands = append(configCommands, "nmap")
+	}
+	configMetadata[utility.CommandsMetaKey] = strings.Join(configCommands, ",")
+	configMetadata[utility.MaxConcurrencyMetaKey] = strconv.Itoa(configScanWorkers)
+
+	// Perintah restart dan shutdown dari server, AGENT_REMOTE_CONTROL=off menolaknya
+	agentControl := usecase.NewAgentControl(os.Getenv("AGENT_REMOTE_CONTROL") != "off")
+
+	// komponen dihentikan terbalik dari urutan pendaftaran: yang didaftarkan wiring berhenti sebelum SSE client
+	lifecycle := utility.NewLifecycle(5*time.Second, nil)
+
+	// Statistik agent, STATS_DIR menulis snapshot JSON lines berkala untuk analisa insiden tanpa stack monitoring.
+	// Didaftarkan pertama agar berhenti terakhir dan snapshot terakhir mencatat kondisi setelah semua komponen berhenti
+	stats := utility.NewStatsRegistry()
+	stats.Add("runtime", utility.RuntimeStats)
+	statsConfig, statsEnabled, err := utility.StatsFileConfigFromEnv("agent-stats")
+	if err != nil {
+		log.Fatalf("Konfigurasi STATS tidak valid: %v", err)
+	}
+	if statsEnabled {
+		if err := utility.StartStatsFileWriter(stats, statsConfig, lifecycle); err != nil {
+			log.Fatalf("STATS_DIR tidak bisa dipakai: %v", err)
+		}
+	}
+
+	if configServerURL != "" {
+		utility.Infof("Using server URL: %s", configServerURL)
+	}
+	if configServerSRV != "" {
+		utility.Infof("Using server SRV record: %s", configServerSRV)
+	}
 	if configClientID != "" {
-		fmt.Printf("Using client ID: %s\n", configClientID)
+		utility.Infof("Using client ID: %s", configClientID)
+	}
+
+	// event yang tertampung baru diproses setelah server mengonfirmasi koneksi dan semua handler terdaftar,
+	// koneksi dibuka sebelum wiring agar server cepat melihat agent online
+	var sseClient *utility.SSEClient
+	var readyMu sync.Mutex
+	connected, wired := false, false
+	markReady := func(connectedNow, wiredNow bool) {
+		readyMu.Lock()
+		defer readyMu.Unlock()
+		connected, wired = connected || connectedNow, wired || wiredNow
+		if connected && wired {
+			sseClient.MarkReady()
+		}
 	}
 
 	// Inisialisasi SSE client
-	sseClient := utility.NewSSEClient(utility.SSEClientConfig{
-		ServerURL: configServerURL,
-		ClientID:  configClientID,
+	sseClient, err = utility.NewSSEClient(utility.SSEClientConfig{
+		ServerURL:  configServerURL,
+		ServerURLs: configServerURLs,
+		ServerSRV:  configServerSRV,
+		ClientID:   configClientID,
+		SPKIPins:   configSPKIPins,
+		Events:     configEvents,
+		Transport:  configTransport,
+
+		BearerToken: configServerToken,
+		Metadata:    configMetadata,
+
+		// event yang datang sebelum wiring selesai ditampung dulu
+		StartupBufferSize: 100,
+
+		AutoReconnect: true,
+		OnReconnect: func(clientID string) {
+			utility.Infof("Tersambung kembali ke server dengan client ID: %s", clientID)
+		},
+		OnConnected: func(clientID string) { markReady(true, false) },
+
+		// handler yang panic tidak mematikan stream, stack trace dicetak agar penyebabnya terlihat
+		OnHandlerError: func(eventType string, err error) {
+			var panicErr utility.HandlerPanicError
+			if errors.As(err, &panicErr) {
+				utility.Errorf("Handler event %s panic: %v\n%s", eventType, panicErr.Value, panicErr.Stack)
+				return
+			}
+			utility.Errorf("Handler event %s gagal: %v", eventType, err)
+		},
+	})
+	if err != nil {
+		log.Fatalf("Konfigurasi SSE client tidak valid: %v", err)
+	}
+	lifecycle.Register("sse-client", func(ctx context.Context) error {
+		sseClient.Close()
+		return nil
+	})
+	stats.Add("sse", func(ctx context.Context) (any, error) {
+		return map[string]any{
+			"connected":  sseClient.IsConnected(),
+			"server_url": sseClient.ActiveServerURL(),
+			"client":     sseClient.Stats(),
+		}, nil
 	})
 
-	// gabung semua komponen
-	wiring.SetupDependency(sseClient)
+	// Mode soak test: goroutine, file descriptor, dan goroutine koneksi SSE dicek berkala untuk menemukan
+	// kebocoran saat reconnect berulang, peringatan ditulis ke log dan ringkasannya ikut snapshot STATS_DIR
+	if os.Getenv("SOAK_TEST") == "on" {
+		leakConfig := utility.LeakDetectorConfig{}
+		if interval, err := time.ParseDuration(os.Getenv("SOAK_INTERVAL")); err == nil {
+			leakConfig.Interval = interval
+		}
+		leakDetector := utility.NewLeakDetector(leakConfig)
+		leakDetector.AddGauge("sse_readers", func() int64 { return sseClient.Stats().Readers })
+		leakDetector.AddCheck("sse", sseClient.LeakCheck)
+
+		leakCtx, stopLeaks := context.WithCancel(context.Background())
+		go leakDetector.Run(leakCtx)
+		lifecycle.Register("leak-detector", func(ctx context.Context) error {
+			stopLeaks()
+			return nil
+		})
+		stats.Add("leaks", func(ctx context.Context) (any, error) {
+			report := leakDetector.Report()
+			// sampel lengkap sudah ada di snapshot sebelumnya, cukup kondisi terakhir
+			return map[string]any{
+				"baseline": report.Baseline,
+				"latest":   report.Latest,
+				"warnings": report.Warnings,
+				"failing":  report.Failing,
+			}, nil
+		})
+	}
 
-	// Mulai koneksi
+	// Mulai koneksi, perintah yang datang selama wiring ditampung StartupBufferSize
 	if err := sseClient.Connect(); err != nil {
 		log.Fatalf("Gagal terhubung ke server: %v", err)
 	}
 
-	// Menunggu input dari user untuk keluar
-	fmt.Println("Client is running. Press Enter to exit.")
-	bufio.NewReader(os.Stdin).ReadBytes('\n')
+	// gabung semua komponen
+	if err := wiring.SetupDependency(sseClient, wiring.Config{
+		PluginDir: configPluginDir,
+		SpoolDir:  configSpoolDir,
+		SPKIPins:  configSPKIPins,
+
+		ServerToken: configServerToken,
+
+		Capabilities:   configCapabilities,
+		ResourceLimits: configResourceLimits,
+		ScanSchedules:  configScanSchedules,
+		ScheduleFile:   configScheduleFile,
+		SNMP:           configSNMP,
+		Nmap:           configNmap,
+		AgentControl:   agentControl,
+		Secrets:        configSecrets,
+		ScanWorkers:    configScanWorkers,
+		Identity:       configIdentity,
+		Stats:          stats,
+		Lifecycle:      lifecycle,
+	}); err != nil {
+		log.Fatalf("Gagal menyiapkan dependency: %v", err)
+	}
+
+	// semua handler sudah terdaftar
+	markReady(false, true)
+
+	// Menunggu sinyal berhenti, input dari user atau perintah dari server untuk keluar, semuanya lewat
+	// shutdown yang sama sehingga scan dihentikan dan hasilnya dikirim dulu
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	utility.Infof("Client is running. Press Enter to exit.")
+	enter := make(chan struct{})
+	go func() {
+		// stdin dari service manager biasanya kosong, EOF bukan perintah keluar
+		if _, err := bufio.NewReader(os.Stdin).ReadBytes('\n'); err == nil {
+			close(enter)
+		}
+	}()
+
+	action := usecase.AgentShutdown
+	select {
+	case <-ctx.Done():
+	case <-enter:
+	case action = <-agentControl.Actions():
+	}
+	// sinyal kedua saat shutdown tertahan langsung menghentikan proses
+	stop()
+
+	utility.Infof("Client shutting down...")
+	if err := lifecycle.Shutdown(context.Background()); err != nil {
+		utility.Errorf("Gagal menghentikan client dengan rapi: %v", err)
+	}
 
-	// Close SSE client
-	sseClient.Close()
-	fmt.Println("Client shutting down...")
+	if action == usecase.AgentRestart {
+		utility.Infof("Client restarting...")
+		if err := platform.Restart(); err != nil {
+			log.Fatalf("Gagal restart client: %v", err)
+		}
+	}
 
 }
@@ -0,0 +1,189 @@
+package platform
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/icmp"
+)
+
+// ErrUnsupported is returned by operations this OS build cannot perform
+var ErrUnsupported = errors.New("operation not supported on this platform")
+
+// Capabilities describes which privileged operations work for this agent process,
+// detected once at startup so a missing permission is reported before the first scan
+type Capabilities struct {
+	OS               string   `json:"os"`
+	RawICMP          bool     `json:"raw_icmp"`          // raw ICMP sockets, needs root, CAP_NET_RAW or administrator
+	UnprivilegedICMP bool     `json:"unprivileged_icmp"` // datagram ICMP sockets, linux ping_group_range or macOS
+	ICMPv6           bool     `json:"icmpv6"`            // the same sockets for ICMPv6, false on hosts with IPv6 disabled
+	ARPTable         bool     `json:"arp_table"`
+	Interfaces       bool     `json:"interfaces"`
+	Notes            []string `json:"notes"` // why a capability is missing and how to enable it
+}
+
+// ICMP reports whether ICMP probes can be sent at all
+func (c Capabilities) ICMP() bool {
+	return c.RawICMP || c.UnprivilegedICMP
+}
+
+// ICMPPrivileged tells the pinger to use raw sockets, datagram sockets are only used when raw ones are not allowed
+func (c Capabilities) ICMPPrivileged() bool {
+	return c.RawICMP || !c.UnprivilegedICMP
+}
+
+// Metadata flattens the capabilities into SSE client metadata so the server can target capable agents
+func (c Capabilities) Metadata() map[string]string {
+	return map[string]string{
+		"cap_icmp":       strconv.FormatBool(c.ICMP()),
+		"cap_raw_icmp":   strconv.FormatBool(c.RawICMP),
+		"cap_icmpv6":     strconv.FormatBool(c.ICMPv6),
+		"cap_arp_table":  strconv.FormatBool(c.ARPTable),
+		"cap_interfaces": strconv.FormatBool(c.Interfaces),
+	}
+}
+
+var (
+	detectOnce   sync.Once
+	capabilities Capabilities
+)
+
+// Detect probes the capabilities of the running process, the result is cached
+func Detect() Capabilities {
+	detectOnce.Do(func() {
+		capabilities = detect()
+	})
+	return capabilities
+}
+
+func detect() Capabilities {
+	c := Capabilities{OS: runtime.GOOS}
+
+	if conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0"); err == nil {
+		conn.Close()
+		c.RawICMP = true
+	}
+
+	if unprivilegedICMPSupported {
+		if conn, err := icmp.ListenPacket("udp4", "0.0.0.0"); err == nil {
+			conn.Close()
+			c.UnprivilegedICMP = true
+		}
+	}
+
+	if !c.ICMP() {
+		c.Notes = append(c.Notes, icmpHint)
+	} else {
+		// ICMPv6 needs the same privilege as ICMP, so only the socket kind the pinger will use is tried
+		network := "udp6"
+		if c.ICMPPrivileged() {
+			network = "ip6:ipv6-icmp"
+		}
+		if conn, err := icmp.ListenPacket(network, "::"); err == nil {
+			conn.Close()
+			c.ICMPv6 = true
+		} else {
+			c.Notes = append(c.Notes, "icmpv6 unavailable: "+err.Error())
+		}
+	}
+
+	if _, err := readARPTable(); err == nil {
+		c.ARPTable = true
+	} else {
+		c.Notes = append(c.Notes, "arp table unavailable: "+err.Error())
+	}
+
+	if _, err := net.Interfaces(); err == nil {
+		c.Interfaces = true
+	} else {
+		c.Notes = append(c.Notes, "interface enumeration unavailable: "+err.Error())
+	}
+
+	return c
+}
+
+// Interface is a network interface of the agent host
+type Interface struct {
+	Name     string   `json:"name"`
+	MAC      string   `json:"mac"`
+	Addrs    []string `json:"addrs"` // CIDR notation
+	Up       bool     `json:"up"`
+	Loopback bool     `json:"loopback"`
+}
+
+// Interfaces lists the network interfaces with their addresses
+func Interfaces() ([]Interface, error) {
+	netInterfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := make([]Interface, 0, len(netInterfaces))
+	for _, netInterface := range netInterfaces {
+		item := Interface{
+			Name:     netInterface.Name,
+			MAC:      netInterface.HardwareAddr.String(),
+			Up:       netInterface.Flags&net.FlagUp != 0,
+			Loopback: netInterface.Flags&net.FlagLoopback != 0,
+		}
+
+		// an interface whose addresses cannot be read is still listed
+		if addrs, err := netInterface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				item.Addrs = append(item.Addrs, addr.String())
+			}
+		}
+
+		interfaces = append(interfaces, item)
+	}
+	return interfaces, nil
+}
+
+// ARPEntry is a resolved neighbour from the OS ARP cache
+type ARPEntry struct {
+	IP        string `json:"ip"`
+	MAC       string `json:"mac"`
+	Interface string `json:"interface"`
+	// State is the neighbour state where the OS reports it, empty where it does not
+	State string `json:"state,omitempty" enum:"reachable,stale,delay,probe,permanent,noarp"`
+}
+
+// Neighbour states of ARPEntry. Only a reachable entry was confirmed recently, a stale one may belong
+// to a host that left long ago; delay and probe are a stale entry the kernel is confirming again.
+const (
+	ARPReachable = "reachable"
+	ARPStale     = "stale"
+	ARPDelay     = "delay"
+	ARPProbe     = "probe"
+	ARPPermanent = "permanent"
+	ARPNoARP     = "noarp"
+)
+
+// Confirmed tells whether the neighbour answered recently, an entry without a known state counts as confirmed
+func (e ARPEntry) Confirmed() bool {
+	switch e.State {
+	case "", ARPReachable, ARPPermanent, ARPNoARP:
+		return true
+	}
+	return false
+}
+
+// ReadARPTable returns the OS ARP cache, ErrUnsupported where the platform has no known source
+func ReadARPTable() ([]ARPEntry, error) {
+	return readARPTable()
+}
+
+// MachineID returns an identifier stable across reboots and agent reinstalls, ErrUnsupported where
+// the platform has no known source
+func MachineID() (string, error) {
+	return machineID()
+}
+
+// PeerTCPWindow returns the receive window the peer advertised in its SYN-ACK, read before any data is
+// exchanged, ErrUnsupported where the kernel does not expose it
+func PeerTCPWindow(conn *net.TCPConn) (int, error) {
+	return peerTCPWindow(conn)
+}
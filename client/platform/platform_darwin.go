@@ -0,0 +1,57 @@
+//go:build darwin
+
+package platform
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// macOS allows datagram ICMP sockets for every user
+const unprivilegedICMPSupported = true
+
+const icmpHint = "icmp unavailable: datagram ICMP sockets were refused, check the sandbox or run as root"
+
+// readARPTable parses `arp -an`, lines look like "? (192.168.1.1) at aa:bb:cc:dd:ee:ff on en0 ifscope [ethernet]"
+func readARPTable() ([]ARPEntry, error) {
+	out, err := exec.Command("arp", "-an").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ARPEntry
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[2] != "at" || fields[3] == "(incomplete)" {
+			continue
+		}
+		entries = append(entries, ARPEntry{
+			IP:        strings.Trim(fields[1], "()"),
+			MAC:       fields[3],
+			Interface: fields[5],
+		})
+	}
+	return entries, nil
+}
+
+// machineID reads IOPlatformUUID from `ioreg -rd1 -c IOPlatformExpertDevice`
+func machineID() (string, error) {
+	out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		// "IOPlatformUUID" = "564D8E7A-..."
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.Contains(key, `"IOPlatformUUID"`) {
+			return strings.Trim(strings.TrimSpace(value), `"`), nil
+		}
+	}
+	return "", ErrUnsupported
+}
+
+func peerTCPWindow(conn *net.TCPConn) (int, error) {
+	return 0, ErrUnsupported
+}
@@ -0,0 +1,149 @@
+//go:build linux
+
+package platform
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// datagram ICMP is allowed for groups listed in net.ipv4.ping_group_range
+const unprivilegedICMPSupported = true
+
+const icmpHint = "icmp unavailable: run as root, grant CAP_NET_RAW (setcap cap_net_raw+ep) or widen net.ipv4.ping_group_range"
+
+// readARPTable dumps the IPv4 neighbours over netlink, which reports their state. /proc/net/arp is the
+// fallback, it only tells complete entries apart so their state is left empty.
+func readARPTable() ([]ARPEntry, error) {
+	if entries, err := readNeighbours(); err == nil {
+		return entries, nil
+	}
+	return readProcARP()
+}
+
+// neighbourStates names the NUD states of a resolved neighbour, incomplete and failed ones have no MAC
+var neighbourStates = map[uint16]string{
+	unix.NUD_REACHABLE: ARPReachable,
+	unix.NUD_STALE:     ARPStale,
+	unix.NUD_DELAY:     ARPDelay,
+	unix.NUD_PROBE:     ARPProbe,
+	unix.NUD_PERMANENT: ARPPermanent,
+	unix.NUD_NOARP:     ARPNoARP,
+}
+
+// readNeighbours reads the answer of RTM_GETNEIGH, an ndmsg followed by its route attributes
+func readNeighbours() ([]ARPEntry, error) {
+	data, err := syscall.NetlinkRIB(unix.RTM_GETNEIGH, unix.AF_INET)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ARPEntry
+	for _, message := range messages {
+		if message.Header.Type != unix.RTM_NEWNEIGH || len(message.Data) < unix.SizeofNdMsg {
+			continue
+		}
+		index := int(int32(binary.NativeEndian.Uint32(message.Data[4:8])))
+		state, ok := neighbourStates[binary.NativeEndian.Uint16(message.Data[8:10])]
+		if !ok {
+			continue
+		}
+
+		var ip net.IP
+		var mac net.HardwareAddr
+		attrs := message.Data[unix.SizeofNdMsg:]
+		for len(attrs) >= unix.SizeofRtAttr {
+			length := int(binary.NativeEndian.Uint16(attrs[0:2]))
+			if length < unix.SizeofRtAttr || length > len(attrs) {
+				break
+			}
+			switch binary.NativeEndian.Uint16(attrs[2:4]) {
+			case unix.NDA_DST:
+				ip = net.IP(attrs[unix.SizeofRtAttr:length])
+			case unix.NDA_LLADDR:
+				mac = net.HardwareAddr(attrs[unix.SizeofRtAttr:length])
+			}
+			attrs = attrs[min((length+unix.RTA_ALIGNTO-1)&^(unix.RTA_ALIGNTO-1), len(attrs)):]
+		}
+		if ip.To4() == nil || ip.IsUnspecified() || ip.IsMulticast() || len(mac) == 0 {
+			continue
+		}
+
+		entry := ARPEntry{IP: ip.String(), MAC: mac.String(), State: state}
+		if netInterface, err := net.InterfaceByIndex(index); err == nil {
+			entry.Interface = netInterface.Name
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readProcARP parses /proc/net/arp, incomplete entries have an all zero MAC and are skipped
+func readProcARP() ([]ARPEntry, error) {
+	file, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []ARPEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+
+	for scanner.Scan() {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+		entries = append(entries, ARPEntry{IP: fields[0], MAC: fields[3], Interface: fields[5]})
+	}
+
+	return entries, scanner.Err()
+}
+
+// machineID reads the systemd machine id, older distributions only have the dbus copy
+func machineID() (string, error) {
+	var lastErr error
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if id := strings.TrimSpace(string(data)); id != "" {
+			return id, nil
+		}
+	}
+	return "", lastErr
+}
+
+// peerTCPWindow reads tcpi_snd_wnd, it holds the unscaled window of the SYN-ACK until the peer sends again
+func peerTCPWindow(conn *net.TCPConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var info *unix.TCPInfo
+	var sockErr error
+	if err := raw.Control(func(fd uintptr) {
+		info, sockErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	}); err != nil {
+		return 0, err
+	}
+	if sockErr != nil {
+		return 0, sockErr
+	}
+	return int(info.Snd_wnd), nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin && !windows
+
+package platform
+
+import "net"
+
+const unprivilegedICMPSupported = false
+
+const icmpHint = "icmp unavailable: raw ICMP sockets were refused, run the agent as root"
+
+func readARPTable() ([]ARPEntry, error) {
+	return nil, ErrUnsupported
+}
+
+func machineID() (string, error) {
+	return "", ErrUnsupported
+}
+
+func peerTCPWindow(conn *net.TCPConn) (int, error) {
+	return 0, ErrUnsupported
+}
@@ -0,0 +1,62 @@
+//go:build windows
+
+package platform
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// Windows has no datagram ICMP sockets, probes always use raw sockets
+const unprivilegedICMPSupported = false
+
+const icmpHint = "icmp unavailable: run the agent as administrator or allow raw sockets for its account"
+
+// readARPTable parses `arp -a`, entries are grouped under "Interface: 192.168.1.10 --- 0xb" headers
+func readARPTable() ([]ARPEntry, error) {
+	out, err := exec.Command("arp", "-a").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ARPEntry
+	iface := ""
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "Interface:" {
+			iface = fields[1]
+			continue
+		}
+		if len(fields) < 3 || strings.Count(fields[1], "-") != 5 || fields[1] == "ff-ff-ff-ff-ff-ff" {
+			continue // header line or broadcast entry
+		}
+		entries = append(entries, ARPEntry{
+			IP:        fields[0],
+			MAC:       strings.ReplaceAll(fields[1], "-", ":"),
+			Interface: iface,
+		})
+	}
+	return entries, nil
+}
+
+// machineID reads MachineGuid, written by setup and kept until the OS is reinstalled
+func machineID() (string, error) {
+	out, err := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid").Output()
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		// "    MachineGuid    REG_SZ    4c4c4544-..."
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "MachineGuid" {
+			return fields[2], nil
+		}
+	}
+	return "", ErrUnsupported
+}
+
+func peerTCPWindow(conn *net.TCPConn) (int, error) {
+	return 0, ErrUnsupported
+}
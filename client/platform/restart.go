@@ -0,0 +1,18 @@
+//go:build !windows
+
+package platform
+
+import (
+	"os"
+	"syscall"
+)
+
+// Restart replaces the process with a fresh copy of the same binary, arguments and environment,
+// the process id and stdin are kept so a service manager does not notice the restart
+func Restart() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
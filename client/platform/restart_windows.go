@@ -0,0 +1,25 @@
+//go:build windows
+
+package platform
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Restart starts a fresh copy of the binary with the same arguments and exits, Windows cannot
+// replace a running process image
+func Restart() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
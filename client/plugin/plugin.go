@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"client/gateway"
+	"context"
+	"fmt"
+	"shared/utility"
+	"sync"
+	"time"
+)
+
+// Env holds the shared dependencies handed to every plugin when it is invoked
+type Env struct {
+	CallServer gateway.CallServer
+}
+
+// ScannerPlugin is a scan capability triggered by a single SSE event
+type ScannerPlugin interface {
+	Name() string
+	TriggerEvent() string
+	Handle(ctx context.Context, env Env, data []byte) error
+}
+
+// TimedPlugin is implemented by plugins that must not run longer than Timeout, a stuck run
+// is cancelled and reported instead of holding back the events behind it
+type TimedPlugin interface {
+	Timeout() time.Duration
+}
+
+// Registry keeps plugins by name and makes sure no two plugins claim the same event
+type Registry struct {
+	mu      sync.RWMutex
+	plugins []ScannerPlugin
+	names   map[string]bool
+	events  map[string]string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		names:  make(map[string]bool),
+		events: make(map[string]string),
+	}
+}
+
+// Register adds a plugin, rejecting duplicate names and trigger events
+func (r *Registry) Register(p ScannerPlugin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.Name() == "" || p.TriggerEvent() == "" {
+		return fmt.Errorf("plugin name and trigger event must not be empty")
+	}
+
+	if r.names[p.Name()] {
+		return fmt.Errorf("plugin %s already registered", p.Name())
+	}
+
+	if owner, exists := r.events[p.TriggerEvent()]; exists {
+		return fmt.Errorf("event %s already handled by plugin %s", p.TriggerEvent(), owner)
+	}
+
+	r.names[p.Name()] = true
+	r.events[p.TriggerEvent()] = p.Name()
+	r.plugins = append(r.plugins, p)
+	return nil
+}
+
+// Plugins returns registered plugins in registration order
+func (r *Registry) Plugins() []ScannerPlugin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]ScannerPlugin(nil), r.plugins...)
+}
+
+// Attach registers an event handler on the SSE client for every plugin
+func (r *Registry) Attach(sseClient *utility.SSEClient, env Env) {
+	for _, p := range r.Plugins() {
+		var options []utility.HandlerOption
+		if timed, ok := p.(TimedPlugin); ok {
+			options = append(options, utility.WithHandlerTimeout(timed.Timeout()))
+		}
+
+		// ctx carries the trace of the message so plugins can continue it
+		sseClient.AddEventContextHandler(p.TriggerEvent(), func(ctx context.Context, data []byte) error {
+			return p.Handle(ctx, env, data)
+		}, options...)
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds a plugin to the package level registry, meant to be called from init()
+// of a plugin package so it is picked up by a blank import
+func Register(p ScannerPlugin) {
+	if err := defaultRegistry.Register(p); err != nil {
+		panic(err)
+	}
+}
+
+// Registered returns plugins that registered themselves through Register
+func Registered() []ScannerPlugin {
+	return defaultRegistry.Plugins()
+}
+
+type funcPlugin struct {
+	name    string
+	event   string
+	handler func(ctx context.Context, env Env, data []byte) error
+}
+
+// NewFuncPlugin wraps a plain function as a ScannerPlugin
+func NewFuncPlugin(name, event string, handler func(ctx context.Context, env Env, data []byte) error) ScannerPlugin {
+	return funcPlugin{name: name, event: event, handler: handler}
+}
+
+// NewCommandPlugin registers a handler for a command declared in shared/command, the event name and
+// the payload type come from the declaration
+func NewCommandPlugin[Req, Res any](name string, command utility.Command[Req, Res], handler func(ctx context.Context, env Env, payload Req) error) ScannerPlugin {
+	return NewFuncPlugin(name, command.Event, func(ctx context.Context, env Env, data []byte) error {
+		payload, err := command.Decode(data)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, env, payload)
+	})
+}
+
+func (f funcPlugin) Name() string { return f.name }
+
+func (f funcPlugin) TriggerEvent() string { return f.event }
+
+func (f funcPlugin) Handle(ctx context.Context, env Env, data []byte) error {
+	return f.handler(ctx, env, data)
+}
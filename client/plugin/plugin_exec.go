@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"bytes"
+	"client/gateway"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// ExecManifest describes an external executable plugin, stored as a JSON file in the plugin directory
+type ExecManifest struct {
+	Name       string   `json:"name"`
+	Event      string   `json:"event"`
+	Command    string   `json:"command"`
+	Args       []string `json:"args"`
+	TimeoutSec int      `json:"timeout_sec"`
+	ResultPath string   `json:"result_path"` // optional server path where the result is posted
+}
+
+// ExecInput is written as JSON to the plugin's stdin
+type ExecInput struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// ExecOutput is read as JSON from the plugin's stdout
+type ExecOutput struct {
+	Error  string          `json:"error,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+type execPlugin struct {
+	manifest ExecManifest
+}
+
+func NewExecPlugin(manifest ExecManifest) ScannerPlugin {
+	return execPlugin{manifest: manifest}
+}
+
+func (e execPlugin) Name() string { return e.manifest.Name }
+
+func (e execPlugin) TriggerEvent() string { return e.manifest.Event }
+
+// Timeout bounds one run of the executable, the SSE client cancels ctx once it passes
+func (e execPlugin) Timeout() time.Duration {
+	if e.manifest.TimeoutSec <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(e.manifest.TimeoutSec) * time.Second
+}
+
+func (e execPlugin) Handle(ctx context.Context, env Env, data []byte) error {
+
+	input, err := json.Marshal(ExecInput{Event: e.manifest.Event, Data: data})
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.manifest.Command, e.manifest.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("plugin %s failed: %v: %s", e.manifest.Name, err, stderr.String())
+	}
+
+	var output ExecOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return fmt.Errorf("plugin %s returned invalid output: %v", e.manifest.Name, err)
+	}
+
+	if output.Error != "" {
+		return fmt.Errorf("plugin %s: %s", e.manifest.Name, output.Error)
+	}
+
+	if e.manifest.ResultPath == "" || len(output.Result) == 0 || env.CallServer == nil {
+		return nil
+	}
+
+	if _, err := env.CallServer(ctx, gateway.CallServerReq{
+		Method:  "POST",
+		Path:    e.manifest.ResultPath,
+		Payload: output.Result,
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LoadExecPlugins reads every *.json manifest in dir; a missing dir simply yields no plugins
+func LoadExecPlugins(dir string) ([]ScannerPlugin, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []ScannerPlugin
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var manifest ExecManifest
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return nil, fmt.Errorf("invalid plugin manifest %s: %v", file, err)
+		}
+
+		if manifest.Command == "" {
+			return nil, fmt.Errorf("plugin manifest %s has no command", file)
+		}
+
+		// relative commands are resolved against the manifest location
+		if !filepath.IsAbs(manifest.Command) && filepath.Base(manifest.Command) != manifest.Command {
+			manifest.Command = filepath.Join(dir, manifest.Command)
+		}
+
+		plugins = append(plugins, NewExecPlugin(manifest))
+	}
+
+	return plugins, nil
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"fmt"
+	"shared/command"
+	"shared/utility"
+	"sync"
+	"time"
+)
+
+const (
+	AgentRestart  = "restart"
+	AgentShutdown = "shutdown"
+)
+
+// jeda sebelum agent berhenti agar ack perintah sempat terkirim ke server
+const agentControlGrace = time.Second
+
+// AgentCommand adalah payload event agent_restart dan agent_shutdown
+type AgentCommand = command.AgentControlRequest
+
+// AgentControl meneruskan perintah restart dan shutdown dari server ke main, yang menghentikan
+// semua komponen lewat lifecycle. Agent yang remote control-nya dimatikan menolak perintah tanpa ack.
+type AgentControl struct {
+	enabled bool
+
+	mu        sync.Mutex
+	requested string
+	actions   chan string
+}
+
+func NewAgentControl(enabled bool) *AgentControl {
+	return &AgentControl{enabled: enabled, actions: make(chan string, 1)}
+}
+
+// Request menjadwalkan action setelah jeda ack, perintah berikutnya ditolak selama agent sedang berhenti
+func (a *AgentControl) Request(action string, command AgentCommand) error {
+	if !a.enabled {
+		return fmt.Errorf("remote control dimatikan di agent ini, %s ditolak", action)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.requested != "" {
+		return fmt.Errorf("agent sedang menjalankan %s", a.requested)
+	}
+	a.requested = action
+
+	utility.Infof("Perintah %s dari %s diterima: %s", action, command.RequestedBy, command.Reason)
+	time.AfterFunc(agentControlGrace, func() { a.actions <- action })
+	return nil
+}
+
+// Actions mengirim action yang harus dijalankan main, paling banyak satu
+func (a *AgentControl) Actions() <-chan string {
+	return a.actions
+}
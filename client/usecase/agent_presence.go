@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"errors"
+	"shared/utility"
+	"time"
+)
+
+// AgentPresence mendaftarkan agent ke server saat mulai lalu mengirim heartbeat, sehingga server bisa
+// membedakan agent yang terdaftar tapi offline dari yang belum pernah terlihat
+type AgentPresence struct {
+	registerAgent gateway.RegisterAgent
+	sendHeartbeat gateway.SendHeartbeat
+	identity      gateway.AgentIdentity
+	clientID      func() string
+}
+
+func NewAgentPresence(
+	RegisterAgent gateway.RegisterAgent,
+	SendHeartbeat gateway.SendHeartbeat,
+	identity gateway.AgentIdentity,
+	clientID func() string,
+) *AgentPresence {
+	return &AgentPresence{
+		registerAgent: RegisterAgent,
+		sendHeartbeat: SendHeartbeat,
+		identity:      identity,
+		clientID:      clientID,
+	}
+}
+
+// Run mendaftar dan mengirim heartbeat sampai ctx selesai. Interval mengikuti jawaban server, fallback
+// dipakai sebelum server menjawab. Pendaftaran yang gagal diulang pada interval berikutnya.
+func (p *AgentPresence) Run(ctx context.Context, fallback time.Duration) {
+	if fallback <= 0 {
+		fallback = 30 * time.Second
+	}
+
+	interval := fallback
+	registered := false
+
+	for {
+		identity := p.identity
+		identity.ClientID = p.clientID()
+
+		var res *gateway.AgentPresenceRes
+		var err error
+		if registered {
+			res, err = p.sendHeartbeat(ctx, gateway.AgentPresenceReq{Identity: identity})
+			if errors.Is(err, gateway.ErrAgentNotRegistered) {
+				utility.Warnf("Server tidak mengenal agent, mendaftar ulang")
+				registered = false
+				res, err = p.registerAgent(ctx, gateway.AgentPresenceReq{Identity: identity})
+				registered = err == nil
+			}
+		} else {
+			res, err = p.registerAgent(ctx, gateway.AgentPresenceReq{Identity: identity})
+			registered = err == nil
+			if registered {
+				utility.Infof("Agent %s terdaftar ke server", identity.ClientID)
+			}
+		}
+
+		if err != nil && ctx.Err() == nil {
+			utility.Warnf("Presence agent gagal: %v", err)
+		}
+		if res != nil && res.HeartbeatInterval > 0 {
+			interval = res.HeartbeatInterval
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
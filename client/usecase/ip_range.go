@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"shared/utility"
+	"strings"
+)
+
+// maxExpandedHosts membatasi jumlah alamat satu job, sebuah /64 IPv6 tidak mungkin discan satu per satu
+const maxExpandedHosts = 1 << 24
+
+// expandIPRange memperluas daftar range dipisah koma menjadi list IP. Setiap bagian boleh IP tunggal,
+// CIDR, atau range start-end, IPv4 dan IPv6 boleh dicampur, mis. "10.0.0.0/30, 2001:db8::1-2001:db8::4, 10.0.1.7"
+func expandIPRange(ipRange string) ([]string, error) {
+	var ipList []string
+
+	for _, part := range strings.Split(ipRange, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last, err := parseRangeBounds(part)
+		if err != nil {
+			return nil, err
+		}
+
+		size := rangeSize(first, last)
+		if !size.IsInt64() || int64(len(ipList))+size.Int64() > maxExpandedHosts {
+			return nil, fmt.Errorf("range %s terlalu besar, maksimal %d IP per job", part, maxExpandedHosts)
+		}
+		utility.Debugf("Memperluas %s menjadi %s IP", part, size)
+
+		for addr := first; ; addr = addr.Next() {
+			ipList = append(ipList, addr.String())
+			if addr == last {
+				break
+			}
+		}
+	}
+
+	if len(ipList) == 0 {
+		return nil, fmt.Errorf("format IP tidak didukung: %s", ipRange)
+	}
+	return ipList, nil
+}
+
+// parseRangeBounds mengembalikan alamat pertama dan terakhir dari IP tunggal, CIDR atau range start-end
+func parseRangeBounds(part string) (netip.Addr, netip.Addr, error) {
+	// CIDR mencakup semua alamatnya, termasuk alamat network dan broadcast
+	if prefix, err := netip.ParsePrefix(part); err == nil {
+		prefix = prefix.Masked()
+		first := prefix.Addr()
+		last := first
+		hostBits := first.BitLen() - prefix.Bits()
+		bytes := first.AsSlice()
+		for i := len(bytes) - 1; i >= 0 && hostBits > 0; i-- {
+			bits := min(hostBits, 8)
+			bytes[i] |= byte(1<<bits - 1)
+			hostBits -= bits
+		}
+		last, _ = netip.AddrFromSlice(bytes)
+		return first, last, nil
+	}
+
+	if addr, err := netip.ParseAddr(part); err == nil {
+		return addr, addr, nil
+	}
+
+	// alamat IPv6 tidak pernah mengandung "-", jadi pemisah start-end tidak ambigu
+	if start, end, found := strings.Cut(part, "-"); found {
+		first, errStart := netip.ParseAddr(strings.TrimSpace(start))
+		last, errEnd := netip.ParseAddr(strings.TrimSpace(end))
+		if errStart != nil || errEnd != nil {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("format range IP tidak valid: %s", part)
+		}
+		if first.Is4() != last.Is4() {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("range %s mencampur IPv4 dan IPv6", part)
+		}
+		if last.Less(first) {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("IP akhir harus lebih besar dari IP awal")
+		}
+		return first, last, nil
+	}
+
+	return netip.Addr{}, netip.Addr{}, fmt.Errorf("format IP tidak didukung: %s", part)
+}
+
+// rangeSize menghitung jumlah alamat first sampai last, big.Int karena range IPv6 melebihi uint64
+func rangeSize(first, last netip.Addr) *big.Int {
+	size := new(big.Int).Sub(new(big.Int).SetBytes(last.AsSlice()), new(big.Int).SetBytes(first.AsSlice()))
+	return size.Add(size, big.NewInt(1))
+}
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"fmt"
+	"runtime/debug"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"sync"
+	"time"
+)
+
+// ResourceLimits adalah batas pemakaian resource agent, nilai 0 berarti tanpa batas
+type ResourceLimits = command.ResourceLimits
+
+// persentase worker minimum saat CPU di atas batas, satu worker per job tetap jalan
+const minWorkersPercent = 10
+
+// ResourceGuard memantau CPU dan memory agent sendiri lalu mengurangi worker atau menghentikan
+// sementara scan saat batas terlewati, setiap perubahan dilaporkan ke server
+type ResourceGuard struct {
+	readResourceUsage gateway.ReadResourceUsage
+	reportThrottle    gateway.ReportThrottle
+	clientID          func() string
+	clock             core.Clock
+
+	mu             sync.Mutex
+	limits         ResourceLimits
+	workersPercent int
+	paused         bool
+	changed        chan struct{} // ditutup setiap kali worker boleh jalan lagi
+	nextProbe      time.Time
+}
+
+func NewResourceGuard(
+	ReadResourceUsage gateway.ReadResourceUsage,
+	ReportThrottle gateway.ReportThrottle,
+	clientID func() string,
+	limits ResourceLimits,
+	clock core.Clock,
+) *ResourceGuard {
+	return &ResourceGuard{
+		readResourceUsage: ReadResourceUsage,
+		reportThrottle:    ReportThrottle,
+		clientID:          clientID,
+		clock:             clock,
+		limits:            limits,
+		workersPercent:    100,
+		changed:           make(chan struct{}),
+	}
+}
+
+// Run mengambil sampel pemakaian resource setiap interval sampai ctx selesai
+func (g *ResourceGuard) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	ticker := g.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			g.check(ctx)
+		}
+	}
+}
+
+// SetLimits mengganti batas, misalnya yang dikirim server, batas yang dilepas langsung membebaskan worker
+func (g *ResourceGuard) SetLimits(limits ResourceLimits) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.limits = limits
+	if limits.MaxCPUPercent <= 0 {
+		g.workersPercent = 100
+	}
+	if limits.MaxMemoryMB <= 0 {
+		g.paused = false
+	}
+	g.notify()
+}
+
+// Limits mengembalikan batas yang sedang berlaku
+func (g *ResourceGuard) Limits() ResourceLimits {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.limits
+}
+
+// Wait dipanggil worker sebelum setiap probe, menunggu selama scan dihentikan sementara,
+// nomor worker di luar jatah saat CPU tinggi, atau sampai giliran sesuai batas probe per detik
+func (g *ResourceGuard) Wait(ctx context.Context, workerID, workers int) error {
+	if g == nil {
+		return nil
+	}
+
+	for {
+		g.mu.Lock()
+		allowed := max(1, workers*g.workersPercent/100)
+		if !g.paused && workerID < allowed {
+			break
+		}
+		changed := g.changed
+		g.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+
+	// mu masih dipegang dari loop di atas
+	var delay time.Duration
+	if g.limits.MaxProbesPerSecond > 0 {
+		now := g.clock.Now()
+		if g.nextProbe.Before(now) {
+			g.nextProbe = now
+		}
+		delay = g.nextProbe.Sub(now)
+		g.nextProbe = g.nextProbe.Add(time.Second / time.Duration(g.limits.MaxProbesPerSecond))
+	}
+	g.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-g.clock.After(delay):
+		return nil
+	}
+}
+
+// notify membangunkan semua worker yang menunggu, mu harus dipegang
+func (g *ResourceGuard) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+func (g *ResourceGuard) check(ctx context.Context) {
+
+	usage, err := g.readResourceUsage(ctx, gateway.ReadResourceUsageReq{})
+	if err != nil {
+		utility.Warnf("Gagal membaca pemakaian resource: %v", err)
+		return
+	}
+	memoryMB := float64(usage.MemoryBytes) / (1 << 20)
+
+	g.mu.Lock()
+	limits := g.limits
+	var events []gateway.ReportThrottleReq
+	event := func(action, reason string) {
+		events = append(events, gateway.ReportThrottleReq{
+			Action:     action,
+			Reason:     reason,
+			CPUPercent: usage.CPUPercent,
+			MemoryMB:   memoryMB,
+			At:         g.clock.Now(),
+		})
+	}
+
+	// memory: scan dihentikan sementara dan baru lanjut setelah turun di bawah 90% batas
+	if limits.MaxMemoryMB > 0 {
+		switch {
+		case !g.paused && memoryMB > float64(limits.MaxMemoryMB):
+			g.paused = true
+			event("paused", fmt.Sprintf("memory %.0f MB melewati batas %d MB", memoryMB, limits.MaxMemoryMB))
+		case g.paused && memoryMB < 0.9*float64(limits.MaxMemoryMB):
+			g.paused = false
+			event("resumed", fmt.Sprintf("memory %.0f MB di bawah batas %d MB", memoryMB, limits.MaxMemoryMB))
+		}
+	}
+
+	// CPU: jatah worker dibagi dua setiap sampel di atas batas dan dikembalikan bertahap
+	if limits.MaxCPUPercent > 0 {
+		switch {
+		case usage.CPUPercent > limits.MaxCPUPercent && g.workersPercent > minWorkersPercent:
+			g.workersPercent = max(minWorkersPercent, g.workersPercent/2)
+			event("throttled", fmt.Sprintf("CPU %.0f%% melewati batas %.0f%%", usage.CPUPercent, limits.MaxCPUPercent))
+		case usage.CPUPercent < 0.8*limits.MaxCPUPercent && g.workersPercent < 100:
+			g.workersPercent = min(100, g.workersPercent*2)
+			if g.workersPercent == 100 {
+				event("resumed", fmt.Sprintf("CPU %.0f%% di bawah batas %.0f%%", usage.CPUPercent, limits.MaxCPUPercent))
+			}
+		}
+	}
+
+	for i := range events {
+		events[i].WorkersPercent = g.workersPercent
+	}
+	g.notify()
+	paused := g.paused
+	g.mu.Unlock()
+
+	// hasil scan yang tertahan di memory dikembalikan ke OS selama scan berhenti
+	if paused {
+		debug.FreeOSMemory()
+	}
+
+	for _, e := range events {
+		utility.Infof("Resource guard %s: %s (worker %d%%)", e.Action, e.Reason, e.WorkersPercent)
+
+		e.ClientID = g.clientID()
+		if e.ClientID == "" {
+			continue // belum tersambung, server belum mengenal agent ini
+		}
+		if _, err := g.reportThrottle(ctx, e); err != nil {
+			utility.Warnf("Gagal melaporkan throttle ke server: %v", err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shared/command"
+	"shared/utility"
+	"sync"
+)
+
+// ErrScanCancelled adalah cause context job yang dihentikan server lewat scan_cancel, membedakannya dari
+// agent yang sedang berhenti
+var ErrScanCancelled = errors.New("scan dibatalkan")
+
+// ScanCancelReq adalah payload event scan_cancel
+type ScanCancelReq = command.ScanCancelRequest
+
+// RunningScans mencatat context setiap job scan yang sedang berjalan agar scan_cancel bisa menghentikannya
+type RunningScans struct {
+	mu   sync.Mutex
+	jobs map[string]*runningScan
+}
+
+type runningScan struct {
+	cancel context.CancelCauseFunc
+}
+
+func NewRunningScans() *RunningScans {
+	return &RunningScans{jobs: map[string]*runningScan{}}
+}
+
+// Start membuat context job dari ctx, done wajib dipanggil saat job selesai. Job tanpa id, mis. scan
+// terjadwal, tidak dicatat karena server tidak bisa menyebutnya
+func (r *RunningScans) Start(ctx context.Context, jobID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if jobID == "" {
+		return ctx, func() { cancel(nil) }
+	}
+
+	scan := &runningScan{cancel: cancel}
+	r.mu.Lock()
+	r.jobs[jobID] = scan
+	r.mu.Unlock()
+
+	return ctx, func() {
+		cancel(nil)
+		r.mu.Lock()
+		// perintah yang dikirim ulang memakai id yang sama, hanya catatan milik job ini yang dihapus
+		if r.jobs[jobID] == scan {
+			delete(r.jobs, jobID)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Cancel menghentikan job, error jika job tidak berjalan di agent ini sehingga server tidak menerima ack
+func (r *RunningScans) Cancel(req ScanCancelReq) error {
+	r.mu.Lock()
+	scan, ok := r.jobs[req.JobID]
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("job %s tidak sedang berjalan di agent ini", req.JobID)
+	}
+
+	utility.Infof("Job %s dibatalkan oleh %s: %s", req.JobID, req.RequestedBy, req.Reason)
+	cause := ErrScanCancelled
+	if req.Reason != "" {
+		cause = fmt.Errorf("%w: %s", ErrScanCancelled, req.Reason)
+	}
+	scan.cancel(cause)
+	return nil
+}
+
+// failedReportStatus adalah status dan error laporan job yang gagal, cancelled jika job dihentikan scan_cancel
+func failedReportStatus(ctx context.Context, err error) (string, string) {
+	if cause := context.Cause(ctx); errors.Is(cause, ErrScanCancelled) {
+		return "cancelled", cause.Error()
+	}
+	return "failed", err.Error()
+}
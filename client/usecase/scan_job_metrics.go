@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"client/gateway"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// jumlah sampel durasi probe yang disimpan untuk menghitung persentil,
+// range besar tidak membuat memory ikut membesar
+const probeSampleSize = 10000
+
+// probeRecorder mengumpulkan durasi probe, hanya dipakai dari satu goroutine (collector)
+type probeRecorder struct {
+	count   int
+	sum     float64
+	min     float64
+	max     float64
+	samples []float64
+}
+
+func (p *probeRecorder) add(d time.Duration) {
+	ms := durationMs(d)
+
+	if p.count == 0 || ms < p.min {
+		p.min = ms
+	}
+	if ms > p.max {
+		p.max = ms
+	}
+	p.count++
+	p.sum += ms
+
+	// reservoir sampling agar sampel tetap mewakili seluruh probe
+	if len(p.samples) < probeSampleSize {
+		p.samples = append(p.samples, ms)
+		return
+	}
+	if i := rand.IntN(p.count); i < probeSampleSize {
+		p.samples[i] = ms
+	}
+}
+
+func (p *probeRecorder) stats() gateway.ProbeStats {
+	if p.count == 0 {
+		return gateway.ProbeStats{}
+	}
+
+	sorted := append([]float64(nil), p.samples...)
+	sort.Float64s(sorted)
+
+	return gateway.ProbeStats{
+		Count: p.count,
+		Min:   p.min,
+		Avg:   p.sum / float64(p.count),
+		P50:   percentile(sorted, 0.50),
+		P95:   percentile(sorted, 0.95),
+		Max:   p.max,
+	}
+}
+
+// percentile mengambil nilai persentil dari slice yang sudah terurut
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+// workerUtilization menghitung porsi waktu sibuk tiap worker terhadap durasi fase probe
+func workerUtilization(busy []time.Duration, wall time.Duration) []float64 {
+	utilization := make([]float64, len(busy))
+	if wall <= 0 {
+		return utilization
+	}
+	for i, b := range busy {
+		utilization[i] = float64(b) / float64(wall)
+	}
+	return utilization
+}
+
+// estimateScanDuration menghitung durasi terburuk: setiap worker menunggu timeout penuh untuk tiap host
+func estimateScanDuration(hosts, workers int, timeout time.Duration) time.Duration {
+	if hosts == 0 || workers <= 0 {
+		return 0
+	}
+	rounds := (hosts + workers - 1) / workers
+	return time.Duration(rounds) * timeout
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"shared/core"
+	"sync"
+	"time"
+)
+
+// scanPacer dipakai bersama semua worker satu job sehingga lajunya tetap di bawah batas berapa pun jumlah
+// workernya: token bucket untuk paket per detik, dan jeda minimal antar probe. Satu probe adalah satu
+// permintaan ke host, mis. ping 3 echo, atau satu koneksi TCP
+type scanPacer struct {
+	clock core.Clock
+
+	mu sync.Mutex
+
+	rate   float64 // token per detik, 0 jika paket tidak dibatasi
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	delay     time.Duration // 0 jika probe tidak diberi jeda
+	nextProbe time.Time
+}
+
+// newScanPacer membuat pacer dari opsi job, nil jika job tidak dibatasi
+func newScanPacer(clock core.Clock, maxPacketsPerSecond, probeDelayMs, workers int) *scanPacer {
+	if maxPacketsPerSecond <= 0 && probeDelayMs <= 0 {
+		return nil
+	}
+
+	now := clock.Now()
+	pacer := &scanPacer{clock: clock, delay: time.Duration(probeDelayMs) * time.Millisecond, last: now, nextProbe: now}
+	if maxPacketsPerSecond > 0 {
+		// bucket mulai penuh, probe pertama setiap worker tidak menunggu
+		pacer.rate = float64(maxPacketsPerSecond)
+		pacer.burst = float64(max(1, min(workers, maxPacketsPerSecond)))
+		pacer.tokens = pacer.burst
+	}
+	return pacer
+}
+
+// Wait menunggu giliran satu probe yang mengirim packets paket, atau sampai ctx selesai; pacer nil tidak menunggu
+func (p *scanPacer) Wait(ctx context.Context, packets int) error {
+	if p == nil {
+		return ctx.Err()
+	}
+
+	// giliran dipesan langsung walau harus menunggu, worker berikutnya antre di belakangnya
+	p.mu.Lock()
+	now := p.clock.Now()
+	var wait time.Duration
+	if p.delay > 0 {
+		wait = max(0, p.nextProbe.Sub(now))
+		p.nextProbe = now.Add(wait + p.delay)
+	}
+	if p.rate > 0 {
+		p.tokens = min(p.burst, p.tokens+now.Sub(p.last).Seconds()*p.rate)
+		p.last = now
+		p.tokens -= float64(packets)
+		wait = max(wait, time.Duration(-p.tokens/p.rate*float64(time.Second)))
+	}
+	p.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.clock.After(wait):
+		return nil
+	}
+}
+
+// minDuration adalah waktu minimum sejumlah probe dengan laju pacer, 0 untuk job tanpa batas
+func (p *scanPacer) minDuration(probes, packets int) time.Duration {
+	if p == nil || probes <= 0 {
+		return 0
+	}
+	var d time.Duration
+	if p.delay > 0 {
+		d = time.Duration(probes-1) * p.delay
+	}
+	if p.rate > 0 {
+		extra := max(0, float64(probes*packets)-p.burst)
+		d = max(d, time.Duration(extra/p.rate*float64(time.Second)))
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"shared/core"
+	"shared/utility"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// jarak minimum antar laporan progress, laporan terakhir selalu dikirim saat scan selesai
+const scanProgressInterval = time.Second
+
+// progressReporter mengirim "x dari y IP" ke server selama scan berjalan, worker hanya menaikkan
+// counter sehingga probe tidak pernah menunggu request ke server
+type progressReporter struct {
+	clock  core.Clock
+	report gateway.ReportScanProgress
+	req    gateway.ReportScanProgressReq
+
+	scanned atomic.Int64
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+// startProgressReporter mulai melapor setiap interval, nil jika agent belum punya client id
+func startProgressReporter(ctx context.Context, clock core.Clock, report gateway.ReportScanProgress, req ScanDevicesReq, total int) *progressReporter {
+	if report == nil || req.ClientID == "" || total == 0 {
+		return nil
+	}
+
+	p := &progressReporter{
+		clock:  clock,
+		report: report,
+		req: gateway.ReportScanProgressReq{
+			ClientID: req.ClientID,
+			JobID:    req.JobID,
+			IPRange:  req.IPRange,
+			Total:    total,
+		},
+		stop: make(chan struct{}),
+	}
+
+	p.done.Add(1)
+	go func() {
+		defer p.done.Done()
+
+		ticker := p.clock.NewTicker(scanProgressInterval)
+		defer ticker.Stop()
+
+		sent := int64(-1)
+		for {
+			select {
+			case <-p.stop:
+				// laporan akhir tetap dikirim walau scan dibatalkan
+				p.send(context.WithoutCancel(ctx), &sent)
+				return
+			case <-ticker.C():
+				p.send(ctx, &sent)
+			}
+		}
+	}()
+	return p
+}
+
+// Add mencatat satu IP selesai diprobe
+func (p *progressReporter) Add() {
+	if p == nil {
+		return
+	}
+	p.scanned.Add(1)
+}
+
+// Close mengirim laporan terakhir dan menunggu pengirimnya selesai
+func (p *progressReporter) Close() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.done.Wait()
+}
+
+func (p *progressReporter) send(ctx context.Context, sent *int64) {
+	scanned := p.scanned.Load()
+	if scanned == *sent {
+		return
+	}
+	*sent = scanned
+
+	req := p.req
+	req.Scanned = int(scanned)
+	req.At = p.clock.Now()
+	if _, err := p.report(ctx, req); err != nil {
+		utility.Warnf("Gagal mengirim progress scan: %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"encoding/json"
+	"fmt"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScanSchedule adalah scan lokal yang dijalankan agent sendiri sesuai ekspresi cron tanpa menunggu trigger
+// server, opsinya sama dengan scan_icmp, nmap tidak bisa dijadwalkan
+type ScanSchedule = command.ScanSchedule
+
+// ScanScheduleOverride dikirim server lewat event schedule_update dan menggantikan jadwal lokal,
+// Schedules nil mengembalikan agent ke jadwal dari konfigurasinya sendiri. Override disimpan di disk
+// sehingga tetap berlaku setelah restart walau server belum bisa dihubungi
+type ScanScheduleOverride = command.ScheduleUpdate
+
+type scheduledScan struct {
+	ScanSchedule
+	cron utility.CronSchedule
+	next time.Time
+}
+
+// ParseScanSchedules membaca jadwal dari konfigurasi agent, entri dipisah ";" dan tiap entri berformat
+// "<cron>|<ip range>", misalnya "*/30 * * * *|10.0.0.0/24;@daily|192.168.1.0/24"
+func ParseScanSchedules(value string) ([]ScanSchedule, error) {
+	var schedules []ScanSchedule
+
+	for i, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		cron, ipRange, ok := strings.Cut(entry, "|")
+		if !ok {
+			return nil, fmt.Errorf("jadwal %q harus berformat <cron>|<ip range>", entry)
+		}
+		schedules = append(schedules, ScanSchedule{
+			Name:    fmt.Sprintf("local-%d", i+1),
+			Cron:    strings.TrimSpace(cron),
+			IPRange: strings.TrimSpace(ipRange),
+		})
+	}
+
+	if _, err := compileScanSchedules(schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func compileScanSchedules(schedules []ScanSchedule) ([]*scheduledScan, error) {
+	compiled := make([]*scheduledScan, 0, len(schedules))
+	names := map[string]bool{}
+
+	for i, schedule := range schedules {
+		if schedule.Name == "" {
+			schedule.Name = fmt.Sprintf("schedule-%d", i+1)
+		}
+		if names[schedule.Name] {
+			return nil, fmt.Errorf("nama jadwal %s dipakai lebih dari sekali", schedule.Name)
+		}
+		names[schedule.Name] = true
+
+		if schedule.IPRange == "" {
+			return nil, fmt.Errorf("jadwal %s tidak punya ip_range", schedule.Name)
+		}
+		if _, err := expandIPRange(schedule.IPRange); err != nil {
+			return nil, fmt.Errorf("jadwal %s: %v", schedule.Name, err)
+		}
+		switch schedule.Method {
+		case "", "icmp", "arp", "udp":
+		default:
+			return nil, fmt.Errorf("jadwal %s: method %q tidak bisa dijadwalkan, gunakan icmp, arp atau udp", schedule.Name, schedule.Method)
+		}
+		for _, port := range schedule.Ports {
+			if port < 1 || port > 65535 {
+				return nil, fmt.Errorf("jadwal %s: port %d di luar jangkauan", schedule.Name, port)
+			}
+		}
+		if schedule.Workers < 0 || schedule.TimeoutMs < 0 || schedule.MaxPacketsPerSecond < 0 || schedule.ProbeDelayMs < 0 {
+			return nil, fmt.Errorf("jadwal %s: workers, timeout dan batas laju tidak boleh negatif", schedule.Name)
+		}
+		cron, err := utility.ParseCron(schedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("jadwal %s: %v", schedule.Name, err)
+		}
+		compiled = append(compiled, &scheduledScan{ScanSchedule: schedule, cron: cron})
+	}
+	return compiled, nil
+}
+
+// ScanScheduler menjalankan scan terjadwal dan mengunggah hasilnya seperti scan dari trigger server,
+// berguna untuk agent di link yang jarang menerima perintah. Jadwal dari server selalu didahulukan.
+type ScanScheduler struct {
+	scanDevices      ScanDevices
+	clientID         func() string
+	saveScheduleFile gateway.SaveScheduleFile
+	clock            core.Clock
+
+	mu       sync.Mutex
+	local    []*scheduledScan
+	override []*scheduledScan // nil berarti jadwal lokal yang berlaku
+	running  map[string]bool  // nama jadwal yang scannya belum selesai
+	changed  chan struct{}
+}
+
+// NewScanScheduler memakai jadwal lokal, kecuali override dari server yang tersimpan di disk masih ada
+func NewScanScheduler(
+	scanDevices ScanDevices,
+	clientID func() string,
+	local []ScanSchedule,
+	LoadScheduleFile gateway.LoadScheduleFile,
+	SaveScheduleFile gateway.SaveScheduleFile,
+	clock core.Clock,
+) (*ScanScheduler, error) {
+	compiled, err := compileScanSchedules(local)
+	if err != nil {
+		return nil, err
+	}
+	s := &ScanScheduler{
+		scanDevices:      scanDevices,
+		clientID:         clientID,
+		saveScheduleFile: SaveScheduleFile,
+		clock:            clock,
+		local:            compiled,
+		running:          map[string]bool{},
+		changed:          make(chan struct{}, 1),
+	}
+
+	saved, err := LoadScheduleFile(context.Background(), gateway.LoadScheduleFileReq{})
+	if err != nil {
+		return nil, err
+	}
+	if saved.Data != nil {
+		// file yang rusak tidak menghentikan agent, jadwal lokal berlaku sampai server mengirim ulang
+		var override ScanScheduleOverride
+		if err := json.Unmarshal(saved.Data, &override); err != nil {
+			utility.Warnf("Jadwal dari server yang tersimpan tidak terbaca, memakai jadwal lokal: %v", err)
+		} else if s.override, err = compileScanSchedules(override.Schedules); err != nil {
+			utility.Warnf("Jadwal dari server yang tersimpan tidak valid, memakai jadwal lokal: %v", err)
+		} else {
+			utility.Infof("Jadwal scan dari server dipulihkan: %d jadwal", len(s.override))
+		}
+	}
+	return s, nil
+}
+
+// SetOverride memasang jadwal dari server, nil kembali ke jadwal lokal. Jadwal disimpan dulu,
+// jika gagal tersimpan jadwal lama tetap berlaku dan error dikembalikan
+func (s *ScanScheduler) SetOverride(schedules []ScanSchedule) error {
+	var compiled []*scheduledScan
+	var data []byte
+	if schedules != nil {
+		var err error
+		if compiled, err = compileScanSchedules(schedules); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(ScanScheduleOverride{Schedules: schedules}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.saveScheduleFile(context.Background(), gateway.SaveScheduleFileReq{Data: data}); err != nil {
+		return fmt.Errorf("jadwal dari server gagal disimpan: %v", err)
+	}
+
+	s.mu.Lock()
+	s.override = compiled
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// active mengembalikan jadwal yang berlaku, mu harus dipegang
+func (s *ScanScheduler) active() []*scheduledScan {
+	if s.override != nil {
+		return s.override
+	}
+	return s.local
+}
+
+// Run menunggu jadwal terdekat dan menjalankan scannya sampai ctx selesai, scan yang sedang jalan
+// ikut dibatalkan lewat ctx
+func (s *ScanScheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		s.mu.Lock()
+		now := s.clock.Now()
+		var wake time.Time
+		for _, schedule := range s.active() {
+			if schedule.next.IsZero() {
+				schedule.next = schedule.cron.Next(now)
+			}
+			if !schedule.next.IsZero() && (wake.IsZero() || schedule.next.Before(wake)) {
+				wake = schedule.next
+			}
+		}
+		s.mu.Unlock()
+
+		// tanpa jadwal yang bisa jalan, tunggu sampai server mengirim jadwal baru
+		var fire <-chan time.Time
+		if !wake.IsZero() {
+			fire = s.clock.After(wake.Sub(now))
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.changed:
+		case <-fire:
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		now = s.clock.Now()
+		var due []ScanSchedule
+		for _, schedule := range s.active() {
+			if schedule.next.IsZero() || schedule.next.After(now) {
+				continue
+			}
+			schedule.next = schedule.cron.Next(now)
+
+			// jadwal yang scan sebelumnya belum selesai dilewati agar scan tidak menumpuk
+			if s.running[schedule.Name] {
+				utility.Infof("Scan terjadwal %s dilewati, scan sebelumnya belum selesai", schedule.Name)
+				continue
+			}
+			s.running[schedule.Name] = true
+			due = append(due, schedule.ScanSchedule)
+		}
+		s.mu.Unlock()
+
+		for _, schedule := range due {
+			wg.Add(1)
+			go func(schedule ScanSchedule) {
+				defer wg.Done()
+				s.runScan(ctx, schedule)
+			}(schedule)
+		}
+	}
+}
+
+func (s *ScanScheduler) runScan(ctx context.Context, schedule ScanSchedule) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, schedule.Name)
+		s.mu.Unlock()
+	}()
+
+	utility.Infof("Menjalankan scan terjadwal %s untuk %s", schedule.Name, schedule.IPRange)
+
+	ctx, span := utility.StartSpan(ctx, "scheduled_scan "+schedule.Name)
+	defer span.End()
+
+	// tanpa job id: server tidak menunggu laporan job untuk scan yang tidak dia minta
+	_, err := s.scanDevices(ctx, ScanDevicesReq{
+		ScanRequest: command.ScanRequest{
+			IPRange: schedule.IPRange,
+			Method:  schedule.Method,
+
+			SNMPCredential:      schedule.SNMPCredential,
+			Ports:               schedule.Ports,
+			Banners:             schedule.Banners,
+			MaxPacketsPerSecond: schedule.MaxPacketsPerSecond,
+			ProbeDelayMs:        schedule.ProbeDelayMs,
+			Workers:             schedule.Workers,
+		},
+		TimeOut:  time.Duration(schedule.TimeoutMs) * time.Millisecond,
+		ClientID: s.clientID(),
+	})
+	span.SetError(err)
+	if err != nil {
+		utility.Errorf("Scan terjadwal %s gagal: %v", schedule.Name, err)
+	}
+}
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"encoding/json"
+	"shared/core"
+	"testing"
+	"time"
+)
+
+// schedulerHarness menjalankan ScanScheduler dengan jam palsu, setiap scan yang dimulai dikirim ke scans
+// dan baru selesai setelah finish menerima nilai
+type schedulerHarness struct {
+	clock     *core.FakeClock
+	scheduler *ScanScheduler
+	scans     chan ScanDevicesReq
+	finish    chan struct{}
+	saved     []byte
+}
+
+func newSchedulerHarness(t *testing.T, local []ScanSchedule, saved []byte) *schedulerHarness {
+	t.Helper()
+
+	h := &schedulerHarness{
+		clock:  core.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)),
+		scans:  make(chan ScanDevicesReq, 8),
+		finish: make(chan struct{}),
+		saved:  saved,
+	}
+	scanDevices := func(ctx context.Context, req ScanDevicesReq) (*ScanDevicesRes, error) {
+		h.scans <- req
+		select {
+		case <-h.finish:
+		case <-ctx.Done():
+		}
+		return &ScanDevicesRes{}, nil
+	}
+	load := func(ctx context.Context, req gateway.LoadScheduleFileReq) (*gateway.LoadScheduleFileRes, error) {
+		return &gateway.LoadScheduleFileRes{Data: h.saved}, nil
+	}
+	save := func(ctx context.Context, req gateway.SaveScheduleFileReq) (*gateway.SaveScheduleFileRes, error) {
+		h.saved = req.Data
+		return &gateway.SaveScheduleFileRes{}, nil
+	}
+
+	scheduler, err := NewScanScheduler(scanDevices, func() string { return "agent-1" }, local, load, save, h.clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.scheduler = scheduler
+	return h
+}
+
+// run menjalankan Run sampai test selesai, scan yang belum selesai dibatalkan lewat ctx
+func (h *schedulerHarness) run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.scheduler.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitForWaiters menunggu sampai Run memasang timer berikutnya di jam palsu
+func (h *schedulerHarness) waitForWaiters(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for h.clock.Waiters() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timer jam palsu: %d, seharusnya %d", h.clock.Waiters(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// nextScan mengembalikan scan yang dimulai berikutnya
+func (h *schedulerHarness) nextScan(t *testing.T) ScanDevicesReq {
+	t.Helper()
+	select {
+	case req := <-h.scans:
+		return req
+	case <-time.After(2 * time.Second):
+		t.Fatal("tidak ada scan yang dimulai")
+		return ScanDevicesReq{}
+	}
+}
+
+// noScan memastikan tidak ada scan yang dimulai, dipanggil saat Run sedang menunggu timernya
+func (h *schedulerHarness) noScan(t *testing.T) {
+	t.Helper()
+	select {
+	case req := <-h.scans:
+		t.Fatalf("scan %s dimulai, seharusnya tidak ada", req.IPRange)
+	default:
+	}
+}
+
+func TestScanSchedulerRunsOnTheClock(t *testing.T) {
+	h := newSchedulerHarness(t, []ScanSchedule{{Name: "local", Cron: "*/5 * * * *", IPRange: "10.0.0.0/30"}}, nil)
+	close(h.finish)
+	h.run(t)
+	h.waitForWaiters(t, 1)
+
+	// jadwal berikutnya 00:05, jam baru di 00:04:30
+	h.clock.Advance(4 * time.Minute)
+	h.noScan(t)
+
+	h.clock.Advance(30 * time.Second)
+	if req := h.nextScan(t); req.IPRange != "10.0.0.0/30" || req.ClientID != "agent-1" {
+		t.Fatalf("scan: got %s dari %s, seharusnya 10.0.0.0/30 dari agent-1", req.IPRange, req.ClientID)
+	}
+}
+
+var (
+	localSchedule    = ScanSchedule{Name: "local", Cron: "*/5 * * * *", IPRange: "10.0.0.0/30"}
+	overrideSchedule = ScanSchedule{Name: "server", Cron: "*/2 * * * *", IPRange: "10.1.0.0/30"}
+)
+
+func TestScanSchedulerOverrideReplacesTheLocalSchedules(t *testing.T) {
+	h := newSchedulerHarness(t, []ScanSchedule{localSchedule}, nil)
+	close(h.finish)
+
+	if err := h.scheduler.SetOverride([]ScanSchedule{overrideSchedule}); err != nil {
+		t.Fatal(err)
+	}
+	if h.saved == nil {
+		t.Fatal("override tidak disimpan")
+	}
+	// sinyal perubahan dari SetOverride masih antre, Run memasang timer 00:02 dua kali
+	h.run(t)
+	h.waitForWaiters(t, 2)
+
+	// 00:02 hanya milik jadwal server
+	h.clock.Advance(90 * time.Second)
+	if req := h.nextScan(t); req.IPRange != overrideSchedule.IPRange {
+		t.Fatalf("scan saat override: got %s, seharusnya %s", req.IPRange, overrideSchedule.IPRange)
+	}
+	h.waitForWaiters(t, 1)
+
+	// timer 00:04 dari override tertinggal di jam palsu, jadwal lokal menambah timer 00:05
+	if err := h.scheduler.SetOverride(nil); err != nil {
+		t.Fatal(err)
+	}
+	if h.saved != nil {
+		t.Fatal("override yang dilepas masih tersimpan")
+	}
+	h.waitForWaiters(t, 2)
+
+	h.clock.Advance(3 * time.Minute)
+	if req := h.nextScan(t); req.IPRange != localSchedule.IPRange {
+		t.Fatalf("scan setelah override dilepas: got %s, seharusnya %s", req.IPRange, localSchedule.IPRange)
+	}
+	h.waitForWaiters(t, 1)
+	h.noScan(t)
+}
+
+func TestScanSchedulerRestoresTheSavedOverride(t *testing.T) {
+	saved, err := json.Marshal(ScanScheduleOverride{Schedules: []ScanSchedule{overrideSchedule}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newSchedulerHarness(t, []ScanSchedule{localSchedule}, saved)
+	close(h.finish)
+	h.run(t)
+	h.waitForWaiters(t, 1)
+
+	h.clock.Advance(90 * time.Second)
+	if req := h.nextScan(t); req.IPRange != overrideSchedule.IPRange {
+		t.Fatalf("scan setelah restart: got %s, seharusnya override %s", req.IPRange, overrideSchedule.IPRange)
+	}
+}
+
+func TestScanSchedulerIgnoresAnUnreadableSavedOverride(t *testing.T) {
+	h := newSchedulerHarness(t, []ScanSchedule{localSchedule}, []byte("{rusak"))
+	close(h.finish)
+	h.run(t)
+	h.waitForWaiters(t, 1)
+
+	h.clock.Advance(5 * time.Minute)
+	if req := h.nextScan(t); req.IPRange != localSchedule.IPRange {
+		t.Fatalf("scan: got %s, seharusnya jadwal lokal %s", req.IPRange, localSchedule.IPRange)
+	}
+}
+
+func TestScanSchedulerSkipsAScheduleStillRunning(t *testing.T) {
+	h := newSchedulerHarness(t, []ScanSchedule{{Name: "minutely", Cron: "* * * * *", IPRange: "10.0.0.0/30"}}, nil)
+	h.run(t)
+	h.waitForWaiters(t, 1)
+
+	h.clock.Advance(30 * time.Second)
+	h.nextScan(t)
+	h.waitForWaiters(t, 1)
+
+	// scan 00:01 belum selesai saat 00:02 tiba
+	h.clock.Advance(time.Minute)
+	h.waitForWaiters(t, 1)
+	h.noScan(t)
+
+	h.finish <- struct{}{}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.scheduler.mu.Lock()
+		running := len(h.scheduler.running)
+		h.scheduler.mu.Unlock()
+		if running == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("scan yang selesai masih tercatat berjalan")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	h.clock.Advance(time.Minute)
+	h.nextScan(t)
+	close(h.finish)
+}
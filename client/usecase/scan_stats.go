@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ScanStats menghitung scan yang dijalankan agent sejak start, dari trigger server maupun jadwal lokal
+type ScanStats struct {
+	mu   sync.Mutex
+	snap ScanStatsSnapshot
+}
+
+// ScanStatsSnapshot adalah bagian scans di snapshot statistik agent
+type ScanStatsSnapshot struct {
+	Running     int     `json:"running"`
+	Completed   uint64  `json:"completed"`
+	Failed      uint64  `json:"failed"`
+	Cancelled   uint64  `json:"cancelled"` // dihentikan server lewat scan_cancel
+	DryRuns     uint64  `json:"dry_runs"`
+	HostsProbed uint64  `json:"hosts_probed"`
+	LastError   string  `json:"last_error,omitempty"`
+	LastTotalMs float64 `json:"last_total_ms"` // durasi scan terakhir yang selesai
+}
+
+func NewScanStats() *ScanStats {
+	return &ScanStats{}
+}
+
+// Wrap menghitung setiap pemanggilan u tanpa mengubah hasilnya
+func (s *ScanStats) Wrap(u ScanDevices) ScanDevices {
+	return func(ctx context.Context, req ScanDevicesReq) (*ScanDevicesRes, error) {
+		s.mu.Lock()
+		s.snap.Running++
+		s.mu.Unlock()
+
+		res, err := u(ctx, req)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.snap.Running--
+		switch {
+		case err != nil && errors.Is(context.Cause(ctx), ErrScanCancelled):
+			s.snap.Cancelled++
+		case err != nil:
+			s.snap.Failed++
+			s.snap.LastError = err.Error()
+		case req.DryRun:
+			s.snap.DryRuns++
+		default:
+			s.snap.Completed++
+			s.snap.HostsProbed += uint64(res.Metrics.Probe.Count)
+			s.snap.LastTotalMs = res.Metrics.TotalMs
+		}
+		return res, err
+	}
+}
+
+func (s *ScanStats) Snapshot() ScanStatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snap
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"encoding/json"
+	"fmt"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"sync"
+	"time"
+)
+
+// DiscoverServicesReq memakai payload perintah discover_services, WindowMs default 5 detik
+type DiscoverServicesReq struct {
+	command.DiscoverServicesRequest
+	ClientID string // diisi controller dari koneksi SSE
+}
+
+type DiscoverServicesRes struct {
+	Found   int
+	Created int
+}
+
+type DiscoverServices = core.ActionHandler[DiscoverServicesReq, DiscoverServicesRes]
+
+// ImplDiscoverServices mendengarkan mDNS dan SSDP bersamaan selama window, lalu melaporkan
+// printer, kamera, NAS dan service lain yang terdengar ke server dalam satu laporan
+func ImplDiscoverServices(
+	ListenMDNS gateway.ListenMDNS,
+	ListenSSDP gateway.ListenSSDP,
+	ReportServices gateway.ReportCommandResult[command.DiscoverServicesResult],
+) DiscoverServices {
+	return func(ctx context.Context, req DiscoverServicesReq) (*DiscoverServicesRes, error) {
+
+		window := time.Duration(req.WindowMs) * time.Millisecond
+		if window <= 0 {
+			window = 5 * time.Second
+		}
+
+		listeners := map[string]core.ActionHandler[gateway.ListenServicesReq, gateway.ListenServicesRes]{}
+		protocols := req.Protocols
+		if len(protocols) == 0 {
+			protocols = []string{"mdns", "ssdp"}
+		}
+		for _, protocol := range protocols {
+			switch protocol {
+			case "mdns":
+				listeners[protocol] = ListenMDNS
+			case "ssdp":
+				listeners[protocol] = ListenSSDP
+			default:
+				return nil, fmt.Errorf("protocol discovery %q tidak dikenal, gunakan mdns atau ssdp", protocol)
+			}
+		}
+
+		utility.Infof("Mendengarkan %v selama %s", protocols, window)
+
+		var mu sync.Mutex
+		var services []gateway.DiscoveredService
+		var errs []error
+
+		var wg sync.WaitGroup
+		for protocol, listen := range listeners {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := listen(ctx, gateway.ListenServicesReq{Window: window})
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", protocol, err))
+					return
+				}
+				services = append(services, res.Services...)
+			}()
+		}
+		wg.Wait()
+
+		// satu protocol yang gagal tidak membuang hasil protocol lain
+		if len(errs) == len(listeners) {
+			return nil, errs[0]
+		}
+		for _, err := range errs {
+			utility.Warnf("Discovery %v", err)
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		utility.Infof("Discovery selesai: %d service ditemukan", len(services))
+		if len(services) == 0 {
+			return &DiscoverServicesRes{}, nil
+		}
+
+		res, err := ReportServices(ctx, gateway.ReportCommandResultReq[command.DiscoverServicesResult]{
+			ID:     req.ClientID,
+			Result: command.DiscoverServicesResult{Services: services},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// server membalas jumlah service yang baru pertama kali terlihat
+		var reported struct {
+			Created int `json:"created"`
+		}
+		json.Unmarshal(res.Data, &reported)
+
+		return &DiscoverServicesRes{Found: len(services), Created: reported.Created}, nil
+	}
+}
@@ -3,176 +3,389 @@ package usecase
 import (
 	"client/gateway"
 	"context"
+	"encoding/json"
 	"fmt"
-	"net"
+	"net/url"
+	"shared/command"
 	"shared/core"
+	"shared/utility"
+	"strings"
 	"sync"
 	"time"
 )
 
 type ScanDevicesReq struct {
-	IPRange string `json:"ip_range"`
-	Workers int
-	TimeOut time.Duration
+	// Method icmp (default), arp untuk segmen L2 agent, atau udp untuk host yang menolak ICMP dan TCP;
+	// DryRun hanya validasi dan hitung rencana scan, tanpa probe. Batas laju 0 tidak membatasi, Workers 0
+	// memakai jumlah worker agent
+	command.ScanRequest
+	TimeOut  time.Duration
+	ClientID string // diisi controller dari koneksi SSE, dipakai untuk laporan job
 }
 
 type ScanDevicesRes struct {
 	// ScanResults []gateway.ScanICMPRes
+	Metrics gateway.ScanJobMetrics
+	// EstimatedMs adalah perkiraan durasi terburuk, diisi saat dry run
+	EstimatedMs int64
 }
 
 type ScanDevices = core.ActionHandler[ScanDevicesReq, ScanDevicesRes]
 
+// probeResult membawa hasil scan beserta lama probe untuk statistik job
+type probeResult struct {
+	result   gateway.ScanICMPRes
+	duration time.Duration
+}
+
 func ImplScanDevices(
 	ScanICMP gateway.ScanICMP,
-	CallServer gateway.CallServer,
+	ScanARP gateway.ScanARP,
+	ScanUDP gateway.ScanUDP,
+	CreateResultSpool gateway.CreateResultSpool,
+	UploadResult gateway.UploadResult,
+	ReportScanJob gateway.ReportScanJob,
+	ReportScanProgress gateway.ReportScanProgress,
+	QuerySNMP gateway.QuerySNMP,
+	ScanTCPPorts gateway.ScanTCPPorts,
+	OSFingerprint gateway.OSFingerprint,
+	GetSecret gateway.GetSecret,
+	Guard *ResourceGuard,
+	Clock core.Clock,
 ) ScanDevices {
 	return func(ctx context.Context, req ScanDevicesReq) (*ScanDevicesRes, error) {
 
-		var result []gateway.ScanICMPRes
-
-		ipList, err := expandIPRange(req.IPRange)
-		if err != nil {
-			return nil, err
+		if req.Workers <= 0 {
+			req.Workers = 10
 		}
-
-		fmt.Printf("Memulai scan network untuk %d IP dengan %d workers\n", len(ipList), req.Workers)
-
-		var wg sync.WaitGroup
-		ipChan := make(chan string, len(ipList))
-
-		// Menambahkan semua IP ke channel
-		for _, ip := range ipList {
-			ipChan <- ip
+		if req.TimeOut <= 0 {
+			req.TimeOut = time.Second
+		}
+		if req.MaxPacketsPerSecond < 0 || req.ProbeDelayMs < 0 {
+			return nil, fmt.Errorf("max_packets_per_second dan probe_delay_ms tidak boleh negatif")
+		}
+		pacer := newScanPacer(Clock, req.MaxPacketsPerSecond, req.ProbeDelayMs, req.Workers)
+
+		// community probe SNMP pada scan UDP diisi setelah credential job ditemukan
+		var snmpCommunity string
+
+		// probe dipilih sekali per job, semua worker memakai cara yang sama
+		var probe gateway.ScanICMP
+		switch req.Method {
+		case "", "icmp":
+			probe = ScanICMP
+		case "arp":
+			probe = func(ctx context.Context, req gateway.ScanICMPReq) (*gateway.ScanICMPRes, error) {
+				return ScanARP(ctx, gateway.ScanARPReq{IP: req.IP, Timeout: req.Timeout})
+			}
+		case "udp":
+			probe = func(ctx context.Context, req gateway.ScanICMPReq) (*gateway.ScanICMPRes, error) {
+				return ScanUDP(ctx, gateway.ScanUDPReq{IP: req.IP, Timeout: req.Timeout, Community: snmpCommunity})
+			}
+		default:
+			return nil, fmt.Errorf("method scan %q tidak dikenal, gunakan icmp, arp atau udp", req.Method)
+		}
+		// packets adalah paket yang dikirim satu probe, dihitung pacer: ping 3 echo, udp 3 service, arp 1 request
+		protocol, packets := "ICMP", 3
+		switch req.Method {
+		case "arp":
+			protocol, packets = "ARP", 1
+		case "udp":
+			protocol = "UDP"
 		}
-		close(ipChan)
-
-		// Memulai worker
-		for i := range req.Workers {
-			wg.Add(1)
-			go func(id int) {
-				defer wg.Done()
-				fmt.Printf("Worker %d dimulai\n", id)
-
-				for ip := range ipChan {
-					resultScan, err := ScanICMP(ctx, gateway.ScanICMPReq{
-						IP:      ip,
-						Timeout: req.TimeOut,
-					})
-					if err != nil {
-
-						fmt.Printf("IP %s error\n", ip)
-
-						result = append(result, *resultScan)
 
-						continue
+		startedAt := time.Now()
+		var metrics gateway.ScanJobMetrics
+		totalHosts := 0
+		var estimatedMs int64
+
+		err := func() error {
+
+			// credential dicari sebelum probe, dry run juga gagal jika id tidak ada di agent ini
+			community, err := snmpCredential(ctx, GetSecret, req.SNMPCredential)
+			if err != nil {
+				return err
+			}
+			snmpCommunity = community
+
+			expandStart := time.Now()
+			ipList, err := expandIPRange(req.IPRange)
+			metrics.ExpandMs = durationMs(time.Since(expandStart))
+			if err != nil {
+				return err
+			}
+			totalHosts = len(ipList)
+
+			// dry run: laporkan apa yang akan discan tanpa mengirim probe
+			if req.DryRun {
+				estimated := max(estimateScanDuration(totalHosts, req.Workers, req.TimeOut), pacer.minDuration(totalHosts, packets))
+				estimatedMs = estimated.Milliseconds()
+				utility.Infof("Dry run: %d IP dengan %d workers, perkiraan maksimal %d ms", totalHosts, req.Workers, estimatedMs)
+				return nil
+			}
+
+			// hasil ditampung di spool: buffer memory terbatas, sisanya ditulis ke disk
+			spoolRes, err := CreateResultSpool(ctx, gateway.CreateResultSpoolReq{})
+			if err != nil {
+				return err
+			}
+			spool := spoolRes.Spool
+			defer spool.Close()
+
+			utility.Infof("Memulai scan network untuk %d IP dengan %d workers", len(ipList), req.Workers)
+
+			probeStart := time.Now()
+
+			var wg sync.WaitGroup
+			ipChan := make(chan string, req.Workers*2)
+			resultChan := make(chan probeResult, req.Workers*2)
+
+			// Menambahkan IP ke channel secara bertahap
+			go func() {
+				defer close(ipChan)
+				for _, ip := range ipList {
+					select {
+					case <-ctx.Done():
+						return
+					case ipChan <- ip:
 					}
-
-					result = append(result, *resultScan)
-
 				}
+			}()
+
+			// Satu goroutine yang menulis ke spool sehingga worker tidak berebut slice
+			// progress dihitung di collector, satu-satunya tempat yang melihat semua hasil
+			progress := startProgressReporter(ctx, Clock, ReportScanProgress, req, len(ipList))
+
+			var spoolErr error
+			var probes probeRecorder
+			collectorDone := make(chan struct{})
+			go func() {
+				defer close(collectorDone)
+				defer progress.Close()
+				for probe := range resultChan {
+					probes.add(probe.duration)
+					progress.Add()
+					if err := spool.Add(probe.result); err != nil && spoolErr == nil {
+						spoolErr = err
+					}
+				}
+			}()
+
+			// waktu sibuk per worker, tiap worker hanya menulis index miliknya sendiri
+			busy := make([]time.Duration, req.Workers)
+
+			// Memulai worker
+			for i := range req.Workers {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+					utility.Debugf("Worker %d dimulai", id)
+
+					for ip := range ipChan {
+						// tertahan di sini selama resource agent melewati batas
+						if err := Guard.Wait(ctx, id, req.Workers); err != nil {
+							break
+						}
+						// menunggu giliran di pacer tidak dihitung sebagai waktu sibuk worker
+						if err := pacer.Wait(ctx, packets); err != nil {
+							break
+						}
+
+						probeBegin := time.Now()
+						resultScan, err := probe(ctx, gateway.ScanICMPReq{
+							IP:      ip,
+							Timeout: req.TimeOut,
+						})
+						duration := time.Since(probeBegin)
+						busy[id] += duration
+
+						if err != nil {
+
+							utility.Debugf("IP %s error", ip)
+
+							// protokol hasil error sama dengan yang dilaporkan gateway ICMP untuk target IPv6
+							errProtocol := protocol
+							if protocol == "ICMP" && strings.Contains(ip, ":") {
+								errProtocol = "ICMPv6"
+							}
+
+							resultChan <- probeResult{
+								result: gateway.ScanICMPRes{
+									IP:        ip,
+									Timestamp: time.Now(),
+									Protocol:  errProtocol,
+									Status:    "Error",
+								},
+								duration: duration,
+							}
+
+							continue
+						}
+
+						// host yang menjawab ping ditanya identitasnya lewat SNMP, di luar waktu probe
+						if QuerySNMP != nil && resultScan.Status == "Online" && pacer.Wait(ctx, 1) == nil {
+							resultScan.SNMPData = querySNMPData(ctx, QuerySNMP, ip, snmpCommunity)
+						}
+
+						// port TCP juga hanya dicek pada host online, host mati tidak perlu ditunggu per port
+						tcpWindow := 0
+						if ScanTCPPorts != nil && len(req.Ports) > 0 && resultScan.Status == "Online" {
+							resultScan.OpenPorts, tcpWindow = scanOpenPorts(ctx, ScanTCPPorts, ip, req, pacer)
+						}
+
+						// tebakan OS hanya memakai yang sudah terlihat dari probe, tidak ada paket tambahan
+						if OSFingerprint != nil && resultScan.Status == "Online" {
+							if res, err := OSFingerprint(ctx, gateway.OSFingerprintReq{TTL: resultScan.TTL, TCPWindow: tcpWindow}); err == nil {
+								resultScan.OSGuess = res.OSGuess
+							}
+						}
+
+						resultChan <- probeResult{result: *resultScan, duration: duration}
 
-				fmt.Printf("Worker %d selesai\n", id)
-			}(i)
-		}
+					}
 
-		wg.Wait()
-		fmt.Printf("Scan network selesai\n")
+					utility.Debugf("Worker %d selesai", id)
+				}(i)
+			}
+
+			wg.Wait()
+			close(resultChan)
+			<-collectorDone
+			utility.Infof("Scan network selesai")
+
+			probeWall := time.Since(probeStart)
+			metrics.ProbeMs = durationMs(probeWall)
+			metrics.Probe = probes.stats()
+			metrics.WorkerUtilization = workerUtilization(busy, probeWall)
+			if probeWall > 0 {
+				metrics.HostsPerSecond = float64(probes.count) / probeWall.Seconds()
+			}
+
+			if spoolErr != nil {
+				return spoolErr
+			}
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			// hasil dikirim per chunk terkompresi sehingga koneksi lambat bisa melanjutkan upload,
+			// client_id dan job_id ikut di path karena server meneruskan array hasil ke endpoint itu apa adanya
+			query := url.Values{"client_id": {req.ClientID}}
+			if req.JobID != "" {
+				query.Set("job_id", req.JobID)
+			}
+			uploadStart := time.Now()
+			_, err = UploadResult(ctx, gateway.UploadResultReq{
+				Path:   "/api/scan-devices-result?" + query.Encode(),
+				Source: spool,
+			})
+			metrics.UploadMs = durationMs(time.Since(uploadStart))
+
+			return err
+		}()
+
+		finishedAt := time.Now()
+		metrics.TotalMs = durationMs(finishedAt.Sub(startedAt))
+
+		// laporan job dikirim baik scan berhasil maupun gagal, agar operator bisa melihat penyebabnya
+		if req.JobID != "" {
+			report := gateway.ReportScanJobReq{
+				JobID:       req.JobID,
+				ClientID:    req.ClientID,
+				Status:      "completed",
+				TotalHosts:  totalHosts,
+				Workers:     req.Workers,
+				TimeoutMs:   req.TimeOut.Milliseconds(),
+				EstimatedMs: estimatedMs,
+				StartedAt:   startedAt,
+				FinishedAt:  finishedAt,
+				Metrics:     metrics,
+			}
+			if err != nil {
+				report.Status, report.Error = failedReportStatus(ctx, err)
+			} else if req.DryRun {
+				report.Status = "planned"
+			}
+
+			// context scan bisa sudah dibatalkan, laporan tetap dikirim
+			if _, reportErr := ReportScanJob(context.WithoutCancel(ctx), report); reportErr != nil {
+				utility.Warnf("Gagal mengirim laporan job %s: %v", req.JobID, reportErr)
+			}
+		}
 
-		if _, err = CallServer(ctx, gateway.CallServerReq{
-			Method:  "POST",
-			Path:    "/api/scan-devices-result",
-			Payload: result,
-		}); err != nil {
+		if err != nil {
 			return nil, err
 		}
 
-		return &ScanDevicesRes{}, nil
-	}
-}
+		if req.DryRun {
+			return &ScanDevicesRes{Metrics: metrics, EstimatedMs: estimatedMs}, nil
+		}
 
-// Memperluas range IP seperti "192.168.1.1-192.168.1.254" menjadi list IP
-func expandIPRange(ipRange string) ([]string, error) {
-	var startIP, endIP net.IP
+		utility.Infof("Job selesai: %d host dalam %.0f ms (%.1f host/detik)", totalHosts, metrics.TotalMs, metrics.HostsPerSecond)
 
-	// Memeriksa apakah input adalah CIDR
-	if _, network, err := net.ParseCIDR(ipRange); err == nil {
-		fmt.Printf("Memproses range CIDR: %s", ipRange)
-		return expandCIDR(network)
+		return &ScanDevicesRes{Metrics: metrics}, nil
 	}
+}
 
-	// Memeriksa apakah input adalah IP tunggal
-	if ip := net.ParseIP(ipRange); ip != nil {
-		fmt.Printf("IP tunggal terdeteksi: %s", ipRange)
-		return []string{ipRange}, nil
+// querySNMPData mengembalikan sysDescr dan sysName sebagai JSON, kosong jika host tidak menjawab SNMP
+func querySNMPData(ctx context.Context, QuerySNMP gateway.QuerySNMP, ip, community string) string {
+	res, err := QuerySNMP(ctx, gateway.QuerySNMPReq{IP: ip, Community: community})
+	if err != nil || (res.SysDescr == "" && res.SysName == "") {
+		return ""
 	}
 
-	// Memeriksa apakah input adalah range IP (format: start-end)
-	if _, err := fmt.Sscanf(ipRange, "%s-%s", &startIP, &endIP); err == nil {
-		fmt.Printf("Range IP terdeteksi: %s ke %s", startIP, endIP)
-		return expandIPStartEnd(startIP.String(), endIP.String())
+	data, err := json.Marshal(res)
+	if err != nil {
+		return ""
 	}
-
-	return nil, fmt.Errorf("format IP tidak didukung: %s", ipRange)
+	return string(data)
 }
 
-// Memperluas CIDR menjadi list IP
-func expandCIDR(network *net.IPNet) ([]string, error) {
-	var ipList []string
-
-	// Mendapatkan IP awal
-	ip := network.IP.To4()
-	if ip == nil {
-		return nil, fmt.Errorf("hanya mendukung IPv4")
+// scanOpenPorts mengembalikan port TCP yang terbuka dan window SYN-ACK-nya, kosong jika pengecekan gagal
+func scanOpenPorts(ctx context.Context, ScanTCPPorts gateway.ScanTCPPorts, ip string, req ScanDevicesReq, pacer *scanPacer) ([]gateway.OpenPort, int) {
+	tcpReq := gateway.ScanTCPPortsReq{IP: ip, Ports: req.Ports, Timeout: req.TimeOut, Banner: req.Banners}
+	if pacer != nil {
+		// setiap SYN satu paket, port TCP ikut batas laju job
+		tcpReq.Pace = func(ctx context.Context) error { return pacer.Wait(ctx, 1) }
 	}
-
-	// Konversi IP ke uint32 untuk memudahkan increment
-	startIP := uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
-
-	// Mendapatkan mask
-	mask := network.Mask
-	ones, _ := mask.Size()
-
-	// Menghitung jumlah IP
-	hostBits := 32 - ones
-	numIPs := uint32(1 << hostBits)
-
-	fmt.Printf("Memperluas CIDR menjadi %d IP", numIPs)
-
-	// Memperluas range
-	for i := uint32(0); i < numIPs; i++ {
-		currentIP := startIP + i
-		ip := net.IPv4(byte(currentIP>>24), byte(currentIP>>16), byte(currentIP>>8), byte(currentIP))
-		ipList = append(ipList, ip.String())
+	res, err := ScanTCPPorts(ctx, tcpReq)
+	if err != nil {
+		utility.Debugf("Port TCP %s gagal dicek: %v", ip, err)
+		return nil, 0
 	}
-
-	return ipList, nil
+	return res.OpenPorts, res.TCPWindow
 }
 
-// Memperluas range IP dari start ke end
-func expandIPStartEnd(startIPStr, endIPStr string) ([]string, error) {
-	startIP := net.ParseIP(startIPStr).To4()
-	endIP := net.ParseIP(endIPStr).To4()
-
-	if startIP == nil || endIP == nil {
-		return nil, fmt.Errorf("format IP tidak valid")
+// snmpCredential mengambil community dari secrets store, id kosong memakai community konfigurasi agent
+func snmpCredential(ctx context.Context, GetSecret gateway.GetSecret, id string) (string, error) {
+	if id == "" {
+		return "", nil
 	}
-
-	// Konversi IP ke uint32
-	start := uint32(startIP[0])<<24 | uint32(startIP[1])<<16 | uint32(startIP[2])<<8 | uint32(startIP[3])
-	end := uint32(endIP[0])<<24 | uint32(endIP[1])<<16 | uint32(endIP[2])<<8 | uint32(endIP[3])
-
-	if end < start {
-		return nil, fmt.Errorf("IP akhir harus lebih besar dari IP awal")
+	if GetSecret == nil {
+		return "", fmt.Errorf("snmp credential %s diminta tapi secrets store tidak aktif di agent ini", id)
 	}
 
-	numIPs := end - start + 1
-	fmt.Printf("Memperluas range IP menjadi %d IP", numIPs)
-
-	var ipList []string
-	for i := start; i <= end; i++ {
-		ip := net.IPv4(byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
-		ipList = append(ipList, ip.String())
+	res, err := GetSecret(ctx, gateway.GetSecretReq{ID: id})
+	if err != nil {
+		return "", err
+	}
+	if res.Secret.Type != gateway.SecretSNMP || res.Secret.Community == "" {
+		return "", fmt.Errorf("credential %s bukan credential snmp", id)
 	}
+	return res.Secret.Community, nil
+}
 
-	return ipList, nil
+// LimitScanWorkers menjalankan setiap scan dengan paling banyak maxWorkers worker, jumlah yang diumumkan
+// agent sebagai max_concurrency, dan dengan maxWorkers jika permintaan tidak menyebut jumlahnya
+func LimitScanWorkers(scanDevices ScanDevices, maxWorkers int) ScanDevices {
+	if maxWorkers <= 0 {
+		return scanDevices
+	}
+	return func(ctx context.Context, req ScanDevicesReq) (*ScanDevicesRes, error) {
+		if req.Workers <= 0 || req.Workers > maxWorkers {
+			req.Workers = maxWorkers
+		}
+		return scanDevices(ctx, req)
+	}
 }
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"net/url"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"strings"
+	"time"
+)
+
+// ScanNmapReq adalah payload event scan_nmap, field-nya sama dengan scan_icmp
+type ScanNmapReq struct {
+	// batas laju diteruskan ke --max-rate dan --scan-delay, nmap mengatur paketnya sendiri
+	command.ScanRequest
+	ClientID string // diisi controller dari koneksi SSE, dipakai untuk laporan job
+}
+
+type ScanNmapRes struct {
+	Metrics gateway.ScanJobMetrics
+}
+
+type ScanNmap = core.ActionHandler[ScanNmapReq, ScanNmapRes]
+
+// ImplScanNmap menjalankan job dengan nmap, hasilnya lewat spool, upload dan laporan job yang sama dengan scan_icmp
+func ImplScanNmap(
+	ScanNmap gateway.ScanNmap,
+	CreateResultSpool gateway.CreateResultSpool,
+	UploadResult gateway.UploadResult,
+	ReportScanJob gateway.ReportScanJob,
+) ScanNmap {
+	return func(ctx context.Context, req ScanNmapReq) (*ScanNmapRes, error) {
+
+		startedAt := time.Now()
+		var metrics gateway.ScanJobMetrics
+		totalHosts := 0
+
+		err := func() error {
+
+			// range divalidasi dengan aturan yang sama dengan scan lain sebelum diberikan ke nmap
+			expandStart := time.Now()
+			ipList, err := expandIPRange(req.IPRange)
+			metrics.ExpandMs = durationMs(time.Since(expandStart))
+			if err != nil {
+				return err
+			}
+			totalHosts = len(ipList)
+
+			if req.DryRun {
+				utility.Infof("Dry run nmap: %d IP", totalHosts)
+				return nil
+			}
+
+			var targets []string
+			for _, part := range strings.Split(req.IPRange, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					targets = append(targets, part)
+				}
+			}
+
+			utility.Infof("Memulai scan nmap untuk %d IP", totalHosts)
+			probeStart := time.Now()
+			res, err := ScanNmap(ctx, gateway.ScanNmapReq{
+				Targets:   targets,
+				Ports:     req.Ports,
+				MaxRate:   req.MaxPacketsPerSecond,
+				ScanDelay: time.Duration(req.ProbeDelayMs) * time.Millisecond,
+			})
+			probeWall := time.Since(probeStart)
+			metrics.ProbeMs = durationMs(probeWall)
+			if err != nil {
+				return err
+			}
+			utility.Debugf("nmap %s", strings.Join(res.Args, " "))
+			if probeWall > 0 {
+				metrics.HostsPerSecond = float64(totalHosts) / probeWall.Seconds()
+			}
+
+			spoolRes, err := CreateResultSpool(ctx, gateway.CreateResultSpoolReq{})
+			if err != nil {
+				return err
+			}
+			spool := spoolRes.Spool
+			defer spool.Close()
+
+			for _, host := range res.Hosts {
+				if err := spool.Add(host); err != nil {
+					return err
+				}
+			}
+
+			query := url.Values{"client_id": {req.ClientID}}
+			if req.JobID != "" {
+				query.Set("job_id", req.JobID)
+			}
+			uploadStart := time.Now()
+			_, err = UploadResult(ctx, gateway.UploadResultReq{
+				Path:   "/api/scan-devices-result?" + query.Encode(),
+				Source: spool,
+			})
+			metrics.UploadMs = durationMs(time.Since(uploadStart))
+
+			return err
+		}()
+
+		finishedAt := time.Now()
+		metrics.TotalMs = durationMs(finishedAt.Sub(startedAt))
+
+		// laporan job dikirim baik scan berhasil maupun gagal, termasuk saat nmap tidak terpasang
+		if req.JobID != "" {
+			report := gateway.ReportScanJobReq{
+				JobID:      req.JobID,
+				ClientID:   req.ClientID,
+				Status:     "completed",
+				TotalHosts: totalHosts,
+				StartedAt:  startedAt,
+				FinishedAt: finishedAt,
+				Metrics:    metrics,
+			}
+			if err != nil {
+				report.Status, report.Error = failedReportStatus(ctx, err)
+			} else if req.DryRun {
+				report.Status = "planned"
+			}
+
+			if _, reportErr := ReportScanJob(context.WithoutCancel(ctx), report); reportErr != nil {
+				utility.Warnf("Gagal mengirim laporan job %s: %v", req.JobID, reportErr)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		utility.Infof("Job nmap selesai: %d host dalam %.0f ms", totalHosts, metrics.TotalMs)
+
+		return &ScanNmapRes{Metrics: metrics}, nil
+	}
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"fmt"
+	"regexp"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+)
+
+// id credential dipakai di payload perintah dan log, jadi dibatasi ke karakter yang aman
+var secretIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// SecretSetReq adalah payload event secret_set, credential hanya dikirim sekali saat disimpan
+type SecretSetReq = command.SecretSetRequest
+
+type SecretSetRes struct{}
+
+type SecretSet = core.ActionHandler[SecretSetReq, SecretSetRes]
+
+// ImplSecretSet menyimpan atau mengganti credential di secrets store agent
+func ImplSecretSet(SaveSecret gateway.SaveSecret) SecretSet {
+	return func(ctx context.Context, req SecretSetReq) (*SecretSetRes, error) {
+
+		if !secretIDPattern.MatchString(req.ID) {
+			return nil, fmt.Errorf("id secret %q tidak valid, gunakan huruf, angka, titik, minus atau underscore", req.ID)
+		}
+
+		secret := gateway.Secret{
+			Type:       req.Type,
+			Community:  req.Community,
+			Username:   req.Username,
+			Password:   req.Password,
+			PrivateKey: req.PrivateKey,
+		}
+
+		switch req.Type {
+		case gateway.SecretSNMP:
+			if req.Community == "" {
+				return nil, fmt.Errorf("secret snmp %s membutuhkan community", req.ID)
+			}
+		case gateway.SecretSSH:
+			if req.Username == "" || (req.Password == "" && req.PrivateKey == "") {
+				return nil, fmt.Errorf("secret ssh %s membutuhkan username dan password atau private key", req.ID)
+			}
+		case gateway.SecretWMI:
+			if req.Username == "" || req.Password == "" {
+				return nil, fmt.Errorf("secret wmi %s membutuhkan username dan password", req.ID)
+			}
+		default:
+			return nil, fmt.Errorf("tipe secret %q tidak dikenal, gunakan snmp, ssh atau wmi", req.Type)
+		}
+
+		if _, err := SaveSecret(ctx, gateway.SaveSecretReq{ID: req.ID, Secret: secret}); err != nil {
+			return nil, err
+		}
+
+		// nilai credential tidak pernah dicetak
+		utility.Infof("Secret %s (%s) disimpan", req.ID, req.Type)
+
+		return &SecretSetRes{}, nil
+	}
+}
+
+// SecretDeleteReq adalah payload event secret_delete
+type SecretDeleteReq = command.SecretDeleteRequest
+
+type SecretDeleteRes struct {
+	Deleted bool
+}
+
+type SecretDelete = core.ActionHandler[SecretDeleteReq, SecretDeleteRes]
+
+// ImplSecretDelete menghapus credential, id yang tidak ada bukan error agar perintah bisa diulang
+func ImplSecretDelete(DeleteSecret gateway.DeleteSecret) SecretDelete {
+	return func(ctx context.Context, req SecretDeleteReq) (*SecretDeleteRes, error) {
+
+		res, err := DeleteSecret(ctx, gateway.DeleteSecretReq{ID: req.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		if res.Deleted {
+			utility.Infof("Secret %s dihapus", req.ID)
+		}
+
+		return &SecretDeleteRes{Deleted: res.Deleted}, nil
+	}
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"client/gateway"
+	"context"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"time"
+)
+
+// TracerouteReq memakai payload perintah traceroute, MaxHops default 30, Probes 3, TimeoutMs 1000
+type TracerouteReq struct {
+	command.TracerouteRequest
+	ClientID string // diisi controller dari koneksi SSE
+}
+
+type TracerouteRes struct {
+	Hops    int
+	Reached bool
+}
+
+type Traceroute = core.ActionHandler[TracerouteReq, TracerouteRes]
+
+// ImplTraceroute menelusuri jalur dari agent ke target lalu melaporkannya, trace yang gagal tetap
+// dilaporkan agar server tidak menunggu selamanya
+func ImplTraceroute(
+	Trace gateway.Traceroute,
+	ReportTraceroute gateway.ReportCommandResult[command.TracerouteResult],
+) Traceroute {
+	return func(ctx context.Context, req TracerouteReq) (*TracerouteRes, error) {
+
+		if req.MaxHops <= 0 || req.MaxHops > 64 {
+			req.MaxHops = 30
+		}
+		if req.Probes <= 0 || req.Probes > 10 {
+			req.Probes = 3
+		}
+		if req.TimeoutMs <= 0 {
+			req.TimeoutMs = 1000
+		}
+
+		utility.Infof("Traceroute %s ke %s, maksimal %d hop", req.TraceID, req.Target, req.MaxHops)
+
+		startedAt := time.Now()
+		res, traceErr := Trace(ctx, gateway.TracerouteReq{
+			Target:  req.Target,
+			MaxHops: req.MaxHops,
+			Probes:  req.Probes,
+			Timeout: time.Duration(req.TimeoutMs) * time.Millisecond,
+		})
+
+		report := command.TracerouteResult{
+			ClientID:   req.ClientID,
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+		}
+		if traceErr != nil {
+			report.Error = traceErr.Error()
+		} else {
+			report.ResolvedIP = res.ResolvedIP
+			report.Hops = res.Hops
+			report.Reached = res.Reached
+		}
+
+		// laporan tetap dikirim walau trace dibatalkan, context baru dipakai agar report tidak ikut batal
+		if _, err := ReportTraceroute(context.WithoutCancel(ctx), gateway.ReportCommandResultReq[command.TracerouteResult]{
+			ID:     req.TraceID,
+			Result: report,
+		}); err != nil {
+			return nil, err
+		}
+		if traceErr != nil {
+			return nil, traceErr
+		}
+
+		utility.Infof("Traceroute %s selesai: %d hop, target tercapai %t", req.TraceID, len(res.Hops), res.Reached)
+
+		return &TracerouteRes{Hops: len(res.Hops), Reached: res.Reached}, nil
+	}
+}
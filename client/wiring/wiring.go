@@ -3,27 +3,207 @@ package wiring
 import (
 	"client/controller"
 	"client/gateway"
+	"client/platform"
+	"client/plugin"
 	"client/usecase"
+	"context"
+	"shared/command"
+	"shared/core"
 	"shared/utility"
+	"time"
 )
 
-func SetupDependency(sseClient *utility.SSEClient) {
+type Config struct {
+	PluginDir string
+	SpoolDir  string // directory for scan results that do not fit in memory, empty means os temp dir
+	SPKIPins  []string
+	// ServerToken dikirim di setiap request REST ke server, sama dengan token stream SSE
+	ServerToken string
+	// Capabilities adalah hasil platform.Detect, menentukan cara probe dijalankan di OS ini
+	Capabilities platform.Capabilities
+	// SNMP menentukan cara host yang menjawab ping ditanya identitasnya, community kosong mematikannya
+	SNMP gateway.SNMPConfig
+	// Nmap menentukan binary dan template argumen scan_nmap, job gagal dilaporkan jika nmap tidak terpasang
+	Nmap gateway.NmapConfig
+	// ResourceLimits adalah batas awal resource guard, server bisa menggantinya lewat event resource_limits
+	ResourceLimits usecase.ResourceLimits
+	// ScanSchedules adalah scan lokal yang tetap jalan tanpa trigger server, jadwal dari server menggantikannya
+	ScanSchedules []usecase.ScanSchedule
+	// ScheduleFile menyimpan jadwal dari server agar tetap jalan setelah restart, kosong tidak menyimpan
+	ScheduleFile string
+	// AgentControl menerima perintah restart dan shutdown dari server, main yang menjalankannya
+	AgentControl *usecase.AgentControl
+	// Secrets menyimpan credential scan yang disebut dengan id di perintah, nil mematikan secret_set
+	Secrets *gateway.SecretStore
+	// ScanWorkers adalah worker maksimal per scan, juga jumlah worker jika perintah tidak menyebutnya
+	ScanWorkers int
+	// Identity dikirim saat agent mendaftar ke server, client id diambil dari koneksi SSE
+	Identity gateway.AgentIdentity
+	// Stats menerima bagian snapshot statistik dari komponen agent
+	Stats *utility.StatsRegistry
+	// Lifecycle menerima hook berhenti dari komponen yang berjalan di background
+	Lifecycle *utility.Lifecycle
+	// Clock menjalankan jadwal scan, jeda retry dan batas laju probe, nil memakai jam asli
+	Clock core.Clock
+}
+
+func SetupDependency(sseClient *utility.SSEClient, config Config) error {
+
+	clock := config.Clock
+	if clock == nil {
+		clock = core.RealClock{}
+	}
 
 	// gateways
-	scanICMPImpl := gateway.ImplScanICMP()
-	callServerImpl := gateway.ImplCallServer()
+	scanICMPImpl := gateway.ImplScanICMP(config.Capabilities)
+	scanARPImpl := gateway.ImplScanARP(config.Capabilities)
+	scanUDPImpl := gateway.ImplScanUDP(config.SNMP)
+	scanTCPPortsImpl := gateway.ImplScanTCPPorts()
+	osFingerprintImpl := gateway.ImplOSFingerprint()
+	scanNmapImpl, err := gateway.ImplScanNmap(config.Nmap)
+	if err != nil {
+		return err
+	}
+	httpStats := utility.NewHTTPStats()
+	config.Stats.Add("http", func(ctx context.Context) (any, error) { return httpStats.Snapshot(), nil })
+	callServerImpl, err := gateway.ImplCallServer(gateway.CallServerConfig{
+		BaseURL:  sseClient.ActiveServerURL,
+		SPKIPins: config.SPKIPins,
+		Stats:    httpStats,
+
+		BearerToken: config.ServerToken,
+	})
+	if err != nil {
+		return err
+	}
+	uploadResultImpl := gateway.ImplUploadResultChunked(callServerImpl, gateway.UploadResultConfig{Clock: clock})
+	createResultSpoolImpl := gateway.ImplCreateResultSpoolOnDisk(config.SpoolDir)
+	reportScanJobImpl := gateway.ImplReportScanJob(callServerImpl)
+	readResourceUsageImpl := gateway.ImplReadResourceUsage()
+	reportThrottleImpl := gateway.ImplReportThrottle(callServerImpl)
+	reportScanProgressImpl := gateway.ImplReportScanProgress(callServerImpl)
+	querySNMPImpl, err := gateway.ImplQuerySNMP(config.SNMP)
+	if err != nil {
+		return err
+	}
+	var getSecretImpl gateway.GetSecret
+	if config.Secrets != nil {
+		getSecretImpl = gateway.ImplGetSecret(config.Secrets)
+	}
+	listenMDNSImpl := gateway.ImplListenMDNS()
+	listenSSDPImpl := gateway.ImplListenSSDP()
+	reportServicesImpl := gateway.ImplReportCommandResult(callServerImpl, command.DiscoverServices)
+	traceImpl := gateway.ImplTraceroute(config.Capabilities)
+	reportTracerouteImpl := gateway.ImplReportCommandResult(callServerImpl, command.Traceroute)
+	loadScheduleFileImpl := gateway.ImplLoadScheduleFile(config.ScheduleFile)
+	saveScheduleFileImpl := gateway.ImplSaveScheduleFile(config.ScheduleFile)
+	registerAgentImpl := gateway.ImplRegisterAgent(callServerImpl)
+	sendHeartbeatImpl := gateway.ImplSendHeartbeat(callServerImpl)
 	// ...other gateways here...
 
+	// agent mendaftar ke server lalu mengirim heartbeat selama hidup
+	agentPresence := usecase.NewAgentPresence(registerAgentImpl, sendHeartbeatImpl, config.Identity, sseClient.GetClientID)
+
+	// resource guard berjalan selama agent hidup dan membatasi semua scan
+	resourceGuard := usecase.NewResourceGuard(readResourceUsageImpl, reportThrottleImpl, sseClient.GetClientID, config.ResourceLimits, clock)
+
 	// use cases
-	scanDevicesImpl := usecase.ImplScanDevices(scanICMPImpl, callServerImpl)
+	scanDevicesImpl := usecase.ImplScanDevices(scanICMPImpl, scanARPImpl, scanUDPImpl, createResultSpoolImpl, uploadResultImpl, reportScanJobImpl, reportScanProgressImpl, querySNMPImpl, scanTCPPortsImpl, osFingerprintImpl, getSecretImpl, resourceGuard, clock)
+
+	scanDevicesImpl = usecase.LimitScanWorkers(scanDevicesImpl, config.ScanWorkers)
+
+	// scan dari trigger dan dari jadwal lokal sama-sama terhitung
+	scanStats := usecase.NewScanStats()
+	scanDevicesImpl = scanStats.Wrap(scanDevicesImpl)
+	config.Stats.Add("scans", func(ctx context.Context) (any, error) { return scanStats.Snapshot(), nil })
+	discoverServicesImpl := usecase.ImplDiscoverServices(listenMDNSImpl, listenSSDPImpl, reportServicesImpl)
+	tracerouteImpl := usecase.ImplTraceroute(traceImpl, reportTracerouteImpl)
 	// ...other usecases here...
 
+	// scheduler menjalankan scan lokal walau server tidak pernah mengirim trigger
+	scanScheduler, err := usecase.NewScanScheduler(scanDevicesImpl, sseClient.GetClientID, config.ScanSchedules, loadScheduleFileImpl, saveScheduleFileImpl, clock)
+	if err != nil {
+		return err
+	}
+
+	// job dari server dicatat agar scan_cancel bisa menghentikannya
+	runningScans := usecase.NewRunningScans()
+
 	c := controller.Controller{
 		SSEClient: sseClient,
 	}
 
-	// controllers
-	c.HandleScanDevices(scanDevicesImpl)
-	// ...other controllers here...
+	registry := plugin.NewRegistry()
+
+	// built in plugins
+	builtins := []plugin.ScannerPlugin{
+		c.ScanDevicesPlugin(scanDevicesImpl, runningScans),
+		c.ScanNmapPlugin(usecase.ImplScanNmap(scanNmapImpl, createResultSpoolImpl, uploadResultImpl, reportScanJobImpl), runningScans),
+		c.ScanCancelPlugin(runningScans),
+		c.DiscoverServicesPlugin(discoverServicesImpl),
+		c.TraceroutePlugin(tracerouteImpl),
+		c.ResourceLimitsPlugin(resourceGuard),
+		c.LogLevelPlugin(utility.DefaultLogger()),
+		c.ScanSchedulePlugin(scanScheduler),
+		c.AgentControlPlugin(config.AgentControl, usecase.AgentRestart),
+		c.AgentControlPlugin(config.AgentControl, usecase.AgentShutdown),
+	}
+	if config.Secrets != nil {
+		builtins = append(builtins,
+			c.SecretSetPlugin(usecase.ImplSecretSet(gateway.ImplSaveSecret(config.Secrets))),
+			c.SecretDeletePlugin(usecase.ImplSecretDelete(gateway.ImplDeleteSecret(config.Secrets))),
+		)
+	}
+
+	// plugins from packages that self registered via init()
+	builtins = append(builtins, plugin.Registered()...)
+
+	// external executable plugins
+	execPlugins, err := plugin.LoadExecPlugins(config.PluginDir)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range append(builtins, execPlugins...) {
+		if err := registry.Register(p); err != nil {
+			return err
+		}
+		utility.Infof("Plugin %s terdaftar untuk event %s", p.Name(), p.TriggerEvent())
+	}
+
+	// worker background baru dijalankan setelah semua langkah yang bisa gagal, startup yang gagal
+	// tidak meninggalkan goroutine tanpa pemilik
+	runWorker(config.Lifecycle, "agent-presence", func(ctx context.Context) {
+		agentPresence.Run(ctx, 30*time.Second)
+	})
+	runWorker(config.Lifecycle, "resource-guard", func(ctx context.Context) {
+		resourceGuard.Run(ctx, 2*time.Second)
+	})
+	runWorker(config.Lifecycle, "scan-scheduler", scanScheduler.Run)
+
+	registry.Attach(sseClient, plugin.Env{
+		CallServer: callServerImpl,
+	})
+
+	return nil
+}
 
+// runWorker menjalankan run di goroutine sampai lifecycle berhenti, hook berhenti menunggu run selesai
+// atau batas waktu shutdown habis
+func runWorker(lifecycle *utility.Lifecycle, name string, run func(ctx context.Context)) {
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+	lifecycle.Register(name, func(ctx context.Context) error {
+		stop()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
 }
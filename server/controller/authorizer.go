@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/model"
+	"shared/core"
+	"shared/utility"
+	"slices"
+)
+
+// RoleAuthorizer admits the callers of a route by the roles of their bearer token: admin reaches
+// every route, otherwise the caller needs the role named like the access of the route, operator or
+// agent, and one of the roles the route lists. The user id of the token becomes the operator of the request,
+// see OperatorHeader.
+func RoleAuthorizer(jwt utility.JWTTokenizer) utility.Authorizer {
+	return utility.AuthorizerFunc(func(r *http.Request, route utility.APIData) (*http.Request, error) {
+
+		if route.Access == utility.Public {
+			return withOperator(r, ""), nil
+		}
+
+		token, errMessage, ok := GetBearerToken(nil, r)
+		if !ok {
+			return nil, utility.Unauthenticated(errMessage)
+		}
+
+		content, err := jwt.VerifyToken(token)
+		if err != nil {
+			return nil, utility.Unauthenticated("unverified token")
+		}
+
+		var payload model.UserTokenPayload
+		if err := json.Unmarshal(content, &payload); err != nil {
+			return nil, utility.Unauthenticated("incorrect token payload")
+		}
+
+		if !slices.Contains(payload.Roles, model.RoleAdmin) {
+			if !slices.Contains(payload.Roles, string(route.Access)) {
+				return nil, utility.Forbidden("unauthorized operation, needs " + string(route.Access) + " access")
+			}
+			if len(route.Roles) > 0 && !slices.ContainsFunc(route.Roles, func(role string) bool { return slices.Contains(payload.Roles, role) }) {
+				return nil, utility.Forbidden("unauthorized operation, needs a role of the route")
+			}
+		}
+
+		ctx := core.AttachDataToContext(r.Context(), UserIDContext, payload.UserID)
+		ctx = core.AttachDataToContext(ctx, UserRolesContext, payload.Roles)
+		return withOperator(r.WithContext(ctx), payload.UserID), nil
+	})
+}
+
+// withOperator replaces the operator header of the caller on a copy of the request, an empty operator
+// leaves it unset
+func withOperator(r *http.Request, operator string) *http.Request {
+	r = r.Clone(r.Context())
+	r.Header.Del(OperatorHeader)
+	if operator != "" {
+		r.Header.Set(OperatorHeader, operator)
+	}
+	return r
+}
+
+// AgentIdentity is the client ID an agent is authenticated as, the user id of its token set by
+// RoleAuthorizer. The SSE server checks it so an agent can neither connect nor ack as another one.
+func AgentIdentity(r *http.Request) (string, error) {
+	clientID := core.GetDataFromContext[string](r.Context(), UserIDContext)
+	if clientID == "" {
+		return "", utility.Unauthenticated("request is not authenticated")
+	}
+	return clientID, nil
+}
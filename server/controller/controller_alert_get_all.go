@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) AlertGetAllHandler(u usecase.AlertGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/alerts",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.Alert]{},
+		Summary:      "List alerts raised by the scan result analyzers",
+		Description:  "Paged 100 alerts at a time by default and at most 1000, newest first unless sort says otherwise",
+		Tag:          "Alert",
+		QueryParams: append([]utility.QueryParam{
+			{Name: "kind", Type: "string", Description: "only alerts of this analyzer, e.g. device_count_drop"},
+			{Name: "client_id", Type: "string", Description: "only alerts about this agent"},
+		}, utility.ListQueryParams(usecase.AlertListFields...)...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.AlertGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
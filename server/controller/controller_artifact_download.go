@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ArtifactDownloadHandler(u usecase.ArtifactDownload) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:      http.MethodGet,
+		Url:         "/api/artifacts/{id}/download",
+		Access:      utility.Public,
+		Summary:     "Download artifact (supports Range)",
+		Description: "Requires expires and signature from the download-url endpoint. Supports Range and If-Range for resuming.",
+		Tag:         "Artifact",
+		QueryParams: []utility.QueryParam{
+			{Name: "expires", Type: "string", Required: true},
+			{Name: "signature", Type: "string", Required: true},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ArtifactDownloadReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		res, err := u(r.Context(), req)
+		if err != nil {
+			utility.Fail(w, err)
+			return
+		}
+		defer res.File.Close()
+
+		// ETag lets clients resume with If-Range without re-downloading a changed file
+		w.Header().Set("ETag", fmt.Sprintf("%q", res.SHA256))
+		w.Header().Set("X-Checksum-SHA256", res.SHA256)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", res.FileName))
+
+		// ServeContent takes care of Range, If-Range and partial content responses
+		http.ServeContent(w, r, res.FileName, res.ModTime, res.File)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ArtifactDownloadURLHandler(u usecase.ArtifactDownloadURL) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/artifacts/{id}/download-url",
+		Access:       model.AccessAgent,
+		ResponseBody: usecase.ArtifactDownloadURLRes{},
+		Summary:      "Get signed download url",
+		Tag:          "Artifact",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ArtifactDownloadURLReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
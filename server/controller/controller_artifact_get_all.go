@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ArtifactGetAllHandler(u usecase.ArtifactGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/artifacts",
+		Access:       model.AccessAgent,
+		ResponseBody: usecase.ArtifactGetAllRes{},
+		Summary:      "List artifacts with checksum",
+		Tag:          "Artifact",
+		QueryParams: []utility.QueryParam{
+			{Name: "kind", Type: "string", Description: "agent or plugin"},
+			{Name: "platform", Type: "string", Description: "target platform"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ArtifactGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ArtifactUploadHandler(u usecase.ArtifactUpload) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/artifacts",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.ArtifactUploadRes{},
+		Summary:      "Upload agent binary or plugin",
+		Tag:          "Artifact",
+		MultipartFormParam: []utility.MultipartFormParam{
+			{Name: "name", Type: "string", Description: "artifact name", Required: true},
+			{Name: "version", Type: "string", Description: "artifact version", Required: true},
+			{Name: "platform", Type: "string", Description: "target platform, e.g. linux/amd64"},
+			{Name: "kind", Type: "string", Description: "agent or plugin", Required: true},
+			{Name: "file", Type: "file", Description: "artifact file", Required: true},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			utility.Fail(w, fmt.Errorf("invalid multipart form: %v", err))
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			utility.Fail(w, fmt.Errorf("file is required"))
+			return
+		}
+		defer file.Close()
+
+		req := usecase.ArtifactUploadReq{
+			Name:     r.FormValue("name"),
+			Version:  r.FormValue("version"),
+			Platform: r.FormValue("platform"),
+			Kind:     r.FormValue("kind"),
+			FileName: header.Filename,
+			Content:  file,
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) ClientGetAllHandler(u usecase.ClientGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/clients",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.Client]{},
+		Summary:      "List the known agents",
+		Description:  "Paged 50 agents at a time by default and at most 500, sorted by client_id unless sort says otherwise",
+		Tag:          "Client",
+		QueryParams:  utility.ListQueryParams(usecase.ClientListFields...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ClientGroupsSetHandler(u usecase.ClientGroupsSet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/clients/{id}/groups",
+		Access:       model.AccessOperator,
+		Body:         usecase.ClientGroupsSetBody{},
+		ResponseBody: usecase.ClientGroupsSetRes{},
+		Summary:      "Assign an agent to its groups",
+		Description:  "The groups replace the ones the agent was in, scan triggers and rollouts target a group through its members. The agent does not have to be connected or registered",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientGroupsSetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ClientHeartbeatHandler(u usecase.ClientHeartbeat) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/clients/heartbeat",
+		Access:       model.AccessAgent,
+		Body:         usecase.ClientHeartbeatBody{},
+		ResponseBody: usecase.ClientPresenceRes{},
+		Summary:      "Mark a registered agent as seen",
+		Description:  "Sent by an agent at the interval it was given on registration, an unregistered agent gets 404 and registers again",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientHeartbeatReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
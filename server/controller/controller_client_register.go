@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ClientRegisterHandler(u usecase.ClientRegister) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/clients/register",
+		Access:       model.AccessAgent,
+		Body:         usecase.ClientRegisterBody{},
+		ResponseBody: usecase.ClientPresenceRes{},
+		Summary:      "Register an agent with its version and platform",
+		Description:  "Sent by an agent when it starts, it registers as the agent of its token, the answer gives the interval of its heartbeats",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientRegisterReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+)
+
+func (c Controller) ClientResourceLimitsSetHandler(u usecase.ClientResourceLimitsSet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/clients/{id}/resource-limits",
+		Access:       model.AccessOperator,
+		Body:         command.ResourceLimits{},
+		ResponseBody: usecase.ClientResourceLimitsSetRes{},
+		Summary:      "Push resource limits to an agent",
+		Description:  "Replaces the CPU, memory and probe rate caps enforced by the agent resource guard, 0 removes a cap",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientResourceLimitsSetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
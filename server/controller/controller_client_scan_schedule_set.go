@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+)
+
+func (c Controller) ClientScanScheduleSetHandler(u usecase.ClientScanScheduleSet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/clients/{id}/scan-schedules",
+		Access:       model.AccessOperator,
+		Body:         command.ScheduleUpdate{},
+		ResponseBody: usecase.ClientScanScheduleSetRes{},
+		Summary:      "Push scan schedules to an agent",
+		Description:  "The agent saves the schedules, runs them on its own even while disconnected and uploads the results, they replace its configured SCAN_SCHEDULES until null schedules are pushed",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientScanScheduleSetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ClientSecretDeleteHandler(u usecase.ClientSecretDelete) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodDelete,
+		Url:          "/api/clients/{id}/secrets/{secret_id}",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.ClientSecretRes{},
+		Summary:      "Delete a scan credential from the secrets store of a connected agent",
+		Tag:          "Client",
+		QueryParams: []utility.QueryParam{
+			{Name: "ack_timeout_ms", Type: "integer", Description: "how long to wait for the agent acknowledgement"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientSecretDeleteReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
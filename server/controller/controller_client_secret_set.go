@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ClientSecretSetHandler(u usecase.ClientSecretSet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/clients/{id}/secrets/{secret_id}",
+		Access:       model.AccessAdmin,
+		Body:         usecase.ClientSecretSetBody{},
+		ResponseBody: usecase.ClientSecretRes{},
+		Summary:      "Store a scan credential in the secrets store of a connected agent",
+		Description:  "The agent encrypts it on disk and scan triggers reference it by id (snmp_credential), the server does not keep it. The operator is the user of the bearer token",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientSecretSetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ClientShutdownHandler(u usecase.ClientControl) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/clients/{id}/shutdown",
+		Access:       model.AccessAdmin,
+		Body:         usecase.ClientControlBody{},
+		ResponseBody: usecase.ClientControlRes{},
+		Summary:      "Shut a connected agent down",
+		Description:  "The agent confirms with an ack then stops, it only comes back when started on its host. The operator is the user of the bearer token and is refused by agents running with AGENT_REMOTE_CONTROL=off",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientControlReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+		req.Action = usecase.ClientShutdown
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) ClientThrottleEventGetAllHandler(u usecase.ClientThrottleEventGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/clients/{id}/throttle-events",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.ClientThrottleEvent]{},
+		Summary:      "List resource guard events of an agent",
+		Description:  "Paged 100 events at a time by default and at most 1000, newest first unless sort says otherwise",
+		Tag:          "Client",
+		QueryParams:  utility.ListQueryParams(usecase.ClientThrottleEventListFields...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientThrottleEventGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
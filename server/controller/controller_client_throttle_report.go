@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ClientThrottleReportHandler(u usecase.ClientThrottleReport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/clients/{id}/throttle-events",
+		Access:       model.AccessAgent,
+		Body:         usecase.ClientThrottleReportBody{},
+		ResponseBody: usecase.ClientThrottleReportRes{},
+		Summary:      "Report a resource guard event",
+		Description:  "Sent by an agent when it throttles workers for CPU, pauses scans for memory, or resumes",
+		Tag:          "Client",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ClientThrottleReportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"shared/utility"
+)
+
+// dashboardFiles is the live dashboard, a static page reading the REST API and the dashboard feed
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+func (c Controller) DashboardHandler() utility.APIData {
+
+	apiData := utility.APIData{
+		Method:      http.MethodGet,
+		Url:         "/dashboard/",
+		Access:      utility.Public,
+		Summary:     "Live dashboard",
+		Description: "Web page of the connected agents, the running jobs and the scan progress. The page opens the dashboard feed with the token of a dashboard user, the feed exists when DASHBOARD_JWT_SECRET is set.",
+		Tag:         "Dashboard",
+		File: &utility.FileResponse{
+			ContentTypes: []string{"text/html"},
+			Description:  "The page, its script and its style sheet",
+		},
+	}
+
+	files, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+
+	c.Mux.Handle(apiData.GetMethodUrl(), http.StripPrefix("/dashboard/", http.FileServerFS(files)))
+
+	return apiData
+}
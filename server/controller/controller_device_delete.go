@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) DeviceDeleteHandler(u usecase.DeviceDelete) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodDelete,
+		Url:          "/api/devices/{id}",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.DeviceDeleteRes{},
+		Summary:      "Remove a device from the inventory",
+		Tag:          "Device",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.DeviceDeleteReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) DeviceExportHandler(u usecase.DeviceExport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:      http.MethodGet,
+		Url:         "/api/devices/export",
+		Access:      model.AccessOperator,
+		Summary:     "Export the device inventory as CSV",
+		Description: "Streams every matching device, without the row limit of GET /api/devices. Columns are id, ip, mac, hostname, vendor, status, first_seen, last_seen, probed_at and client_id",
+		Tag:         "Device",
+		QueryParams: []utility.QueryParam{
+			{Name: "format", Type: "string", Description: "csv, the default"},
+			{Name: "columns", Type: "string", Description: "comma separated or repeated columns in the order wanted, default all"},
+			{Name: "status", Type: "string", Description: "only devices whose latest probe had this status, e.g. Online"},
+			{Name: "search", Type: "string", Description: "part of the ip, hostname, mac or vendor"},
+		},
+		File: &utility.FileResponse{
+			ContentTypes: []string{"text/csv"},
+			Description:  "CSV file with a header row, named devices-<time>.csv",
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.DeviceExportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		res, err := u(r.Context(), req)
+		if err != nil {
+			utility.Fail(w, err)
+			return
+		}
+
+		serveExport(w, r, res)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
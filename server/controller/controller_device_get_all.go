@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) DeviceGetAllHandler(u usecase.DeviceGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/devices",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.Device]{},
+		Summary:      "List the device inventory",
+		Description:  "Paged 100 devices at a time by default and at most 1000, sorted by id unless sort says otherwise",
+		Tag:          "Device",
+		QueryParams: append([]utility.QueryParam{
+			{Name: "status", Type: "string", Description: "only devices whose latest probe had this status, e.g. Online"},
+			{Name: "search", Type: "string", Description: "part of the ip, hostname, mac or vendor"},
+		}, utility.ListQueryParams(usecase.DeviceListFields...)...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.DeviceGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
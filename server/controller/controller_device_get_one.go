@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) DeviceGetOneHandler(u usecase.DeviceGetOne) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/devices/{id}",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.DeviceGetOneRes{},
+		Summary:      "Get a device of the inventory",
+		Tag:          "Device",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.DeviceGetOneReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) DeviceUpdateHandler(u usecase.DeviceUpdate) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/devices/{id}",
+		Access:       model.AccessAdmin,
+		Body:         usecase.DeviceUpdateBody{},
+		ResponseBody: usecase.DeviceUpdateRes{},
+		Summary:      "Update the mac, hostname and vendor of a device",
+		Tag:          "Device",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.DeviceUpdateReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
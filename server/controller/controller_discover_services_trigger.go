@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) DiscoverServicesTriggerHandler(u usecase.DiscoverServicesTrigger) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/discover-services-trigger",
+		Access:       model.AccessOperator,
+		Body:         usecase.DiscoverServicesTriggerReq{},
+		ResponseBody: usecase.DiscoverServicesTriggerRes{},
+		Summary:      "Listen for mDNS and SSDP announcements",
+		Description:  "The agents listen on their segment for window_ms and report printers, cameras, NAS and other announced services to POST /api/clients/{id}/discovered-services",
+		Tag:          "Discovery",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.DiscoverServicesTriggerReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
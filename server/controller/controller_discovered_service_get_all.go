@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) DiscoveredServiceGetAllHandler(u usecase.DiscoveredServiceGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/discovered-services",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.DiscoveredService]{},
+		Summary:      "List services discovered over mDNS and SSDP",
+		Description:  "Paged 100 services at a time by default and at most 1000, sorted by ip, protocol and service_type unless sort says otherwise",
+		Tag:          "Discovery",
+		QueryParams: append([]utility.QueryParam{
+			{Name: "protocol", Type: "string", Description: "mdns or ssdp"},
+			{Name: "ip", Type: "string", Description: "only services announced by this address"},
+			{Name: "client_id", Type: "string", Description: "only services last heard by this agent"},
+			{Name: "search", Type: "string", Description: "part of the service type or name"},
+		}, utility.ListQueryParams(usecase.DiscoveredServiceListFields...)...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.DiscoveredServiceGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
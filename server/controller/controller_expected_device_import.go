@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ExpectedDeviceImportHandler(u usecase.ExpectedDeviceImport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/sites/{id}/expected-devices",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.ExpectedDeviceImportRes{},
+		Summary:      "Import the expected device inventory of a site",
+		Description:  "CSV or XLSX whose first row names the columns ip (required), hostname, mac and description. The file replaces the previous inventory and the site is reconciled right away",
+		Tag:          "Site",
+		MultipartFormParam: []utility.MultipartFormParam{
+			{Name: "file", Type: "file", Description: "inventory as .csv or .xlsx", Required: true},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ExpectedDeviceImportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			utility.Fail(w, fmt.Errorf("invalid multipart form: %v", err))
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			utility.Fail(w, fmt.Errorf("file is required"))
+			return
+		}
+		defer file.Close()
+
+		req.FileName = header.Filename
+		req.Content = file
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) InventoryReconcileHandler(u usecase.InventoryReconcile) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/sites/{id}/reconcile",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.InventoryReconcileRes{},
+		Summary:      "Reconcile the inventory of a site with the discovered devices",
+		Description:  "Lists matched, missing and unexpected devices, inventory_reconciliation_changed is broadcast when the outcome changed since the last run. Every scan upload of an agent of the site reconciles it as well",
+		Tag:          "Site",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.InventoryReconcileReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
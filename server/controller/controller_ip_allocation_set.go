@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) IPAllocationSetHandler(u usecase.IPAllocationSet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/ipam/addresses/{ip}",
+		Access:       model.AccessAdmin,
+		Body:         usecase.IPAllocationSetBody{},
+		ResponseBody: usecase.IPAllocationSetRes{},
+		Summary:      "Mark an address as reserved, assigned or free",
+		Tag:          "IPAM",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.IPAllocationSetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) IPAMAddressGetAllHandler(u usecase.IPAMAddressGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/ipam/subnets/{id}/addresses",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.IPAMAddressGetAllRes{},
+		Summary:      "List the recorded and scanned addresses of a subnet, flagging free or reserved ones that answer",
+		Tag:          "IPAM",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.IPAMAddressGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) IPAMSubnetCreateHandler(u usecase.IPAMSubnetCreate) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/ipam/subnets",
+		Access:       model.AccessAdmin,
+		Body:         usecase.IPAMSubnetCreateReq{},
+		ResponseBody: usecase.IPAMSubnetCreateRes{},
+		Summary:      "Create an IPAM subnet whose addresses can be reserved, assigned or freed",
+		Tag:          "IPAM",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.IPAMSubnetCreateReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) IPAMSubnetGetAllHandler(u usecase.IPAMSubnetGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/ipam/subnets",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.IPAMSubnetGetAllRes{},
+		Summary:      "List the IPAM subnets",
+		Tag:          "IPAM",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.IPAMSubnetGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
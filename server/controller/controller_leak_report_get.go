@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) LeakReportGetHandler(u usecase.LeakReportGet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/admin/leaks",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.LeakReportGetRes{},
+		Summary:      "Goroutine, file descriptor and SSE stream samples of the leak detector",
+		Description:  "Only answers when the server runs with SOAK_TEST=on. failing lists the consistency checks broken right now, warnings the leaks suspected since start",
+		Tag:          "Admin",
+		QueryParams: []utility.QueryParam{
+			{Name: "kind", Type: "string", Description: "filter warnings by kind: goroutines, open_fds or a check name"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.LeakReportGetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) LogLevelSetHandler(u usecase.LogLevelSet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/admin/log-level",
+		Access:       model.AccessAdmin,
+		Body:         usecase.LogLevelSetReq{},
+		ResponseBody: usecase.LogLevelSetRes{},
+		Summary:      "Change the log level of the server and/or agents",
+		Description:  "scope server (default), agents or all; agents receive set_log_level. duration_seconds reverts to the previous level on its own",
+		Tag:          "Admin",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.LogLevelSetReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
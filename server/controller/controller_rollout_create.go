@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) RolloutCreateHandler(u usecase.RolloutCreate) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/rollouts",
+		Access:       model.AccessAdmin,
+		Body:         usecase.RolloutCreateReq{},
+		ResponseBody: usecase.RolloutCreateRes{},
+		Summary:      "Roll a command out to a canary share of the agents first",
+		Description:  "The canary receives the command first, the remaining agents only once min_success_percent of the canary acknowledged within ack_window_ms, otherwise the rollout is aborted. Follow it with GET /api/rollouts/{id}",
+		Tag:          "Rollout",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.RolloutCreateReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
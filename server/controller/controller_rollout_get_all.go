@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) RolloutGetAllHandler(u usecase.RolloutGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/rollouts",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.Rollout]{},
+		Summary:      "List rollouts, newest first",
+		Description:  "Paged 100 rollouts at a time by default and at most 1000, newest first unless sort says otherwise",
+		Tag:          "Rollout",
+		QueryParams: append([]utility.QueryParam{
+			{Name: "status", Type: "string", Description: "canary, proceeding, completed, aborted or failed"},
+		}, utility.ListQueryParams(usecase.RolloutListFields...)...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.RolloutGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"server/model"
 	"server/usecase"
 	"shared/utility"
 )
@@ -9,27 +10,26 @@ import (
 func (c Controller) ScanDevicesTriggerHandler(u usecase.ScanICMPTrigger) utility.APIData {
 
 	apiData := utility.APIData{
-		// Access:  model.MANAJEMEN_PENGGUNA_DAFTAR_PENGGUNA_CREATE,
-		Method:  http.MethodPost,
-		Url:     "/api/scan-devices-trigger",
-		Body:    usecase.ScanICMPTriggerReq{},
-		Summary: "Scan with ICMP By Range",
-		Tag:     "Scan",
+		Method:       http.MethodPost,
+		Url:          "/api/scan-devices-trigger",
+		Access:       model.AccessOperator,
+		Body:         usecase.ScanICMPTriggerBody{},
+		ResponseBody: usecase.ScanICMPTriggerRes{},
+		Summary:      "Scan with ICMP By Range",
+		Description:  "Large ranges are created as pending_approval and need POST /api/scan-jobs/{id}/approve by another operator. A range overlapping a running job on the same agents is rejected, queued or merged per on_conflict. The consistency_token of the response lets GET /api/scan-jobs read the job right away",
+		Tag:          "Scan",
 	}
 
 	handler := func(w http.ResponseWriter, r *http.Request) {
 
-		body, ok := utility.ParseJSON[usecase.ScanICMPTriggerReq](w, r)
+		req, ok := utility.ExtractRequest[usecase.ScanICMPTriggerReq](w, r, apiData.Url)
 		if !ok {
 			return
 		}
 
-		utility.HandleUsecase(r.Context(), w, u, body)
+		utility.HandleUsecase(r.Context(), w, u, req)
 	}
 
-	// authorizationHandler := Authorization(handler)
-	// authenticatedHandler := Authentication(authorizationHandler, c.JWT)
-	// c.Mux.HandleFunc(apiData.GetMethodUrl(), authenticatedHandler)
 	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
 
 	return apiData
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanJobApproveHandler(u usecase.ScanJobApprove) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/scan-jobs/{id}/approve",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.ScanICMPTriggerRes{},
+		Summary:      "Approve a sensitive scan job",
+		Description:  "Dispatches a job waiting in pending_approval, the approving operator, the user of the bearer token, must differ from the requester",
+		Tag:          "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "ack_timeout_ms", Type: "integer", Description: "how long to wait for agent acknowledgements"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanJobApproveReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanJobCancelHandler(u usecase.ScanJobCancel) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/scan-jobs/{id}/cancel",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.ScanICMPTriggerRes{},
+		Summary:      "Cancel a scan job",
+		Description:  "A job pending approval or queued is cancelled at once. A dispatched job is sent scan_cancel, the agents still scanning stop and report cancelled, and the job turns cancelled once every agent reported",
+		Tag:          "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "reason", Type: "string", Description: "why the job is cancelled, recorded in the audit and sent to the agents"},
+			{Name: "ack_timeout_ms", Type: "integer", Description: "how long to wait for agent acknowledgements"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanJobCancelReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
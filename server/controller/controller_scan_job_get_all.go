@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanJobGetAllHandler(u usecase.ScanJobGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/scan-jobs",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.ScanJobGetAllRes{},
+		Summary:      "List scan jobs with agent reports",
+		Tag:          "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "consistency_token", Type: "string", Description: "token returned by a trigger or approval, the read waits until those writes are visible"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanJobGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
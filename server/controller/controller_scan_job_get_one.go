@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanJobGetOneHandler(u usecase.ScanJobGetOne) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/scan-jobs/{id}",
+		Access:       model.AccessOperator,
+		ResponseBody: usecase.ScanJobGetOneRes{},
+		Summary:      "Get scan job with the status and reports of each agent",
+		Tag:          "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "consistency_token", Type: "string", Description: "token returned by a trigger or approval, the read waits until those writes are visible"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanJobGetOneReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanJobReportHandler(u usecase.ScanJobReport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/scan-jobs/{id}/report",
+		Access:       model.AccessAgent,
+		Body:         usecase.ScanJobReportBody{},
+		ResponseBody: usecase.ScanJobReportRes{},
+		Summary:      "Report scan job completion",
+		Description:  "Sent by an agent when it finishes a job, carries expand time, probe time distribution, worker utilization and hosts/sec",
+		Tag:          "Scan",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanJobReportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanJobSummaryReportHandler(u usecase.ScanJobSummaryReport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:      http.MethodGet,
+		Url:         "/api/scan-jobs/{id}/report",
+		Access:      model.AccessOperator,
+		Summary:     "Download the report of a scan job",
+		Description: "Online and offline host counts, response time statistics, the devices the job added to the inventory and the outcome per agent, as an Excel workbook or a PDF",
+		Tag:         "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "format", Type: "string", Description: "xlsx, the default, or pdf"},
+		},
+		File: &utility.FileResponse{
+			ContentTypes: []string{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/pdf"},
+			Description:  "The report, named scan-job-<id>-<time>.xlsx or .pdf",
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanJobSummaryReportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		res, err := u(r.Context(), req)
+		if err != nil {
+			utility.Fail(w, err)
+			return
+		}
+
+		serveExport(w, r, res)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
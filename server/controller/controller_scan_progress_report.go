@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanProgressReportHandler(u usecase.ScanProgressReport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/clients/{id}/scan-progress",
+		Access:       model.AccessAgent,
+		Body:         usecase.ScanProgressReportBody{},
+		ResponseBody: usecase.ScanProgressReportRes{},
+		Summary:      "Report how many addresses of a running scan are done",
+		Description:  "Relayed to the dashboard feed as scan_progress on the site:<id> topic of the agent, or the unassigned topic",
+		Tag:          "Scan",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanProgressReportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanResultExportHandler(u usecase.ScanResultExport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:      http.MethodGet,
+		Url:         "/api/scan-results/export",
+		Access:      model.AccessOperator,
+		Summary:     "Export scan results as CSV",
+		Description: "Streams every matching result, without the row limit of GET /api/scan-results. Columns are id, client_id, job_id, ip, timestamp, protocol, status, response_time, mac, port, open_ports, ttl, os_guess, icmp_mode, sys_name and sys_descr",
+		Tag:         "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "format", Type: "string", Description: "csv, the default"},
+			{Name: "columns", Type: "string", Description: "comma separated or repeated columns in the order wanted, default all"},
+			{Name: "site_id", Type: "integer", Description: "only results reported by agents of this site"},
+			{Name: "client_id", Type: "string", Description: "only results reported by this agent"},
+			{Name: "ip", Type: "string", Description: "exact ip address"},
+			{Name: "job_id", Type: "string", Description: "only results uploaded for this scan job"},
+			{Name: "status", Type: "string", Description: "only results with this status, e.g. Online"},
+			{Name: "since", Type: "string", Description: "only results probed at or after this RFC 3339 time or date"},
+			{Name: "until", Type: "string", Description: "only results probed before this RFC 3339 time or date"},
+		},
+		File: &utility.FileResponse{
+			ContentTypes: []string{"text/csv"},
+			Description:  "CSV file with a header row, named scan-results-<time>.csv",
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanResultExportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		res, err := u(r.Context(), req)
+		if err != nil {
+			utility.Fail(w, err)
+			return
+		}
+
+		serveExport(w, r, res)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) ScanResultGetAllHandler(u usecase.ScanResultGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/scan-results",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.ScanResult]{},
+		Summary:      "List discovered devices",
+		Description:  "Paged 1000 results at a time, also the most a page holds, newest first unless sort says otherwise",
+		Tag:          "Scan",
+		QueryParams: append([]utility.QueryParam{
+			{Name: "site_id", Type: "integer", Description: "only devices reported by agents of this site"},
+			{Name: "client_id", Type: "string", Description: "only devices reported by this agent"},
+			{Name: "ip", Type: "string", Description: "exact ip address"},
+			{Name: "job_id", Type: "string", Description: "only results uploaded for this scan job"},
+		}, utility.ListQueryParams(usecase.ScanResultListFields...)...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanResultGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
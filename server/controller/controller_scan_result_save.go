@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanResultSaveHandler(u usecase.ScanResultSave) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/scan-devices-result",
+		Access:       model.AccessAgent,
+		Body:         []model.ScanResult{},
+		ResponseBody: usecase.ScanResultSaveRes{},
+		Summary:      "Store the results of a finished scan",
+		Description:  "A JSON array of scan results, agents send it through a chunked upload targeting this endpoint",
+		Tag:          "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "client_id", Type: "string", Description: "agent sending the results"},
+			{Name: "job_id", Type: "string", Description: "scan job the results belong to"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanResultSaveReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		// the body is a bare array, which ExtractRequest cannot bind
+		req.ScanResults, ok = utility.ParseJSON[[]model.ScanResult](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) ScanResultStreamHandler(u usecase.ScanResultStream) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/scan-results/stream",
+		Access:       model.AccessAgent,
+		ResponseBody: usecase.ScanResultStreamRes{},
+		Summary:      "Stream scan results as NDJSON",
+		Description:  "One scan result JSON object per line (application/x-ndjson), rows are validated and inserted in batches as they arrive",
+		Tag:          "Scan",
+		QueryParams: []utility.QueryParam{
+			{Name: "client_id", Type: "string", Description: "agent sending the results"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ScanResultStreamReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+		req.Body = r.Body
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
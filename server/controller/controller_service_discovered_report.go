@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+)
+
+func (c Controller) ServiceDiscoveredReportHandler(u usecase.ServiceDiscoveredReport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          command.DiscoverServices.ResultPath,
+		Access:       model.AccessAgent,
+		Body:         usecase.ServiceDiscoveredReportBody{},
+		ResponseBody: usecase.ServiceDiscoveredReportRes{},
+		Summary:      "Report services an agent heard announced",
+		Description:  "Stored per protocol, ip, type and name, and published to the dashboard feed as service_discovered on the site:<id> topic of the agent, or the unassigned topic",
+		Tag:          "Discovery",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.ServiceDiscoveredReportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
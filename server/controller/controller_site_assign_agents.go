@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) SiteAssignAgentsHandler(u usecase.SiteAssignAgents) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/sites/{id}/agents",
+		Access:       model.AccessAdmin,
+		Body:         usecase.SiteAssignAgentsBody{},
+		ResponseBody: usecase.SiteAssignAgentsRes{},
+		Summary:      "Assign agents to a site",
+		Tag:          "Site",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.SiteAssignAgentsReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) SiteCreateHandler(u usecase.SiteCreate) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/sites",
+		Access:       model.AccessAdmin,
+		Body:         usecase.SiteCreateReq{},
+		ResponseBody: usecase.SiteCreateRes{},
+		Summary:      "Create a site with its subnets",
+		Description:  "A subnet may not overlap another subnet of the site or of any other site",
+		Tag:          "Site",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.SiteCreateReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) SSEEventCatalogGetHandler(u usecase.SSEEventCatalogGet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/sse/catalog",
+		Access:       model.AccessAgent,
+		ResponseBody: usecase.SSEEventCatalogGetRes{},
+		Summary:      "SSE event catalog",
+		Description:  "Event types with their direction, payload schema, acknowledgement and required agent capabilities",
+		Tag:          "SSE",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.SSEEventCatalogGetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
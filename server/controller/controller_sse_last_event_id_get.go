@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) SSELastEventIDGetHandler(u usecase.SSELastEventIDGet) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/admin/sse/clients/{id}/last-event-id",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.SSELastEventIDGetRes{},
+		Summary:      "Last SSE event id sent to a client",
+		Description:  "Every frame carries an increasing id: per client, kept across reconnects with the same client id",
+		Tag:          "Admin",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.SSELastEventIDGetReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
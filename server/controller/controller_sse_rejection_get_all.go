@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) SSERejectionGetAllHandler(u usecase.SSERejectionGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/admin/sse/rejections",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.SSERejectionGetAllRes{},
+		Summary:      "Recent rejected SSE connections",
+		Tag:          "Admin",
+		QueryParams: []utility.QueryParam{
+			{Name: "reason", Type: "string", Description: "filter by reason code"},
+		},
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.SSERejectionGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
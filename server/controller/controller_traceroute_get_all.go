@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) TracerouteGetAllHandler(u usecase.TracerouteGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/traceroutes",
+		Access:       model.AccessOperator,
+		ResponseBody: core.ListPage[model.Traceroute]{},
+		Summary:      "List traceroutes, newest first",
+		Description:  "Paged 50 traces at a time by default and at most 500, newest first unless sort says otherwise",
+		Tag:          "Diagnostics",
+		QueryParams: append([]utility.QueryParam{
+			{Name: "client_id", Type: "string", Description: "only traces run by this agent"},
+			{Name: "target", Type: "string", Description: "only traces to this target, as it was requested"},
+		}, utility.ListQueryParams(usecase.TracerouteListFields...)...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.TracerouteGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+)
+
+func (c Controller) TracerouteReportHandler(u usecase.TracerouteReport) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          command.Traceroute.ResultPath,
+		Access:       model.AccessAgent,
+		Body:         usecase.TracerouteReportBody{},
+		ResponseBody: usecase.TracerouteReportRes{},
+		Summary:      "Report the hops of a traceroute",
+		Description:  "Sent by the agent the trace was requested from, error is set when it could not trace at all",
+		Tag:          "Diagnostics",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.TracerouteReportReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) TracerouteTriggerHandler(u usecase.TracerouteTrigger) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/traceroute-trigger",
+		Access:       model.AccessOperator,
+		Body:         usecase.TracerouteTriggerReq{},
+		ResponseBody: usecase.TracerouteTriggerRes{},
+		Summary:      "Trace the path from an agent to a target",
+		Description:  "The agent must be connected, it reports the hops on /api/traceroutes/{id}/report and the trace is read with GET /api/traceroutes/{id}",
+		Tag:          "Diagnostics",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.TracerouteTriggerReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
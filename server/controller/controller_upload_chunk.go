@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) UploadChunkHandler(u usecase.UploadChunk) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPut,
+		Url:          "/api/uploads/{id}/chunks/{index}",
+		Access:       model.AccessAgent,
+		ResponseBody: usecase.UploadChunkRes{},
+		Summary:      "Upload one chunk",
+		Description:  "Body is a JSON array of items, optionally sent with Content-Encoding: gzip",
+		Tag:          "Upload",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.UploadChunkReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 16<<20))
+		if err != nil {
+			utility.Fail(w, fmt.Errorf("failed to read chunk: %v", err))
+			return
+		}
+		req.Data = data
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
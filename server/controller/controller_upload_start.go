@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) UploadStartHandler(u usecase.UploadStart) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/uploads",
+		Access:       model.AccessAgent,
+		Body:         usecase.UploadStartReq{},
+		ResponseBody: usecase.UploadStartRes{},
+		Summary:      "Start or resume chunked upload",
+		Description:  "Returns the chunks already received so the client only re-sends the missing ones. target_path must be an ingest route taking a JSON array (/api/scan-devices-result), completing posts to it with the credentials of the uploader",
+		Tag:          "Upload",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.UploadStartReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) WebhookCreateHandler(u usecase.WebhookCreate) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodPost,
+		Url:          "/api/webhooks",
+		Access:       model.AccessAdmin,
+		Body:         usecase.WebhookCreateReq{},
+		ResponseBody: usecase.WebhookCreateRes{},
+		Summary:      "Register a URL notified of scan events",
+		Description:  "Events are scan_completed and device_discovered. Every delivery is a POST signed with X-Webhook-Signature: sha256= followed by the hex HMAC-SHA256 of X-Webhook-Timestamp, a dot and the body, keyed by the secret returned here only. A delivery not answered with 2xx is retried with exponential backoff",
+		Tag:          "Webhook",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		body, ok := utility.ParseJSON[usecase.WebhookCreateReq](w, r)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, body)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
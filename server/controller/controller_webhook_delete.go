@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+)
+
+func (c Controller) WebhookDeleteHandler(u usecase.WebhookDelete) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodDelete,
+		Url:          "/api/webhooks/{id}",
+		Access:       model.AccessAdmin,
+		ResponseBody: usecase.WebhookDeleteRes{},
+		Summary:      "Remove a webhook, its queued deliveries are dropped",
+		Tag:          "Webhook",
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.WebhookDeleteReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func (c Controller) WebhookDeliveryGetAllHandler(u usecase.WebhookDeliveryGetAll) utility.APIData {
+
+	apiData := utility.APIData{
+		Method:       http.MethodGet,
+		Url:          "/api/webhooks/{id}/deliveries",
+		Access:       model.AccessAdmin,
+		ResponseBody: core.ListPage[model.WebhookDelivery]{},
+		Summary:      "List the deliveries of a webhook with their attempts and last error",
+		Description:  "Paged 100 deliveries at a time by default and at most 1000, newest first unless sort says otherwise",
+		Tag:          "Webhook",
+		QueryParams: append([]utility.QueryParam{
+			{Name: "status", Type: "string", Description: "only deliveries pending, delivered or failed"},
+		}, utility.ListQueryParams(usecase.WebhookDeliveryListFields...)...),
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+
+		req, ok := utility.ExtractRequest[usecase.WebhookDeliveryGetAllReq](w, r, apiData.Url)
+		if !ok {
+			return
+		}
+
+		utility.HandleUsecase(r.Context(), w, u, req)
+	}
+
+	c.Mux.HandleFunc(apiData.GetMethodUrl(), handler)
+
+	return apiData
+}
@@ -0,0 +1,182 @@
+// Live dashboard: agents and jobs are polled from the REST API, scan progress streams from the
+// dashboard feed (/api/dashboard/sse), both with the JWT of a dashboard user.
+(function () {
+  "use strict";
+
+  var REFRESH_MS = 10000;
+  var MAX_EVENTS = 200;
+  var RUNNING = ["dispatched", "queued", "pending_approval"];
+
+  var progress = {}; // job id, or agent id for scheduled scans, to agent id to the latest progress event
+  var source = null;
+  var token = ""; // the JWT entered in the form, sent with every REST call
+
+  function el(id) { return document.getElementById(id); }
+
+  function text(value) {
+    var span = document.createElement("span");
+    span.textContent = value === undefined || value === null ? "" : String(value);
+    return span.innerHTML;
+  }
+
+  function when(value) {
+    if (!value) { return ""; }
+    var date = new Date(value);
+    return isNaN(date) ? "" : date.toLocaleString();
+  }
+
+  function badge(status) {
+    return '<span class="status ' + text(status) + '">' + text(status).replace("_", " ") + "</span>";
+  }
+
+  function rows(tbody, html, columns, empty) {
+    tbody.innerHTML = html.length ? html.join("") : '<tr><td class="empty" colspan="' + columns + '">' + empty + "</td></tr>";
+  }
+
+  function getJSON(url) {
+    if (!token) { return Promise.reject(new Error("enter a token to load the dashboard")); }
+    var headers = { Accept: "application/json", Authorization: "Bearer " + token };
+    return fetch(url, { headers: headers }).then(function (res) {
+      return res.json().then(function (body) {
+        if (body.status !== "success") { throw new Error(body.error || "request failed"); }
+        return body.data;
+      });
+    });
+  }
+
+  function loadAgents() {
+    return getJSON("/api/clients?sort=-last_seen&size=500").then(function (clients) {
+      var online = 0;
+      rows(el("agents"), clients.map(function (c) {
+        if (c.status === "online") { online++; }
+        return "<tr><td>" + text(c.client_id) + "</td><td>" + badge(c.status) + "</td><td>" + text(c.site_id) +
+          "</td><td>" + text(c.hostname) + " " + text(c.os) + "/" + text(c.arch) + "</td><td>" + text(c.version) +
+          "</td><td>" + text((c.commands || []).join(", ")) + "</td><td>" + text(when(c.last_seen)) + "</td></tr>";
+      }), 7, "no agent registered yet");
+      el("agents-summary").textContent = online + " of " + clients.length + " online";
+    });
+  }
+
+  function progressCell(key) {
+    var agents = progress[key] || {};
+    return Object.keys(agents).sort().map(function (clientID) {
+      var p = agents[clientID];
+      var percent = Math.max(0, Math.min(100, p.percent || 0));
+      return '<div class="progress">' + text(clientID) + ": " + text(p.scanned) + "/" + text(p.total) +
+        '<div class="bar"><div style="width:' + percent.toFixed(1) + '%"></div></div></div>';
+    }).join("");
+  }
+
+  function loadJobs() {
+    return getJSON("/api/scan-jobs").then(function (data) {
+      var running = (data.scan_jobs || []).filter(function (job) { return RUNNING.indexOf(job.status) >= 0; });
+      var html = running.map(function (job) {
+        return "<tr><td>" + text(job.job_id) + "</td><td>" + badge(job.status) + "</td><td>" + text(job.method) +
+          "</td><td>" + text(job.ip_range) + "</td><td>" + text(job.requested_by) + "</td><td id=\"progress-" +
+          text(job.job_id) + "\">" + progressCell(job.job_id) + "</td></tr>";
+      });
+      // scheduled scans of the agents have no job, their progress is keyed by the agent
+      Object.keys(progress).filter(function (key) { return key.indexOf("job-") !== 0; }).forEach(function (clientID) {
+        html.push("<tr><td>scheduled</td><td>" + badge("dispatched") + "</td><td></td><td></td><td>" + text(clientID) +
+          "</td><td>" + progressCell(clientID) + "</td></tr>");
+      });
+      rows(el("jobs"), html, 6, "no job running");
+    });
+  }
+
+  function refresh() {
+    Promise.all([loadAgents(), loadJobs()]).catch(function (err) { logEvent("error", err.message); });
+  }
+
+  function logEvent(type, detail) {
+    var list = el("events");
+    var item = document.createElement("li");
+    item.textContent = new Date().toLocaleTimeString() + " " + type + " " + detail;
+    list.insertBefore(item, list.firstChild);
+    while (list.children.length > MAX_EVENTS) { list.removeChild(list.lastChild); }
+  }
+
+  // the payload may come wrapped with its message id, the dashboard feed writes every field in camelCase
+  function payload(event) {
+    try {
+      var data = JSON.parse(event.data);
+      return data && data.data !== undefined && data.event !== undefined ? data.data : data;
+    } catch (e) {
+      return {};
+    }
+  }
+
+  function onProgress(event) {
+    var p = payload(event);
+    var key = p.jobId || p.clientId;
+    if (!key) { return; }
+    progress[key] = progress[key] || {};
+    progress[key][p.clientId] = p;
+    if (p.total && p.scanned >= p.total) {
+      setTimeout(function () { delete progress[key][p.clientId]; loadJobs(); }, REFRESH_MS);
+    }
+    var cell = el("progress-" + key);
+    if (cell) { cell.innerHTML = progressCell(key); } else { loadJobs(); }
+  }
+
+  // topicsOf derives the topics the token may read, a token scoped to sites cannot subscribe to the others
+  function topicsOf() {
+    // the claims carry the user payload as base64 json in "content"
+    var user = {};
+    try {
+      var part = token.split(".")[1].replace(/-/g, "+").replace(/_/g, "/");
+      var claims = JSON.parse(atob(part + "===".slice((part.length + 3) % 4)));
+      user = JSON.parse(atob(claims.content));
+    } catch (e) {
+      return Promise.reject(new Error("token is not a dashboard token"));
+    }
+    var roles = user.roles || [];
+    if (roles.indexOf("admin") < 0) {
+      return Promise.resolve(roles.filter(function (role) { return /^(site|tenant):./.test(role); }));
+    }
+    return getJSON("/api/sites").then(function (data) {
+      return (data.sites || []).map(function (site) { return "site:" + site.ID; }).concat(["unassigned"]);
+    });
+  }
+
+  function setFeedStatus(connected, label) {
+    var status = el("feed-status");
+    status.className = "status " + (connected ? "online" : "offline");
+    status.textContent = label;
+  }
+
+  function connect(entered) {
+    token = entered;
+    refresh();
+    if (source) { source.close(); }
+    topicsOf().then(function (topics) {
+      var url = "/api/dashboard/sse?access_token=" + encodeURIComponent(token) + "&topics=" + encodeURIComponent(topics.join(","));
+      source = new EventSource(url);
+      source.addEventListener("open", function () { setFeedStatus(true, "feed connected, " + topics.length + " topic(s)"); });
+      source.addEventListener("error", function () { setFeedStatus(false, "feed disconnected, retrying"); });
+      source.addEventListener("scan_progress", function (event) {
+        onProgress(event);
+      });
+      ["service_discovered", "traceroute_completed", "inventory_reconciliation_changed"].forEach(function (type) {
+        source.addEventListener(type, function (event) {
+          logEvent(type, JSON.stringify(payload(event)));
+        });
+      });
+    }).catch(function (err) { setFeedStatus(false, err.message); });
+  }
+
+  el("connect").addEventListener("submit", function (event) {
+    event.preventDefault();
+    var entered = el("token").value.trim();
+    sessionStorage.setItem("dashboardToken", entered);
+    if (entered) { connect(entered); }
+  });
+
+  var saved = sessionStorage.getItem("dashboardToken");
+  if (saved) {
+    el("token").value = saved;
+    connect(saved);
+  }
+
+  setInterval(function () { if (token) { refresh(); } }, REFRESH_MS);
+})();
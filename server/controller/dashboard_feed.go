@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/model"
+	"shared/utility"
+)
+
+// DashboardTopicScope verifies the token of a dashboard user connecting to the dashboard feed and
+// limits its subscriptions to the topics of its roles. Browsers cannot set headers on an EventSource
+// so the token may also come as ?access_token=.
+func DashboardTopicScope(jwt utility.JWTTokenizer) func(r *http.Request) (utility.TopicScope, error) {
+	return func(r *http.Request) (utility.TopicScope, error) {
+
+		token := r.URL.Query().Get("access_token")
+		if token == "" {
+			bearerToken, errMessage, ok := GetBearerToken(nil, r)
+			if !ok {
+				return utility.TopicScope{}, fmt.Errorf("%s", errMessage)
+			}
+			token = bearerToken
+		}
+
+		content, err := jwt.VerifyToken(token)
+		if err != nil {
+			return utility.TopicScope{}, fmt.Errorf("unverified token")
+		}
+
+		var payload model.UserTokenPayload
+		if err := json.Unmarshal(content, &payload); err != nil {
+			return utility.TopicScope{}, fmt.Errorf("incorrect token payload")
+		}
+
+		return payload.TopicScope(), nil
+	}
+}
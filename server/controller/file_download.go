@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"server/usecase"
+	"shared/utility"
+)
+
+// serveExport sends an export as an attachment. Once the first bytes are out the status is sent, a
+// failure afterwards can only cut the file short and is logged
+func serveExport(w http.ResponseWriter, r *http.Request, file *usecase.ExportFile) {
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := file.Write(r.Context(), w); err != nil && r.Context().Err() == nil {
+		utility.Errorf("Export %s cut short: %v", file.FileName, err)
+	}
+}
@@ -1,29 +1,36 @@
 package controller
 
 import (
+	"compress/gzip"
 	"context"
+	"io"
 	"net/http"
 	"shared/core"
 	"shared/utility"
 	"strings"
-
-	"github.com/google/uuid"
 )
 
 const requestIDKey core.ContextKey = "REQUEST_ID"
 
-func RequestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		requestID := uuid.New().String()
+// RequestIDMiddleware gives every request an id from idGenerator, returned in X-Request-ID so a report
+// of a failed call can be matched with the server logs
+func RequestIDMiddleware(next http.Handler, idGenerator core.IDGenerator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := idGenerator.NewID()
 		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
 		w.Header().Set("X-Request-ID", requestID)
 		next.ServeHTTP(w, r.WithContext(ctx))
-	}
+	})
 }
 
 const UserIDContext core.ContextKey = "userID"
 
-const UserAccessContext core.ContextKey = "userAccess"
+const UserRolesContext core.ContextKey = "userRoles"
+
+// OperatorHeader identifies who performs an action to the request structs, read with the
+// http:"header(X-Operator)" tag. RoleAuthorizer sets it to the user id of the token it verified and
+// drops the one a caller sent.
+const OperatorHeader = "X-Operator"
 
 func GetBearerToken(w http.ResponseWriter, r *http.Request) (string, string, bool) {
 
@@ -40,61 +47,28 @@ func GetBearerToken(w http.ResponseWriter, r *http.Request) (string, string, boo
 	return bearerToken[1], "", true
 }
 
-func Authentication(next http.HandlerFunc, jwt utility.JWTTokenizer) http.HandlerFunc {
-
-	return func(w http.ResponseWriter, r *http.Request) {
-
-		// bearerToken, errMessage, ok := GetBearerToken(w, r)
-		// if !ok {
-		// 	utility.WriteJSON(w, http.StatusUnauthorized, utility.Response{Status: "failed", Error: &errMessage})
-		// 	return
-		// }
+// Decompress transparently inflates gzip encoded request bodies so handlers always read plain JSON
+func Decompress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		// content, err := jwt.VerifyToken(bearerToken)
-		// if err != nil {
-		// 	msg := "unverified token"
-		// 	utility.WriteJSON(w, http.StatusUnauthorized, utility.Response{Status: "failed", Error: &msg})
-		// 	return
-		// }
+		if !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-		// var userTokenPayload model.UserTokenPayload
-		// if err := json.Unmarshal(content, &userTokenPayload); err != nil {
-		// 	msg := "incorrect token payload"
-		// 	utility.WriteJSON(w, http.StatusUnauthorized, utility.Response{Status: "failed", Error: &msg})
-		// 	return
-		// }
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			msg := "invalid gzip body"
+			utility.WriteJSON(w, http.StatusBadRequest, utility.Response{Status: "failed", Error: &msg})
+			return
+		}
+		defer reader.Close()
 
-		// ctx := core.AttachDataToContext(r.Context(), UserAccessContext, userTokenPayload.UserAccess)
-		// ctx = core.AttachDataToContext(ctx, UserIDContext, userTokenPayload.UserID)
-
-		// r = r.WithContext(ctx)
+		r.Body = io.NopCloser(reader)
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
 
 		next.ServeHTTP(w, r)
-
-	}
-
-}
-
-// func Authorization(next http.HandlerFunc, access model.Access) http.HandlerFunc {
-func Authorization(next http.HandlerFunc) http.HandlerFunc {
-
-	return func(w http.ResponseWriter, r *http.Request) {
-
-		// // by pass
-		// if access == "0" {
-		// 	next.ServeHTTP(w, r)
-		// 	return
-		// }
-
-		// userAccess := core.GetDataFromContext(r.Context(), UserAccessContext, model.UserAccess(""))
-
-		// if !userAccess.HasAccess(access) {
-		// 	msg := "unauthorized operation"
-		// 	utility.WriteJSON(w, http.StatusForbidden, utility.Response{Status: "failed", Error: &msg})
-		// 	return
-		// }
-
-		next.ServeHTTP(w, r)
-
-	}
+	})
 }
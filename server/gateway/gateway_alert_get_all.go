@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type AlertGetAllReq struct {
+	Kind     string         // optional
+	ClientID string         // optional
+	List     core.ListQuery // optional, all the alerts when the size is 0
+}
+
+type AlertGetAllRes struct {
+	Alerts []model.Alert
+	Total  int64 // alerts matching the filters, ignoring the page
+}
+
+type AlertGetAll = core.ActionHandler[AlertGetAllReq, AlertGetAllRes]
+
+// AlertListColumns are the fields the alerts can be sorted and filtered by, with their column
+var AlertListColumns = map[string]string{
+	"id":         "id",
+	"kind":       "kind",
+	"severity":   "severity",
+	"client_id":  "client_id",
+	"raised_at":  "raised_at",
+	"created_at": "created_at",
+}
+
+func ImplAlertGetAllWithSQlite(db *gorm.DB) AlertGetAll {
+	return func(ctx context.Context, req AlertGetAllReq) (*AlertGetAllRes, error) {
+
+		var alerts []model.Alert
+
+		query := utility.GetDBFromContext(ctx, db).Model(&model.Alert{})
+		if req.Kind != "" {
+			query = query.Where("kind = ?", req.Kind)
+		}
+		if req.ClientID != "" {
+			query = query.Where("client_id = ?", req.ClientID)
+		}
+
+		total, err := utility.ListPage(query, req.List, AlertListColumns, &alerts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AlertGetAllRes{Alerts: alerts, Total: total}, nil
+	}
+}
@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type AlertSaveReq struct {
+	Alert *model.Alert
+}
+
+type AlertSaveRes struct{}
+
+type AlertSave = core.ActionHandler[AlertSaveReq, AlertSaveRes]
+
+func ImplAlertSaveWithSQlite(db *gorm.DB) AlertSave {
+	return func(ctx context.Context, req AlertSaveReq) (*AlertSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Create(req.Alert).Error; err != nil {
+			return nil, err
+		}
+
+		return &AlertSaveRes{}, nil
+	}
+}
@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ArtifactGetAllReq struct {
+	Kind     string
+	Platform string
+}
+
+type ArtifactGetAllRes struct {
+	Artifacts []model.Artifact
+}
+
+type ArtifactGetAll = core.ActionHandler[ArtifactGetAllReq, ArtifactGetAllRes]
+
+func ImplArtifactGetAllWithSQlite(db *gorm.DB) ArtifactGetAll {
+	return func(ctx context.Context, req ArtifactGetAllReq) (*ArtifactGetAllRes, error) {
+
+		var artifacts []model.Artifact
+
+		query := utility.GetDBFromContext(ctx, db)
+		if req.Kind != "" {
+			query = query.Where("kind = ?", req.Kind)
+		}
+		if req.Platform != "" {
+			query = query.Where("platform = ?", req.Platform)
+		}
+
+		if err := query.Order("id desc").Find(&artifacts).Error; err != nil {
+			return nil, err
+		}
+
+		return &ArtifactGetAllRes{Artifacts: artifacts}, nil
+	}
+}
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ArtifactGetOneReq struct {
+	ID uint
+}
+
+type ArtifactGetOneRes struct {
+	Artifact *model.Artifact // nil when not found
+}
+
+type ArtifactGetOne = core.ActionHandler[ArtifactGetOneReq, ArtifactGetOneRes]
+
+func ImplArtifactGetOneWithSQlite(db *gorm.DB) ArtifactGetOne {
+	return func(ctx context.Context, req ArtifactGetOneReq) (*ArtifactGetOneRes, error) {
+
+		var artifact model.Artifact
+
+		err := utility.GetDBFromContext(ctx, db).First(&artifact, req.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ArtifactGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &ArtifactGetOneRes{Artifact: &artifact}, nil
+	}
+}
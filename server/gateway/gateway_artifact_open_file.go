@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"shared/core"
+	"time"
+)
+
+type ArtifactOpenFileReq struct {
+	Path string
+}
+
+type ArtifactOpenFileRes struct {
+	File    *os.File
+	ModTime time.Time
+}
+
+type ArtifactOpenFile = core.ActionHandler[ArtifactOpenFileReq, ArtifactOpenFileRes]
+
+func ImplArtifactOpenFileOnDisk() ArtifactOpenFile {
+	return func(ctx context.Context, req ArtifactOpenFileReq) (*ArtifactOpenFileRes, error) {
+
+		file, err := os.Open(req.Path)
+		if err != nil {
+			return nil, fmt.Errorf("artifact file not available")
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		return &ArtifactOpenFileRes{File: file, ModTime: info.ModTime()}, nil
+	}
+}
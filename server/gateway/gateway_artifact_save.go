@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ArtifactSaveReq struct {
+	Artifact *model.Artifact
+}
+
+type ArtifactSaveRes struct{}
+
+type ArtifactSave = core.ActionHandler[ArtifactSaveReq, ArtifactSaveRes]
+
+func ImplArtifactSaveWithSQlite(db *gorm.DB) ArtifactSave {
+	return func(ctx context.Context, req ArtifactSaveReq) (*ArtifactSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Save(req.Artifact).Error; err != nil {
+			return nil, err
+		}
+
+		return &ArtifactSaveRes{}, nil
+	}
+}
@@ -0,0 +1,64 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"shared/core"
+)
+
+type ArtifactStoreFileReq struct {
+	FileName string
+	Content  io.Reader
+}
+
+type ArtifactStoreFileRes struct {
+	Path   string
+	Size   int64
+	SHA256 string
+}
+
+type ArtifactStoreFile = core.ActionHandler[ArtifactStoreFileReq, ArtifactStoreFileRes]
+
+// ImplArtifactStoreFileOnDisk writes the artifact into baseDir and computes its checksum while copying
+func ImplArtifactStoreFileOnDisk(baseDir string) ArtifactStoreFile {
+	return func(ctx context.Context, req ArtifactStoreFileReq) (*ArtifactStoreFileRes, error) {
+
+		if err := os.MkdirAll(baseDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+		}
+
+		tmp, err := os.CreateTemp(baseDir, "upload-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+
+		hasher := sha256.New()
+		size, err := io.Copy(io.MultiWriter(tmp, hasher), req.Content)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write artifact: %w", err)
+		}
+
+		checksum := hex.EncodeToString(hasher.Sum(nil))
+
+		// file is stored by checksum so re-uploading the same content is idempotent
+		path := filepath.Join(baseDir, checksum+"-"+filepath.Base(req.FileName))
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return nil, fmt.Errorf("failed to move artifact: %w", err)
+		}
+
+		return &ArtifactStoreFileRes{
+			Path:   path,
+			Size:   size,
+			SHA256: checksum,
+		}, nil
+	}
+}
@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClientAssignSiteReq struct {
+	ClientIDs []string
+	SiteID    *uint // nil removes the agents from their site
+}
+
+type ClientAssignSiteRes struct{}
+
+type ClientAssignSite = core.ActionHandler[ClientAssignSiteReq, ClientAssignSiteRes]
+
+func ImplClientAssignSiteWithSQlite(db *gorm.DB) ClientAssignSite {
+	return func(ctx context.Context, req ClientAssignSiteReq) (*ClientAssignSiteRes, error) {
+
+		if len(req.ClientIDs) == 0 {
+			return &ClientAssignSiteRes{}, nil
+		}
+
+		clients := make([]model.Client, 0, len(req.ClientIDs))
+		for _, clientID := range req.ClientIDs {
+			clients = append(clients, model.Client{ClientID: clientID, SiteID: req.SiteID})
+		}
+
+		// agents do not have to be known before they are placed on a site
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"site_id", "updated_at"}),
+		}).Create(&clients).Error; err != nil {
+			return nil, err
+		}
+
+		return &ClientAssignSiteRes{}, nil
+	}
+}
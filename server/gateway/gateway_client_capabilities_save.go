@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClientCapabilitiesSaveReq struct {
+	ClientID       string
+	Commands       []string
+	Capabilities   []string
+	MaxConcurrency int
+}
+
+type ClientCapabilitiesSaveRes struct{}
+
+type ClientCapabilitiesSave = core.ActionHandler[ClientCapabilitiesSaveReq, ClientCapabilitiesSaveRes]
+
+// ImplClientCapabilitiesSaveWithSQlite stores what the agent announced on connect, the agent does not
+// have to be registered yet
+func ImplClientCapabilitiesSaveWithSQlite(db *gorm.DB) ClientCapabilitiesSave {
+	return func(ctx context.Context, req ClientCapabilitiesSaveReq) (*ClientCapabilitiesSaveRes, error) {
+
+		client := model.Client{
+			ClientID:       req.ClientID,
+			Commands:       req.Commands,
+			Capabilities:   req.Capabilities,
+			MaxConcurrency: req.MaxConcurrency,
+		}
+
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"commands", "capabilities", "max_concurrency", "updated_at"}),
+		}).Create(&client).Error; err != nil {
+			return nil, err
+		}
+
+		return &ClientCapabilitiesSaveRes{}, nil
+	}
+}
@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ClientDuplicateDeleteReq struct{}
+
+type ClientDuplicateDeleteRes struct {
+	Deleted int64
+}
+
+type ClientDuplicateDelete = core.ActionHandler[ClientDuplicateDeleteReq, ClientDuplicateDeleteRes]
+
+// ImplClientDuplicateDeleteWithSQlite keeps the newest row of every client_id and deletes the others,
+// soft deleted rows included since the unique index covers them too. A database without the table
+// yet has nothing to delete.
+func ImplClientDuplicateDeleteWithSQlite(db *gorm.DB) ClientDuplicateDelete {
+	return func(ctx context.Context, req ClientDuplicateDeleteReq) (*ClientDuplicateDeleteRes, error) {
+
+		tx := utility.GetDBFromContext(ctx, db)
+		if !tx.Migrator().HasTable(&model.Client{}) {
+			return &ClientDuplicateDeleteRes{}, nil
+		}
+
+		newest := tx.Unscoped().Model(&model.Client{}).Select("MAX(id)").Group("client_id")
+
+		result := tx.Unscoped().Where("id NOT IN (?)", newest).Delete(&model.Client{})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return &ClientDuplicateDeleteRes{Deleted: result.RowsAffected}, nil
+	}
+}
@@ -10,23 +10,48 @@ import (
 )
 
 type ClientGetAllReq struct {
+	SiteID    uint           // optional, only agents assigned to this site
+	ClientIDs []string       // optional, only these agents
+	List      core.ListQuery // optional, all the agents when the size is 0
 }
 
 type ClientGetAllRes struct {
 	Clients []model.Client
+	Total   int64 // agents matching the filters, ignoring the page
 }
 
 type ClientGetAll = core.ActionHandler[ClientGetAllReq, ClientGetAllRes]
 
+// ClientListColumns are the fields the agents can be sorted and filtered by, with their column
+var ClientListColumns = map[string]string{
+	"client_id":  "client_id",
+	"site_id":    "site_id",
+	"version":    "version",
+	"os":         "os",
+	"hostname":   "hostname",
+	"last_seen":  "last_seen",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 func ImplClientGetAllWithSQlite(db *gorm.DB) ClientGetAll {
 	return func(ctx context.Context, req ClientGetAllReq) (*ClientGetAllRes, error) {
 
 		var clients []model.Client
 
-		if err := utility.GetDBFromContext(ctx, db).Find(&clients).Error; err != nil {
+		query := utility.GetDBFromContext(ctx, db).Model(&model.Client{})
+		if req.SiteID != 0 {
+			query = query.Where("site_id = ?", req.SiteID)
+		}
+		if len(req.ClientIDs) > 0 {
+			query = query.Where("client_id IN ?", req.ClientIDs)
+		}
+
+		total, err := utility.ListPage(query, req.List, ClientListColumns, &clients)
+		if err != nil {
 			return nil, err
 		}
 
-		return &ClientGetAllRes{Clients: clients}, nil
+		return &ClientGetAllRes{Clients: clients, Total: total}, nil
 	}
 }
@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"errors"
 	"server/model"
 	"server/utility"
 	"shared/core"
@@ -14,7 +15,7 @@ type ClientGetOneReq struct {
 }
 
 type ClientGetOneRes struct {
-	Client model.Client
+	Client *model.Client // nil when the agent never registered
 }
 
 type ClientGetOne = core.ActionHandler[ClientGetOneReq, ClientGetOneRes]
@@ -24,10 +25,14 @@ func ImplClientGetOneWithSQlite(db *gorm.DB) ClientGetOne {
 
 		var client model.Client
 
-		if err := utility.GetDBFromContext(ctx, db).First(&client, req.ClientID).Error; err != nil {
+		err := utility.GetDBFromContext(ctx, db).Where("client_id = ?", req.ClientID).First(&client).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ClientGetOneRes{}, nil
+		}
+		if err != nil {
 			return nil, err
 		}
 
-		return &ClientGetOneRes{Client: client}, nil
+		return &ClientGetOneRes{Client: &client}, nil
 	}
 }
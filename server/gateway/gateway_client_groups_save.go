@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClientGroupsSaveReq struct {
+	ClientID string
+	Groups   []string
+}
+
+type ClientGroupsSaveRes struct{}
+
+type ClientGroupsSave = core.ActionHandler[ClientGroupsSaveReq, ClientGroupsSaveRes]
+
+// ImplClientGroupsSaveWithSQlite replaces the groups of an agent, like a site the agent does not have
+// to be known yet
+func ImplClientGroupsSaveWithSQlite(db *gorm.DB) ClientGroupsSave {
+	return func(ctx context.Context, req ClientGroupsSaveReq) (*ClientGroupsSaveRes, error) {
+
+		client := model.Client{
+			ClientID: req.ClientID,
+			Groups:   req.Groups,
+		}
+
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"groups", "updated_at"}),
+		}).Create(&client).Error; err != nil {
+			return nil, err
+		}
+
+		return &ClientGroupsSaveRes{}, nil
+	}
+}
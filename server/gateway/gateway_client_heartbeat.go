@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ClientHeartbeatReq struct {
+	ClientID string
+	Now      time.Time
+}
+
+type ClientHeartbeatRes struct {
+	Registered bool // false when the agent has no registration to update
+}
+
+type ClientHeartbeat = core.ActionHandler[ClientHeartbeatReq, ClientHeartbeatRes]
+
+func ImplClientHeartbeatWithSQlite(db *gorm.DB) ClientHeartbeat {
+	return func(ctx context.Context, req ClientHeartbeatReq) (*ClientHeartbeatRes, error) {
+
+		result := utility.GetDBFromContext(ctx, db).
+			Model(&model.Client{}).
+			Where("client_id = ? AND registered_at IS NOT NULL", req.ClientID).
+			Update("last_seen", req.Now)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return &ClientHeartbeatRes{Registered: result.RowsAffected > 0}, nil
+	}
+}
@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClientRegisterReq struct {
+	ClientID string
+	Version  string
+	OS       string
+	Arch     string
+	Hostname string
+	Now      time.Time
+}
+
+type ClientRegisterRes struct{}
+
+type ClientRegister = core.ActionHandler[ClientRegisterReq, ClientRegisterRes]
+
+// ImplClientRegisterWithSQlite stores the identity of the agent, an agent already placed on a site
+// keeps its site
+func ImplClientRegisterWithSQlite(db *gorm.DB) ClientRegister {
+	return func(ctx context.Context, req ClientRegisterReq) (*ClientRegisterRes, error) {
+
+		client := model.Client{
+			ClientID:     req.ClientID,
+			Version:      req.Version,
+			OS:           req.OS,
+			Arch:         req.Arch,
+			Hostname:     req.Hostname,
+			RegisteredAt: &req.Now,
+			LastSeen:     &req.Now,
+		}
+
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"version", "os", "arch", "hostname", "registered_at", "last_seen", "updated_at"}),
+		}).Create(&client).Error; err != nil {
+			return nil, err
+		}
+
+		return &ClientRegisterRes{}, nil
+	}
+}
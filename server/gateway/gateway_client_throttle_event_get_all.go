@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ClientThrottleEventGetAllReq struct {
+	ClientID string
+	List     core.ListQuery // optional, all the events when the size is 0
+}
+
+type ClientThrottleEventGetAllRes struct {
+	Events []model.ClientThrottleEvent
+	Total  int64 // events matching the filters, ignoring the page
+}
+
+type ClientThrottleEventGetAll = core.ActionHandler[ClientThrottleEventGetAllReq, ClientThrottleEventGetAllRes]
+
+// ClientThrottleEventListColumns are the fields the throttle events can be sorted and filtered by, with their column
+var ClientThrottleEventListColumns = map[string]string{
+	"id":         "id",
+	"action":     "action",
+	"at":         "at",
+	"created_at": "created_at",
+}
+
+func ImplClientThrottleEventGetAllWithSQlite(db *gorm.DB) ClientThrottleEventGetAll {
+	return func(ctx context.Context, req ClientThrottleEventGetAllReq) (*ClientThrottleEventGetAllRes, error) {
+
+		var events []model.ClientThrottleEvent
+
+		query := utility.GetDBFromContext(ctx, db).Model(&model.ClientThrottleEvent{}).Where("client_id = ?", req.ClientID)
+
+		total, err := utility.ListPage(query, req.List, ClientThrottleEventListColumns, &events)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ClientThrottleEventGetAllRes{Events: events, Total: total}, nil
+	}
+}
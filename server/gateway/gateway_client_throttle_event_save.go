@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ClientThrottleEventSaveReq struct {
+	Event *model.ClientThrottleEvent
+}
+
+type ClientThrottleEventSaveRes struct{}
+
+type ClientThrottleEventSave = core.ActionHandler[ClientThrottleEventSaveReq, ClientThrottleEventSaveRes]
+
+func ImplClientThrottleEventSaveWithSQlite(db *gorm.DB) ClientThrottleEventSave {
+	return func(ctx context.Context, req ClientThrottleEventSaveReq) (*ClientThrottleEventSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Create(req.Event).Error; err != nil {
+			return nil, err
+		}
+
+		return &ClientThrottleEventSaveRes{}, nil
+	}
+}
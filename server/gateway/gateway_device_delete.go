@@ -0,0 +1,32 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type DeviceDeleteReq struct {
+	ID uint
+}
+
+type DeviceDeleteRes struct {
+	Deleted bool
+}
+
+type DeviceDelete = core.ActionHandler[DeviceDeleteReq, DeviceDeleteRes]
+
+func ImplDeviceDeleteWithSQlite(db *gorm.DB) DeviceDelete {
+	return func(ctx context.Context, req DeviceDeleteReq) (*DeviceDeleteRes, error) {
+
+		result := utility.GetDBFromContext(ctx, db).Delete(&model.Device{}, req.ID)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return &DeviceDeleteRes{Deleted: result.RowsAffected > 0}, nil
+	}
+}
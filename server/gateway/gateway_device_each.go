@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DeviceEachReq struct {
+	Status string // optional
+	Search string // optional, matches part of the ip, hostname, mac or vendor
+	// FirstSeenSince keeps the devices first found online at or after it, optional
+	FirstSeenSince time.Time
+	// Each is called for every matching device in id order, an error stops the walk and is returned
+	Each func(device model.Device) error
+}
+
+type DeviceEachRes struct {
+	Rows int
+}
+
+type DeviceEach = core.ActionHandler[DeviceEachReq, DeviceEachRes]
+
+// ImplDeviceEachWithSQlite walks the matching devices in batches of scanResultEachBatch
+func ImplDeviceEachWithSQlite(db *gorm.DB) DeviceEach {
+	return func(ctx context.Context, req DeviceEachReq) (*DeviceEachRes, error) {
+
+		query := utility.GetDBFromContext(ctx, db).WithContext(ctx)
+		if req.Status != "" {
+			query = query.Where("status = ?", req.Status)
+		}
+		if !req.FirstSeenSince.IsZero() {
+			query = query.Where("first_seen >= ?", req.FirstSeenSince)
+		}
+		if req.Search != "" {
+			like := "%" + req.Search + "%"
+			query = query.Where("ip LIKE ? OR hostname LIKE ? OR mac LIKE ? OR vendor LIKE ?", like, like, like, like)
+		}
+
+		res := DeviceEachRes{}
+
+		var batch []model.Device
+		result := query.FindInBatches(&batch, scanResultEachBatch, func(tx *gorm.DB, _ int) error {
+			for _, device := range batch {
+				if err := req.Each(device); err != nil {
+					return err
+				}
+				res.Rows++
+			}
+			return nil
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return &res, nil
+	}
+}
@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type DeviceGetAllReq struct {
+	Status string         // optional
+	Search string         // optional, matches part of the ip, hostname, mac or vendor
+	List   core.ListQuery // optional, all the devices when the size is 0
+}
+
+type DeviceGetAllRes struct {
+	Devices []model.Device
+	Total   int64 // devices matching the filters, ignoring the page
+}
+
+type DeviceGetAll = core.ActionHandler[DeviceGetAllReq, DeviceGetAllRes]
+
+// DeviceListColumns are the fields the devices can be sorted and filtered by, with their column
+var DeviceListColumns = map[string]string{
+	"id":         "id",
+	"ip":         "ip",
+	"mac":        "mac",
+	"hostname":   "hostname",
+	"vendor":     "vendor",
+	"status":     "status",
+	"client_id":  "client_id",
+	"first_seen": "first_seen",
+	"last_seen":  "last_seen",
+	"probed_at":  "probed_at",
+}
+
+func ImplDeviceGetAllWithSQlite(db *gorm.DB) DeviceGetAll {
+	return func(ctx context.Context, req DeviceGetAllReq) (*DeviceGetAllRes, error) {
+
+		var devices []model.Device
+
+		query := utility.GetDBFromContext(ctx, db).Model(&model.Device{})
+		if req.Status != "" {
+			query = query.Where("status = ?", req.Status)
+		}
+		if req.Search != "" {
+			like := "%" + req.Search + "%"
+			query = query.Where("ip LIKE ? OR hostname LIKE ? OR mac LIKE ? OR vendor LIKE ?", like, like, like, like)
+		}
+
+		total, err := utility.ListPage(query, req.List, DeviceListColumns, &devices)
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeviceGetAllRes{Devices: devices, Total: total}, nil
+	}
+}
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type DeviceGetOneReq struct {
+	ID uint
+}
+
+type DeviceGetOneRes struct {
+	Device *model.Device // nil when not found
+}
+
+type DeviceGetOne = core.ActionHandler[DeviceGetOneReq, DeviceGetOneRes]
+
+func ImplDeviceGetOneWithSQlite(db *gorm.DB) DeviceGetOne {
+	return func(ctx context.Context, req DeviceGetOneReq) (*DeviceGetOneRes, error) {
+
+		var device model.Device
+
+		err := utility.GetDBFromContext(ctx, db).First(&device, req.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &DeviceGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeviceGetOneRes{Device: &device}, nil
+	}
+}
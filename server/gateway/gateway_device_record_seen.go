@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type DeviceRecordSeenReq struct {
+	ScanResults []model.ScanResult
+}
+
+type DeviceRecordSeenRes struct {
+	Created []model.Device // devices the results added to the inventory
+}
+
+type DeviceRecordSeen = core.ActionHandler[DeviceRecordSeenReq, DeviceRecordSeenRes]
+
+// ImplDeviceRecordSeenWithSQlite folds scan results into the inventory. An online result creates the
+// device when the address is unknown, other results only update devices already known, so scanning
+// a large empty range does not fill the inventory. Results older than the latest probe are ignored.
+func ImplDeviceRecordSeenWithSQlite(db *gorm.DB) DeviceRecordSeen {
+	return func(ctx context.Context, req DeviceRecordSeenReq) (*DeviceRecordSeenRes, error) {
+
+		if len(req.ScanResults) == 0 {
+			return &DeviceRecordSeenRes{}, nil
+		}
+
+		// the latest result per address, an upload may probe the same address more than once
+		latest := map[string]model.ScanResult{}
+		online := map[string]model.ScanResult{} // latest online result per address
+		for _, result := range req.ScanResults {
+			if current, ok := latest[result.IP]; !ok || !result.Timestamp.Before(current.Timestamp) {
+				latest[result.IP] = result
+			}
+			if result.Status != model.ScanResultOnline {
+				continue
+			}
+			if current, ok := online[result.IP]; !ok || !result.Timestamp.Before(current.Timestamp) {
+				online[result.IP] = result
+			}
+		}
+
+		ips := make([]string, 0, len(latest))
+		for ip := range latest {
+			ips = append(ips, ip)
+		}
+
+		res := DeviceRecordSeenRes{}
+
+		err := utility.GetDBFromContext(ctx, db).Transaction(func(tx *gorm.DB) error {
+
+			var devices []model.Device
+			if err := tx.Where("ip IN ?", ips).Find(&devices).Error; err != nil {
+				return err
+			}
+			known := make(map[string]*model.Device, len(devices))
+			for i := range devices {
+				known[devices[i].IP] = &devices[i]
+			}
+
+			for _, ip := range ips {
+				result := latest[ip]
+				seen, isOnline := online[ip]
+
+				device, ok := known[ip]
+				if !ok {
+					if !isOnline {
+						continue
+					}
+					device = &model.Device{IP: ip, FirstSeen: seen.Timestamp}
+				}
+
+				if isOnline && seen.Timestamp.After(device.LastSeen) {
+					device.LastSeen = seen.Timestamp
+				}
+				// the hardware address only an agent on the same segment sees, a MAC set by an operator is kept
+				if isOnline && seen.MAC != "" && device.MAC == "" {
+					device.MAC = seen.MAC
+				}
+				// the name the device gives itself, a name set by an operator is kept
+				if identity, ok := seen.SNMPIdentity(); isOnline && ok && device.Hostname == "" {
+					device.Hostname = identity.SysName
+				}
+				if !result.Timestamp.Before(device.ProbedAt) {
+					device.Status = result.Status
+					device.ProbedAt = result.Timestamp
+					device.ClientID = result.ClientID
+				}
+
+				if err := tx.Save(device).Error; err != nil {
+					return err
+				}
+				if !ok {
+					res.Created = append(res.Created, *device)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &res, nil
+	}
+}
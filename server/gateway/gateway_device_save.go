@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type DeviceSaveReq struct {
+	Device *model.Device
+}
+
+type DeviceSaveRes struct{}
+
+type DeviceSave = core.ActionHandler[DeviceSaveReq, DeviceSaveRes]
+
+func ImplDeviceSaveWithSQlite(db *gorm.DB) DeviceSave {
+	return func(ctx context.Context, req DeviceSaveReq) (*DeviceSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Save(req.Device).Error; err != nil {
+			return nil, err
+		}
+
+		return &DeviceSaveRes{}, nil
+	}
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type DiscoveredServiceGetAllReq struct {
+	Protocol string         // optional
+	IP       string         // optional
+	ClientID string         // optional
+	Search   string         // optional, matches part of the service type or name
+	List     core.ListQuery // optional, all the services when the size is 0
+}
+
+type DiscoveredServiceGetAllRes struct {
+	Services []model.DiscoveredService
+	Total    int64 // services matching the filters, ignoring the page
+}
+
+type DiscoveredServiceGetAll = core.ActionHandler[DiscoveredServiceGetAllReq, DiscoveredServiceGetAllRes]
+
+// DiscoveredServiceListColumns are the fields the services can be sorted and filtered by, with their column
+var DiscoveredServiceListColumns = map[string]string{
+	"id":           "id",
+	"protocol":     "protocol",
+	"ip":           "ip",
+	"service_type": "service_type",
+	"name":         "name",
+	"port":         "port",
+	"client_id":    "client_id",
+	"first_seen":   "first_seen",
+	"last_seen":    "last_seen",
+}
+
+func ImplDiscoveredServiceGetAllWithSQlite(db *gorm.DB) DiscoveredServiceGetAll {
+	return func(ctx context.Context, req DiscoveredServiceGetAllReq) (*DiscoveredServiceGetAllRes, error) {
+
+		var services []model.DiscoveredService
+
+		query := utility.GetDBFromContext(ctx, db).Model(&model.DiscoveredService{})
+		if req.Protocol != "" {
+			query = query.Where("protocol = ?", req.Protocol)
+		}
+		if req.IP != "" {
+			query = query.Where("ip = ?", req.IP)
+		}
+		if req.ClientID != "" {
+			query = query.Where("client_id = ?", req.ClientID)
+		}
+		if req.Search != "" {
+			like := "%" + req.Search + "%"
+			query = query.Where("service_type LIKE ? OR name LIKE ?", like, like)
+		}
+
+		total, err := utility.ListPage(query, req.List, DiscoveredServiceListColumns, &services)
+		if err != nil {
+			return nil, err
+		}
+
+		return &DiscoveredServiceGetAllRes{Services: services, Total: total}, nil
+	}
+}
@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type DiscoveredServiceSaveReq struct {
+	Services []model.DiscoveredService
+}
+
+type DiscoveredServiceSaveRes struct {
+	Services []model.DiscoveredService // as stored, with their id and first seen
+	Created  int
+}
+
+type DiscoveredServiceSave = core.ActionHandler[DiscoveredServiceSaveReq, DiscoveredServiceSaveRes]
+
+// ImplDiscoveredServiceSaveWithSQlite upserts services on protocol, ip, type and name, an older
+// announcement never moves LastSeen back
+func ImplDiscoveredServiceSaveWithSQlite(db *gorm.DB) DiscoveredServiceSave {
+	return func(ctx context.Context, req DiscoveredServiceSaveReq) (*DiscoveredServiceSaveRes, error) {
+
+		res := DiscoveredServiceSaveRes{Services: make([]model.DiscoveredService, 0, len(req.Services))}
+
+		err := utility.GetDBFromContext(ctx, db).Transaction(func(tx *gorm.DB) error {
+			for _, service := range req.Services {
+
+				var stored model.DiscoveredService
+				err := tx.Where("protocol = ? AND ip = ? AND service_type = ? AND name = ?",
+					service.Protocol, service.IP, service.ServiceType, service.Name).
+					First(&stored).Error
+
+				switch {
+				case err == nil:
+					if service.LastSeen.After(stored.LastSeen) {
+						// an announcement without SRV or TXT keeps what an earlier one told
+						if service.Port != 0 {
+							stored.Port = service.Port
+						}
+						if len(service.Details) > 0 {
+							stored.Details = service.Details
+						}
+						stored.ClientID = service.ClientID
+						stored.LastSeen = service.LastSeen
+					}
+				case errors.Is(err, gorm.ErrRecordNotFound):
+					stored = service
+					stored.FirstSeen = service.LastSeen
+					res.Created++
+				default:
+					return err
+				}
+
+				if err := tx.Save(&stored).Error; err != nil {
+					return err
+				}
+				res.Services = append(res.Services, stored)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &res, nil
+	}
+}
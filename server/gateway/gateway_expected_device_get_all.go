@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ExpectedDeviceGetAllReq struct {
+	SiteID uint
+}
+
+type ExpectedDeviceGetAllRes struct {
+	Devices []model.ExpectedDevice
+}
+
+type ExpectedDeviceGetAll = core.ActionHandler[ExpectedDeviceGetAllReq, ExpectedDeviceGetAllRes]
+
+func ImplExpectedDeviceGetAllWithSQlite(db *gorm.DB) ExpectedDeviceGetAll {
+	return func(ctx context.Context, req ExpectedDeviceGetAllReq) (*ExpectedDeviceGetAllRes, error) {
+
+		var devices []model.ExpectedDevice
+
+		if err := utility.GetDBFromContext(ctx, db).Where("site_id = ?", req.SiteID).Order("id").Find(&devices).Error; err != nil {
+			return nil, err
+		}
+
+		return &ExpectedDeviceGetAllRes{Devices: devices}, nil
+	}
+}
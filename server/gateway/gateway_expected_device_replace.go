@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ExpectedDeviceReplaceReq struct {
+	SiteID  uint
+	Devices []model.ExpectedDevice
+}
+
+type ExpectedDeviceReplaceRes struct{}
+
+type ExpectedDeviceReplace = core.ActionHandler[ExpectedDeviceReplaceReq, ExpectedDeviceReplaceRes]
+
+// ImplExpectedDeviceReplaceWithSQlite swaps the whole inventory of a site, readers never see half of an import
+func ImplExpectedDeviceReplaceWithSQlite(db *gorm.DB) ExpectedDeviceReplace {
+	return func(ctx context.Context, req ExpectedDeviceReplaceReq) (*ExpectedDeviceReplaceRes, error) {
+
+		err := utility.GetDBFromContext(ctx, db).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("site_id = ?", req.SiteID).Delete(&model.ExpectedDevice{}).Error; err != nil {
+				return err
+			}
+			if len(req.Devices) == 0 {
+				return nil
+			}
+			return tx.CreateInBatches(req.Devices, 100).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &ExpectedDeviceReplaceRes{}, nil
+	}
+}
@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"shared/core"
+)
+
+type InternalDispatchReq struct {
+	Method        string
+	Path          string
+	Body          []byte
+	Authorization string // the Authorization header of the caller, the target checks its access again
+}
+
+type InternalDispatchRes struct {
+	StatusCode int
+	Body       []byte
+}
+
+type InternalDispatch = core.ActionHandler[InternalDispatchReq, InternalDispatchRes]
+
+// ImplInternalDispatch replays a request through the server's own authorized mux, used to hand
+// a reassembled upload to the endpoint it was meant for with the credentials of the uploader
+func ImplInternalDispatch(handler http.Handler) InternalDispatch {
+	return func(ctx context.Context, req InternalDispatchReq) (*InternalDispatchRes, error) {
+
+		httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.Path, bytes.NewReader(req.Body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if req.Authorization != "" {
+			httpReq.Header.Set("Authorization", req.Authorization)
+		}
+
+		recorder := &bufferResponseWriter{header: http.Header{}, status: http.StatusOK}
+		handler.ServeHTTP(recorder, httpReq)
+
+		return &InternalDispatchRes{
+			StatusCode: recorder.status,
+			Body:       recorder.body.Bytes(),
+		}, nil
+	}
+}
+
+type bufferResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func (b *bufferResponseWriter) Header() http.Header { return b.header }
+
+func (b *bufferResponseWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *bufferResponseWriter) WriteHeader(statusCode int) { b.status = statusCode }
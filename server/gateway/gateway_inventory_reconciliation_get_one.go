@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type InventoryReconciliationGetOneReq struct {
+	SiteID uint
+}
+
+type InventoryReconciliationGetOneRes struct {
+	Reconciliation *model.InventoryReconciliation // nil when the site was never reconciled
+}
+
+type InventoryReconciliationGetOne = core.ActionHandler[InventoryReconciliationGetOneReq, InventoryReconciliationGetOneRes]
+
+func ImplInventoryReconciliationGetOneWithSQlite(db *gorm.DB) InventoryReconciliationGetOne {
+	return func(ctx context.Context, req InventoryReconciliationGetOneReq) (*InventoryReconciliationGetOneRes, error) {
+
+		var reconciliation model.InventoryReconciliation
+
+		err := utility.GetDBFromContext(ctx, db).Where("site_id = ?", req.SiteID).First(&reconciliation).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &InventoryReconciliationGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &InventoryReconciliationGetOneRes{Reconciliation: &reconciliation}, nil
+	}
+}
@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InventoryReconciliationSaveReq struct {
+	Reconciliation *model.InventoryReconciliation
+}
+
+type InventoryReconciliationSaveRes struct{}
+
+type InventoryReconciliationSave = core.ActionHandler[InventoryReconciliationSaveReq, InventoryReconciliationSaveRes]
+
+// ImplInventoryReconciliationSaveWithSQlite keeps one row per site, the latest outcome replaces the previous one
+func ImplInventoryReconciliationSaveWithSQlite(db *gorm.DB) InventoryReconciliationSave {
+	return func(ctx context.Context, req InventoryReconciliationSaveReq) (*InventoryReconciliationSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "site_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"matched", "missing_ips", "unexpected_ips", "reconciled_at"}),
+		}).Create(req.Reconciliation).Error; err != nil {
+			return nil, err
+		}
+
+		return &InventoryReconciliationSaveRes{}, nil
+	}
+}
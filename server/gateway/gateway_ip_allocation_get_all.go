@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type IPAllocationGetAllReq struct {
+	SubnetID uint     // optional, zero means every subnet
+	IPs      []string // optional, nil means every address
+}
+
+type IPAllocationGetAllRes struct {
+	Allocations []model.IPAllocation
+}
+
+type IPAllocationGetAll = core.ActionHandler[IPAllocationGetAllReq, IPAllocationGetAllRes]
+
+func ImplIPAllocationGetAllWithSQlite(db *gorm.DB) IPAllocationGetAll {
+	return func(ctx context.Context, req IPAllocationGetAllReq) (*IPAllocationGetAllRes, error) {
+
+		var allocations []model.IPAllocation
+
+		query := utility.GetDBFromContext(ctx, db)
+		if req.SubnetID != 0 {
+			query = query.Where("subnet_id = ?", req.SubnetID)
+		}
+
+		if req.IPs != nil {
+			query = query.Where("ip IN ?", req.IPs)
+		}
+
+		if err := query.Order("id").Find(&allocations).Error; err != nil {
+			return nil, err
+		}
+
+		return &IPAllocationGetAllRes{Allocations: allocations}, nil
+	}
+}
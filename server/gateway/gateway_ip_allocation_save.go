@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IPAllocationSaveReq struct {
+	Allocation *model.IPAllocation
+}
+
+type IPAllocationSaveRes struct{}
+
+type IPAllocationSave = core.ActionHandler[IPAllocationSaveReq, IPAllocationSaveRes]
+
+// ImplIPAllocationSaveWithSQlite records the state of an address, the row of an address is updated in place
+func ImplIPAllocationSaveWithSQlite(db *gorm.DB) IPAllocationSave {
+	return func(ctx context.Context, req IPAllocationSaveReq) (*IPAllocationSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ip"}},
+			DoUpdates: clause.AssignmentColumns([]string{"subnet_id", "status", "hostname", "note", "updated_at"}),
+		}).Create(req.Allocation).Error; err != nil {
+			return nil, err
+		}
+
+		return &IPAllocationSaveRes{}, nil
+	}
+}
@@ -0,0 +1,64 @@
+package gateway
+
+import (
+	"context"
+	"net/netip"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type IPAMSubnetGetAllReq struct {
+	Containing  netip.Addr   // optional, only the subnets this IPv4 address is in
+	Overlapping netip.Prefix // optional, only the subnets sharing an address with this IPv4 prefix
+}
+
+type IPAMSubnetGetAllRes struct {
+	Subnets []model.IPAMSubnet
+}
+
+type IPAMSubnetGetAll = core.ActionHandler[IPAMSubnetGetAllReq, IPAMSubnetGetAllRes]
+
+// gorm does not know CIDR as an initialism, the column of IPAMSubnet.CIDR is named c_id_r
+const ipamSubnetCIDRColumn = "c_id_r"
+
+func ImplIPAMSubnetGetAllWithSQlite(db *gorm.DB) IPAMSubnetGetAll {
+	return func(ctx context.Context, req IPAMSubnetGetAllReq) (*IPAMSubnetGetAllRes, error) {
+
+		var subnets []model.IPAMSubnet
+
+		tx := utility.GetDBFromContext(ctx, db)
+		query := tx
+
+		// the CIDRs are stored masked, the subnets holding an address are among its 33 masked prefixes
+		if req.Containing.IsValid() {
+			query = query.Where(ipamSubnetCIDRColumn+" IN ?", supernetCIDRs(req.Containing, 32))
+		}
+
+		// a subnet overlapping the prefix either holds it or has its address inside it
+		if req.Overlapping.IsValid() {
+			group := tx.Session(&gorm.Session{NewDB: true})
+			inside := utility.WhereIPv4InPrefix(group, "substr("+ipamSubnetCIDRColumn+", 1, instr("+ipamSubnetCIDRColumn+", '/') - 1)", req.Overlapping)
+			query = query.Where(group.Where(ipamSubnetCIDRColumn+" IN ?", supernetCIDRs(req.Overlapping.Addr(), req.Overlapping.Bits())).Or(inside))
+		}
+
+		if err := query.Order("id").Find(&subnets).Error; err != nil {
+			return nil, err
+		}
+
+		return &IPAMSubnetGetAllRes{Subnets: subnets}, nil
+	}
+}
+
+// supernetCIDRs lists the masked prefixes of addr from /0 to /maxBits, as IPAMSubnetCreate stores them
+func supernetCIDRs(addr netip.Addr, maxBits int) []string {
+	cidrs := make([]string, 0, maxBits+1)
+	for bits := 0; bits <= maxBits; bits++ {
+		if prefix, err := addr.Unmap().Prefix(bits); err == nil {
+			cidrs = append(cidrs, prefix.String())
+		}
+	}
+	return cidrs
+}
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type IPAMSubnetGetOneReq struct {
+	ID uint
+}
+
+type IPAMSubnetGetOneRes struct {
+	Subnet *model.IPAMSubnet // nil when not found
+}
+
+type IPAMSubnetGetOne = core.ActionHandler[IPAMSubnetGetOneReq, IPAMSubnetGetOneRes]
+
+func ImplIPAMSubnetGetOneWithSQlite(db *gorm.DB) IPAMSubnetGetOne {
+	return func(ctx context.Context, req IPAMSubnetGetOneReq) (*IPAMSubnetGetOneRes, error) {
+
+		var subnet model.IPAMSubnet
+
+		err := utility.GetDBFromContext(ctx, db).First(&subnet, req.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &IPAMSubnetGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &IPAMSubnetGetOneRes{Subnet: &subnet}, nil
+	}
+}
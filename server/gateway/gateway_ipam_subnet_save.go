@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type IPAMSubnetSaveReq struct {
+	Subnet *model.IPAMSubnet
+}
+
+type IPAMSubnetSaveRes struct{}
+
+type IPAMSubnetSave = core.ActionHandler[IPAMSubnetSaveReq, IPAMSubnetSaveRes]
+
+func ImplIPAMSubnetSaveWithSQlite(db *gorm.DB) IPAMSubnetSave {
+	return func(ctx context.Context, req IPAMSubnetSaveReq) (*IPAMSubnetSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Save(req.Subnet).Error; err != nil {
+			return nil, err
+		}
+
+		return &IPAMSubnetSaveRes{}, nil
+	}
+}
@@ -0,0 +1,28 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"shared/core"
+	"shared/utility"
+)
+
+type LeakReportGetReq struct{}
+
+type LeakReportGetRes struct {
+	Report utility.LeakReport
+}
+
+type LeakReportGet = core.ActionHandler[LeakReportGetReq, LeakReportGetRes]
+
+// ImplLeakReportGet reads the leak detector of the process, detector is nil unless the server runs in soak test mode
+func ImplLeakReportGet(detector *utility.LeakDetector) LeakReportGet {
+	return func(ctx context.Context, request LeakReportGetReq) (*LeakReportGetRes, error) {
+
+		if detector == nil {
+			return nil, fmt.Errorf("leak detector is off, start the server with SOAK_TEST=on")
+		}
+
+		return &LeakReportGetRes{Report: detector.Report()}, nil
+	}
+}
@@ -0,0 +1,28 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type LogLevelSetReq struct {
+	Level    utility.LogLevel
+	Duration time.Duration // 0 keeps the level until the next change
+}
+
+type LogLevelSetRes struct {
+	Previous  utility.LogLevel
+	RevertsAt time.Time // zero when the level is kept
+}
+
+type LogLevelSet = core.ActionHandler[LogLevelSetReq, LogLevelSetRes]
+
+func ImplLogLevelSet(logger *utility.Logger) LogLevelSet {
+	return func(ctx context.Context, req LogLevelSetReq) (*LogLevelSetRes, error) {
+		previous, revertsAt := logger.SetLevelFor(req.Level, req.Duration)
+		return &LogLevelSetRes{Previous: previous, RevertsAt: revertsAt}, nil
+	}
+}
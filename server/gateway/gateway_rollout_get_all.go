@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type RolloutGetAllReq struct {
+	Status string         // optional
+	List   core.ListQuery // optional, all the rollouts when the size is 0
+}
+
+type RolloutGetAllRes struct {
+	Rollouts []model.Rollout
+	Total    int64 // rollouts matching the filters, ignoring the page
+}
+
+type RolloutGetAll = core.ActionHandler[RolloutGetAllReq, RolloutGetAllRes]
+
+// RolloutListColumns are the fields the rollouts can be sorted and filtered by, with their column
+var RolloutListColumns = map[string]string{
+	"id":           "id",
+	"rollout_id":   "rollout_id",
+	"event_type":   "event_type",
+	"status":       "status",
+	"requested_by": "requested_by",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"finished_at":  "finished_at",
+}
+
+func ImplRolloutGetAllWithSQlite(db *gorm.DB) RolloutGetAll {
+	return func(ctx context.Context, req RolloutGetAllReq) (*RolloutGetAllRes, error) {
+
+		var rollouts []model.Rollout
+
+		query := utility.GetDBFromContext(ctx, db).Model(&model.Rollout{})
+		if req.Status != "" {
+			query = query.Where("status = ?", req.Status)
+		}
+
+		total, err := utility.ListPage(query, req.List, RolloutListColumns, &rollouts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RolloutGetAllRes{Rollouts: rollouts, Total: total}, nil
+	}
+}
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type RolloutGetOneReq struct {
+	RolloutID string
+}
+
+type RolloutGetOneRes struct {
+	Rollout *model.Rollout // nil when not found
+}
+
+type RolloutGetOne = core.ActionHandler[RolloutGetOneReq, RolloutGetOneRes]
+
+func ImplRolloutGetOneWithSQlite(db *gorm.DB) RolloutGetOne {
+	return func(ctx context.Context, req RolloutGetOneReq) (*RolloutGetOneRes, error) {
+
+		var rollout model.Rollout
+
+		err := utility.GetDBFromContext(ctx, db).Where("rollout_id = ?", req.RolloutID).First(&rollout).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &RolloutGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &RolloutGetOneRes{Rollout: &rollout}, nil
+	}
+}
@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type RolloutSaveReq struct {
+	Rollout *model.Rollout
+}
+
+type RolloutSaveRes struct{}
+
+type RolloutSave = core.ActionHandler[RolloutSaveReq, RolloutSaveRes]
+
+func ImplRolloutSaveWithSQlite(db *gorm.DB) RolloutSave {
+	return func(ctx context.Context, req RolloutSaveReq) (*RolloutSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Save(req.Rollout).Error; err != nil {
+			return nil, err
+		}
+
+		return &RolloutSaveRes{}, nil
+	}
+}
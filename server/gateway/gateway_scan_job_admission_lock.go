@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScanJobAdmissionLockReq struct{}
+
+type ScanJobAdmissionLockRes struct{}
+
+type ScanJobAdmissionLock = core.ActionHandler[ScanJobAdmissionLockReq, ScanJobAdmissionLockRes]
+
+// ImplScanJobAdmissionLockWithSQlite writes the admission row inside the transaction of the context,
+// a concurrent admission waits on the write lock until this one commits or rolls back
+func ImplScanJobAdmissionLockWithSQlite(db *gorm.DB) ScanJobAdmissionLock {
+	return func(ctx context.Context, req ScanJobAdmissionLockReq) (*ScanJobAdmissionLockRes, error) {
+
+		lock := model.ScanJobAdmissionLock{ID: 1, Version: 1}
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.Assignments(map[string]any{"version": gorm.Expr("version + 1")}),
+		}).Create(&lock).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanJobAdmissionLockRes{}, nil
+	}
+}
@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobAuditSaveReq struct {
+	Audit *model.ScanJobAudit
+}
+
+type ScanJobAuditSaveRes struct{}
+
+type ScanJobAuditSave = core.ActionHandler[ScanJobAuditSaveReq, ScanJobAuditSaveRes]
+
+func ImplScanJobAuditSaveWithSQlite(db *gorm.DB) ScanJobAuditSave {
+	return func(ctx context.Context, req ScanJobAuditSaveReq) (*ScanJobAuditSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Create(req.Audit).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanJobAuditSaveRes{}, nil
+	}
+}
@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobCountByStatusReq struct {
+}
+
+type ScanJobCountByStatusRes struct {
+	Counts map[string]int64
+}
+
+type ScanJobCountByStatus = core.ActionHandler[ScanJobCountByStatusReq, ScanJobCountByStatusRes]
+
+func ImplScanJobCountByStatusWithSQlite(db *gorm.DB) ScanJobCountByStatus {
+	return func(ctx context.Context, req ScanJobCountByStatusReq) (*ScanJobCountByStatusRes, error) {
+
+		var rows []struct {
+			Status string
+			Total  int64
+		}
+		if err := utility.GetDBFromContext(ctx, db).Model(&model.ScanJob{}).
+			Select("status, COUNT(*) AS total").
+			Group("status").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		counts := make(map[string]int64, len(rows))
+		for _, row := range rows {
+			counts[row.Status] = row.Total
+		}
+
+		return &ScanJobCountByStatusRes{Counts: counts}, nil
+	}
+}
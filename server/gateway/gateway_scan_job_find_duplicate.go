@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobFindDuplicateReq struct {
+	RequestKey string
+	Since      time.Time // only jobs created at or after this count as duplicates
+}
+
+type ScanJobFindDuplicateRes struct {
+	ScanJob *model.ScanJob // the newest matching job, nil when there is none
+}
+
+type ScanJobFindDuplicate = core.ActionHandler[ScanJobFindDuplicateReq, ScanJobFindDuplicateRes]
+
+// ImplScanJobFindDuplicateWithSQlite skips failed and cancelled jobs, retrying one is not a duplicate
+func ImplScanJobFindDuplicateWithSQlite(db *gorm.DB) ScanJobFindDuplicate {
+	return func(ctx context.Context, req ScanJobFindDuplicateReq) (*ScanJobFindDuplicateRes, error) {
+
+		var scanJob model.ScanJob
+
+		err := utility.GetDBFromContext(ctx, db).
+			Where("request_key = ? AND created_at >= ? AND status NOT IN ?", req.RequestKey, req.Since, []string{model.ScanJobFailed, model.ScanJobCancelled}).
+			Order("id desc").
+			First(&scanJob).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ScanJobFindDuplicateRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanJobFindDuplicateRes{ScanJob: &scanJob}, nil
+	}
+}
@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobGetActiveReq struct {
+	Statuses []string
+	Since    time.Time // jobs created before this are considered stale and skipped
+	Before   time.Time // optional, only the jobs created before this, the stale ones
+}
+
+type ScanJobGetActiveRes struct {
+	ScanJobs []model.ScanJob // oldest first
+}
+
+type ScanJobGetActive = core.ActionHandler[ScanJobGetActiveReq, ScanJobGetActiveRes]
+
+func ImplScanJobGetActiveWithSQlite(db *gorm.DB) ScanJobGetActive {
+	return func(ctx context.Context, req ScanJobGetActiveReq) (*ScanJobGetActiveRes, error) {
+
+		var scanJobs []model.ScanJob
+
+		query := utility.GetDBFromContext(ctx, db).
+			Preload("Reports").
+			Where("status IN ? AND dry_run = ? AND created_at >= ?", req.Statuses, false, req.Since)
+		if !req.Before.IsZero() {
+			query = query.Where("created_at < ?", req.Before)
+		}
+
+		if err := query.
+			Order("id").
+			Find(&scanJobs).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanJobGetActiveRes{ScanJobs: scanJobs}, nil
+	}
+}
@@ -0,0 +1,31 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobGetAllReq struct{}
+
+type ScanJobGetAllRes struct {
+	ScanJobs []model.ScanJob
+}
+
+type ScanJobGetAll = core.ActionHandler[ScanJobGetAllReq, ScanJobGetAllRes]
+
+func ImplScanJobGetAllWithSQlite(db *gorm.DB) ScanJobGetAll {
+	return func(ctx context.Context, req ScanJobGetAllReq) (*ScanJobGetAllRes, error) {
+
+		var scanJobs []model.ScanJob
+
+		if err := utility.GetDBFromContext(ctx, db).Preload("Reports").Preload("Audits").Order("id desc").Find(&scanJobs).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanJobGetAllRes{ScanJobs: scanJobs}, nil
+	}
+}
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobGetOneReq struct {
+	JobID string
+}
+
+type ScanJobGetOneRes struct {
+	ScanJob *model.ScanJob // nil when not found
+}
+
+type ScanJobGetOne = core.ActionHandler[ScanJobGetOneReq, ScanJobGetOneRes]
+
+func ImplScanJobGetOneWithSQlite(db *gorm.DB) ScanJobGetOne {
+	return func(ctx context.Context, req ScanJobGetOneReq) (*ScanJobGetOneRes, error) {
+
+		var scanJob model.ScanJob
+
+		err := utility.GetDBFromContext(ctx, db).Preload("Reports").Preload("Audits").Where("job_id = ?", req.JobID).First(&scanJob).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ScanJobGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanJobGetOneRes{ScanJob: &scanJob}, nil
+	}
+}
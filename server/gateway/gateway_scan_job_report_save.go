@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScanJobReportSaveReq struct {
+	Report *model.ScanJobReport
+}
+
+type ScanJobReportSaveRes struct{}
+
+type ScanJobReportSave = core.ActionHandler[ScanJobReportSaveReq, ScanJobReportSaveRes]
+
+func ImplScanJobReportSaveWithSQlite(db *gorm.DB) ScanJobReportSave {
+	return func(ctx context.Context, req ScanJobReportSaveReq) (*ScanJobReportSaveRes, error) {
+
+		// an agent re-sending its report replaces the previous one
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "job_id"}, {Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"status", "error", "total_hosts", "workers", "timeout_ms", "estimated_ms",
+				"started_at", "finished_at", "metrics", "updated_at",
+			}),
+		}).Create(req.Report).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanJobReportSaveRes{}, nil
+	}
+}
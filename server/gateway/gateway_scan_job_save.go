@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobSaveReq struct {
+	ScanJob *model.ScanJob
+}
+
+type ScanJobSaveRes struct{}
+
+type ScanJobSave = core.ActionHandler[ScanJobSaveReq, ScanJobSaveRes]
+
+func ImplScanJobSaveWithSQlite(db *gorm.DB) ScanJobSave {
+	return func(ctx context.Context, req ScanJobSaveReq) (*ScanJobSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Omit("Reports", "Audits").Save(req.ScanJob).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanJobSaveRes{}, nil
+	}
+}
@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobSetRecipientsReq struct {
+	JobID      string
+	Recipients []string
+}
+
+type ScanJobSetRecipientsRes struct{}
+
+type ScanJobSetRecipients = core.ActionHandler[ScanJobSetRecipientsReq, ScanJobSetRecipientsRes]
+
+func ImplScanJobSetRecipientsWithSQlite(db *gorm.DB) ScanJobSetRecipients {
+	return func(ctx context.Context, req ScanJobSetRecipientsReq) (*ScanJobSetRecipientsRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).
+			Model(&model.ScanJob{}).
+			Where("job_id = ?", req.JobID).
+			Select("recipients").
+			Updates(&model.ScanJob{Recipients: req.Recipients}).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanJobSetRecipientsRes{}, nil
+	}
+}
@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobTransitionReq struct {
+	JobID      string
+	FromStatus string
+	ToStatus   string
+	ApprovedBy string // optional, recorded with Now when set
+	Now        time.Time
+}
+
+type ScanJobTransitionRes struct {
+	Updated bool // false when the job was not in FromStatus anymore
+}
+
+type ScanJobTransition = core.ActionHandler[ScanJobTransitionReq, ScanJobTransitionRes]
+
+// ImplScanJobTransitionWithSQlite moves a job between statuses only if it is still in the expected one,
+// so two operators approving at the same time dispatch the job once
+func ImplScanJobTransitionWithSQlite(db *gorm.DB) ScanJobTransition {
+	return func(ctx context.Context, req ScanJobTransitionReq) (*ScanJobTransitionRes, error) {
+
+		updates := map[string]any{"status": req.ToStatus}
+		if req.ApprovedBy != "" {
+			updates["approved_by"] = req.ApprovedBy
+			updates["approved_at"] = req.Now
+		}
+
+		result := utility.GetDBFromContext(ctx, db).
+			Model(&model.ScanJob{}).
+			Where("job_id = ? AND status = ?", req.JobID, req.FromStatus).
+			Updates(updates)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return &ScanJobTransitionRes{Updated: result.RowsAffected == 1}, nil
+	}
+}
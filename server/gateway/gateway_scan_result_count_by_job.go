@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanResultCountByJobReq struct {
+	JobIDs []string
+}
+
+type ScanResultCountByJobRes struct {
+	// Counts is keyed by job id then client id
+	Counts map[string]map[string]int64
+}
+
+type ScanResultCountByJob = core.ActionHandler[ScanResultCountByJobReq, ScanResultCountByJobRes]
+
+func ImplScanResultCountByJobWithSQlite(db *gorm.DB) ScanResultCountByJob {
+	return func(ctx context.Context, req ScanResultCountByJobReq) (*ScanResultCountByJobRes, error) {
+
+		counts := map[string]map[string]int64{}
+		if len(req.JobIDs) == 0 {
+			return &ScanResultCountByJobRes{Counts: counts}, nil
+		}
+
+		var rows []struct {
+			JobID    string
+			ClientID string
+			Total    int64
+		}
+		if err := utility.GetDBFromContext(ctx, db).Model(&model.ScanResult{}).
+			Select("job_id, client_id, COUNT(*) AS total").
+			Where("job_id IN ?", req.JobIDs).
+			Group("job_id, client_id").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			if counts[row.JobID] == nil {
+				counts[row.JobID] = map[string]int64{}
+			}
+			counts[row.JobID][row.ClientID] = row.Total
+		}
+
+		return &ScanResultCountByJobRes{Counts: counts}, nil
+	}
+}
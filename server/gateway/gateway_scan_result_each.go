@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScanResultEachReq struct {
+	ClientIDs []string // optional, nil means every agent
+	IP        string
+	JobID     string
+	Status    string
+	Since     time.Time // optional, results probed at or after
+	Until     time.Time // optional, results probed before
+	// Each is called for every matching result in the order they were stored, an error stops the walk and is returned
+	Each func(result model.ScanResult) error
+}
+
+type ScanResultEachRes struct {
+	Rows int
+}
+
+type ScanResultEach = core.ActionHandler[ScanResultEachReq, ScanResultEachRes]
+
+// scanResultEachBatch is the number of rows held in memory at once
+const scanResultEachBatch = 500
+
+// ImplScanResultEachWithSQlite walks the matching results in batches, so an export of millions of rows
+// never holds more than one batch
+func ImplScanResultEachWithSQlite(db *gorm.DB) ScanResultEach {
+	return func(ctx context.Context, req ScanResultEachReq) (*ScanResultEachRes, error) {
+
+		query := utility.GetDBFromContext(ctx, db).WithContext(ctx)
+		if req.ClientIDs != nil {
+			query = query.Where("client_id IN ?", req.ClientIDs)
+		}
+		if req.IP != "" {
+			query = query.Where("ip = ?", req.IP)
+		}
+		if req.JobID != "" {
+			query = query.Where("job_id = ?", req.JobID)
+		}
+		if req.Status != "" {
+			query = query.Where("status = ?", req.Status)
+		}
+		if !req.Since.IsZero() {
+			query = query.Where("timestamp >= ?", req.Since)
+		}
+		if !req.Until.IsZero() {
+			query = query.Where("timestamp < ?", req.Until)
+		}
+
+		res := ScanResultEachRes{}
+
+		var batch []model.ScanResult
+		result := query.FindInBatches(&batch, scanResultEachBatch, func(tx *gorm.DB, _ int) error {
+			for _, scanResult := range batch {
+				if err := req.Each(scanResult); err != nil {
+					return err
+				}
+				res.Rows++
+			}
+			return nil
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return &res, nil
+	}
+}
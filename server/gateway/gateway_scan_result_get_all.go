@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanResultGetAllReq struct {
+	ClientIDs []string // optional, nil means every agent
+	IP        string
+	JobID     string
+	// LatestPerIP keeps only the newest result, by id, of each address matching the filters
+	LatestPerIP bool
+	List        core.ListQuery // optional, all the results when the size is 0
+}
+
+type ScanResultGetAllRes struct {
+	ScanResults []model.ScanResult
+	Total       int64 // results matching the filters, ignoring the page
+}
+
+type ScanResultGetAll = core.ActionHandler[ScanResultGetAllReq, ScanResultGetAllRes]
+
+// ScanResultListColumns are the fields the scan results can be sorted and filtered by, with their column
+var ScanResultListColumns = map[string]string{
+	"id":        "id",
+	"client_id": "client_id",
+	"job_id":    "job_id",
+	"ip":        "ip",
+	"protocol":  "protocol",
+	"status":    "status",
+	"timestamp": "timestamp",
+}
+
+func ImplScanResultGetAllWithSQlite(db *gorm.DB) ScanResultGetAll {
+	return func(ctx context.Context, req ScanResultGetAllReq) (*ScanResultGetAllRes, error) {
+
+		var scanResults []model.ScanResult
+
+		filters := func(query *gorm.DB) *gorm.DB {
+			if req.ClientIDs != nil {
+				query = query.Where("client_id IN ?", req.ClientIDs)
+			}
+			if req.IP != "" {
+				query = query.Where("ip = ?", req.IP)
+			}
+			if req.JobID != "" {
+				query = query.Where("job_id = ?", req.JobID)
+			}
+			return query
+		}
+
+		tx := utility.GetDBFromContext(ctx, db)
+		query := tx.Model(&model.ScanResult{}).Scopes(filters)
+		if req.LatestPerIP {
+			latest := tx.Model(&model.ScanResult{}).Scopes(filters).Select("MAX(id)").Group("ip")
+			query = query.Where("id IN (?)", latest)
+		}
+
+		total, err := utility.ListPage(query, req.List, ScanResultListColumns, &scanResults)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanResultGetAllRes{ScanResults: scanResults, Total: total}, nil
+	}
+}
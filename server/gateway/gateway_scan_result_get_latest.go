@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"context"
+	"net/netip"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanResultGetLatestReq struct {
+	IPs    []string     // optional, nil means every address ever scanned
+	Prefix netip.Prefix // optional, the IPv4 subnet the addresses are in
+}
+
+type ScanResultGetLatestRes struct {
+	ScanResults []model.ScanResult // the most recent result of each address
+}
+
+type ScanResultGetLatest = core.ActionHandler[ScanResultGetLatestReq, ScanResultGetLatestRes]
+
+func ImplScanResultGetLatestWithSQlite(db *gorm.DB) ScanResultGetLatest {
+	return func(ctx context.Context, req ScanResultGetLatestReq) (*ScanResultGetLatestRes, error) {
+
+		var scanResults []model.ScanResult
+
+		tx := utility.GetDBFromContext(ctx, db)
+
+		latest := tx.Model(&model.ScanResult{}).Select("MAX(id)").Group("ip")
+		if req.IPs != nil {
+			latest = latest.Where("ip IN ?", req.IPs)
+		}
+		if req.Prefix.IsValid() {
+			latest = utility.WhereIPv4InPrefix(latest, "ip", req.Prefix)
+		}
+
+		if err := tx.Where("id IN (?)", latest).Order("ip").Find(&scanResults).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanResultGetLatestRes{ScanResults: scanResults}, nil
+	}
+}
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanResultSaveReq struct {
+	ScanResults []model.ScanResult
+}
+
+type ScanResultSaveRes struct{}
+
+type ScanResultSave = core.ActionHandler[ScanResultSaveReq, ScanResultSaveRes]
+
+// ImplScanResultSaveWithSQlite stores a whole upload in one transaction, either every row is stored or none
+func ImplScanResultSaveWithSQlite(db *gorm.DB) ScanResultSave {
+	return func(ctx context.Context, req ScanResultSaveReq) (*ScanResultSaveRes, error) {
+
+		if len(req.ScanResults) == 0 {
+			return &ScanResultSaveRes{}, nil
+		}
+
+		err := utility.GetDBFromContext(ctx, db).Transaction(func(tx *gorm.DB) error {
+			return tx.CreateInBatches(req.ScanResults, 100).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanResultSaveRes{}, nil
+	}
+}
@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type ScanResultSaveBatchReq struct {
+	ScanResults []model.ScanResult
+}
+
+type ScanResultSaveBatchRes struct{}
+
+type ScanResultSaveBatch = core.ActionHandler[ScanResultSaveBatchReq, ScanResultSaveBatchRes]
+
+func ImplScanResultSaveBatchWithSQlite(db *gorm.DB) ScanResultSaveBatch {
+	return func(ctx context.Context, req ScanResultSaveBatchReq) (*ScanResultSaveBatchRes, error) {
+
+		if len(req.ScanResults) == 0 {
+			return &ScanResultSaveBatchRes{}, nil
+		}
+
+		if err := utility.GetDBFromContext(ctx, db).CreateInBatches(req.ScanResults, 100).Error; err != nil {
+			return nil, err
+		}
+
+		return &ScanResultSaveBatchRes{}, nil
+	}
+}
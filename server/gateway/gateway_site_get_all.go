@@ -0,0 +1,31 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type SiteGetAllReq struct{}
+
+type SiteGetAllRes struct {
+	Sites []model.Site
+}
+
+type SiteGetAll = core.ActionHandler[SiteGetAllReq, SiteGetAllRes]
+
+func ImplSiteGetAllWithSQlite(db *gorm.DB) SiteGetAll {
+	return func(ctx context.Context, req SiteGetAllReq) (*SiteGetAllRes, error) {
+
+		var sites []model.Site
+
+		if err := utility.GetDBFromContext(ctx, db).Preload("Agents").Order("name").Find(&sites).Error; err != nil {
+			return nil, err
+		}
+
+		return &SiteGetAllRes{Sites: sites}, nil
+	}
+}
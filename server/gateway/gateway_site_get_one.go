@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type SiteGetOneReq struct {
+	ID uint
+}
+
+type SiteGetOneRes struct {
+	Site *model.Site // nil when not found
+}
+
+type SiteGetOne = core.ActionHandler[SiteGetOneReq, SiteGetOneRes]
+
+func ImplSiteGetOneWithSQlite(db *gorm.DB) SiteGetOne {
+	return func(ctx context.Context, req SiteGetOneReq) (*SiteGetOneRes, error) {
+
+		var site model.Site
+
+		err := utility.GetDBFromContext(ctx, db).Preload("Agents").First(&site, req.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &SiteGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &SiteGetOneRes{Site: &site}, nil
+	}
+}
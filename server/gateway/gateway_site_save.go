@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type SiteSaveReq struct {
+	Site *model.Site
+}
+
+type SiteSaveRes struct{}
+
+type SiteSave = core.ActionHandler[SiteSaveReq, SiteSaveRes]
+
+func ImplSiteSaveWithSQlite(db *gorm.DB) SiteSave {
+	return func(ctx context.Context, req SiteSaveReq) (*SiteSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Omit("Agents").Save(req.Site).Error; err != nil {
+			return nil, err
+		}
+
+		return &SiteSaveRes{}, nil
+	}
+}
@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"context"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type SSEClientGroupsSetReq struct {
+	ClientID string
+	Groups   []string // the only groups the client stays a member of, empty leaves every group
+}
+
+type SSEClientGroupsSetRes struct{}
+
+type SSEClientGroupsSet = core.ActionHandler[SSEClientGroupsSetReq, SSEClientGroupsSetRes]
+
+// ImplSSEClientGroupsSet sets the group memberships of a client on this instance
+func ImplSSEClientGroupsSet(sse *utility.SSEServer) SSEClientGroupsSet {
+	return func(ctx context.Context, request SSEClientGroupsSetReq) (*SSEClientGroupsSetRes, error) {
+
+		if sse == nil {
+			return &SSEClientGroupsSetRes{}, nil
+		}
+
+		if err := sse.SetClientGroups(request.ClientID, request.Groups); err != nil {
+			return nil, err
+		}
+
+		return &SSEClientGroupsSetRes{}, nil
+	}
+}
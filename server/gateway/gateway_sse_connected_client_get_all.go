@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"context"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type SSEConnectedClientGetAllReq struct {
+	Group string // optional, only the members of this group
+}
+
+type SSEConnectedClientGetAllRes struct {
+	ClientIDs []string
+}
+
+type SSEConnectedClientGetAll = core.ActionHandler[SSEConnectedClientGetAllReq, SSEConnectedClientGetAllRes]
+
+// ImplSSEConnectedClientGetAll lists the clients connected to any instance of the cluster, members of a group may be offline
+func ImplSSEConnectedClientGetAll(sse *utility.SSEServer) SSEConnectedClientGetAll {
+	return func(ctx context.Context, request SSEConnectedClientGetAllReq) (*SSEConnectedClientGetAllRes, error) {
+
+		if sse == nil {
+			return &SSEConnectedClientGetAllRes{ClientIDs: []string{}}, nil
+		}
+
+		if request.Group != "" {
+			return &SSEConnectedClientGetAllRes{ClientIDs: sse.GetGroupMembers(request.Group)}, nil
+		}
+
+		return &SSEConnectedClientGetAllRes{ClientIDs: sse.GetClusterClientIDs()}, nil
+	}
+}
@@ -0,0 +1,23 @@
+package gateway
+
+import (
+	"context"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type SSEEventCatalogGetReq struct {
+}
+
+type SSEEventCatalogGetRes struct {
+	Events []utility.EventCatalogEntry
+}
+
+type SSEEventCatalogGet = core.ActionHandler[SSEEventCatalogGetReq, SSEEventCatalogGetRes]
+
+func ImplSSEEventCatalogGet(catalog *utility.EventCatalog) SSEEventCatalogGet {
+	return func(ctx context.Context, request SSEEventCatalogGetReq) (*SSEEventCatalogGetRes, error) {
+		return &SSEEventCatalogGetRes{Events: catalog.Entries()}, nil
+	}
+}
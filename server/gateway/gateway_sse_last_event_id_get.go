@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"context"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type SSELastEventIDGetReq struct {
+	ClientID string
+}
+
+type SSELastEventIDGetRes struct {
+	LastEventID uint64
+	Found       bool // false when the client is not connected to this instance or got no event yet
+	Connected   bool
+}
+
+type SSELastEventIDGet = core.ActionHandler[SSELastEventIDGetReq, SSELastEventIDGetRes]
+
+func ImplSSELastEventIDGet(sse *utility.SSEServer) SSELastEventIDGet {
+	return func(ctx context.Context, request SSELastEventIDGetReq) (*SSELastEventIDGetRes, error) {
+
+		id, found := sse.LastEventID(request.ClientID)
+
+		return &SSELastEventIDGetRes{
+			LastEventID: id,
+			Found:       found,
+			Connected:   sse.IsClientConnected(request.ClientID),
+		}, nil
+	}
+}
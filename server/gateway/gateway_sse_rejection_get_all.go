@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type SSERejectionGetAllReq struct{}
+
+type SSERejectionGetAllRes struct {
+	Rejections []utility.Rejection
+	Counts     map[utility.RejectReason]int64
+}
+
+type SSERejectionGetAll = core.ActionHandler[SSERejectionGetAllReq, SSERejectionGetAllRes]
+
+func ImplSSERejectionGetAll(sse *utility.SSEServer) SSERejectionGetAll {
+	return func(ctx context.Context, request SSERejectionGetAllReq) (*SSERejectionGetAllRes, error) {
+
+		rejections, counts := sse.GetRejections()
+
+		return &SSERejectionGetAllRes{
+			Rejections: rejections,
+			Counts:     counts,
+		}, nil
+	}
+}
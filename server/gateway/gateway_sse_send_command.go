@@ -0,0 +1,35 @@
+package gateway
+
+import (
+	"context"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type SendCommandReq[Req any] struct {
+	Payload   Req
+	ClientIDs []string // optional, empty means every connected agent
+	Group     string   // optional, send to the members of this group instead
+	Volatile  bool     // never kept for an agent that is offline, e.g. a credential
+	Requires  []string // commands the agents must have announced, the others do not receive it
+}
+
+type SendCommand[Req any] = core.ActionHandler[SendCommandReq[Req], SendSSEMessageRes]
+
+// ImplSendCommand sends one command declared in shared/command through SendSSEMessage, the event name
+// and the ack requirement come from the declaration instead of each use case
+func ImplSendCommand[Req, Res any](send SendSSEMessage, command utility.Command[Req, Res]) SendCommand[Req] {
+	return func(ctx context.Context, request SendCommandReq[Req]) (*SendSSEMessageRes, error) {
+		msg := command.Message(request.Payload)
+		return send(ctx, SendSSEMessageReq{
+			EventType:  msg.EventType,
+			Data:       msg.Data,
+			ClientIDs:  request.ClientIDs,
+			Group:      request.Group,
+			RequireAck: command.RequireAck,
+			Volatile:   request.Volatile,
+			Requires:   request.Requires,
+		})
+	}
+}
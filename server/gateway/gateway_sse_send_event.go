@@ -8,11 +8,19 @@ import (
 )
 
 type SendSSEMessageReq struct {
-	EventType string
-	Data      any
+	EventType  string
+	Data       any
+	ClientIDs  []string // optional, empty means broadcast
+	Topic      string   // optional, publish only to subscribers of this topic
+	Group      string   // optional, send to the members of this group, e.g. every agent of a datacenter
+	RequireAck bool     // assign a message id so the receivers acknowledge it, see WaitSSEAck
+	Volatile   bool     // never stored for offline clients, e.g. credentials
+	Requires   []string // commands a receiver must have announced, see utility.Message.Requires
 }
 
-type SendSSEMessageRes struct{}
+type SendSSEMessageRes struct {
+	MessageID string // empty unless RequireAck was set
+}
 
 type SendSSEMessage = core.ActionHandler[SendSSEMessageReq, SendSSEMessageRes]
 
@@ -23,15 +31,31 @@ func ImplSendSSEMessage(sse *utility.SSEServer) SendSSEMessage {
 			return &SendSSEMessageRes{}, nil
 		}
 
-		err := sse.SendToClients(ctx, utility.Message{
+		msg := utility.Message{
 			EventType: request.EventType,
 			Data:      request.Data,
-		})
+			Volatile:  request.Volatile,
+			Requires:  request.Requires,
+		}
+		if request.RequireAck {
+			msg.ID = sse.NewMessageID()
+		}
+
+		var err error
+		switch {
+		case request.Topic != "":
+			err = sse.PublishToTopic(ctx, request.Topic, msg)
+		case request.Group != "":
+			err = sse.SendToGroup(ctx, request.Group, msg)
+		default:
+			err = sse.SendToClients(ctx, msg, request.ClientIDs...)
+		}
 
 		if err != nil {
 			return nil, err
 		}
+		utility.Debugf("Sent %s (id %q) to clients %v, topic %q, group %q", msg.EventType, msg.ID, request.ClientIDs, request.Topic, request.Group)
 
-		return &SendSSEMessageRes{}, nil
+		return &SendSSEMessageRes{MessageID: msg.ID}, nil
 	}
 }
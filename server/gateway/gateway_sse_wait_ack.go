@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"shared/core"
+	"shared/utility"
+)
+
+type WaitSSEAckReq struct {
+	MessageID string
+	ClientIDs []string      // empty means the first ack from any client is enough
+	Timeout   time.Duration // how long to wait before giving up
+}
+
+type WaitSSEAckRes struct {
+	AcknowledgedBy []string
+	Complete       bool // false when the timeout passed before every client acknowledged
+}
+
+type WaitSSEAck = core.ActionHandler[WaitSSEAckReq, WaitSSEAckRes]
+
+func ImplWaitSSEAck(sse *utility.SSEServer) WaitSSEAck {
+	return func(ctx context.Context, request WaitSSEAckReq) (*WaitSSEAckRes, error) {
+
+		if sse == nil || request.MessageID == "" {
+			return &WaitSSEAckRes{AcknowledgedBy: []string{}}, nil
+		}
+
+		// the timeout runs on the clock of ctx so a rollout or a scan waits on the same time as its caller
+		waitCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func(timeout <-chan time.Time) {
+			select {
+			case <-timeout:
+				cancel()
+			case <-waitCtx.Done():
+			}
+		}(core.GetClockFromContext(ctx).After(request.Timeout))
+
+		// a timeout is an answer, not a failure, the caller decides what missing acks mean
+		err := sse.WaitForAck(waitCtx, request.MessageID, request.ClientIDs...)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return &WaitSSEAckRes{
+			AcknowledgedBy: sse.GetAcks(request.MessageID),
+			Complete:       err == nil,
+		}, nil
+	}
+}
@@ -0,0 +1,56 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type TracerouteGetAllReq struct {
+	ClientID string         // optional
+	Target   string         // optional
+	List     core.ListQuery // optional, all the traces when the size is 0
+}
+
+type TracerouteGetAllRes struct {
+	Traceroutes []model.Traceroute
+	Total       int64 // traces matching the filters, ignoring the page
+}
+
+type TracerouteGetAll = core.ActionHandler[TracerouteGetAllReq, TracerouteGetAllRes]
+
+// TracerouteListColumns are the fields the traces can be sorted and filtered by, with their column
+var TracerouteListColumns = map[string]string{
+	"id":          "id",
+	"trace_id":    "trace_id",
+	"client_id":   "client_id",
+	"target":      "target",
+	"status":      "status",
+	"started_at":  "started_at",
+	"finished_at": "finished_at",
+}
+
+func ImplTracerouteGetAllWithSQlite(db *gorm.DB) TracerouteGetAll {
+	return func(ctx context.Context, req TracerouteGetAllReq) (*TracerouteGetAllRes, error) {
+
+		var traceroutes []model.Traceroute
+
+		query := utility.GetDBFromContext(ctx, db).Model(&model.Traceroute{})
+		if req.ClientID != "" {
+			query = query.Where("client_id = ?", req.ClientID)
+		}
+		if req.Target != "" {
+			query = query.Where("target = ?", req.Target)
+		}
+
+		total, err := utility.ListPage(query, req.List, TracerouteListColumns, &traceroutes)
+		if err != nil {
+			return nil, err
+		}
+
+		return &TracerouteGetAllRes{Traceroutes: traceroutes, Total: total}, nil
+	}
+}
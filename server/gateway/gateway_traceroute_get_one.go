@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type TracerouteGetOneReq struct {
+	TraceID string
+}
+
+type TracerouteGetOneRes struct {
+	Traceroute *model.Traceroute // nil when not found
+}
+
+type TracerouteGetOne = core.ActionHandler[TracerouteGetOneReq, TracerouteGetOneRes]
+
+func ImplTracerouteGetOneWithSQlite(db *gorm.DB) TracerouteGetOne {
+	return func(ctx context.Context, req TracerouteGetOneReq) (*TracerouteGetOneRes, error) {
+
+		var traceroute model.Traceroute
+
+		err := utility.GetDBFromContext(ctx, db).Where("trace_id = ?", req.TraceID).First(&traceroute).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &TracerouteGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &TracerouteGetOneRes{Traceroute: &traceroute}, nil
+	}
+}
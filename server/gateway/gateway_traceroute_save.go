@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type TracerouteSaveReq struct {
+	Traceroute *model.Traceroute
+}
+
+type TracerouteSaveRes struct{}
+
+type TracerouteSave = core.ActionHandler[TracerouteSaveReq, TracerouteSaveRes]
+
+func ImplTracerouteSaveWithSQlite(db *gorm.DB) TracerouteSave {
+	return func(ctx context.Context, req TracerouteSaveReq) (*TracerouteSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Save(req.Traceroute).Error; err != nil {
+			return nil, err
+		}
+
+		return &TracerouteSaveRes{}, nil
+	}
+}
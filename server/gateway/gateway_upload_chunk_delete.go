@@ -0,0 +1,32 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type UploadChunkDeleteReq struct {
+	UploadID string
+}
+
+type UploadChunkDeleteRes struct{}
+
+type UploadChunkDelete = core.ActionHandler[UploadChunkDeleteReq, UploadChunkDeleteRes]
+
+func ImplUploadChunkDeleteWithSQlite(db *gorm.DB) UploadChunkDelete {
+	return func(ctx context.Context, req UploadChunkDeleteReq) (*UploadChunkDeleteRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).
+			Unscoped().
+			Where("upload_id = ?", req.UploadID).
+			Delete(&model.UploadChunk{}).Error; err != nil {
+			return nil, err
+		}
+
+		return &UploadChunkDeleteRes{}, nil
+	}
+}
@@ -0,0 +1,36 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type UploadChunkGetAllReq struct {
+	UploadID string
+}
+
+type UploadChunkGetAllRes struct {
+	Chunks []model.UploadChunk
+}
+
+type UploadChunkGetAll = core.ActionHandler[UploadChunkGetAllReq, UploadChunkGetAllRes]
+
+func ImplUploadChunkGetAllWithSQlite(db *gorm.DB) UploadChunkGetAll {
+	return func(ctx context.Context, req UploadChunkGetAllReq) (*UploadChunkGetAllRes, error) {
+
+		var chunks []model.UploadChunk
+
+		if err := utility.GetDBFromContext(ctx, db).
+			Where("upload_id = ?", req.UploadID).
+			Order("chunk_index").
+			Find(&chunks).Error; err != nil {
+			return nil, err
+		}
+
+		return &UploadChunkGetAllRes{Chunks: chunks}, nil
+	}
+}
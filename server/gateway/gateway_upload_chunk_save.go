@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadChunkSaveReq struct {
+	Chunk *model.UploadChunk
+}
+
+type UploadChunkSaveRes struct{}
+
+type UploadChunkSave = core.ActionHandler[UploadChunkSaveReq, UploadChunkSaveRes]
+
+func ImplUploadChunkSaveWithSQlite(db *gorm.DB) UploadChunkSave {
+	return func(ctx context.Context, req UploadChunkSaveReq) (*UploadChunkSaveRes, error) {
+
+		// a re-sent chunk replaces the previous copy
+		if err := utility.GetDBFromContext(ctx, db).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "upload_id"}, {Name: "chunk_index"}},
+			DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+		}).Create(req.Chunk).Error; err != nil {
+			return nil, err
+		}
+
+		return &UploadChunkSaveRes{}, nil
+	}
+}
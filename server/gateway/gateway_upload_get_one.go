@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type UploadGetOneReq struct {
+	UploadID string
+}
+
+type UploadGetOneRes struct {
+	Upload         *model.Upload // nil when not found
+	ReceivedChunks []int
+}
+
+type UploadGetOne = core.ActionHandler[UploadGetOneReq, UploadGetOneRes]
+
+func ImplUploadGetOneWithSQlite(db *gorm.DB) UploadGetOne {
+	return func(ctx context.Context, req UploadGetOneReq) (*UploadGetOneRes, error) {
+
+		var upload model.Upload
+
+		err := utility.GetDBFromContext(ctx, db).Where("upload_id = ?", req.UploadID).First(&upload).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &UploadGetOneRes{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		received := []int{}
+		if err := utility.GetDBFromContext(ctx, db).
+			Model(&model.UploadChunk{}).
+			Where("upload_id = ?", req.UploadID).
+			Order("chunk_index").
+			Pluck("chunk_index", &received).Error; err != nil {
+			return nil, err
+		}
+
+		return &UploadGetOneRes{Upload: &upload, ReceivedChunks: received}, nil
+	}
+}
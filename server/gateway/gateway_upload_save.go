@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type UploadSaveReq struct {
+	Upload *model.Upload
+}
+
+type UploadSaveRes struct{}
+
+type UploadSave = core.ActionHandler[UploadSaveReq, UploadSaveRes]
+
+func ImplUploadSaveWithSQlite(db *gorm.DB) UploadSave {
+	return func(ctx context.Context, req UploadSaveReq) (*UploadSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Save(req.Upload).Error; err != nil {
+			return nil, err
+		}
+
+		return &UploadSaveRes{}, nil
+	}
+}
@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type WebhookDeleteReq struct {
+	ID uint
+}
+
+type WebhookDeleteRes struct {
+	Deleted bool
+}
+
+type WebhookDelete = core.ActionHandler[WebhookDeleteReq, WebhookDeleteRes]
+
+// ImplWebhookDeleteWithSQlite removes the webhook, its deliveries still pending fail on their next attempt
+func ImplWebhookDeleteWithSQlite(db *gorm.DB) WebhookDelete {
+	return func(ctx context.Context, req WebhookDeleteReq) (*WebhookDeleteRes, error) {
+
+		result := utility.GetDBFromContext(ctx, db).Delete(&model.Webhook{}, req.ID)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return &WebhookDeleteRes{Deleted: result.RowsAffected > 0}, nil
+	}
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryClaimDueReq struct {
+	Now   time.Time
+	Limit int
+	// Token marks the rows of this claim, Lease pushes their next attempt so no other instance takes
+	// them while they are posted. A claim whose instance died is due again once the lease ends.
+	Token string
+	Lease time.Duration
+}
+
+type WebhookDeliveryClaimDueRes struct {
+	// Deliveries are the oldest due first, Webhook is nil when the webhook was removed since
+	Deliveries []model.WebhookDelivery
+}
+
+type WebhookDeliveryClaimDue = core.ActionHandler[WebhookDeliveryClaimDueReq, WebhookDeliveryClaimDueRes]
+
+// ImplWebhookDeliveryClaimDueWithSQlite takes the due deliveries with one conditional UPDATE, a row
+// another instance claimed first no longer matches it, then reads back the rows carrying the token
+func ImplWebhookDeliveryClaimDueWithSQlite(db *gorm.DB) WebhookDeliveryClaimDue {
+	return func(ctx context.Context, req WebhookDeliveryClaimDueReq) (*WebhookDeliveryClaimDueRes, error) {
+
+		query := utility.GetDBFromContext(ctx, db)
+
+		due := query.Model(&model.WebhookDelivery{}).
+			Select("id").
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, req.Now).
+			Order("next_attempt_at, id")
+		if req.Limit > 0 {
+			due = due.Limit(req.Limit)
+		}
+
+		claim := query.Model(&model.WebhookDelivery{}).
+			Where("id IN (?) AND status = ? AND next_attempt_at <= ?", due, model.WebhookDeliveryPending, req.Now).
+			Updates(map[string]any{
+				"claim_token":     req.Token,
+				"next_attempt_at": req.Now.Add(req.Lease),
+			})
+		if claim.Error != nil {
+			return nil, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return &WebhookDeliveryClaimDueRes{}, nil
+		}
+
+		var deliveries []model.WebhookDelivery
+		if err := query.Preload("Webhook").
+			Where("claim_token = ? AND status = ?", req.Token, model.WebhookDeliveryPending).
+			Order("id").
+			Find(&deliveries).Error; err != nil {
+			return nil, err
+		}
+
+		return &WebhookDeliveryClaimDueRes{Deliveries: deliveries}, nil
+	}
+}
@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryGetAllReq struct {
+	WebhookID uint
+	Status    string         // optional
+	List      core.ListQuery // optional, all the deliveries when the size is 0
+}
+
+type WebhookDeliveryGetAllRes struct {
+	Deliveries []model.WebhookDelivery
+	Total      int64 // deliveries matching the filters, ignoring the page
+}
+
+type WebhookDeliveryGetAll = core.ActionHandler[WebhookDeliveryGetAllReq, WebhookDeliveryGetAllRes]
+
+// WebhookDeliveryListColumns are the fields the deliveries can be sorted and filtered by, with their column
+var WebhookDeliveryListColumns = map[string]string{
+	"id":              "id",
+	"event_id":        "event_id",
+	"event":           "event",
+	"status":          "status",
+	"attempts":        "attempts",
+	"next_attempt_at": "next_attempt_at",
+	"delivered_at":    "delivered_at",
+	"created_at":      "created_at",
+}
+
+func ImplWebhookDeliveryGetAllWithSQlite(db *gorm.DB) WebhookDeliveryGetAll {
+	return func(ctx context.Context, req WebhookDeliveryGetAllReq) (*WebhookDeliveryGetAllRes, error) {
+
+		var deliveries []model.WebhookDelivery
+
+		query := utility.GetDBFromContext(ctx, db).Model(&model.WebhookDelivery{}).Where("webhook_id = ?", req.WebhookID)
+		if req.Status != "" {
+			query = query.Where("status = ?", req.Status)
+		}
+
+		total, err := utility.ListPage(query, req.List, WebhookDeliveryListColumns, &deliveries)
+		if err != nil {
+			return nil, err
+		}
+
+		return &WebhookDeliveryGetAllRes{Deliveries: deliveries, Total: total}, nil
+	}
+}
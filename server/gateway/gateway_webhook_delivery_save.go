@@ -0,0 +1,31 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookDeliverySaveReq struct {
+	Delivery *model.WebhookDelivery
+}
+
+type WebhookDeliverySaveRes struct{}
+
+type WebhookDeliverySave = core.ActionHandler[WebhookDeliverySaveReq, WebhookDeliverySaveRes]
+
+// ImplWebhookDeliverySaveWithSQlite stores the outcome of an attempt, the preloaded webhook is not written back
+func ImplWebhookDeliverySaveWithSQlite(db *gorm.DB) WebhookDeliverySave {
+	return func(ctx context.Context, req WebhookDeliverySaveReq) (*WebhookDeliverySaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Omit(clause.Associations).Save(req.Delivery).Error; err != nil {
+			return nil, err
+		}
+
+		return &WebhookDeliverySaveRes{}, nil
+	}
+}
@@ -0,0 +1,89 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is one occurrence of an event, EventID lets receivers drop duplicates
+type WebhookEvent struct {
+	EventID string
+	Data    any
+}
+
+type WebhookEventEnqueueReq struct {
+	Event  string
+	Events []WebhookEvent // every occurrence is queued with one read of the webhooks
+	Now    time.Time
+}
+
+type WebhookEventEnqueueRes struct {
+	Queued int // deliveries, one per occurrence and webhook subscribed to the event
+}
+
+type WebhookEventEnqueue = core.ActionHandler[WebhookEventEnqueueReq, WebhookEventEnqueueRes]
+
+// ImplWebhookEventEnqueueWithSQlite queues one delivery of every occurrence per webhook subscribed to it, the
+// webhook dispatcher posts them. Queuing in the database lets a delivery survive a restart and keeps the
+// request that raised the event from waiting on a slow receiver.
+func ImplWebhookEventEnqueueWithSQlite(db *gorm.DB) WebhookEventEnqueue {
+	return func(ctx context.Context, req WebhookEventEnqueueReq) (*WebhookEventEnqueueRes, error) {
+
+		query := utility.GetDBFromContext(ctx, db)
+
+		// the events are a JSON column, the few webhooks are filtered here rather than in SQL
+		var webhooks []model.Webhook
+		if err := query.Find(&webhooks).Error; err != nil {
+			return nil, err
+		}
+
+		var subscribed []model.Webhook
+		for _, webhook := range webhooks {
+			if slices.Contains(webhook.Events, req.Event) {
+				subscribed = append(subscribed, webhook)
+			}
+		}
+		if len(subscribed) == 0 {
+			return &WebhookEventEnqueueRes{}, nil
+		}
+
+		deliveries := make([]model.WebhookDelivery, 0, len(req.Events)*len(subscribed))
+		for _, event := range req.Events {
+			payload, err := json.Marshal(model.WebhookPayload{
+				EventID:   event.EventID,
+				Event:     req.Event,
+				CreatedAt: req.Now,
+				Data:      event.Data,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, webhook := range subscribed {
+				deliveries = append(deliveries, model.WebhookDelivery{
+					EventID:       event.EventID,
+					WebhookID:     webhook.ID,
+					Event:         req.Event,
+					Payload:       string(payload),
+					Status:        model.WebhookDeliveryPending,
+					NextAttemptAt: req.Now,
+				})
+			}
+		}
+		if len(deliveries) == 0 {
+			return &WebhookEventEnqueueRes{}, nil
+		}
+
+		if err := query.Create(&deliveries).Error; err != nil {
+			return nil, err
+		}
+
+		return &WebhookEventEnqueueRes{Queued: len(deliveries)}, nil
+	}
+}
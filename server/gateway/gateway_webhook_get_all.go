@@ -0,0 +1,30 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type WebhookGetAllReq struct{}
+
+type WebhookGetAllRes struct {
+	Webhooks []model.Webhook
+}
+
+type WebhookGetAll = core.ActionHandler[WebhookGetAllReq, WebhookGetAllRes]
+
+func ImplWebhookGetAllWithSQlite(db *gorm.DB) WebhookGetAll {
+	return func(ctx context.Context, req WebhookGetAllReq) (*WebhookGetAllRes, error) {
+
+		var webhooks []model.Webhook
+		if err := utility.GetDBFromContext(ctx, db).Order("id").Find(&webhooks).Error; err != nil {
+			return nil, err
+		}
+
+		return &WebhookGetAllRes{Webhooks: webhooks}, nil
+	}
+}
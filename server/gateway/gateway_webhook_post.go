@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"shared/core"
+	"strconv"
+	"time"
+)
+
+type WebhookPostReq struct {
+	URL        string
+	Secret     string
+	Event      string
+	EventID    string
+	DeliveryID uint
+	Payload    []byte
+	Now        time.Time
+}
+
+type WebhookPostRes struct {
+	StatusCode int
+}
+
+type WebhookPost = core.ActionHandler[WebhookPostReq, WebhookPostRes]
+
+// ImplWebhookPost posts the payload with its signature: X-Webhook-Signature is sha256= followed by the hex
+// HMAC-SHA256, keyed by the secret of the webhook, of X-Webhook-Timestamp, a dot and the body. Receivers
+// recompute it and reject old timestamps so a captured request cannot be replayed. Any answer, 2xx or not,
+// is a result, the error is for a request that got no answer.
+func ImplWebhookPost(timeout time.Duration) WebhookPost {
+
+	client := &http.Client{
+		Timeout: timeout,
+		// a receiver moving its endpoint must re-register it, the signed request is not replayed elsewhere
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	return func(ctx context.Context, req WebhookPostReq) (*WebhookPostRes, error) {
+
+		timestamp := strconv.FormatInt(req.Now.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(req.Secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(req.Payload)
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("User-Agent", "sse-scan-server-webhook")
+		httpReq.Header.Set("X-Webhook-Event", req.Event)
+		httpReq.Header.Set("X-Webhook-Event-Id", req.EventID)
+		httpReq.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(req.DeliveryID), 10))
+		httpReq.Header.Set("X-Webhook-Timestamp", timestamp)
+		httpReq.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		// the body is not used, reading a little of it lets the connection be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+		return &WebhookPostRes{StatusCode: resp.StatusCode}, nil
+	}
+}
@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+
+	"gorm.io/gorm"
+)
+
+type WebhookSaveReq struct {
+	Webhook *model.Webhook
+}
+
+type WebhookSaveRes struct{}
+
+type WebhookSave = core.ActionHandler[WebhookSaveReq, WebhookSaveRes]
+
+func ImplWebhookSaveWithSQlite(db *gorm.DB) WebhookSave {
+	return func(ctx context.Context, req WebhookSaveReq) (*WebhookSaveRes, error) {
+
+		if err := utility.GetDBFromContext(ctx, db).Save(req.Webhook).Error; err != nil {
+			return nil, err
+		}
+
+		return &WebhookSaveRes{}, nil
+	}
+}
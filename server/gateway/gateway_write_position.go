@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// consistency tokens are opaque to callers, the prefix lets the format change later
+const consistencyTokenPrefix = "wp1."
+
+type WritePositionAdvanceReq struct{}
+
+type WritePositionAdvanceRes struct {
+	ConsistencyToken string
+}
+
+type WritePositionAdvance = core.ActionHandler[WritePositionAdvanceReq, WritePositionAdvanceRes]
+
+// ImplWritePositionAdvanceWithSQlite bumps the write position after the writes of a mutation, inside
+// their transaction when the context carries one so the token commits with them
+func ImplWritePositionAdvanceWithSQlite(db *gorm.DB) WritePositionAdvance {
+	return func(ctx context.Context, req WritePositionAdvanceReq) (*WritePositionAdvanceRes, error) {
+
+		var position model.WritePosition
+
+		err := utility.GetDBFromContext(ctx, db).Transaction(func(tx *gorm.DB) error {
+			// upsert agar baris pertama tidak perlu disiapkan saat migrasi
+			if err := tx.Exec("INSERT INTO write_positions (id, seq) VALUES (1, 1) ON CONFLICT(id) DO UPDATE SET seq = seq + 1").Error; err != nil {
+				return err
+			}
+			return tx.First(&position, 1).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &WritePositionAdvanceRes{ConsistencyToken: consistencyTokenPrefix + strconv.FormatUint(position.Seq, 10)}, nil
+	}
+}
+
+type WritePositionWaitReq struct {
+	ConsistencyToken string // empty returns at once
+}
+
+type WritePositionWaitRes struct{}
+
+type WritePositionWait = core.ActionHandler[WritePositionWaitReq, WritePositionWaitRes]
+
+// ImplWritePositionWaitWithSQlite blocks a read until the database it reads from has caught up with
+// the write behind the token, giving up after timeout. A replica lagging behind, or a write whose
+// transaction has not committed yet, is then waited for instead of answering without it.
+func ImplWritePositionWaitWithSQlite(db *gorm.DB, timeout time.Duration) WritePositionWait {
+	return func(ctx context.Context, req WritePositionWaitReq) (*WritePositionWaitRes, error) {
+
+		if req.ConsistencyToken == "" {
+			return &WritePositionWaitRes{}, nil
+		}
+
+		seq, err := parseConsistencyToken(req.ConsistencyToken)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		poll := time.NewTicker(20 * time.Millisecond)
+		defer poll.Stop()
+
+		for {
+			var position model.WritePosition
+			err := utility.GetDBFromContext(ctx, db).First(&position, 1).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && ctx.Err() == nil {
+				return nil, core.NewInternalServerError(err)
+			}
+			if position.Seq >= seq {
+				return &WritePositionWaitRes{}, nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, core.NewGatewayTimeoutError(fmt.Errorf("the write behind the consistency token is not visible yet, retry the request"))
+			case <-poll.C:
+			}
+		}
+	}
+}
+
+func parseConsistencyToken(token string) (uint64, error) {
+	value, ok := strings.CutPrefix(token, consistencyTokenPrefix)
+	if !ok {
+		return 0, fmt.Errorf("invalid consistency token")
+	}
+	seq, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || seq == 0 {
+		return 0, fmt.Errorf("invalid consistency token")
+	}
+	return seq, nil
+}
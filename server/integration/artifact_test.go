@@ -0,0 +1,16 @@
+package integration
+
+import (
+	"net/http"
+	"server/model"
+	"testing"
+)
+
+func TestDownloadURLOfAMissingArtifactIsNotFound(t *testing.T) {
+	server := startServer(t)
+	agent := server.token(t, "agent-1", model.RoleAgent)
+
+	if got := server.status(agent, http.MethodGet, "/api/artifacts/42/download-url", nil); got != http.StatusNotFound {
+		t.Fatalf("download url: got status %d, want %d", got, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"server/model"
+	"testing"
+	"time"
+)
+
+func TestPresenceIsBoundToTheToken(t *testing.T) {
+	server := startServer(t)
+	agent := server.token(t, "agent-1", model.RoleAgent)
+
+	// an agent the server does not know is told apart from a malformed heartbeat
+	server.call(t, agent, http.MethodPost, "/api/clients/heartbeat", map[string]any{}, http.StatusNotFound, nil)
+
+	server.call(t, agent, http.MethodPost, "/api/clients/register", map[string]any{"client_id": "agent-2", "version": "1.0.0"}, http.StatusBadRequest, nil)
+	server.call(t, agent, http.MethodPost, "/api/clients/register", map[string]any{"client_id": "agent-1", "version": "1.0.0"}, http.StatusOK, nil)
+
+	server.call(t, agent, http.MethodPost, "/api/clients/heartbeat", map[string]any{"client_id": "agent-1"}, http.StatusOK, nil)
+	server.call(t, agent, http.MethodPost, "/api/clients/heartbeat", map[string]any{"client_id": "agent-2"}, http.StatusBadRequest, nil)
+}
+
+func TestStreamAndAckAreBoundToTheToken(t *testing.T) {
+	server := startServer(t)
+	connectAgent(t, server, "agent-1")
+	agent := server.token(t, "agent-1", model.RoleAgent)
+
+	if status := server.status(agent, http.MethodGet, "/api/sse/connect?client_id=agent-2", nil); status != http.StatusUnauthorized {
+		t.Fatalf("connect as agent-2: got status %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	server.call(t, agent, http.MethodPost, "/api/sse/ack", map[string]any{"client_id": "agent-2", "message_id": "msg-1"}, http.StatusForbidden, nil)
+	server.call(t, agent, http.MethodPost, "/api/sse/ack", map[string]any{"message_id": "msg-1"}, http.StatusNoContent, nil)
+
+	if acks := server.SSE.GetAcks("msg-1"); len(acks) != 1 || acks[0] != "agent-1" {
+		t.Fatalf("acks of msg-1: got %v, want the agent of the token", acks)
+	}
+}
+
+func TestReconnectReplacesTheStreamOfTheSameAgent(t *testing.T) {
+	server := startServer(t)
+	agent := server.token(t, "agent-1", model.RoleAgent)
+
+	first := openStream(t, server, agent, "agent-1")
+	waitFor(t, "agent-1 to connect", func() bool { return server.SSE.IsClientConnected("agent-1") })
+
+	// the previous stream is not noticed as dead yet, the reconnect takes over instead of a 409
+	openStream(t, server, agent, "agent-1")
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, first.Body)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the previous stream of agent-1 was not closed")
+	}
+	if count := server.SSE.GetConnectedClientCount(); count != 1 {
+		t.Fatalf("connected clients: got %d, want the new stream only", count)
+	}
+}
+
+func TestSequenceIsForgottenOnDisconnect(t *testing.T) {
+	server := startServer(t)
+	agent := server.token(t, "agent-1", model.RoleAgent)
+
+	openStream(t, server, agent, "agent-1")
+	waitFor(t, "the first frame to agent-1", func() bool {
+		_, found := server.SSE.LastEventID("agent-1")
+		return found
+	})
+	before, _ := server.SSE.LastEventID("agent-1")
+
+	// the stream replacing the previous one continues its ids
+	second := openStream(t, server, agent, "agent-1")
+	waitFor(t, "the replacing stream to write", func() bool {
+		id, _ := server.SSE.LastEventID("agent-1")
+		return id > before
+	})
+
+	second.Body.Close()
+	waitFor(t, "agent-1 to disconnect", func() bool { return !server.SSE.IsClientConnected("agent-1") })
+	waitFor(t, "the ids of agent-1 to be forgotten", func() bool {
+		_, found := server.SSE.LastEventID("agent-1")
+		return !found
+	})
+}
+
+// openStream connects to the SSE stream without an SSEClient, so nothing reconnects on its own
+func openStream(t *testing.T, server *testServer, token, clientID string) *http.Response {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/sse/connect?client_id="+clientID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("connect %s: status %d, want %d", clientID, resp.StatusCode, http.StatusOK)
+	}
+	return resp
+}
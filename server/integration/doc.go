@@ -0,0 +1,3 @@
+// Package integration boots the server modules behind httptest with an in-memory SQLite and drives
+// them with a real SSEClient standing in for an agent, from the trigger of a scan to its report.
+package integration
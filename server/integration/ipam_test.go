@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIPAMMatchesAddressesBySubnet(t *testing.T) {
+	server := startServer(t)
+	admin := server.token(t, "root", model.RoleAdmin)
+
+	var wide, narrow usecase.IPAMSubnetCreateRes
+	server.call(t, admin, http.MethodPost, "/api/ipam/subnets", usecase.IPAMSubnetCreateReq{CIDR: "10.1.0.0/16"}, http.StatusOK, &wide)
+	server.call(t, admin, http.MethodPost, "/api/ipam/subnets", usecase.IPAMSubnetCreateReq{CIDR: "10.3.0.0/26"}, http.StatusOK, &narrow)
+
+	// inside an existing subnet, holding one, then next to both
+	server.call(t, admin, http.MethodPost, "/api/ipam/subnets", usecase.IPAMSubnetCreateReq{CIDR: "10.1.2.0/24"}, http.StatusBadRequest, nil)
+	server.call(t, admin, http.MethodPost, "/api/ipam/subnets", usecase.IPAMSubnetCreateReq{CIDR: "10.0.0.0/8"}, http.StatusBadRequest, nil)
+	server.call(t, admin, http.MethodPost, "/api/ipam/subnets", usecase.IPAMSubnetCreateReq{CIDR: "10.3.0.64/26"}, http.StatusOK, nil)
+
+	var allocated usecase.IPAllocationSetRes
+	server.call(t, admin, http.MethodPut, "/api/ipam/addresses/10.3.0.5", usecase.IPAllocationSetBody{Status: model.IPFree}, http.StatusOK, &allocated)
+	if allocated.Allocation.SubnetID != narrow.Subnet.ID {
+		t.Fatalf("allocation: got subnet %d, want %d", allocated.Allocation.SubnetID, narrow.Subnet.ID)
+	}
+	server.call(t, admin, http.MethodPut, "/api/ipam/addresses/10.4.0.1", usecase.IPAllocationSetBody{Status: model.IPFree}, http.StatusBadRequest, nil)
+
+	for _, ip := range []string{"10.1.0.9", "10.1.200.1", "10.10.0.1", "10.3.0.5", "10.3.0.63", "10.3.0.64"} {
+		if err := server.DB.Create(&model.ScanResult{ClientID: "agent-1", IP: ip, Timestamp: time.Now(), Protocol: "icmp", Status: model.ScanResultOnline}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	addresses := func(subnetID uint) []string {
+		var res usecase.IPAMAddressGetAllRes
+		server.call(t, admin, http.MethodGet, fmt.Sprintf("/api/ipam/subnets/%d/addresses", subnetID), nil, http.StatusOK, &res)
+		var ips []string
+		for _, address := range res.Addresses {
+			ips = append(ips, address.IP)
+		}
+		return ips
+	}
+	if ips := addresses(wide.Subnet.ID); !slices.Equal(ips, []string{"10.1.0.9", "10.1.200.1"}) {
+		t.Fatalf("10.1.0.0/16 addresses: got %v", ips)
+	}
+	if ips := addresses(narrow.Subnet.ID); !slices.Equal(ips, []string{"10.3.0.5", "10.3.0.63"}) {
+		t.Fatalf("10.3.0.0/26 addresses: got %v", ips)
+	}
+}
@@ -0,0 +1,44 @@
+package integration
+
+import (
+	"context"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	sharedUtility "shared/utility"
+	"testing"
+	"time"
+)
+
+func TestOfflineMessagesExpireOnTheStoreClock(t *testing.T) {
+	db := openDB(t)
+	if err := db.AutoMigrate(&model.SSEPendingMessage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// far from the wall clock, an age measured on time.Now would drop the message at once
+	clock := core.NewFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := utility.NewGormOfflineStore(db, time.Hour, clock)
+	ctx := context.Background()
+
+	if err := store.Save(ctx, "agent-1", sharedUtility.Message{ID: "m1", EventType: "scan_icmp", Data: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(30 * time.Minute)
+	pending, err := store.Pending(ctx, "agent-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || !pending[0].StoredAt.Equal(clock.Now().Add(-30*time.Minute)) {
+		t.Fatalf("pending: got %+v, want m1 stored on the clock", pending)
+	}
+
+	clock.Advance(time.Hour)
+	if pending, err = store.Pending(ctx, "agent-1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("pending: got %+v, want m1 dropped once older than the max age", pending)
+	}
+}
@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"testing"
+)
+
+func TestRolloutInProgressResumesAtStartup(t *testing.T) {
+	db := openDB(t)
+
+	// the previous run stopped after the canary succeeded
+	if err := db.AutoMigrate(&model.Rollout{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.Rollout{
+		RolloutID:         "rollout-1",
+		EventType:         "resource_limits",
+		Payload:           json.RawMessage("{}"),
+		CanaryPercent:     50,
+		MinSuccessPercent: 100,
+		AckWindowMs:       100,
+		Canary:            []string{"agent-1"},
+		Remainder:         []string{"agent-2"},
+		CanaryAcked:       []string{"agent-1"},
+		RemainderAcked:    []string{},
+		Status:            model.RolloutProceeding,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	server := startServerOn(t, db)
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	waitFor(t, "rollout-1 to complete", func() bool {
+		var res usecase.RolloutGetOneRes
+		server.call(t, operator, http.MethodGet, "/api/rollouts/rollout-1", nil, http.StatusOK, &res)
+		return res.Rollout.Status == model.RolloutCompleted
+	})
+}
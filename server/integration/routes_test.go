@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"path/filepath"
+	"shared/utility"
+	"testing"
+)
+
+// TestEveryRouteDeclaresItsAccess catches at test time what Authorize would only refuse once a request
+// arrives: a controller route without an Access, or a route main.go puts on the mux behind the back of
+// the ApiPrinter
+func TestEveryRouteDeclaresItsAccess(t *testing.T) {
+	controllers, err := filepath.Glob("../controller/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	for _, path := range append(controllers, "../main.go") {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.CompositeLit:
+				if isSelector(n.Type, "utility", "APIData") && !hasKey(n, "Access") {
+					t.Errorf("%s: utility.APIData without an Access", fset.Position(n.Pos()))
+				}
+			case *ast.CallExpr:
+				if isSelector(n.Fun, "mux", "Handle") || isSelector(n.Fun, "mux", "HandleFunc") {
+					t.Errorf("%s: route registered on the mux directly, use ApiPrinter.HandleRaw", fset.Position(n.Pos()))
+				}
+			}
+			return true
+		})
+	}
+}
+
+func isSelector(expr ast.Expr, x, sel string) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	return ok && ident.Name == x && selector.Sel.Name == sel
+}
+
+func hasKey(lit *ast.CompositeLit, key string) bool {
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if ident, ok := kv.Key.(*ast.Ident); ok && ident.Name == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestResponsesCarryARequestID(t *testing.T) {
+	server := startServer(t)
+
+	seen := map[string]bool{}
+	for range 2 {
+		resp, err := http.Get(server.URL + "/api/scan-jobs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		id := resp.Header.Get("X-Request-ID")
+		if id == "" || seen[id] {
+			t.Fatalf("X-Request-ID: got %q, want a new id on every response", id)
+		}
+		seen[id] = true
+	}
+}
+
+// TestAddRefusesARouteWithoutAccess covers the routes added once the spec is published, ValidateAccess
+// ran long before them
+func TestAddRefusesARouteWithoutAccess(t *testing.T) {
+	apiPrinter := utility.NewApiPrinter()
+	apiPrinter.PublishAPI(http.NewServeMux(), "http://localhost", "/openapi")
+
+	if err := apiPrinter.Add(utility.APIData{Method: http.MethodGet, Url: "/api/late", Access: utility.Public}); err != nil {
+		t.Fatalf("declared route: %v", err)
+	}
+	if err := apiPrinter.Add(utility.APIData{Method: http.MethodGet, Url: "/api/open"}); err == nil {
+		t.Fatal("route without an access was added")
+	}
+}
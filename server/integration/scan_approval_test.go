@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"testing"
+)
+
+func TestScanApprovalNeedsAnotherOperator(t *testing.T) {
+	server := startServer(t)
+	agent := connectAgent(t, server, "agent-1")
+	alice := server.token(t, "alice", model.RoleOperator)
+	bob := server.token(t, "bob", model.RoleOperator)
+
+	// more hosts than the approval policy lets through unattended
+	var pending usecase.ScanICMPTriggerRes
+	server.call(t, alice, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs: []string{agent.ClientID},
+		IPRange:   "10.50.0.0/21",
+	}, http.StatusOK, &pending)
+	if pending.Status != model.ScanJobPendingApproval {
+		t.Fatalf("trigger: got status %s, want %s", pending.Status, model.ScanJobPendingApproval)
+	}
+
+	server.call(t, alice, http.MethodPost, "/api/scan-jobs/"+pending.JobID+"/approve", nil, http.StatusBadRequest, nil)
+
+	var approved usecase.ScanICMPTriggerRes
+	server.call(t, bob, http.MethodPost, "/api/scan-jobs/"+pending.JobID+"/approve", nil, http.StatusOK, &approved)
+	if approved.Status != model.ScanJobDispatched {
+		t.Fatalf("approval: got status %s, want %s", approved.Status, model.ScanJobDispatched)
+	}
+	if command := agent.nextCommand(t); command.JobID != pending.JobID {
+		t.Fatalf("command: got job %s, want the approved job %s", command.JobID, pending.JobID)
+	}
+}
+
+func TestReadGivesUpOnATokenAheadOfTheDatabase(t *testing.T) {
+	server := startServer(t)
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	// no write has reached this position, the read waits it out and tells the caller to retry
+	if got := server.status(operator, http.MethodGet, "/api/scan-jobs?consistency_token=wp1.1000000", nil); got != http.StatusGatewayTimeout {
+		t.Fatalf("read: got status %d, want %d", got, http.StatusGatewayTimeout)
+	}
+}
+
+func TestOperatorHeaderCannotBeSpoofed(t *testing.T) {
+	server := startServer(t)
+	agent := connectAgent(t, server, "agent-1")
+	alice := server.token(t, "alice", model.RoleOperator)
+
+	var pending usecase.ScanICMPTriggerRes
+	server.call(t, alice, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs: []string{agent.ClientID},
+		IPRange:   "10.50.0.0/21",
+	}, http.StatusOK, &pending)
+
+	// the operator is the user of the token, an approval naming another one in the header is still alice's own
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/scan-jobs/"+pending.JobID+"/approve", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+alice)
+	req.Header.Set("X-Operator", "bob")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("approval: got status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
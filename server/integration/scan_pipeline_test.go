@@ -0,0 +1,258 @@
+package integration
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"shared/utility"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestTriggerScanReport(t *testing.T) {
+	server := startServer(t)
+	agent := connectAgent(t, server, "agent-1")
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	var triggered usecase.ScanICMPTriggerRes
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs: []string{agent.ClientID},
+		IPRange:   "10.20.0.0/30",
+	}, http.StatusOK, &triggered)
+
+	if triggered.Status != model.ScanJobDispatched || !triggered.Acknowledged {
+		t.Fatalf("trigger: got status %s acknowledged %v, want a dispatched and acknowledged job", triggered.Status, triggered.Acknowledged)
+	}
+
+	command := agent.nextCommand(t)
+	if command.JobID != triggered.JobID || command.IPRange != "10.20.0.0/30" {
+		t.Fatalf("command: got job %s range %s, want job %s range 10.20.0.0/30", command.JobID, command.IPRange, triggered.JobID)
+	}
+
+	sent := agent.runScan(t, command)
+
+	completed := agent.nextCompleted(t, triggered.JobID)
+	if completed.Status != model.ScanJobCompleted || completed.Reports != 1 || completed.Failed != 0 {
+		t.Fatalf("scan_completed: got %+v, want one completed report", completed)
+	}
+
+	var stored []model.ScanResult
+	server.call(t, operator, http.MethodGet, "/api/scan-results?job_id="+triggered.JobID, nil, http.StatusOK, &stored)
+	if len(stored) != len(sent) {
+		t.Fatalf("scan results: got %d, want the %d the agent uploaded", len(stored), len(sent))
+	}
+	for _, result := range stored {
+		if result.ClientID != agent.ClientID || result.JobID != triggered.JobID || result.Status != "online" {
+			t.Fatalf("scan result %+v does not belong to the job of %s", result, agent.ClientID)
+		}
+	}
+
+	var job usecase.ScanJobGetOneRes
+	server.call(t, operator, http.MethodGet, "/api/scan-jobs/"+triggered.JobID, nil, http.StatusOK, &job)
+	if job.ScanJob.Status != model.ScanJobCompleted {
+		t.Fatalf("scan job: got status %s, want %s", job.ScanJob.Status, model.ScanJobCompleted)
+	}
+}
+
+func TestQueuedScanReleasedByReport(t *testing.T) {
+	server := startServer(t)
+	agent := connectAgent(t, server, "agent-1")
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	var running usecase.ScanICMPTriggerRes
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs: []string{agent.ClientID},
+		IPRange:   "10.30.0.0/30",
+	}, http.StatusOK, &running)
+	first := agent.nextCommand(t)
+
+	// an overlapping range on the same agent waits for the running job
+	var queued usecase.ScanICMPTriggerRes
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs:  []string{agent.ClientID},
+		IPRange:    "10.30.0.0/29",
+		OnConflict: usecase.ScanConflictQueue,
+	}, http.StatusOK, &queued)
+	if queued.Status != model.ScanJobQueued {
+		t.Fatalf("overlapping trigger: got status %s, want %s", queued.Status, model.ScanJobQueued)
+	}
+
+	agent.runScan(t, first)
+	agent.nextCompleted(t, running.JobID)
+
+	released := agent.nextCommand(t)
+	if released.JobID != queued.JobID {
+		t.Fatalf("released command: got job %s, want the queued job %s", released.JobID, queued.JobID)
+	}
+}
+
+func TestTriggerFailsForAnAgentFilteringTheCommandOut(t *testing.T) {
+	server := startServer(t)
+	agent := connectAgent(t, server, "agent-1", "scan_completed")
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs: []string{agent.ClientID},
+		IPRange:   "10.70.0.0/30",
+	}, http.StatusBadRequest, nil)
+
+	var jobs usecase.ScanJobGetAllRes
+	server.call(t, operator, http.MethodGet, "/api/scan-jobs", nil, http.StatusOK, &jobs)
+	if len(jobs.ScanJobs) != 1 || jobs.ScanJobs[0].Status != model.ScanJobFailed {
+		t.Fatalf("scan jobs: got %+v, want the one job failed", jobs.ScanJobs)
+	}
+}
+
+func TestConcurrentTriggersAdmitOneJob(t *testing.T) {
+	server := startServer(t)
+	agent := connectAgent(t, server, "agent-1")
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	// different ranges so the dedupe window does not coalesce them, every one overlaps the others
+	ranges := []string{"10.60.0.0/30", "10.60.0.0/29", "10.60.0.0/28", "10.60.0.0/27"}
+
+	statuses := make(chan int, len(ranges))
+	var wg sync.WaitGroup
+	for _, ipRange := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- server.status(operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+				ClientIDs: []string{agent.ClientID},
+				IPRange:   ipRange,
+			})
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	admitted := 0
+	for status := range statuses {
+		switch status {
+		case http.StatusOK:
+			admitted++
+		case http.StatusBadRequest:
+		default:
+			t.Fatalf("trigger: unexpected status %d", status)
+		}
+	}
+	if admitted != 1 {
+		t.Fatalf("admitted %d overlapping jobs, want exactly one", admitted)
+	}
+}
+
+func TestRoutesNeedTheirAccess(t *testing.T) {
+	server := startServer(t)
+	agent := server.token(t, "agent-1", model.RoleAgent)
+
+	server.call(t, "", http.MethodGet, "/api/scan-jobs", nil, http.StatusUnauthorized, nil)
+	server.call(t, agent, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{IPRange: "10.40.0.0/30"}, http.StatusForbidden, nil)
+
+	// an upload cannot carry an agent into the routes of operators
+	server.call(t, agent, http.MethodPost, "/api/uploads", map[string]any{
+		"upload_id":    "escalate",
+		"target_path":  "/api/scan-jobs/job-1/approve",
+		"total_chunks": 1,
+		"checksums":    []string{"0"},
+	}, http.StatusBadRequest, nil)
+}
+
+func TestListRefusesAnInvalidPage(t *testing.T) {
+	server := startServer(t)
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	server.call(t, operator, http.MethodGet, "/api/clients?page=abc", nil, http.StatusBadRequest, nil)
+	server.call(t, operator, http.MethodGet, "/api/devices?size=0", nil, http.StatusBadRequest, nil)
+	server.call(t, operator, http.MethodGet, "/api/devices?sort=password", nil, http.StatusBadRequest, nil)
+
+	var devices []model.Device
+	server.call(t, operator, http.MethodGet, "/api/devices?page=2&size=10&sort=-last_seen", nil, http.StatusOK, &devices)
+}
+
+func TestBroadcastSkipsAnAgentLackingTheMethod(t *testing.T) {
+	server := startServer(t)
+	capable := connectAgent(t, server, "agent-1")
+	udpOnly := connectAgentWithMeta(t, server, "agent-2", map[string]string{utility.CommandsMetaKey: "udp,tcp"})
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	// no site owns the range, the command is broadcast
+	var triggered usecase.ScanICMPTriggerRes
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		IPRange: "10.80.0.0/30",
+	}, http.StatusOK, &triggered)
+
+	if scan := capable.nextCommand(t); scan.JobID != triggered.JobID {
+		t.Fatalf("command: got job %s, want %s", scan.JobID, triggered.JobID)
+	}
+	udpOnly.noCommand(t)
+
+	// named, the same agent is refused instead of being skipped
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs: []string{udpOnly.ClientID},
+		IPRange:   "10.81.0.0/30",
+	}, http.StatusBadRequest, nil)
+}
+
+func TestGroupsAreAssignedByTheServer(t *testing.T) {
+	server := startServer(t)
+	agent := connectAgentWithMeta(t, server, "agent-1", map[string]string{"groups": "dc1"})
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	// the metadata of the agent does not make it a member
+	if members := server.SSE.GetGroupMembers("dc1"); len(members) != 0 {
+		t.Fatalf("dc1 members: got %v, want none before an operator assigns the agent", members)
+	}
+	server.call(t, agent.token, http.MethodPut, "/api/clients/agent-1/groups", usecase.ClientGroupsSetBody{Groups: []string{"dc1"}}, http.StatusForbidden, nil)
+
+	server.call(t, operator, http.MethodPut, "/api/clients/agent-1/groups", usecase.ClientGroupsSetBody{Groups: []string{"dc1", "edge"}}, http.StatusOK, nil)
+
+	var triggered usecase.ScanICMPTriggerRes
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		Group:   "dc1",
+		IPRange: "10.90.0.0/30",
+	}, http.StatusOK, &triggered)
+	if scan := agent.nextCommand(t); scan.JobID != triggered.JobID {
+		t.Fatalf("command: got job %s, want %s", scan.JobID, triggered.JobID)
+	}
+
+	// the new groups replace the old ones, the group left empty is dropped
+	server.call(t, operator, http.MethodPut, "/api/clients/agent-1/groups", usecase.ClientGroupsSetBody{Groups: []string{"edge"}}, http.StatusOK, nil)
+	if members := server.SSE.GetGroupMembers("dc1"); len(members) != 0 {
+		t.Fatalf("dc1 members: got %v, want none once the agent left it", members)
+	}
+
+	var clients []model.Client
+	server.call(t, operator, http.MethodGet, "/api/clients?client_id=agent-1", nil, http.StatusOK, &clients)
+	if len(clients) != 1 || len(clients[0].Groups) != 1 || clients[0].Groups[0] != "edge" {
+		t.Fatalf("clients: got %+v, want agent-1 stored in edge", clients)
+	}
+}
+
+func TestNmapNeedsTheCapability(t *testing.T) {
+	server := startServer(t)
+	withoutNmap := connectAgentWithMeta(t, server, "agent-1", map[string]string{utility.CommandsMetaKey: "icmp,nmap", "cap_nmap": "false"})
+	withNmap := connectAgentWithMeta(t, server, "agent-2", map[string]string{utility.CommandsMetaKey: "icmp,nmap", "cap_nmap": "true"})
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	waitFor(t, "the capabilities to be recorded", func() bool {
+		var clients []model.Client
+		server.DB.Where("client_id IN ?", []string{withoutNmap.ClientID, withNmap.ClientID}).Find(&clients)
+		return len(clients) == 2
+	})
+
+	// announcing the command is not enough, nmap must be installed
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		ClientIDs: []string{withoutNmap.ClientID},
+		IPRange:   "10.85.0.0/30",
+		Method:    model.ScanMethodNmap,
+	}, http.StatusBadRequest, nil)
+
+	var clients []model.Client
+	server.call(t, operator, http.MethodGet, "/api/clients", nil, http.StatusOK, &clients)
+	for _, client := range clients {
+		if want := client.ClientID == withNmap.ClientID; slices.Contains(client.Capabilities, "cap_nmap") != want {
+			t.Fatalf("client %s: got capabilities %v", client.ClientID, client.Capabilities)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/model"
+	"shared/command"
+	"shared/utility"
+	"testing"
+	"time"
+)
+
+func TestScheduleUpdateReachesAgentsHandlingTheFormerName(t *testing.T) {
+	server := startServer(t)
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	// an agent released before the rename only handles scan_schedule
+	received := make(chan command.ScheduleUpdate, 4)
+	client, err := utility.NewSSEClient(utility.SSEClientConfig{
+		ServerURL:   server.URL,
+		ClientID:    "agent-1",
+		BearerToken: server.token(t, "agent-1", model.RoleAgent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.AddEventHandler("scan_schedule", func(data []byte) error {
+		var update command.ScheduleUpdate
+		if err := json.Unmarshal(data, &update); err != nil {
+			return err
+		}
+		received <- update
+		return nil
+	})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	waitFor(t, "agent-1 to connect", func() bool { return server.SSE.IsClientConnected("agent-1") })
+
+	server.call(t, operator, http.MethodPut, "/api/clients/agent-1/scan-schedules", command.ScheduleUpdate{
+		Schedules: []command.ScanSchedule{{Name: "nightly", Cron: "@daily", IPRange: "10.95.0.0/30"}},
+	}, http.StatusOK, nil)
+
+	select {
+	case update := <-received:
+		if len(update.Schedules) != 1 || update.Schedules[0].Name != "nightly" {
+			t.Fatalf("scan_schedule: got %+v, want the nightly schedule", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent-1 received no scan_schedule")
+	}
+}
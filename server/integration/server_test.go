@@ -0,0 +1,365 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"server/controller"
+	"server/model"
+	"server/wiring"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testSecret = "integration-secret"
+
+// testServer is the server of main.go without its optional parts, the redis broker, the dashboard
+// feed and the soak test detector
+type testServer struct {
+	URL       string
+	SSE       *utility.SSEServer
+	DB        *gorm.DB
+	tokenizer utility.JWTTokenizer
+}
+
+func startServer(t *testing.T) *testServer {
+	t.Helper()
+	return startServerOn(t, openDB(t))
+}
+
+// openDB opens an empty in-memory database, closed when the test ends
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection of the pool would open its own empty in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// startServerOn is startServer on a database the test prepared, e.g. with rows of an older schema
+func startServerOn(t *testing.T, db *gorm.DB) *testServer {
+	t.Helper()
+
+	tokenizer, err := utility.NewJWTTokenizer(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lifecycle := utility.NewLifecycle(5*time.Second, nil)
+	sseServer := utility.NewSSEServer(utility.SSEConfig{MaxConnections: 10, KeepAlive: time.Second, ClientIdentity: controller.AgentIdentity})
+	apiPrinter := utility.NewApiPrinter()
+	eventCatalog := utility.NewEventCatalog()
+	stats := utility.NewStatsRegistry()
+
+	mux := http.NewServeMux()
+	apiPrinter.
+		HandleRaw(mux, http.MethodGet, "/api/sse/connect", model.AccessAgent, sseServer.Handler()).
+		HandleRaw(mux, http.MethodPost, "/api/sse/ack", model.AccessAgent, http.HandlerFunc(sseServer.HandleAck))
+
+	handler := apiPrinter.Authorize(controller.RoleAuthorizer(tokenizer))(mux)
+	if err := wiring.SetupDependency(mux, handler, sseServer, nil, apiPrinter, eventCatalog, stats, lifecycle, nil, db, "test-artifact-url-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := httptest.NewServer(controller.RequestIDMiddleware(controller.Decompress(handler), core.NewULIDGenerator(core.RealClock{})))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		sseServer.Shutdown(ctx)
+		httpServer.Close()
+		lifecycle.Shutdown(ctx)
+	})
+
+	return &testServer{URL: httpServer.URL, SSE: sseServer, DB: db, tokenizer: tokenizer}
+}
+
+// token signs a token for the user with the roles as RoleAuthorizer expects it
+func (s *testServer) token(t *testing.T, userID string, roles ...string) string {
+	t.Helper()
+
+	content, err := json.Marshal(model.UserTokenPayload{UserID: userID, Roles: roles})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := s.tokenizer.CreateToken(content, time.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// call sends body as JSON with the bearer token and decodes the data of the response into out, it
+// fails the test unless the server answered with status
+func (s *testServer) call(t *testing.T, token, method, path string, body any, status int, out any) {
+	t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, resp.StatusCode, status, raw)
+	}
+	if out == nil {
+		return
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		t.Fatalf("%s %s: %v: %s", method, path, err, raw)
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		t.Fatalf("%s %s: %v: %s", method, path, err, envelope.Data)
+	}
+}
+
+// status sends body like call and only returns the status, it is safe to use from other goroutines
+func (s *testServer) status(token, method, path string, body any) int {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return 0
+	}
+	req, err := http.NewRequest(method, s.URL+path, bytes.NewReader(encoded))
+	if err != nil {
+		return 0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode
+}
+
+// fakeAgent is an agent connected over a real SSEClient, the commands it receives are handed to the
+// test instead of probing the network
+type fakeAgent struct {
+	ClientID  string
+	token     string
+	server    *testServer
+	commands  chan command.ScanRequest
+	completed chan model.ScanCompletedEvent
+}
+
+// connectAgent connects the agent, events limits the event types it subscribes to like SSEClientConfig.Events
+func connectAgent(t *testing.T, server *testServer, clientID string, events ...string) *fakeAgent {
+	t.Helper()
+	return connectAgentWithMeta(t, server, clientID, nil, events...)
+}
+
+// connectAgentWithMeta is connectAgent with connect metadata, e.g. the commands the agent announces
+func connectAgentWithMeta(t *testing.T, server *testServer, clientID string, meta map[string]string, events ...string) *fakeAgent {
+	t.Helper()
+
+	agent := &fakeAgent{
+		ClientID:  clientID,
+		token:     server.token(t, clientID, model.RoleAgent),
+		server:    server,
+		commands:  make(chan command.ScanRequest, 8),
+		completed: make(chan model.ScanCompletedEvent, 8),
+	}
+
+	client, err := utility.NewSSEClient(utility.SSEClientConfig{
+		ServerURL:   server.URL,
+		ClientID:    clientID,
+		BearerToken: agent.token,
+		Events:      events,
+		Metadata:    meta,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.AddEventHandler(command.ScanICMP.Event, func(data []byte) error {
+		scan, err := command.ScanICMP.Decode(data)
+		if err != nil {
+			return err
+		}
+		agent.commands <- scan
+		return nil
+	})
+	client.AddEventHandler("scan_completed", func(data []byte) error {
+		var event model.ScanCompletedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		agent.completed <- event
+		return nil
+	})
+
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	waitFor(t, "agent "+clientID+" to connect", func() bool { return server.SSE.IsClientConnected(clientID) })
+	return agent
+}
+
+// nextCommand waits for the scan command the server sends to the agent
+func (a *fakeAgent) nextCommand(t *testing.T) command.ScanRequest {
+	t.Helper()
+
+	select {
+	case scan := <-a.commands:
+		return scan
+	case <-time.After(5 * time.Second):
+		t.Fatalf("agent %s received no scan command", a.ClientID)
+		return command.ScanRequest{}
+	}
+}
+
+// noCommand fails the test when the agent receives a scan command within a short wait
+func (a *fakeAgent) noCommand(t *testing.T) {
+	t.Helper()
+
+	select {
+	case scan := <-a.commands:
+		t.Fatalf("agent %s received scan command %s", a.ClientID, scan.JobID)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// nextCompleted waits for the scan_completed broadcast of the job
+func (a *fakeAgent) nextCompleted(t *testing.T, jobID string) model.ScanCompletedEvent {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-a.completed:
+			if event.JobID == jobID {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("agent %s received no scan_completed for %s", a.ClientID, jobID)
+			return model.ScanCompletedEvent{}
+		}
+	}
+}
+
+// runScan answers a command like the agent does: the results of the fake ICMP probe go through a
+// chunked upload into /api/scan-devices-result, then the job is reported
+func (a *fakeAgent) runScan(t *testing.T, scan command.ScanRequest) []model.ScanResult {
+	t.Helper()
+
+	results := fakeICMP(t, a.ClientID, scan)
+
+	chunk, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(chunk)
+	uploadID := "upload-" + scan.JobID
+
+	a.server.call(t, a.token, http.MethodPost, "/api/uploads", map[string]any{
+		"upload_id":    uploadID,
+		"target_path":  fmt.Sprintf("/api/scan-devices-result?client_id=%s&job_id=%s", a.ClientID, scan.JobID),
+		"total_chunks": 1,
+		"total_items":  len(results),
+		"checksums":    []string{hex.EncodeToString(sum[:])},
+	}, http.StatusOK, nil)
+	a.server.call(t, a.token, http.MethodPut, "/api/uploads/"+uploadID+"/chunks/0", chunk, http.StatusOK, nil)
+	a.server.call(t, a.token, http.MethodPost, "/api/uploads/"+uploadID+"/complete", nil, http.StatusOK, nil)
+
+	now := time.Now()
+	a.server.call(t, a.token, http.MethodPost, "/api/scan-jobs/"+scan.JobID+"/report", map[string]any{
+		"client_id":   a.ClientID,
+		"status":      "completed",
+		"total_hosts": len(results),
+		"started_at":  now.Add(-time.Second),
+		"finished_at": now,
+	}, http.StatusOK, nil)
+
+	return results
+}
+
+// fakeICMP stands in for the ICMP gateway of the agent, every host of the range answers
+func fakeICMP(t *testing.T, clientID string, scan command.ScanRequest) []model.ScanResult {
+	t.Helper()
+
+	prefix, err := netip.ParsePrefix(scan.IPRange)
+	if err != nil {
+		t.Fatalf("fake ICMP only probes CIDR ranges: %v", err)
+	}
+
+	var results []model.ScanResult
+	for addr := prefix.Masked().Addr(); prefix.Contains(addr); addr = addr.Next() {
+		results = append(results, model.ScanResult{
+			ClientID:     clientID,
+			JobID:        scan.JobID,
+			IP:           addr.String(),
+			Timestamp:    time.Now(),
+			Protocol:     "icmp",
+			Status:       "online",
+			ResponseTime: 1.5,
+		})
+	}
+	return results
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"net/http"
+	"server/model"
+	"server/usecase"
+	"testing"
+	"time"
+)
+
+func TestSiteSubnetsBelongToOneSite(t *testing.T) {
+	server := startServer(t)
+	admin := server.token(t, "root", model.RoleAdmin)
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	var created usecase.SiteCreateRes
+	server.call(t, admin, http.MethodPost, "/api/sites", usecase.SiteCreateReq{Name: "hq", Subnets: []string{"10.10.0.0/24"}}, http.StatusOK, &created)
+
+	server.call(t, admin, http.MethodPost, "/api/sites", usecase.SiteCreateReq{Name: "branch", Subnets: []string{"10.10.0.128/25"}}, http.StatusBadRequest, nil)
+	server.call(t, admin, http.MethodPost, "/api/sites", usecase.SiteCreateReq{Name: "branch", Subnets: []string{"10.20.0.0/24", "10.20.0.10-10.20.0.20"}}, http.StatusBadRequest, nil)
+
+	connectAgent(t, server, "agent-1")
+	server.call(t, admin, http.MethodPut, "/api/sites/1/agents", usecase.SiteAssignAgentsBody{ClientIDs: []string{"agent-1"}}, http.StatusOK, nil)
+
+	// a site scan stays within the subnets of the site
+	server.call(t, operator, http.MethodPost, "/api/scan-devices-trigger", usecase.ScanICMPTriggerBody{
+		SiteID:  created.Site.ID,
+		IPRange: "10.10.1.0/30",
+	}, http.StatusBadRequest, nil)
+}
+
+func TestDuplicateClientsAreRemovedBeforeMigrating(t *testing.T) {
+	db := openDB(t)
+
+	// the registry before client_id was unique
+	if err := db.Exec("CREATE TABLE clients (id integer PRIMARY KEY AUTOINCREMENT, created_at datetime, updated_at datetime, deleted_at datetime, client_id text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, clientID := range []string{"agent-1", "agent-2", "agent-1"} {
+		if err := db.Exec("INSERT INTO clients (created_at, updated_at, client_id) VALUES (?, ?, ?)", now, now, clientID).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := startServerOn(t, db)
+	operator := server.token(t, "alice", model.RoleOperator)
+
+	var clients []model.Client
+	server.call(t, operator, http.MethodGet, "/api/clients", nil, http.StatusOK, &clients)
+	if len(clients) != 2 {
+		t.Fatalf("clients: got %+v, want agent-1 and agent-2 once", clients)
+	}
+	for _, client := range clients {
+		if client.ClientID == "agent-1" && client.ID != 3 {
+			t.Fatalf("agent-1: kept row %d, want the newest row 3", client.ID)
+		}
+	}
+}
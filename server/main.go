@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"server/controller"
 	"server/model"
 	"server/wiring"
+	"shared/core"
 	"shared/utility"
+	"syscall"
 	"time"
 
 	"gorm.io/driver/sqlite"
@@ -15,12 +22,70 @@ import (
 
 func main() {
 
+	// komponen dihentikan terbalik dari urutan pendaftaran, yang bergantung ke komponen lain berhenti lebih dulu
+	// TODO put into env
+	lifecycle := utility.NewLifecycle(10*time.Second, nil)
+
 	// Konfigurasi SSE
 	// TODO put into env
 	sseConfig := utility.SSEConfig{
 		MaxConnections: 1000,
 		KeepAlive:      15 * time.Second,
 		Origins:        []string{"*"}, // Untuk development, bisa lebih spesifik untuk production
+		// jeda reconnect yang disarankan ke client, dikirim sebagai retry: saat connect
+		ClientRetryHint: 3 * time.Second,
+		// seluruh stream dikompres jika client menerima gzip/deflate, payload scan dengan daftar IP panjang mengecil jauh
+		CompressStream: true,
+		// client_id agent harus sama dengan user id tokennya, agent tidak bisa connect atau ack atas nama agent lain
+		ClientIdentity: controller.AgentIdentity,
+		// agent dipilih dengan label meta_qos, link satelit diberi kelonggaran dan dashboard LAN dibuat ketat
+		QoSClasses: map[string]utility.QoSClass{
+			"satellite": {WriteTimeout: 30 * time.Second, QueueSize: 1024, KeepAlive: 45 * time.Second},
+			"lan":       {WriteTimeout: 2 * time.Second, QueueSize: 64, KeepAlive: 5 * time.Second},
+		},
+	}
+
+	// Broker redis untuk fan-out ke instance lain di belakang load balancer
+	if redisAddr := os.Getenv("REDIS_ADDR"); redisAddr != "" {
+		broker, err := utility.NewRedisBroker(utility.RedisBrokerConfig{
+			Addr:     redisAddr,
+			Password: os.Getenv("REDIS_PASSWORD"),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		lifecycle.Register("redis-broker", func(ctx context.Context) error { return broker.Close() })
+		sseConfig.Broker = broker
+	}
+
+	// Level log awal, bisa diubah saat berjalan lewat PUT /api/admin/log-level
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		parsed, err := utility.ParseLogLevel(level)
+		if err != nil {
+			log.Fatal(err)
+		}
+		utility.DefaultLogger().SetLevel(parsed)
+	}
+
+	// Span ditulis ke log jika diminta, traceparent tetap diteruskan walau tidak ada exporter
+	if os.Getenv("TRACE_EXPORTER") == "log" {
+		utility.SetSpanExporter(utility.LogSpanExporter{})
+	}
+
+	// Token API wajib, identitas operator dan agent diambil dari token yang terverifikasi
+	apiSecret := os.Getenv("API_JWT_SECRET")
+	if apiSecret == "" {
+		log.Fatal("API_JWT_SECRET is required")
+	}
+	apiTokenizer, err := utility.NewJWTTokenizer(apiSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Url download artifact bersifat public, hanya tanda tangan dengan secret ini yang melindunginya
+	artifactURLSecret := os.Getenv("ARTIFACT_URL_SECRET")
+	if artifactURLSecret == "" {
+		log.Fatal("ARTIFACT_URL_SECRET is required")
 	}
 
 	// TODO put into env
@@ -29,36 +94,187 @@ func main() {
 	if err != nil {
 		panic("failed to connect database")
 	}
-
-	db.AutoMigrate(&model.Client{})
+	lifecycle.Register("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
 
 	// Inisialisasi SSE server
 	sseServer := utility.NewSSEServer(sseConfig)
 
 	// inisialisasi HTTP server
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET  /api/sse/connect", sseServer.HandleSSE)
-
 	apiPrinter := utility.NewApiPrinter()
+	eventCatalog := utility.NewEventCatalog()
+
+	// route mentah tidak masuk dokumentasi, HandleRaw mendaftarkannya bersama aksesnya karena route tanpa deklarasi ditolak Authorize
+	apiPrinter.
+		HandleRaw(mux, http.MethodGet, "/api/sse/connect", model.AccessAgent, sseServer.Handler()). // middleware dari sseServer.Use ikut terpasang
+		HandleRaw(mux, http.MethodGet, "/api/sse/ws", model.AccessAgent, sseServer.WSHandler()).    // stream yang sama lewat WebSocket untuk proxy yang menahan SSE
+		HandleRaw(mux, http.MethodPost, "/api/sse/ack", model.AccessAgent, http.HandlerFunc(sseServer.HandleAck))
+
+	// Feed dashboard terpisah dari feed agent, user hanya bisa subscribe ke topic site/tenant sesuai role di JWT-nya
+	var dashboardSSE *utility.SSEServer
+	if secret := os.Getenv("DASHBOARD_JWT_SECRET"); secret != "" {
+		tokenizer, err := utility.NewJWTTokenizer(secret)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dashboardSSE = utility.NewSSEServer(utility.SSEConfig{
+			MaxConnections: 500,
+			KeepAlive:      15 * time.Second,
+			Origins:        []string{"*"},
+			Logger:         utility.DefaultLogger().WithPrefix("[SSE dashboard] "),
+			TopicScope:     controller.DashboardTopicScope(tokenizer),
+			// dashboard browser membaca camelCase, struct yang sama tetap snake_case untuk agent
+			Payload: utility.PayloadMarshaler{Case: utility.FieldCaseCamel},
+		})
+		// Feed dashboard memverifikasi tokennya sendiri lewat TopicScope, jadi terbuka di level route
+		apiPrinter.HandleRaw(mux, http.MethodGet, "/api/dashboard/sse", utility.Public, dashboardSSE.Handler())
+	}
+
+	// Statistik proses, module menambahkan bagiannya sendiri saat wiring
+	httpStats := utility.NewHTTPStats()
+	stats := utility.NewStatsRegistry()
+	stats.Add("runtime", utility.RuntimeStats)
+	stats.Add("http", func(ctx context.Context) (any, error) { return httpStats.Snapshot(), nil })
+	stats.Add("sse", func(ctx context.Context) (any, error) { return sseServer.Stats(), nil })
+	if dashboardSSE != nil {
+		stats.Add("dashboard_sse", func(ctx context.Context) (any, error) { return dashboardSSE.Stats(), nil })
+	}
+
+	// Mode soak test: goroutine, file descriptor, dan stream SSE dicek berkala untuk menemukan kebocoran,
+	// peringatan ditulis ke log dan laporannya dibaca lewat GET /api/admin/leaks
+	var leakDetector *utility.LeakDetector
+	if os.Getenv("SOAK_TEST") == "on" {
+		leakConfig := utility.LeakDetectorConfig{}
+		if interval, err := time.ParseDuration(os.Getenv("SOAK_INTERVAL")); err == nil {
+			leakConfig.Interval = interval
+		}
+		leakDetector = utility.NewLeakDetector(leakConfig)
+		leakDetector.AddGauge("sse_clients", func() int64 { return int64(sseServer.GetConnectedClientCount()) })
+		leakDetector.AddGauge("sse_streams", func() int64 { return sseServer.Stats().Streams })
+		leakDetector.AddCheck("sse", sseServer.LeakCheck)
+		if dashboardSSE != nil {
+			leakDetector.AddGauge("dashboard_sse_streams", func() int64 { return dashboardSSE.Stats().Streams })
+			leakDetector.AddCheck("dashboard_sse", dashboardSSE.LeakCheck)
+		}
+
+		leakCtx, stopLeaks := context.WithCancel(context.Background())
+		go leakDetector.Run(leakCtx)
+		lifecycle.Register("leak-detector", func(ctx context.Context) error {
+			stopLeaks()
+			return nil
+		})
+	}
+
+	// Stream SSE agent ikut didokumentasikan sebelum wiring memeriksa akses semua route
+	for _, url := range []string{"/api/sse/connect", "/api/sse/ws"} {
+		if err := apiPrinter.Add(utility.APIData{
+			Method:      http.MethodGet,
+			Url:         url,
+			Access:      model.AccessAgent,
+			Summary:     "Event stream of an agent",
+			Description: "Commands and notifications for the agent, /api/sse/ws carries the same stream over a WebSocket",
+			Tag:         "SSE",
+			Stream:      true,
+		}); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Akses tiap route ditegakkan dari role di JWT, semua route mux lewat Authorize
+	handler := apiPrinter.Authorize(controller.RoleAuthorizer(apiTokenizer))(mux)
 
 	// gabung semua komponen
-	wiring.SetupDependency(mux, sseServer, apiPrinter, db)
+	if err := wiring.SetupDependency(mux, handler, sseServer, dashboardSSE, apiPrinter, eventCatalog, stats, lifecycle, leakDetector, db, artifactURLSecret); err != nil {
+		log.Fatal(err)
+	}
+
+	// Event server ke client dari catalog didokumentasikan di stream SSE agent
+	for _, spec := range eventCatalog.Specs() {
+		if spec.Direction == utility.EventToClient {
+			apiPrinter.AddSSEEvent(spec.Type, spec.Payload, spec.Summary)
+		}
+	}
 
 	// TODO put into env
 	port := 8080
 
-	// Print API ke console dan openapi
+	// Print API ke console, openapi dan halaman dokumentasi yang tidak butuh internet
 	apiPrinter.
 		PrintAPIDataTable().
-		PublishAPI(mux, fmt.Sprintf("http://localhost:%d", port), "/openapi")
+		PublishAPI(mux, fmt.Sprintf("http://localhost:%d", port), "/openapi").
+		PublishSwaggerUI(mux, "/docs").
+		PublishMarkdown(mux, "/docs.md")
+
+	// Kontrak event SSE dalam AsyncAPI, openapi kurang cocok untuk menggambarkan stream
+	eventCatalog.PublishAsyncAPI(mux, apiPrinter, "/asyncapi", utility.AsyncAPIConfig{
+		Title:   "Network scanner SSE events",
+		BaseURL: fmt.Sprintf("http://localhost:%d", port),
+		Streams: []string{"/api/sse/connect", "/api/sse/ws"},
+	})
+
+	// Referensi API dalam Markdown ditulis ke file jika API_DOCS_MARKDOWN diisi, untuk di-commit bersama kode
+	if path := os.Getenv("API_DOCS_MARKDOWN"); path != "" {
+		if err := apiPrinter.ExportMarkdown(path); err != nil {
+			utility.Errorf("API docs %s: %v", path, err)
+		}
+	}
 
 	// Default route
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	apiPrinter.HandleRaw(mux, http.MethodGet, "/{$}", utility.Public, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "Server is running")
-	})
+	}))
+
+	// stream SSE tidak dihitung di statistik http, durasinya selama agent atau dashboard terhubung.
+	// Setiap response membawa X-Request-ID agar laporan request yang gagal bisa dicocokkan dengan log server
+	requestIDs := core.NewULIDGenerator(core.RealClock{})
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: httpStats.Middleware(utility.TraceHTTP(controller.RequestIDMiddleware(controller.Decompress(handler), requestIDs)), "/api/sse/connect", "/api/sse/ws", "/api/dashboard/sse"),
+	}
+
+	// Snapshot statistik ke file JSON lines jika STATS_DIR diisi, untuk analisa insiden tanpa stack monitoring.
+	// Didaftarkan sebelum http server agar snapshot terakhir ditulis setelah semua koneksi berhenti
+	statsConfig, statsEnabled, err := utility.StatsFileConfigFromEnv("server-stats")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if statsEnabled {
+		if err := utility.StartStatsFileWriter(stats, statsConfig, lifecycle); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	lifecycle.Register("http-server", httpServer.Shutdown)
+
+	// stream SSE ditutup lebih dulu, http.Server.Shutdown tidak menunggu koneksi yang tidak pernah selesai
+	lifecycle.Register("sse-server", sseServer.Shutdown)
+	if dashboardSSE != nil {
+		lifecycle.Register("dashboard-sse-server", dashboardSSE.Shutdown)
+	}
 
 	// start server
-	fmt.Printf("Server started at http://localhost:%d\n", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), mux))
+	go func() {
+		utility.Infof("Server started at http://localhost:%d", port)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// tunggu sinyal berhenti lalu matikan dengan rapi
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	utility.Infof("Server shutting down...")
+
+	if err := lifecycle.Shutdown(context.Background()); err != nil {
+		utility.Errorf("Shutdown: %v", err)
+	}
 
 }
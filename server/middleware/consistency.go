@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+)
+
+// ConsistencyTokenSetter is a mutation response that hands out a consistency token
+type ConsistencyTokenSetter[S any] interface {
+	*S
+	SetConsistencyToken(token string)
+}
+
+// ConsistencyTokenReader is a read request that may carry the token of an earlier mutation
+type ConsistencyTokenReader interface {
+	MinConsistencyToken() string
+}
+
+// ConsistencyToken advances the write position once the mutation succeeded and returns it in the response,
+// every write of the mutation is done by then
+func ConsistencyToken[R any, S any, PS ConsistencyTokenSetter[S]](actionHandler core.ActionHandler[R, S], advance gateway.WritePositionAdvance) core.ActionHandler[R, S] {
+	return func(ctx context.Context, request R) (*S, error) {
+
+		response, err := actionHandler(ctx, request)
+		if err != nil || response == nil {
+			return response, err
+		}
+
+		res, err := advance(ctx, gateway.WritePositionAdvanceReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		PS(response).SetConsistencyToken(res.ConsistencyToken)
+
+		return response, nil
+	}
+}
+
+// ReadAfterWrite makes a read wait until the writes behind the token of the request are visible
+func ReadAfterWrite[R ConsistencyTokenReader, S any](actionHandler core.ActionHandler[R, S], wait gateway.WritePositionWait) core.ActionHandler[R, S] {
+	return func(ctx context.Context, request R) (*S, error) {
+
+		if _, err := wait(ctx, gateway.WritePositionWaitReq{ConsistencyToken: request.MinConsistencyToken()}); err != nil {
+			return nil, err
+		}
+
+		return actionHandler(ctx, request)
+	}
+}
@@ -0,0 +1,10 @@
+package model
+
+import "shared/utility"
+
+// Access levels declared by every route, utility.Public is used for routes open to anyone
+const (
+	AccessAgent    utility.Access = "agent"    // called by scanning agents with their client identity
+	AccessOperator utility.Access = "operator" // runs and inspects scans
+	AccessAdmin    utility.Access = "admin"    // manages sites, artifacts and the SSE server
+)
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+const (
+	AlertInfo     = "info"
+	AlertWarning  = "warning"
+	AlertCritical = "critical"
+)
+
+// Alert is raised by a scan result analyzer, see usecase.ScanResultAnalyzer, and broadcast as alert_raised
+type Alert struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Kind      string    `gorm:"index" json:"kind"` // name of the analyzer that raised it
+	Severity  string    `json:"severity"`          // info, warning or critical
+	ClientID  string    `gorm:"index" json:"client_id"`
+	Message   string    `json:"message"`
+	RaisedAt  time.Time `json:"raised_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
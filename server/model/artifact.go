@@ -0,0 +1,16 @@
+package model
+
+import "gorm.io/gorm"
+
+// Artifact is a distributable file (agent binary or scan plugin) hosted by the server
+type Artifact struct {
+	gorm.Model
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Platform string `json:"platform"` // e.g. linux/amd64, windows/amd64
+	Kind     string `json:"kind"`     // agent or plugin
+	FileName string `json:"file_name"`
+	Path     string `json:"-"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
@@ -1,9 +1,83 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"shared/utility"
+	"slices"
+	"strings"
+	"time"
 
-// TODO completing this later
+	"gorm.io/gorm"
+)
+
+// Client is an agent known to the server. It is created when the agent registers, or earlier when it
+// is placed on a site, RegisteredAt stays nil until the agent itself registers.
 type Client struct {
 	gorm.Model
-	ClientID string
+	ClientID     string     `gorm:"uniqueIndex" json:"client_id"`
+	SiteID       *uint      `gorm:"index" json:"site_id"` // nil when the agent is not assigned to a site
+	Version      string     `json:"version"`
+	OS           string     `json:"os"`
+	Arch         string     `json:"arch"`
+	Hostname     string     `json:"hostname"`
+	RegisteredAt *time.Time `json:"registered_at"`                                              // latest registration, agents register when they start
+	LastSeen     *time.Time `gorm:"index" json:"last_seen"`                                     // latest registration or heartbeat
+	Status       string     `gorm:"-" json:"status,omitempty" enum:"online,offline,never_seen"` // computed when listed, see StatusOf
+	// Commands are what the agent announced it carries out when it last connected, nil for an agent
+	// that never announced them, which is trusted with every command
+	Commands []string `gorm:"serializer:json" json:"commands" enum:"icmp,arp,udp,tcp,snmp,nmap,traceroute,discover"`
+	// Capabilities are the cap_ metadata keys the agent reported true when it last connected, unlike the
+	// commands an agent that never reported one is not trusted with it
+	Capabilities []string `gorm:"serializer:json" json:"capabilities"`
+	// MaxConcurrency is the most probe workers the agent runs for one scan, 0 when not announced.
+	// A scan asking for more workers is not sent to the agent.
+	MaxConcurrency int `json:"max_concurrency"`
+	// Groups are the SSE groups an operator assigned the agent to, the agent cannot choose them
+	Groups []string `gorm:"serializer:json" json:"groups"`
+}
+
+const (
+	ClientOnline    = "online"     // connected to the SSE stream
+	ClientOffline   = "offline"    // registered but not connected
+	ClientNeverSeen = "never_seen" // known only from a site assignment
+)
+
+// Commands the agents announce, a scan needs the one of its method plus tcp for ports and snmp for a credential
+const (
+	CommandICMP       = "icmp"
+	CommandARP        = "arp"
+	CommandUDP        = "udp"
+	CommandTCP        = "tcp"
+	CommandSNMP       = "snmp"
+	CommandNmap       = "nmap"
+	CommandTraceroute = "traceroute"
+	CommandDiscover   = "discover" // listening for mDNS and SSDP announcements
+)
+
+// Supports tells whether the agent announced every command, an agent that announced none supports all.
+// A capability among them, a cap_ key, is only supported once the agent reported it.
+func (c Client) Supports(commands ...string) bool {
+	for _, command := range commands {
+		if strings.HasPrefix(command, utility.CapabilityMetaPrefix) {
+			if !slices.Contains(c.Capabilities, command) {
+				return false
+			}
+			continue
+		}
+		if c.Commands != nil && !slices.Contains(c.Commands, command) {
+			return false
+		}
+	}
+	return true
+}
+
+// StatusOf tells whether the agent holds an SSE stream, the registry alone cannot tell an agent that
+// crashed from one between heartbeats
+func (c Client) StatusOf(connected bool) string {
+	switch {
+	case connected:
+		return ClientOnline
+	case c.RegisteredAt == nil || c.LastSeen == nil:
+		return ClientNeverSeen
+	}
+	return ClientOffline
 }
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// ClientThrottleEvent is reported by an agent whenever its resource guard throttles, pauses or resumes scans
+type ClientThrottleEvent struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	ClientID       string    `gorm:"index" json:"client_id"`
+	Action         string    `json:"action"` // throttled, paused or resumed
+	Reason         string    `json:"reason"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryMB       float64   `json:"memory_mb"`
+	WorkersPercent int       `json:"workers_percent"` // share of the workers still probing after the action
+	At             time.Time `json:"at"`              // agent clock
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Device is an entry of the persistent inventory, created the first time a scan finds the address online
+type Device struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	IP        string    `gorm:"uniqueIndex" json:"ip"`
+	MAC       string    `json:"mac"`
+	Hostname  string    `json:"hostname"`
+	Vendor    string    `json:"vendor"`
+	FirstSeen time.Time `json:"first_seen"` // first scan that found it online
+	LastSeen  time.Time `json:"last_seen"`  // latest scan that found it online
+	Status    string    `json:"status"`     // result of the latest probe, e.g. Online or Failed
+	ProbedAt  time.Time `json:"probed_at"`  // time of the latest probe
+	ClientID  string    `json:"client_id"`  // agent of the latest probe
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// protocols a service can be discovered with
+const (
+	DiscoveryMDNS = "mdns"
+	DiscoverySSDP = "ssdp"
+)
+
+// DiscoveredService is a service an agent heard announced on its segment, e.g. a printer over mDNS
+// or a camera over SSDP. The same announcement heard again only moves LastSeen.
+type DiscoveredService struct {
+	ID       uint   `gorm:"primarykey" json:"id"`
+	Protocol string `gorm:"uniqueIndex:idx_discovered_service" json:"protocol"` // mdns or ssdp
+	IP       string `gorm:"uniqueIndex:idx_discovered_service;index" json:"ip"`
+	// ServiceType is the DNS-SD type such as _ipp._tcp, or the SSDP notification type
+	ServiceType string `gorm:"uniqueIndex:idx_discovered_service" json:"service_type"`
+	// Name is the DNS-SD instance name, or the USN of an SSDP device
+	Name string `gorm:"uniqueIndex:idx_discovered_service" json:"name"`
+	Port int    `json:"port"`
+	// Details are the TXT records of an mDNS service, or the SERVER and LOCATION headers of SSDP
+	Details   map[string]string `gorm:"serializer:json" json:"details"`
+	ClientID  string            `gorm:"index" json:"client_id"` // agent that heard it last
+	FirstSeen time.Time         `json:"first_seen"`
+	LastSeen  time.Time         `json:"last_seen"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// ExpectedDevice is a device the inventory of a site says should be reachable, imported from CSV or XLSX
+type ExpectedDevice struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	SiteID      uint      `gorm:"index" json:"site_id"`
+	IP          string    `gorm:"index" json:"ip"`
+	Hostname    string    `json:"hostname"`
+	MAC         string    `json:"mac"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// InventoryReconciliation is the last known outcome of comparing a site inventory with what its agents discovered
+type InventoryReconciliation struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	SiteID        uint      `gorm:"uniqueIndex" json:"site_id"`
+	Matched       int       `json:"matched"`
+	MissingIPs    []string  `gorm:"serializer:json" json:"missing_ips"`    // expected but not online
+	UnexpectedIPs []string  `gorm:"serializer:json" json:"unexpected_ips"` // online but not expected
+	ReconciledAt  time.Time `json:"reconciled_at"`
+}
+
+// InventoryReconciliationChangedEvent is broadcast when the missing or unexpected devices of a site changed
+type InventoryReconciliationChangedEvent struct {
+	SiteID        uint     `json:"site_id"`
+	Matched       int      `json:"matched"`
+	MissingIPs    []string `json:"missing_ips"`
+	UnexpectedIPs []string `json:"unexpected_ips"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	IPReserved = "reserved" // held for a future use, nothing should answer on it
+	IPAssigned = "assigned" // in use by a known device
+	IPFree     = "free"     // released, nothing should answer on it until it is assigned again
+)
+
+// IPAMSubnet is an IPv4 subnet whose addresses are tracked by the IPAM module
+type IPAMSubnet struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CIDR        string    `gorm:"uniqueIndex" json:"cidr"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IPAllocation is the recorded state of one address of an IPAM subnet
+type IPAllocation struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	SubnetID  uint      `gorm:"index" json:"subnet_id"`
+	IP        string    `gorm:"uniqueIndex" json:"ip"`
+	Status    string    `json:"status"` // reserved, assigned or free
+	Hostname  string    `json:"hostname"`
+	Note      string    `json:"note"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
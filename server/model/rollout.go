@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RolloutCanary     = "canary"     // sent to the canary clients, waiting for their acknowledgements
+	RolloutProceeding = "proceeding" // the canary succeeded, sent to the remaining clients
+	RolloutCompleted  = "completed"  // every client received the command, see RemainderAcked for who confirmed
+	RolloutAborted    = "aborted"    // too few canary acknowledgements in the window, the remainder never got the command
+	RolloutFailed     = "failed"     // the command could not be sent
+)
+
+// Rollout sends one command to a fleet in two waves, a canary share first and the remainder only once
+// enough canary clients acknowledged it, so a bad config or agent update stops at the canary
+type Rollout struct {
+	ID                uint            `gorm:"primarykey" json:"-"`
+	RolloutID         string          `gorm:"uniqueIndex" json:"rollout_id"`
+	EventType         string          `json:"event_type"`
+	Payload           json.RawMessage `gorm:"serializer:json" json:"payload"`
+	Group             string          `json:"group,omitempty"` // SSE group the targets were taken from
+	CanaryPercent     int             `json:"canary_percent"`
+	MinSuccessPercent int             `json:"min_success_percent"` // share of the canary that must acknowledge
+	AckWindowMs       int64           `json:"ack_window_ms"`
+	Canary            []string        `gorm:"serializer:json" json:"canary"`
+	Remainder         []string        `gorm:"serializer:json" json:"remainder"`
+	CanaryAcked       []string        `gorm:"serializer:json" json:"canary_acked"`
+	RemainderAcked    []string        `gorm:"serializer:json" json:"remainder_acked"`
+	Status            string          `gorm:"index" json:"status"`
+	Reason            string          `json:"reason,omitempty"` // why the rollout was aborted or failed
+	RequestedBy       string          `json:"requested_by"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	FinishedAt        *time.Time      `json:"finished_at"`
+}
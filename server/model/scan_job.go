@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ScanJobPendingApproval = "pending_approval"
+	ScanJobQueued          = "queued" // waits for an overlapping running job to finish
+	ScanJobDispatched      = "dispatched"
+	ScanJobCompleted       = "completed" // every expected agent reported
+	ScanJobFailed          = "failed"    // every expected agent reported and none of them succeeded, or they did not report in time
+	// ScanJobCancelled is set by POST /api/scan-jobs/{id}/cancel before dispatch, or once every expected
+	// agent reported and one of them stopped its scan on scan_cancel
+	ScanJobCancelled = "cancelled"
+)
+
+// how the agents of a scan job discover hosts
+const (
+	ScanMethodICMP = "icmp"
+	ScanMethodARP  = "arp" // only reaches the agent's own L2 segment, finds hosts that drop ICMP
+	ScanMethodUDP  = "udp" // DNS, NTP and SNMP probes, finds hosts that drop ICMP and TCP beyond the segment
+	// nmap runs on the agents through the scan_nmap event, only agents with nmap installed (cap_nmap) can take it
+	ScanMethodNmap = "nmap"
+)
+
+// status of one agent within a dispatched scan job, a reported agent takes the status of its report
+const (
+	ScanJobAgentPending = "pending" // the command is not acknowledged yet
+	ScanJobAgentRunning = "running" // acknowledged, the report is not in yet
+)
+
+// ScanJobAdmissionLock is the single row every trigger writes before it checks its twins and the running
+// jobs, so the triggers of every instance sharing the database admit their jobs one at a time
+type ScanJobAdmissionLock struct {
+	ID      uint `gorm:"primaryKey"`
+	Version int64
+}
+
+// ScanJob is one scan dispatched to the agents, each agent answers with a ScanJobReport
+type ScanJob struct {
+	gorm.Model
+	JobID      string   `gorm:"uniqueIndex" json:"job_id"`
+	Command    string   `json:"command"`                         // SSE event sent to the agents, e.g. scan_icmp
+	Method     string   `json:"method" enum:"icmp,arp,udp,nmap"` // how agents discover hosts, icmp, arp or udp
+	IPRange    string   `json:"ip_range"`
+	SiteID     *uint    `gorm:"index" json:"site_id"`              // set when the trigger targeted a site
+	ClientIDs  []string `gorm:"serializer:json" json:"client_ids"` // empty means every agent, or every member of Group
+	Group      string   `json:"group,omitempty"`                   // SSE group the job was sent to instead of client ids
+	DryRun     bool     `json:"dry_run"`                           // agents only report a plan, no probe is sent
+	TotalHosts int      `json:"total_hosts"`
+	// SNMPCredential is the id of the community in the secrets store of the agents, the value never reaches the server
+	SNMPCredential string `json:"snmp_credential,omitempty"`
+	// Ports are the TCP ports checked on every host found online, Banners grabs a banner from each open one
+	Ports   []int `gorm:"serializer:json" json:"ports,omitempty"`
+	Banners bool  `json:"banners,omitempty"`
+	// MaxPacketsPerSecond and ProbeDelayMs pace the probes of every agent, 0 is unpaced
+	MaxPacketsPerSecond int `json:"max_packets_per_second,omitempty"`
+	ProbeDelayMs        int `json:"probe_delay_ms,omitempty"`
+	// Workers are the probe workers of every agent, 0 leaves each agent its own default
+	Workers int `json:"workers,omitempty"`
+	// Status is pending_approval for sensitive commands until a second operator approves,
+	// queued while an overlapping job runs, then dispatched and completed, or failed, once every expected agent reported
+	Status string `gorm:"index" json:"status" enum:"pending_approval,queued,dispatched,completed,failed,cancelled"`
+	// Recipients are the agents that acknowledged the command, they are expected to report back
+	Recipients []string `gorm:"serializer:json" json:"recipients"`
+	// RequestKey identifies the command, range and targets so identical triggers can be coalesced
+	RequestKey  string          `gorm:"index" json:"-"`
+	RequestedBy string          `json:"requested_by"`
+	ApprovedBy  string          `json:"approved_by"`
+	ApprovedAt  *time.Time      `json:"approved_at"`
+	Reports     []ScanJobReport `gorm:"foreignKey:JobID;references:JobID" json:"reports"`
+	Audits      []ScanJobAudit  `gorm:"foreignKey:JobID;references:JobID" json:"audits"`
+	// Agents is the status per expected agent, derived from the recipients and reports when the job is read
+	Agents []ScanJobAgentStatus `gorm:"-" json:"agents"`
+}
+
+// ScanJobAgentStatus is where one agent stands in a scan job
+type ScanJobAgentStatus struct {
+	ClientID string `json:"client_id"`
+	Status   string `json:"status" enum:"pending,running,completed,failed,cancelled,planned"` // pending, running, or the status of its report
+	Error    string `json:"error,omitempty"`
+	// Results is the number of scan results the agent uploaded for the job
+	Results int64 `json:"results"`
+}
+
+// ScanJobAudit records who did what to a scan job
+type ScanJobAudit struct {
+	gorm.Model
+	JobID    string `gorm:"index" json:"job_id"`
+	Action   string `json:"action"` // requested, approved, queued, merged, coalesced, dispatched, cancel_requested, completed, failed or cancelled
+	Operator string `json:"operator"`
+	Detail   string `json:"detail"`
+}
+
+// ScanJobReport is the completion report of one agent with its timing breakdown
+type ScanJobReport struct {
+	gorm.Model
+	JobID      string `gorm:"uniqueIndex:idx_scan_job_report_client" json:"job_id"`
+	ClientID   string `gorm:"uniqueIndex:idx_scan_job_report_client" json:"client_id"`
+	Status     string `json:"status"` // completed, failed, cancelled or planned (dry run)
+	Error      string `json:"error"`
+	TotalHosts int    `json:"total_hosts"`
+	Workers    int    `json:"workers"`
+	TimeoutMs  int64  `json:"timeout_ms"`
+	// EstimatedMs is the worst case duration of the scan, filled for dry runs
+	EstimatedMs int64          `json:"estimated_ms"`
+	StartedAt   time.Time      `json:"started_at"`
+	FinishedAt  time.Time      `json:"finished_at"`
+	Metrics     ScanJobMetrics `gorm:"serializer:json" json:"metrics"`
+}
+
+// ScanJobMetrics is measured by the agent so operators can tune workers and timeouts
+type ScanJobMetrics struct {
+	ExpandMs          float64    `json:"expand_ms"`
+	ProbeMs           float64    `json:"probe_ms"`
+	UploadMs          float64    `json:"upload_ms"`
+	TotalMs           float64    `json:"total_ms"`
+	Probe             ProbeStats `json:"probe"`
+	WorkerUtilization []float64  `json:"worker_utilization"`
+	HostsPerSecond    float64    `json:"hosts_per_second"`
+}
+
+// ProbeStats is the distribution of single probe durations in milliseconds
+type ProbeStats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min_ms"`
+	Avg   float64 `json:"avg_ms"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	Max   float64 `json:"max_ms"`
+}
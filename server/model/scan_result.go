@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScanResultOnline is the status of a probe the host answered
+const ScanResultOnline = "Online"
+
+// ScanResult is a single probe result reported by an agent
+type ScanResult struct {
+	gorm.Model
+	ClientID     string    `gorm:"index" json:"client_id"`
+	JobID        string    `gorm:"index" json:"job_id,omitempty"` // scan job the agent ran, empty for results of another origin
+	IP           string    `gorm:"index" json:"ip"`
+	Timestamp    time.Time `json:"timestamp"`
+	Protocol     string    `json:"protocol"`
+	MAC          string    `json:"mac,omitempty"` // known when the agent discovered the host over ARP
+	Status       string    `json:"status"`
+	ResponseTime float64   `json:"response_time"`
+	SNMPData     string    `json:"snmp_data"`
+
+	// Port is the UDP port whose service answered a udp scan, zero when the host only refused the probes
+	Port int `json:"port,omitempty"`
+	// OpenPorts are the TCP ports of the job that accepted a connection, with their banners when asked
+	OpenPorts []OpenPort `gorm:"serializer:json" json:"open_ports,omitempty"`
+	// TTL of the first ICMP echo reply, OSGuess is the best effort OS family the agent derived from it
+	// and the TCP window of an open port, e.g. Linux, Windows or Cisco IOS
+	TTL     int    `json:"ttl,omitempty"`
+	OSGuess string `json:"os_guess,omitempty"`
+	// ICMPMode is privileged (raw sockets) or unprivileged (datagram sockets) for icmp scans, an agent
+	// whose raw sockets are refused falls back to unprivileged on its own
+	ICMPMode string `json:"icmp_mode,omitempty"`
+}
+
+// OpenPort is one TCP port that accepted a connection, Service is guessed from the banner
+type OpenPort struct {
+	Port    int    `json:"port"`
+	Service string `json:"service,omitempty"` // e.g. ssh, http, smtp
+	Banner  string `json:"banner,omitempty"`
+}
+
+// SNMPIdentity is what a device reports about itself over SNMP, the agent stores it as JSON in SNMPData
+type SNMPIdentity struct {
+	SysDescr string `json:"sys_descr"`
+	SysName  string `json:"sys_name"`
+}
+
+// SNMPIdentity decodes SNMPData, false when the device did not answer SNMP
+func (r ScanResult) SNMPIdentity() (SNMPIdentity, bool) {
+	var identity SNMPIdentity
+	if r.SNMPData == "" || json.Unmarshal([]byte(r.SNMPData), &identity) != nil {
+		return SNMPIdentity{}, false
+	}
+	return identity, true
+}
@@ -0,0 +1,12 @@
+package model
+
+import "gorm.io/gorm"
+
+// Site is a physical location (branch, datacenter) with the subnets it owns and the agents placed there
+type Site struct {
+	gorm.Model
+	Name    string   `gorm:"uniqueIndex" json:"name"`
+	Address string   `json:"address"`
+	Subnets []string `gorm:"serializer:json" json:"subnets"` // CIDRs or start-end ranges
+	Agents  []Client `gorm:"foreignKey:SiteID" json:"agents"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// ScanCompletedEvent is broadcast once every expected agent reported a job
+type ScanCompletedEvent struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"` // completed, failed or cancelled
+	IPRange   string `json:"ip_range"`
+	DryRun    bool   `json:"dry_run"`
+	Reports   int    `json:"reports"`
+	Failed    int    `json:"failed"`
+	Cancelled int    `json:"cancelled"`
+}
+
+// ScanProgressEvent relays the progress an agent reports while it scans, published to the dashboard feed
+type ScanProgressEvent struct {
+	JobID    string    `json:"job_id,omitempty"` // empty for the scheduled scans of the agent
+	ClientID string    `json:"client_id"`
+	IPRange  string    `json:"ip_range"`
+	Scanned  int       `json:"scanned"`
+	Total    int       `json:"total"`
+	Percent  float64   `json:"percent"`
+	At       time.Time `json:"at"`
+}
+
+// ServiceDiscoveredEvent is published to the dashboard feed when an agent reports services
+type ServiceDiscoveredEvent struct {
+	ClientID string              `json:"client_id"`
+	Services []DiscoveredService `json:"services"`
+}
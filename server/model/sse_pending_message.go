@@ -0,0 +1,19 @@
+package model
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// SSEPendingMessage is a message addressed to an agent that was offline when it was sent
+type SSEPendingMessage struct {
+	gorm.Model
+	ClientID  string          `gorm:"index" json:"client_id"`
+	MessageID string          `json:"message_id"`
+	EventType string          `json:"event_type"`
+	Data      json.RawMessage `json:"data"`
+	Requires  []string        `gorm:"serializer:json" json:"requires,omitempty"` // see Message.Requires
+	// Traceparent is the span that sent the message, the agent continues the trace when it connects again
+	Traceparent string `json:"traceparent,omitempty"`
+}
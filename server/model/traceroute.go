@@ -0,0 +1,34 @@
+package model
+
+import (
+	"shared/command"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TraceroutePending   = "pending" // sent to the agent, the report is not in yet
+	TracerouteCompleted = "completed"
+	TracerouteFailed    = "failed" // the agent could not trace or did not take the command
+)
+
+type TracerouteHop = command.TracerouteHop
+
+// Traceroute is the path from one agent to a target, traced on request to diagnose reachability
+type Traceroute struct {
+	gorm.Model
+	TraceID    string          `gorm:"uniqueIndex" json:"trace_id"`
+	ClientID   string          `gorm:"index" json:"client_id"`
+	Target     string          `json:"target"`
+	ResolvedIP string          `json:"resolved_ip"`
+	MaxHops    int             `json:"max_hops"`
+	Status     string          `gorm:"index" json:"status"`
+	Reached    bool            `json:"reached"` // the target answered, false when the path ends before it
+	Hops       []TracerouteHop `gorm:"serializer:json" json:"hops"`
+	Error      string          `json:"error,omitempty"`
+	// RequestedBy is the operator that triggered the trace (user of the bearer token)
+	RequestedBy string     `json:"requested_by"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "gorm.io/gorm"
+
+// Upload is a resumable chunked upload, identified by the checksum of the full payload
+type Upload struct {
+	gorm.Model
+	UploadID    string `gorm:"uniqueIndex" json:"upload_id"`
+	TargetPath  string `json:"target_path"`
+	TotalChunks int    `json:"total_chunks"`
+	TotalItems  int    `json:"total_items"`
+	Checksums   string `json:"-"`      // comma separated sha256 per chunk
+	Status      string `json:"status"` // receiving, completed
+	Owner       string `json:"-"`      // user of the token that started the upload, only it sends chunks and completes
+}
+
+// UploadTargets are the routes a completed upload may be posted to, agent ingest routes taking a JSON array
+var UploadTargets = []string{"/api/scan-devices-result"}
+
+// UploadChunk holds one chunk of an upload, a JSON array of items
+type UploadChunk struct {
+	gorm.Model
+	UploadID   string `gorm:"uniqueIndex:idx_upload_chunk" json:"upload_id"`
+	ChunkIndex int    `gorm:"uniqueIndex:idx_upload_chunk" json:"chunk_index"`
+	Data       []byte `json:"-"`
+}
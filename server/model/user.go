@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"shared/utility"
+	"strings"
+)
+
+// Roles carried in the token of a dashboard user, site and tenant roles name what they grant, e.g. site:3 or tenant:acme
+const (
+	RoleAdmin        = "admin"    // sees every topic and reaches every route
+	RoleOperator     = "operator" // reaches the operator routes
+	RoleAgent        = "agent"    // reaches the agent routes, the token agents connect with
+	RoleSitePrefix   = "site:"    // site:<id> sees the topic of that site
+	RoleTenantPrefix = "tenant:"  // tenant:<name> sees the topic of that tenant and every topic below it
+)
+
+// UnassignedTopic carries the dashboard events of agents assigned to no site, only admins see it
+const UnassignedTopic = "unassigned"
+
+// UserTokenPayload is the content of the JWT a dashboard user connects with, and of the token the API
+// authorizes callers with, signed with API_JWT_SECRET
+type UserTokenPayload struct {
+	UserID string   `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
+
+// SiteTopic is the dashboard topic carrying the events of a site
+func SiteTopic(siteID uint) string {
+	return fmt.Sprintf("%s%d", RoleSitePrefix, siteID)
+}
+
+// TopicScope derives the dashboard topics the user may subscribe to from its roles, unknown roles grant nothing
+func (p UserTokenPayload) TopicScope() utility.TopicScope {
+	var patterns []string
+	for _, role := range p.Roles {
+		switch {
+		case role == RoleAdmin:
+			return utility.AllTopics()
+		case strings.HasPrefix(role, RoleSitePrefix) && len(role) > len(RoleSitePrefix):
+			patterns = append(patterns, role)
+		case strings.HasPrefix(role, RoleTenantPrefix) && len(role) > len(RoleTenantPrefix):
+			patterns = append(patterns, role, role+":*")
+		}
+	}
+	return utility.NewTopicScope(patterns...)
+}
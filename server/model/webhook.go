@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// events a webhook subscribes to, the payload data is the matching struct
+const (
+	WebhookEventScanCompleted    = "scan_completed"    // ScanCompletedEvent, once a job completed, failed or was cancelled
+	WebhookEventDeviceDiscovered = "device_discovered" // Device, the first time a scan finds an address online
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []string{WebhookEventScanCompleted, WebhookEventDeviceDiscovered}
+
+const (
+	WebhookDeliveryPending   = "pending"   // waits for its first attempt or for the retry after a failure
+	WebhookDeliveryDelivered = "delivered" // the receiver answered 2xx
+	WebhookDeliveryFailed    = "failed"    // every attempt failed, or the webhook was removed meanwhile
+)
+
+// Webhook is an external URL notified of scan events, so integrations do not poll the results API
+type Webhook struct {
+	gorm.Model
+	URL    string   `json:"url"`
+	Events []string `gorm:"serializer:json" json:"events"`
+	// Secret signs every payload, it is only shown in the response that created the webhook
+	Secret      string `json:"-"`
+	Description string `json:"description"`
+	CreatedBy   string `json:"created_by"`
+}
+
+// WebhookDelivery is one event queued for one webhook, it is kept after the last attempt for inspection
+type WebhookDelivery struct {
+	ID uint `gorm:"primarykey" json:"id"`
+	// EventID is the same for every webhook receiving the event, receivers use it to drop duplicates
+	EventID       string     `gorm:"index" json:"event_id"`
+	WebhookID     uint       `gorm:"index" json:"webhook_id"`
+	Event         string     `json:"event"`
+	Payload       string     `json:"payload"` // the signed JSON body, sent unchanged on every attempt
+	Status        string     `gorm:"index" json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastStatus    int        `json:"last_status,omitempty"` // HTTP status of the last attempt, 0 when no answer came
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	// ClaimToken is the dispatcher pass posting the delivery, see WebhookDeliveryClaimDue
+	ClaimToken string   `gorm:"index" json:"-"`
+	Webhook    *Webhook `gorm:"foreignKey:WebhookID" json:"-"`
+}
+
+// WebhookPayload is the JSON body posted to a webhook
+type WebhookPayload struct {
+	EventID   string    `json:"event_id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
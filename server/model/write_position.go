@@ -0,0 +1,8 @@
+package model
+
+// WritePosition is a single row sequence advanced after every mutation that hands out a
+// consistency token, a database that shows sequence n also shows every write made before it
+type WritePosition struct {
+	ID  uint   `gorm:"primarykey"`
+	Seq uint64 `gorm:"not null;default:0"`
+}
@@ -0,0 +1,108 @@
+package module
+
+import (
+	"context"
+	"net/http"
+	"shared/core"
+	"shared/utility"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Dependency is the set of shared infrastructure handed to every module factory
+type Dependency struct {
+	SSEServer   *utility.SSEServer
+	DB          *gorm.DB
+	IDGenerator core.IDGenerator
+	// Clock drives the background work of the modules, their schedules and timeouts
+	Clock core.Clock
+	// DashboardSSE is the feed of dashboard users, subscriptions are scoped by role, nil when not configured
+	DashboardSSE *utility.SSEServer
+	// EventCatalog is the registry modules describe their SSE events in
+	EventCatalog *utility.EventCatalog
+	// LeakDetector samples the process during soak tests, nil unless SOAK_TEST is on
+	LeakDetector *utility.LeakDetector
+	// ArtifactURLSecret keys the signed urls artifacts are downloaded with, the download route is public
+	ArtifactURLSecret string
+	// Handler is the mux behind Authorize, requests the server replays to itself go through it so
+	// they keep the access checks of their caller
+	Handler http.Handler
+}
+
+// ServerModule is a self contained feature (devices, reports, alerts, ...) that plugs itself into the server
+type ServerModule interface {
+	// Name identifies the module in logs
+	Name() string
+
+	// Premigrate fixes the stored rows a migration would fail on, such as duplicates of a column
+	// becoming unique. It runs before the migrations of every module.
+	Premigrate(ctx context.Context) error
+
+	// Migrations returns the models that must be auto migrated before routes are served
+	Migrations() []any
+
+	// RegisterRoutes registers http handlers and describes them in the api printer, it fails when the
+	// api printer refuses one of the descriptions
+	RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error
+
+	// RegisterEventHandlers hooks into the SSE server (connect/disconnect hooks, etc)
+	RegisterEventHandlers(sseServer *utility.SSEServer)
+
+	// RegisterEvents describes the SSE events the module sends or expects in the event catalog
+	RegisterEvents(catalog *utility.EventCatalog)
+
+	// RegisterStats adds the sections the module contributes to the stats snapshots
+	RegisterStats(stats *utility.StatsRegistry)
+
+	// RegisterWorkers starts the background work of the module once the migrations ran, and registers
+	// its stop in the lifecycle
+	RegisterWorkers(lifecycle *utility.Lifecycle)
+}
+
+// Factory builds a module from the shared dependencies
+type Factory func(deps Dependency) ServerModule
+
+var (
+	mu        sync.Mutex
+	factories []Factory
+)
+
+// Register adds a module factory, typically called from an init() function
+func Register(factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	factories = append(factories, factory)
+}
+
+// Build creates every registered module in registration order
+func Build(deps Dependency) []ServerModule {
+	mu.Lock()
+	defer mu.Unlock()
+
+	modules := make([]ServerModule, 0, len(factories))
+	for _, factory := range factories {
+		modules = append(modules, factory(deps))
+	}
+	return modules
+}
+
+// BaseModule provides no-op implementations so modules only override what they need
+type BaseModule struct{}
+
+func (BaseModule) Premigrate(ctx context.Context) error { return nil }
+
+func (BaseModule) Migrations() []any { return nil }
+
+func (BaseModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+	return nil
+}
+
+func (BaseModule) RegisterEventHandlers(sseServer *utility.SSEServer) {}
+
+func (BaseModule) RegisterEvents(catalog *utility.EventCatalog) {}
+
+func (BaseModule) RegisterStats(stats *utility.StatsRegistry) {}
+
+func (BaseModule) RegisterWorkers(lifecycle *utility.Lifecycle) {}
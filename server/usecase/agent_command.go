@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/core"
+	"slices"
+	"time"
+)
+
+// agentCommandRes is how a command sent to one agent went
+type agentCommandRes struct {
+	MessageID string
+	// Acknowledged is true once the agent confirmed the command reached its handlers
+	Acknowledged bool
+}
+
+// requireConnectedAgent refuses a command meant for now when the agent is not connected to any instance
+func requireConnectedAgent(ctx context.Context, SSEConnectedClientGetAll gateway.SSEConnectedClientGetAll, clientID string) error {
+	connected, err := SSEConnectedClientGetAll(ctx, gateway.SSEConnectedClientGetAllReq{})
+	if err != nil {
+		return core.NewInternalServerError(err)
+	}
+	if !slices.Contains(connected.ClientIDs, clientID) {
+		return fmt.Errorf("client %s is not connected", clientID)
+	}
+	return nil
+}
+
+// sendAgentCommand sends a command to one agent and waits up to ackTimeoutMs, default 5000, for its ack.
+// A volatile command is never kept for the agent if it disconnects meanwhile.
+func sendAgentCommand[Req any](
+	ctx context.Context,
+	SendCommand gateway.SendCommand[Req],
+	WaitSSEAck gateway.WaitSSEAck,
+	clientID string,
+	payload Req,
+	volatile bool,
+	ackTimeoutMs int,
+) (*agentCommandRes, error) {
+
+	if ackTimeoutMs <= 0 {
+		ackTimeoutMs = 5000
+	}
+
+	sent, err := SendCommand(ctx, gateway.SendCommandReq[Req]{
+		Payload:   payload,
+		ClientIDs: []string{clientID},
+		Volatile:  volatile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	acked, err := WaitSSEAck(ctx, gateway.WaitSSEAckReq{
+		MessageID: sent.MessageID,
+		ClientIDs: []string{clientID},
+		Timeout:   time.Duration(ackTimeoutMs) * time.Millisecond,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &agentCommandRes{MessageID: sent.MessageID, Acknowledged: acked.Complete}, nil
+}
@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"shared/utility"
+	"time"
+)
+
+// recordDevices folds stored scan results into the device inventory and raises device_discovered for
+// every device it added. A failure is only logged because the results are already stored and the agent
+// must not upload them again.
+func recordDevices(ctx context.Context, DeviceRecordSeen gateway.DeviceRecordSeen, WebhookEventEnqueue gateway.WebhookEventEnqueue, IDGenerator core.IDGenerator, results []model.ScanResult, now time.Time) {
+	if len(results) == 0 {
+		return
+	}
+	res, err := DeviceRecordSeen(ctx, gateway.DeviceRecordSeenReq{ScanResults: results})
+	if err != nil {
+		utility.Errorf("Failed to update the device inventory from %d scan results: %v", len(results), err)
+		return
+	}
+	created := make([]any, 0, len(res.Created))
+	for _, device := range res.Created {
+		created = append(created, device)
+	}
+	notifyWebhooksAll(ctx, WebhookEventEnqueue, IDGenerator, model.WebhookEventDeviceDiscovered, created, now)
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFile is a download built by an export usecase, Write runs once the response headers are sent,
+// so it streams straight into the response and a failure halfway only cuts the file short
+type ExportFile struct {
+	FileName    string
+	ContentType string
+	Write       func(ctx context.Context, w io.Writer) error
+}
+
+// exportColumn is one column an export can select, Value renders the cell of a row
+type exportColumn[T any] struct {
+	Name  string
+	Value func(row T) string
+}
+
+// selectExportColumns picks the columns in the order given, every column when none is
+func selectExportColumns[T any](all []exportColumn[T], selected []string) ([]exportColumn[T], error) {
+	if len(selected) == 0 {
+		return all, nil
+	}
+
+	var columns []exportColumn[T]
+	for _, name := range selected {
+		found := false
+		for _, column := range all {
+			if column.Name == name {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(all))
+			for i, column := range all {
+				names[i] = column.Name
+			}
+			return nil, fmt.Errorf("unknown column %q, columns are %s", name, strings.Join(names, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// exportFormat checks the requested format, csv is the default and the only one rows are exported in
+func exportFormat(format string) error {
+	if format != "" && format != "csv" {
+		return fmt.Errorf("format must be csv")
+	}
+	return nil
+}
+
+// exportFileName is the suggested name of a download, e.g. devices-20240102-150405.csv
+func exportFileName(prefix string, now time.Time, extension string) string {
+	return fmt.Sprintf("%s-%s.%s", prefix, now.UTC().Format("20060102-150405"), extension)
+}
+
+// csvRowWriter writes the header then a row per call, each returns the callback a walk gateway takes
+func csvRowWriter[T any](w io.Writer, columns []exportColumn[T]) (each func(row T) error, flush func() error, err error) {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, nil, err
+	}
+
+	record := make([]string, len(columns))
+	each = func(row T) error {
+		for i, column := range columns {
+			record[i] = csvCell(column.Value(row))
+		}
+		return writer.Write(record)
+	}
+	flush = func() error {
+		writer.Flush()
+		return writer.Error()
+	}
+	return each, flush, nil
+}
+
+// csvCell keeps a spreadsheet from running a cell as a formula, banners and names come from the scanned
+// hosts and a value such as =HYPERLINK(...) would run when the export is opened
+func csvCell(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// exportTime renders a timestamp in RFC 3339, empty for the zero time
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
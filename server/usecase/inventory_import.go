@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"server/model"
+	"strconv"
+	"strings"
+)
+
+// maxInventoryFileSize bounds an uploaded inventory, XLSX files are read in memory to unzip them
+const maxInventoryFileSize = 16 << 20
+
+// parseExpectedDevices reads an inventory file, CSV or XLSX (first worksheet), whose first row names
+// the columns: ip is required, hostname, mac and description are optional and other columns ignored
+func parseExpectedDevices(fileName string, content io.Reader) ([]model.ExpectedDevice, error) {
+
+	data, err := io.ReadAll(io.LimitReader(content, maxInventoryFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxInventoryFileSize {
+		return nil, fmt.Errorf("inventory file exceeds %d MiB", maxInventoryFileSize>>20)
+	}
+
+	var rows [][]string
+	if strings.EqualFold(path.Ext(fileName), ".xlsx") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		rows, err = readXLSXRows(data)
+	} else {
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err = reader.ReadAll()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid inventory file: %v", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("inventory file is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["ip"]; !ok {
+		return nil, fmt.Errorf("inventory file needs an ip column in its first row")
+	}
+	cell := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	seen := map[string]int{}
+	devices := make([]model.ExpectedDevice, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		line := i + 2
+
+		ip := cell(row, "ip")
+		if ip == "" && strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue // blank line
+		}
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, fmt.Errorf("line %d: invalid ip %q", line, ip)
+		}
+		ip = parsed.String()
+		if first, duplicate := seen[ip]; duplicate {
+			return nil, fmt.Errorf("line %d: ip %s already listed on line %d", line, ip, first)
+		}
+		seen[ip] = line
+
+		devices = append(devices, model.ExpectedDevice{
+			IP:          ip,
+			Hostname:    cell(row, "hostname"),
+			MAC:         strings.ToLower(cell(row, "mac")),
+			Description: cell(row, "description"),
+		})
+	}
+	return devices, nil
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSXRows returns the cell text of the first worksheet, enough for an inventory export
+// without pulling a spreadsheet library in
+func readXLSXRows(data []byte) ([][]string, error) {
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if err := decodeZipXML(archive, "xl/sharedStrings.xml", &shared, true); err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(shared.Items))
+	for i, item := range shared.Items {
+		strs[i] = item.Text
+		for _, run := range item.Runs {
+			strs[i] += run.Text
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeZipXML(archive, "xl/worksheets/sheet1.xml", &sheet, false); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, sheetRow := range sheet.Rows {
+		var row []string
+		for _, c := range sheetRow.Cells {
+			column := xlsxColumn(c.Ref, len(row))
+			for len(row) <= column {
+				row = append(row, "")
+			}
+
+			switch c.Type {
+			case "s":
+				index, err := strconv.Atoi(c.Value)
+				if err != nil || index < 0 || index >= len(strs) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", c.Ref)
+				}
+				row[column] = strs[index]
+			case "inlineStr":
+				row[column] = c.Inline
+			default:
+				row[column] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeZipXML(archive *zip.Reader, name string, v any, optional bool) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("%s not found, is it an xlsx workbook", name)
+	}
+	defer file.Close()
+
+	return xml.NewDecoder(file).Decode(v)
+}
+
+// xlsxColumn turns the letters of a cell reference (C7) into a zero based column, cells without
+// a reference follow the previous one
+func xlsxColumn(ref string, fallback int) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+	if column == 0 {
+		return fallback
+	}
+	return column - 1
+}
@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+	"strings"
+	"time"
+)
+
+// InventoryMatch is an expected device an agent of the site found online
+type InventoryMatch struct {
+	Device   model.ExpectedDevice `json:"device"`
+	ClientID string               `json:"client_id"`
+	LastSeen time.Time            `json:"last_seen"`
+}
+
+// InventoryReconciliationReport compares the inventory of a site with the latest result of every address its agents probed
+type InventoryReconciliationReport struct {
+	SiteID     uint                   `json:"site_id"`
+	Matched    []InventoryMatch       `json:"matched"`
+	Missing    []model.ExpectedDevice `json:"missing"`    // expected but offline or never probed
+	Unexpected []model.ScanResult     `json:"unexpected"` // online inside the site but not expected
+	// Changed is true when the missing or unexpected devices differ from the previous reconciliation
+	Changed      bool      `json:"changed"`
+	ReconciledAt time.Time `json:"reconciled_at"`
+}
+
+// reconcileSite builds the report of a site, stores its outcome and broadcasts inventory_reconciliation_changed
+// when the missing or unexpected devices are not the ones of the previous reconciliation
+func reconcileSite(
+	ctx context.Context,
+	SiteGetOne gateway.SiteGetOne,
+	ExpectedDeviceGetAll gateway.ExpectedDeviceGetAll,
+	ScanResultGetAll gateway.ScanResultGetAll,
+	InventoryReconciliationGetOne gateway.InventoryReconciliationGetOne,
+	InventoryReconciliationSave gateway.InventoryReconciliationSave,
+	SendSSEMessage gateway.SendSSEMessage,
+	PublishDashboard gateway.SendSSEMessage,
+	siteID uint,
+	now time.Time,
+) (*InventoryReconciliationReport, error) {
+
+	siteRes, err := SiteGetOne(ctx, gateway.SiteGetOneReq{ID: siteID})
+	if err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+	if siteRes.Site == nil {
+		return nil, fmt.Errorf("site %d not found", siteID)
+	}
+	site := siteRes.Site
+
+	expectedRes, err := ExpectedDeviceGetAll(ctx, gateway.ExpectedDeviceGetAllReq{SiteID: siteID})
+	if err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+
+	report := InventoryReconciliationReport{
+		SiteID:       siteID,
+		Matched:      []InventoryMatch{},
+		Missing:      []model.ExpectedDevice{},
+		Unexpected:   []model.ScanResult{},
+		ReconciledAt: now,
+	}
+
+	// only the newest result of each address is read, it is the current state of the address
+	latest := map[string]model.ScanResult{}
+	if len(site.Agents) > 0 {
+		clientIDs := make([]string, 0, len(site.Agents))
+		for _, agent := range site.Agents {
+			clientIDs = append(clientIDs, agent.ClientID)
+		}
+		resultsRes, err := ScanResultGetAll(ctx, gateway.ScanResultGetAllReq{ClientIDs: clientIDs, LatestPerIP: true})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		for _, result := range resultsRes.ScanResults {
+			latest[result.IP] = result
+		}
+	}
+
+	expected := make(map[string]bool, len(expectedRes.Devices))
+	for _, device := range expectedRes.Devices {
+		expected[device.IP] = true
+
+		result, ok := latest[device.IP]
+		if !ok || result.Status != model.ScanResultOnline {
+			report.Missing = append(report.Missing, device)
+			continue
+		}
+		report.Matched = append(report.Matched, InventoryMatch{Device: device, ClientID: result.ClientID, LastSeen: result.Timestamp})
+	}
+
+	// agents may probe beyond their site, only addresses of the site subnets count as unexpected
+	var siteRanges []addrRange
+	for _, subnet := range site.Subnets {
+		if bounds, err := parseAddrRange(subnet); err == nil {
+			siteRanges = append(siteRanges, bounds)
+		}
+	}
+	inSite := func(ip string) bool {
+		addr, err := parseAddrRange(ip)
+		if err != nil {
+			return false
+		}
+		for _, bounds := range siteRanges {
+			if bounds.contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+	for ip, result := range latest {
+		if !expected[ip] && result.Status == model.ScanResultOnline && inSite(ip) {
+			report.Unexpected = append(report.Unexpected, result)
+		}
+	}
+	slices.SortFunc(report.Unexpected, func(a, b model.ScanResult) int { return compareIPStrings(a.IP, b.IP) })
+
+	current := model.InventoryReconciliation{
+		SiteID:        siteID,
+		Matched:       len(report.Matched),
+		MissingIPs:    make([]string, 0, len(report.Missing)),
+		UnexpectedIPs: make([]string, 0, len(report.Unexpected)),
+		ReconciledAt:  now,
+	}
+	for _, device := range report.Missing {
+		current.MissingIPs = append(current.MissingIPs, device.IP)
+	}
+	for _, result := range report.Unexpected {
+		current.UnexpectedIPs = append(current.UnexpectedIPs, result.IP)
+	}
+	slices.SortFunc(current.MissingIPs, compareIPStrings)
+
+	previousRes, err := InventoryReconciliationGetOne(ctx, gateway.InventoryReconciliationGetOneReq{SiteID: siteID})
+	if err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+	previous := previousRes.Reconciliation
+	report.Changed = previous == nil ||
+		!slices.Equal(previous.MissingIPs, current.MissingIPs) ||
+		!slices.Equal(previous.UnexpectedIPs, current.UnexpectedIPs)
+
+	if _, err := InventoryReconciliationSave(ctx, gateway.InventoryReconciliationSaveReq{Reconciliation: &current}); err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+
+	if report.Changed {
+		event := model.InventoryReconciliationChangedEvent{
+			SiteID:        siteID,
+			Matched:       current.Matched,
+			MissingIPs:    current.MissingIPs,
+			UnexpectedIPs: current.UnexpectedIPs,
+		}
+		if _, err := SendSSEMessage(ctx, gateway.SendSSEMessageReq{
+			EventType: "inventory_reconciliation_changed",
+			Data:      event,
+		}); err != nil {
+			return nil, err
+		}
+		// dashboard users only see the sites their roles grant
+		if _, err := PublishDashboard(ctx, gateway.SendSSEMessageReq{
+			EventType: "inventory_reconciliation_changed",
+			Data:      event,
+			Topic:     model.SiteTopic(siteID),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &report, nil
+}
+
+// InventoryReconciler reconciles the sites of the agents of every ingested upload, so
+// inventory_reconciliation_changed follows the scans instead of waiting for a manual run. It raises
+// no alerts, the outcome is stored and broadcast like a run through /api/sites/{id}/reconcile.
+type InventoryReconciler struct {
+	clientGetAll                  gateway.ClientGetAll
+	siteGetOne                    gateway.SiteGetOne
+	expectedDeviceGetAll          gateway.ExpectedDeviceGetAll
+	scanResultGetAll              gateway.ScanResultGetAll
+	inventoryReconciliationGetOne gateway.InventoryReconciliationGetOne
+	inventoryReconciliationSave   gateway.InventoryReconciliationSave
+	sendSSEMessage                gateway.SendSSEMessage
+	publishDashboard              gateway.SendSSEMessage
+}
+
+func NewInventoryReconciler(
+	ClientGetAll gateway.ClientGetAll,
+	SiteGetOne gateway.SiteGetOne,
+	ExpectedDeviceGetAll gateway.ExpectedDeviceGetAll,
+	ScanResultGetAll gateway.ScanResultGetAll,
+	InventoryReconciliationGetOne gateway.InventoryReconciliationGetOne,
+	InventoryReconciliationSave gateway.InventoryReconciliationSave,
+	SendSSEMessage gateway.SendSSEMessage,
+	PublishDashboard gateway.SendSSEMessage,
+) *InventoryReconciler {
+	return &InventoryReconciler{
+		clientGetAll:                  ClientGetAll,
+		siteGetOne:                    SiteGetOne,
+		expectedDeviceGetAll:          ExpectedDeviceGetAll,
+		scanResultGetAll:              ScanResultGetAll,
+		inventoryReconciliationGetOne: InventoryReconciliationGetOne,
+		inventoryReconciliationSave:   InventoryReconciliationSave,
+		sendSSEMessage:                SendSSEMessage,
+		publishDashboard:              PublishDashboard,
+	}
+}
+
+func (r *InventoryReconciler) Name() string { return "inventory_reconciliation" }
+
+func (r *InventoryReconciler) Analyze(ctx context.Context, batch ScanResultBatch) ([]model.Alert, error) {
+
+	clientIDs := []string{}
+	for _, result := range batch.Results {
+		if !slices.Contains(clientIDs, result.ClientID) {
+			clientIDs = append(clientIDs, result.ClientID)
+		}
+	}
+
+	clientsRes, err := r.clientGetAll(ctx, gateway.ClientGetAllReq{ClientIDs: clientIDs})
+	if err != nil {
+		return nil, err
+	}
+	siteIDs := []uint{}
+	for _, client := range clientsRes.Clients {
+		if client.SiteID != nil && !slices.Contains(siteIDs, *client.SiteID) {
+			siteIDs = append(siteIDs, *client.SiteID)
+		}
+	}
+
+	var errs []error
+	for _, siteID := range siteIDs {
+		// a site without an imported inventory would report every online host as unexpected
+		expectedRes, err := r.expectedDeviceGetAll(ctx, gateway.ExpectedDeviceGetAllReq{SiteID: siteID})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(expectedRes.Devices) == 0 {
+			continue
+		}
+
+		if _, err := reconcileSite(ctx, r.siteGetOne, r.expectedDeviceGetAll, r.scanResultGetAll, r.inventoryReconciliationGetOne, r.inventoryReconciliationSave, r.sendSSEMessage, r.publishDashboard, siteID, batch.ReceivedAt); err != nil {
+			errs = append(errs, fmt.Errorf("site %d: %w", siteID, err))
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// compareIPStrings orders addresses numerically so 10.0.0.9 comes before 10.0.0.10
+func compareIPStrings(a, b string) int {
+	left, errLeft := netip.ParseAddr(a)
+	right, errRight := netip.ParseAddr(b)
+	if errLeft != nil || errRight != nil {
+		return strings.Compare(a, b)
+	}
+	return left.Compare(right)
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strings"
+)
+
+// addrRange is an inclusive range of addresses of one family, IPv4 or IPv6
+type addrRange struct {
+	first netip.Addr
+	last  netip.Addr
+}
+
+func (r addrRange) overlaps(other addrRange) bool {
+	if r.first.BitLen() != other.first.BitLen() {
+		return false
+	}
+	return r.first.Compare(other.last) <= 0 && other.first.Compare(r.last) <= 0
+}
+
+func (r addrRange) contains(other addrRange) bool {
+	if r.first.BitLen() != other.first.BitLen() {
+		return false
+	}
+	return r.first.Compare(other.first) <= 0 && other.last.Compare(r.last) <= 0
+}
+
+// size counts the addresses of the range, capped where it is larger than anything worth counting exactly
+func (r addrRange) size() int {
+	size := new(big.Int).Sub(new(big.Int).SetBytes(r.last.AsSlice()), new(big.Int).SetBytes(r.first.AsSlice()))
+	if size.Cmp(big.NewInt(1<<31)) >= 0 {
+		return 1 << 31
+	}
+	return int(size.Int64()) + 1
+}
+
+// splitRanges splits a comma separated list of ranges, a site with several subnets is scanned as one job
+func splitRanges(ipRange string) []string {
+	var ranges []string
+	for _, part := range strings.Split(ipRange, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			ranges = append(ranges, part)
+		}
+	}
+	return ranges
+}
+
+// countRangeHosts counts the addresses of single IPs, CIDRs or start-end ranges without expanding them
+func countRangeHosts(ipRange string) (int, error) {
+	ranges := splitRanges(ipRange)
+	if len(ranges) == 0 {
+		return 0, fmt.Errorf("ip_range is required")
+	}
+
+	total := 0
+	for _, r := range ranges {
+		count, err := countSingleRangeHosts(r)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func countSingleRangeHosts(ipRange string) (int, error) {
+	bounds, err := parseAddrRange(ipRange)
+	if err != nil {
+		return 0, err
+	}
+	return bounds.size(), nil
+}
+
+// parseAddrRange converts an address, CIDR or start-end range into its first and last address
+func parseAddrRange(ipRange string) (addrRange, error) {
+	ipRange = strings.TrimSpace(ipRange)
+
+	if prefix, err := netip.ParsePrefix(ipRange); err == nil {
+		first := prefix.Masked().Addr().Unmap()
+		// the last address is the first with every host bit set, a mapped prefix counts its IPv4 bits only
+		bytes := first.AsSlice()
+		for bit := prefix.Bits() - (prefix.Addr().BitLen() - first.BitLen()); bit < first.BitLen(); bit++ {
+			bytes[bit/8] |= 0x80 >> (bit % 8)
+		}
+		last, _ := netip.AddrFromSlice(bytes)
+		return addrRange{first: first, last: last}, nil
+	}
+
+	if addr, err := netip.ParseAddr(ipRange); err == nil {
+		addr = addr.Unmap()
+		return addrRange{first: addr, last: addr}, nil
+	}
+
+	start, end, found := strings.Cut(ipRange, "-")
+	if !found {
+		return addrRange{}, fmt.Errorf("unsupported ip range format: %s", ipRange)
+	}
+
+	first, errStart := netip.ParseAddr(strings.TrimSpace(start))
+	last, errEnd := netip.ParseAddr(strings.TrimSpace(end))
+	if errStart != nil || errEnd != nil {
+		return addrRange{}, fmt.Errorf("invalid ip range: %s", ipRange)
+	}
+	first, last = first.Unmap(), last.Unmap()
+	if first.BitLen() != last.BitLen() {
+		return addrRange{}, fmt.Errorf("invalid ip range: start and end are of different families")
+	}
+	if last.Less(first) {
+		return addrRange{}, fmt.Errorf("invalid ip range: end is before start")
+	}
+	return addrRange{first: first, last: last}, nil
+}
+
+// rangesOverlap reports whether any range of a overlaps any range of b, unparsable ranges never overlap
+func rangesOverlap(a, b []string) bool {
+	for _, left := range a {
+		leftBounds, err := parseAddrRange(left)
+		if err != nil {
+			continue
+		}
+		for _, right := range b {
+			rightBounds, err := parseAddrRange(right)
+			if err != nil {
+				continue
+			}
+			if leftBounds.overlaps(rightBounds) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rangeOutside returns the first range of inner that no single range of outer contains, empty when
+// every one is contained. An unparsable range is never contained.
+func rangeOutside(inner, outer []string) string {
+	for _, r := range inner {
+		bounds, err := parseAddrRange(r)
+		if err != nil {
+			return r
+		}
+		contained := false
+		for _, o := range outer {
+			if outerBounds, err := parseAddrRange(o); err == nil && outerBounds.contains(bounds) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			return r
+		}
+	}
+	return ""
+}
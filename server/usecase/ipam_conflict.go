@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"sync"
+	"time"
+)
+
+// IPAMAddress is an address of an IPAM subnet, recorded or only seen by the scans, with its last scan finding
+type IPAMAddress struct {
+	IP           string     `json:"ip"`
+	Status       string     `json:"status"` // reserved, assigned, free or empty when never recorded
+	Hostname     string     `json:"hostname"`
+	Note         string     `json:"note"`
+	LastStatus   string     `json:"last_status"` // status of the latest scan, empty when never scanned
+	LastSeen     *time.Time `json:"last_seen"`
+	LastClientID string     `json:"last_client_id"`
+	Conflict     bool       `json:"conflict"` // a free or reserved address answered the latest scan
+}
+
+// ipamConflict reports whether an address recorded as not in use answered a probe
+func ipamConflict(status, scanStatus string) bool {
+	return scanStatus == model.ScanResultOnline && (status == model.IPFree || status == model.IPReserved)
+}
+
+// IPAMConflictDetector raises an alert the first time a free or reserved address answers a scan,
+// the address is alerted again once it went quiet or was recorded as assigned in between
+type IPAMConflictDetector struct {
+	ipAllocationGetAll gateway.IPAllocationGetAll
+
+	mu      sync.Mutex
+	flagged map[string]bool // addresses already alerted
+}
+
+func NewIPAMConflictDetector(IPAllocationGetAll gateway.IPAllocationGetAll) *IPAMConflictDetector {
+	return &IPAMConflictDetector{ipAllocationGetAll: IPAllocationGetAll, flagged: map[string]bool{}}
+}
+
+func (d *IPAMConflictDetector) Name() string { return "ipam_conflict" }
+
+func (d *IPAMConflictDetector) Analyze(ctx context.Context, batch ScanResultBatch) ([]model.Alert, error) {
+
+	latest := map[string]model.ScanResult{}
+	ips := []string{}
+	for _, result := range batch.Results {
+		if _, ok := latest[result.IP]; !ok {
+			ips = append(ips, result.IP)
+		}
+		latest[result.IP] = result
+	}
+	if len(ips) == 0 {
+		return nil, nil
+	}
+
+	res, err := d.ipAllocationGetAll(ctx, gateway.IPAllocationGetAllReq{IPs: ips})
+	if err != nil {
+		return nil, err
+	}
+	allocations := make(map[string]model.IPAllocation, len(res.Allocations))
+	for _, allocation := range res.Allocations {
+		allocations[allocation.IP] = allocation
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var alerts []model.Alert
+	for _, ip := range ips {
+		result := latest[ip]
+		allocation, recorded := allocations[ip]
+
+		if !recorded || !ipamConflict(allocation.Status, result.Status) {
+			delete(d.flagged, ip)
+			continue
+		}
+		if d.flagged[ip] {
+			continue
+		}
+		d.flagged[ip] = true
+
+		alerts = append(alerts, model.Alert{
+			Severity: model.AlertWarning,
+			ClientID: result.ClientID,
+			Message:  fmt.Sprintf("%s is recorded as %s in IPAM but answered the %s probe", ip, allocation.Status, result.Protocol),
+		})
+	}
+	return alerts, nil
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"shared/utility"
+	"sort"
+	"time"
+)
+
+// splitCanary orders the targets by a hash of the rollout id so every rollout samples a different but
+// reproducible canary, then takes the canary share rounded up to at least one client
+func splitCanary(rolloutID string, targets []string, percent int) (canary, remainder []string) {
+	ordered := append([]string(nil), targets...)
+	rank := make(map[string]string, len(ordered))
+	for _, clientID := range ordered {
+		sum := sha256.Sum256([]byte(rolloutID + "|" + clientID))
+		rank[clientID] = hex.EncodeToString(sum[:])
+	}
+	sort.Slice(ordered, func(i, j int) bool { return rank[ordered[i]] < rank[ordered[j]] })
+
+	size := (len(ordered)*percent + 99) / 100
+	size = max(1, min(size, len(ordered)))
+	return ordered[:size], ordered[size:]
+}
+
+// sendRolloutWave sends the command to one wave and waits the ack window, it returns the clients that acknowledged
+func sendRolloutWave(
+	ctx context.Context,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	rollout *model.Rollout,
+	clientIDs []string,
+) ([]string, error) {
+
+	sent, err := SendSSEMessage(ctx, gateway.SendSSEMessageReq{
+		EventType:  rollout.EventType,
+		Data:       rollout.Payload,
+		ClientIDs:  clientIDs,
+		RequireAck: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	acked, err := WaitSSEAck(ctx, gateway.WaitSSEAckReq{
+		MessageID: sent.MessageID,
+		ClientIDs: clientIDs,
+		Timeout:   time.Duration(rollout.AckWindowMs) * time.Millisecond,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(acked.AcknowledgedBy)
+	return acked.AcknowledgedBy, nil
+}
+
+// runRollout drives a rollout saved in the canary or proceeding status to its end, every step is saved
+// so the status endpoint follows it. A rollout in the proceeding status only sends the remainder. When
+// ctx ends the rollout keeps its last status, RolloutResume continues it at the next start.
+func runRollout(
+	ctx context.Context,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	RolloutSave gateway.RolloutSave,
+	rollout *model.Rollout,
+) {
+
+	save := func() {
+		if _, err := RolloutSave(ctx, gateway.RolloutSaveReq{Rollout: rollout}); err != nil {
+			utility.Errorf("Failed to save rollout %s: %v", rollout.RolloutID, err)
+		}
+	}
+	finish := func(status, reason string) {
+		now := core.GetClockFromContext(ctx).Now()
+		rollout.Status = status
+		rollout.Reason = reason
+		rollout.FinishedAt = &now
+		save()
+	}
+
+	if rollout.Status == model.RolloutCanary {
+		canaryAcked, err := sendRolloutWave(ctx, SendSSEMessage, WaitSSEAck, rollout, rollout.Canary)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			finish(model.RolloutFailed, fmt.Sprintf("failed to send the canary wave: %v", err))
+			return
+		}
+		rollout.CanaryAcked = canaryAcked
+
+		if 100*len(canaryAcked) < rollout.MinSuccessPercent*len(rollout.Canary) {
+			finish(model.RolloutAborted, fmt.Sprintf("%d of %d canary clients acknowledged within %d ms, %d%% required",
+				len(canaryAcked), len(rollout.Canary), rollout.AckWindowMs, rollout.MinSuccessPercent))
+			return
+		}
+
+		if len(rollout.Remainder) == 0 {
+			finish(model.RolloutCompleted, "")
+			return
+		}
+
+		rollout.Status = model.RolloutProceeding
+		save()
+	}
+
+	remainderAcked, err := sendRolloutWave(ctx, SendSSEMessage, WaitSSEAck, rollout, rollout.Remainder)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		finish(model.RolloutFailed, fmt.Sprintf("failed to send the remaining wave: %v", err))
+		return
+	}
+	rollout.RemainderAcked = remainderAcked
+
+	finish(model.RolloutCompleted, "")
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"time"
+)
+
+type ScanJobAdmitReq struct {
+	ScanJob      model.ScanJob
+	ConflictMode string // reject, queue or merge, already resolved from the policy and the trigger
+	Operator     string
+	Now          time.Time
+}
+
+type ScanJobAdmitRes struct {
+	// ScanJob is the saved job, pending_approval, queued or dispatched
+	ScanJob model.ScanJob
+	// Answer is set instead when no job was saved, the twin or the running job the trigger merged into
+	Answer *ScanICMPTriggerRes
+}
+
+// ScanJobAdmit checks a job against its twins and the running jobs and saves it. It is wired inside
+// a transaction so the checks and the insert are one step for every instance sharing the database.
+type ScanJobAdmit = core.ActionHandler[ScanJobAdmitReq, ScanJobAdmitRes]
+
+func ImplScanJobAdmit(
+	ScanJobAdmissionLock gateway.ScanJobAdmissionLock,
+	ScanJobFindDuplicate gateway.ScanJobFindDuplicate,
+	ScanJobGetActive gateway.ScanJobGetActive,
+	ScanJobSave gateway.ScanJobSave,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	Policy ScanApprovalPolicy,
+	ConflictPolicy ScanConflictPolicy,
+	DedupPolicy ScanDedupPolicy,
+) ScanJobAdmit {
+	return func(ctx context.Context, req ScanJobAdmitReq) (*ScanJobAdmitRes, error) {
+
+		// taken first so a concurrent trigger sees this job once it reads the jobs
+		if _, err := ScanJobAdmissionLock(ctx, gateway.ScanJobAdmissionLockReq{}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		scanJob := req.ScanJob
+
+		duplicate, err := DedupPolicy.findDuplicate(ctx, ScanJobFindDuplicate, scanJob, req.Now)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate != nil {
+			answer, err := coalesceScanJob(ctx, ScanJobAuditSave, *duplicate, req.Operator)
+			if err != nil {
+				return nil, err
+			}
+			return &ScanJobAdmitRes{Answer: answer}, nil
+		}
+
+		// a job waiting for approval is checked for conflicts when it is approved
+		if Policy.requiresApproval(scanJob) {
+			scanJob.Status = model.ScanJobPendingApproval
+		} else {
+			conflicts, err := findScanConflicts(ctx, ScanJobGetActive, ConflictPolicy, scanJob, req.Now)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(conflicts) > 0 {
+				switch req.ConflictMode {
+				case ScanConflictQueue:
+					scanJob.Status = model.ScanJobQueued
+				case ScanConflictMerge:
+					answer, err := mergeScanJob(ctx, ScanJobAuditSave, scanJob, conflicts, req.Operator)
+					if err != nil {
+						return nil, err
+					}
+					return &ScanJobAdmitRes{Answer: answer}, nil
+				default:
+					return nil, newScanConflictError(scanJob, conflicts)
+				}
+			}
+		}
+
+		if _, err := ScanJobSave(ctx, gateway.ScanJobSaveReq{ScanJob: &scanJob}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ScanJobAdmitRes{ScanJob: scanJob}, nil
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/command"
+	"shared/core"
+	"sort"
+	"strings"
+)
+
+// scanCommands are the commands an agent must have announced to run a job: its method, tcp for the
+// port checks and snmp for a credential. nmap also needs the capabilities of scan_nmap, an agent
+// without nmap installed reports cap_nmap false.
+func scanCommands(method string, ports []int, snmpCredential string) []string {
+	commands := []string{method}
+	if method == model.ScanMethodNmap {
+		commands = append(commands, command.ScanNmap.Capabilities...)
+	}
+	if len(ports) > 0 {
+		commands = append(commands, model.CommandTCP)
+	}
+	if snmpCredential != "" {
+		commands = append(commands, model.CommandSNMP)
+	}
+	return commands
+}
+
+// capableAgents keeps the targets able to run the commands with workers probe workers, 0 asks for no
+// particular number. Agents the operator named are refused when one of them falls short, agents picked
+// from the sites are routed around. A broadcast or a group is resolved when the message is sent, the
+// SSE server skips its members lacking a command, see utility.Message.Requires.
+func capableAgents(
+	ctx context.Context,
+	ClientGetAll gateway.ClientGetAll,
+	clientIDs []string,
+	named bool,
+	commands []string,
+	workers int,
+) ([]string, error) {
+
+	if len(clientIDs) == 0 {
+		return clientIDs, nil
+	}
+
+	res, err := ClientGetAll(ctx, gateway.ClientGetAllReq{ClientIDs: clientIDs})
+	if err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+
+	// agents missing from the registry never announced anything and are trusted like old agents
+	unsupported := map[string][]string{}
+	for _, client := range res.Clients {
+		for _, command := range commands {
+			if !client.Supports(command) {
+				unsupported[client.ClientID] = append(unsupported[client.ClientID], command)
+			}
+		}
+		if workers > 0 && client.MaxConcurrency > 0 && workers > client.MaxConcurrency {
+			unsupported[client.ClientID] = append(unsupported[client.ClientID], fmt.Sprintf("at most %d workers", client.MaxConcurrency))
+		}
+	}
+	if len(unsupported) == 0 {
+		return clientIDs, nil
+	}
+
+	lacking := make([]string, 0, len(unsupported))
+	for clientID, missing := range unsupported {
+		lacking = append(lacking, fmt.Sprintf("%s (%s)", clientID, strings.Join(missing, ", ")))
+	}
+	sort.Strings(lacking)
+
+	capable := make([]string, 0, len(clientIDs))
+	for _, clientID := range clientIDs {
+		if _, ok := unsupported[clientID]; !ok {
+			capable = append(capable, clientID)
+		}
+	}
+
+	if named || len(capable) == 0 {
+		needs := strings.Join(commands, ", ")
+		if workers > 0 {
+			needs += fmt.Sprintf(" with %d workers", workers)
+		}
+		return nil, core.NewErrorWithData(
+			fmt.Errorf("agent(s) %s do not support the command, it needs %s", strings.Join(lacking, ", "), needs),
+			map[string]any{"unsupported": unsupported},
+		)
+	}
+	return capable, nil
+}
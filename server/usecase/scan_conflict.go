@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"shared/utility"
+	"strings"
+	"time"
+)
+
+const (
+	ScanConflictReject = "reject" // refuse the trigger while an overlapping job runs
+	ScanConflictQueue  = "queue"  // hold the job and dispatch it once the overlapping jobs finished
+	ScanConflictMerge  = "merge"  // answer with the running job when it already covers the request
+)
+
+// ScanConflictPolicy decides what happens to a job whose range overlaps a job still running on the same agents
+type ScanConflictPolicy struct {
+	Mode string // reject, queue or merge, empty means reject
+	// StaleAfter bounds how long a dispatched job counts as running when its agents never report back
+	StaleAfter time.Duration
+}
+
+func (p ScanConflictPolicy) mode(override string) (string, error) {
+	mode := p.Mode
+	if override != "" {
+		mode = override
+	}
+	switch mode {
+	case "":
+		return ScanConflictReject, nil
+	case ScanConflictReject, ScanConflictQueue, ScanConflictMerge:
+		return mode, nil
+	}
+	return "", fmt.Errorf("on_conflict must be reject, queue or merge")
+}
+
+func (p ScanConflictPolicy) since(now time.Time) time.Time {
+	if p.StaleAfter <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.StaleAfter)
+}
+
+// expectedAgents are the agents a dispatched job waits for, the acknowledged ones once known
+func expectedAgents(job model.ScanJob) []string {
+	if len(job.Recipients) > 0 {
+		return job.Recipients
+	}
+	return job.ClientIDs
+}
+
+// scanJobFinished reports whether every expected agent sent its report, a broadcast nobody acknowledged never finishes
+func scanJobFinished(job model.ScanJob) bool {
+	agents := expectedAgents(job)
+	if len(agents) == 0 {
+		return false
+	}
+
+	reported := make(map[string]bool, len(job.Reports))
+	for _, report := range job.Reports {
+		reported[report.ClientID] = true
+	}
+	for _, agent := range agents {
+		if !reported[agent] {
+			return false
+		}
+	}
+	return true
+}
+
+// sharesAgents reports whether two jobs probe from at least one common agent, a broadcast shares every agent
+func sharesAgents(a, b model.ScanJob) bool {
+	if len(a.ClientIDs) == 0 || len(b.ClientIDs) == 0 {
+		return true
+	}
+	for _, left := range a.ClientIDs {
+		for _, right := range b.ClientIDs {
+			if left == right {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scanJobsConflict reports whether two jobs would probe the same addresses from the same agents
+func scanJobsConflict(a, b model.ScanJob) bool {
+	return sharesAgents(a, b) && rangesOverlap(splitRanges(a.IPRange), splitRanges(b.IPRange))
+}
+
+// scanJobCovers reports whether running already probes every address of job from every agent of job
+func scanJobCovers(running, job model.ScanJob) bool {
+	// an icmp scan does not find what an arp scan finds, jobs from before methods existed are icmp
+	runningMethod, _ := scanMethod(running.Method)
+	jobMethod, _ := scanMethod(job.Method)
+	if runningMethod != jobMethod {
+		return false
+	}
+	// the running job must check every port of job, and read banners when job wants them
+	if job.Banners && !running.Banners {
+		return false
+	}
+	runningPorts := make(map[int]bool, len(running.Ports))
+	for _, port := range running.Ports {
+		runningPorts[port] = true
+	}
+	for _, port := range job.Ports {
+		if !runningPorts[port] {
+			return false
+		}
+	}
+	// members of a group are unknown here, a group job only covers the same group
+	if running.Group != "" && running.Group != job.Group {
+		return false
+	}
+	if len(running.ClientIDs) > 0 {
+		if len(job.ClientIDs) == 0 {
+			return false
+		}
+		agents := make(map[string]bool, len(running.ClientIDs))
+		for _, clientID := range running.ClientIDs {
+			agents[clientID] = true
+		}
+		for _, clientID := range job.ClientIDs {
+			if !agents[clientID] {
+				return false
+			}
+		}
+	}
+
+	var outer []addrRange
+	for _, r := range splitRanges(running.IPRange) {
+		bounds, err := parseAddrRange(r)
+		if err != nil {
+			return false
+		}
+		outer = append(outer, bounds)
+	}
+
+	for _, r := range splitRanges(job.IPRange) {
+		inner, err := parseAddrRange(r)
+		if err != nil {
+			return false
+		}
+		covered := false
+		for _, bounds := range outer {
+			if bounds.contains(inner) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// findScanConflicts returns the running jobs that overlap job, dry runs never conflict because they send no probes
+func findScanConflicts(
+	ctx context.Context,
+	ScanJobGetActive gateway.ScanJobGetActive,
+	policy ScanConflictPolicy,
+	job model.ScanJob,
+	now time.Time,
+) ([]model.ScanJob, error) {
+
+	if job.DryRun {
+		return nil, nil
+	}
+
+	active, err := ScanJobGetActive(ctx, gateway.ScanJobGetActiveReq{
+		Statuses: []string{model.ScanJobDispatched},
+		Since:    policy.since(now),
+	})
+	if err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+
+	var conflicts []model.ScanJob
+	for _, running := range active.ScanJobs {
+		if running.JobID == job.JobID || scanJobFinished(running) {
+			continue
+		}
+		if scanJobsConflict(running, job) {
+			conflicts = append(conflicts, running)
+		}
+	}
+	return conflicts, nil
+}
+
+func newScanConflictError(job model.ScanJob, conflicts []model.ScanJob) error {
+	jobIDs := make([]string, 0, len(conflicts))
+	for _, running := range conflicts {
+		jobIDs = append(jobIDs, running.JobID)
+	}
+	return core.NewErrorWithData(
+		fmt.Errorf("ip range %s overlaps running scan job(s) %s on the same agents", job.IPRange, strings.Join(jobIDs, ", ")),
+		map[string]any{"conflicting_job_ids": jobIDs},
+	)
+}
+
+// releaseQueuedScanJobs dispatches, oldest first, the queued jobs that no longer overlap a running job
+func releaseQueuedScanJobs(
+	ctx context.Context,
+	ScanJobGetActive gateway.ScanJobGetActive,
+	ScanJobTransition gateway.ScanJobTransition,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	ScanJobSetRecipients gateway.ScanJobSetRecipients,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	policy ScanConflictPolicy,
+	now time.Time,
+) error {
+
+	active, err := ScanJobGetActive(ctx, gateway.ScanJobGetActiveReq{
+		Statuses: []string{model.ScanJobDispatched},
+		Since:    policy.since(now),
+	})
+	if err != nil {
+		return err
+	}
+
+	// a queued job waits as long as it takes, it is not stale like a job whose agents never reported
+	waiting, err := ScanJobGetActive(ctx, gateway.ScanJobGetActiveReq{
+		Statuses: []string{model.ScanJobQueued},
+	})
+	if err != nil {
+		return err
+	}
+	queued := waiting.ScanJobs
+
+	var running []model.ScanJob
+	for _, job := range active.ScanJobs {
+		if !scanJobFinished(job) {
+			running = append(running, job)
+		}
+	}
+
+	for _, job := range queued {
+
+		blocked := false
+		for _, other := range running {
+			if scanJobsConflict(other, job) {
+				blocked = true
+				break
+			}
+		}
+		if blocked {
+			continue
+		}
+
+		transitioned, err := ScanJobTransition(ctx, gateway.ScanJobTransitionReq{
+			JobID:      job.JobID,
+			FromStatus: model.ScanJobQueued,
+			ToStatus:   model.ScanJobDispatched,
+			Now:        now,
+		})
+		if err != nil {
+			return err
+		}
+		if !transitioned.Updated {
+			continue // released by a concurrent report
+		}
+		running = append(running, job)
+
+		// the reporting agent should not wait for the acknowledgements of the released job
+		go func(ctx context.Context, job model.ScanJob) {
+			_, err := dispatchScanJob(ctx, SendSSEMessage, WaitSSEAck, ScanJobAuditSave, ScanJobSetRecipients, job, "", 0)
+			if err == nil {
+				return
+			}
+			utility.Errorf("Failed to dispatch queued scan job %s: %v", job.JobID, err)
+
+			// a job that never reached its agents must not block the jobs queued behind it
+			now := core.GetClockFromContext(ctx).Now()
+			if _, err := failScanJob(ctx, ScanJobTransition, ScanJobAuditSave, job, "dispatch_failed", err.Error(), now); err != nil {
+				utility.Errorf("Failed to mark scan job %s failed: %v", job.JobID, err)
+				return
+			}
+			if err := releaseQueuedScanJobs(ctx, ScanJobGetActive, ScanJobTransition, ScanJobAuditSave, ScanJobSetRecipients, SendSSEMessage, WaitSSEAck, policy, now); err != nil {
+				utility.Errorf("Failed to release queued scan jobs: %v", err)
+			}
+		}(context.WithoutCancel(ctx), job)
+	}
+
+	return nil
+}
+
+// failScanJob turns a dispatched job failed and records why, it reports false without an error when
+// the job was not dispatched anymore
+func failScanJob(
+	ctx context.Context,
+	ScanJobTransition gateway.ScanJobTransition,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	job model.ScanJob,
+	action, detail string,
+	now time.Time,
+) (bool, error) {
+
+	transitioned, err := ScanJobTransition(ctx, gateway.ScanJobTransitionReq{
+		JobID:      job.JobID,
+		FromStatus: model.ScanJobDispatched,
+		ToStatus:   model.ScanJobFailed,
+		Now:        now,
+	})
+	if err != nil {
+		return false, err
+	}
+	if !transitioned.Updated {
+		return false, nil
+	}
+
+	if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+		JobID:  job.JobID,
+		Action: action,
+		Detail: detail,
+	}}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// scanCompletedEvent is the scan_completed event of a job that ended with status
+func scanCompletedEvent(job model.ScanJob, status string) model.ScanCompletedEvent {
+	failed, cancelled := 0, 0
+	for _, report := range job.Reports {
+		switch report.Status {
+		case "failed":
+			failed++
+		case model.ScanJobCancelled:
+			cancelled++
+		}
+	}
+	return model.ScanCompletedEvent{
+		JobID:     job.JobID,
+		Status:    status,
+		IPRange:   job.IPRange,
+		DryRun:    job.DryRun,
+		Reports:   len(job.Reports),
+		Failed:    failed,
+		Cancelled: cancelled,
+	}
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ScanDedupPolicy coalesces identical triggers, e.g. a double click on the dashboard, into the first job
+type ScanDedupPolicy struct {
+	// Window is how long after a trigger an identical one returns the same job, 0 disables the check
+	Window time.Duration
+}
+
+// scanJobRequestKey identifies what a job probes and from where, independent of the order targets were listed in
+func scanJobRequestKey(job model.ScanJob) string {
+	clientIDs := append([]string(nil), job.ClientIDs...)
+	sort.Strings(clientIDs)
+
+	ranges := splitRanges(job.IPRange)
+	sort.Strings(ranges)
+
+	siteID := uint(0)
+	if job.SiteID != nil {
+		siteID = *job.SiteID
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s|%s|%d|%t|%s|%v|%t", job.Command, job.Method, strings.Join(ranges, ","), strings.Join(clientIDs, ","), job.Group, siteID, job.DryRun, job.SNMPCredential, job.Ports, job.Banners)
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// findDuplicate returns the job an identical trigger created within the window, nil when job is new
+func (p ScanDedupPolicy) findDuplicate(
+	ctx context.Context,
+	ScanJobFindDuplicate gateway.ScanJobFindDuplicate,
+	job model.ScanJob,
+	now time.Time,
+) (*model.ScanJob, error) {
+
+	if p.Window <= 0 {
+		return nil, nil
+	}
+
+	res, err := ScanJobFindDuplicate(ctx, gateway.ScanJobFindDuplicateReq{
+		RequestKey: job.RequestKey,
+		Since:      now.Add(-p.Window),
+	})
+	if err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+	return res.ScanJob, nil
+}
+
+// coalesceScanJob answers a duplicate trigger with the job created by the first one
+func coalesceScanJob(
+	ctx context.Context,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	existing model.ScanJob,
+	operator string,
+) (*ScanICMPTriggerRes, error) {
+
+	if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+		JobID:    existing.JobID,
+		Action:   "coalesced",
+		Operator: operator,
+		Detail:   fmt.Sprintf("identical %s %s requested again", existing.Command, existing.IPRange),
+	}}); err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+
+	acknowledgedBy := existing.Recipients
+	if acknowledgedBy == nil {
+		acknowledgedBy = []string{}
+	}
+
+	return &ScanICMPTriggerRes{
+		JobID:          existing.JobID,
+		Status:         existing.Status,
+		AcknowledgedBy: acknowledgedBy,
+		Duplicate:      true,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+)
+
+// scanJobAgents derives where each agent stands in job, agents that reported without being expected
+// (members of a broadcast) are listed after the expected ones
+func scanJobAgents(job model.ScanJob, results map[string]int64) []model.ScanJobAgentStatus {
+
+	acknowledged := make(map[string]bool, len(job.Recipients))
+	for _, clientID := range job.Recipients {
+		acknowledged[clientID] = true
+	}
+	reports := make(map[string]model.ScanJobReport, len(job.Reports))
+	for _, report := range job.Reports {
+		reports[report.ClientID] = report
+	}
+
+	agents := []model.ScanJobAgentStatus{}
+	listed := map[string]bool{}
+	add := func(clientID string) {
+		if listed[clientID] {
+			return
+		}
+		listed[clientID] = true
+
+		agent := model.ScanJobAgentStatus{ClientID: clientID, Status: model.ScanJobAgentPending, Results: results[clientID]}
+		if report, ok := reports[clientID]; ok {
+			agent.Status = report.Status
+			agent.Error = report.Error
+		} else if acknowledged[clientID] {
+			agent.Status = model.ScanJobAgentRunning
+		}
+		agents = append(agents, agent)
+	}
+
+	for _, clientID := range expectedAgents(job) {
+		add(clientID)
+	}
+	for _, report := range job.Reports {
+		add(report.ClientID)
+	}
+	return agents
+}
+
+// describeScanJobs fills the agent status of every job with a single count of the uploaded results
+func describeScanJobs(ctx context.Context, ScanResultCountByJob gateway.ScanResultCountByJob, jobs []model.ScanJob) error {
+
+	jobIDs := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		jobIDs = append(jobIDs, job.JobID)
+	}
+
+	counted, err := ScanResultCountByJob(ctx, gateway.ScanResultCountByJobReq{JobIDs: jobIDs})
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		jobs[i].Agents = scanJobAgents(jobs[i], counted.Counts[jobs[i].JobID])
+	}
+	return nil
+}
+
+// scanJobOutcome is the final status of a job every expected agent reported on, cancelled when an agent
+// stopped on scan_cancel, since the results are partial, and failed only when no report succeeded
+func scanJobOutcome(job model.ScanJob) string {
+	for _, report := range job.Reports {
+		if report.Status == model.ScanJobCancelled {
+			return model.ScanJobCancelled
+		}
+	}
+	for _, report := range job.Reports {
+		if report.Status != "failed" {
+			return model.ScanJobCompleted
+		}
+	}
+	return model.ScanJobFailed
+}
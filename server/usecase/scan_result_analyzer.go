@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"server/gateway"
+	"server/model"
+	"shared/utility"
+	"sync"
+	"time"
+)
+
+// ScanResultBatch is one complete upload of scan results as analyzers see it, after it was stored
+type ScanResultBatch struct {
+	ClientID   string // agent that sent the upload, rows may still name other agents
+	Results    []model.ScanResult
+	ReceivedAt time.Time
+}
+
+// ScanResultAnalyzer looks for anomalies in ingested results, every returned alert is stored and
+// broadcast as alert_raised. Implementations keep whatever history they compare against.
+type ScanResultAnalyzer interface {
+	// Name is the kind of the alerts raised, e.g. device_count_drop
+	Name() string
+	Analyze(ctx context.Context, batch ScanResultBatch) ([]model.Alert, error)
+}
+
+// analyzeScanResults runs the analyzers in order, a failing analyzer is logged and skipped
+// because the results are already stored and the agent must not upload them again
+func analyzeScanResults(
+	ctx context.Context,
+	AlertSave gateway.AlertSave,
+	SendSSEMessage gateway.SendSSEMessage,
+	analyzers []ScanResultAnalyzer,
+	batch ScanResultBatch,
+) {
+	for _, analyzer := range analyzers {
+
+		alerts, err := analyzer.Analyze(ctx, batch)
+		if err != nil {
+			utility.Errorf("Scan result analyzer %s failed: %v", analyzer.Name(), err)
+			continue
+		}
+
+		for _, alert := range alerts {
+			if alert.Kind == "" {
+				alert.Kind = analyzer.Name()
+			}
+			if alert.ClientID == "" {
+				alert.ClientID = batch.ClientID
+			}
+			alert.RaisedAt = batch.ReceivedAt
+
+			if _, err := AlertSave(ctx, gateway.AlertSaveReq{Alert: &alert}); err != nil {
+				utility.Errorf("Failed to save %s alert: %v", alert.Kind, err)
+				continue
+			}
+			if _, err := SendSSEMessage(ctx, gateway.SendSSEMessageReq{EventType: "alert_raised", Data: alert}); err != nil {
+				utility.Warnf("Failed to broadcast %s alert %d: %v", alert.Kind, alert.ID, err)
+			}
+		}
+	}
+}
+
+// DeviceCountDropDetector raises an alert when an agent finds noticeably fewer online hosts than
+// the last time it scanned the same span of addresses. The history lives in memory and starts over
+// on restart, the first upload of a span only records the baseline. A span not scanned for maxAge is
+// forgotten, and past maxSpans the span scanned longest ago makes room, so agents scanning ever new
+// ranges do not grow the history without bound.
+type DeviceCountDropDetector struct {
+	Threshold float64 // fraction of the previous count, 0.2 alerts on a drop of more than 20%
+
+	maxAge   time.Duration
+	maxSpans int
+
+	mu       sync.Mutex
+	previous map[string]spanCount // the last upload per agent and span
+}
+
+// spanCount is the online hosts of the last upload of a span and when it was received
+type spanCount struct {
+	online int
+	seenAt time.Time
+}
+
+func NewDeviceCountDropDetector(threshold float64, maxAge time.Duration, maxSpans int) *DeviceCountDropDetector {
+	return &DeviceCountDropDetector{Threshold: threshold, maxAge: maxAge, maxSpans: maxSpans, previous: map[string]spanCount{}}
+}
+
+func (d *DeviceCountDropDetector) Name() string { return "device_count_drop" }
+
+func (d *DeviceCountDropDetector) Analyze(ctx context.Context, batch ScanResultBatch) ([]model.Alert, error) {
+
+	// rows are grouped per agent, the span is what makes two uploads comparable
+	type span struct {
+		first, last netip.Addr
+		probed      int
+		online      int
+	}
+	spans := map[string]*span{}
+	for _, result := range batch.Results {
+		ip, err := netip.ParseAddr(result.IP)
+		if err != nil {
+			continue // validated on ingestion, an address netip rejects is left out of the count
+		}
+		ip = ip.Unmap()
+		s, ok := spans[result.ClientID]
+		if !ok {
+			s = &span{first: ip, last: ip}
+			spans[result.ClientID] = s
+		}
+		if ip.Less(s.first) {
+			s.first = ip
+		}
+		if s.last.Less(ip) {
+			s.last = ip
+		}
+		s.probed++
+		if result.Status == model.ScanResultOnline {
+			s.online++
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.forgetExpired(batch.ReceivedAt)
+
+	var alerts []model.Alert
+	for clientID, s := range spans {
+		key := fmt.Sprintf("%s|%s-%s|%d", clientID, s.first, s.last, s.probed)
+		last, known := d.previous[key]
+		if !known {
+			d.makeRoom()
+		}
+		d.previous[key] = spanCount{online: s.online, seenAt: batch.ReceivedAt}
+
+		previous := last.online
+		if !known || previous == 0 || float64(previous-s.online) <= d.Threshold*float64(previous) {
+			continue
+		}
+		alerts = append(alerts, model.Alert{
+			Severity: model.AlertWarning,
+			ClientID: clientID,
+			Message: fmt.Sprintf("online hosts in %s-%s dropped from %d to %d (%.0f%%)",
+				s.first, s.last, previous, s.online, 100*float64(previous-s.online)/float64(previous)),
+		})
+	}
+	return alerts, nil
+}
+
+// forgetExpired drops the spans last received more than maxAge before now, mu must be held
+func (d *DeviceCountDropDetector) forgetExpired(now time.Time) {
+	if d.maxAge <= 0 {
+		return
+	}
+	for key, count := range d.previous {
+		if now.Sub(count.seenAt) > d.maxAge {
+			delete(d.previous, key)
+		}
+	}
+}
+
+// makeRoom drops the span received longest ago when a new one would pass maxSpans, mu must be held
+func (d *DeviceCountDropDetector) makeRoom() {
+	if d.maxSpans <= 0 || len(d.previous) < d.maxSpans {
+		return
+	}
+
+	var oldestKey string
+	var oldest time.Time
+	for key, count := range d.previous {
+		if oldestKey == "" || count.seenAt.Before(oldest) {
+			oldestKey, oldest = key, count.seenAt
+		}
+	}
+	delete(d.previous, oldestKey)
+}
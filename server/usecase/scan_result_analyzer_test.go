@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/model"
+	"testing"
+	"time"
+)
+
+// onlineBatch is an upload of agent-1 over 10.0.<subnet>.1-10.0.<subnet>.10, the first online hosts answered
+func onlineBatch(subnet, online int, receivedAt time.Time) ScanResultBatch {
+	batch := ScanResultBatch{ClientID: "agent-1", ReceivedAt: receivedAt}
+	for host := 1; host <= 10; host++ {
+		status := "offline"
+		if host <= online {
+			status = model.ScanResultOnline
+		}
+		batch.Results = append(batch.Results, model.ScanResult{ClientID: "agent-1", IP: fmt.Sprintf("10.0.%d.%d", subnet, host), Status: status})
+	}
+	return batch
+}
+
+func TestDeviceCountDropDetectorForgetsOldSpans(t *testing.T) {
+	now := time.Now()
+	ctx := context.Background()
+	detector := NewDeviceCountDropDetector(0.2, time.Hour, 2)
+
+	analyze := func(batch ScanResultBatch) int {
+		t.Helper()
+		alerts, err := detector.Analyze(ctx, batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(alerts)
+	}
+
+	analyze(onlineBatch(1, 10, now))
+	if n := analyze(onlineBatch(1, 5, now.Add(time.Minute))); n != 1 {
+		t.Fatalf("drop within the max age: got %d alerts, want 1", n)
+	}
+
+	// past the max age the span starts over from a new baseline
+	if n := analyze(onlineBatch(1, 1, now.Add(2*time.Hour))); n != 0 {
+		t.Fatalf("drop after the max age: got %d alerts, want the baseline recorded again", n)
+	}
+
+	// a third span evicts the one received longest ago
+	analyze(onlineBatch(2, 10, now.Add(2*time.Hour+time.Minute)))
+	analyze(onlineBatch(3, 10, now.Add(2*time.Hour+2*time.Minute)))
+	if len(detector.previous) != 2 {
+		t.Fatalf("spans kept: got %d, want the cap of 2", len(detector.previous))
+	}
+	if n := analyze(onlineBatch(1, 0, now.Add(2*time.Hour+3*time.Minute))); n != 0 {
+		t.Fatalf("drop of an evicted span: got %d alerts, want none", n)
+	}
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type AlertGetAllReq struct {
+	Kind     string         `json:"kind" http:"query"`
+	ClientID string         `json:"client_id" http:"query"`
+	List     core.ListQuery `json:"list" http:"list"`
+}
+
+type AlertGetAll = core.ActionHandler[AlertGetAllReq, core.ListPage[model.Alert]]
+
+// AlertListFields are the fields the alert list is sorted and filtered by
+var AlertListFields = slices.Sorted(maps.Keys(gateway.AlertListColumns))
+
+func ImplAlertGetAll(
+	AlertGetAll gateway.AlertGetAll,
+) AlertGetAll {
+	return func(ctx context.Context, req AlertGetAllReq) (*core.ListPage[model.Alert], error) {
+
+		list := req.List.Normalize(100, 1000, "-id")
+		if err := list.Validate(AlertListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := AlertGetAll(ctx, gateway.AlertGetAllReq{
+			Kind:     req.Kind,
+			ClientID: req.ClientID,
+			List:     list,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.Alert]{Items: res.Alerts, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
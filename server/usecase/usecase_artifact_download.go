@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"server/gateway"
+	"server/utility"
+	"shared/core"
+	"time"
+)
+
+type ArtifactDownloadReq struct {
+	ID        int       `json:"id" http:"path"`
+	Expires   string    `json:"expires" http:"query"`
+	Signature string    `json:"signature" http:"query"`
+	Now       time.Time `http:"now"`
+}
+
+type ArtifactDownloadRes struct {
+	File     *os.File
+	FileName string
+	ModTime  time.Time
+	SHA256   string
+}
+
+type ArtifactDownload = core.ActionHandler[ArtifactDownloadReq, ArtifactDownloadRes]
+
+func ImplArtifactDownload(
+	ArtifactGetOne gateway.ArtifactGetOne,
+	ArtifactOpenFile gateway.ArtifactOpenFile,
+	signer *utility.URLSigner,
+) ArtifactDownload {
+	return func(ctx context.Context, req ArtifactDownloadReq) (*ArtifactDownloadRes, error) {
+
+		if err := signer.Verify(ArtifactDownloadPath(uint(req.ID)), req.Expires, req.Signature, req.Now); err != nil {
+			return nil, err
+		}
+
+		res, err := ArtifactGetOne(ctx, gateway.ArtifactGetOneReq{ID: uint(req.ID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if res.Artifact == nil {
+			return nil, core.NewNotFoundError(fmt.Errorf("artifact %d not found", req.ID))
+		}
+
+		opened, err := ArtifactOpenFile(ctx, gateway.ArtifactOpenFileReq{Path: res.Artifact.Path})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ArtifactDownloadRes{
+			File:     opened.File,
+			FileName: res.Artifact.FileName,
+			ModTime:  opened.ModTime,
+			SHA256:   res.Artifact.SHA256,
+		}, nil
+	}
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/utility"
+	"shared/core"
+	"time"
+)
+
+type ArtifactDownloadURLReq struct {
+	ID  int       `json:"id" http:"path"`
+	Now time.Time `http:"now"`
+}
+
+type ArtifactDownloadURLRes struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+}
+
+type ArtifactDownloadURL = core.ActionHandler[ArtifactDownloadURLReq, ArtifactDownloadURLRes]
+
+// ImplArtifactDownloadURL issues a signed url so agents can download without holding an api credential
+func ImplArtifactDownloadURL(
+	ArtifactGetOne gateway.ArtifactGetOne,
+	signer *utility.URLSigner,
+	ttl time.Duration,
+) ArtifactDownloadURL {
+	return func(ctx context.Context, req ArtifactDownloadURLReq) (*ArtifactDownloadURLRes, error) {
+
+		res, err := ArtifactGetOne(ctx, gateway.ArtifactGetOneReq{ID: uint(req.ID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if res.Artifact == nil {
+			return nil, core.NewNotFoundError(fmt.Errorf("artifact %d not found", req.ID))
+		}
+
+		path := ArtifactDownloadPath(res.Artifact.ID)
+
+		return &ArtifactDownloadURLRes{
+			URL:       signer.Sign(path, req.Now, ttl),
+			ExpiresAt: req.Now.Add(ttl),
+			Size:      res.Artifact.Size,
+			SHA256:    res.Artifact.SHA256,
+		}, nil
+	}
+}
+
+func ArtifactDownloadPath(id uint) string {
+	return fmt.Sprintf("/api/artifacts/%d/download", id)
+}
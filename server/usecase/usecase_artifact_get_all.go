@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type ArtifactGetAllReq struct {
+	Kind     string `json:"kind" http:"query"`
+	Platform string `json:"platform" http:"query"`
+}
+
+type ArtifactGetAllRes struct {
+	Artifacts []model.Artifact `json:"artifacts"`
+}
+
+type ArtifactGetAll = core.ActionHandler[ArtifactGetAllReq, ArtifactGetAllRes]
+
+func ImplArtifactGetAll(
+	ArtifactGetAll gateway.ArtifactGetAll,
+) ArtifactGetAll {
+	return func(ctx context.Context, req ArtifactGetAllReq) (*ArtifactGetAllRes, error) {
+
+		res, err := ArtifactGetAll(ctx, gateway.ArtifactGetAllReq{
+			Kind:     req.Kind,
+			Platform: req.Platform,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ArtifactGetAllRes{Artifacts: res.Artifacts}, nil
+	}
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type ArtifactUploadReq struct {
+	Name     string    `json:"name"`
+	Version  string    `json:"version"`
+	Platform string    `json:"platform"`
+	Kind     string    `json:"kind"`
+	FileName string    `json:"-"`
+	Content  io.Reader `json:"-"`
+}
+
+type ArtifactUploadRes struct {
+	Artifact model.Artifact `json:"artifact"`
+}
+
+type ArtifactUpload = core.ActionHandler[ArtifactUploadReq, ArtifactUploadRes]
+
+func ImplArtifactUpload(
+	ArtifactStoreFile gateway.ArtifactStoreFile,
+	ArtifactSave gateway.ArtifactSave,
+) ArtifactUpload {
+	return func(ctx context.Context, req ArtifactUploadReq) (*ArtifactUploadRes, error) {
+
+		if req.Name == "" || req.Version == "" {
+			return nil, fmt.Errorf("name and version are required")
+		}
+
+		if req.Kind != "agent" && req.Kind != "plugin" {
+			return nil, fmt.Errorf("kind must be agent or plugin")
+		}
+
+		if req.Content == nil {
+			return nil, fmt.Errorf("file is required")
+		}
+
+		stored, err := ArtifactStoreFile(ctx, gateway.ArtifactStoreFileReq{
+			FileName: req.FileName,
+			Content:  req.Content,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		artifact := model.Artifact{
+			Name:     req.Name,
+			Version:  req.Version,
+			Platform: req.Platform,
+			Kind:     req.Kind,
+			FileName: req.FileName,
+			Path:     stored.Path,
+			Size:     stored.Size,
+			SHA256:   stored.SHA256,
+		}
+
+		if _, err := ArtifactSave(ctx, gateway.ArtifactSaveReq{Artifact: &artifact}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ArtifactUploadRes{Artifact: artifact}, nil
+	}
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+	"shared/utility"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ClientCapabilitiesRecordReq struct {
+	ClientID string
+	Meta     utility.ClientMeta // the metadata the agent connected with
+}
+
+type ClientCapabilitiesRecordRes struct {
+	Recorded bool // false when the agent announced no commands
+}
+
+type ClientCapabilitiesRecord = core.ActionHandler[ClientCapabilitiesRecordReq, ClientCapabilitiesRecordRes]
+
+// ImplClientCapabilitiesRecord keeps the commands, the capabilities and the concurrency an agent announces
+// in its connect metadata, the scan trigger reads them to pick the agents able to run a job. An agent that
+// announces no commands keeps what it announced before.
+func ImplClientCapabilitiesRecord(
+	ClientCapabilitiesSave gateway.ClientCapabilitiesSave,
+) ClientCapabilitiesRecord {
+	return func(ctx context.Context, req ClientCapabilitiesRecordReq) (*ClientCapabilitiesRecordRes, error) {
+
+		announced, ok := req.Meta[utility.CommandsMetaKey]
+		if !ok {
+			return &ClientCapabilitiesRecordRes{}, nil
+		}
+
+		commands := []string{}
+		for _, command := range strings.Split(announced, ",") {
+			if command = strings.ToLower(strings.TrimSpace(command)); command != "" {
+				commands = append(commands, command)
+			}
+		}
+		maxConcurrency, _ := strconv.Atoi(req.Meta[utility.MaxConcurrencyMetaKey])
+
+		capabilities := []string{}
+		for key, value := range req.Meta {
+			if strings.HasPrefix(key, utility.CapabilityMetaPrefix) && value == "true" {
+				capabilities = append(capabilities, key)
+			}
+		}
+		sort.Strings(capabilities)
+
+		if _, err := ClientCapabilitiesSave(ctx, gateway.ClientCapabilitiesSaveReq{
+			ClientID:       req.ClientID,
+			Commands:       commands,
+			Capabilities:   capabilities,
+			MaxConcurrency: max(maxConcurrency, 0),
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ClientCapabilitiesRecordRes{Recorded: true}, nil
+	}
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+)
+
+const (
+	ClientRestart  = "restart"
+	ClientShutdown = "shutdown"
+)
+
+type ClientControlBody struct {
+	Reason string `json:"reason"`
+	// AckTimeoutMs is how long to wait for the agent to confirm, default 5000
+	AckTimeoutMs int `json:"ack_timeout_ms"`
+}
+
+type ClientControlReq struct {
+	ClientID string            `json:"id" http:"path"`
+	Body     ClientControlBody `http:"body"`
+	Action   string            `json:"-"` // restart or shutdown, set by the route
+	Operator string            `json:"-" http:"header(X-Operator)"`
+}
+
+type ClientControlRes struct {
+	MessageID string `json:"message_id"`
+	// Acknowledged is true once the agent confirmed, it stops its components right after
+	Acknowledged bool `json:"acknowledged"`
+}
+
+type ClientControl = core.ActionHandler[ClientControlReq, ClientControlRes]
+
+// ImplClientControl asks an agent to restart or shut down through its lifecycle. An agent that is
+// offline gets nothing, a queued restart would bounce it again whenever it reconnects.
+func ImplClientControl(
+	SendAgentRestart gateway.SendCommand[command.AgentControlRequest],
+	SendAgentShutdown gateway.SendCommand[command.AgentControlRequest],
+	WaitSSEAck gateway.WaitSSEAck,
+	SSEConnectedClientGetAll gateway.SSEConnectedClientGetAll,
+) ClientControl {
+	return func(ctx context.Context, req ClientControlReq) (*ClientControlRes, error) {
+
+		send := SendAgentRestart
+		switch req.Action {
+		case ClientRestart:
+		case ClientShutdown:
+			send = SendAgentShutdown
+		default:
+			return nil, fmt.Errorf("action must be restart or shutdown")
+		}
+		if req.Operator == "" {
+			return nil, fmt.Errorf("operator is required to %s an agent", req.Action)
+		}
+		if err := requireConnectedAgent(ctx, SSEConnectedClientGetAll, req.ClientID); err != nil {
+			return nil, err
+		}
+
+		utility.Infof("Agent %s requested by %s for %s: %s", req.Action, req.Operator, req.ClientID, req.Body.Reason)
+
+		res, err := sendAgentCommand(ctx, send, WaitSSEAck, req.ClientID, command.AgentControlRequest{
+			Reason:      req.Body.Reason,
+			RequestedBy: req.Operator,
+		}, true, req.Body.AckTimeoutMs)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ClientControlRes{MessageID: res.MessageID, Acknowledged: res.Acknowledged}, nil
+	}
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+)
+
+type ClientDuplicateRemoveReq struct{}
+
+type ClientDuplicateRemoveRes struct {
+	Removed int64
+}
+
+type ClientDuplicateRemove = core.ActionHandler[ClientDuplicateRemoveReq, ClientDuplicateRemoveRes]
+
+// ImplClientDuplicateRemove drops the rows an agent got twice before client_id became unique, the
+// newest row of the agent is the one kept
+func ImplClientDuplicateRemove(
+	ClientDuplicateDelete gateway.ClientDuplicateDelete,
+) ClientDuplicateRemove {
+	return func(ctx context.Context, req ClientDuplicateRemoveReq) (*ClientDuplicateRemoveRes, error) {
+
+		deleted, err := ClientDuplicateDelete(ctx, gateway.ClientDuplicateDeleteReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ClientDuplicateRemoveRes{Removed: deleted.Deleted}, nil
+	}
+}
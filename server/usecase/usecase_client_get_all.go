@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type ClientGetAllReq struct {
+	List core.ListQuery `json:"list" http:"list"`
+}
+
+type ClientGetAll = core.ActionHandler[ClientGetAllReq, core.ListPage[model.Client]]
+
+// ClientListFields are the fields the agent list is sorted and filtered by
+var ClientListFields = slices.Sorted(maps.Keys(gateway.ClientListColumns))
+
+// ImplClientGetAll lists the agents with their status, an agent is online while it holds an SSE stream
+func ImplClientGetAll(
+	ClientGetAll gateway.ClientGetAll,
+	SSEConnectedClientGetAll gateway.SSEConnectedClientGetAll,
+) ClientGetAll {
+	return func(ctx context.Context, req ClientGetAllReq) (*core.ListPage[model.Client], error) {
+
+		list := req.List.Normalize(50, 500, "client_id")
+		if err := list.Validate(ClientListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := ClientGetAll(ctx, gateway.ClientGetAllReq{List: list})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		connected, err := SSEConnectedClientGetAll(ctx, gateway.SSEConnectedClientGetAllReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		online := make(map[string]bool, len(connected.ClientIDs))
+		for _, clientID := range connected.ClientIDs {
+			online[clientID] = true
+		}
+
+		for i := range res.Clients {
+			res.Clients[i].Status = res.Clients[i].StatusOf(online[res.Clients[i].ClientID])
+		}
+
+		return &core.ListPage[model.Client]{Items: res.Clients, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+)
+
+type ClientGroupsRestoreReq struct {
+	ClientIDs []string // optional, every known agent when empty
+}
+
+type ClientGroupsRestoreRes struct {
+	Restored int // agents whose memberships were set
+}
+
+type ClientGroupsRestore = core.ActionHandler[ClientGroupsRestoreReq, ClientGroupsRestoreRes]
+
+// ImplClientGroupsRestore sets the group memberships of the SSE server from the groups stored with the
+// agents. A named agent that is not stored, or has no groups anymore, leaves the groups it was in.
+func ImplClientGroupsRestore(
+	ClientGetAll gateway.ClientGetAll,
+	SSEClientGroupsSet gateway.SSEClientGroupsSet,
+) ClientGroupsRestore {
+	return func(ctx context.Context, req ClientGroupsRestoreReq) (*ClientGroupsRestoreRes, error) {
+
+		stored, err := ClientGetAll(ctx, gateway.ClientGetAllReq{ClientIDs: req.ClientIDs})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		groups := make(map[string][]string, len(req.ClientIDs)+len(stored.Clients))
+		for _, clientID := range req.ClientIDs {
+			groups[clientID] = nil
+		}
+		for _, client := range stored.Clients {
+			groups[client.ClientID] = client.Groups
+		}
+
+		for clientID, clientGroups := range groups {
+			if _, err := SSEClientGroupsSet(ctx, gateway.SSEClientGroupsSetReq{ClientID: clientID, Groups: clientGroups}); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+		}
+
+		return &ClientGroupsRestoreRes{Restored: len(groups)}, nil
+	}
+}
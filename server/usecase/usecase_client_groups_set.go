@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"server/gateway"
+	"shared/core"
+	"slices"
+)
+
+// group names are matched as they are in scan triggers and rollouts
+var clientGroupPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type ClientGroupsSetBody struct {
+	Groups []string `json:"groups"` // replaces the groups of the agent, empty removes it from every group
+}
+
+type ClientGroupsSetReq struct {
+	ClientID string              `json:"id" http:"path"`
+	Body     ClientGroupsSetBody `http:"body"`
+}
+
+type ClientGroupsSetRes struct {
+	Groups []string `json:"groups"`
+}
+
+type ClientGroupsSet = core.ActionHandler[ClientGroupsSetReq, ClientGroupsSetRes]
+
+// ImplClientGroupsSet assigns an agent to its groups. They are stored with the agent and applied right
+// away on this instance, the other instances apply them when the agent connects to them.
+func ImplClientGroupsSet(
+	ClientGroupsSave gateway.ClientGroupsSave,
+	SSEClientGroupsSet gateway.SSEClientGroupsSet,
+) ClientGroupsSet {
+	return func(ctx context.Context, req ClientGroupsSetReq) (*ClientGroupsSetRes, error) {
+
+		groups := []string{}
+		for _, group := range req.Body.Groups {
+			if !clientGroupPattern.MatchString(group) {
+				return nil, fmt.Errorf("group %q must be 1 to 64 letters, digits, dots, dashes or underscores", group)
+			}
+			if !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+		slices.Sort(groups)
+
+		if _, err := ClientGroupsSave(ctx, gateway.ClientGroupsSaveReq{ClientID: req.ClientID, Groups: groups}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if _, err := SSEClientGroupsSet(ctx, gateway.SSEClientGroupsSetReq{ClientID: req.ClientID, Groups: groups}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ClientGroupsSetRes{Groups: groups}, nil
+	}
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/core"
+	"strings"
+	"time"
+)
+
+type ClientRegisterBody struct {
+	ClientID string `json:"client_id"` // optional, the agent of the token when empty
+	Version  string `json:"version"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Hostname string `json:"hostname"`
+}
+
+type ClientRegisterReq struct {
+	Body   ClientRegisterBody `http:"body"`
+	Caller string             `json:"-" http:"header(X-Operator)"` // the agent the token was issued to
+	Now    time.Time          `json:"-" http:"now"`
+}
+
+// ClientPresenceRes tells the agent how often to send its heartbeat
+type ClientPresenceRes struct {
+	HeartbeatIntervalSeconds int `json:"heartbeat_interval_seconds"`
+}
+
+type ClientRegister = core.ActionHandler[ClientRegisterReq, ClientPresenceRes]
+
+// ImplClientRegister records the identity an agent announces when it starts, it is then listed as
+// online until it misses its heartbeats
+func ImplClientRegister(
+	ClientRegister gateway.ClientRegister,
+	heartbeatInterval time.Duration,
+) ClientRegister {
+	return func(ctx context.Context, req ClientRegisterReq) (*ClientPresenceRes, error) {
+
+		body := req.Body
+		clientID, err := presenceClientID(body.ClientID, req.Caller)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := ClientRegister(ctx, gateway.ClientRegisterReq{
+			ClientID: clientID,
+			Version:  body.Version,
+			OS:       body.OS,
+			Arch:     body.Arch,
+			Hostname: body.Hostname,
+			Now:      req.Now,
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ClientPresenceRes{HeartbeatIntervalSeconds: int(heartbeatInterval / time.Second)}, nil
+	}
+}
+
+type ClientHeartbeatBody struct {
+	ClientID string `json:"client_id"` // optional, the agent of the token when empty
+}
+
+type ClientHeartbeatReq struct {
+	Body   ClientHeartbeatBody `http:"body"`
+	Caller string              `json:"-" http:"header(X-Operator)"` // the agent the token was issued to
+	Now    time.Time           `json:"-" http:"now"`
+}
+
+type ClientHeartbeat = core.ActionHandler[ClientHeartbeatReq, ClientPresenceRes]
+
+// ImplClientHeartbeat moves the last_seen of a registered agent, an agent the server does not know,
+// e.g. after its database was replaced, is refused and registers again
+func ImplClientHeartbeat(
+	ClientHeartbeat gateway.ClientHeartbeat,
+	heartbeatInterval time.Duration,
+) ClientHeartbeat {
+	return func(ctx context.Context, req ClientHeartbeatReq) (*ClientPresenceRes, error) {
+
+		clientID, err := presenceClientID(req.Body.ClientID, req.Caller)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := ClientHeartbeat(ctx, gateway.ClientHeartbeatReq{ClientID: clientID, Now: req.Now})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		// a 404 the agent tells apart from a malformed heartbeat, it registers again on it
+		if !res.Registered {
+			return nil, core.NewNotFoundError(fmt.Errorf("agent %s is not registered, register before sending heartbeats", clientID))
+		}
+
+		return &ClientPresenceRes{HeartbeatIntervalSeconds: int(heartbeatInterval / time.Second)}, nil
+	}
+}
+
+// presenceClientID is the agent a register or heartbeat is about, always the one of the token, an agent
+// cannot register or keep alive another agent
+func presenceClientID(clientID, caller string) (string, error) {
+	if caller == "" {
+		return "", fmt.Errorf("the token carries no agent")
+	}
+	clientID = strings.TrimSpace(clientID)
+	if clientID != "" && clientID != caller {
+		return "", fmt.Errorf("client_id %s does not match the agent of the token", clientID)
+	}
+	return caller, nil
+}
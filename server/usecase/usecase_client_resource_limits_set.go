@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/command"
+	"shared/core"
+)
+
+type ClientResourceLimitsSetReq struct {
+	ClientID string                 `json:"id" http:"path"`
+	Body     command.ResourceLimits `http:"body"`
+}
+
+type ClientResourceLimitsSetRes struct {
+	MessageID string `json:"message_id"`
+}
+
+type ClientResourceLimitsSet = core.ActionHandler[ClientResourceLimitsSetReq, ClientResourceLimitsSetRes]
+
+// ImplClientResourceLimitsSet pushes new resource limits to an agent, an offline agent gets them on reconnect
+func ImplClientResourceLimitsSet(
+	SendResourceLimits gateway.SendCommand[command.ResourceLimits],
+) ClientResourceLimitsSet {
+	return func(ctx context.Context, req ClientResourceLimitsSetReq) (*ClientResourceLimitsSetRes, error) {
+
+		if req.Body.MaxCPUPercent < 0 || req.Body.MaxCPUPercent > 100 {
+			return nil, fmt.Errorf("max_cpu_percent must be between 0 and 100")
+		}
+
+		if req.Body.MaxMemoryMB < 0 || req.Body.MaxProbesPerSecond < 0 {
+			return nil, fmt.Errorf("max_memory_mb and max_probes_per_second must not be negative")
+		}
+
+		sent, err := SendResourceLimits(ctx, gateway.SendCommandReq[command.ResourceLimits]{
+			Payload:   req.Body,
+			ClientIDs: []string{req.ClientID},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &ClientResourceLimitsSetRes{MessageID: sent.MessageID}, nil
+	}
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+)
+
+type ClientScanScheduleSetReq struct {
+	ClientID string                 `json:"id" http:"path"`
+	Body     command.ScheduleUpdate `http:"body"`
+}
+
+type ClientScanScheduleSetRes struct {
+	MessageID string `json:"message_id"`
+}
+
+type ClientScanScheduleSet = core.ActionHandler[ClientScanScheduleSetReq, ClientScanScheduleSetRes]
+
+// ImplClientScanScheduleSet pushes scan schedules that take precedence over the ones configured on the agent
+func ImplClientScanScheduleSet(
+	SendScheduleUpdate gateway.SendCommand[command.ScheduleUpdate],
+) ClientScanScheduleSet {
+	return func(ctx context.Context, req ClientScanScheduleSetReq) (*ClientScanScheduleSetRes, error) {
+
+		names := map[string]bool{}
+		for i := range req.Body.Schedules {
+			schedule := &req.Body.Schedules[i]
+			if schedule.Name == "" {
+				// the agent names unnamed schedules the same way
+				schedule.Name = fmt.Sprintf("schedule-%d", i+1)
+			}
+			if names[schedule.Name] {
+				return nil, fmt.Errorf("schedule name %s is used more than once", schedule.Name)
+			}
+			names[schedule.Name] = true
+
+			if _, err := utility.ParseCron(schedule.Cron); err != nil {
+				return nil, fmt.Errorf("schedule %s: %v", schedule.Name, err)
+			}
+			if schedule.IPRange == "" {
+				return nil, fmt.Errorf("schedule %s: ip_range is required", schedule.Name)
+			}
+			if _, err := countRangeHosts(schedule.IPRange); err != nil {
+				return nil, fmt.Errorf("schedule %s: %v", schedule.Name, err)
+			}
+			if schedule.Workers < 0 || schedule.TimeoutMs < 0 {
+				return nil, fmt.Errorf("schedule %s: workers and timeout_ms must not be negative", schedule.Name)
+			}
+
+			method, err := scanMethod(schedule.Method)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %s: %v", schedule.Name, err)
+			}
+			if method == model.ScanMethodNmap {
+				return nil, fmt.Errorf("schedule %s: nmap scans cannot be scheduled on the agent", schedule.Name)
+			}
+			schedule.Method = method
+
+			if schedule.Ports, err = scanPorts(schedule.Ports, schedule.Banners); err != nil {
+				return nil, fmt.Errorf("schedule %s: %v", schedule.Name, err)
+			}
+			if err := scanPacing(schedule.MaxPacketsPerSecond, schedule.ProbeDelayMs); err != nil {
+				return nil, fmt.Errorf("schedule %s: %v", schedule.Name, err)
+			}
+		}
+
+		sent, err := SendScheduleUpdate(ctx, gateway.SendCommandReq[command.ScheduleUpdate]{
+			Payload:   req.Body,
+			ClientIDs: []string{req.ClientID},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &ClientScanScheduleSetRes{MessageID: sent.MessageID}, nil
+	}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"server/gateway"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+)
+
+// secret ids show up in scan triggers and logs, the agent applies the same rule
+var clientSecretIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type ClientSecretSetBody struct {
+	Type       string `json:"type"` // snmp, ssh or wmi
+	Community  string `json:"community"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"private_key"`
+	// AckTimeoutMs is how long to wait for the agent to confirm it stored the secret, default 5000
+	AckTimeoutMs int `json:"ack_timeout_ms"`
+}
+
+type ClientSecretSetReq struct {
+	ClientID string              `json:"id" http:"path"`
+	SecretID string              `json:"secret_id" http:"path"`
+	Body     ClientSecretSetBody `http:"body"`
+	Operator string              `json:"-" http:"header(X-Operator)"`
+}
+
+type ClientSecretRes struct {
+	MessageID string `json:"message_id"`
+	// Acknowledged is true once the agent wrote the change to its secrets store
+	Acknowledged bool `json:"acknowledged"`
+}
+
+type ClientSecretSet = core.ActionHandler[ClientSecretSetReq, ClientSecretRes]
+
+// ImplClientSecretSet pushes a credential to the encrypted secrets store of one agent, scan triggers
+// then reference it by id. The server keeps nothing: the message is volatile so the offline store
+// never writes it to the database, and an agent that is not connected is refused.
+func ImplClientSecretSet(
+	SendSecretSet gateway.SendCommand[command.SecretSetRequest],
+	WaitSSEAck gateway.WaitSSEAck,
+	SSEConnectedClientGetAll gateway.SSEConnectedClientGetAll,
+) ClientSecretSet {
+	return func(ctx context.Context, req ClientSecretSetReq) (*ClientSecretRes, error) {
+
+		if err := validateClientSecretReq(req.SecretID, req.Operator); err != nil {
+			return nil, err
+		}
+
+		switch req.Body.Type {
+		case "snmp":
+			if req.Body.Community == "" {
+				return nil, fmt.Errorf("community is required for an snmp secret")
+			}
+		case "ssh":
+			if req.Body.Username == "" || (req.Body.Password == "" && req.Body.PrivateKey == "") {
+				return nil, fmt.Errorf("username and a password or private_key are required for an ssh secret")
+			}
+		case "wmi":
+			if req.Body.Username == "" || req.Body.Password == "" {
+				return nil, fmt.Errorf("username and password are required for a wmi secret")
+			}
+		default:
+			return nil, fmt.Errorf("type must be snmp, ssh or wmi")
+		}
+
+		// only the id is logged, never the credential
+		utility.Infof("Secret %s (%s) pushed to %s by %s", req.SecretID, req.Body.Type, req.ClientID, req.Operator)
+
+		if err := requireConnectedAgent(ctx, SSEConnectedClientGetAll, req.ClientID); err != nil {
+			return nil, err
+		}
+
+		res, err := sendAgentCommand(ctx, SendSecretSet, WaitSSEAck, req.ClientID, command.SecretSetRequest{
+			ID:         req.SecretID,
+			Type:       req.Body.Type,
+			Community:  req.Body.Community,
+			Username:   req.Body.Username,
+			Password:   req.Body.Password,
+			PrivateKey: req.Body.PrivateKey,
+		}, true, req.Body.AckTimeoutMs)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ClientSecretRes{MessageID: res.MessageID, Acknowledged: res.Acknowledged}, nil
+	}
+}
+
+type ClientSecretDeleteReq struct {
+	ClientID     string `json:"id" http:"path"`
+	SecretID     string `json:"secret_id" http:"path"`
+	AckTimeoutMs int    `json:"ack_timeout_ms" http:"query"`
+	Operator     string `json:"-" http:"header(X-Operator)"`
+}
+
+type ClientSecretDelete = core.ActionHandler[ClientSecretDeleteReq, ClientSecretRes]
+
+// ImplClientSecretDelete removes a credential from the secrets store of one agent
+func ImplClientSecretDelete(
+	SendSecretDelete gateway.SendCommand[command.SecretDeleteRequest],
+	WaitSSEAck gateway.WaitSSEAck,
+	SSEConnectedClientGetAll gateway.SSEConnectedClientGetAll,
+) ClientSecretDelete {
+	return func(ctx context.Context, req ClientSecretDeleteReq) (*ClientSecretRes, error) {
+
+		if err := validateClientSecretReq(req.SecretID, req.Operator); err != nil {
+			return nil, err
+		}
+
+		utility.Infof("Secret %s deleted from %s by %s", req.SecretID, req.ClientID, req.Operator)
+
+		if err := requireConnectedAgent(ctx, SSEConnectedClientGetAll, req.ClientID); err != nil {
+			return nil, err
+		}
+
+		res, err := sendAgentCommand(ctx, SendSecretDelete, WaitSSEAck, req.ClientID, command.SecretDeleteRequest{
+			ID: req.SecretID,
+		}, true, req.AckTimeoutMs)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ClientSecretRes{MessageID: res.MessageID, Acknowledged: res.Acknowledged}, nil
+	}
+}
+
+func validateClientSecretReq(secretID, operator string) error {
+	if !clientSecretIDPattern.MatchString(secretID) {
+		return fmt.Errorf("secret id must be 1 to 64 letters, digits, dots, dashes or underscores")
+	}
+	if operator == "" {
+		return fmt.Errorf("operator is required to change agent secrets")
+	}
+	return nil
+}
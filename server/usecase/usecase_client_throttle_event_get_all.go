@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type ClientThrottleEventGetAllReq struct {
+	ClientID string         `json:"id" http:"path"`
+	List     core.ListQuery `json:"list" http:"list"`
+}
+
+type ClientThrottleEventGetAll = core.ActionHandler[ClientThrottleEventGetAllReq, core.ListPage[model.ClientThrottleEvent]]
+
+// ClientThrottleEventListFields are the fields the throttle event list is sorted and filtered by
+var ClientThrottleEventListFields = slices.Sorted(maps.Keys(gateway.ClientThrottleEventListColumns))
+
+func ImplClientThrottleEventGetAll(
+	ClientThrottleEventGetAll gateway.ClientThrottleEventGetAll,
+) ClientThrottleEventGetAll {
+	return func(ctx context.Context, req ClientThrottleEventGetAllReq) (*core.ListPage[model.ClientThrottleEvent], error) {
+
+		list := req.List.Normalize(100, 1000, "-id")
+		if err := list.Validate(ClientThrottleEventListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := ClientThrottleEventGetAll(ctx, gateway.ClientThrottleEventGetAllReq{
+			ClientID: req.ClientID,
+			List:     list,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.ClientThrottleEvent]{Items: res.Events, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"time"
+)
+
+type ClientThrottleReportBody struct {
+	Action         string    `json:"action"`
+	Reason         string    `json:"reason"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryMB       float64   `json:"memory_mb"`
+	WorkersPercent int       `json:"workers_percent"`
+	At             time.Time `json:"at"`
+}
+
+type ClientThrottleReportReq struct {
+	ClientID string                   `json:"id" http:"path"`
+	Body     ClientThrottleReportBody `http:"body"`
+}
+
+type ClientThrottleReportRes struct{}
+
+type ClientThrottleReport = core.ActionHandler[ClientThrottleReportReq, ClientThrottleReportRes]
+
+// ImplClientThrottleReport records a throttle event sent by the resource guard of an agent
+func ImplClientThrottleReport(
+	ClientThrottleEventSave gateway.ClientThrottleEventSave,
+) ClientThrottleReport {
+	return func(ctx context.Context, req ClientThrottleReportReq) (*ClientThrottleReportRes, error) {
+
+		switch req.Body.Action {
+		case "throttled", "paused", "resumed":
+		default:
+			return nil, fmt.Errorf("action must be throttled, paused or resumed")
+		}
+
+		if _, err := ClientThrottleEventSave(ctx, gateway.ClientThrottleEventSaveReq{
+			Event: &model.ClientThrottleEvent{
+				ClientID:       req.ClientID,
+				Action:         req.Body.Action,
+				Reason:         req.Body.Reason,
+				CPUPercent:     req.Body.CPUPercent,
+				MemoryMB:       req.Body.MemoryMB,
+				WorkersPercent: req.Body.WorkersPercent,
+				At:             req.Body.At,
+			},
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ClientThrottleReportRes{}, nil
+	}
+}
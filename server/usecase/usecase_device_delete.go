@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/core"
+)
+
+type DeviceDeleteReq struct {
+	ID int `json:"id" http:"path"`
+}
+
+type DeviceDeleteRes struct{}
+
+type DeviceDelete = core.ActionHandler[DeviceDeleteReq, DeviceDeleteRes]
+
+// ImplDeviceDelete removes a device from the inventory, it comes back as a new device once a scan finds it online again
+func ImplDeviceDelete(
+	DeviceDelete gateway.DeviceDelete,
+) DeviceDelete {
+	return func(ctx context.Context, req DeviceDeleteReq) (*DeviceDeleteRes, error) {
+
+		res, err := DeviceDelete(ctx, gateway.DeviceDeleteReq{ID: uint(req.ID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if !res.Deleted {
+			return nil, fmt.Errorf("device %d not found", req.ID)
+		}
+
+		return &DeviceDeleteRes{}, nil
+	}
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"strconv"
+	"time"
+)
+
+type DeviceExportReq struct {
+	Format  string    `json:"format" http:"query"`
+	Columns []string  `json:"columns" http:"query"` // every column when empty
+	Status  string    `json:"status" http:"query"`
+	Search  string    `json:"search" http:"query"`
+	Now     time.Time `json:"-" http:"now"`
+}
+
+type DeviceExport = core.ActionHandler[DeviceExportReq, ExportFile]
+
+// deviceExportColumns are the columns of a device export, in their default order
+var deviceExportColumns = []exportColumn[model.Device]{
+	{Name: "id", Value: func(d model.Device) string { return strconv.FormatUint(uint64(d.ID), 10) }},
+	{Name: "ip", Value: func(d model.Device) string { return d.IP }},
+	{Name: "mac", Value: func(d model.Device) string { return d.MAC }},
+	{Name: "hostname", Value: func(d model.Device) string { return d.Hostname }},
+	{Name: "vendor", Value: func(d model.Device) string { return d.Vendor }},
+	{Name: "status", Value: func(d model.Device) string { return d.Status }},
+	{Name: "first_seen", Value: func(d model.Device) string { return exportTime(d.FirstSeen) }},
+	{Name: "last_seen", Value: func(d model.Device) string { return exportTime(d.LastSeen) }},
+	{Name: "probed_at", Value: func(d model.Device) string { return exportTime(d.ProbedAt) }},
+	{Name: "client_id", Value: func(d model.Device) string { return d.ClientID }},
+}
+
+// ImplDeviceExport streams the device inventory as CSV, with the filters of GET /api/devices and no row limit
+func ImplDeviceExport(
+	DeviceEach gateway.DeviceEach,
+) DeviceExport {
+	return func(ctx context.Context, req DeviceExportReq) (*ExportFile, error) {
+
+		if err := exportFormat(req.Format); err != nil {
+			return nil, err
+		}
+		columns, err := selectExportColumns(deviceExportColumns, req.Columns)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ExportFile{
+			FileName:    exportFileName("devices", req.Now, "csv"),
+			ContentType: "text/csv; charset=utf-8",
+			Write: func(ctx context.Context, w io.Writer) error {
+				each, flush, err := csvRowWriter(w, columns)
+				if err != nil {
+					return err
+				}
+				if _, err := DeviceEach(ctx, gateway.DeviceEachReq{
+					Status: req.Status,
+					Search: req.Search,
+					Each:   each,
+				}); err != nil {
+					return err
+				}
+				return flush()
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type DeviceGetAllReq struct {
+	Status string         `json:"status" http:"query"`
+	Search string         `json:"search" http:"query"`
+	List   core.ListQuery `json:"list" http:"list"`
+}
+
+type DeviceGetAll = core.ActionHandler[DeviceGetAllReq, core.ListPage[model.Device]]
+
+// DeviceListFields are the fields the device list is sorted and filtered by
+var DeviceListFields = slices.Sorted(maps.Keys(gateway.DeviceListColumns))
+
+func ImplDeviceGetAll(
+	DeviceGetAll gateway.DeviceGetAll,
+) DeviceGetAll {
+	return func(ctx context.Context, req DeviceGetAllReq) (*core.ListPage[model.Device], error) {
+
+		list := req.List.Normalize(100, 1000, "id")
+		if err := list.Validate(DeviceListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := DeviceGetAll(ctx, gateway.DeviceGetAllReq{
+			Status: req.Status,
+			Search: req.Search,
+			List:   list,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.Device]{Items: res.Devices, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type DeviceGetOneReq struct {
+	ID int `json:"id" http:"path"`
+}
+
+type DeviceGetOneRes struct {
+	Device model.Device `json:"device"`
+}
+
+type DeviceGetOne = core.ActionHandler[DeviceGetOneReq, DeviceGetOneRes]
+
+func ImplDeviceGetOne(
+	DeviceGetOne gateway.DeviceGetOne,
+) DeviceGetOne {
+	return func(ctx context.Context, req DeviceGetOneReq) (*DeviceGetOneRes, error) {
+
+		res, err := DeviceGetOne(ctx, gateway.DeviceGetOneReq{ID: uint(req.ID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if res.Device == nil {
+			return nil, fmt.Errorf("device %d not found", req.ID)
+		}
+
+		return &DeviceGetOneRes{Device: *res.Device}, nil
+	}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"strings"
+)
+
+// DeviceUpdateBody holds what an operator knows about a device, the scan fields are kept up to date by the scans
+type DeviceUpdateBody struct {
+	MAC      string `json:"mac"`
+	Hostname string `json:"hostname"`
+	Vendor   string `json:"vendor"`
+}
+
+type DeviceUpdateReq struct {
+	ID   int              `json:"id" http:"path"`
+	Body DeviceUpdateBody `http:"body"`
+}
+
+type DeviceUpdateRes struct {
+	Device model.Device `json:"device"`
+}
+
+type DeviceUpdate = core.ActionHandler[DeviceUpdateReq, DeviceUpdateRes]
+
+func ImplDeviceUpdate(
+	DeviceGetOne gateway.DeviceGetOne,
+	DeviceSave gateway.DeviceSave,
+) DeviceUpdate {
+	return func(ctx context.Context, req DeviceUpdateReq) (*DeviceUpdateRes, error) {
+
+		mac := strings.TrimSpace(req.Body.MAC)
+		if mac != "" {
+			hw, err := net.ParseMAC(mac)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mac %q", req.Body.MAC)
+			}
+			mac = hw.String()
+		}
+
+		res, err := DeviceGetOne(ctx, gateway.DeviceGetOneReq{ID: uint(req.ID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if res.Device == nil {
+			return nil, fmt.Errorf("device %d not found", req.ID)
+		}
+
+		device := res.Device
+		device.MAC = mac
+		device.Hostname = strings.TrimSpace(req.Body.Hostname)
+		device.Vendor = strings.TrimSpace(req.Body.Vendor)
+
+		if _, err := DeviceSave(ctx, gateway.DeviceSaveReq{Device: device}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &DeviceUpdateRes{Device: *device}, nil
+	}
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/command"
+	"shared/core"
+	"time"
+)
+
+type DiscoverServicesTriggerReq struct {
+	// ClientIDs are the agents that listen, empty asks every connected agent
+	ClientIDs []string `json:"client_ids"`
+	// WindowMs is how long the agents listen for announcements, default 5000, at most 60000
+	WindowMs int `json:"window_ms" validate:"max=60000"`
+	// Protocols are mdns and/or ssdp, empty listens for both
+	Protocols []string `json:"protocols" validate:"dive,oneof=mdns ssdp"`
+	// AckTimeoutMs is how long to wait for the agents to confirm they received the command, default 5000
+	AckTimeoutMs int `json:"ack_timeout_ms"`
+}
+
+type DiscoverServicesTriggerRes struct {
+	MessageID      string   `json:"message_id"`
+	AcknowledgedBy []string `json:"acknowledged_by"`
+	Acknowledged   bool     `json:"acknowledged"`
+}
+
+type DiscoverServicesTrigger = core.ActionHandler[DiscoverServicesTriggerReq, DiscoverServicesTriggerRes]
+
+// ImplDiscoverServicesTrigger asks agents to listen for mDNS and SSDP announcements on their segment,
+// each agent reports what it heard once its window closed. Only agents announcing the discover command
+// are asked, a named agent without it is refused.
+func ImplDiscoverServicesTrigger(
+	SendDiscoverServices gateway.SendCommand[command.DiscoverServicesRequest],
+	WaitSSEAck gateway.WaitSSEAck,
+	ClientGetAll gateway.ClientGetAll,
+) DiscoverServicesTrigger {
+	return func(ctx context.Context, req DiscoverServicesTriggerReq) (*DiscoverServicesTriggerRes, error) {
+
+		if req.WindowMs <= 0 {
+			req.WindowMs = 5000
+		}
+		if req.WindowMs > 60000 {
+			return nil, fmt.Errorf("window_ms must be at most 60000")
+		}
+		for _, protocol := range req.Protocols {
+			if protocol != model.DiscoveryMDNS && protocol != model.DiscoverySSDP {
+				return nil, fmt.Errorf("protocols must be %s or %s", model.DiscoveryMDNS, model.DiscoverySSDP)
+			}
+		}
+		if req.AckTimeoutMs <= 0 {
+			req.AckTimeoutMs = 5000
+		}
+		if _, err := capableAgents(ctx, ClientGetAll, req.ClientIDs, true, []string{model.CommandDiscover}, 0); err != nil {
+			return nil, err
+		}
+
+		sent, err := SendDiscoverServices(ctx, gateway.SendCommandReq[command.DiscoverServicesRequest]{
+			Payload: command.DiscoverServicesRequest{
+				WindowMs:  req.WindowMs,
+				Protocols: req.Protocols,
+			},
+			ClientIDs: req.ClientIDs,
+			Requires:  []string{model.CommandDiscover},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		acked, err := WaitSSEAck(ctx, gateway.WaitSSEAckReq{
+			MessageID: sent.MessageID,
+			ClientIDs: req.ClientIDs,
+			Timeout:   time.Duration(req.AckTimeoutMs) * time.Millisecond,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &DiscoverServicesTriggerRes{
+			MessageID:      sent.MessageID,
+			AcknowledgedBy: acked.AcknowledgedBy,
+			Acknowledged:   acked.Complete,
+		}, nil
+	}
+}
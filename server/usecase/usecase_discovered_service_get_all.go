@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type DiscoveredServiceGetAllReq struct {
+	Protocol string         `json:"protocol" http:"query"`
+	IP       string         `json:"ip" http:"query"`
+	ClientID string         `json:"client_id" http:"query"`
+	Search   string         `json:"search" http:"query"`
+	List     core.ListQuery `json:"list" http:"list"`
+}
+
+type DiscoveredServiceGetAll = core.ActionHandler[DiscoveredServiceGetAllReq, core.ListPage[model.DiscoveredService]]
+
+// DiscoveredServiceListFields are the fields the service list is sorted and filtered by
+var DiscoveredServiceListFields = slices.Sorted(maps.Keys(gateway.DiscoveredServiceListColumns))
+
+func ImplDiscoveredServiceGetAll(
+	DiscoveredServiceGetAll gateway.DiscoveredServiceGetAll,
+) DiscoveredServiceGetAll {
+	return func(ctx context.Context, req DiscoveredServiceGetAllReq) (*core.ListPage[model.DiscoveredService], error) {
+
+		list := req.List.Normalize(100, 1000, "ip,protocol,service_type")
+		if err := list.Validate(DiscoveredServiceListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := DiscoveredServiceGetAll(ctx, gateway.DiscoveredServiceGetAllReq{
+			Protocol: req.Protocol,
+			IP:       req.IP,
+			ClientID: req.ClientID,
+			Search:   req.Search,
+			List:     list,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.DiscoveredService]{Items: res.Services, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"server/gateway"
+	"shared/core"
+	"time"
+)
+
+type ExpectedDeviceImportReq struct {
+	SiteID   int       `json:"id" http:"path"`
+	FileName string    `json:"-"`
+	Content  io.Reader `json:"-"`
+	Now      time.Time `json:"-" http:"now"`
+}
+
+type ExpectedDeviceImportRes struct {
+	Imported       int                           `json:"imported"`
+	Reconciliation InventoryReconciliationReport `json:"reconciliation"`
+}
+
+type ExpectedDeviceImport = core.ActionHandler[ExpectedDeviceImportReq, ExpectedDeviceImportRes]
+
+// ImplExpectedDeviceImport replaces the inventory of a site with an uploaded CSV or XLSX file, a single
+// invalid row rejects the whole file, then reconciles the site against the new inventory
+func ImplExpectedDeviceImport(
+	SiteGetOne gateway.SiteGetOne,
+	ExpectedDeviceReplace gateway.ExpectedDeviceReplace,
+	ExpectedDeviceGetAll gateway.ExpectedDeviceGetAll,
+	ScanResultGetAll gateway.ScanResultGetAll,
+	InventoryReconciliationGetOne gateway.InventoryReconciliationGetOne,
+	InventoryReconciliationSave gateway.InventoryReconciliationSave,
+	SendSSEMessage gateway.SendSSEMessage,
+	PublishDashboard gateway.SendSSEMessage,
+) ExpectedDeviceImport {
+	return func(ctx context.Context, req ExpectedDeviceImportReq) (*ExpectedDeviceImportRes, error) {
+
+		siteRes, err := SiteGetOne(ctx, gateway.SiteGetOneReq{ID: uint(req.SiteID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if siteRes.Site == nil {
+			return nil, fmt.Errorf("site %d not found", req.SiteID)
+		}
+
+		devices, err := parseExpectedDevices(req.FileName, req.Content)
+		if err != nil {
+			return nil, err
+		}
+		for i := range devices {
+			devices[i].SiteID = siteRes.Site.ID
+		}
+
+		if _, err := ExpectedDeviceReplace(ctx, gateway.ExpectedDeviceReplaceReq{
+			SiteID:  siteRes.Site.ID,
+			Devices: devices,
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		report, err := reconcileSite(ctx, SiteGetOne, ExpectedDeviceGetAll, ScanResultGetAll, InventoryReconciliationGetOne, InventoryReconciliationSave, SendSSEMessage, PublishDashboard, siteRes.Site.ID, req.Now)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ExpectedDeviceImportRes{Imported: len(devices), Reconciliation: *report}, nil
+	}
+}
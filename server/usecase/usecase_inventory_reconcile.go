@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+	"time"
+)
+
+type InventoryReconcileReq struct {
+	SiteID int       `json:"id" http:"path"`
+	Now    time.Time `json:"-" http:"now"`
+}
+
+type InventoryReconcileRes struct {
+	Reconciliation InventoryReconciliationReport `json:"reconciliation"`
+}
+
+type InventoryReconcile = core.ActionHandler[InventoryReconcileReq, InventoryReconcileRes]
+
+// ImplInventoryReconcile compares the inventory of a site with the latest scan results of its agents,
+// subscribers of inventory_reconciliation_changed are told only when the outcome differs from the last run
+func ImplInventoryReconcile(
+	SiteGetOne gateway.SiteGetOne,
+	ExpectedDeviceGetAll gateway.ExpectedDeviceGetAll,
+	ScanResultGetAll gateway.ScanResultGetAll,
+	InventoryReconciliationGetOne gateway.InventoryReconciliationGetOne,
+	InventoryReconciliationSave gateway.InventoryReconciliationSave,
+	SendSSEMessage gateway.SendSSEMessage,
+	PublishDashboard gateway.SendSSEMessage,
+) InventoryReconcile {
+	return func(ctx context.Context, req InventoryReconcileReq) (*InventoryReconcileRes, error) {
+
+		report, err := reconcileSite(ctx, SiteGetOne, ExpectedDeviceGetAll, ScanResultGetAll, InventoryReconciliationGetOne, InventoryReconciliationSave, SendSSEMessage, PublishDashboard, uint(req.SiteID), req.Now)
+		if err != nil {
+			return nil, err
+		}
+
+		return &InventoryReconcileRes{Reconciliation: *report}, nil
+	}
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"strings"
+	"time"
+)
+
+type IPAllocationSetBody struct {
+	Status   string `json:"status" validate:"required,oneof=reserved assigned free"` // reserved, assigned or free
+	Hostname string `json:"hostname"`
+	Note     string `json:"note"`
+}
+
+type IPAllocationSetReq struct {
+	IP   string              `json:"ip" http:"path"`
+	Body IPAllocationSetBody `http:"body"`
+	Now  time.Time           `json:"-" http:"now"`
+}
+
+type IPAllocationSetRes struct {
+	Allocation model.IPAllocation `json:"allocation"`
+}
+
+type IPAllocationSet = core.ActionHandler[IPAllocationSetReq, IPAllocationSetRes]
+
+// ImplIPAllocationSet records the state of an address, the address must be inside one of the IPAM subnets
+func ImplIPAllocationSet(
+	IPAMSubnetGetAll gateway.IPAMSubnetGetAll,
+	IPAllocationSave gateway.IPAllocationSave,
+) IPAllocationSet {
+	return func(ctx context.Context, req IPAllocationSetReq) (*IPAllocationSetRes, error) {
+
+		switch req.Body.Status {
+		case model.IPReserved, model.IPAssigned, model.IPFree:
+		default:
+			return nil, fmt.Errorf("status must be reserved, assigned or free")
+		}
+
+		addr, err := netip.ParseAddr(strings.TrimSpace(req.IP))
+		if err != nil || !addr.Is4() {
+			return nil, fmt.Errorf("invalid IPv4 address %q", req.IP)
+		}
+
+		// subnets do not overlap, an address is inside one at most
+		subnets, err := IPAMSubnetGetAll(ctx, gateway.IPAMSubnetGetAllReq{Containing: addr})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if len(subnets.Subnets) == 0 {
+			return nil, fmt.Errorf("%s is not inside any IPAM subnet", addr)
+		}
+		subnetID := subnets.Subnets[0].ID
+
+		allocation := model.IPAllocation{
+			SubnetID:  subnetID,
+			IP:        addr.String(),
+			Status:    req.Body.Status,
+			Hostname:  strings.TrimSpace(req.Body.Hostname),
+			Note:      req.Body.Note,
+			UpdatedAt: req.Now,
+		}
+
+		if _, err := IPAllocationSave(ctx, gateway.IPAllocationSaveReq{Allocation: &allocation}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &IPAllocationSetRes{Allocation: allocation}, nil
+	}
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"server/gateway"
+	"shared/core"
+	"sort"
+)
+
+type IPAMAddressGetAllReq struct {
+	SubnetID int `json:"id" http:"path"`
+}
+
+type IPAMAddressGetAllRes struct {
+	Addresses []IPAMAddress `json:"addresses"`
+	Conflicts int           `json:"conflicts"` // addresses flagged in Addresses
+}
+
+type IPAMAddressGetAll = core.ActionHandler[IPAMAddressGetAllReq, IPAMAddressGetAllRes]
+
+func ImplIPAMAddressGetAll(
+	IPAMSubnetGetOne gateway.IPAMSubnetGetOne,
+	IPAllocationGetAll gateway.IPAllocationGetAll,
+	ScanResultGetLatest gateway.ScanResultGetLatest,
+) IPAMAddressGetAll {
+	return func(ctx context.Context, req IPAMAddressGetAllReq) (*IPAMAddressGetAllRes, error) {
+
+		subnetRes, err := IPAMSubnetGetOne(ctx, gateway.IPAMSubnetGetOneReq{ID: uint(req.SubnetID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if subnetRes.Subnet == nil {
+			return nil, fmt.Errorf("subnet %d not found", req.SubnetID)
+		}
+		prefix, err := netip.ParsePrefix(subnetRes.Subnet.CIDR)
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		allocations, err := IPAllocationGetAll(ctx, gateway.IPAllocationGetAllReq{SubnetID: uint(req.SubnetID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		// the scans are not tied to subnets, the addresses ever scanned are matched against the prefix
+		scans, err := ScanResultGetLatest(ctx, gateway.ScanResultGetLatestReq{Prefix: prefix})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		addresses := map[string]*IPAMAddress{}
+		for _, allocation := range allocations.Allocations {
+			addresses[allocation.IP] = &IPAMAddress{
+				IP:       allocation.IP,
+				Status:   allocation.Status,
+				Hostname: allocation.Hostname,
+				Note:     allocation.Note,
+			}
+		}
+		for _, result := range scans.ScanResults {
+			address, ok := addresses[result.IP]
+			if !ok {
+				address = &IPAMAddress{IP: result.IP}
+				addresses[result.IP] = address
+			}
+			seen := result.Timestamp
+			address.LastStatus = result.Status
+			address.LastSeen = &seen
+			address.LastClientID = result.ClientID
+		}
+
+		res := IPAMAddressGetAllRes{Addresses: []IPAMAddress{}}
+		for _, address := range addresses {
+			address.Conflict = ipamConflict(address.Status, address.LastStatus)
+			if address.Conflict {
+				res.Conflicts++
+			}
+			res.Addresses = append(res.Addresses, *address)
+		}
+		sort.Slice(res.Addresses, func(i, j int) bool {
+			return compareIPStrings(res.Addresses[i].IP, res.Addresses[j].IP) < 0
+		})
+
+		return &res, nil
+	}
+}
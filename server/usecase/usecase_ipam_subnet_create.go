@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"strings"
+)
+
+type IPAMSubnetCreateReq struct {
+	CIDR        string `json:"cidr" validate:"required,cidr"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type IPAMSubnetCreateRes struct {
+	Subnet model.IPAMSubnet `json:"subnet"`
+}
+
+type IPAMSubnetCreate = core.ActionHandler[IPAMSubnetCreateReq, IPAMSubnetCreateRes]
+
+func ImplIPAMSubnetCreate(
+	IPAMSubnetGetAll gateway.IPAMSubnetGetAll,
+	IPAMSubnetSave gateway.IPAMSubnetSave,
+) IPAMSubnetCreate {
+	return func(ctx context.Context, req IPAMSubnetCreateReq) (*IPAMSubnetCreateRes, error) {
+
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(req.CIDR))
+		if err != nil || !prefix.Addr().Is4() {
+			return nil, fmt.Errorf("cidr must be an IPv4 subnet such as 10.0.0.0/24")
+		}
+		cidr := prefix.Masked().String()
+
+		// an address belongs to a single subnet, otherwise its allocation would be ambiguous
+		existing, err := IPAMSubnetGetAll(ctx, gateway.IPAMSubnetGetAllReq{Overlapping: prefix.Masked()})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if len(existing.Subnets) > 0 {
+			return nil, fmt.Errorf("cidr %s overlaps subnet %s", cidr, existing.Subnets[0].CIDR)
+		}
+
+		subnet := model.IPAMSubnet{
+			CIDR:        cidr,
+			Name:        strings.TrimSpace(req.Name),
+			Description: req.Description,
+		}
+
+		if _, err := IPAMSubnetSave(ctx, gateway.IPAMSubnetSaveReq{Subnet: &subnet}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &IPAMSubnetCreateRes{Subnet: subnet}, nil
+	}
+}
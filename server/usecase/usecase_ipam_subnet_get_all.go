@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type IPAMSubnetGetAllReq struct{}
+
+type IPAMSubnetGetAllRes struct {
+	Subnets []model.IPAMSubnet `json:"subnets"`
+}
+
+type IPAMSubnetGetAll = core.ActionHandler[IPAMSubnetGetAllReq, IPAMSubnetGetAllRes]
+
+func ImplIPAMSubnetGetAll(
+	IPAMSubnetGetAll gateway.IPAMSubnetGetAll,
+) IPAMSubnetGetAll {
+	return func(ctx context.Context, req IPAMSubnetGetAllReq) (*IPAMSubnetGetAllRes, error) {
+
+		res, err := IPAMSubnetGetAll(ctx, gateway.IPAMSubnetGetAllReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &IPAMSubnetGetAllRes{Subnets: res.Subnets}, nil
+	}
+}
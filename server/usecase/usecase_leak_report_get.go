@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+	"shared/utility"
+)
+
+type LeakReportGetReq struct {
+	// Kind keeps the warnings of one kind, goroutines, open_fds or a check name such as sse
+	Kind string `json:"kind" http:"query"`
+}
+
+type LeakReportGetRes struct {
+	utility.LeakReport
+}
+
+type LeakReportGet = core.ActionHandler[LeakReportGetReq, LeakReportGetRes]
+
+func ImplLeakReportGet(
+	LeakReportGet gateway.LeakReportGet,
+) LeakReportGet {
+	return func(ctx context.Context, req LeakReportGetReq) (*LeakReportGetRes, error) {
+
+		res, err := LeakReportGet(ctx, gateway.LeakReportGetReq{})
+		if err != nil {
+			return nil, err
+		}
+
+		report := res.Report
+		if req.Kind != "" {
+			warnings := make([]utility.LeakWarning, 0)
+			for _, warning := range report.Warnings {
+				if warning.Kind == req.Kind {
+					warnings = append(warnings, warning)
+				}
+			}
+			report.Warnings = warnings
+		}
+
+		return &LeakReportGetRes{LeakReport: report}, nil
+	}
+}
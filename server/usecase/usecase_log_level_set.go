@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"time"
+)
+
+const (
+	LogLevelScopeServer = "server"
+	LogLevelScopeAgents = "agents"
+	LogLevelScopeAll    = "all"
+)
+
+type LogLevelSetReq struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn error"` // debug, info, warn or error
+	// DurationSeconds puts the previous level back after this many seconds, 0 keeps the new level, at most 86400
+	DurationSeconds int `json:"duration_seconds" validate:"min=0,max=86400"`
+	// Scope is server (default), agents or all
+	Scope string `json:"scope" validate:"omitempty,oneof=server agents all"`
+	// ClientIDs narrows the agents, empty means every connected agent
+	ClientIDs []string `json:"client_ids"`
+	// AckTimeoutMs is how long to wait for the agents to apply the level, default 5000
+	AckTimeoutMs int    `json:"ack_timeout_ms"`
+	Operator     string `json:"-" http:"header(X-Operator)"`
+}
+
+type LogLevelServerState struct {
+	Level     string     `json:"level"`
+	Previous  string     `json:"previous"`
+	RevertsAt *time.Time `json:"reverts_at,omitempty"`
+}
+
+type LogLevelSetRes struct {
+	Server *LogLevelServerState `json:"server,omitempty"`
+	// MessageID, AcknowledgedBy and Acknowledged are set when agents were asked
+	MessageID      string   `json:"message_id,omitempty"`
+	AcknowledgedBy []string `json:"acknowledged_by,omitempty"`
+	Acknowledged   bool     `json:"acknowledged"`
+}
+
+type LogLevelSet = core.ActionHandler[LogLevelSetReq, LogLevelSetRes]
+
+// ImplLogLevelSet switches the log level of the server, of agents or of both without a restart,
+// a duration turns verbose logging off again on its own once an investigation is over
+func ImplLogLevelSet(
+	LogLevelSet gateway.LogLevelSet,
+	SendSetLogLevel gateway.SendCommand[command.SetLogLevelRequest],
+	WaitSSEAck gateway.WaitSSEAck,
+) LogLevelSet {
+	return func(ctx context.Context, req LogLevelSetReq) (*LogLevelSetRes, error) {
+
+		level, err := utility.ParseLogLevel(req.Level)
+		if err != nil {
+			return nil, err
+		}
+		if req.DurationSeconds < 0 || req.DurationSeconds > 86400 {
+			return nil, fmt.Errorf("duration_seconds must be between 0 and 86400")
+		}
+		if req.Scope == "" {
+			req.Scope = LogLevelScopeServer
+		}
+		if req.Scope != LogLevelScopeServer && req.Scope != LogLevelScopeAgents && req.Scope != LogLevelScopeAll {
+			return nil, fmt.Errorf("scope must be %s, %s or %s", LogLevelScopeServer, LogLevelScopeAgents, LogLevelScopeAll)
+		}
+		if req.Scope == LogLevelScopeServer && len(req.ClientIDs) > 0 {
+			return nil, fmt.Errorf("client_ids needs scope %s or %s", LogLevelScopeAgents, LogLevelScopeAll)
+		}
+		if req.AckTimeoutMs <= 0 {
+			req.AckTimeoutMs = 5000
+		}
+
+		utility.Infof("Log level %s for %s requested by %s (%ds)", level, req.Scope, req.Operator, req.DurationSeconds)
+
+		res := LogLevelSetRes{}
+
+		if req.Scope != LogLevelScopeServer {
+			sent, err := SendSetLogLevel(ctx, gateway.SendCommandReq[command.SetLogLevelRequest]{
+				Payload: command.SetLogLevelRequest{
+					Level:           level.String(),
+					DurationSeconds: req.DurationSeconds,
+				},
+				ClientIDs: req.ClientIDs,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			acked, err := WaitSSEAck(ctx, gateway.WaitSSEAckReq{
+				MessageID: sent.MessageID,
+				ClientIDs: req.ClientIDs,
+				Timeout:   time.Duration(req.AckTimeoutMs) * time.Millisecond,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			res.MessageID = sent.MessageID
+			res.AcknowledgedBy = acked.AcknowledgedBy
+			res.Acknowledged = acked.Complete
+		}
+
+		if req.Scope != LogLevelScopeAgents {
+			changed, err := LogLevelSet(ctx, gateway.LogLevelSetReq{
+				Level:    level,
+				Duration: time.Duration(req.DurationSeconds) * time.Second,
+			})
+			if err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+
+			res.Server = &LogLevelServerState{Level: level.String(), Previous: changed.Previous.String()}
+			if !changed.RevertsAt.IsZero() {
+				res.Server.RevertsAt = &changed.RevertsAt
+			}
+			if req.Scope == LogLevelScopeServer {
+				res.Acknowledged = true
+			}
+		}
+
+		return &res, nil
+	}
+}
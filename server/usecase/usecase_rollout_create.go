@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"shared/utility"
+	"strings"
+)
+
+type RolloutCreateReq struct {
+	// EventType is the command to roll out, any server to client event of the catalog, e.g. resource_limits
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	// ClientIDs are the targets, without them every connected client or every member of Group
+	ClientIDs []string `json:"client_ids"`
+	Group     string   `json:"group"`
+	// CanaryPercent of the targets receive the command first, default 10
+	CanaryPercent int `json:"canary_percent" validate:"omitempty,min=1,max=100"`
+	// MinSuccessPercent of the canary must acknowledge within the window for the rollout to proceed, default 100
+	MinSuccessPercent int `json:"min_success_percent" validate:"omitempty,min=1,max=100"`
+	// AckWindowMs is how long each wave may take to acknowledge, default 60000
+	AckWindowMs int64  `json:"ack_window_ms" validate:"min=0"`
+	Operator    string `json:"-" http:"header(X-Operator)"`
+}
+
+type RolloutCreateRes struct {
+	Rollout model.Rollout `json:"rollout"`
+}
+
+type RolloutCreate = core.ActionHandler[RolloutCreateReq, RolloutCreateRes]
+
+// ImplRolloutCreate answers once the canary is chosen, the waves are sent in the background and
+// followed through the rollout status
+func ImplRolloutCreate(
+	SSEEventCatalogGet gateway.SSEEventCatalogGet,
+	SSEConnectedClientGetAll gateway.SSEConnectedClientGetAll,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	RolloutSave gateway.RolloutSave,
+	IDGenerator core.IDGenerator,
+) RolloutCreate {
+	return func(ctx context.Context, req RolloutCreateReq) (*RolloutCreateRes, error) {
+
+		catalog, err := SSEEventCatalogGet(ctx, gateway.SSEEventCatalogGetReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		known := false
+		for _, entry := range catalog.Events {
+			if entry.Type == req.EventType && entry.Direction == utility.EventToClient {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("event_type %q is not a server to client event of the catalog", req.EventType)
+		}
+
+		if req.CanaryPercent == 0 {
+			req.CanaryPercent = 10
+		}
+		if req.MinSuccessPercent == 0 {
+			req.MinSuccessPercent = 100
+		}
+		if req.AckWindowMs == 0 {
+			req.AckWindowMs = 60000
+		}
+		if req.CanaryPercent < 1 || req.CanaryPercent > 100 {
+			return nil, fmt.Errorf("canary_percent must be between 1 and 100")
+		}
+		if req.MinSuccessPercent < 1 || req.MinSuccessPercent > 100 {
+			return nil, fmt.Errorf("min_success_percent must be between 1 and 100")
+		}
+		if req.AckWindowMs < 0 {
+			return nil, fmt.Errorf("ack_window_ms must not be negative")
+		}
+		if len(req.Payload) == 0 {
+			req.Payload = json.RawMessage("{}")
+		}
+
+		targets := make([]string, 0, len(req.ClientIDs))
+		seen := map[string]bool{}
+		for _, clientID := range req.ClientIDs {
+			if clientID = strings.TrimSpace(clientID); clientID != "" && !seen[clientID] {
+				seen[clientID] = true
+				targets = append(targets, clientID)
+			}
+		}
+		if len(targets) == 0 {
+			connected, err := SSEConnectedClientGetAll(ctx, gateway.SSEConnectedClientGetAllReq{Group: req.Group})
+			if err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+			targets = connected.ClientIDs
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("no client to roll out to")
+		}
+
+		rollout := model.Rollout{
+			RolloutID:         "rollout-" + IDGenerator.NewID(),
+			EventType:         req.EventType,
+			Payload:           req.Payload,
+			Group:             req.Group,
+			CanaryPercent:     req.CanaryPercent,
+			MinSuccessPercent: req.MinSuccessPercent,
+			AckWindowMs:       req.AckWindowMs,
+			CanaryAcked:       []string{},
+			RemainderAcked:    []string{},
+			Status:            model.RolloutCanary,
+			RequestedBy:       req.Operator,
+		}
+		rollout.Canary, rollout.Remainder = splitCanary(rollout.RolloutID, targets, req.CanaryPercent)
+
+		if _, err := RolloutSave(ctx, gateway.RolloutSaveReq{Rollout: &rollout}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		// the runner owns its copy, the response reports the rollout as it was accepted
+		running := rollout
+		go runRollout(context.WithoutCancel(ctx), SendSSEMessage, WaitSSEAck, RolloutSave, &running)
+
+		return &RolloutCreateRes{Rollout: rollout}, nil
+	}
+}
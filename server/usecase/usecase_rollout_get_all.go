@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type RolloutGetAllReq struct {
+	Status string         `json:"status" http:"query"`
+	List   core.ListQuery `json:"list" http:"list"`
+}
+
+type RolloutGetAll = core.ActionHandler[RolloutGetAllReq, core.ListPage[model.Rollout]]
+
+// RolloutListFields are the fields the rollout list is sorted and filtered by
+var RolloutListFields = slices.Sorted(maps.Keys(gateway.RolloutListColumns))
+
+func ImplRolloutGetAll(
+	RolloutGetAll gateway.RolloutGetAll,
+) RolloutGetAll {
+	return func(ctx context.Context, req RolloutGetAllReq) (*core.ListPage[model.Rollout], error) {
+
+		list := req.List.Normalize(100, 1000, "-id")
+		if err := list.Validate(RolloutListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := RolloutGetAll(ctx, gateway.RolloutGetAllReq{Status: req.Status, List: list})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.Rollout]{Items: res.Rollouts, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
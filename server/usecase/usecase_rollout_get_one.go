@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type RolloutGetOneReq struct {
+	RolloutID string `json:"id" http:"path"`
+}
+
+type RolloutGetOneRes struct {
+	Rollout model.Rollout `json:"rollout"`
+}
+
+type RolloutGetOne = core.ActionHandler[RolloutGetOneReq, RolloutGetOneRes]
+
+func ImplRolloutGetOne(
+	RolloutGetOne gateway.RolloutGetOne,
+) RolloutGetOne {
+	return func(ctx context.Context, req RolloutGetOneReq) (*RolloutGetOneRes, error) {
+
+		res, err := RolloutGetOne(ctx, gateway.RolloutGetOneReq{RolloutID: req.RolloutID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if res.Rollout == nil {
+			return nil, fmt.Errorf("rollout %s not found", req.RolloutID)
+		}
+
+		return &RolloutGetOneRes{Rollout: *res.Rollout}, nil
+	}
+}
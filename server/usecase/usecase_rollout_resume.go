@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"sync"
+)
+
+type RolloutResumeReq struct{}
+
+type RolloutResumeRes struct {
+	Resumed []string // the rollouts continued in the background
+	// Done is closed once every resumed rollout ended or stopped with ctx
+	Done <-chan struct{}
+}
+
+type RolloutResume = core.ActionHandler[RolloutResumeReq, RolloutResumeRes]
+
+// ImplRolloutResume continues the rollouts a restart interrupted, a rollout stopped in its canary wave
+// sends that wave again since its acknowledgements are only saved once the wave ends
+func ImplRolloutResume(
+	RolloutGetAll gateway.RolloutGetAll,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	RolloutSave gateway.RolloutSave,
+) RolloutResume {
+	return func(ctx context.Context, req RolloutResumeReq) (*RolloutResumeRes, error) {
+
+		var rollouts []model.Rollout
+		for _, status := range []string{model.RolloutCanary, model.RolloutProceeding} {
+			res, err := RolloutGetAll(ctx, gateway.RolloutGetAllReq{Status: status})
+			if err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+			rollouts = append(rollouts, res.Rollouts...)
+		}
+
+		var wg sync.WaitGroup
+		resumed := make([]string, 0, len(rollouts))
+		for _, rollout := range rollouts {
+			resumed = append(resumed, rollout.RolloutID)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runRollout(ctx, SendSSEMessage, WaitSSEAck, RolloutSave, &rollout)
+			}()
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		return &RolloutResumeRes{Resumed: resumed, Done: done}, nil
+	}
+}
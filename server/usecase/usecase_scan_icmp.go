@@ -2,34 +2,482 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"server/gateway"
+	"server/model"
+	"shared/command"
 	"shared/core"
+	"shared/utility"
+	"sort"
+	"strings"
+	"time"
 )
 
-type ScanICMPTriggerReq struct {
+type ScanICMPTriggerBody struct {
 	ClientIDs []string `json:"client_ids"`
+	// IPRange may list several ranges separated by commas, empty with a site scans every subnet of the site
+	IPRange string `json:"ip_range" example:"192.168.1.0/24"`
+	// SiteID targets the agents assigned to a site, without it agents are picked by the site subnets covering the range
+	SiteID uint `json:"site_id"`
+	// Group targets the members of an SSE group, operators assign agents to groups through /api/clients/{id}/groups
+	Group string `json:"group"`
+	// Method is how the agents discover hosts: icmp (default), arp, which also finds hosts filtering
+	// ICMP but only on the agent's own L2 segment, udp, which probes DNS, NTP and SNMP, or nmap, a heavier
+	// scan run by nmap on the agents that identifies the services of the ports and sends scan_nmap instead
+	Method string `json:"method" enum:"icmp,arp,udp,nmap"`
+	// SNMPCredential is the id of an snmp credential pushed to the agents with PUT /api/clients/{id}/secrets/{secret_id}
+	SNMPCredential string `json:"snmp_credential"`
+	// Ports are TCP ports (at most 100) the agents check on every host they find online
+	Ports []int `json:"ports" example:"[22,80,443]"`
+	// Banners reads the first bytes each open port sends, or its answer to an HTTP HEAD, to identify the service
+	Banners bool `json:"banners"`
+	// MaxPacketsPerSecond caps the probe packets per second of each agent across its workers, ProbeDelayMs
+	// is the least time between two probes of an agent; 0 leaves the scan unpaced
+	MaxPacketsPerSecond int `json:"max_packets_per_second"`
+	ProbeDelayMs        int `json:"probe_delay_ms"`
+	// Workers are the probe workers of each agent, 0 leaves the agent its default. An agent announcing a
+	// lower max_concurrency is refused when named and skipped when picked from the sites.
+	Workers int `json:"workers"`
+	// DryRun asks the agents to validate and expand the range and report what they would scan
+	DryRun bool `json:"dry_run"`
+	// AckTimeoutMs is how long to wait for the agents to confirm they received the command, default 5000
+	AckTimeoutMs int `json:"ack_timeout_ms"`
+	// OnConflict overrides the configured policy for a range overlapping a running job: reject, queue or merge
+	OnConflict string `json:"on_conflict" enum:"reject,queue,merge"`
+}
+
+type ScanICMPTriggerReq struct {
+	ScanICMPTriggerBody `http:"body"`
+	Operator            string    `json:"-" http:"header(X-Operator)"`
+	Now                 time.Time `json:"-" http:"now"`
+}
+
+type ScanICMPTriggerRes struct {
+	JobID string `json:"job_id"`
+	// Status is pending_approval when the command needs a second operator before it is sent,
+	// queued while an overlapping job runs, or merged when JobID is the running job already covering the request
+	Status         string   `json:"status"`
+	MessageID      string   `json:"message_id"`
+	AcknowledgedBy []string `json:"acknowledged_by"`
+	// Acknowledged is true when every targeted client (or at least one on broadcast) confirmed in time
+	Acknowledged bool `json:"acknowledged"`
+	// Duplicate is true when an identical trigger created JobID moments ago, Status is then the status of that job
+	Duplicate bool `json:"duplicate,omitempty"`
+	// ConsistencyToken passed to the scan job endpoints makes them see this call's writes
+	ConsistencyToken string `json:"consistency_token,omitempty"`
+}
+
+func (r *ScanICMPTriggerRes) SetConsistencyToken(token string) { r.ConsistencyToken = token }
+
+// ScanApprovalPolicy decides which commands are sensitive and must be approved before dispatch
+type ScanApprovalPolicy struct {
+	MaxHostsWithoutApproval int // ranges with more hosts than this need approval, 0 disables the check
 }
 
-type ScanICMPTriggerRes struct{}
+func (p ScanApprovalPolicy) requiresApproval(job model.ScanJob) bool {
+	if job.DryRun {
+		return false // a dry run never sends probes
+	}
+	return p.MaxHostsWithoutApproval > 0 && job.TotalHosts > p.MaxHostsWithoutApproval
+}
 
 // Send to All Client
 type ScanICMPTrigger = core.ActionHandler[ScanICMPTriggerReq, ScanICMPTriggerRes]
 
 func ImplScanICMPTrigger(
 	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	ScanJobAdmit ScanJobAdmit,
+	ScanJobTransition gateway.ScanJobTransition,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	ScanJobGetActive gateway.ScanJobGetActive,
+	ScanJobSetRecipients gateway.ScanJobSetRecipients,
+	SiteGetOne gateway.SiteGetOne,
+	SiteGetAll gateway.SiteGetAll,
+	ClientGetAll gateway.ClientGetAll,
+	IDGenerator core.IDGenerator,
+	ConflictPolicy ScanConflictPolicy,
 ) ScanICMPTrigger {
 	return func(ctx context.Context, req ScanICMPTriggerReq) (*ScanICMPTriggerRes, error) {
 
-		// send and forget
-		_, err := SendSSEMessage(ctx, gateway.SendSSEMessageReq{
-			EventType: "scan_icmp",
-			// Data:      req.IPRange,
+		// the requester is kept so another operator approves the job, an anonymous one would approve itself
+		if req.Operator == "" {
+			return nil, fmt.Errorf("operator is required to trigger a scan job")
+		}
+
+		method, err := scanMethod(req.Method)
+		if err != nil {
+			return nil, err
+		}
+
+		ports, err := scanPorts(req.Ports, req.Banners)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := scanPacing(req.MaxPacketsPerSecond, req.ProbeDelayMs); err != nil {
+			return nil, err
+		}
+		if req.Workers < 0 || req.Workers > 1000 {
+			return nil, fmt.Errorf("workers must be between 0 and 1000")
+		}
+
+		conflictMode, err := ConflictPolicy.mode(req.OnConflict)
+		if err != nil {
+			return nil, err
+		}
+
+		ipRange, clientIDs, siteID, err := resolveScanTargets(ctx, SiteGetOne, SiteGetAll, req)
+		if err != nil {
+			return nil, err
+		}
+
+		clientIDs, err = capableAgents(ctx, ClientGetAll, clientIDs, len(req.ClientIDs) > 0, scanCommands(method, ports, req.SNMPCredential), req.Workers)
+		if err != nil {
+			return nil, err
+		}
+
+		totalHosts, err := countRangeHosts(ipRange)
+		if err != nil {
+			return nil, err
+		}
+
+		// nmap has its own event so agents only run it when asked for it by name
+		event := command.ScanICMP.Event
+		if method == model.ScanMethodNmap {
+			event = command.ScanNmap.Event
+		}
+
+		// the job id lets agents report their timing breakdown back to this trigger
+		scanJob := model.ScanJob{
+			JobID:       "job-" + IDGenerator.NewID(),
+			Command:     event,
+			Method:      method,
+			IPRange:     ipRange,
+			SiteID:      siteID,
+			ClientIDs:   clientIDs,
+			Group:       req.Group,
+			DryRun:      req.DryRun,
+			TotalHosts:  totalHosts,
+			Status:      model.ScanJobDispatched,
+			RequestedBy: req.Operator,
+
+			SNMPCredential: req.SNMPCredential,
+			Ports:          ports,
+			Banners:        req.Banners,
+
+			MaxPacketsPerSecond: req.MaxPacketsPerSecond,
+			ProbeDelayMs:        req.ProbeDelayMs,
+			Workers:             req.Workers,
+		}
+		scanJob.RequestKey = scanJobRequestKey(scanJob)
+
+		// two triggers racing each other must not both miss the other's job, admit runs in one transaction
+		admitted, err := ScanJobAdmit(ctx, ScanJobAdmitReq{
+			ScanJob:      scanJob,
+			ConflictMode: conflictMode,
+			Operator:     req.Operator,
+			Now:          req.Now,
 		})
+		if err != nil {
+			return nil, err
+		}
+		if admitted.Answer != nil {
+			return admitted.Answer, nil
+		}
+		scanJob = admitted.ScanJob
+
+		if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+			JobID:    scanJob.JobID,
+			Action:   "requested",
+			Operator: req.Operator,
+			Detail:   fmt.Sprintf("%s %s (%d hosts)", scanJob.Command, scanJob.IPRange, totalHosts),
+		}}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if scanJob.Status == model.ScanJobQueued {
+			if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+				JobID:    scanJob.JobID,
+				Action:   "queued",
+				Operator: req.Operator,
+				Detail:   "waiting for overlapping scan jobs to finish",
+			}}); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+		}
+
+		if scanJob.Status != model.ScanJobDispatched {
+			return &ScanICMPTriggerRes{
+				JobID:          scanJob.JobID,
+				Status:         scanJob.Status,
+				AcknowledgedBy: []string{},
+			}, nil
+		}
 
+		res, err := dispatchScanJob(ctx, SendSSEMessage, WaitSSEAck, ScanJobAuditSave, ScanJobSetRecipients, scanJob, req.Operator, req.AckTimeoutMs)
 		if err != nil {
+			// the job never reached its agents, it must not hold the range until it turns stale
+			if _, failErr := failScanJob(ctx, ScanJobTransition, ScanJobAuditSave, scanJob, "dispatch_failed", err.Error(), req.Now); failErr != nil {
+				return nil, core.NewInternalServerError(failErr)
+			}
+			if releaseErr := releaseQueuedScanJobs(ctx, ScanJobGetActive, ScanJobTransition, ScanJobAuditSave, ScanJobSetRecipients, SendSSEMessage, WaitSSEAck, ConflictPolicy, req.Now); releaseErr != nil {
+				return nil, core.NewInternalServerError(releaseErr)
+			}
 			return nil, err
 		}
+		return res, nil
+	}
+}
+
+// scanMethod validates the discovery method of a trigger, empty means icmp
+func scanMethod(method string) (string, error) {
+	switch method {
+	case "":
+		return model.ScanMethodICMP, nil
+	case model.ScanMethodICMP, model.ScanMethodARP, model.ScanMethodUDP, model.ScanMethodNmap:
+		return method, nil
+	}
+	return "", fmt.Errorf("method must be %s, %s, %s or %s", model.ScanMethodICMP, model.ScanMethodARP, model.ScanMethodUDP, model.ScanMethodNmap)
+}
+
+// scanPorts validates the TCP ports of a trigger, sorted without duplicates so identical triggers share a request key
+func scanPorts(ports []int, banners bool) ([]int, error) {
+	if banners && len(ports) == 0 {
+		return nil, fmt.Errorf("banners needs the ports to read them from")
+	}
+
+	seen := make(map[int]bool, len(ports))
+	unique := make([]int, 0, len(ports))
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("port %d is out of range", port)
+		}
+		if !seen[port] {
+			seen[port] = true
+			unique = append(unique, port)
+		}
+	}
+	if len(unique) > 100 {
+		return nil, fmt.Errorf("at most 100 ports can be checked per job")
+	}
+	sort.Ints(unique)
+	return unique, nil
+}
+
+// scanPacing validates the pacing of a trigger, the limits only keep a typo from stalling a job for days
+func scanPacing(maxPacketsPerSecond, probeDelayMs int) error {
+	if maxPacketsPerSecond < 0 || maxPacketsPerSecond > 1000000 {
+		return fmt.Errorf("max_packets_per_second must be between 0 and 1000000")
+	}
+	if probeDelayMs < 0 || probeDelayMs > 60000 {
+		return fmt.Errorf("probe_delay_ms must be between 0 and 60000")
+	}
+	return nil
+}
+
+// mergeScanJob answers a trigger with the running job that already probes the whole request, or rejects it
+func mergeScanJob(
+	ctx context.Context,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	scanJob model.ScanJob,
+	conflicts []model.ScanJob,
+	operator string,
+) (*ScanICMPTriggerRes, error) {
+
+	for _, running := range conflicts {
+		if !scanJobCovers(running, scanJob) {
+			continue
+		}
+
+		if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+			JobID:    running.JobID,
+			Action:   "merged",
+			Operator: operator,
+			Detail:   fmt.Sprintf("%s %s already covered", scanJob.Command, scanJob.IPRange),
+		}}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ScanICMPTriggerRes{
+			JobID:          running.JobID,
+			Status:         "merged",
+			AcknowledgedBy: []string{},
+		}, nil
+	}
+
+	// a partial overlap cannot be merged without probing the overlapping part twice
+	return nil, newScanConflictError(scanJob, conflicts)
+}
+
+// dispatchScanJob sends the job to the agents and waits until they confirm the command reached their handlers
+func dispatchScanJob(
+	ctx context.Context,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	ScanJobSetRecipients gateway.ScanJobSetRecipients,
+	scanJob model.ScanJob,
+	operator string,
+	ackTimeoutMs int,
+) (*ScanICMPTriggerRes, error) {
+
+	if ackTimeoutMs <= 0 {
+		ackTimeoutMs = 5000
+	}
+
+	msg := scanCommand(scanJob.Command).Message(command.ScanRequest{
+		JobID:   scanJob.JobID,
+		IPRange: scanJob.IPRange,
+		Method:  scanJob.Method,
+		DryRun:  scanJob.DryRun,
+
+		SNMPCredential: scanJob.SNMPCredential,
+		Ports:          scanJob.Ports,
+		Banners:        scanJob.Banners,
+
+		MaxPacketsPerSecond: scanJob.MaxPacketsPerSecond,
+		ProbeDelayMs:        scanJob.ProbeDelayMs,
+		Workers:             scanJob.Workers,
+	})
+
+	sent, err := SendSSEMessage(ctx, gateway.SendSSEMessageReq{
+		EventType:  msg.EventType,
+		Data:       msg.Data,
+		ClientIDs:  scanJob.ClientIDs,
+		Group:      scanJob.Group,
+		RequireAck: true,
+		// the named agents were checked on trigger, the members of a broadcast or a group are checked here
+		Requires: scanCommands(scanJob.Method, scanJob.Ports, scanJob.SNMPCredential),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+		JobID:    scanJob.JobID,
+		Action:   "dispatched",
+		Operator: operator,
+		Detail:   "message " + sent.MessageID,
+	}}); err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+
+	acked, err := WaitSSEAck(ctx, gateway.WaitSSEAckReq{
+		MessageID: sent.MessageID,
+		ClientIDs: scanJob.ClientIDs,
+		Timeout:   time.Duration(ackTimeoutMs) * time.Millisecond,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the agents that confirmed are the ones the job waits for before it counts as completed
+	if _, err := ScanJobSetRecipients(ctx, gateway.ScanJobSetRecipientsReq{
+		JobID:      scanJob.JobID,
+		Recipients: acked.AcknowledgedBy,
+	}); err != nil {
+		return nil, core.NewInternalServerError(err)
+	}
+
+	return &ScanICMPTriggerRes{
+		JobID:          scanJob.JobID,
+		Status:         model.ScanJobDispatched,
+		MessageID:      sent.MessageID,
+		AcknowledgedBy: acked.AcknowledgedBy,
+		Acknowledged:   acked.Complete,
+	}, nil
+}
+
+// scanCommand is the declaration of the event a job was saved with
+func scanCommand(event string) utility.Command[command.ScanRequest, struct{}] {
+	if event == command.ScanNmap.Event {
+		return command.ScanNmap
+	}
+	return command.ScanICMP
+}
+
+// resolveScanTargets works out the range and the agents of a trigger from its site or from the sites covering the range
+func resolveScanTargets(
+	ctx context.Context,
+	SiteGetOne gateway.SiteGetOne,
+	SiteGetAll gateway.SiteGetAll,
+	req ScanICMPTriggerReq,
+) (string, []string, *uint, error) {
+
+	// the group resolves its members when the message is sent, the usecase never sees them
+	if req.Group != "" {
+		if req.SiteID != 0 || len(req.ClientIDs) > 0 {
+			return "", nil, nil, fmt.Errorf("group cannot be combined with site_id or client_ids")
+		}
+		return req.IPRange, nil, nil, nil
+	}
+
+	if req.SiteID != 0 {
+		res, err := SiteGetOne(ctx, gateway.SiteGetOneReq{ID: req.SiteID})
+		if err != nil {
+			return "", nil, nil, core.NewInternalServerError(err)
+		}
+		if res.Site == nil {
+			return "", nil, nil, fmt.Errorf("site %d not found", req.SiteID)
+		}
+		site := res.Site
+
+		ipRange := req.IPRange
+		if ipRange == "" {
+			ipRange = strings.Join(site.Subnets, ",")
+		} else if outside := rangeOutside(splitRanges(ipRange), site.Subnets); outside != "" {
+			// the agents of the site are not meant to reach beyond its subnets
+			return "", nil, nil, fmt.Errorf("range %s is not within the subnets of site %s", outside, site.Name)
+		}
+
+		agents := make(map[string]bool, len(site.Agents))
+		for _, agent := range site.Agents {
+			agents[agent.ClientID] = true
+		}
+		if len(agents) == 0 {
+			return "", nil, nil, fmt.Errorf("site %s has no agents assigned", site.Name)
+		}
+
+		clientIDs := req.ClientIDs
+		if len(clientIDs) == 0 {
+			for _, agent := range site.Agents {
+				clientIDs = append(clientIDs, agent.ClientID)
+			}
+		}
+		for _, clientID := range clientIDs {
+			if !agents[clientID] {
+				return "", nil, nil, fmt.Errorf("client %s is not assigned to site %s", clientID, site.Name)
+			}
+		}
+
+		return ipRange, clientIDs, &site.ID, nil
+	}
+
+	if len(req.ClientIDs) > 0 {
+		return req.IPRange, req.ClientIDs, nil, nil
+	}
+
+	// no explicit target: the agents of every site owning part of the range, or everyone when no site matches
+	res, err := SiteGetAll(ctx, gateway.SiteGetAllReq{})
+	if err != nil {
+		return "", nil, nil, core.NewInternalServerError(err)
+	}
 
-		return &ScanICMPTriggerRes{}, nil
+	requested := splitRanges(req.IPRange)
+	seen := map[string]bool{}
+	var clientIDs []string
+	for _, site := range res.Sites {
+		if !rangesOverlap(requested, site.Subnets) {
+			continue
+		}
+		for _, agent := range site.Agents {
+			if !seen[agent.ClientID] {
+				seen[agent.ClientID] = true
+				clientIDs = append(clientIDs, agent.ClientID)
+			}
+		}
 	}
+
+	return req.IPRange, clientIDs, nil, nil
 }
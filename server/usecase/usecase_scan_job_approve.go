@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"time"
+)
+
+type ScanJobApproveReq struct {
+	JobID        string    `json:"id" http:"path"`
+	AckTimeoutMs int       `json:"ack_timeout_ms" http:"query"`
+	Operator     string    `json:"-" http:"header(X-Operator)"`
+	Now          time.Time `json:"-" http:"now"`
+}
+
+type ScanJobApprove = core.ActionHandler[ScanJobApproveReq, ScanICMPTriggerRes]
+
+// ImplScanJobApprove lets a second operator release a job waiting in pending_approval
+func ImplScanJobApprove(
+	ScanJobGetOne gateway.ScanJobGetOne,
+	ScanJobTransition gateway.ScanJobTransition,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	ScanJobGetActive gateway.ScanJobGetActive,
+	ScanJobSetRecipients gateway.ScanJobSetRecipients,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	ConflictPolicy ScanConflictPolicy,
+) ScanJobApprove {
+	return func(ctx context.Context, req ScanJobApproveReq) (*ScanICMPTriggerRes, error) {
+
+		if req.Operator == "" {
+			return nil, fmt.Errorf("operator is required to approve a scan job")
+		}
+
+		existing, err := ScanJobGetOne(ctx, gateway.ScanJobGetOneReq{JobID: req.JobID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.ScanJob == nil {
+			return nil, fmt.Errorf("scan job %s not found", req.JobID)
+		}
+
+		scanJob := *existing.ScanJob
+
+		if scanJob.Status != model.ScanJobPendingApproval {
+			return nil, fmt.Errorf("scan job %s is %s, not waiting for approval", req.JobID, scanJob.Status)
+		}
+
+		// without a known requester the two operator rule cannot be checked, the job can only be cancelled
+		if scanJob.RequestedBy == "" {
+			return nil, fmt.Errorf("scan job %s has no recorded requester and cannot be approved", req.JobID)
+		}
+
+		if scanJob.RequestedBy == req.Operator {
+			return nil, fmt.Errorf("scan job %s must be approved by a different operator than %s", req.JobID, req.Operator)
+		}
+
+		// an overlapping job started while this one waited, queue it or keep it pending so it can be approved later
+		conflicts, err := findScanConflicts(ctx, ScanJobGetActive, ConflictPolicy, scanJob, req.Now)
+		if err != nil {
+			return nil, err
+		}
+
+		toStatus := model.ScanJobDispatched
+		if len(conflicts) > 0 {
+			if conflictMode, _ := ConflictPolicy.mode(""); conflictMode != ScanConflictQueue {
+				return nil, newScanConflictError(scanJob, conflicts)
+			}
+			toStatus = model.ScanJobQueued
+		}
+
+		transitioned, err := ScanJobTransition(ctx, gateway.ScanJobTransitionReq{
+			JobID:      scanJob.JobID,
+			FromStatus: model.ScanJobPendingApproval,
+			ToStatus:   toStatus,
+			ApprovedBy: req.Operator,
+			Now:        req.Now,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if !transitioned.Updated {
+			return nil, fmt.Errorf("scan job %s was already approved", req.JobID)
+		}
+
+		if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+			JobID:    scanJob.JobID,
+			Action:   "approved",
+			Operator: req.Operator,
+		}}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if toStatus == model.ScanJobQueued {
+			if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+				JobID:    scanJob.JobID,
+				Action:   "queued",
+				Operator: req.Operator,
+				Detail:   "waiting for overlapping scan jobs to finish",
+			}}); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+
+			return &ScanICMPTriggerRes{
+				JobID:          scanJob.JobID,
+				Status:         toStatus,
+				AcknowledgedBy: []string{},
+			}, nil
+		}
+
+		return dispatchScanJob(ctx, SendSSEMessage, WaitSSEAck, ScanJobAuditSave, ScanJobSetRecipients, scanJob, req.Operator, req.AckTimeoutMs)
+	}
+}
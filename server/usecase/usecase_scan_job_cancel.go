@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/command"
+	"shared/core"
+	"strings"
+	"time"
+)
+
+type ScanJobCancelReq struct {
+	JobID        string    `json:"id" http:"path"`
+	Reason       string    `json:"reason" http:"query"`
+	AckTimeoutMs int       `json:"ack_timeout_ms" http:"query"`
+	Operator     string    `json:"-" http:"header(X-Operator)"`
+	Now          time.Time `json:"-" http:"now"`
+}
+
+type ScanJobCancel = core.ActionHandler[ScanJobCancelReq, ScanICMPTriggerRes]
+
+// ImplScanJobCancel stops a scan job. A job that never reached the agents, pending approval or queued, is
+// cancelled right away and the queued jobs are released; a dispatched one gets scan_cancel and turns cancelled once the agents report back,
+// agents that finished before the event keep their report
+func ImplScanJobCancel(
+	ScanJobGetOne gateway.ScanJobGetOne,
+	ScanJobTransition gateway.ScanJobTransition,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	ScanJobGetActive gateway.ScanJobGetActive,
+	ScanJobSetRecipients gateway.ScanJobSetRecipients,
+	SendSSEMessage gateway.SendSSEMessage,
+	SendScanCancel gateway.SendCommand[command.ScanCancelRequest],
+	WaitSSEAck gateway.WaitSSEAck,
+	ConflictPolicy ScanConflictPolicy,
+) ScanJobCancel {
+	return func(ctx context.Context, req ScanJobCancelReq) (*ScanICMPTriggerRes, error) {
+
+		if req.Operator == "" {
+			return nil, fmt.Errorf("operator is required to cancel a scan job")
+		}
+
+		existing, err := ScanJobGetOne(ctx, gateway.ScanJobGetOneReq{JobID: req.JobID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.ScanJob == nil {
+			return nil, fmt.Errorf("scan job %s not found", req.JobID)
+		}
+
+		scanJob := *existing.ScanJob
+
+		switch scanJob.Status {
+		case model.ScanJobPendingApproval, model.ScanJobQueued:
+
+			transitioned, err := ScanJobTransition(ctx, gateway.ScanJobTransitionReq{
+				JobID:      scanJob.JobID,
+				FromStatus: scanJob.Status,
+				ToStatus:   model.ScanJobCancelled,
+				Now:        req.Now,
+			})
+			if err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+
+			// approved or released by another request in between, the caller retries against the new status
+			if !transitioned.Updated {
+				return nil, fmt.Errorf("scan job %s is not %s anymore, try again", req.JobID, scanJob.Status)
+			}
+
+			if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+				JobID:    scanJob.JobID,
+				Action:   model.ScanJobCancelled,
+				Operator: req.Operator,
+				Detail:   req.Reason,
+			}}); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+
+			// every way a job ends releases the queue, like a report does
+			if err := releaseQueuedScanJobs(ctx, ScanJobGetActive, ScanJobTransition, ScanJobAuditSave, ScanJobSetRecipients, SendSSEMessage, WaitSSEAck, ConflictPolicy, req.Now); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+
+			return &ScanICMPTriggerRes{
+				JobID:          scanJob.JobID,
+				Status:         model.ScanJobCancelled,
+				AcknowledgedBy: []string{},
+			}, nil
+
+		case model.ScanJobDispatched:
+
+		default:
+			return nil, fmt.Errorf("scan job %s is already %s", req.JobID, scanJob.Status)
+		}
+
+		// only the agents still scanning are asked, the others already reported
+		reported := make(map[string]bool, len(scanJob.Reports))
+		for _, report := range scanJob.Reports {
+			reported[report.ClientID] = true
+		}
+		var running []string
+		for _, clientID := range expectedAgents(scanJob) {
+			if !reported[clientID] {
+				running = append(running, clientID)
+			}
+		}
+
+		// a broadcast nobody acknowledged has no known agent, the event goes to the same audience as the job
+		sendTo := gateway.SendCommandReq[command.ScanCancelRequest]{
+			Payload: command.ScanCancelRequest{
+				JobID:       scanJob.JobID,
+				Reason:      req.Reason,
+				RequestedBy: req.Operator,
+			},
+			ClientIDs: running,
+		}
+		if len(running) == 0 {
+			if len(expectedAgents(scanJob)) > 0 {
+				return nil, fmt.Errorf("every agent of scan job %s already reported", req.JobID)
+			}
+			sendTo.Group = scanJob.Group
+		}
+
+		sent, err := SendScanCancel(ctx, sendTo)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.AckTimeoutMs <= 0 {
+			req.AckTimeoutMs = 5000
+		}
+		acked, err := WaitSSEAck(ctx, gateway.WaitSSEAckReq{
+			MessageID: sent.MessageID,
+			ClientIDs: running,
+			Timeout:   time.Duration(req.AckTimeoutMs) * time.Millisecond,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		detail := "acknowledged by " + strings.Join(acked.AcknowledgedBy, ", ")
+		if len(acked.AcknowledgedBy) == 0 {
+			detail = "no agent acknowledged"
+		}
+		if req.Reason != "" {
+			detail = req.Reason + ", " + detail
+		}
+		if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+			JobID:    scanJob.JobID,
+			Action:   "cancel_requested",
+			Operator: req.Operator,
+			Detail:   detail,
+		}}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ScanICMPTriggerRes{
+			JobID:          scanJob.JobID,
+			Status:         model.ScanJobDispatched,
+			MessageID:      sent.MessageID,
+			AcknowledgedBy: acked.AcknowledgedBy,
+			Acknowledged:   acked.Complete,
+		}, nil
+	}
+}
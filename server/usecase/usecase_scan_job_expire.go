@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"time"
+)
+
+type ScanJobExpireReq struct {
+	Now time.Time
+}
+
+type ScanJobExpireRes struct {
+	Expired []string // the jobs turned failed
+}
+
+type ScanJobExpire = core.ActionHandler[ScanJobExpireReq, ScanJobExpireRes]
+
+// ImplScanJobExpire fails the dispatched jobs whose agents did not report within the stale window of the
+// conflict policy, broadcasts scan_completed for them like a report does and releases the queued jobs
+func ImplScanJobExpire(
+	ScanJobGetActive gateway.ScanJobGetActive,
+	ScanJobTransition gateway.ScanJobTransition,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	ScanJobSetRecipients gateway.ScanJobSetRecipients,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	WebhookEventEnqueue gateway.WebhookEventEnqueue,
+	IDGenerator core.IDGenerator,
+	ConflictPolicy ScanConflictPolicy,
+) ScanJobExpire {
+	return func(ctx context.Context, req ScanJobExpireReq) (*ScanJobExpireRes, error) {
+
+		// without a stale window a dispatched job runs until its agents report
+		if ConflictPolicy.StaleAfter <= 0 {
+			return &ScanJobExpireRes{}, nil
+		}
+
+		stale, err := ScanJobGetActive(ctx, gateway.ScanJobGetActiveReq{
+			Statuses: []string{model.ScanJobDispatched},
+			Before:   ConflictPolicy.since(req.Now),
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		res := &ScanJobExpireRes{Expired: []string{}}
+		var sendErr error
+		for _, job := range stale.ScanJobs {
+
+			detail := fmt.Sprintf("%d of %d agent report(s) after %s", len(job.Reports), len(expectedAgents(job)), ConflictPolicy.StaleAfter)
+			expired, err := failScanJob(ctx, ScanJobTransition, ScanJobAuditSave, job, "timed_out", detail, req.Now)
+			if err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+			if !expired {
+				continue // the last report arrived in between
+			}
+			res.Expired = append(res.Expired, job.JobID)
+
+			completed := scanCompletedEvent(job, model.ScanJobFailed)
+			notifyWebhooks(ctx, WebhookEventEnqueue, IDGenerator, model.WebhookEventScanCompleted, completed, req.Now)
+			if _, err := SendSSEMessage(ctx, gateway.SendSSEMessageReq{
+				EventType: "scan_completed",
+				Data:      completed,
+			}); err != nil {
+				sendErr = err
+			}
+		}
+
+		// the jobs are failed whether the broadcast went out or not, the queue must not wait for them
+		if len(res.Expired) > 0 {
+			if err := releaseQueuedScanJobs(ctx, ScanJobGetActive, ScanJobTransition, ScanJobAuditSave, ScanJobSetRecipients, SendSSEMessage, WaitSSEAck, ConflictPolicy, req.Now); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+		}
+
+		if sendErr != nil {
+			return nil, sendErr
+		}
+		return res, nil
+	}
+}
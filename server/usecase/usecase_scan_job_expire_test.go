@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestScanJobExpireFailsStaleJobsAndReleasesTheQueue(t *testing.T) {
+	now := time.Now()
+	policy := ScanConflictPolicy{Mode: ScanConflictQueue, StaleAfter: time.Hour}
+
+	store := newScanJobStore(
+		model.ScanJob{Model: gorm.Model{CreatedAt: now.Add(-2 * time.Hour)}, JobID: "job-stale", Command: "scan_icmp", IPRange: "10.0.0.0/30", ClientIDs: []string{"agent-1"}, Recipients: []string{"agent-1"}, Status: model.ScanJobDispatched},
+		model.ScanJob{Model: gorm.Model{CreatedAt: now.Add(-time.Minute)}, JobID: "job-running", Command: "scan_icmp", IPRange: "10.1.0.0/30", ClientIDs: []string{"agent-1"}, Recipients: []string{"agent-1"}, Status: model.ScanJobDispatched},
+		model.ScanJob{Model: gorm.Model{CreatedAt: now.Add(-90 * time.Minute)}, JobID: "job-queued", Command: "scan_icmp", IPRange: "10.0.0.0/29", ClientIDs: []string{"agent-1"}, Status: model.ScanJobQueued},
+	)
+
+	completed := make(chan model.ScanCompletedEvent, 4)
+	sendSSEMessage := func(ctx context.Context, req gateway.SendSSEMessageReq) (*gateway.SendSSEMessageRes, error) {
+		if event, ok := req.Data.(model.ScanCompletedEvent); ok {
+			completed <- event
+		}
+		return &gateway.SendSSEMessageRes{MessageID: "message-1"}, nil
+	}
+	waitSSEAck := func(ctx context.Context, req gateway.WaitSSEAckReq) (*gateway.WaitSSEAckRes, error) {
+		return &gateway.WaitSSEAckRes{AcknowledgedBy: req.ClientIDs, Complete: true}, nil
+	}
+	auditSave := func(ctx context.Context, req gateway.ScanJobAuditSaveReq) (*gateway.ScanJobAuditSaveRes, error) {
+		return &gateway.ScanJobAuditSaveRes{}, nil
+	}
+	webhookEnqueue := func(ctx context.Context, req gateway.WebhookEventEnqueueReq) (*gateway.WebhookEventEnqueueRes, error) {
+		return &gateway.WebhookEventEnqueueRes{}, nil
+	}
+
+	expire := ImplScanJobExpire(
+		store.getActive, store.transition, auditSave, store.setRecipients,
+		sendSSEMessage, waitSSEAck, webhookEnqueue, core.NewULIDGenerator(core.RealClock{}), policy,
+	)
+
+	res, err := expire(context.Background(), ScanJobExpireReq{Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(res.Expired, []string{"job-stale"}) {
+		t.Fatalf("expired: got %v, want only the stale job", res.Expired)
+	}
+
+	if status := store.status("job-stale"); status != model.ScanJobFailed {
+		t.Fatalf("stale job: got status %s, want %s", status, model.ScanJobFailed)
+	}
+	if status := store.status("job-running"); status != model.ScanJobDispatched {
+		t.Fatalf("running job: got status %s, want it left %s", status, model.ScanJobDispatched)
+	}
+	// queued before the stale window began, it still waits for its turn rather than being skipped
+	if status := store.status("job-queued"); status != model.ScanJobDispatched {
+		t.Fatalf("queued job: got status %s, want it released to %s", status, model.ScanJobDispatched)
+	}
+
+	if event := <-completed; event.JobID != "job-stale" || event.Status != model.ScanJobFailed {
+		t.Fatalf("scan_completed: got %+v, want the stale job failed", event)
+	}
+}
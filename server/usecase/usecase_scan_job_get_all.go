@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type ScanJobGetAllReq struct {
+	// ConsistencyToken from a trigger or approval makes the list include that job
+	ConsistencyToken string `json:"consistency_token" http:"query"`
+}
+
+func (r ScanJobGetAllReq) MinConsistencyToken() string { return r.ConsistencyToken }
+
+type ScanJobGetAllRes struct {
+	ScanJobs []model.ScanJob `json:"scan_jobs"`
+}
+
+type ScanJobGetAll = core.ActionHandler[ScanJobGetAllReq, ScanJobGetAllRes]
+
+func ImplScanJobGetAll(
+	ScanJobGetAll gateway.ScanJobGetAll,
+	ScanResultCountByJob gateway.ScanResultCountByJob,
+) ScanJobGetAll {
+	return func(ctx context.Context, req ScanJobGetAllReq) (*ScanJobGetAllRes, error) {
+
+		res, err := ScanJobGetAll(ctx, gateway.ScanJobGetAllReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if err := describeScanJobs(ctx, ScanResultCountByJob, res.ScanJobs); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ScanJobGetAllRes{ScanJobs: res.ScanJobs}, nil
+	}
+}
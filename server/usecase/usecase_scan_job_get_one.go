@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type ScanJobGetOneReq struct {
+	JobID string `json:"id" http:"path"`
+	// ConsistencyToken from a trigger or approval makes the job read at least as that call left it
+	ConsistencyToken string `json:"consistency_token" http:"query"`
+}
+
+func (r ScanJobGetOneReq) MinConsistencyToken() string { return r.ConsistencyToken }
+
+type ScanJobGetOneRes struct {
+	ScanJob model.ScanJob `json:"scan_job"`
+}
+
+type ScanJobGetOne = core.ActionHandler[ScanJobGetOneReq, ScanJobGetOneRes]
+
+func ImplScanJobGetOne(
+	ScanJobGetOne gateway.ScanJobGetOne,
+	ScanResultCountByJob gateway.ScanResultCountByJob,
+) ScanJobGetOne {
+	return func(ctx context.Context, req ScanJobGetOneReq) (*ScanJobGetOneRes, error) {
+
+		res, err := ScanJobGetOne(ctx, gateway.ScanJobGetOneReq{JobID: req.JobID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if res.ScanJob == nil {
+			return nil, fmt.Errorf("scan job %s not found", req.JobID)
+		}
+
+		jobs := []model.ScanJob{*res.ScanJob}
+		if err := describeScanJobs(ctx, ScanResultCountByJob, jobs); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ScanJobGetOneRes{ScanJob: jobs[0]}, nil
+	}
+}
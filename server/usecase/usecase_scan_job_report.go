@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"shared/utility"
+	"time"
+)
+
+type ScanJobReportBody struct {
+	ClientID    string               `json:"client_id"`
+	Status      string               `json:"status"`
+	Error       string               `json:"error"`
+	TotalHosts  int                  `json:"total_hosts"`
+	Workers     int                  `json:"workers"`
+	TimeoutMs   int64                `json:"timeout_ms"`
+	EstimatedMs int64                `json:"estimated_ms"`
+	StartedAt   time.Time            `json:"started_at"`
+	FinishedAt  time.Time            `json:"finished_at"`
+	Metrics     model.ScanJobMetrics `json:"metrics"`
+}
+
+// how long a report waits for the dispatch to record the acknowledging agents
+const (
+	scanJobRecipientPollInterval = 500 * time.Millisecond
+	scanJobRecipientPolls        = 120
+)
+
+type ScanJobReportReq struct {
+	JobID string            `json:"id" http:"path"`
+	Body  ScanJobReportBody `http:"body"`
+	Now   time.Time         `json:"-" http:"now"`
+}
+
+type ScanJobReportRes struct{}
+
+type ScanJobReport = core.ActionHandler[ScanJobReportReq, ScanJobReportRes]
+
+// ImplScanJobReport stores the completion report an agent sends when it finishes a scan job,
+// completes the job once every expected agent reported, failed when none of them succeeded, broadcasts scan_completed to the agents and the webhooks and releases the queued jobs it was blocking
+func ImplScanJobReport(
+	ScanJobGetOne gateway.ScanJobGetOne,
+	ScanJobReportSave gateway.ScanJobReportSave,
+	ScanJobTransition gateway.ScanJobTransition,
+	ScanJobAuditSave gateway.ScanJobAuditSave,
+	ScanJobGetActive gateway.ScanJobGetActive,
+	ScanJobSetRecipients gateway.ScanJobSetRecipients,
+	SendSSEMessage gateway.SendSSEMessage,
+	WaitSSEAck gateway.WaitSSEAck,
+	WebhookEventEnqueue gateway.WebhookEventEnqueue,
+	IDGenerator core.IDGenerator,
+	ConflictPolicy ScanConflictPolicy,
+) ScanJobReport {
+	return func(ctx context.Context, req ScanJobReportReq) (*ScanJobReportRes, error) {
+
+		if req.Body.ClientID == "" {
+			return nil, fmt.Errorf("client_id is required")
+		}
+
+		switch req.Body.Status {
+		case "completed", "failed", "planned", model.ScanJobCancelled:
+		default:
+			return nil, fmt.Errorf("status must be completed, failed, cancelled or planned")
+		}
+
+		existing, err := ScanJobGetOne(ctx, gateway.ScanJobGetOneReq{JobID: req.JobID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.ScanJob == nil {
+			return nil, fmt.Errorf("scan job %s not found", req.JobID)
+		}
+
+		if _, err := ScanJobReportSave(ctx, gateway.ScanJobReportSaveReq{
+			Report: &model.ScanJobReport{
+				JobID:       req.JobID,
+				ClientID:    req.Body.ClientID,
+				Status:      req.Body.Status,
+				Error:       req.Body.Error,
+				TotalHosts:  req.Body.TotalHosts,
+				Workers:     req.Body.Workers,
+				TimeoutMs:   req.Body.TimeoutMs,
+				EstimatedMs: req.Body.EstimatedMs,
+				StartedAt:   req.Body.StartedAt,
+				FinishedAt:  req.Body.FinishedAt,
+				Metrics:     req.Body.Metrics,
+			},
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.ScanJob.Status != model.ScanJobDispatched {
+			return &ScanJobReportRes{}, nil
+		}
+
+		// complete finishes the job once every expected agent reported, waiting reports that the
+		// expected agents are unknown because the dispatch still collects acknowledgements
+		complete := func(ctx context.Context, now time.Time) (waiting bool, err error) {
+
+			updated, err := ScanJobGetOne(ctx, gateway.ScanJobGetOneReq{JobID: req.JobID})
+			if err != nil {
+				return false, core.NewInternalServerError(err)
+			}
+
+			if updated.ScanJob == nil || updated.ScanJob.Status != model.ScanJobDispatched {
+				return false, nil
+			}
+			if !scanJobFinished(*updated.ScanJob) {
+				// the acknowledgements are still collected, the expected agents are not known yet
+				return len(updated.ScanJob.Recipients) == 0, nil
+			}
+
+			outcome := scanJobOutcome(*updated.ScanJob)
+			transitioned, err := ScanJobTransition(ctx, gateway.ScanJobTransitionReq{
+				JobID:      req.JobID,
+				FromStatus: model.ScanJobDispatched,
+				ToStatus:   outcome,
+				Now:        now,
+			})
+			if err != nil {
+				return false, core.NewInternalServerError(err)
+			}
+
+			if !transitioned.Updated {
+				return false, nil
+			}
+
+			if _, err := ScanJobAuditSave(ctx, gateway.ScanJobAuditSaveReq{Audit: &model.ScanJobAudit{
+				JobID:    req.JobID,
+				Action:   outcome,
+				Operator: req.Body.ClientID,
+				Detail:   fmt.Sprintf("%d agent report(s)", len(updated.ScanJob.Reports)),
+			}}); err != nil {
+				return false, core.NewInternalServerError(err)
+			}
+
+			// observers and webhooks learn the job finished without polling GET /api/scan-jobs/{id}
+			completed := scanCompletedEvent(*updated.ScanJob, outcome)
+			notifyWebhooks(ctx, WebhookEventEnqueue, IDGenerator, model.WebhookEventScanCompleted, completed, now)
+			_, sendErr := SendSSEMessage(ctx, gateway.SendSSEMessageReq{
+				EventType: "scan_completed",
+				Data:      completed,
+			})
+
+			// the job is finished whether the broadcast went out or not, the jobs it blocked must not wait for it
+			if err := releaseQueuedScanJobs(ctx, ScanJobGetActive, ScanJobTransition, ScanJobAuditSave, ScanJobSetRecipients, SendSSEMessage, WaitSSEAck, ConflictPolicy, now); err != nil {
+				return false, core.NewInternalServerError(err)
+			}
+
+			return false, sendErr
+		}
+
+		waiting, err := complete(ctx, req.Now)
+		if err != nil {
+			return nil, err
+		}
+
+		// a fast agent may report before the dispatch recorded who acknowledged, check again once it did
+		if waiting {
+			go func(ctx context.Context) {
+				clock := core.GetClockFromContext(ctx)
+				for range scanJobRecipientPolls {
+					<-clock.After(scanJobRecipientPollInterval)
+					waiting, err := complete(ctx, clock.Now())
+					if err != nil {
+						utility.Errorf("Failed to complete scan job %s: %v", req.JobID, err)
+						return
+					}
+					if !waiting {
+						return
+					}
+				}
+			}(context.WithoutCancel(ctx))
+		}
+
+		return &ScanJobReportRes{}, nil
+	}
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scanJobStore keeps the jobs of a test in memory behind the scan job gateways
+type scanJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*model.ScanJob
+}
+
+func newScanJobStore(jobs ...model.ScanJob) *scanJobStore {
+	store := &scanJobStore{jobs: map[string]*model.ScanJob{}}
+	for i := range jobs {
+		job := jobs[i]
+		store.jobs[job.JobID] = &job
+	}
+	return store
+}
+
+func (s *scanJobStore) status(jobID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[jobID].Status
+}
+
+func (s *scanJobStore) getOne(ctx context.Context, req gateway.ScanJobGetOneReq) (*gateway.ScanJobGetOneRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[req.JobID]
+	if !ok {
+		return &gateway.ScanJobGetOneRes{}, nil
+	}
+	copied := *job
+	return &gateway.ScanJobGetOneRes{ScanJob: &copied}, nil
+}
+
+func (s *scanJobStore) saveReport(ctx context.Context, req gateway.ScanJobReportSaveReq) (*gateway.ScanJobReportSaveRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[req.Report.JobID]
+	job.Reports = append(job.Reports, *req.Report)
+	return &gateway.ScanJobReportSaveRes{}, nil
+}
+
+func (s *scanJobStore) transition(ctx context.Context, req gateway.ScanJobTransitionReq) (*gateway.ScanJobTransitionRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[req.JobID]
+	if !ok || job.Status != req.FromStatus {
+		return &gateway.ScanJobTransitionRes{}, nil
+	}
+	job.Status = req.ToStatus
+	return &gateway.ScanJobTransitionRes{Updated: true}, nil
+}
+
+func (s *scanJobStore) getActive(ctx context.Context, req gateway.ScanJobGetActiveReq) (*gateway.ScanJobGetActiveRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := &gateway.ScanJobGetActiveRes{}
+	for _, job := range s.jobs {
+		if !slices.Contains(req.Statuses, job.Status) || job.CreatedAt.Before(req.Since) {
+			continue
+		}
+		if req.Before.IsZero() || job.CreatedAt.Before(req.Before) {
+			res.ScanJobs = append(res.ScanJobs, *job)
+		}
+	}
+	return res, nil
+}
+
+func (s *scanJobStore) setRecipients(ctx context.Context, req gateway.ScanJobSetRecipientsReq) (*gateway.ScanJobSetRecipientsRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[req.JobID].Recipients = req.Recipients
+	return &gateway.ScanJobSetRecipientsRes{}, nil
+}
+
+func TestScanJobReportReleasesQueuedJobsWhenBroadcastFails(t *testing.T) {
+	now := time.Now()
+	store := newScanJobStore(
+		model.ScanJob{JobID: "job-running", Command: "scan_icmp", IPRange: "10.0.0.0/30", ClientIDs: []string{"agent-1"}, Recipients: []string{"agent-1"}, Status: model.ScanJobDispatched},
+		model.ScanJob{JobID: "job-queued", Command: "scan_icmp", IPRange: "10.0.0.0/29", ClientIDs: []string{"agent-1"}, Status: model.ScanJobQueued},
+	)
+
+	broadcastErr := errors.New("broker unavailable")
+	sendSSEMessage := func(ctx context.Context, req gateway.SendSSEMessageReq) (*gateway.SendSSEMessageRes, error) {
+		if req.EventType == "scan_completed" {
+			return nil, broadcastErr
+		}
+		return &gateway.SendSSEMessageRes{MessageID: "message-1"}, nil
+	}
+	waitSSEAck := func(ctx context.Context, req gateway.WaitSSEAckReq) (*gateway.WaitSSEAckRes, error) {
+		return &gateway.WaitSSEAckRes{AcknowledgedBy: req.ClientIDs, Complete: true}, nil
+	}
+	auditSave := func(ctx context.Context, req gateway.ScanJobAuditSaveReq) (*gateway.ScanJobAuditSaveRes, error) {
+		return &gateway.ScanJobAuditSaveRes{}, nil
+	}
+	webhookEnqueue := func(ctx context.Context, req gateway.WebhookEventEnqueueReq) (*gateway.WebhookEventEnqueueRes, error) {
+		return &gateway.WebhookEventEnqueueRes{}, nil
+	}
+
+	report := ImplScanJobReport(
+		store.getOne, store.saveReport, store.transition, auditSave, store.getActive, store.setRecipients,
+		sendSSEMessage, waitSSEAck, webhookEnqueue, core.NewULIDGenerator(core.RealClock{}),
+		ScanConflictPolicy{Mode: ScanConflictReject, StaleAfter: time.Hour},
+	)
+
+	_, err := report(context.Background(), ScanJobReportReq{
+		JobID: "job-running",
+		Body:  ScanJobReportBody{ClientID: "agent-1", Status: "completed"},
+		Now:   now,
+	})
+	if !errors.Is(err, broadcastErr) {
+		t.Fatalf("report: got error %v, want the failed broadcast", err)
+	}
+
+	if status := store.status("job-running"); status != model.ScanJobCompleted {
+		t.Fatalf("reported job: got status %s, want %s", status, model.ScanJobCompleted)
+	}
+	if status := store.status("job-queued"); status != model.ScanJobDispatched {
+		t.Fatalf("queued job: got status %s, want it released to %s", status, model.ScanJobDispatched)
+	}
+}
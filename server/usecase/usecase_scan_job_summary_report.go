@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"server/gateway"
+	"server/model"
+	"server/utility"
+	"shared/core"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type ScanJobSummaryReportReq struct {
+	JobID  string    `json:"id" http:"path"`
+	Format string    `json:"format" http:"query"` // xlsx, the default, or pdf
+	Now    time.Time `json:"-" http:"now"`
+}
+
+type ScanJobSummaryReport = core.ActionHandler[ScanJobSummaryReportReq, ExportFile]
+
+// scanJobSummary is what the report of a job shows, computed before anything is written
+type scanJobSummary struct {
+	Job     model.ScanJob // with the status per agent
+	Results int
+	Hosts   int // distinct addresses with a result, online when one agent found them online
+	Online  int
+	Offline int
+	// ResponseTime is the distribution of the response times of the online results
+	ResponseTime model.ProbeStats
+	// NewDevices were first found online by this job, or by one running at the same time
+	NewDevices  []model.Device
+	StartedAt   time.Time // earliest start reported by an agent
+	FinishedAt  time.Time // latest finish reported by an agent
+	GeneratedAt time.Time
+}
+
+// ImplScanJobSummaryReport renders the summary of a scan job as an Excel workbook or a PDF: the host
+// counts, the response time distribution, the devices the job added to the inventory and the outcome
+// per agent. A job still running is reported as far as it went.
+func ImplScanJobSummaryReport(
+	ScanJobGetOne gateway.ScanJobGetOne,
+	ScanResultCountByJob gateway.ScanResultCountByJob,
+	ScanResultEach gateway.ScanResultEach,
+	DeviceEach gateway.DeviceEach,
+) ScanJobSummaryReport {
+	return func(ctx context.Context, req ScanJobSummaryReportReq) (*ExportFile, error) {
+
+		if req.Format == "" {
+			req.Format = "xlsx"
+		}
+		if req.Format != "xlsx" && req.Format != "pdf" {
+			return nil, fmt.Errorf("format must be xlsx or pdf")
+		}
+
+		res, err := ScanJobGetOne(ctx, gateway.ScanJobGetOneReq{JobID: req.JobID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if res.ScanJob == nil {
+			return nil, fmt.Errorf("scan job %s not found", req.JobID)
+		}
+
+		jobs := []model.ScanJob{*res.ScanJob}
+		if err := describeScanJobs(ctx, ScanResultCountByJob, jobs); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		summary := scanJobSummary{Job: jobs[0], GeneratedAt: req.Now}
+		for _, report := range summary.Job.Reports {
+			if !report.StartedAt.IsZero() && (summary.StartedAt.IsZero() || report.StartedAt.Before(summary.StartedAt)) {
+				summary.StartedAt = report.StartedAt
+			}
+			if report.FinishedAt.After(summary.FinishedAt) {
+				summary.FinishedAt = report.FinishedAt
+			}
+		}
+
+		// several agents may probe the same address, it is online when one of them found it
+		online := map[string]bool{}
+		var responseTimes []float64
+		if _, err := ScanResultEach(ctx, gateway.ScanResultEachReq{
+			JobID: req.JobID,
+			Each: func(result model.ScanResult) error {
+				summary.Results++
+				isOnline := result.Status == model.ScanResultOnline
+				online[result.IP] = online[result.IP] || isOnline
+				if isOnline {
+					responseTimes = append(responseTimes, result.ResponseTime)
+				}
+				return nil
+			},
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		summary.Hosts = len(online)
+		for _, up := range online {
+			if up {
+				summary.Online++
+			}
+		}
+		summary.Offline = summary.Hosts - summary.Online
+		summary.ResponseTime = responseTimeStats(responseTimes)
+
+		// a device found online by the job and first seen after it was requested was added by it
+		if _, err := DeviceEach(ctx, gateway.DeviceEachReq{
+			FirstSeenSince: summary.Job.CreatedAt,
+			Each: func(device model.Device) error {
+				if online[device.IP] {
+					summary.NewDevices = append(summary.NewDevices, device)
+				}
+				return nil
+			},
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		file := ExportFile{
+			FileName:    exportFileName("scan-job-"+req.JobID, req.Now, req.Format),
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			Write: func(ctx context.Context, w io.Writer) error {
+				return utility.WriteXLSX(w, scanJobSummarySheets(summary))
+			},
+		}
+		if req.Format == "pdf" {
+			file.ContentType = "application/pdf"
+			file.Write = func(ctx context.Context, w io.Writer) error {
+				_, err := scanJobSummaryPDF(summary).WriteTo(w)
+				return err
+			}
+		}
+		return &file, nil
+	}
+}
+
+// responseTimeStats is the distribution of the response times in milliseconds
+func responseTimeStats(times []float64) model.ProbeStats {
+	if len(times) == 0 {
+		return model.ProbeStats{}
+	}
+	sorted := append([]float64(nil), times...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, t := range sorted {
+		sum += t
+	}
+	percentile := func(q float64) float64 { return sorted[int(q*float64(len(sorted)-1))] }
+
+	return model.ProbeStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Avg:   sum / float64(len(sorted)),
+		P50:   percentile(0.50),
+		P95:   percentile(0.95),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// scanJobSummaryFields are the label and value lines shared by both formats, counts stay numbers so
+// a spreadsheet can compute with them
+func scanJobSummaryFields(summary scanJobSummary) [][2]any {
+	job := summary.Job
+	ms := func(value float64) float64 { return math.Round(value*100) / 100 }
+
+	return [][2]any{
+		{"Job", job.JobID},
+		{"Status", job.Status},
+		{"Method", job.Method},
+		{"IP range", job.IPRange},
+		{"Dry run", strconv.FormatBool(job.DryRun)},
+		{"Requested by", job.RequestedBy},
+		{"Requested at", exportTime(job.CreatedAt)},
+		{"Started at", exportTime(summary.StartedAt)},
+		{"Finished at", exportTime(summary.FinishedAt)},
+		{"Addresses in range", job.TotalHosts},
+		{"Hosts probed", summary.Hosts},
+		{"Online", summary.Online},
+		{"Offline", summary.Offline},
+		{"Results", summary.Results},
+		{"New devices", len(summary.NewDevices)},
+		{"Response time min (ms)", ms(summary.ResponseTime.Min)},
+		{"Response time avg (ms)", ms(summary.ResponseTime.Avg)},
+		{"Response time p50 (ms)", ms(summary.ResponseTime.P50)},
+		{"Response time p95 (ms)", ms(summary.ResponseTime.P95)},
+		{"Response time max (ms)", ms(summary.ResponseTime.Max)},
+		{"Generated at", exportTime(summary.GeneratedAt)},
+	}
+}
+
+// scanJobSummaryAgents is a row per agent: its status, results, report timing and error
+func scanJobSummaryAgents(summary scanJobSummary) (header []string, rows [][]any) {
+	reports := make(map[string]model.ScanJobReport, len(summary.Job.Reports))
+	for _, report := range summary.Job.Reports {
+		reports[report.ClientID] = report
+	}
+
+	header = []string{"Agent", "Status", "Results", "Hosts", "Started", "Finished", "Hosts/s", "Error"}
+	for _, agent := range summary.Job.Agents {
+		report := reports[agent.ClientID]
+		rows = append(rows, []any{
+			agent.ClientID,
+			agent.Status,
+			agent.Results,
+			report.TotalHosts,
+			exportTime(report.StartedAt),
+			exportTime(report.FinishedAt),
+			math.Round(report.Metrics.HostsPerSecond*10) / 10,
+			agent.Error,
+		})
+	}
+	return header, rows
+}
+
+func scanJobSummaryNewDevices(summary scanJobSummary) (header []string, rows [][]any) {
+	header = []string{"IP", "MAC", "Hostname", "Vendor", "First seen", "Agent"}
+	for _, device := range summary.NewDevices {
+		rows = append(rows, []any{device.IP, device.MAC, device.Hostname, device.Vendor, exportTime(device.FirstSeen), device.ClientID})
+	}
+	return header, rows
+}
+
+func scanJobSummarySheets(summary scanJobSummary) []utility.XLSXSheet {
+
+	table := func(name string, header []string, rows [][]any) utility.XLSXSheet {
+		headerRow := make([]any, len(header))
+		for i, cell := range header {
+			headerRow[i] = cell
+		}
+		return utility.XLSXSheet{Name: name, Rows: append([][]any{headerRow}, rows...)}
+	}
+
+	fields := scanJobSummaryFields(summary)
+	overview := make([][]any, len(fields))
+	for i, field := range fields {
+		overview[i] = []any{field[0], field[1]}
+	}
+
+	agentHeader, agentRows := scanJobSummaryAgents(summary)
+	deviceHeader, deviceRows := scanJobSummaryNewDevices(summary)
+	return []utility.XLSXSheet{
+		table("Summary", []string{"Field", "Value"}, overview),
+		table("Agents", agentHeader, agentRows),
+		table("New devices", deviceHeader, deviceRows),
+	}
+}
+
+func scanJobSummaryPDF(summary scanJobSummary) *utility.PDFDocument {
+	doc := utility.NewPDFDocument()
+	doc.Title("Scan job report " + summary.Job.JobID)
+
+	doc.Heading("Summary")
+	fields := scanJobSummaryFields(summary)
+	overview := make([][]any, len(fields))
+	for i, field := range fields {
+		overview[i] = []any{field[0], field[1]}
+	}
+	doc.Table([]string{"Field", "Value"}, pdfCells(overview))
+
+	doc.Heading("Agents")
+	agentHeader, agentRows := scanJobSummaryAgents(summary)
+	doc.Table(agentHeader, pdfCells(agentRows))
+
+	doc.Heading(fmt.Sprintf("New devices (%d)", len(summary.NewDevices)))
+	if len(summary.NewDevices) == 0 {
+		doc.Text("The job found no device that was not in the inventory already.")
+	} else {
+		deviceHeader, deviceRows := scanJobSummaryNewDevices(summary)
+		doc.Table(deviceHeader, pdfCells(deviceRows))
+	}
+	return doc
+}
+
+// pdfCells renders the cells of a table as text
+func pdfCells(rows [][]any) [][]string {
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, len(row))
+		for j, cell := range row {
+			cells[i][j] = fmt.Sprint(cell)
+		}
+	}
+	return cells
+}
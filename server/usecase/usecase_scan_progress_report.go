@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"time"
+)
+
+type ScanProgressReportBody struct {
+	JobID   string    `json:"job_id"`
+	IPRange string    `json:"ip_range"`
+	Scanned int       `json:"scanned" validate:"min=0"`
+	Total   int       `json:"total" validate:"min=0"`
+	At      time.Time `json:"at"`
+}
+
+type ScanProgressReportReq struct {
+	ClientID string                 `json:"id" http:"path"`
+	Body     ScanProgressReportBody `http:"body"`
+	Now      time.Time              `json:"-" http:"now"`
+}
+
+type ScanProgressReportRes struct{}
+
+type ScanProgressReport = core.ActionHandler[ScanProgressReportReq, ScanProgressReportRes]
+
+// ImplScanProgressReport relays the progress of a running scan to the dashboard feed as scan_progress,
+// on the topic of the site of the agent. Progress is not stored, the job report carries the outcome.
+func ImplScanProgressReport(
+	ClientGetOne gateway.ClientGetOne,
+	PublishDashboard gateway.SendSSEMessage,
+) ScanProgressReport {
+	return func(ctx context.Context, req ScanProgressReportReq) (*ScanProgressReportRes, error) {
+
+		if req.Body.Total <= 0 || req.Body.Scanned < 0 || req.Body.Scanned > req.Body.Total {
+			return nil, fmt.Errorf("scanned must be between 0 and total, total must be positive")
+		}
+
+		clientRes, err := ClientGetOne(ctx, gateway.ClientGetOneReq{ClientID: req.ClientID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		topic := model.UnassignedTopic
+		if clientRes.Client != nil && clientRes.Client.SiteID != nil {
+			topic = model.SiteTopic(*clientRes.Client.SiteID)
+		}
+
+		at := req.Body.At
+		if at.IsZero() {
+			at = req.Now
+		}
+
+		if _, err := PublishDashboard(ctx, gateway.SendSSEMessageReq{
+			EventType: "scan_progress",
+			Data: model.ScanProgressEvent{
+				JobID:    req.Body.JobID,
+				ClientID: req.ClientID,
+				IPRange:  req.Body.IPRange,
+				Scanned:  req.Body.Scanned,
+				Total:    req.Body.Total,
+				Percent:  float64(req.Body.Scanned) * 100 / float64(req.Body.Total),
+				At:       at,
+			},
+			Topic: topic,
+		}); err != nil {
+			return nil, err
+		}
+
+		return &ScanProgressReportRes{}, nil
+	}
+}
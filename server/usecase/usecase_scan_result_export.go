@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ScanResultExportReq struct {
+	Format   string    `json:"format" http:"query"`
+	Columns  []string  `json:"columns" http:"query"` // every column when empty
+	SiteID   int       `json:"site_id" http:"query"`
+	ClientID string    `json:"client_id" http:"query"`
+	IP       string    `json:"ip" http:"query"`
+	JobID    string    `json:"job_id" http:"query"`
+	Status   string    `json:"status" http:"query"`
+	Since    time.Time `json:"since" http:"query"`
+	Until    time.Time `json:"until" http:"query"`
+	Now      time.Time `json:"-" http:"now"`
+}
+
+type ScanResultExport = core.ActionHandler[ScanResultExportReq, ExportFile]
+
+// scanResultExportColumns are the columns of a scan result export, in their default order
+var scanResultExportColumns = []exportColumn[model.ScanResult]{
+	{Name: "id", Value: func(r model.ScanResult) string { return strconv.FormatUint(uint64(r.ID), 10) }},
+	{Name: "client_id", Value: func(r model.ScanResult) string { return r.ClientID }},
+	{Name: "job_id", Value: func(r model.ScanResult) string { return r.JobID }},
+	{Name: "ip", Value: func(r model.ScanResult) string { return r.IP }},
+	{Name: "timestamp", Value: func(r model.ScanResult) string { return exportTime(r.Timestamp) }},
+	{Name: "protocol", Value: func(r model.ScanResult) string { return r.Protocol }},
+	{Name: "status", Value: func(r model.ScanResult) string { return r.Status }},
+	{Name: "response_time", Value: func(r model.ScanResult) string { return strconv.FormatFloat(r.ResponseTime, 'f', -1, 64) }},
+	{Name: "mac", Value: func(r model.ScanResult) string { return r.MAC }},
+	{Name: "port", Value: func(r model.ScanResult) string { return exportInt(r.Port) }},
+	{Name: "open_ports", Value: func(r model.ScanResult) string { return exportOpenPorts(r.OpenPorts) }},
+	{Name: "ttl", Value: func(r model.ScanResult) string { return exportInt(r.TTL) }},
+	{Name: "os_guess", Value: func(r model.ScanResult) string { return r.OSGuess }},
+	{Name: "icmp_mode", Value: func(r model.ScanResult) string { return r.ICMPMode }},
+	{Name: "sys_name", Value: func(r model.ScanResult) string { identity, _ := r.SNMPIdentity(); return identity.SysName }},
+	{Name: "sys_descr", Value: func(r model.ScanResult) string { identity, _ := r.SNMPIdentity(); return identity.SysDescr }},
+}
+
+// ImplScanResultExport streams the matching scan results as CSV, with the filters of GET /api/scan-results
+// and no row limit. The rows are read in batches while they are written, so the export is not held in memory.
+func ImplScanResultExport(
+	SiteGetOne gateway.SiteGetOne,
+	ScanResultEach gateway.ScanResultEach,
+) ScanResultExport {
+	return func(ctx context.Context, req ScanResultExportReq) (*ExportFile, error) {
+
+		if err := exportFormat(req.Format); err != nil {
+			return nil, err
+		}
+		columns, err := selectExportColumns(scanResultExportColumns, req.Columns)
+		if err != nil {
+			return nil, err
+		}
+
+		clientIDs, err := scanResultClients(ctx, SiteGetOne, req.SiteID, req.ClientID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ExportFile{
+			FileName:    exportFileName("scan-results", req.Now, "csv"),
+			ContentType: "text/csv; charset=utf-8",
+			Write: func(ctx context.Context, w io.Writer) error {
+				each, flush, err := csvRowWriter(w, columns)
+				if err != nil {
+					return err
+				}
+				if _, err := ScanResultEach(ctx, gateway.ScanResultEachReq{
+					ClientIDs: clientIDs,
+					IP:        req.IP,
+					JobID:     req.JobID,
+					Status:    req.Status,
+					Since:     req.Since,
+					Until:     req.Until,
+					Each:      each,
+				}); err != nil {
+					return err
+				}
+				return flush()
+			},
+		}, nil
+	}
+}
+
+// exportInt leaves a zero, which means unknown for these columns, empty
+func exportInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+// exportOpenPorts renders the open ports as port/service pairs separated by semicolons, e.g. 22/ssh;8080
+func exportOpenPorts(ports []model.OpenPort) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port.Port)
+		if port.Service != "" {
+			parts[i] = fmt.Sprintf("%d/%s", port.Port, port.Service)
+		}
+	}
+	return strings.Join(parts, ";")
+}
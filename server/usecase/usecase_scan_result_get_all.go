@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type ScanResultGetAllReq struct {
+	SiteID   int            `json:"site_id" http:"query"`
+	ClientID string         `json:"client_id" http:"query"`
+	IP       string         `json:"ip" http:"query"`
+	JobID    string         `json:"job_id" http:"query"`
+	List     core.ListQuery `json:"list" http:"list"`
+}
+
+type ScanResultGetAll = core.ActionHandler[ScanResultGetAllReq, core.ListPage[model.ScanResult]]
+
+// ScanResultListFields are the fields the scan result list is sorted and filtered by
+var ScanResultListFields = slices.Sorted(maps.Keys(gateway.ScanResultListColumns))
+
+// ImplScanResultGetAll lists discovered devices, filtering by site keeps results reported by the agents of that site
+func ImplScanResultGetAll(
+	SiteGetOne gateway.SiteGetOne,
+	ScanResultGetAll gateway.ScanResultGetAll,
+) ScanResultGetAll {
+	return func(ctx context.Context, req ScanResultGetAllReq) (*core.ListPage[model.ScanResult], error) {
+
+		list := req.List.Normalize(1000, 1000, "-id")
+		if err := list.Validate(ScanResultListFields...); err != nil {
+			return nil, err
+		}
+
+		clientIDs, err := scanResultClients(ctx, SiteGetOne, req.SiteID, req.ClientID)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := ScanResultGetAll(ctx, gateway.ScanResultGetAllReq{
+			ClientIDs: clientIDs,
+			IP:        req.IP,
+			JobID:     req.JobID,
+			List:      list,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.ScanResult]{Items: res.ScanResults, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
+
+// scanResultClients is the agent filter of a result query, nil for every agent, the agents of the site
+// when siteID is set, narrowed to clientID when it is set too
+func scanResultClients(ctx context.Context, SiteGetOne gateway.SiteGetOne, siteID int, clientID string) ([]string, error) {
+
+	var clientIDs []string
+	if clientID != "" {
+		clientIDs = []string{clientID}
+	}
+
+	if siteID != 0 {
+		res, err := SiteGetOne(ctx, gateway.SiteGetOneReq{ID: uint(siteID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if res.Site == nil {
+			return nil, fmt.Errorf("site %d not found", siteID)
+		}
+
+		siteAgents := make([]string, 0, len(res.Site.Agents))
+		for _, agent := range res.Site.Agents {
+			if clientID == "" || agent.ClientID == clientID {
+				siteAgents = append(siteAgents, agent.ClientID)
+			}
+		}
+		clientIDs = siteAgents
+	}
+
+	return clientIDs, nil
+}
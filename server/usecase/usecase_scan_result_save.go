@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"time"
+)
+
+type ScanResultSaveReq struct {
+	ClientID    string             `json:"client_id" http:"query"`
+	JobID       string             `json:"job_id" http:"query"` // scan job the results belong to, echoed by the agent
+	ScanResults []model.ScanResult `json:"-"`
+	Now         time.Time          `json:"-" http:"now"`
+}
+
+type ScanResultSaveRes struct {
+	Accepted int                  `json:"accepted"`
+	Rejected int                  `json:"rejected"`
+	Errors   []ScanResultRowError `json:"errors"` // Line is the 1-based position in the array
+}
+
+type ScanResultSave = core.ActionHandler[ScanResultSaveReq, ScanResultSaveRes]
+
+// ImplScanResultSave stores the result array of a finished scan, usually handed over by a completed
+// chunked upload. Invalid rows are skipped and reported rather than failing the upload, because the
+// upload would be retried with the same rows forever.
+func ImplScanResultSave(
+	ScanResultSave gateway.ScanResultSave,
+	DeviceRecordSeen gateway.DeviceRecordSeen,
+	AlertSave gateway.AlertSave,
+	SendSSEMessage gateway.SendSSEMessage,
+	WebhookEventEnqueue gateway.WebhookEventEnqueue,
+	IDGenerator core.IDGenerator,
+	Analyzers []ScanResultAnalyzer,
+) ScanResultSave {
+	return func(ctx context.Context, req ScanResultSaveReq) (*ScanResultSaveRes, error) {
+
+		res := ScanResultSaveRes{Errors: []ScanResultRowError{}}
+
+		accepted := make([]model.ScanResult, 0, len(req.ScanResults))
+		for i, scanResult := range req.ScanResults {
+
+			if err := validateScanResult(&scanResult); err != nil {
+				res.Rejected++
+				if len(res.Errors) < scanResultStreamMaxErrors {
+					res.Errors = append(res.Errors, ScanResultRowError{Line: i + 1, Error: err.Error()})
+				}
+				continue
+			}
+
+			if scanResult.ClientID == "" {
+				scanResult.ClientID = req.ClientID
+			}
+			if scanResult.JobID == "" {
+				scanResult.JobID = req.JobID
+			}
+			accepted = append(accepted, scanResult)
+		}
+
+		if _, err := ScanResultSave(ctx, gateway.ScanResultSaveReq{ScanResults: accepted}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		res.Accepted = len(accepted)
+
+		recordDevices(ctx, DeviceRecordSeen, WebhookEventEnqueue, IDGenerator, accepted, req.Now)
+
+		if len(accepted) > 0 {
+			analyzeScanResults(ctx, AlertSave, SendSSEMessage, Analyzers, ScanResultBatch{
+				ClientID:   req.ClientID,
+				Results:    accepted,
+				ReceivedAt: req.Now,
+			})
+		}
+
+		return &res, nil
+	}
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"time"
+)
+
+type ScanResultStreamReq struct {
+	ClientID string    `json:"client_id" http:"query"`
+	Body     io.Reader `json:"-"`
+	Now      time.Time `json:"-" http:"now"`
+}
+
+type ScanResultRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ScanResultStreamRes struct {
+	Accepted int                  `json:"accepted"`
+	Rejected int                  `json:"rejected"`
+	Errors   []ScanResultRowError `json:"errors"`
+}
+
+type ScanResultStream = core.ActionHandler[ScanResultStreamReq, ScanResultStreamRes]
+
+const (
+	scanResultStreamBatchSize = 200
+	scanResultStreamMaxErrors = 100
+)
+
+// ImplScanResultStream reads NDJSON rows as they arrive, validates them and inserts them in batches
+// so an agent can stream results during the scan instead of sending one huge array at the end.
+// Every stored batch updates the device inventory, once the whole stream is stored the analyzers
+// look at it and raise alerts for what they find.
+func ImplScanResultStream(
+	ScanResultSaveBatch gateway.ScanResultSaveBatch,
+	DeviceRecordSeen gateway.DeviceRecordSeen,
+	AlertSave gateway.AlertSave,
+	SendSSEMessage gateway.SendSSEMessage,
+	WebhookEventEnqueue gateway.WebhookEventEnqueue,
+	IDGenerator core.IDGenerator,
+	Analyzers []ScanResultAnalyzer,
+) ScanResultStream {
+	return func(ctx context.Context, req ScanResultStreamReq) (*ScanResultStreamRes, error) {
+
+		res := ScanResultStreamRes{Errors: []ScanResultRowError{}}
+
+		batch := make([]model.ScanResult, 0, scanResultStreamBatchSize)
+
+		// the analyzers see the whole upload, rows are only kept when there is one to run
+		var accepted []model.ScanResult
+
+		flush := func() error {
+			if _, err := ScanResultSaveBatch(ctx, gateway.ScanResultSaveBatchReq{ScanResults: batch}); err != nil {
+				return core.NewInternalServerError(err)
+			}
+			recordDevices(ctx, DeviceRecordSeen, WebhookEventEnqueue, IDGenerator, batch, req.Now)
+			if len(Analyzers) > 0 {
+				accepted = append(accepted, batch...)
+			}
+			res.Accepted += len(batch)
+			batch = batch[:0]
+			return nil
+		}
+
+		rowError := func(line int, err error) {
+			res.Rejected++
+			if len(res.Errors) < scanResultStreamMaxErrors {
+				res.Errors = append(res.Errors, ScanResultRowError{Line: line, Error: err.Error()})
+			}
+		}
+
+		scanner := bufio.NewScanner(req.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		line := 0
+		for scanner.Scan() {
+			line++
+
+			row := bytes.TrimSpace(scanner.Bytes())
+			if len(row) == 0 {
+				continue
+			}
+
+			var scanResult model.ScanResult
+			if err := json.Unmarshal(row, &scanResult); err != nil {
+				rowError(line, fmt.Errorf("invalid json: %v", err))
+				continue
+			}
+
+			if err := validateScanResult(&scanResult); err != nil {
+				rowError(line, err)
+				continue
+			}
+
+			if scanResult.ClientID == "" {
+				scanResult.ClientID = req.ClientID
+			}
+
+			batch = append(batch, scanResult)
+			if len(batch) >= scanResultStreamBatchSize {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if err := flush(); err != nil {
+			return nil, err
+		}
+
+		// rows already inserted stay inserted, the error tells the agent where the stream broke
+		if err := scanner.Err(); err != nil {
+			return nil, core.NewErrorWithData(fmt.Errorf("stream interrupted after line %d: %v", line, err), res)
+		}
+
+		// an interrupted stream is not analyzed, the missing rows would look like hosts gone offline
+		if len(accepted) > 0 {
+			analyzeScanResults(ctx, AlertSave, SendSSEMessage, Analyzers, ScanResultBatch{
+				ClientID:   req.ClientID,
+				Results:    accepted,
+				ReceivedAt: req.Now,
+			})
+		}
+
+		return &res, nil
+	}
+}
+
+func validateScanResult(scanResult *model.ScanResult) error {
+	scanResult.ID = 0
+
+	if net.ParseIP(scanResult.IP) == nil {
+		return fmt.Errorf("invalid ip %q", scanResult.IP)
+	}
+
+	if scanResult.Status == "" {
+		return fmt.Errorf("status is required")
+	}
+
+	if scanResult.ResponseTime < 0 {
+		return fmt.Errorf("response_time must not be negative")
+	}
+
+	return nil
+}
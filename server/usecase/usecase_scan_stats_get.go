@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+)
+
+type ScanStatsGetReq struct {
+}
+
+type ScanStatsGetRes struct {
+	JobsByStatus map[string]int64 `json:"jobs_by_status"`
+}
+
+type ScanStatsGet = core.ActionHandler[ScanStatsGetReq, ScanStatsGetRes]
+
+// ImplScanStatsGet is the scan section of the stats snapshots
+func ImplScanStatsGet(ScanJobCountByStatus gateway.ScanJobCountByStatus) ScanStatsGet {
+	return func(ctx context.Context, req ScanStatsGetReq) (*ScanStatsGetRes, error) {
+
+		counts, err := ScanJobCountByStatus(ctx, gateway.ScanJobCountByStatusReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &ScanStatsGetRes{JobsByStatus: counts.Counts}, nil
+	}
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"server/gateway"
+	"server/model"
+	"shared/command"
+	"shared/core"
+	"time"
+)
+
+type ServiceDiscoveredReportBody = command.DiscoverServicesResult
+
+type ServiceDiscoveredReportReq struct {
+	ClientID string                      `json:"id" http:"path"`
+	Body     ServiceDiscoveredReportBody `http:"body"`
+	Now      time.Time                   `json:"-" http:"now"`
+}
+
+type ServiceDiscoveredReportRes struct {
+	Received int `json:"received"`
+	Created  int `json:"created"` // services never seen before
+}
+
+type ServiceDiscoveredReport = core.ActionHandler[ServiceDiscoveredReportReq, ServiceDiscoveredReportRes]
+
+// ImplServiceDiscoveredReport stores the services an agent heard announced and publishes them to the
+// dashboard feed as service_discovered, on the topic of the site of the agent
+func ImplServiceDiscoveredReport(
+	DiscoveredServiceSave gateway.DiscoveredServiceSave,
+	ClientGetOne gateway.ClientGetOne,
+	PublishDashboard gateway.SendSSEMessage,
+) ServiceDiscoveredReport {
+	return func(ctx context.Context, req ServiceDiscoveredReportReq) (*ServiceDiscoveredReportRes, error) {
+
+		services := make([]model.DiscoveredService, 0, len(req.Body.Services))
+		for _, item := range req.Body.Services {
+			if item.Protocol != model.DiscoveryMDNS && item.Protocol != model.DiscoverySSDP {
+				return nil, fmt.Errorf("protocol must be %s or %s", model.DiscoveryMDNS, model.DiscoverySSDP)
+			}
+			if net.ParseIP(item.IP) == nil {
+				return nil, fmt.Errorf("invalid ip %q", item.IP)
+			}
+			if item.ServiceType == "" && item.Name == "" {
+				return nil, fmt.Errorf("service at %s has neither a type nor a name", item.IP)
+			}
+
+			seenAt := item.SeenAt
+			if seenAt.IsZero() || seenAt.After(req.Now) {
+				seenAt = req.Now
+			}
+			services = append(services, model.DiscoveredService{
+				Protocol:    item.Protocol,
+				IP:          item.IP,
+				ServiceType: item.ServiceType,
+				Name:        item.Name,
+				Port:        item.Port,
+				Details:     item.Details,
+				ClientID:    req.ClientID,
+				LastSeen:    seenAt,
+			})
+		}
+
+		if len(services) == 0 {
+			return &ServiceDiscoveredReportRes{}, nil
+		}
+
+		saved, err := DiscoveredServiceSave(ctx, gateway.DiscoveredServiceSaveReq{Services: services})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		clientRes, err := ClientGetOne(ctx, gateway.ClientGetOneReq{ClientID: req.ClientID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		topic := model.UnassignedTopic
+		if clientRes.Client != nil && clientRes.Client.SiteID != nil {
+			topic = model.SiteTopic(*clientRes.Client.SiteID)
+		}
+
+		if _, err := PublishDashboard(ctx, gateway.SendSSEMessageReq{
+			EventType: "service_discovered",
+			Data: model.ServiceDiscoveredEvent{
+				ClientID: req.ClientID,
+				Services: saved.Services,
+			},
+			Topic: topic,
+		}); err != nil {
+			return nil, err
+		}
+
+		return &ServiceDiscoveredReportRes{Received: len(services), Created: saved.Created}, nil
+	}
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type SiteAssignAgentsBody struct {
+	ClientIDs []string `json:"client_ids" validate:"required,min=1"`
+}
+
+type SiteAssignAgentsReq struct {
+	SiteID int                  `json:"id" http:"path"`
+	Body   SiteAssignAgentsBody `http:"body"`
+}
+
+type SiteAssignAgentsRes struct {
+	Site model.Site `json:"site"`
+}
+
+type SiteAssignAgents = core.ActionHandler[SiteAssignAgentsReq, SiteAssignAgentsRes]
+
+// ImplSiteAssignAgents places agents on a site, an agent belongs to one site so it moves from its previous one
+func ImplSiteAssignAgents(
+	SiteGetOne gateway.SiteGetOne,
+	ClientAssignSite gateway.ClientAssignSite,
+) SiteAssignAgents {
+	return func(ctx context.Context, req SiteAssignAgentsReq) (*SiteAssignAgentsRes, error) {
+
+		if len(req.Body.ClientIDs) == 0 {
+			return nil, fmt.Errorf("client_ids is required")
+		}
+
+		existing, err := SiteGetOne(ctx, gateway.SiteGetOneReq{ID: uint(req.SiteID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.Site == nil {
+			return nil, fmt.Errorf("site %d not found", req.SiteID)
+		}
+
+		if _, err := ClientAssignSite(ctx, gateway.ClientAssignSiteReq{
+			ClientIDs: req.Body.ClientIDs,
+			SiteID:    &existing.Site.ID,
+		}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		updated, err := SiteGetOne(ctx, gateway.SiteGetOneReq{ID: existing.Site.ID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &SiteAssignAgentsRes{Site: *updated.Site}, nil
+	}
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"strings"
+)
+
+type SiteCreateReq struct {
+	Name    string   `json:"name" validate:"required"`
+	Address string   `json:"address"`
+	Subnets []string `json:"subnets"`
+}
+
+type SiteCreateRes struct {
+	Site model.Site `json:"site"`
+}
+
+type SiteCreate = core.ActionHandler[SiteCreateReq, SiteCreateRes]
+
+// ImplSiteCreate stores a site, a subnet belongs to one site only so an untargeted scan knows its agents
+func ImplSiteCreate(
+	SiteGetAll gateway.SiteGetAll,
+	SiteSave gateway.SiteSave,
+) SiteCreate {
+	return func(ctx context.Context, req SiteCreateReq) (*SiteCreateRes, error) {
+
+		if strings.TrimSpace(req.Name) == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+
+		subnets := make([]string, 0, len(req.Subnets))
+		for _, subnet := range req.Subnets {
+			subnet = strings.TrimSpace(subnet)
+			if _, err := parseAddrRange(subnet); err != nil {
+				return nil, fmt.Errorf("invalid subnet %q: %v", subnet, err)
+			}
+			if rangesOverlap([]string{subnet}, subnets) {
+				return nil, fmt.Errorf("subnet %s overlaps another subnet of the site", subnet)
+			}
+			subnets = append(subnets, subnet)
+		}
+
+		existing, err := SiteGetAll(ctx, gateway.SiteGetAllReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		for _, other := range existing.Sites {
+			for _, subnet := range subnets {
+				if rangesOverlap([]string{subnet}, other.Subnets) {
+					return nil, fmt.Errorf("subnet %s overlaps a subnet of site %s", subnet, other.Name)
+				}
+			}
+		}
+
+		site := model.Site{
+			Name:    strings.TrimSpace(req.Name),
+			Address: req.Address,
+			Subnets: subnets,
+			Agents:  []model.Client{},
+		}
+
+		if _, err := SiteSave(ctx, gateway.SiteSaveReq{Site: &site}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &SiteCreateRes{Site: site}, nil
+	}
+}
@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type SiteGetAllReq struct{}
+
+type SiteGetAllRes struct {
+	Sites []model.Site `json:"sites"`
+}
+
+type SiteGetAll = core.ActionHandler[SiteGetAllReq, SiteGetAllRes]
+
+func ImplSiteGetAll(
+	SiteGetAll gateway.SiteGetAll,
+) SiteGetAll {
+	return func(ctx context.Context, req SiteGetAllReq) (*SiteGetAllRes, error) {
+
+		res, err := SiteGetAll(ctx, gateway.SiteGetAllReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &SiteGetAllRes{Sites: res.Sites}, nil
+	}
+}
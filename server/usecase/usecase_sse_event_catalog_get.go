@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+	"shared/utility"
+)
+
+type SSEEventCatalogGetReq struct {
+}
+
+type SSEEventCatalogGetRes struct {
+	Events []utility.EventCatalogEntry `json:"events"`
+}
+
+type SSEEventCatalogGet = core.ActionHandler[SSEEventCatalogGetReq, SSEEventCatalogGetRes]
+
+func ImplSSEEventCatalogGet(
+	SSEEventCatalogGet gateway.SSEEventCatalogGet,
+) SSEEventCatalogGet {
+	return func(ctx context.Context, req SSEEventCatalogGetReq) (*SSEEventCatalogGetRes, error) {
+
+		res, err := SSEEventCatalogGet(ctx, gateway.SSEEventCatalogGetReq{})
+		if err != nil {
+			return nil, err
+		}
+
+		return &SSEEventCatalogGetRes{Events: res.Events}, nil
+	}
+}
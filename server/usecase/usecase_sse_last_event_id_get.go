@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/core"
+)
+
+type SSELastEventIDGetReq struct {
+	ClientID string `json:"id" http:"path"`
+}
+
+type SSELastEventIDGetRes struct {
+	ClientID    string `json:"client_id"`
+	LastEventID uint64 `json:"last_event_id"`
+	Connected   bool   `json:"connected"`
+}
+
+type SSELastEventIDGet = core.ActionHandler[SSELastEventIDGetReq, SSELastEventIDGetRes]
+
+func ImplSSELastEventIDGet(
+	SSELastEventIDGet gateway.SSELastEventIDGet,
+) SSELastEventIDGet {
+	return func(ctx context.Context, req SSELastEventIDGetReq) (*SSELastEventIDGetRes, error) {
+
+		res, err := SSELastEventIDGet(ctx, gateway.SSELastEventIDGetReq{ClientID: req.ClientID})
+		if err != nil {
+			return nil, err
+		}
+
+		if !res.Found {
+			return nil, fmt.Errorf("no event was sent to client %s", req.ClientID)
+		}
+
+		return &SSELastEventIDGetRes{
+			ClientID:    req.ClientID,
+			LastEventID: res.LastEventID,
+			Connected:   res.Connected,
+		}, nil
+	}
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"shared/core"
+	"shared/utility"
+)
+
+type SSERejectionGetAllReq struct {
+	Reason string `json:"reason" http:"query"`
+}
+
+type SSERejectionGetAllRes struct {
+	Rejections []utility.Rejection            `json:"rejections"`
+	Counts     map[utility.RejectReason]int64 `json:"counts"`
+}
+
+type SSERejectionGetAll = core.ActionHandler[SSERejectionGetAllReq, SSERejectionGetAllRes]
+
+func ImplSSERejectionGetAll(
+	SSERejectionGetAll gateway.SSERejectionGetAll,
+) SSERejectionGetAll {
+	return func(ctx context.Context, req SSERejectionGetAllReq) (*SSERejectionGetAllRes, error) {
+
+		res, err := SSERejectionGetAll(ctx, gateway.SSERejectionGetAllReq{})
+		if err != nil {
+			return nil, err
+		}
+
+		rejections := res.Rejections
+		if req.Reason != "" {
+			rejections = make([]utility.Rejection, 0)
+			for _, rejection := range res.Rejections {
+				if string(rejection.Reason) == req.Reason {
+					rejections = append(rejections, rejection)
+				}
+			}
+		}
+
+		return &SSERejectionGetAllRes{
+			Rejections: rejections,
+			Counts:     res.Counts,
+		}, nil
+	}
+}
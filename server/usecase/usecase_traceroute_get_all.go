@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type TracerouteGetAllReq struct {
+	ClientID string         `json:"client_id" http:"query"`
+	Target   string         `json:"target" http:"query"`
+	List     core.ListQuery `json:"list" http:"list"`
+}
+
+type TracerouteGetAll = core.ActionHandler[TracerouteGetAllReq, core.ListPage[model.Traceroute]]
+
+// TracerouteListFields are the fields the traceroute list is sorted and filtered by
+var TracerouteListFields = slices.Sorted(maps.Keys(gateway.TracerouteListColumns))
+
+func ImplTracerouteGetAll(
+	TracerouteGetAll gateway.TracerouteGetAll,
+) TracerouteGetAll {
+	return func(ctx context.Context, req TracerouteGetAllReq) (*core.ListPage[model.Traceroute], error) {
+
+		list := req.List.Normalize(50, 500, "-id")
+		if err := list.Validate(TracerouteListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := TracerouteGetAll(ctx, gateway.TracerouteGetAllReq{
+			ClientID: req.ClientID,
+			Target:   req.Target,
+			List:     list,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.Traceroute]{Items: res.Traceroutes, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
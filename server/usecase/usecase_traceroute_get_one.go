@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type TracerouteGetOneReq struct {
+	TraceID string `json:"id" http:"path"`
+}
+
+type TracerouteGetOneRes struct {
+	Traceroute model.Traceroute `json:"traceroute"`
+}
+
+type TracerouteGetOne = core.ActionHandler[TracerouteGetOneReq, TracerouteGetOneRes]
+
+func ImplTracerouteGetOne(
+	TracerouteGetOne gateway.TracerouteGetOne,
+) TracerouteGetOne {
+	return func(ctx context.Context, req TracerouteGetOneReq) (*TracerouteGetOneRes, error) {
+
+		res, err := TracerouteGetOne(ctx, gateway.TracerouteGetOneReq{TraceID: req.TraceID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if res.Traceroute == nil {
+			return nil, fmt.Errorf("traceroute %s not found", req.TraceID)
+		}
+
+		return &TracerouteGetOneRes{Traceroute: *res.Traceroute}, nil
+	}
+}
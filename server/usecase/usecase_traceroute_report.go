@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/command"
+	"shared/core"
+)
+
+type TracerouteReportBody = command.TracerouteResult
+
+type TracerouteReportReq struct {
+	TraceID string               `json:"id" http:"path"`
+	Body    TracerouteReportBody `http:"body"`
+}
+
+type TracerouteReportRes struct{}
+
+type TracerouteReport = core.ActionHandler[TracerouteReportReq, TracerouteReportRes]
+
+// ImplTracerouteReport stores the path an agent traced and publishes it to the dashboard feed as
+// traceroute_completed, on the topic of the site of the agent
+func ImplTracerouteReport(
+	TracerouteGetOne gateway.TracerouteGetOne,
+	TracerouteSave gateway.TracerouteSave,
+	ClientGetOne gateway.ClientGetOne,
+	PublishDashboard gateway.SendSSEMessage,
+) TracerouteReport {
+	return func(ctx context.Context, req TracerouteReportReq) (*TracerouteReportRes, error) {
+
+		existing, err := TracerouteGetOne(ctx, gateway.TracerouteGetOneReq{TraceID: req.TraceID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if existing.Traceroute == nil {
+			return nil, fmt.Errorf("traceroute %s not found", req.TraceID)
+		}
+
+		traceroute := existing.Traceroute
+		if req.Body.ClientID != traceroute.ClientID {
+			return nil, fmt.Errorf("traceroute %s was sent to %s, not %s", req.TraceID, traceroute.ClientID, req.Body.ClientID)
+		}
+		if traceroute.Status == model.TracerouteCompleted {
+			return nil, fmt.Errorf("traceroute %s is already reported", req.TraceID)
+		}
+
+		traceroute.ResolvedIP = req.Body.ResolvedIP
+		traceroute.Hops = req.Body.Hops
+		traceroute.Reached = req.Body.Reached
+		traceroute.Error = req.Body.Error
+		traceroute.Status = model.TracerouteCompleted
+		if req.Body.Error != "" {
+			traceroute.Status = model.TracerouteFailed
+		}
+		if !req.Body.StartedAt.IsZero() {
+			traceroute.StartedAt = &req.Body.StartedAt
+		}
+		if !req.Body.FinishedAt.IsZero() {
+			traceroute.FinishedAt = &req.Body.FinishedAt
+		}
+
+		if _, err := TracerouteSave(ctx, gateway.TracerouteSaveReq{Traceroute: traceroute}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		clientRes, err := ClientGetOne(ctx, gateway.ClientGetOneReq{ClientID: traceroute.ClientID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		topic := model.UnassignedTopic
+		if clientRes.Client != nil && clientRes.Client.SiteID != nil {
+			topic = model.SiteTopic(*clientRes.Client.SiteID)
+		}
+
+		if _, err := PublishDashboard(ctx, gateway.SendSSEMessageReq{
+			EventType: "traceroute_completed",
+			Data:      traceroute,
+			Topic:     topic,
+		}); err != nil {
+			return nil, err
+		}
+
+		return &TracerouteReportRes{}, nil
+	}
+}
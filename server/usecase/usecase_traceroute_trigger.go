@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/command"
+	"shared/core"
+	"strings"
+)
+
+type TracerouteTriggerReq struct {
+	ClientID string `json:"client_id" validate:"required"`
+	Target   string `json:"target" validate:"required" example:"8.8.8.8"` // ip or host name, resolved by the agent
+	MaxHops  int    `json:"max_hops" validate:"max=64"`                   // default 30, at most 64
+	Probes   int    `json:"probes" validate:"max=10"`                     // probes per hop, default 3
+	// TimeoutMs is how long the agent waits for each probe, default 1000
+	TimeoutMs int `json:"timeout_ms"`
+	// AckTimeoutMs is how long to wait for the agent to confirm it received the command, default 5000
+	AckTimeoutMs int    `json:"ack_timeout_ms"`
+	Operator     string `json:"-" http:"header(X-Operator)"`
+}
+
+type TracerouteTriggerRes struct {
+	TraceID   string `json:"trace_id"`
+	Status    string `json:"status"`
+	MessageID string `json:"message_id"`
+	// Acknowledged is true once the agent took the command, the path follows on GET /api/traceroutes/{id}
+	Acknowledged bool `json:"acknowledged"`
+}
+
+type TracerouteTrigger = core.ActionHandler[TracerouteTriggerReq, TracerouteTriggerRes]
+
+// ImplTracerouteTrigger asks one connected agent to trace the path to a target, the agent answers
+// on POST /api/traceroutes/{id}/report. A trace is only meaningful now, so offline agents are refused,
+// and so are agents that did not announce the traceroute command.
+func ImplTracerouteTrigger(
+	SendTraceroute gateway.SendCommand[command.TracerouteRequest],
+	WaitSSEAck gateway.WaitSSEAck,
+	SSEConnectedClientGetAll gateway.SSEConnectedClientGetAll,
+	ClientGetAll gateway.ClientGetAll,
+	TracerouteSave gateway.TracerouteSave,
+	TracerouteGetOne gateway.TracerouteGetOne,
+	IDGenerator core.IDGenerator,
+) TracerouteTrigger {
+	return func(ctx context.Context, req TracerouteTriggerReq) (*TracerouteTriggerRes, error) {
+
+		if req.ClientID == "" {
+			return nil, fmt.Errorf("client_id is required")
+		}
+		req.Target = strings.TrimSpace(req.Target)
+		if req.Target == "" || strings.ContainsAny(req.Target, " /") {
+			return nil, fmt.Errorf("target must be an ip or a host name")
+		}
+		if req.MaxHops <= 0 {
+			req.MaxHops = 30
+		}
+		if req.MaxHops > 64 {
+			return nil, fmt.Errorf("max_hops cannot exceed 64")
+		}
+		if req.Probes <= 0 {
+			req.Probes = 3
+		}
+		if req.Probes > 10 {
+			return nil, fmt.Errorf("probes cannot exceed 10")
+		}
+		if req.TimeoutMs <= 0 {
+			req.TimeoutMs = 1000
+		}
+		if err := requireConnectedAgent(ctx, SSEConnectedClientGetAll, req.ClientID); err != nil {
+			return nil, err
+		}
+		if _, err := capableAgents(ctx, ClientGetAll, []string{req.ClientID}, true, []string{model.CommandTraceroute}, 0); err != nil {
+			return nil, err
+		}
+
+		// the trace is saved first, a fast agent may report before the ack wait returns
+		traceroute := model.Traceroute{
+			TraceID:     "trace-" + IDGenerator.NewID(),
+			ClientID:    req.ClientID,
+			Target:      req.Target,
+			MaxHops:     req.MaxHops,
+			Status:      model.TraceroutePending,
+			RequestedBy: req.Operator,
+		}
+		if _, err := TracerouteSave(ctx, gateway.TracerouteSaveReq{Traceroute: &traceroute}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		sent, err := sendAgentCommand(ctx, SendTraceroute, WaitSSEAck, req.ClientID, command.TracerouteRequest{
+			TraceID:   traceroute.TraceID,
+			Target:    req.Target,
+			MaxHops:   req.MaxHops,
+			Probes:    req.Probes,
+			TimeoutMs: req.TimeoutMs,
+		}, false, req.AckTimeoutMs)
+		if err != nil {
+			return nil, err
+		}
+
+		status := model.TraceroutePending
+		if !sent.Acknowledged {
+			current, err := TracerouteGetOne(ctx, gateway.TracerouteGetOneReq{TraceID: traceroute.TraceID})
+			if err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+			status = current.Traceroute.Status
+
+			// an agent that reported did take the command even if its ack got lost
+			if status == model.TraceroutePending {
+				status = model.TracerouteFailed
+				current.Traceroute.Status = status
+				current.Traceroute.Error = "the agent did not acknowledge the command"
+				if _, err := TracerouteSave(ctx, gateway.TracerouteSaveReq{Traceroute: current.Traceroute}); err != nil {
+					return nil, core.NewInternalServerError(err)
+				}
+			}
+		}
+
+		return &TracerouteTriggerRes{
+			TraceID:      traceroute.TraceID,
+			Status:       status,
+			MessageID:    sent.MessageID,
+			Acknowledged: sent.Acknowledged,
+		}, nil
+	}
+}
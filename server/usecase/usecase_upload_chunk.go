@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"strings"
+)
+
+type UploadChunkReq struct {
+	UploadID string `json:"id" http:"path"`
+	Index    int    `json:"index" http:"path"`
+	Data     []byte `json:"-"`
+	Owner    string `json:"-" http:"header(X-Operator)"`
+}
+
+type UploadChunkRes struct {
+	UploadID string `json:"upload_id"`
+	Index    int    `json:"index"`
+}
+
+type UploadChunk = core.ActionHandler[UploadChunkReq, UploadChunkRes]
+
+func ImplUploadChunk(
+	UploadGetOne gateway.UploadGetOne,
+	UploadChunkSave gateway.UploadChunkSave,
+) UploadChunk {
+	return func(ctx context.Context, req UploadChunkReq) (*UploadChunkRes, error) {
+
+		existing, err := UploadGetOne(ctx, gateway.UploadGetOneReq{UploadID: req.UploadID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.Upload == nil || existing.Upload.Owner != req.Owner {
+			return nil, fmt.Errorf("upload %s not found", req.UploadID)
+		}
+
+		if req.Index < 0 || req.Index >= existing.Upload.TotalChunks {
+			return nil, fmt.Errorf("chunk index %d out of range", req.Index)
+		}
+
+		sum := sha256.Sum256(req.Data)
+		checksums := strings.Split(existing.Upload.Checksums, ",")
+		if hex.EncodeToString(sum[:]) != checksums[req.Index] {
+			return nil, fmt.Errorf("checksum mismatch for chunk %d", req.Index)
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(req.Data, &items); err != nil {
+			return nil, fmt.Errorf("chunk %d must be a JSON array", req.Index)
+		}
+
+		if _, err := UploadChunkSave(ctx, gateway.UploadChunkSaveReq{Chunk: &model.UploadChunk{
+			UploadID:   req.UploadID,
+			ChunkIndex: req.Index,
+			Data:       req.Data,
+		}}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &UploadChunkRes{UploadID: req.UploadID, Index: req.Index}, nil
+	}
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/gateway"
+	"shared/core"
+)
+
+type UploadCompleteReq struct {
+	UploadID      string `json:"id" http:"path"`
+	Authorization string `json:"-" http:"header(Authorization)"` // forwarded so the target checks the access of the uploader
+	Owner         string `json:"-" http:"header(X-Operator)"`
+}
+
+type UploadCompleteRes struct {
+	UploadID       string          `json:"upload_id"`
+	TotalItems     int             `json:"total_items"`
+	TargetResponse json.RawMessage `json:"target_response,omitempty"`
+}
+
+type UploadComplete = core.ActionHandler[UploadCompleteReq, UploadCompleteRes]
+
+// ImplUploadComplete reassembles every chunk into one JSON array and posts it to the target endpoint
+func ImplUploadComplete(
+	UploadGetOne gateway.UploadGetOne,
+	UploadChunkGetAll gateway.UploadChunkGetAll,
+	UploadChunkDelete gateway.UploadChunkDelete,
+	UploadSave gateway.UploadSave,
+	InternalDispatch gateway.InternalDispatch,
+) UploadComplete {
+	return func(ctx context.Context, req UploadCompleteReq) (*UploadCompleteRes, error) {
+
+		existing, err := UploadGetOne(ctx, gateway.UploadGetOneReq{UploadID: req.UploadID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.Upload == nil || existing.Upload.Owner != req.Owner {
+			return nil, fmt.Errorf("upload %s not found", req.UploadID)
+		}
+
+		upload := existing.Upload
+
+		// completing twice is harmless, the client may not have seen the first response
+		if upload.Status == "completed" {
+			return &UploadCompleteRes{UploadID: upload.UploadID, TotalItems: upload.TotalItems}, nil
+		}
+
+		if len(existing.ReceivedChunks) != upload.TotalChunks {
+			return nil, core.NewErrorWithData(
+				fmt.Errorf("upload incomplete, received %d of %d chunks", len(existing.ReceivedChunks), upload.TotalChunks),
+				existing.ReceivedChunks,
+			)
+		}
+
+		chunks, err := UploadChunkGetAll(ctx, gateway.UploadChunkGetAllReq{UploadID: upload.UploadID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		items := make([]json.RawMessage, 0, upload.TotalItems)
+		for _, chunk := range chunks.Chunks {
+			var chunkItems []json.RawMessage
+			if err := json.Unmarshal(chunk.Data, &chunkItems); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+			items = append(items, chunkItems...)
+		}
+
+		body, err := json.Marshal(items)
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		dispatched, err := InternalDispatch(ctx, gateway.InternalDispatchReq{
+			Method:        http.MethodPost,
+			Path:          upload.TargetPath,
+			Body:          body,
+			Authorization: req.Authorization,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		// keep the chunks so completion can be retried when the target rejected the payload
+		if dispatched.StatusCode >= http.StatusMultipleChoices {
+			return nil, fmt.Errorf("target %s responded with status %d: %s", upload.TargetPath, dispatched.StatusCode, dispatched.Body)
+		}
+
+		upload.Status = "completed"
+		if _, err := UploadSave(ctx, gateway.UploadSaveReq{Upload: upload}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if _, err := UploadChunkDelete(ctx, gateway.UploadChunkDeleteReq{UploadID: upload.UploadID}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &UploadCompleteRes{
+			UploadID:       upload.UploadID,
+			TotalItems:     len(items),
+			TargetResponse: dispatched.Body,
+		}, nil
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+	"strings"
+)
+
+type UploadStartReq struct {
+	UploadID    string   `json:"upload_id"`
+	TargetPath  string   `json:"target_path"`
+	TotalChunks int      `json:"total_chunks"`
+	TotalItems  int      `json:"total_items"`
+	Checksums   []string `json:"checksums"`
+	Owner       string   `json:"-" http:"header(X-Operator)"`
+}
+
+type UploadStartRes struct {
+	UploadID       string `json:"upload_id"`
+	Status         string `json:"status"`
+	ReceivedChunks []int  `json:"received_chunks"`
+}
+
+type UploadStart = core.ActionHandler[UploadStartReq, UploadStartRes]
+
+// ImplUploadStart registers the manifest of a chunked upload, or returns the chunks already
+// received when the same upload is started again so the client only re-sends what is missing
+func ImplUploadStart(
+	UploadGetOne gateway.UploadGetOne,
+	UploadSave gateway.UploadSave,
+) UploadStart {
+	return func(ctx context.Context, req UploadStartReq) (*UploadStartRes, error) {
+
+		if req.UploadID == "" {
+			return nil, fmt.Errorf("upload_id is required")
+		}
+
+		if req.Owner == "" {
+			return nil, fmt.Errorf("upload needs an authenticated caller")
+		}
+
+		target, err := url.Parse(req.TargetPath)
+		if err != nil || target.IsAbs() || target.Host != "" || !slices.Contains(model.UploadTargets, target.Path) {
+			return nil, fmt.Errorf("invalid target_path %s", req.TargetPath)
+		}
+
+		if req.TotalChunks <= 0 || len(req.Checksums) != req.TotalChunks {
+			return nil, fmt.Errorf("checksums must contain one entry per chunk")
+		}
+
+		existing, err := UploadGetOne(ctx, gateway.UploadGetOneReq{UploadID: req.UploadID})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		if existing.Upload != nil {
+			if existing.Upload.Owner != req.Owner {
+				return nil, fmt.Errorf("upload %s belongs to another caller", req.UploadID)
+			}
+			return &UploadStartRes{
+				UploadID:       existing.Upload.UploadID,
+				Status:         existing.Upload.Status,
+				ReceivedChunks: existing.ReceivedChunks,
+			}, nil
+		}
+
+		upload := model.Upload{
+			UploadID:    req.UploadID,
+			TargetPath:  req.TargetPath,
+			TotalChunks: req.TotalChunks,
+			TotalItems:  req.TotalItems,
+			Checksums:   strings.Join(req.Checksums, ","),
+			Status:      "receiving",
+			Owner:       req.Owner,
+		}
+
+		if _, err := UploadSave(ctx, gateway.UploadSaveReq{Upload: &upload}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &UploadStartRes{
+			UploadID:       upload.UploadID,
+			Status:         upload.Status,
+			ReceivedChunks: []int{},
+		}, nil
+	}
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+	"strings"
+)
+
+type WebhookCreateReq struct {
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=scan_completed device_discovered"` // scan_completed, device_discovered
+	// Secret signs the payloads, at least 16 characters, a random one is generated when empty
+	Secret      string `json:"secret" validate:"omitempty,min=16"`
+	Description string `json:"description"`
+	Operator    string `json:"-" http:"header(X-Operator)"`
+}
+
+type WebhookCreateRes struct {
+	Webhook model.Webhook `json:"webhook"`
+	// Secret is only returned here, the receiver keeps it to verify X-Webhook-Signature
+	Secret string `json:"secret"`
+}
+
+type WebhookCreate = core.ActionHandler[WebhookCreateReq, WebhookCreateRes]
+
+// ImplWebhookCreate registers a URL notified of the events it subscribes to
+func ImplWebhookCreate(
+	WebhookSave gateway.WebhookSave,
+) WebhookCreate {
+	return func(ctx context.Context, req WebhookCreateReq) (*WebhookCreateRes, error) {
+
+		target, err := url.Parse(strings.TrimSpace(req.URL))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return nil, fmt.Errorf("url must be an absolute http or https URL")
+		}
+
+		if len(req.Events) == 0 {
+			return nil, fmt.Errorf("events is required, one or more of %s", strings.Join(model.WebhookEvents, ", "))
+		}
+		events := make([]string, 0, len(req.Events))
+		for _, event := range req.Events {
+			if !slices.Contains(model.WebhookEvents, event) {
+				return nil, fmt.Errorf("unknown event %q, events are %s", event, strings.Join(model.WebhookEvents, ", "))
+			}
+			if !slices.Contains(events, event) {
+				events = append(events, event)
+			}
+		}
+
+		secret := req.Secret
+		if secret == "" {
+			random := make([]byte, 32)
+			if _, err := rand.Read(random); err != nil {
+				return nil, core.NewInternalServerError(err)
+			}
+			secret = hex.EncodeToString(random)
+		}
+		if len(secret) < 16 {
+			return nil, fmt.Errorf("secret must be at least 16 characters")
+		}
+
+		webhook := model.Webhook{
+			URL:         target.String(),
+			Events:      events,
+			Secret:      secret,
+			Description: req.Description,
+			CreatedBy:   req.Operator,
+		}
+
+		if _, err := WebhookSave(ctx, gateway.WebhookSaveReq{Webhook: &webhook}); err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &WebhookCreateRes{Webhook: webhook, Secret: secret}, nil
+	}
+}
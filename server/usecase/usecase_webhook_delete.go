@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"server/gateway"
+	"shared/core"
+)
+
+type WebhookDeleteReq struct {
+	ID int `json:"id" http:"path"`
+}
+
+type WebhookDeleteRes struct{}
+
+type WebhookDelete = core.ActionHandler[WebhookDeleteReq, WebhookDeleteRes]
+
+// ImplWebhookDelete stops notifying a webhook, its deliveries still queued are dropped as failed
+func ImplWebhookDelete(
+	WebhookDelete gateway.WebhookDelete,
+) WebhookDelete {
+	return func(ctx context.Context, req WebhookDeleteReq) (*WebhookDeleteRes, error) {
+
+		res, err := WebhookDelete(ctx, gateway.WebhookDeleteReq{ID: uint(req.ID)})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+		if !res.Deleted {
+			return nil, fmt.Errorf("webhook %d not found", req.ID)
+		}
+
+		return &WebhookDeleteRes{}, nil
+	}
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"slices"
+)
+
+type WebhookDeliveryGetAllReq struct {
+	ID     int            `json:"id" http:"path"`
+	Status string         `json:"status" http:"query"`
+	List   core.ListQuery `json:"list" http:"list"`
+}
+
+type WebhookDeliveryGetAll = core.ActionHandler[WebhookDeliveryGetAllReq, core.ListPage[model.WebhookDelivery]]
+
+// WebhookDeliveryListFields are the fields the delivery list is sorted and filtered by
+var WebhookDeliveryListFields = slices.Sorted(maps.Keys(gateway.WebhookDeliveryListColumns))
+
+// ImplWebhookDeliveryGetAll lists the deliveries of a webhook with the outcome of their last attempt
+func ImplWebhookDeliveryGetAll(
+	WebhookDeliveryGetAll gateway.WebhookDeliveryGetAll,
+) WebhookDeliveryGetAll {
+	return func(ctx context.Context, req WebhookDeliveryGetAllReq) (*core.ListPage[model.WebhookDelivery], error) {
+
+		switch req.Status {
+		case "", model.WebhookDeliveryPending, model.WebhookDeliveryDelivered, model.WebhookDeliveryFailed:
+		default:
+			return nil, fmt.Errorf("status must be pending, delivered or failed")
+		}
+		list := req.List.Normalize(100, 1000, "-id")
+		if err := list.Validate(WebhookDeliveryListFields...); err != nil {
+			return nil, err
+		}
+
+		res, err := WebhookDeliveryGetAll(ctx, gateway.WebhookDeliveryGetAllReq{
+			WebhookID: uint(req.ID),
+			Status:    req.Status,
+			List:      list,
+		})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &core.ListPage[model.WebhookDelivery]{Items: res.Deliveries, Metadata: list.Metadata(res.Total)}, nil
+	}
+}
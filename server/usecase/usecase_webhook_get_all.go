@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+)
+
+type WebhookGetAllReq struct{}
+
+type WebhookGetAllRes struct {
+	Webhooks []model.Webhook `json:"webhooks"`
+}
+
+type WebhookGetAll = core.ActionHandler[WebhookGetAllReq, WebhookGetAllRes]
+
+func ImplWebhookGetAll(
+	WebhookGetAll gateway.WebhookGetAll,
+) WebhookGetAll {
+	return func(ctx context.Context, req WebhookGetAllReq) (*WebhookGetAllRes, error) {
+
+		res, err := WebhookGetAll(ctx, gateway.WebhookGetAllReq{})
+		if err != nil {
+			return nil, core.NewInternalServerError(err)
+		}
+
+		return &WebhookGetAllRes{Webhooks: res.Webhooks}, nil
+	}
+}
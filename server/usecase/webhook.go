@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"shared/utility"
+	"sync"
+	"time"
+)
+
+// notifyWebhooks queues the event for the webhooks subscribed to it. A failure is only logged because
+// what raised the event is already stored, the caller must not fail or be retried for a notification.
+func notifyWebhooks(ctx context.Context, WebhookEventEnqueue gateway.WebhookEventEnqueue, IDGenerator core.IDGenerator, event string, data any, now time.Time) {
+	notifyWebhooksAll(ctx, WebhookEventEnqueue, IDGenerator, event, []any{data}, now)
+}
+
+// notifyWebhooksAll is notifyWebhooks for many occurrences of the same event, queued with one read of the webhooks
+func notifyWebhooksAll(ctx context.Context, WebhookEventEnqueue gateway.WebhookEventEnqueue, IDGenerator core.IDGenerator, event string, data []any, now time.Time) {
+	if len(data) == 0 {
+		return
+	}
+	if now.IsZero() {
+		now = core.GetClockFromContext(ctx).Now()
+	}
+	events := make([]gateway.WebhookEvent, 0, len(data))
+	for _, item := range data {
+		events = append(events, gateway.WebhookEvent{EventID: IDGenerator.NewID(), Data: item})
+	}
+	if _, err := WebhookEventEnqueue(ctx, gateway.WebhookEventEnqueueReq{
+		Event:  event,
+		Events: events,
+		Now:    now,
+	}); err != nil {
+		utility.Errorf("Failed to queue %d webhook events %s: %v", len(events), event, err)
+	}
+}
+
+// WebhookDispatcherConfig controls how queued deliveries are posted and retried
+type WebhookDispatcherConfig struct {
+	PollInterval time.Duration // between two looks for due deliveries, defaults to 2 seconds
+	BatchSize    int           // due deliveries claimed at once, defaults to 50
+	Workers      int           // deliveries posted at once, defaults to 4
+	// ClaimLease is how long a claimed delivery is kept from the other instances, a claim of an
+	// instance that died is taken again once it ends. Defaults to 5 minutes.
+	ClaimLease time.Duration
+	// MaxAttempts is the number of attempts before a delivery fails for good, defaults to 8
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled on every attempt up to MaxBackoff,
+	// defaults to 10 seconds and 1 hour: 8 attempts span about 20 minutes
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// WebhookDispatcher posts the deliveries queued by notifyWebhooks. A delivery is done on a 2xx answer,
+// any other answer or no answer schedules a retry with exponential backoff until MaxAttempts.
+// Every instance runs one, a claim keeps a delivery to the instance that took it.
+type WebhookDispatcher struct {
+	config      WebhookDispatcherConfig
+	claimDue    gateway.WebhookDeliveryClaimDue
+	save        gateway.WebhookDeliverySave
+	post        gateway.WebhookPost
+	idGenerator core.IDGenerator
+	clock       core.Clock // times the polls, the attempts and their backoff
+	mu          sync.Mutex // one pass at a time within the instance, the claim covers the others
+}
+
+func NewWebhookDispatcher(
+	WebhookDeliveryClaimDue gateway.WebhookDeliveryClaimDue,
+	WebhookDeliverySave gateway.WebhookDeliverySave,
+	WebhookPost gateway.WebhookPost,
+	IDGenerator core.IDGenerator,
+	config WebhookDispatcherConfig,
+	clock core.Clock,
+) *WebhookDispatcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.ClaimLease <= 0 {
+		config.ClaimLease = 5 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 10 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+
+	return &WebhookDispatcher{
+		config:      config,
+		claimDue:    WebhookDeliveryClaimDue,
+		save:        WebhookDeliverySave,
+		post:        WebhookPost,
+		idGenerator: IDGenerator,
+		clock:       clock,
+	}
+}
+
+// Run posts the due deliveries every poll interval until ctx ends, a delivery cut by the end stays pending
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := d.clock.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx, d.clock.Now()); err != nil && ctx.Err() == nil {
+			utility.Errorf("Webhook dispatcher: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Dispatch posts every delivery due at now, a full batch is followed by the next one, and returns how many were attempted.
+// A batch whose outcome could not be stored ends the pass, its deliveries come back when the claim lease ends.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	attempted := 0
+	for ctx.Err() == nil {
+		due, err := d.claimDue(ctx, gateway.WebhookDeliveryClaimDueReq{
+			Now:   now,
+			Limit: d.config.BatchSize,
+			Token: d.idGenerator.NewID(),
+			Lease: d.config.ClaimLease,
+		})
+		if err != nil {
+			return attempted, err
+		}
+
+		deliveries := make(chan *model.WebhookDelivery)
+		var wg sync.WaitGroup
+		var errMu sync.Mutex
+		var errs []error
+		for range min(d.config.Workers, len(due.Deliveries)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for delivery := range deliveries {
+					if err := d.deliver(ctx, delivery); err != nil {
+						errMu.Lock()
+						errs = append(errs, err)
+						errMu.Unlock()
+					}
+				}
+			}()
+		}
+		for i := range due.Deliveries {
+			deliveries <- &due.Deliveries[i]
+		}
+		close(deliveries)
+		wg.Wait()
+
+		attempted += len(due.Deliveries)
+		if len(errs) > 0 {
+			return attempted, errors.Join(errs...)
+		}
+		if len(due.Deliveries) < d.config.BatchSize {
+			break
+		}
+	}
+	return attempted, nil
+}
+
+// deliver makes one attempt and stores its outcome, the error is a failure to store it
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	now := d.clock.Now()
+
+	if delivery.Webhook == nil {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = "the webhook was removed"
+	} else {
+		res, err := d.post(ctx, gateway.WebhookPostReq{
+			URL:        delivery.Webhook.URL,
+			Secret:     delivery.Webhook.Secret,
+			Event:      delivery.Event,
+			EventID:    delivery.EventID,
+			DeliveryID: delivery.ID,
+			Payload:    []byte(delivery.Payload),
+			Now:        now,
+		})
+		if ctx.Err() != nil {
+			// stopped by the shutdown rather than by the receiver, the attempt is not counted and the
+			// delivery is due again when the claim lease ends
+			return nil
+		}
+
+		delivery.Attempts++
+		delivery.LastStatus = 0
+		switch {
+		case err != nil:
+			delivery.LastError = err.Error()
+		case res.StatusCode < 200 || res.StatusCode > 299:
+			delivery.LastStatus = res.StatusCode
+			delivery.LastError = fmt.Sprintf("the receiver answered %d", res.StatusCode)
+		default:
+			delivery.LastStatus = res.StatusCode
+			delivery.LastError = ""
+			delivery.Status = model.WebhookDeliveryDelivered
+			delivery.DeliveredAt = &now
+		}
+
+		if delivery.Status == model.WebhookDeliveryPending {
+			if delivery.Attempts >= d.config.MaxAttempts {
+				delivery.Status = model.WebhookDeliveryFailed
+				utility.Warnf("Webhook %d gave up on delivery %d of %s after %d attempts: %s",
+					delivery.WebhookID, delivery.ID, delivery.Event, delivery.Attempts, delivery.LastError)
+			} else {
+				delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+			}
+		}
+	}
+
+	// a pending delivery keeps no claim so the next pass of any instance can take it
+	delivery.ClaimToken = ""
+
+	// the attempt is made, posting it again because its outcome was lost is what the retries avoid
+	var err error
+	for attempt := range webhookSaveAttempts {
+		if attempt > 0 {
+			<-d.clock.After(time.Duration(attempt) * 200 * time.Millisecond)
+		}
+		if _, err = d.save(context.WithoutCancel(ctx), gateway.WebhookDeliverySaveReq{Delivery: delivery}); err == nil {
+			return nil
+		}
+	}
+	utility.Errorf("Failed to store webhook delivery %d after %d attempts: %v", delivery.ID, webhookSaveAttempts, err)
+	return fmt.Errorf("store webhook delivery %d: %w", delivery.ID, err)
+}
+
+// webhookSaveAttempts is how many times the outcome of an attempt is stored before the pass gives up
+const webhookSaveAttempts = 3
+
+// backoff is the wait after the given number of failed attempts
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := d.config.Backoff
+	for i := 1; i < attempts && wait < d.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.config.MaxBackoff)
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"server/gateway"
+	"server/model"
+	"shared/core"
+	"testing"
+	"time"
+)
+
+func TestWebhookDispatcherBacksOffOnTheClock(t *testing.T) {
+	ctx := context.Background()
+	clock := core.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	delivery := model.WebhookDelivery{ID: 1, Event: model.WebhookEventScanCompleted, Status: model.WebhookDeliveryPending, Webhook: &model.Webhook{URL: "http://receiver.test"}}
+	claimDue := func(ctx context.Context, req gateway.WebhookDeliveryClaimDueReq) (*gateway.WebhookDeliveryClaimDueRes, error) {
+		if delivery.Status != model.WebhookDeliveryPending || delivery.NextAttemptAt.After(req.Now) {
+			return &gateway.WebhookDeliveryClaimDueRes{}, nil
+		}
+		return &gateway.WebhookDeliveryClaimDueRes{Deliveries: []model.WebhookDelivery{delivery}}, nil
+	}
+	save := func(ctx context.Context, req gateway.WebhookDeliverySaveReq) (*gateway.WebhookDeliverySaveRes, error) {
+		delivery = *req.Delivery
+		return &gateway.WebhookDeliverySaveRes{}, nil
+	}
+	post := func(ctx context.Context, req gateway.WebhookPostReq) (*gateway.WebhookPostRes, error) {
+		return &gateway.WebhookPostRes{StatusCode: http.StatusServiceUnavailable}, nil
+	}
+
+	dispatcher := NewWebhookDispatcher(claimDue, save, post, core.NewULIDGenerator(clock), WebhookDispatcherConfig{
+		MaxAttempts: 4,
+		Backoff:     10 * time.Second,
+		MaxBackoff:  25 * time.Second,
+	}, clock)
+
+	dispatch := func() int {
+		t.Helper()
+		attempted, err := dispatcher.Dispatch(ctx, clock.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return attempted
+	}
+
+	// the wait doubles from the backoff and stops at the max backoff
+	for attempt, wait := range []time.Duration{10 * time.Second, 20 * time.Second, 25 * time.Second} {
+		if n := dispatch(); n != 1 {
+			t.Fatalf("attempt %d: got %d deliveries attempted, want 1", attempt+1, n)
+		}
+		if want := clock.Now().Add(wait); !delivery.NextAttemptAt.Equal(want) {
+			t.Fatalf("attempt %d: next attempt at %s, want %s", attempt+1, delivery.NextAttemptAt, want)
+		}
+
+		clock.Advance(wait - time.Second)
+		if n := dispatch(); n != 0 {
+			t.Fatalf("attempt %d: retried %d deliveries before the backoff passed", attempt+1, n)
+		}
+		clock.Advance(time.Second)
+	}
+
+	if n := dispatch(); n != 1 || delivery.Status != model.WebhookDeliveryFailed {
+		t.Fatalf("last attempt: got %d attempted and status %s, want 1 and %s", n, delivery.Status, model.WebhookDeliveryFailed)
+	}
+}
@@ -2,9 +2,14 @@ package utility
 
 import (
 	"context"
+	"fmt"
+	"net/netip"
 	"shared/core"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const GormDBKey core.ContextKey = "GORM_DB"
@@ -16,3 +21,81 @@ func GetDBFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
 	}
 	return dbCtx
 }
+
+// ListPage counts the rows of query matching the filters of list and finds its page in its order into
+// dest. columns maps the fields the endpoint offers to their column, a field that is not there is
+// refused so a caller cannot put arbitrary SQL in the order or the conditions.
+func ListPage(query *gorm.DB, list core.ListQuery, columns map[string]string, dest any) (int64, error) {
+	for field, value := range list.Filters {
+		column, ok := columns[field]
+		if !ok {
+			return 0, fmt.Errorf("unknown list field %s", field)
+		}
+		query = query.Where(column+" = ?", value)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	for _, sort := range list.SortFields() {
+		column, ok := columns[sort.Field]
+		if !ok {
+			return 0, fmt.Errorf("unknown list field %s", sort.Field)
+		}
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: sort.Desc})
+	}
+	// the primary key last keeps the pages stable when the sorted values are equal
+	query = query.Order("id")
+
+	if list.Size > 0 {
+		query = query.Limit(list.Size).Offset(list.Offset())
+	}
+	if err := query.Find(dest).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// WhereIPv4InPrefix keeps the rows whose column, an IPv4 address in dotted form, is inside prefix. The
+// addresses are stored as text, so a prefix up to /24 becomes LIKE patterns on its leading octets and a
+// longer one the list of its addresses. An invalid or IPv6 prefix matches no row.
+func WhereIPv4InPrefix(query *gorm.DB, column string, prefix netip.Prefix) *gorm.DB {
+	if !prefix.IsValid() || !prefix.Addr().Is4() {
+		return query.Where("1 = 0")
+	}
+	prefix = prefix.Masked()
+
+	bits := prefix.Bits()
+	if bits == 0 {
+		return query
+	}
+	if bits > 24 {
+		var ips []string
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			ips = append(ips, addr.String())
+		}
+		return query.Where(column+" IN ?", ips)
+	}
+
+	// a /20 spans 16 values of its third octet, each one pattern "a.b.c.%"
+	octets := (bits + 7) / 8
+	base := prefix.Addr().As4()
+	count := 1 << (octets*8 - bits)
+
+	conditions := make([]string, 0, count)
+	patterns := make([]any, 0, count)
+	for i := range count {
+		address := base
+		address[octets-1] += byte(i)
+
+		parts := make([]string, octets)
+		for j := range parts {
+			parts[j] = strconv.Itoa(int(address[j]))
+		}
+		conditions = append(conditions, column+" LIKE ?")
+		patterns = append(patterns, strings.Join(parts, ".")+".%")
+	}
+	return query.Where("("+strings.Join(conditions, " OR ")+")", patterns...)
+}
@@ -0,0 +1,199 @@
+package utility
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PDFDocument lays text out on A4 pages with the standard Helvetica and Courier fonts, which every PDF
+// reader has, so a printable report needs no font files and no PDF library. Text is limited to Latin-1,
+// other characters print as '?'.
+type PDFDocument struct {
+	pages [][]byte // content stream of every page
+	page  bytes.Buffer
+	y     float64 // baseline of the next line, from the bottom of the page
+}
+
+// A4 in points and the margin kept on every side
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+)
+
+// the fonts of the document, the names are the resource names of every page
+const (
+	pdfFontRegular = "F1" // Helvetica
+	pdfFontBold    = "F2" // Helvetica-Bold
+	pdfFontMono    = "F3" // Courier, tables are aligned with it
+)
+
+func NewPDFDocument() *PDFDocument {
+	return &PDFDocument{y: pdfPageHeight - pdfMargin}
+}
+
+// Title writes a large bold line
+func (d *PDFDocument) Title(text string) {
+	d.line(pdfFontBold, 16, 24, text)
+}
+
+// Heading writes a bold line with some space above it
+func (d *PDFDocument) Heading(text string) {
+	d.y -= 8
+	d.line(pdfFontBold, 12, 18, text)
+}
+
+// Text writes a paragraph, wrapped at the page width
+func (d *PDFDocument) Text(text string) {
+	// Helvetica averages about half the font size per character
+	for _, line := range pdfWrap(text, int((pdfPageWidth-2*pdfMargin)/5)) {
+		d.line(pdfFontRegular, 10, 14, line)
+	}
+}
+
+// Table writes rows in Courier with every column as wide as its widest cell, cells too long for the
+// page are cut. The header is repeated at the top of every page the table runs onto.
+func (d *PDFDocument) Table(header []string, rows [][]string) {
+	size, leading := 8.0, 11.0
+	maxChars := int((pdfPageWidth - 2*pdfMargin) / (0.6 * size)) // Courier is 0.6 em wide
+
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = len(cell)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], min(len(cell), 40))
+			}
+		}
+	}
+
+	format := func(cells []string) string {
+		var b strings.Builder
+		for i, width := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			if len(cell) > width {
+				cell = cell[:max(width-1, 0)] + "~"
+			}
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", width-len(cell)+2))
+		}
+		line := strings.TrimRight(b.String(), " ")
+		if len(line) > maxChars {
+			line = line[:maxChars]
+		}
+		return line
+	}
+
+	headerLine := format(header)
+	d.line(pdfFontMono, size, leading, headerLine)
+	for _, row := range rows {
+		if d.y-leading < pdfMargin {
+			d.newPage()
+			d.line(pdfFontMono, size, leading, headerLine)
+		}
+		d.line(pdfFontMono, size, leading, format(row))
+	}
+}
+
+// line writes one line, starting a page when the current one is full
+func (d *PDFDocument) line(font string, size, leading float64, text string) {
+	if d.y-leading < pdfMargin {
+		d.newPage()
+	}
+	d.y -= leading
+	fmt.Fprintf(&d.page, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, pdfMargin, d.y, pdfEscape(text))
+}
+
+func (d *PDFDocument) newPage() {
+	d.pages = append(d.pages, bytes.Clone(d.page.Bytes()))
+	d.page.Reset()
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// WriteTo writes the document, it can be called once all the content is laid out
+func (d *PDFDocument) WriteTo(w io.Writer) (int64, error) {
+	pages := append(d.pages, d.page.Bytes())
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// 1 catalog, 2 page tree, 3 to 5 fonts, then a page and its content per page
+	const firstPage = 6
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	for _, font := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+	}
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R /%s 5 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, pdfFontRegular, pdfFontBold, pdfFontMono, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// pdfEscape makes text a PDF literal string in WinAnsiEncoding
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfWrap breaks text into lines of at most width characters at spaces
+func pdfWrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,55 @@
+package utility
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// URLSigner creates and verifies time limited signed URLs
+type URLSigner struct {
+	secretKey []byte
+}
+
+func NewURLSigner(secretKey string) *URLSigner {
+	return &URLSigner{secretKey: []byte(secretKey)}
+}
+
+func (s URLSigner) signature(path string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secretKey)
+	fmt.Fprintf(mac, "%s:%d", path, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign returns path with expires and signature query params appended
+func (s URLSigner) Sign(path string, now time.Time, ttl time.Duration) string {
+	expires := now.Add(ttl).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.signature(path, expires))
+
+	return path + "?" + query.Encode()
+}
+
+// Verify checks that the signature matches the path and has not expired
+func (s URLSigner) Verify(path, expires, signature string, now time.Time) error {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires value")
+	}
+
+	if now.Unix() > expiresUnix {
+		return fmt.Errorf("signed url has expired")
+	}
+
+	if !hmac.Equal([]byte(s.signature(path, expiresUnix)), []byte(signature)) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
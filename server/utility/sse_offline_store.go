@@ -0,0 +1,84 @@
+package utility
+
+import (
+	"context"
+	"encoding/json"
+	"server/model"
+	"shared/core"
+	sharedUtility "shared/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GormOfflineStore keeps messages for offline SSE clients in the database
+type GormOfflineStore struct {
+	db     *gorm.DB
+	maxAge time.Duration
+	clock  core.Clock
+}
+
+// NewGormOfflineStore creates the store, messages older than maxAge are dropped instead of delivered.
+// The age is measured on clock, which also stamps the stored messages.
+func NewGormOfflineStore(db *gorm.DB, maxAge time.Duration, clock core.Clock) *GormOfflineStore {
+	return &GormOfflineStore{db: db, maxAge: maxAge, clock: clock}
+}
+
+func (s *GormOfflineStore) Save(ctx context.Context, clientID string, msg sharedUtility.Message) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	return GetDBFromContext(ctx, s.db).Create(&model.SSEPendingMessage{
+		Model:       gorm.Model{CreatedAt: s.clock.Now()},
+		ClientID:    clientID,
+		MessageID:   msg.ID,
+		EventType:   msg.EventType,
+		Data:        data,
+		Requires:    msg.Requires,
+		Traceparent: msg.Trace,
+	}).Error
+}
+
+func (s *GormOfflineStore) Pending(ctx context.Context, clientID string) ([]sharedUtility.StoredMessage, error) {
+	db := GetDBFromContext(ctx, s.db)
+
+	// expired messages are stale commands, dropping them is safer than running them late
+	if s.maxAge > 0 {
+		if err := db.Unscoped().
+			Where("client_id = ? AND created_at < ?", clientID, s.clock.Now().Add(-s.maxAge)).
+			Delete(&model.SSEPendingMessage{}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []model.SSEPendingMessage
+	if err := db.Where("client_id = ?", clientID).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	pending := make([]sharedUtility.StoredMessage, 0, len(rows))
+	for _, row := range rows {
+		pending = append(pending, sharedUtility.StoredMessage{
+			ID:       row.ID,
+			ClientID: row.ClientID,
+			StoredAt: row.CreatedAt,
+			Message: sharedUtility.Message{
+				ID:        row.MessageID,
+				EventType: row.EventType,
+				Data:      row.Data,
+				Requires:  row.Requires,
+				Trace:     row.Traceparent,
+			},
+		})
+	}
+	return pending, nil
+}
+
+func (s *GormOfflineStore) Delete(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return GetDBFromContext(ctx, s.db).Unscoped().Delete(&model.SSEPendingMessage{}, ids).Error
+}
@@ -0,0 +1,58 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newAlertModule)
+}
+
+// alertModule owns the alerts table, the scan module raises alerts into it while ingesting results
+type alertModule struct {
+	module.BaseModule
+	alertGetAll usecase.AlertGetAll
+}
+
+func newAlertModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	alertGetAllGw := gateway.ImplAlertGetAllWithSQlite(deps.DB)
+
+	// use cases
+	return &alertModule{
+		alertGetAll: usecase.ImplAlertGetAll(alertGetAllGw),
+	}
+}
+
+func (m *alertModule) Name() string { return "alert" }
+
+func (m *alertModule) Migrations() []any {
+	return []any{&model.Alert{}}
+}
+
+func (m *alertModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.AlertGetAllHandler(m.alertGetAll),
+	)
+}
+
+func (m *alertModule) RegisterEvents(catalog *utility.EventCatalog) {
+	catalog.Add(utility.EventSpec{
+		Type:      "alert_raised",
+		Direction: utility.EventToClient,
+		Summary:   "A scan result analyzer found an anomaly, broadcast to all clients",
+		Payload:   model.Alert{},
+	})
+}
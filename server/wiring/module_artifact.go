@@ -0,0 +1,68 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"server/utility"
+	sharedUtility "shared/utility"
+	"time"
+)
+
+func init() {
+	module.Register(newArtifactModule)
+}
+
+type artifactModule struct {
+	module.BaseModule
+	artifactUpload      usecase.ArtifactUpload
+	artifactGetAll      usecase.ArtifactGetAll
+	artifactDownloadURL usecase.ArtifactDownloadURL
+	artifactDownload    usecase.ArtifactDownload
+}
+
+func newArtifactModule(deps module.Dependency) module.ServerModule {
+
+	// TODO put into env
+	artifactDir := "artifacts"
+	urlSigner := utility.NewURLSigner(deps.ArtifactURLSecret)
+	downloadURLTTL := 15 * time.Minute
+
+	// gateways
+	artifactSaveGw := gateway.ImplArtifactSaveWithSQlite(deps.DB)
+	artifactGetAllGw := gateway.ImplArtifactGetAllWithSQlite(deps.DB)
+	artifactGetOneGw := gateway.ImplArtifactGetOneWithSQlite(deps.DB)
+	artifactStoreFileGw := gateway.ImplArtifactStoreFileOnDisk(artifactDir)
+	artifactOpenFileGw := gateway.ImplArtifactOpenFileOnDisk()
+
+	// use cases
+	return &artifactModule{
+		artifactUpload:      usecase.ImplArtifactUpload(artifactStoreFileGw, artifactSaveGw),
+		artifactGetAll:      usecase.ImplArtifactGetAll(artifactGetAllGw),
+		artifactDownloadURL: usecase.ImplArtifactDownloadURL(artifactGetOneGw, urlSigner, downloadURLTTL),
+		artifactDownload:    usecase.ImplArtifactDownload(artifactGetOneGw, artifactOpenFileGw, urlSigner),
+	}
+}
+
+func (m *artifactModule) Name() string { return "artifact" }
+
+func (m *artifactModule) Migrations() []any {
+	return []any{&model.Artifact{}}
+}
+
+func (m *artifactModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *sharedUtility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.ArtifactUploadHandler(m.artifactUpload),
+		c.ArtifactGetAllHandler(m.artifactGetAll),
+		c.ArtifactDownloadURLHandler(m.artifactDownloadURL),
+		c.ArtifactDownloadHandler(m.artifactDownload),
+	)
+}
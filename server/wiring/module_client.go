@@ -0,0 +1,191 @@
+package wiring
+
+import (
+	"context"
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+	"time"
+)
+
+func init() {
+	module.Register(newClientModule)
+}
+
+type clientModule struct {
+	module.BaseModule
+	clientGetAll              usecase.ClientGetAll
+	clientRegister            usecase.ClientRegister
+	clientHeartbeat           usecase.ClientHeartbeat
+	clientCapabilitiesRecord  usecase.ClientCapabilitiesRecord
+	clientThrottleReport      usecase.ClientThrottleReport
+	clientThrottleEventGetAll usecase.ClientThrottleEventGetAll
+	clientResourceLimitsSet   usecase.ClientResourceLimitsSet
+	clientScanScheduleSet     usecase.ClientScanScheduleSet
+	clientControl             usecase.ClientControl
+	clientSecretSet           usecase.ClientSecretSet
+	clientSecretDelete        usecase.ClientSecretDelete
+	clientGroupsSet           usecase.ClientGroupsSet
+	clientGroupsRestore       usecase.ClientGroupsRestore
+	clientDuplicateRemove     usecase.ClientDuplicateRemove
+	// announced are the connects waiting for their capabilities to be stored, see RegisterWorkers
+	announced chan usecase.ClientCapabilitiesRecordReq
+}
+
+func newClientModule(deps module.Dependency) module.ServerModule {
+
+	// TODO put into env
+	heartbeatInterval := 30 * time.Second
+	announcedQueue := 1024
+
+	// gateways
+	sendSSEMessageGw := gateway.ImplSendSSEMessage(deps.SSEServer)
+	sendResourceLimitsGw := gateway.ImplSendCommand(sendSSEMessageGw, command.SetResourceLimits)
+	sendScheduleUpdateGw := gateway.ImplSendCommand(sendSSEMessageGw, command.UpdateSchedule)
+	sendAgentRestartGw := gateway.ImplSendCommand(sendSSEMessageGw, command.AgentRestart)
+	sendAgentShutdownGw := gateway.ImplSendCommand(sendSSEMessageGw, command.AgentShutdown)
+	sendSecretSetGw := gateway.ImplSendCommand(sendSSEMessageGw, command.SetSecret)
+	sendSecretDeleteGw := gateway.ImplSendCommand(sendSSEMessageGw, command.DeleteSecret)
+	waitSSEAckGw := gateway.ImplWaitSSEAck(deps.SSEServer)
+	sseConnectedClientGetAllGw := gateway.ImplSSEConnectedClientGetAll(deps.SSEServer)
+	clientGetAllGw := gateway.ImplClientGetAllWithSQlite(deps.DB)
+	clientRegisterGw := gateway.ImplClientRegisterWithSQlite(deps.DB)
+	clientHeartbeatGw := gateway.ImplClientHeartbeatWithSQlite(deps.DB)
+	clientCapabilitiesSaveGw := gateway.ImplClientCapabilitiesSaveWithSQlite(deps.DB)
+	clientThrottleEventSaveGw := gateway.ImplClientThrottleEventSaveWithSQlite(deps.DB)
+	clientThrottleEventGetAllGw := gateway.ImplClientThrottleEventGetAllWithSQlite(deps.DB)
+	clientGroupsSaveGw := gateway.ImplClientGroupsSaveWithSQlite(deps.DB)
+	sseClientGroupsSetGw := gateway.ImplSSEClientGroupsSet(deps.SSEServer)
+	clientDuplicateDeleteGw := gateway.ImplClientDuplicateDeleteWithSQlite(deps.DB)
+
+	// use cases
+	return &clientModule{
+		clientGetAll:              usecase.ImplClientGetAll(clientGetAllGw, sseConnectedClientGetAllGw),
+		clientRegister:            usecase.ImplClientRegister(clientRegisterGw, heartbeatInterval),
+		clientHeartbeat:           usecase.ImplClientHeartbeat(clientHeartbeatGw, heartbeatInterval),
+		clientCapabilitiesRecord:  usecase.ImplClientCapabilitiesRecord(clientCapabilitiesSaveGw),
+		clientThrottleReport:      usecase.ImplClientThrottleReport(clientThrottleEventSaveGw),
+		clientThrottleEventGetAll: usecase.ImplClientThrottleEventGetAll(clientThrottleEventGetAllGw),
+		clientResourceLimitsSet:   usecase.ImplClientResourceLimitsSet(sendResourceLimitsGw),
+		clientScanScheduleSet:     usecase.ImplClientScanScheduleSet(sendScheduleUpdateGw),
+		clientControl:             usecase.ImplClientControl(sendAgentRestartGw, sendAgentShutdownGw, waitSSEAckGw, sseConnectedClientGetAllGw),
+		clientSecretSet:           usecase.ImplClientSecretSet(sendSecretSetGw, waitSSEAckGw, sseConnectedClientGetAllGw),
+		clientSecretDelete:        usecase.ImplClientSecretDelete(sendSecretDeleteGw, waitSSEAckGw, sseConnectedClientGetAllGw),
+		clientGroupsSet:           usecase.ImplClientGroupsSet(clientGroupsSaveGw, sseClientGroupsSetGw),
+		clientGroupsRestore:       usecase.ImplClientGroupsRestore(clientGetAllGw, sseClientGroupsSetGw),
+		clientDuplicateRemove:     usecase.ImplClientDuplicateRemove(clientDuplicateDeleteGw),
+		announced:                 make(chan usecase.ClientCapabilitiesRecordReq, announcedQueue),
+	}
+}
+
+func (m *clientModule) Name() string { return "client" }
+
+// Premigrate removes the duplicate agents of databases created before client_id was unique, the unique
+// index could not be created on them
+func (m *clientModule) Premigrate(ctx context.Context) error {
+	res, err := m.clientDuplicateRemove(ctx, usecase.ClientDuplicateRemoveReq{})
+	if err != nil {
+		return err
+	}
+	if res.Removed > 0 {
+		utility.Infof("Removed %d duplicate client row(s) before migrating", res.Removed)
+	}
+	return nil
+}
+
+func (m *clientModule) Migrations() []any {
+	return []any{&model.Client{}, &model.ClientThrottleEvent{}}
+}
+
+func (m *clientModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.ClientGetAllHandler(m.clientGetAll),
+		c.ClientRegisterHandler(m.clientRegister),
+		c.ClientHeartbeatHandler(m.clientHeartbeat),
+		c.ClientThrottleReportHandler(m.clientThrottleReport),
+		c.ClientThrottleEventGetAllHandler(m.clientThrottleEventGetAll),
+		c.ClientResourceLimitsSetHandler(m.clientResourceLimitsSet),
+		c.ClientScanScheduleSetHandler(m.clientScanScheduleSet),
+		c.ClientRestartHandler(m.clientControl),
+		c.ClientShutdownHandler(m.clientControl),
+		c.ClientSecretSetHandler(m.clientSecretSet),
+		c.ClientSecretDeleteHandler(m.clientSecretDelete),
+		c.ClientGroupsSetHandler(m.clientGroupsSet),
+	)
+}
+
+// RegisterEventHandlers queues the capabilities every agent announces when it connects, the hook runs
+// before the stream starts so the database is left to the worker. Agents released before schedule_update
+// was renamed keep receiving it under its former name.
+func (m *clientModule) RegisterEventHandlers(sseServer *utility.SSEServer) {
+	sseServer.AliasEvent(command.UpdateSchedule.Event, command.UpdateSchedule.Aliases...)
+
+	sseServer.OnConnect(func(clientID string, meta utility.ClientMeta) {
+		select {
+		case m.announced <- usecase.ClientCapabilitiesRecordReq{ClientID: clientID, Meta: meta}:
+		default:
+			utility.Warnf("Capabilities of %s not recorded, %d connects are already waiting", clientID, cap(m.announced))
+		}
+	})
+}
+
+// RegisterWorkers restores the stored group memberships, then records the queued capabilities in the
+// order the agents connected. Each connect also reloads the groups of the agent, an operator may have
+// changed them through another instance.
+func (m *clientModule) RegisterWorkers(lifecycle *utility.Lifecycle) {
+
+	ctx, stop := context.WithCancel(context.Background())
+	// a group send right after startup reaches the members that are not connected yet
+	if _, err := m.clientGroupsRestore(ctx, usecase.ClientGroupsRestoreReq{}); err != nil {
+		utility.Errorf("Failed to restore the client groups: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case req := <-m.announced:
+				if _, err := m.clientCapabilitiesRecord(ctx, req); err != nil && ctx.Err() == nil {
+					utility.Errorf("Failed to record the capabilities of %s: %v", req.ClientID, err)
+				}
+				if _, err := m.clientGroupsRestore(ctx, usecase.ClientGroupsRestoreReq{ClientIDs: []string{req.ClientID}}); err != nil && ctx.Err() == nil {
+					utility.Errorf("Failed to restore the groups of %s: %v", req.ClientID, err)
+				}
+			}
+		}
+	}()
+
+	lifecycle.Register("client-capabilities", func(ctx context.Context) error {
+		stop()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func (m *clientModule) RegisterEvents(catalog *utility.EventCatalog) {
+	catalog.
+		Add(command.SetResourceLimits.Spec()).
+		Add(command.UpdateSchedule.Spec()).
+		Add(command.AgentRestart.Spec()).
+		Add(command.AgentShutdown.Spec()).
+		Add(command.SetSecret.Spec()).
+		Add(command.DeleteSecret.Spec())
+}
@@ -0,0 +1,34 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/module"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newDashboardModule)
+}
+
+// dashboardModule serves the live dashboard page, the data comes from the routes of the other modules
+type dashboardModule struct {
+	module.BaseModule
+}
+
+func newDashboardModule(deps module.Dependency) module.ServerModule {
+	return &dashboardModule{}
+}
+
+func (m *dashboardModule) Name() string { return "dashboard" }
+
+func (m *dashboardModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.DashboardHandler(),
+	)
+}
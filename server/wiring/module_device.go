@@ -0,0 +1,65 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newDeviceModule)
+}
+
+// deviceModule owns the device inventory, the scan module folds every stored result into it
+type deviceModule struct {
+	module.BaseModule
+	deviceGetAll usecase.DeviceGetAll
+	deviceGetOne usecase.DeviceGetOne
+	deviceUpdate usecase.DeviceUpdate
+	deviceDelete usecase.DeviceDelete
+	deviceExport usecase.DeviceExport
+}
+
+func newDeviceModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	deviceGetAllGw := gateway.ImplDeviceGetAllWithSQlite(deps.DB)
+	deviceGetOneGw := gateway.ImplDeviceGetOneWithSQlite(deps.DB)
+	deviceSaveGw := gateway.ImplDeviceSaveWithSQlite(deps.DB)
+	deviceDeleteGw := gateway.ImplDeviceDeleteWithSQlite(deps.DB)
+	deviceEachGw := gateway.ImplDeviceEachWithSQlite(deps.DB)
+
+	// use cases
+	return &deviceModule{
+		deviceGetAll: usecase.ImplDeviceGetAll(deviceGetAllGw),
+		deviceGetOne: usecase.ImplDeviceGetOne(deviceGetOneGw),
+		deviceUpdate: usecase.ImplDeviceUpdate(deviceGetOneGw, deviceSaveGw),
+		deviceDelete: usecase.ImplDeviceDelete(deviceDeleteGw),
+		deviceExport: usecase.ImplDeviceExport(deviceEachGw),
+	}
+}
+
+func (m *deviceModule) Name() string { return "device" }
+
+func (m *deviceModule) Migrations() []any {
+	return []any{&model.Device{}}
+}
+
+func (m *deviceModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.DeviceGetAllHandler(m.deviceGetAll),
+		c.DeviceGetOneHandler(m.deviceGetOne),
+		c.DeviceUpdateHandler(m.deviceUpdate),
+		c.DeviceDeleteHandler(m.deviceDelete),
+		c.DeviceExportHandler(m.deviceExport),
+	)
+}
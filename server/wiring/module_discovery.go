@@ -0,0 +1,73 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newDiscoveryModule)
+}
+
+// discoveryModule keeps the services agents hear announced over mDNS and SSDP, next to the scan results
+type discoveryModule struct {
+	module.BaseModule
+	discoverServicesTrigger usecase.DiscoverServicesTrigger
+	serviceDiscoveredReport usecase.ServiceDiscoveredReport
+	discoveredServiceGetAll usecase.DiscoveredServiceGetAll
+}
+
+func newDiscoveryModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	sendDiscoverServicesGw := gateway.ImplSendCommand(gateway.ImplSendSSEMessage(deps.SSEServer), command.DiscoverServices)
+	waitSSEAckGw := gateway.ImplWaitSSEAck(deps.SSEServer)
+	publishDashboardGw := gateway.ImplSendSSEMessage(deps.DashboardSSE)
+	clientGetOneGw := gateway.ImplClientGetOneWithSQlite(deps.DB)
+	clientGetAllGw := gateway.ImplClientGetAllWithSQlite(deps.DB)
+	discoveredServiceSaveGw := gateway.ImplDiscoveredServiceSaveWithSQlite(deps.DB)
+	discoveredServiceGetAllGw := gateway.ImplDiscoveredServiceGetAllWithSQlite(deps.DB)
+
+	// use cases
+	return &discoveryModule{
+		discoverServicesTrigger: usecase.ImplDiscoverServicesTrigger(sendDiscoverServicesGw, waitSSEAckGw, clientGetAllGw),
+		serviceDiscoveredReport: usecase.ImplServiceDiscoveredReport(discoveredServiceSaveGw, clientGetOneGw, publishDashboardGw),
+		discoveredServiceGetAll: usecase.ImplDiscoveredServiceGetAll(discoveredServiceGetAllGw),
+	}
+}
+
+func (m *discoveryModule) Name() string { return "discovery" }
+
+func (m *discoveryModule) Migrations() []any {
+	return []any{&model.DiscoveredService{}}
+}
+
+func (m *discoveryModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.DiscoverServicesTriggerHandler(m.discoverServicesTrigger),
+		c.ServiceDiscoveredReportHandler(m.serviceDiscoveredReport),
+		c.DiscoveredServiceGetAllHandler(m.discoveredServiceGetAll),
+	)
+}
+
+func (m *discoveryModule) RegisterEvents(catalog *utility.EventCatalog) {
+	catalog.
+		Add(command.DiscoverServices.Spec()).
+		Add(utility.EventSpec{
+			Type:      "service_discovered",
+			Direction: utility.EventToClient,
+			Summary:   "Services an agent reported, published to the site:<id> topic of the dashboard feed, or unassigned",
+			Payload:   model.ServiceDiscoveredEvent{},
+		})
+}
@@ -0,0 +1,68 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newInventoryModule)
+}
+
+// inventoryModule keeps the expected devices of each site and reconciles them with the scan results
+type inventoryModule struct {
+	module.BaseModule
+	expectedDeviceImport usecase.ExpectedDeviceImport
+	inventoryReconcile   usecase.InventoryReconcile
+}
+
+func newInventoryModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	sendSSEMessageGw := gateway.ImplSendSSEMessage(deps.SSEServer)
+	publishDashboardGw := gateway.ImplSendSSEMessage(deps.DashboardSSE)
+	siteGetOneGw := gateway.ImplSiteGetOneWithSQlite(deps.DB)
+	scanResultGetAllGw := gateway.ImplScanResultGetAllWithSQlite(deps.DB)
+	expectedDeviceReplaceGw := gateway.ImplExpectedDeviceReplaceWithSQlite(deps.DB)
+	expectedDeviceGetAllGw := gateway.ImplExpectedDeviceGetAllWithSQlite(deps.DB)
+	inventoryReconciliationGetOneGw := gateway.ImplInventoryReconciliationGetOneWithSQlite(deps.DB)
+	inventoryReconciliationSaveGw := gateway.ImplInventoryReconciliationSaveWithSQlite(deps.DB)
+
+	// use cases
+	return &inventoryModule{
+		expectedDeviceImport: usecase.ImplExpectedDeviceImport(siteGetOneGw, expectedDeviceReplaceGw, expectedDeviceGetAllGw, scanResultGetAllGw, inventoryReconciliationGetOneGw, inventoryReconciliationSaveGw, sendSSEMessageGw, publishDashboardGw),
+		inventoryReconcile:   usecase.ImplInventoryReconcile(siteGetOneGw, expectedDeviceGetAllGw, scanResultGetAllGw, inventoryReconciliationGetOneGw, inventoryReconciliationSaveGw, sendSSEMessageGw, publishDashboardGw),
+	}
+}
+
+func (m *inventoryModule) Name() string { return "inventory" }
+
+func (m *inventoryModule) Migrations() []any {
+	return []any{&model.ExpectedDevice{}, &model.InventoryReconciliation{}}
+}
+
+func (m *inventoryModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.ExpectedDeviceImportHandler(m.expectedDeviceImport),
+		c.InventoryReconcileHandler(m.inventoryReconcile),
+	)
+}
+
+func (m *inventoryModule) RegisterEvents(catalog *utility.EventCatalog) {
+	catalog.Add(utility.EventSpec{
+		Type:      "inventory_reconciliation_changed",
+		Direction: utility.EventToClient,
+		Summary:   "The missing or unexpected devices of a site changed, broadcast to all clients and published to the site:<id> topic of the dashboard feed",
+		Payload:   model.InventoryReconciliationChangedEvent{},
+	})
+}
@@ -0,0 +1,64 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newIPAMModule)
+}
+
+// ipamModule records which addresses of a subnet are reserved, assigned or free,
+// the scan module raises an alert when a free or reserved address answers
+type ipamModule struct {
+	module.BaseModule
+	ipamSubnetCreate  usecase.IPAMSubnetCreate
+	ipamSubnetGetAll  usecase.IPAMSubnetGetAll
+	ipAllocationSet   usecase.IPAllocationSet
+	ipamAddressGetAll usecase.IPAMAddressGetAll
+}
+
+func newIPAMModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	ipamSubnetSaveGw := gateway.ImplIPAMSubnetSaveWithSQlite(deps.DB)
+	ipamSubnetGetAllGw := gateway.ImplIPAMSubnetGetAllWithSQlite(deps.DB)
+	ipamSubnetGetOneGw := gateway.ImplIPAMSubnetGetOneWithSQlite(deps.DB)
+	ipAllocationSaveGw := gateway.ImplIPAllocationSaveWithSQlite(deps.DB)
+	ipAllocationGetAllGw := gateway.ImplIPAllocationGetAllWithSQlite(deps.DB)
+	scanResultGetLatestGw := gateway.ImplScanResultGetLatestWithSQlite(deps.DB)
+
+	// use cases
+	return &ipamModule{
+		ipamSubnetCreate:  usecase.ImplIPAMSubnetCreate(ipamSubnetGetAllGw, ipamSubnetSaveGw),
+		ipamSubnetGetAll:  usecase.ImplIPAMSubnetGetAll(ipamSubnetGetAllGw),
+		ipAllocationSet:   usecase.ImplIPAllocationSet(ipamSubnetGetAllGw, ipAllocationSaveGw),
+		ipamAddressGetAll: usecase.ImplIPAMAddressGetAll(ipamSubnetGetOneGw, ipAllocationGetAllGw, scanResultGetLatestGw),
+	}
+}
+
+func (m *ipamModule) Name() string { return "ipam" }
+
+func (m *ipamModule) Migrations() []any {
+	return []any{&model.IPAMSubnet{}, &model.IPAllocation{}}
+}
+
+func (m *ipamModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.IPAMSubnetCreateHandler(m.ipamSubnetCreate),
+		c.IPAMSubnetGetAllHandler(m.ipamSubnetGetAll),
+		c.IPAMAddressGetAllHandler(m.ipamAddressGetAll),
+		c.IPAllocationSetHandler(m.ipAllocationSet),
+	)
+}
@@ -0,0 +1,44 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newLeakModule)
+}
+
+// leakModule exposes the leak detector of a soak test run
+type leakModule struct {
+	module.BaseModule
+	leakReportGet usecase.LeakReportGet
+}
+
+func newLeakModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	leakReportGetGw := gateway.ImplLeakReportGet(deps.LeakDetector)
+
+	// use cases
+	return &leakModule{
+		leakReportGet: usecase.ImplLeakReportGet(leakReportGetGw),
+	}
+}
+
+func (m *leakModule) Name() string { return "leak" }
+
+func (m *leakModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.LeakReportGetHandler(m.leakReportGet),
+	)
+}
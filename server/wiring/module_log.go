@@ -0,0 +1,52 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/module"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newLogModule)
+}
+
+// logModule changes the log level of the server and the agents while they run
+type logModule struct {
+	module.BaseModule
+	logLevelSet usecase.LogLevelSet
+}
+
+func newLogModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	logLevelSetGw := gateway.ImplLogLevelSet(utility.DefaultLogger())
+	sendSetLogLevelGw := gateway.ImplSendCommand(gateway.ImplSendSSEMessage(deps.SSEServer), command.SetLogLevel)
+	waitSSEAckGw := gateway.ImplWaitSSEAck(deps.SSEServer)
+
+	// use cases
+	return &logModule{
+		logLevelSet: usecase.ImplLogLevelSet(logLevelSetGw, sendSetLogLevelGw, waitSSEAckGw),
+	}
+}
+
+func (m *logModule) Name() string { return "log" }
+
+func (m *logModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.LogLevelSetHandler(m.logLevelSet),
+	)
+}
+
+func (m *logModule) RegisterEvents(catalog *utility.EventCatalog) {
+	catalog.
+		Add(command.SetLogLevel.Spec())
+}
@@ -0,0 +1,93 @@
+package wiring
+
+import (
+	"context"
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/core"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newRolloutModule)
+}
+
+// rolloutModule sends catalog commands to a canary share of the agents before the rest of the fleet
+type rolloutModule struct {
+	module.BaseModule
+	rolloutCreate usecase.RolloutCreate
+	rolloutGetAll usecase.RolloutGetAll
+	rolloutGetOne usecase.RolloutGetOne
+	rolloutResume usecase.RolloutResume
+	clock         core.Clock
+}
+
+func newRolloutModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	sseEventCatalogGetGw := gateway.ImplSSEEventCatalogGet(deps.EventCatalog)
+	sseConnectedClientGetAllGw := gateway.ImplSSEConnectedClientGetAll(deps.SSEServer)
+	sendSSEMessageGw := gateway.ImplSendSSEMessage(deps.SSEServer)
+	waitSSEAckGw := gateway.ImplWaitSSEAck(deps.SSEServer)
+	rolloutSaveGw := gateway.ImplRolloutSaveWithSQlite(deps.DB)
+	rolloutGetAllGw := gateway.ImplRolloutGetAllWithSQlite(deps.DB)
+	rolloutGetOneGw := gateway.ImplRolloutGetOneWithSQlite(deps.DB)
+
+	// use cases
+	return &rolloutModule{
+		rolloutCreate: usecase.ImplRolloutCreate(sseEventCatalogGetGw, sseConnectedClientGetAllGw, sendSSEMessageGw, waitSSEAckGw, rolloutSaveGw, deps.IDGenerator),
+		rolloutGetAll: usecase.ImplRolloutGetAll(rolloutGetAllGw),
+		rolloutGetOne: usecase.ImplRolloutGetOne(rolloutGetOneGw),
+		rolloutResume: usecase.ImplRolloutResume(rolloutGetAllGw, sendSSEMessageGw, waitSSEAckGw, rolloutSaveGw),
+		clock:         deps.Clock,
+	}
+}
+
+func (m *rolloutModule) Name() string { return "rollout" }
+
+func (m *rolloutModule) Migrations() []any {
+	return []any{&model.Rollout{}}
+}
+
+func (m *rolloutModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.RolloutCreateHandler(m.rolloutCreate),
+		c.RolloutGetAllHandler(m.rolloutGetAll),
+		c.RolloutGetOneHandler(m.rolloutGetOne),
+	)
+}
+
+// RegisterWorkers continues the rollouts the previous run left in progress, on stop they keep their
+// status for the next start
+func (m *rolloutModule) RegisterWorkers(lifecycle *utility.Lifecycle) {
+
+	ctx, stop := context.WithCancel(core.AttachDataToContext(context.Background(), core.ClockContextKey, m.clock))
+	res, err := m.rolloutResume(ctx, usecase.RolloutResumeReq{})
+	if err != nil {
+		utility.Errorf("Failed to resume the rollouts: %v", err)
+		stop()
+		return
+	}
+	if len(res.Resumed) > 0 {
+		utility.Infof("Resumed rollout(s) %v", res.Resumed)
+	}
+
+	lifecycle.Register("rollout-resume", func(ctx context.Context) error {
+		stop()
+		select {
+		case <-res.Done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
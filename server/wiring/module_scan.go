@@ -0,0 +1,197 @@
+package wiring
+
+import (
+	"context"
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/middleware"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/command"
+	"shared/core"
+	"shared/utility"
+	"time"
+)
+
+func init() {
+	module.Register(newScanModule)
+}
+
+type scanModule struct {
+	module.BaseModule
+	scanDevicesTrigger usecase.ScanICMPTrigger
+	scanResultStream   usecase.ScanResultStream
+	scanResultSave     usecase.ScanResultSave
+	scanJobReport      usecase.ScanJobReport
+	scanJobGetAll      usecase.ScanJobGetAll
+	scanJobGetOne      usecase.ScanJobGetOne
+	scanJobApprove     usecase.ScanJobApprove
+	scanJobCancel      usecase.ScanJobCancel
+	scanResultGetAll   usecase.ScanResultGetAll
+	scanResultExport   usecase.ScanResultExport
+	scanJobSummary     usecase.ScanJobSummaryReport
+	scanProgressReport usecase.ScanProgressReport
+	scanStatsGet       usecase.ScanStatsGet
+	scanJobExpire      usecase.ScanJobExpire
+	clock              core.Clock
+}
+
+func newScanModule(deps module.Dependency) module.ServerModule {
+
+	// TODO put into env
+	approvalPolicy := usecase.ScanApprovalPolicy{MaxHostsWithoutApproval: 1024}
+	conflictPolicy := usecase.ScanConflictPolicy{Mode: usecase.ScanConflictReject, StaleAfter: 2 * time.Hour}
+	dedupPolicy := usecase.ScanDedupPolicy{Window: 10 * time.Second}
+
+	// gateways
+	sendSSEMessageGw := gateway.ImplSendSSEMessage(deps.SSEServer)
+	sendScanCancelGw := gateway.ImplSendCommand(sendSSEMessageGw, command.ScanCancel)
+	waitSSEAckGw := gateway.ImplWaitSSEAck(deps.SSEServer)
+	publishDashboardGw := gateway.ImplSendSSEMessage(deps.DashboardSSE)
+	scanResultSaveBatchGw := gateway.ImplScanResultSaveBatchWithSQlite(deps.DB)
+	scanResultSaveGw := gateway.ImplScanResultSaveWithSQlite(deps.DB)
+	deviceRecordSeenGw := gateway.ImplDeviceRecordSeenWithSQlite(deps.DB)
+	scanJobSaveGw := gateway.ImplScanJobSaveWithSQlite(deps.DB)
+	scanJobGetAllGw := gateway.ImplScanJobGetAllWithSQlite(deps.DB)
+	scanJobGetOneGw := gateway.ImplScanJobGetOneWithSQlite(deps.DB)
+	scanResultCountByJobGw := gateway.ImplScanResultCountByJobWithSQlite(deps.DB)
+	scanJobReportSaveGw := gateway.ImplScanJobReportSaveWithSQlite(deps.DB)
+	scanJobTransitionGw := gateway.ImplScanJobTransitionWithSQlite(deps.DB)
+	scanJobAuditSaveGw := gateway.ImplScanJobAuditSaveWithSQlite(deps.DB)
+	scanJobGetActiveGw := gateway.ImplScanJobGetActiveWithSQlite(deps.DB)
+	scanJobSetRecipientsGw := gateway.ImplScanJobSetRecipientsWithSQlite(deps.DB)
+	scanJobFindDuplicateGw := gateway.ImplScanJobFindDuplicateWithSQlite(deps.DB)
+	scanJobAdmissionLockGw := gateway.ImplScanJobAdmissionLockWithSQlite(deps.DB)
+	scanResultGetAllGw := gateway.ImplScanResultGetAllWithSQlite(deps.DB)
+	scanResultEachGw := gateway.ImplScanResultEachWithSQlite(deps.DB)
+	deviceEachGw := gateway.ImplDeviceEachWithSQlite(deps.DB)
+	siteGetOneGw := gateway.ImplSiteGetOneWithSQlite(deps.DB)
+	siteGetAllGw := gateway.ImplSiteGetAllWithSQlite(deps.DB)
+	alertSaveGw := gateway.ImplAlertSaveWithSQlite(deps.DB)
+	ipAllocationGetAllGw := gateway.ImplIPAllocationGetAllWithSQlite(deps.DB)
+	clientGetOneGw := gateway.ImplClientGetOneWithSQlite(deps.DB)
+	clientGetAllGw := gateway.ImplClientGetAllWithSQlite(deps.DB)
+	scanJobCountByStatusGw := gateway.ImplScanJobCountByStatusWithSQlite(deps.DB)
+	writePositionAdvanceGw := gateway.ImplWritePositionAdvanceWithSQlite(deps.DB)
+	writePositionWaitGw := gateway.ImplWritePositionWaitWithSQlite(deps.DB, 2*time.Second)
+	webhookEventEnqueueGw := gateway.ImplWebhookEventEnqueueWithSQlite(deps.DB)
+	expectedDeviceGetAllGw := gateway.ImplExpectedDeviceGetAllWithSQlite(deps.DB)
+	inventoryReconciliationGetOneGw := gateway.ImplInventoryReconciliationGetOneWithSQlite(deps.DB)
+	inventoryReconciliationSaveGw := gateway.ImplInventoryReconciliationSaveWithSQlite(deps.DB)
+
+	resultAnalyzers := []usecase.ScanResultAnalyzer{
+		usecase.NewDeviceCountDropDetector(0.2, 7*24*time.Hour, 10000),
+		usecase.NewIPAMConflictDetector(ipAllocationGetAllGw),
+		usecase.NewInventoryReconciler(clientGetAllGw, siteGetOneGw, expectedDeviceGetAllGw, scanResultGetAllGw, inventoryReconciliationGetOneGw, inventoryReconciliationSaveGw, sendSSEMessageGw, publishDashboardGw),
+	}
+
+	// use cases
+	scanJobAdmit := middleware.TransactionMiddleware(usecase.ImplScanJobAdmit(scanJobAdmissionLockGw, scanJobFindDuplicateGw, scanJobGetActiveGw, scanJobSaveGw, scanJobAuditSaveGw, approvalPolicy, conflictPolicy, dedupPolicy), deps.DB)
+
+	return &scanModule{
+		scanDevicesTrigger: middleware.ConsistencyToken(usecase.ImplScanICMPTrigger(sendSSEMessageGw, waitSSEAckGw, scanJobAdmit, scanJobTransitionGw, scanJobAuditSaveGw, scanJobGetActiveGw, scanJobSetRecipientsGw, siteGetOneGw, siteGetAllGw, clientGetAllGw, deps.IDGenerator, conflictPolicy), writePositionAdvanceGw),
+		scanResultStream:   usecase.ImplScanResultStream(scanResultSaveBatchGw, deviceRecordSeenGw, alertSaveGw, sendSSEMessageGw, webhookEventEnqueueGw, deps.IDGenerator, resultAnalyzers),
+		scanResultSave:     usecase.ImplScanResultSave(scanResultSaveGw, deviceRecordSeenGw, alertSaveGw, sendSSEMessageGw, webhookEventEnqueueGw, deps.IDGenerator, resultAnalyzers),
+		scanJobReport:      usecase.ImplScanJobReport(scanJobGetOneGw, scanJobReportSaveGw, scanJobTransitionGw, scanJobAuditSaveGw, scanJobGetActiveGw, scanJobSetRecipientsGw, sendSSEMessageGw, waitSSEAckGw, webhookEventEnqueueGw, deps.IDGenerator, conflictPolicy),
+		scanJobGetAll:      middleware.ReadAfterWrite(usecase.ImplScanJobGetAll(scanJobGetAllGw, scanResultCountByJobGw), writePositionWaitGw),
+		scanJobGetOne:      middleware.ReadAfterWrite(usecase.ImplScanJobGetOne(scanJobGetOneGw, scanResultCountByJobGw), writePositionWaitGw),
+		scanJobApprove:     middleware.ConsistencyToken(usecase.ImplScanJobApprove(scanJobGetOneGw, scanJobTransitionGw, scanJobAuditSaveGw, scanJobGetActiveGw, scanJobSetRecipientsGw, sendSSEMessageGw, waitSSEAckGw, conflictPolicy), writePositionAdvanceGw),
+		scanJobCancel:      middleware.ConsistencyToken(usecase.ImplScanJobCancel(scanJobGetOneGw, scanJobTransitionGw, scanJobAuditSaveGw, scanJobGetActiveGw, scanJobSetRecipientsGw, sendSSEMessageGw, sendScanCancelGw, waitSSEAckGw, conflictPolicy), writePositionAdvanceGw),
+		scanResultGetAll:   usecase.ImplScanResultGetAll(siteGetOneGw, scanResultGetAllGw),
+		scanResultExport:   usecase.ImplScanResultExport(siteGetOneGw, scanResultEachGw),
+		scanJobSummary:     usecase.ImplScanJobSummaryReport(scanJobGetOneGw, scanResultCountByJobGw, scanResultEachGw, deviceEachGw),
+		scanProgressReport: usecase.ImplScanProgressReport(clientGetOneGw, publishDashboardGw),
+		scanStatsGet:       usecase.ImplScanStatsGet(scanJobCountByStatusGw),
+		scanJobExpire:      usecase.ImplScanJobExpire(scanJobGetActiveGw, scanJobTransitionGw, scanJobAuditSaveGw, scanJobSetRecipientsGw, sendSSEMessageGw, waitSSEAckGw, webhookEventEnqueueGw, deps.IDGenerator, conflictPolicy),
+		clock:              deps.Clock,
+	}
+}
+
+func (m *scanModule) Name() string { return "scan" }
+
+func (m *scanModule) Migrations() []any {
+	return []any{&model.ScanResult{}, &model.ScanJob{}, &model.ScanJobReport{}, &model.ScanJobAudit{}, &model.ScanJobAdmissionLock{}, &model.WritePosition{}}
+}
+
+func (m *scanModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.ScanDevicesTriggerHandler(m.scanDevicesTrigger),
+		c.ScanResultStreamHandler(m.scanResultStream),
+		c.ScanResultSaveHandler(m.scanResultSave),
+		c.ScanJobReportHandler(m.scanJobReport),
+		c.ScanJobGetAllHandler(m.scanJobGetAll),
+		c.ScanJobGetOneHandler(m.scanJobGetOne),
+		c.ScanJobSummaryReportHandler(m.scanJobSummary),
+		c.ScanJobApproveHandler(m.scanJobApprove),
+		c.ScanJobCancelHandler(m.scanJobCancel),
+		c.ScanResultGetAllHandler(m.scanResultGetAll),
+		c.ScanResultExportHandler(m.scanResultExport),
+		c.ScanProgressReportHandler(m.scanProgressReport),
+	)
+}
+
+// RegisterWorkers fails the dispatched jobs past the stale window at startup and every minute on the
+// module clock, so the jobs queued behind them do not wait for agents that will never report
+func (m *scanModule) RegisterWorkers(lifecycle *utility.Lifecycle) {
+
+	ctx, stop := context.WithCancel(core.AttachDataToContext(context.Background(), core.ClockContextKey, m.clock))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := m.clock.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			if _, err := m.scanJobExpire(ctx, usecase.ScanJobExpireReq{Now: m.clock.Now()}); err != nil && ctx.Err() == nil {
+				utility.Errorf("Scan job expiry: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+
+	lifecycle.Register("scan-job-expiry", func(ctx context.Context) error {
+		stop()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func (m *scanModule) RegisterStats(stats *utility.StatsRegistry) {
+	stats.Add("scans", func(ctx context.Context) (any, error) {
+		return m.scanStatsGet(ctx, usecase.ScanStatsGetReq{})
+	})
+}
+
+func (m *scanModule) RegisterEvents(catalog *utility.EventCatalog) {
+	catalog.
+		Add(command.ScanICMP.Spec()).
+		Add(command.ScanNmap.Spec()).
+		Add(command.ScanCancel.Spec()).
+		Add(utility.EventSpec{
+			Type:      "scan_completed",
+			Direction: utility.EventToClient,
+			Summary:   "Every expected agent reported the job, or the job failed because they did not report in time, broadcast to all clients",
+			Payload:   model.ScanCompletedEvent{},
+		}).
+		Add(utility.EventSpec{
+			Type:      "scan_progress",
+			Direction: utility.EventToClient,
+			Summary:   "Progress of a running scan, published to the site:<id> topic of the dashboard feed, or unassigned",
+			Payload:   model.ScanProgressEvent{},
+		})
+}
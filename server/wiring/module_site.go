@@ -0,0 +1,57 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newSiteModule)
+}
+
+type siteModule struct {
+	module.BaseModule
+	siteCreate       usecase.SiteCreate
+	siteGetAll       usecase.SiteGetAll
+	siteAssignAgents usecase.SiteAssignAgents
+}
+
+func newSiteModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	siteSaveGw := gateway.ImplSiteSaveWithSQlite(deps.DB)
+	siteGetAllGw := gateway.ImplSiteGetAllWithSQlite(deps.DB)
+	siteGetOneGw := gateway.ImplSiteGetOneWithSQlite(deps.DB)
+	clientAssignSiteGw := gateway.ImplClientAssignSiteWithSQlite(deps.DB)
+
+	// use cases
+	return &siteModule{
+		siteCreate:       usecase.ImplSiteCreate(siteGetAllGw, siteSaveGw),
+		siteGetAll:       usecase.ImplSiteGetAll(siteGetAllGw),
+		siteAssignAgents: usecase.ImplSiteAssignAgents(siteGetOneGw, clientAssignSiteGw),
+	}
+}
+
+func (m *siteModule) Name() string { return "site" }
+
+func (m *siteModule) Migrations() []any {
+	return []any{&model.Site{}}
+}
+
+func (m *siteModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.SiteCreateHandler(m.siteCreate),
+		c.SiteGetAllHandler(m.siteGetAll),
+		c.SiteAssignAgentsHandler(m.siteAssignAgents),
+	)
+}
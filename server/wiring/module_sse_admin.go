@@ -0,0 +1,51 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newSSEAdminModule)
+}
+
+type sseAdminModule struct {
+	module.BaseModule
+	sseRejectionGetAll usecase.SSERejectionGetAll
+	sseLastEventIDGet  usecase.SSELastEventIDGet
+	sseEventCatalogGet usecase.SSEEventCatalogGet
+}
+
+func newSSEAdminModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	sseRejectionGetAllGw := gateway.ImplSSERejectionGetAll(deps.SSEServer)
+	sseLastEventIDGetGw := gateway.ImplSSELastEventIDGet(deps.SSEServer)
+	sseEventCatalogGetGw := gateway.ImplSSEEventCatalogGet(deps.EventCatalog)
+
+	// use cases
+	return &sseAdminModule{
+		sseRejectionGetAll: usecase.ImplSSERejectionGetAll(sseRejectionGetAllGw),
+		sseLastEventIDGet:  usecase.ImplSSELastEventIDGet(sseLastEventIDGetGw),
+		sseEventCatalogGet: usecase.ImplSSEEventCatalogGet(sseEventCatalogGetGw),
+	}
+}
+
+func (m *sseAdminModule) Name() string { return "sse-admin" }
+
+func (m *sseAdminModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.SSERejectionGetAllHandler(m.sseRejectionGetAll),
+		c.SSELastEventIDGetHandler(m.sseLastEventIDGet),
+		c.SSEEventCatalogGetHandler(m.sseEventCatalogGet),
+	)
+}
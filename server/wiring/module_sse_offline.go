@@ -0,0 +1,41 @@
+package wiring
+
+import (
+	"server/model"
+	"server/module"
+	"server/utility"
+	"shared/core"
+	sharedUtility "shared/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	module.Register(newSSEOfflineModule)
+}
+
+// sseOfflineModule stores targeted messages for agents that are offline and delivers them on reconnect
+type sseOfflineModule struct {
+	module.BaseModule
+	db    *gorm.DB
+	clock core.Clock
+}
+
+func newSSEOfflineModule(deps module.Dependency) module.ServerModule {
+	return &sseOfflineModule{db: deps.DB, clock: deps.Clock}
+}
+
+func (m *sseOfflineModule) Name() string { return "sse-offline" }
+
+func (m *sseOfflineModule) Migrations() []any {
+	return []any{&model.SSEPendingMessage{}}
+}
+
+func (m *sseOfflineModule) RegisterEventHandlers(sseServer *sharedUtility.SSEServer) {
+
+	// TODO put into env
+	maxAge := 24 * time.Hour
+
+	sseServer.SetOfflineStore(utility.NewGormOfflineStore(m.db, maxAge, m.clock))
+}
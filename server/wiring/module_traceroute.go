@@ -0,0 +1,78 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/command"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newTracerouteModule)
+}
+
+// tracerouteModule runs traceroutes from an agent on request and keeps the hops for troubleshooting
+type tracerouteModule struct {
+	module.BaseModule
+	tracerouteTrigger usecase.TracerouteTrigger
+	tracerouteReport  usecase.TracerouteReport
+	tracerouteGetOne  usecase.TracerouteGetOne
+	tracerouteGetAll  usecase.TracerouteGetAll
+}
+
+func newTracerouteModule(deps module.Dependency) module.ServerModule {
+
+	// gateways
+	sendTracerouteGw := gateway.ImplSendCommand(gateway.ImplSendSSEMessage(deps.SSEServer), command.Traceroute)
+	waitSSEAckGw := gateway.ImplWaitSSEAck(deps.SSEServer)
+	sseConnectedClientGetAllGw := gateway.ImplSSEConnectedClientGetAll(deps.SSEServer)
+	publishDashboardGw := gateway.ImplSendSSEMessage(deps.DashboardSSE)
+	clientGetOneGw := gateway.ImplClientGetOneWithSQlite(deps.DB)
+	clientGetAllGw := gateway.ImplClientGetAllWithSQlite(deps.DB)
+	tracerouteSaveGw := gateway.ImplTracerouteSaveWithSQlite(deps.DB)
+	tracerouteGetOneGw := gateway.ImplTracerouteGetOneWithSQlite(deps.DB)
+	tracerouteGetAllGw := gateway.ImplTracerouteGetAllWithSQlite(deps.DB)
+
+	// use cases
+	return &tracerouteModule{
+		tracerouteTrigger: usecase.ImplTracerouteTrigger(sendTracerouteGw, waitSSEAckGw, sseConnectedClientGetAllGw, clientGetAllGw, tracerouteSaveGw, tracerouteGetOneGw, deps.IDGenerator),
+		tracerouteReport:  usecase.ImplTracerouteReport(tracerouteGetOneGw, tracerouteSaveGw, clientGetOneGw, publishDashboardGw),
+		tracerouteGetOne:  usecase.ImplTracerouteGetOne(tracerouteGetOneGw),
+		tracerouteGetAll:  usecase.ImplTracerouteGetAll(tracerouteGetAllGw),
+	}
+}
+
+func (m *tracerouteModule) Name() string { return "traceroute" }
+
+func (m *tracerouteModule) Migrations() []any {
+	return []any{&model.Traceroute{}}
+}
+
+func (m *tracerouteModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.TracerouteTriggerHandler(m.tracerouteTrigger),
+		c.TracerouteReportHandler(m.tracerouteReport),
+		c.TracerouteGetOneHandler(m.tracerouteGetOne),
+		c.TracerouteGetAllHandler(m.tracerouteGetAll),
+	)
+}
+
+func (m *tracerouteModule) RegisterEvents(catalog *utility.EventCatalog) {
+	catalog.
+		Add(command.Traceroute.Spec()).
+		Add(utility.EventSpec{
+			Type:      "traceroute_completed",
+			Direction: utility.EventToClient,
+			Summary:   "A traceroute an agent reported, published to the site:<id> topic of the dashboard feed, or unassigned",
+			Payload:   model.Traceroute{},
+		})
+}
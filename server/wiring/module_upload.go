@@ -0,0 +1,56 @@
+package wiring
+
+import (
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+)
+
+func init() {
+	module.Register(newUploadModule)
+}
+
+type uploadModule struct {
+	module.BaseModule
+	deps module.Dependency
+}
+
+func newUploadModule(deps module.Dependency) module.ServerModule {
+	return &uploadModule{deps: deps}
+}
+
+func (m *uploadModule) Name() string { return "upload" }
+
+func (m *uploadModule) Migrations() []any {
+	return []any{&model.Upload{}, &model.UploadChunk{}}
+}
+
+func (m *uploadModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	// gateways
+	uploadGetOneGw := gateway.ImplUploadGetOneWithSQlite(m.deps.DB)
+	uploadSaveGw := gateway.ImplUploadSaveWithSQlite(m.deps.DB)
+	uploadChunkSaveGw := gateway.ImplUploadChunkSaveWithSQlite(m.deps.DB)
+	uploadChunkGetAllGw := gateway.ImplUploadChunkGetAllWithSQlite(m.deps.DB)
+	uploadChunkDeleteGw := gateway.ImplUploadChunkDeleteWithSQlite(m.deps.DB)
+	internalDispatchGw := gateway.ImplInternalDispatch(m.deps.Handler)
+
+	// use cases
+	uploadStartImpl := usecase.ImplUploadStart(uploadGetOneGw, uploadSaveGw)
+	uploadChunkImpl := usecase.ImplUploadChunk(uploadGetOneGw, uploadChunkSaveGw)
+	uploadCompleteImpl := usecase.ImplUploadComplete(uploadGetOneGw, uploadChunkGetAllGw, uploadChunkDeleteGw, uploadSaveGw, internalDispatchGw)
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.UploadStartHandler(uploadStartImpl),
+		c.UploadChunkHandler(uploadChunkImpl),
+		c.UploadCompleteHandler(uploadCompleteImpl),
+	)
+}
@@ -0,0 +1,94 @@
+package wiring
+
+import (
+	"context"
+	"net/http"
+	"server/controller"
+	"server/gateway"
+	"server/model"
+	"server/module"
+	"server/usecase"
+	"shared/utility"
+	"time"
+)
+
+func init() {
+	module.Register(newWebhookModule)
+}
+
+// webhookModule owns the webhooks and their delivery queue, the scan module queues events into it
+type webhookModule struct {
+	module.BaseModule
+	webhookCreate         usecase.WebhookCreate
+	webhookGetAll         usecase.WebhookGetAll
+	webhookDelete         usecase.WebhookDelete
+	webhookDeliveryGetAll usecase.WebhookDeliveryGetAll
+	dispatcher            *usecase.WebhookDispatcher
+}
+
+func newWebhookModule(deps module.Dependency) module.ServerModule {
+
+	// TODO put into env
+	dispatcherConfig := usecase.WebhookDispatcherConfig{}
+	postTimeout := 10 * time.Second
+
+	// gateways
+	webhookSaveGw := gateway.ImplWebhookSaveWithSQlite(deps.DB)
+	webhookGetAllGw := gateway.ImplWebhookGetAllWithSQlite(deps.DB)
+	webhookDeleteGw := gateway.ImplWebhookDeleteWithSQlite(deps.DB)
+	webhookDeliveryGetAllGw := gateway.ImplWebhookDeliveryGetAllWithSQlite(deps.DB)
+	webhookDeliveryClaimDueGw := gateway.ImplWebhookDeliveryClaimDueWithSQlite(deps.DB)
+	webhookDeliverySaveGw := gateway.ImplWebhookDeliverySaveWithSQlite(deps.DB)
+	webhookPostGw := gateway.ImplWebhookPost(postTimeout)
+
+	// use cases
+	return &webhookModule{
+		webhookCreate:         usecase.ImplWebhookCreate(webhookSaveGw),
+		webhookGetAll:         usecase.ImplWebhookGetAll(webhookGetAllGw),
+		webhookDelete:         usecase.ImplWebhookDelete(webhookDeleteGw),
+		webhookDeliveryGetAll: usecase.ImplWebhookDeliveryGetAll(webhookDeliveryGetAllGw),
+		dispatcher:            usecase.NewWebhookDispatcher(webhookDeliveryClaimDueGw, webhookDeliverySaveGw, webhookPostGw, deps.IDGenerator, dispatcherConfig, deps.Clock),
+	}
+}
+
+func (m *webhookModule) Name() string { return "webhook" }
+
+func (m *webhookModule) Migrations() []any {
+	return []any{&model.Webhook{}, &model.WebhookDelivery{}}
+}
+
+func (m *webhookModule) RegisterRoutes(mux *http.ServeMux, apiPrinter *utility.ApiPrinter) error {
+
+	c := controller.Controller{
+		Mux: mux,
+	}
+
+	return apiPrinter.Add(
+		c.WebhookCreateHandler(m.webhookCreate),
+		c.WebhookGetAllHandler(m.webhookGetAll),
+		c.WebhookDeleteHandler(m.webhookDelete),
+		c.WebhookDeliveryGetAllHandler(m.webhookDeliveryGetAll),
+	)
+}
+
+// RegisterWorkers runs the dispatcher, it is stopped after the http server so the events of the last
+// requests are still queued, an attempt cut by the stop is retried on the next start
+func (m *webhookModule) RegisterWorkers(lifecycle *utility.Lifecycle) {
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.dispatcher.Run(ctx)
+	}()
+
+	lifecycle.Register("webhook-dispatcher", func(ctx context.Context) error {
+		stop()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
@@ -1,34 +1,68 @@
 package wiring
 
 import (
+	"context"
+	"fmt"
 	"net/http"
-	"server/controller"
-	"server/gateway"
-	"server/usecase"
+	"server/module"
+	"shared/core"
 	"shared/utility"
 
 	"gorm.io/gorm"
 )
 
-func SetupDependency(mux *http.ServeMux, sseServer *utility.SSEServer, apiPrinter *utility.ApiPrinter, db *gorm.DB) {
+// SetupDependency builds every registered module, runs their migrations and registers their routes.
+// New features add a module_xxx.go file registering itself in init() instead of editing this function.
+func SetupDependency(mux *http.ServeMux, handler http.Handler, sseServer, dashboardSSE *utility.SSEServer, apiPrinter *utility.ApiPrinter, eventCatalog *utility.EventCatalog, stats *utility.StatsRegistry, lifecycle *utility.Lifecycle, leaks *utility.LeakDetector, db *gorm.DB, artifactURLSecret string) error {
 
-	// gateways
-	// saveClientGw := gateway.ImplClientSaveWithSQlite(db)
-	sendSSEMessageGw := gateway.ImplSendSSEMessage(sseServer)
-	// ...other gateways here...
+	clock := core.RealClock{}
+	modules := module.Build(module.Dependency{
+		SSEServer:         sseServer,
+		DB:                db,
+		IDGenerator:       core.NewULIDGenerator(clock),
+		Clock:             clock,
+		EventCatalog:      eventCatalog,
+		DashboardSSE:      dashboardSSE,
+		LeakDetector:      leaks,
+		Handler:           handler,
+		ArtifactURLSecret: artifactURLSecret,
+	})
 
-	// use cases
-	scanDevicesTriggerImpl := usecase.ImplScanICMPTrigger(sendSSEMessageGw)
-	// ...other usecases here...
+	// migrations, once the rows they would fail on are fixed
+	for _, m := range modules {
+		if err := m.Premigrate(context.Background()); err != nil {
+			return fmt.Errorf("failed to prepare the migrations of module %s: %w", m.Name(), err)
+		}
+	}
+
+	var migrations []any
+	for _, m := range modules {
+		migrations = append(migrations, m.Migrations()...)
+	}
 
-	c := controller.Controller{
-		Mux: mux,
+	if err := db.AutoMigrate(migrations...); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	// controllers
-	apiPrinter.
-		Add(c.ScanDevicesTriggerHandler(scanDevicesTriggerImpl))
+	// routes, event handlers and the event contract
+	for _, m := range modules {
+		if err := m.RegisterRoutes(mux, apiPrinter); err != nil {
+			return fmt.Errorf("failed to register the routes of module %s: %w", m.Name(), err)
+		}
+		m.RegisterEventHandlers(sseServer)
+		m.RegisterEvents(eventCatalog)
+		m.RegisterStats(stats)
+		utility.Infof("Module %s registered", m.Name())
+	}
 
-	// ...other controllers here...
+	// Add refused undeclared documented routes already, the raw ones are checked here
+	if err := apiPrinter.ValidateAccess(); err != nil {
+		return err
+	}
+
+	for _, m := range modules {
+		m.RegisterWorkers(lifecycle)
+	}
 
+	return nil
 }
@@ -0,0 +1,63 @@
+package command
+
+import "shared/utility"
+
+// ResourceLimits is the payload of the resource_limits event, 0 removes a limit
+type ResourceLimits struct {
+	MaxCPUPercent      float64 `json:"max_cpu_percent" validate:"min=0,max=100"`
+	MaxMemoryMB        int     `json:"max_memory_mb" validate:"min=0"`
+	MaxProbesPerSecond int     `json:"max_probes_per_second" validate:"min=0"` // shared by every scan of the agent
+}
+
+var SetResourceLimits = utility.NewCommand[ResourceLimits, struct{}](
+	"resource_limits",
+	"Replace the CPU, memory and probe rate limits of the agent",
+	"",
+)
+
+// ScanSchedule is one scan the agent runs on its own, from its configuration or from schedule_update
+type ScanSchedule struct {
+	Name      string `json:"name"`
+	Cron      string `json:"cron"` // five field cron, @daily style descriptor or "@every 30m"
+	IPRange   string `json:"ip_range"`
+	Workers   int    `json:"workers"`    // 0 uses the scan default
+	TimeoutMs int    `json:"timeout_ms"` // 0 uses the scan default
+	// Method is icmp (default), arp or udp, nmap jobs cannot be scheduled on the agent
+	Method         string `json:"method"`
+	SNMPCredential string `json:"snmp_credential"`
+	Ports          []int  `json:"ports"`
+	Banners        bool   `json:"banners"`
+	// MaxPacketsPerSecond and ProbeDelayMs pace the scheduled scan like a triggered one
+	MaxPacketsPerSecond int `json:"max_packets_per_second"`
+	ProbeDelayMs        int `json:"probe_delay_ms"`
+}
+
+// ScheduleUpdate is the payload of the schedule_update event
+type ScheduleUpdate struct {
+	// Schedules replace the local schedules of the agent, null hands control back to its own configuration
+	Schedules []ScanSchedule `json:"schedules"`
+}
+
+var UpdateSchedule = utility.NewCommand[ScheduleUpdate, struct{}](
+	"schedule_update",
+	"Replace the local scan schedules of the agent, which keeps them on disk across restarts, null schedules restore its own",
+	"",
+).WithAliases("scan_schedule") // the event name before it was persisted on the agent
+
+// AgentControlRequest is the payload of the agent_restart and agent_shutdown events
+type AgentControlRequest struct {
+	Reason      string `json:"reason"`
+	RequestedBy string `json:"requested_by"`
+}
+
+var AgentRestart = utility.NewCommand[AgentControlRequest, struct{}](
+	"agent_restart",
+	"Ask the agent to restart through its lifecycle, the ack confirms it will",
+	"",
+)
+
+var AgentShutdown = utility.NewCommand[AgentControlRequest, struct{}](
+	"agent_shutdown",
+	"Ask the agent to shutdown through its lifecycle, the ack confirms it will",
+	"",
+)
@@ -0,0 +1,34 @@
+package command
+
+import (
+	"shared/utility"
+	"time"
+)
+
+// DiscoverServicesRequest is the payload of the discover_services event
+type DiscoverServicesRequest struct {
+	WindowMs  int      `json:"window_ms"` // how long the agent listens for announcements
+	Protocols []string `json:"protocols"` // mdns and/or ssdp, empty listens for both
+}
+
+// DiscoveredService is one service heard announced over mDNS or SSDP
+type DiscoveredService struct {
+	Protocol    string            `json:"protocol"` // mdns or ssdp
+	IP          string            `json:"ip"`
+	Port        int               `json:"port"`
+	ServiceType string            `json:"service_type"` // e.g. _ipp._tcp or urn:schemas-upnp-org:device:MediaServer:1
+	Name        string            `json:"name"`         // instance name for mdns, USN for ssdp
+	Details     map[string]string `json:"details"`
+	SeenAt      time.Time         `json:"seen_at"`
+}
+
+// DiscoverServicesResult is what the agent reports once the window closed, it is posted under its own client id
+type DiscoverServicesResult struct {
+	Services []DiscoveredService `json:"services"`
+}
+
+var DiscoverServices = utility.NewCommand[DiscoverServicesRequest, DiscoverServicesResult](
+	"discover_services",
+	"Listen for mDNS and SSDP announcements for window_ms and report the services heard",
+	"/api/clients/{id}/discovered-services",
+)
@@ -0,0 +1,17 @@
+package command
+
+import "shared/utility"
+
+// SetLogLevelRequest is the payload of the set_log_level event
+type SetLogLevelRequest struct {
+	Level string `json:"level"` // debug, info, warn or error
+	// DurationSeconds puts the previous level back after this many seconds, 0 keeps the new level
+	DurationSeconds int `json:"duration_seconds"`
+}
+
+// SetLogLevel has no result, the ack tells the level is applied
+var SetLogLevel = utility.NewCommand[SetLogLevelRequest, struct{}](
+	"set_log_level",
+	"Change the log level of the agent, for duration_seconds when set",
+	"",
+)
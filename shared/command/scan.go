@@ -0,0 +1,48 @@
+package command
+
+import "shared/utility"
+
+// ScanRequest is the payload of the scan_icmp and scan_nmap events, the agent streams the results
+// through an upload and reports the job to /api/scan-jobs/{id}/report
+type ScanRequest struct {
+	JobID   string `json:"job_id"`
+	IPRange string `json:"ip_range"`
+	Method  string `json:"method"` // icmp, arp for hosts on the agent's own segment, udp, or nmap
+	DryRun  bool   `json:"dry_run"`
+	// SNMPCredential names the community in the agent's secrets store, empty uses the agent's own configuration
+	SNMPCredential string `json:"snmp_credential,omitempty"`
+	// Ports are TCP ports checked on every host found online, Banners also reads what each open port says
+	Ports   []int `json:"ports,omitempty"`
+	Banners bool  `json:"banners,omitempty"`
+	// MaxPacketsPerSecond is shared by all workers of the agent, ProbeDelayMs spaces two probes of the agent
+	MaxPacketsPerSecond int `json:"max_packets_per_second,omitempty"`
+	ProbeDelayMs        int `json:"probe_delay_ms,omitempty"`
+	// Workers probe at once, 0 is the agent's default, never more than the max_concurrency it announced
+	Workers int `json:"workers,omitempty"`
+}
+
+var ScanICMP = utility.NewCommand[ScanRequest, struct{}](
+	"scan_icmp",
+	"Probe every address of the range and stream the results back, method arp needs cap_arp_table instead",
+	"",
+).WithCapabilities("cap_icmp")
+
+var ScanNmap = utility.NewCommand[ScanRequest, struct{}](
+	"scan_nmap",
+	"Scan the range with nmap on the agent and stream the results back like scan_icmp, sent for method nmap",
+	"",
+).WithCapabilities("cap_nmap")
+
+// ScanCancelRequest is the payload of the scan_cancel event
+type ScanCancelRequest struct {
+	JobID       string `json:"job_id"`
+	Reason      string `json:"reason,omitempty"`
+	RequestedBy string `json:"requested_by"`
+}
+
+// ScanCancel is acknowledged only by the agents running the job, they report it cancelled
+var ScanCancel = utility.NewCommand[ScanCancelRequest, struct{}](
+	"scan_cancel",
+	"Stop a running scan job, the agent reports it cancelled, an agent not running the job does not acknowledge",
+	"",
+)
@@ -0,0 +1,30 @@
+package command
+
+import "shared/utility"
+
+// SecretSetRequest is the payload of the secret_set event, the only time a credential travels to an agent
+type SecretSetRequest struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"` // snmp, ssh or wmi
+	Community  string `json:"community,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+var SetSecret = utility.NewCommand[SecretSetRequest, struct{}](
+	"secret_set",
+	"Store a scan credential encrypted on the agent, never kept for offline agents",
+	"",
+)
+
+// SecretDeleteRequest is the payload of the secret_delete event
+type SecretDeleteRequest struct {
+	ID string `json:"id"`
+}
+
+var DeleteSecret = utility.NewCommand[SecretDeleteRequest, struct{}](
+	"secret_delete",
+	"Remove a scan credential from the secrets store of the agent",
+	"",
+)
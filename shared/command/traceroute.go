@@ -0,0 +1,43 @@
+// Package command declares the commands the server sends to agents together with the result each
+// agent reports, both sides use the same structs so the contract cannot drift
+package command
+
+import (
+	"shared/utility"
+	"time"
+)
+
+// TracerouteRequest is the payload of the traceroute event sent to one agent
+type TracerouteRequest struct {
+	TraceID   string `json:"trace_id"`
+	Target    string `json:"target"`
+	MaxHops   int    `json:"max_hops"`
+	Probes    int    `json:"probes"`     // probes per hop
+	TimeoutMs int    `json:"timeout_ms"` // per probe
+}
+
+// TracerouteHop is one TTL of the path as seen by the agent, IP is empty when no router answered
+type TracerouteHop struct {
+	TTL      int       `json:"ttl"`
+	IP       string    `json:"ip,omitempty"`
+	Hostname string    `json:"hostname,omitempty"`
+	RTTMs    []float64 `json:"rtt_ms"`
+	Lost     int       `json:"lost"`
+}
+
+// TracerouteResult is what the agent reports once the trace ended
+type TracerouteResult struct {
+	ClientID   string          `json:"client_id"`
+	ResolvedIP string          `json:"resolved_ip"`
+	Hops       []TracerouteHop `json:"hops"`
+	Reached    bool            `json:"reached"`
+	Error      string          `json:"error"` // set when the agent could not trace at all
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+}
+
+var Traceroute = utility.NewCommand[TracerouteRequest, TracerouteResult](
+	"traceroute",
+	"Trace the path to target and report the hops",
+	"/api/traceroutes/{id}/report",
+)
@@ -22,6 +22,36 @@ func (a InternalServerError) Error() string {
 	return string(a.error.Error())
 }
 
+// NotFoundError is answered with 404, for a caller that reacts to the resource missing rather than to its request being wrong
+type NotFoundError struct {
+	error
+}
+
+func NewNotFoundError(err error) error {
+	return NotFoundError{
+		error: err,
+	}
+}
+
+func (a NotFoundError) Error() string {
+	return a.error.Error()
+}
+
+// GatewayTimeoutError is answered with 504, for a wait on something else that ran out of time, the caller may retry
+type GatewayTimeoutError struct {
+	error
+}
+
+func NewGatewayTimeoutError(err error) error {
+	return GatewayTimeoutError{
+		error: err,
+	}
+}
+
+func (a GatewayTimeoutError) Error() string {
+	return a.error.Error()
+}
+
 type ErrorWithData struct {
 	error
 	Data any
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// IDGenerator produces unique identifiers for requests, clients, jobs and events
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDGenerator generates random RFC 4122 version 4 UUIDs
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator generates lexicographically sortable IDs (48 bit ms timestamp + 80 bit entropy).
+// IDs generated within the same millisecond are monotonic.
+type ULIDGenerator struct {
+	clock   Clock
+	mu      sync.Mutex
+	lastMs  uint64
+	lastRnd [10]byte
+}
+
+func NewULIDGenerator(clock Clock) *ULIDGenerator {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &ULIDGenerator{clock: clock}
+}
+
+func (g *ULIDGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.clock.Now().UnixMilli())
+
+	if ms == g.lastMs {
+		// increment the previous entropy so ordering is kept inside the same millisecond
+		for i := len(g.lastRnd) - 1; i >= 0; i-- {
+			g.lastRnd[i]++
+			if g.lastRnd[i] != 0 {
+				break
+			}
+		}
+	} else {
+		if _, err := rand.Read(g.lastRnd[:]); err != nil {
+			panic(fmt.Sprintf("failed to read random bytes: %v", err))
+		}
+		g.lastMs = ms
+	}
+
+	var raw [16]byte
+	binary.BigEndian.PutUint16(raw[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(raw[2:6], uint32(ms))
+	copy(raw[6:], g.lastRnd[:])
+
+	return encodeCrockford(raw)
+}
+
+// encodeCrockford encodes 128 bits into the 26 character ULID representation
+func encodeCrockford(raw [16]byte) string {
+	hi := binary.BigEndian.Uint64(raw[0:8])
+	lo := binary.BigEndian.Uint64(raw[8:16])
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// SequentialIDGenerator returns prefix-1, prefix-2, ... and is meant for tests
+type SequentialIDGenerator struct {
+	prefix string
+	next   atomic.Uint64
+}
+
+func NewSequentialIDGenerator(prefix string) *SequentialIDGenerator {
+	return &SequentialIDGenerator{prefix: prefix}
+}
+
+func (g *SequentialIDGenerator) NewID() string {
+	return fmt.Sprintf("%s-%d", g.prefix, g.next.Add(1))
+}
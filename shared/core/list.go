@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ListQuery is the paging, sorting and filtering of a list endpoint. ExtractRequest fills a field of
+// this type tagged http:"list" from the page, size and sort query parameters and from every
+// filter[<field>] parameter.
+type ListQuery struct {
+	Page    int               `json:"page"` // 1 based
+	Size    int               `json:"size"`
+	Sort    string            `json:"sort"`    // comma separated fields, a leading - sorts descending, e.g. -updated_at,client_id
+	Filters map[string]string `json:"filters"` // field to the value it must be equal to
+}
+
+// ListMetadata describes the page returned by a list endpoint
+type ListMetadata struct {
+	Page  int   `json:"page"`
+	Size  int   `json:"size"`
+	Total int64 `json:"total"` // rows matching the filters over all the pages
+	Pages int   `json:"pages"`
+}
+
+// ListPage is the answer of a list usecase, HandleUsecase writes the items as the data of the response
+// and the paging as its metadata
+type ListPage[T any] struct {
+	Items    []T
+	Metadata ListMetadata
+}
+
+// ListResponse gives the data and the metadata of the response, items are never null
+func (p *ListPage[T]) ListResponse() (any, any) {
+	if p.Items == nil {
+		return []T{}, p.Metadata
+	}
+	return p.Items, p.Metadata
+}
+
+// SortField is one field of ListQuery.Sort
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SortFields splits Sort into its fields, in order
+func (q ListQuery) SortFields() []SortField {
+	var fields []SortField
+	for _, part := range strings.Split(q.Sort, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		part = strings.TrimPrefix(part, "-")
+		if part != "" {
+			fields = append(fields, SortField{Field: part, Desc: desc})
+		}
+	}
+	return fields
+}
+
+// Normalize applies the defaults of an endpoint: page 1 and defaultSize rows when unset, at most maxSize
+// rows, and defaultSort when the caller asked for no order
+func (q ListQuery) Normalize(defaultSize, maxSize int, defaultSort string) ListQuery {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Size <= 0 {
+		q.Size = defaultSize
+	}
+	q.Size = min(q.Size, maxSize)
+	if strings.TrimSpace(q.Sort) == "" {
+		q.Sort = defaultSort
+	}
+	return q
+}
+
+// Validate refuses a sort or a filter on a field the endpoint does not offer
+func (q ListQuery) Validate(fields ...string) error {
+	for _, sort := range q.SortFields() {
+		if !slices.Contains(fields, sort.Field) {
+			return fmt.Errorf("cannot sort by %s, the fields are %s", sort.Field, strings.Join(fields, ", "))
+		}
+	}
+	for field := range q.Filters {
+		if !slices.Contains(fields, field) {
+			return fmt.Errorf("cannot filter by %s, the fields are %s", field, strings.Join(fields, ", "))
+		}
+	}
+	return nil
+}
+
+// Offset is the number of rows before the page
+func (q ListQuery) Offset() int {
+	return max(q.Page-1, 0) * q.Size
+}
+
+// Metadata describes the page of q among total rows
+func (q ListQuery) Metadata(total int64) ListMetadata {
+	pages := 0
+	if q.Size > 0 {
+		pages = int((total + int64(q.Size) - 1) / int64(q.Size))
+	}
+	return ListMetadata{Page: q.Page, Size: q.Size, Total: total, Pages: pages}
+}
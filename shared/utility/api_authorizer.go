@@ -0,0 +1,102 @@
+package utility
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Authorizer decides whether a request may reach a documented route. It returns the request to
+// pass on, which may carry the identity of the caller in its context, or an AuthError.
+type Authorizer interface {
+	Authorize(r *http.Request, route APIData) (*http.Request, error)
+}
+
+// AuthorizerFunc adapts a function to Authorizer
+type AuthorizerFunc func(r *http.Request, route APIData) (*http.Request, error)
+
+func (f AuthorizerFunc) Authorize(r *http.Request, route APIData) (*http.Request, error) {
+	return f(r, route)
+}
+
+// AuthError refuses a request, Status is 401 for a caller that is not authenticated and 403 for one
+// lacking the access of the route
+type AuthError struct {
+	Status  int
+	Message string
+}
+
+func (e AuthError) Error() string { return e.Message }
+
+func Unauthenticated(message string) error {
+	return AuthError{Status: http.StatusUnauthorized, Message: message}
+}
+
+func Forbidden(message string) error {
+	return AuthError{Status: http.StatusForbidden, Message: message}
+}
+
+// Authorize enforces the access of the routes with authorizer. A request is matched to its route
+// with the same patterns the routes were registered with, a request matching no documented or
+// declared route is refused so a route registered without an access never passes unchecked.
+func (r *ApiPrinter) Authorize(authorizer Authorizer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+
+			route, ok := r.routeOf(req)
+			if !ok || route.Access == "" {
+				msg := "route without an access declaration"
+				WriteJSON(w, http.StatusForbidden, Response{Status: "failed", Error: &msg})
+				return
+			}
+
+			authorized, err := authorizer.Authorize(req, route)
+			if err != nil {
+				status := http.StatusForbidden
+				var authErr AuthError
+				if errors.As(err, &authErr) {
+					status = authErr.Status
+				}
+				msg := err.Error()
+				WriteJSON(w, status, Response{Status: "failed", Error: &msg})
+				return
+			}
+
+			next.ServeHTTP(w, authorized)
+		})
+	}
+}
+
+// routeOf finds the documented or declared route of a request, the index is rebuilt when routes were added
+func (r *ApiPrinter) routeOf(req *http.Request) (APIData, bool) {
+
+	r.mu.RLock()
+	index, indexed := r.routeIndex, r.routeIndexed
+	count := len(r.urls) + len(r.declared)
+	r.mu.RUnlock()
+
+	if index == nil || indexed != count {
+		r.mu.Lock()
+		if r.routeIndex == nil || r.routeIndexed != len(r.urls)+len(r.declared) {
+			mux := http.NewServeMux()
+			routes := make(map[string]APIData, len(r.urls)+len(r.declared))
+			for _, route := range append(append([]APIData(nil), r.urls...), r.declared...) {
+				pattern := route.GetMethodUrl()
+				if _, dup := routes[pattern]; dup {
+					continue
+				}
+				routes[pattern] = route
+				mux.Handle(pattern, http.NotFoundHandler())
+			}
+			r.routeIndex, r.routes, r.routeIndexed = mux, routes, len(r.urls)+len(r.declared)
+		}
+		index = r.routeIndex
+		r.mu.Unlock()
+	}
+
+	_, pattern := index.Handler(req)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	route, ok := r.routes[pattern]
+	return route, ok
+}
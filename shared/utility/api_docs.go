@@ -0,0 +1,37 @@
+package utility
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// swaggerUIFiles is the Swagger UI distribution (see swaggerui/NOTICE for the version), bundled so the
+// documentation works in deployments without internet access
+//
+//go:embed swaggerui
+var swaggerUIFiles embed.FS
+
+// PublishSwaggerUI serves Swagger UI at path and the spec it reads at path/openapi.json.
+// The spec is generated on every request like the one of PublishAPI, call it after PublishAPI so the
+// spec names the same server.
+func (r *ApiPrinter) PublishSwaggerUI(mux *http.ServeMux, path string) *ApiPrinter {
+
+	path = strings.TrimSuffix(path, "/")
+
+	files, err := fs.Sub(swaggerUIFiles, "swaggerui")
+	if err != nil {
+		panic(err)
+	}
+
+	r.HandleRaw(mux, http.MethodGet, path+"/openapi.json", Public, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.writeSpec(w, true)
+	})).
+		HandleRaw(mux, http.MethodGet, path+"/", Public, http.StripPrefix(path+"/", http.FileServerFS(files))).
+		HandleRaw(mux, http.MethodGet, path, Public, http.RedirectHandler(path+"/", http.StatusMovedPermanently))
+
+	Infof("DOCS %s/", path)
+
+	return r
+}
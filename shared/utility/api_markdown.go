@@ -0,0 +1,288 @@
+package utility
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ExportMarkdown writes the reference of the routes registered so far to a Markdown file, so it
+// can be committed along the code it documents
+func (r *ApiPrinter) ExportMarkdown(path string) error {
+	var b bytes.Buffer
+	r.writeMarkdown(&b)
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// PublishMarkdown serves the reference at url, generated from the current routes on every request
+func (r *ApiPrinter) PublishMarkdown(mux *http.ServeMux, url string) *ApiPrinter {
+	return r.HandleRaw(mux, http.MethodGet, url, Public, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		r.writeMarkdown(w)
+	}))
+}
+
+// writeMarkdown renders the routes grouped by tag: access, parameters, the fields of the body and of
+// the response with their constraints, and the examples
+func (r *ApiPrinter) writeMarkdown(w io.Writer) {
+
+	byTag := map[string][]APIData{}
+	for _, endpoint := range r.snapshot() {
+		tag := endpoint.Tag
+		if tag == "" {
+			tag = "Other"
+		}
+		byTag[tag] = append(byTag[tag], endpoint)
+	}
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	fmt.Fprintf(w, "# API reference\n\n")
+	fmt.Fprintf(w, "Every JSON answer is the envelope `{\"status\": \"success\" | \"failed\", \"error\": string | null, \"data\": ..., \"metadata\": ...}`. ")
+	fmt.Fprintf(w, "A refused request answers 400 with the reason in `error`, an unexpected failure answers 500.\n\n")
+	for _, tag := range tags {
+		fmt.Fprintf(w, "- [%s](#%s)\n", tag, markdownAnchor(tag))
+	}
+
+	for _, tag := range tags {
+		endpoints := byTag[tag]
+		sort.SliceStable(endpoints, func(i, j int) bool {
+			if endpoints[i].Url != endpoints[j].Url {
+				return endpoints[i].Url < endpoints[j].Url
+			}
+			return endpoints[i].Method < endpoints[j].Method
+		})
+
+		fmt.Fprintf(w, "\n## %s\n", tag)
+		for _, endpoint := range endpoints {
+			r.writeMarkdownEndpoint(w, endpoint)
+		}
+	}
+}
+
+func (r *ApiPrinter) writeMarkdownEndpoint(w io.Writer, endpoint APIData) {
+
+	fmt.Fprintf(w, "\n### `%s %s`\n\n", endpoint.Method, endpoint.Url)
+	if endpoint.Summary != "" {
+		fmt.Fprintf(w, "%s.", strings.TrimSuffix(endpoint.Summary, "."))
+	}
+	if endpoint.Description != "" {
+		fmt.Fprintf(w, " %s", endpoint.Description)
+	}
+	fmt.Fprintf(w, "\n\nAccess: `%s`", endpoint.Access)
+	if len(endpoint.Roles) > 0 {
+		fmt.Fprintf(w, ", with one of the roles `%s`", strings.Join(endpoint.Roles, "`, `"))
+	}
+	fmt.Fprintf(w, "\n")
+
+	var params [][]string
+	for _, part := range strings.Split(endpoint.Url, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params = append(params, []string{"`" + strings.Trim(part, "{}") + "`", "path", "string", "yes", ""})
+		}
+	}
+	for _, param := range endpoint.QueryParams {
+		params = append(params, []string{"`" + param.Name + "`", "query", param.Type, markdownYes(param.Required), param.Description})
+	}
+	for _, param := range endpoint.MultipartFormParam {
+		params = append(params, []string{"`" + param.Name + "`", "form", param.Type, markdownYes(param.Required), param.Description})
+	}
+	if len(params) > 0 {
+		fmt.Fprintf(w, "\n**Parameters**\n\n")
+		writeMarkdownTable(w, []string{"Name", "In", "Type", "Required", "Description"}, params)
+	}
+
+	if endpoint.Body != nil && endpoint.Method != http.MethodGet && len(endpoint.MultipartFormParam) == 0 {
+		fmt.Fprintf(w, "\n**Body**\n\n")
+		writeMarkdownFields(w, generateBodySchema(endpoint.Body))
+	}
+
+	switch {
+	case endpoint.File != nil:
+		fmt.Fprintf(w, "\n**Response** a file, %s", strings.Join(endpoint.File.ContentTypes, " or "))
+		if endpoint.File.Description != "" {
+			fmt.Fprintf(w, ": %s", endpoint.File.Description)
+		}
+		fmt.Fprintf(w, "\n")
+	case endpoint.ResponseBody != nil:
+		data, metadata := responseDataSchema(endpoint.ResponseBody)
+		fmt.Fprintf(w, "\n**Response data**\n\n")
+		writeMarkdownFields(w, data)
+		if metadata != nil {
+			fmt.Fprintf(w, "\nThe `metadata` is the page: `page`, `size`, `total` rows and `pages`.\n")
+		}
+	}
+
+	if endpoint.Stream {
+		var rows [][]string
+		for _, event := range r.events() {
+			payload := ""
+			if event.Payload != nil {
+				payload = markdownFieldNames(generateBodySchema(event.Payload))
+			}
+			rows = append(rows, []string{"`" + event.Type + "`", event.Description, payload})
+		}
+		fmt.Fprintf(w, "\n**Events** of the stream, the data of each is its JSON payload\n\n")
+		writeMarkdownTable(w, []string{"Event", "Description", "Payload fields"}, rows)
+	}
+
+	for _, example := range endpoint.Examples {
+		content, err := json.MarshalIndent(example.Content, "", "  ")
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(w, "\n**Example %d**\n\n```json\n%s\n```\n", example.StatusCode, content)
+	}
+}
+
+// writeMarkdownFields lists the fields of a schema, nested objects as dotted names and the items of
+// arrays with []
+func writeMarkdownFields(w io.Writer, schema map[string]interface{}) {
+	var rows [][]string
+	var walk func(prefix string, schema map[string]interface{}, required bool)
+	walk = func(prefix string, schema map[string]interface{}, required bool) {
+		properties, _ := schema["properties"].(map[string]interface{})
+		if prefix != "" && prefix != "[]" {
+			rows = append(rows, []string{"`" + prefix + "`", markdownType(schema), markdownYes(required), markdownConstraints(schema)})
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			if _, nested := items["properties"]; nested {
+				walk(prefix+"[]", items, false)
+			}
+			return
+		}
+		requiredNames, _ := schema["required"].([]string)
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			walk(path, properties[name].(map[string]interface{}), slices.Contains(requiredNames, name))
+		}
+	}
+	walk("", schema, false)
+
+	if len(rows) == 0 {
+		fmt.Fprintf(w, "`%s`\n", markdownType(schema))
+		return
+	}
+	if schema["type"] == "array" {
+		fmt.Fprintf(w, "An array, `[]` is each item.\n\n")
+	}
+	writeMarkdownTable(w, []string{"Field", "Type", "Required", "Constraints"}, rows)
+}
+
+// markdownFieldNames lists the top level fields of an object schema
+func markdownFieldNames(schema map[string]interface{}) string {
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, "`"+name+"`")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func markdownType(schema map[string]interface{}) string {
+	t, _ := schema["type"].(string)
+	if t == "" {
+		t = "any"
+	}
+	if t == "array" {
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			return markdownType(items) + "[]"
+		}
+	}
+	if format, ok := schema["format"].(string); ok {
+		t += " (" + format + ")"
+	}
+	if nullable, _ := schema["nullable"].(bool); nullable {
+		t += ", nullable"
+	}
+	return t
+}
+
+// markdownConstraints renders the bounds and the allowed values of a schema, and those of its items
+func markdownConstraints(schema map[string]interface{}) string {
+	var parts []string
+	bounds := []struct{ key, label string }{
+		{"minimum", ">="}, {"maximum", "<="}, {"minLength", "min length"}, {"maxLength", "max length"},
+		{"minItems", "min items"}, {"maxItems", "max items"}, {"minProperties", "min entries"}, {"maxProperties", "max entries"},
+	}
+	for _, bound := range bounds {
+		if value, ok := schema[bound.key]; ok {
+			label := bound.label
+			if exclusive, _ := schema["exclusive"+strings.ToUpper(bound.key[:1])+bound.key[1:]].(bool); exclusive {
+				label = strings.TrimSuffix(label, "=")
+			}
+			parts = append(parts, fmt.Sprintf("%s %v", label, value))
+		}
+	}
+	if values, ok := schema["enum"].([]interface{}); ok {
+		texts := make([]string, len(values))
+		for i, value := range values {
+			texts[i] = fmt.Sprintf("`%v`", value)
+		}
+		parts = append(parts, "one of "+strings.Join(texts, ", "))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		parts = append(parts, "matches `"+pattern+"`")
+	}
+	if example, ok := schema["example"]; ok {
+		text, _ := json.Marshal(example)
+		parts = append(parts, "example `"+string(text)+"`")
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		if constraints := markdownConstraints(items); constraints != "" {
+			parts = append(parts, "items: "+constraints)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func writeMarkdownTable(w io.Writer, header []string, rows [][]string) {
+	escape := func(cell string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(cell, "|", "\\|"), "\n", " ")
+	}
+	fmt.Fprintf(w, "| %s |\n|%s\n", strings.Join(header, " | "), strings.Repeat(" --- |", len(header)))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escape(cell)
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+}
+
+func markdownYes(required bool) string {
+	if required {
+		return "yes"
+	}
+	return ""
+}
+
+// markdownAnchor is the heading anchor GitHub generates for text
+func markdownAnchor(text string) string {
+	return strings.ReplaceAll(strings.ToLower(text), " ", "-")
+}
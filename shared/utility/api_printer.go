@@ -1,10 +1,16 @@
 package utility
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"gopkg.in/yaml.v3"
@@ -17,22 +23,51 @@ type QueryParam struct {
 	Required    bool
 }
 
+// ListQueryParams documents the parameters of a core.ListQuery, fields are those the endpoint sorts and filters by
+func ListQueryParams(fields ...string) []QueryParam {
+	params := []QueryParam{
+		{Name: "page", Type: "integer", Description: "1 based page, default 1, the paging is in the metadata of the response"},
+		{Name: "size", Type: "integer", Description: "rows per page"},
+		{Name: "sort", Type: "string", Description: "comma separated fields of " + strings.Join(fields, ", ") + ", a leading - sorts descending"},
+	}
+	for _, field := range fields {
+		params = append(params, QueryParam{Name: "filter[" + field + "]", Type: "string", Description: "only rows whose " + field + " is equal to it"})
+	}
+	return params
+}
+
 type ExampleResponse struct {
 	StatusCode int
 	Content    interface{}
 }
 
+// FileResponse documents a route answering with a file download rather than the JSON envelope
+type FileResponse struct {
+	ContentTypes []string // media types the route can answer with, e.g. text/csv
+	Description  string
+}
+
+// Access is the level a caller needs for a route, every route declares one or Public explicitly
+type Access string
+
+// Public marks a route reachable without authentication
+const Public Access = "public"
+
 type APIData struct {
-	Method string
-	Url    string
-	// Access             model.Access
+	Method             string
+	Url                string
+	Access             Access
+	Roles              []string // roles of which the caller needs one on top of Access, empty means Access is enough
 	Body               any
+	ResponseBody       any // the data of a success, documented inside the Response envelope with the error responses
 	QueryParams        []QueryParam
 	Summary            string
 	Description        string
 	Tag                string
 	Examples           []ExampleResponse
 	MultipartFormParam []MultipartFormParam
+	File               *FileResponse // set for downloads, the 200 response is the file
+	Stream             bool          // an SSE stream, the 200 response lists the events of AddSSEEvent
 }
 
 type MultipartFormParam struct {
@@ -46,24 +81,124 @@ func (a APIData) GetMethodUrl() string {
 	return a.Method + " " + a.Url
 }
 
+// ApiPrinter collects the documented routes, routes can still be added after PublishAPI
+// because the served spec is generated from the current list on every request
 type ApiPrinter struct {
-	urls []APIData
+	mu        sync.RWMutex
+	urls      []APIData
+	published bool
+	baseURL   string // the server of the published spec
+	sseEvents []sseEventDoc
+	declared  []APIData // raw routes left out of the docs, known to Authorize only
+
+	// index of the routes by pattern for Authorize, rebuilt once routes were added
+	routeIndex   *http.ServeMux
+	routes       map[string]APIData
+	routeIndexed int
+}
+
+// sseEventDoc is an event documented on the stream routes
+type sseEventDoc struct {
+	Type        string
+	Payload     any
+	Description string
+}
+
+// AddSSEEvent documents an event the SSE streams send, payload is an example value whose type gives
+// the schema of the data. A later call for the same type replaces the earlier one.
+func (r *ApiPrinter) AddSSEEvent(eventType string, payload any, description string) *ApiPrinter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event := sseEventDoc{Type: eventType, Payload: payload, Description: description}
+	for i := range r.sseEvents {
+		if r.sseEvents[i].Type == eventType {
+			r.sseEvents[i] = event
+			return r
+		}
+	}
+	r.sseEvents = append(r.sseEvents, event)
+	return r
+}
+
+// events copies the documented events sorted by type
+func (r *ApiPrinter) events() []sseEventDoc {
+	r.mu.RLock()
+	events := append([]sseEventDoc(nil), r.sseEvents...)
+	r.mu.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Type < events[j].Type })
+	return events
 }
 
-func (r *ApiPrinter) Add(apiData APIData) *ApiPrinter {
-	r.urls = append(r.urls, apiData)
+// Add documents the routes, every one is validated whether it comes at startup or after the spec was
+// published, and none is added when one of them is invalid. A route added after PublishAPI is echoed
+// to the console.
+func (r *ApiPrinter) Add(routes ...APIData) error {
+	var errs []error
+	for _, apiData := range routes {
+		if err := validateRoute(apiData); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, apiData := range routes {
+		if r.published {
+			Infof("API added: %s %s %s", apiData.Method, apiData.Url, apiData.Access)
+		}
+		r.urls = append(r.urls, apiData)
+	}
+	return nil
+}
+
+// validateRoute refuses a route the docs or Authorize could not use
+func validateRoute(apiData APIData) error {
+	switch {
+	case apiData.Method == "" || apiData.Url == "":
+		return fmt.Errorf("route %q needs a method and a url", apiData.GetMethodUrl())
+	case !strings.HasPrefix(apiData.Url, "/"):
+		return fmt.Errorf("route %s: url must start with /", apiData.GetMethodUrl())
+	case apiData.Access == "":
+		return fmt.Errorf("route %s added without an access declaration (use utility.Public for open routes)", apiData.GetMethodUrl())
+	}
+	return nil
+}
+
+// HandleRaw registers a route the docs leave out, like the spec itself or the ack of the SSE stream,
+// together with its access so Authorize still enforces it. A route documented with Add is registered
+// by its controller instead, HandleRaw is the only way this package offers to serve an undocumented one.
+func (r *ApiPrinter) HandleRaw(mux *http.ServeMux, method, url string, access Access, handler http.Handler) *ApiPrinter {
+	mux.Handle(method+" "+url, handler)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.declared = append(r.declared, APIData{Method: method, Url: url, Access: access})
 	return r
 }
 
-func (r ApiPrinter) Print() ApiPrinter {
-	for _, v := range r.urls {
-		// fmt.Printf("%s %s %s\n", v.Method, v.Url, v.Access)
-		fmt.Printf("%s %s\n", v.Method, v.Url)
+// snapshot copies the routes so callers iterate without holding the lock
+func (r *ApiPrinter) snapshot() []APIData {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]APIData(nil), r.urls...)
+}
+
+func (r *ApiPrinter) Print() *ApiPrinter {
+	for _, v := range r.snapshot() {
+		fmt.Printf("%s %s %s\n", v.Method, v.Url, v.Access)
 	}
 	return r
 }
 
-func (r ApiPrinter) PrintAPIDataTable() ApiPrinter {
+// PrintAPIDataTable prints the routes registered so far, it can be called again after late registrations
+func (r *ApiPrinter) PrintAPIDataTable() *ApiPrinter {
 	// Define colors
 	headerColor := color.New(color.FgHiCyan, color.Bold)
 	// adminColor := color.New(color.FgRed)
@@ -85,7 +220,7 @@ func (r ApiPrinter) PrintAPIDataTable() ApiPrinter {
 
 	// Print each row
 	rowFormat := fmt.Sprintf("%%-%ds %%-%ds %%-%ds %%-%ds %%s\n", tagWidth, accessWidth, summaryWidth, methodWidth)
-	for _, item := range r.urls {
+	for _, item := range r.snapshot() {
 		// var rowColor *color.Color
 		// switch item.Access {
 		// case model.ADMIN_OPERATION:
@@ -99,7 +234,7 @@ func (r ApiPrinter) PrintAPIDataTable() ApiPrinter {
 		// }
 
 		tag := truncateOrPad(item.Tag, tagWidth)
-		access := truncateOrPad(getDescriptionFromAccess(), accessWidth)
+		access := truncateOrPad(getDescriptionFromAccess(item.Access), accessWidth)
 		summary := truncateOrPad(item.Summary, summaryWidth)
 		method := truncateOrPad(item.Method, methodWidth)
 		url := truncateOrPad(item.Url, urlWidth)
@@ -111,20 +246,32 @@ func (r ApiPrinter) PrintAPIDataTable() ApiPrinter {
 	return r
 }
 
-func getDescriptionFromAccess() string {
-	// if access == model.ANONYMOUS {
-	// 	return "ANONYMOUS"
-	// }
-
-	// if access == model.ADMIN_OPERATION {
-	// 	return "ADMIN"
-	// }
+func getDescriptionFromAccess(access Access) string {
+	if access == "" {
+		return "UNDECLARED"
+	}
+	return strings.ToUpper(string(access))
+}
 
-	// if access == model.DEFAULT_OPERATION {
-	// 	return "USER"
-	// }
+// ValidateAccess fails when a raw route of HandleRaw did not declare its access level, Add already
+// refuses such a documented route. Wiring calls it before serving so a forgotten declaration never
+// ships an open endpoint.
+func (r *ApiPrinter) ValidateAccess() error {
+	r.mu.RLock()
+	routes := append(append([]APIData(nil), r.urls...), r.declared...)
+	r.mu.RUnlock()
+
+	var undeclared []string
+	for _, item := range routes {
+		if item.Access == "" {
+			undeclared = append(undeclared, item.GetMethodUrl())
+		}
+	}
 
-	return "OTHERS"
+	if len(undeclared) > 0 {
+		return fmt.Errorf("routes without an access declaration (use utility.Public for open routes): %s", strings.Join(undeclared, ", "))
+	}
+	return nil
 }
 
 func truncateOrPad(s string, width int) string {
@@ -134,7 +281,7 @@ func truncateOrPad(s string, width int) string {
 	return fmt.Sprintf("%-*s", width, s)
 }
 
-func (r ApiPrinter) generateOpenAPISchema(baseURL string) OpenAPISchema {
+func (r *ApiPrinter) generateOpenAPISchema(baseURL string) OpenAPISchema {
 
 	schema := OpenAPISchema{
 		OpenAPI: "3.0.0",
@@ -155,7 +302,7 @@ func (r ApiPrinter) generateOpenAPISchema(baseURL string) OpenAPISchema {
 
 	uniqueTags := make(map[string]bool)
 
-	for _, endpoint := range r.urls {
+	for _, endpoint := range r.snapshot() {
 		path := endpoint.Url
 		method := strings.ToLower(endpoint.Method)
 
@@ -258,18 +405,86 @@ func (r ApiPrinter) generateOpenAPISchema(baseURL string) OpenAPISchema {
 			}
 		}
 
+		// a download answers with the file, sent as an attachment
+		if endpoint.File != nil {
+			content := map[string]interface{}{}
+			for _, contentType := range endpoint.File.ContentTypes {
+				content[contentType] = map[string]interface{}{
+					"schema": map[string]string{"type": "string", "format": "binary"},
+				}
+			}
+			description := endpoint.File.Description
+			if description == "" {
+				description = "File download"
+			}
+			operation["responses"].(map[string]interface{})["200"] = map[string]interface{}{
+				"description": description,
+				"headers": map[string]interface{}{
+					"Content-Disposition": map[string]interface{}{
+						"description": "attachment with the suggested file name",
+						"schema":      map[string]string{"type": "string"},
+					},
+				},
+				"content": content,
+			}
+		}
+
+		// a typed response documents the envelope of every status HandleUsecase answers with
+		if endpoint.ResponseBody != nil && endpoint.File == nil {
+			responses := operation["responses"].(map[string]interface{})
+			data, metadata := responseDataSchema(endpoint.ResponseBody)
+			errorData := map[string]interface{}{"nullable": true, "description": "detail of the error, when the usecase gives one"}
+			invalidData := map[string]interface{}{
+				"nullable":    true,
+				"description": "the message of every field that failed validation by its json path, or the detail the usecase gives",
+				"example":     map[string]string{"name": "is required"},
+			}
+			setResponseSchema(responses, "200", "Successful operation", responseEnvelopeSchema("success", false, data, metadata))
+			setResponseSchema(responses, "400", "Invalid request", responseEnvelopeSchema("failed", true, invalidData, nil))
+			setResponseSchema(responses, "500", "Internal server error", responseEnvelopeSchema("failed", true, errorData, nil))
+		}
+
+		// a stream answers with events, each documented as a schema the operation lists in x-sse-events
+		if endpoint.Stream {
+			var events []map[string]interface{}
+			for _, event := range r.events() {
+				doc := map[string]interface{}{"event": event.Type, "description": event.Description}
+				if event.Payload != nil {
+					name := "SSEEvent." + event.Type
+					schema.Components["schemas"] = withSchema(schema.Components["schemas"], name, generateBodySchema(event.Payload))
+					doc["payload"] = map[string]string{"$ref": "#/components/schemas/" + name}
+				}
+				events = append(events, doc)
+			}
+			operation["x-sse-events"] = events
+			operation["responses"].(map[string]interface{})["200"] = map[string]interface{}{
+				"description": "Server-sent events, every message is an event: line naming one of x-sse-events and a data: line with its JSON payload",
+				"content": map[string]interface{}{
+					"text/event-stream": map[string]interface{}{
+						"schema": map[string]string{"type": "string"},
+					},
+				},
+			}
+		}
+
 		// Add default 200 response if no examples provided
-		if len(endpoint.Examples) == 0 {
+		if len(endpoint.Examples) == 0 && endpoint.File == nil && endpoint.ResponseBody == nil && !endpoint.Stream {
 			operation["responses"].(map[string]interface{})["200"] = map[string]interface{}{
 				"description": "Successful operation",
 			}
 		}
 
-		// if endpoint.Access != "0" {
-		// 	operation["security"] = []map[string][]string{
-		// 		{"bearerAuth": {}},
-		// 	}
-		// }
+		if endpoint.Access != Public {
+			operation["security"] = []map[string][]string{
+				{"bearerAuth": {}},
+			}
+		}
+		if endpoint.Access != "" {
+			operation["x-access"] = endpoint.Access
+		}
+		if len(endpoint.Roles) > 0 {
+			operation["x-roles"] = endpoint.Roles
+		}
 
 		pathItem[method] = operation
 	}
@@ -289,40 +504,178 @@ func (r ApiPrinter) generateOpenAPISchema(baseURL string) OpenAPISchema {
 	return schema
 }
 
+// withSchema adds a named schema to the schemas of the components
+func withSchema(schemas interface{}, name string, schema map[string]interface{}) map[string]interface{} {
+	named, ok := schemas.(map[string]interface{})
+	if !ok {
+		named = map[string]interface{}{}
+	}
+	named[name] = schema
+	return named
+}
+
+// responseDataSchema is the schema of the data of a success and, for a core.ListPage, of its metadata
+func responseDataSchema(body any) (data, metadata map[string]interface{}) {
+	t := reflect.TypeOf(body)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*listResponse)(nil)).Elem()) {
+		items, _ := t.FieldByName("Items")
+		meta, _ := t.FieldByName("Metadata")
+		return generateSchema(items.Type), generateSchema(meta.Type)
+	}
+	return generateSchema(t), nil
+}
+
+// responseEnvelopeSchema is the schema of Response around data
+func responseEnvelopeSchema(status string, withError bool, data, metadata map[string]interface{}) map[string]interface{} {
+	errorSchema := map[string]interface{}{"type": "string", "nullable": true}
+	if withError {
+		errorSchema = map[string]interface{}{"type": "string"}
+	}
+	properties := map[string]interface{}{
+		"status": map[string]interface{}{"type": "string", "enum": []string{status}},
+		"error":  errorSchema,
+		"data":   data,
+	}
+	if metadata != nil {
+		properties["metadata"] = metadata
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   []string{"status", "error", "data"},
+	}
+}
+
+// setResponseSchema sets the json schema of a status, keeping the example of the route if it has one
+func setResponseSchema(responses map[string]interface{}, statusCode, description string, schema map[string]interface{}) {
+	response, ok := responses[statusCode].(map[string]interface{})
+	if !ok {
+		response = map[string]interface{}{"description": description}
+		responses[statusCode] = response
+	}
+	content, ok := response["content"].(map[string]interface{})
+	if !ok {
+		content = map[string]interface{}{}
+		response["content"] = content
+	}
+	media, ok := content["application/json"].(map[string]interface{})
+	if !ok {
+		media = map[string]interface{}{}
+		content["application/json"] = media
+	}
+	media["schema"] = schema
+}
+
 func generateBodySchema(body interface{}) map[string]interface{} {
 	return generateSchema(reflect.TypeOf(body))
 }
 
 func generateSchema(t reflect.Type) map[string]interface{} {
+	return generateSchemaOf(t, map[reflect.Type]bool{})
+}
+
+// generateSchemaOf builds the schema of t, visiting holds the structs being expanded so a type
+// referring back to itself, like a site listing its agents listing their site, stops as an object
+func generateSchemaOf(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
 	schema := map[string]interface{}{}
 
+	// time.Time marshals as an RFC 3339 string, not as its internal fields
+	if t == reflect.TypeOf(time.Time{}) {
+		schema["type"] = "string"
+		schema["format"] = "date-time"
+		return schema
+	}
+
+	// a null time marshaling itself, like gorm.DeletedAt, is a time or null
+	if t.Kind() == reflect.Struct && t.Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) {
+		_, hasTime := t.FieldByName("Time")
+		if valid, ok := t.FieldByName("Valid"); hasTime && ok && valid.Type.Kind() == reflect.Bool {
+			schema["type"] = "string"
+			schema["format"] = "date-time"
+			schema["nullable"] = true
+			return schema
+		}
+	}
+
 	switch t.Kind() {
 	case reflect.Struct:
 		schema["type"] = "object"
+		if visiting[t] {
+			return schema
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
 		properties := make(map[string]interface{})
+		var required []string
 		for i := 0; i < t.NumField(); i++ {
 			field := t.Field(i)
 			jsonTag := field.Tag.Get("json")
-			if jsonTag == "" {
-				jsonTag = field.Name
+			if jsonTag == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			name := strings.Split(jsonTag, ",")[0]
+
+			// the fields of an untagged embedded struct are promoted, like encoding/json does
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+				embedded := generateSchemaOf(fieldType, visiting)
+				for key, value := range embedded["properties"].(map[string]interface{}) {
+					if _, ok := properties[key]; !ok {
+						properties[key] = value
+					}
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
 			}
-			jsonTag = strings.Split(jsonTag, ",")[0]
 
-			fieldSchema := generateSchema(field.Type)
-			properties[jsonTag] = fieldSchema
+			fieldSchema := generateSchemaOf(field.Type, visiting)
+			if applyValidationRules(fieldSchema, field) {
+				required = append(required, name)
+			}
+			applyEnumExampleTags(fieldSchema, field)
+			properties[name] = fieldSchema
 		}
 		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
 
-	case reflect.Slice:
+	case reflect.Slice, reflect.Array:
+		// a byte slice marshals as base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			schema["type"] = "string"
+			schema["format"] = "byte"
+			return schema
+		}
 		schema["type"] = "array"
-		schema["items"] = generateSchema(t.Elem())
+		schema["items"] = generateSchemaOf(t.Elem(), visiting)
+
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = generateSchemaOf(t.Elem(), visiting)
 
 	case reflect.Ptr:
-		return generateSchema(t.Elem())
+		return generateSchemaOf(t.Elem(), visiting)
+
+	case reflect.Interface:
+		// any value, the schema says nothing about it
 
 	case reflect.String:
 		schema["type"] = "string"
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		schema["type"] = "integer"
 	case reflect.Float32, reflect.Float64:
 		schema["type"] = "number"
@@ -335,6 +688,155 @@ func generateSchema(t reflect.Type) map[string]interface{} {
 	return schema
 }
 
+// validationFormats are the validate rules naming a string format
+var validationFormats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"http_url": "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"ip":       "ip",
+	"ipv4":     "ipv4",
+	"ip4_addr": "ipv4",
+	"ipv6":     "ipv6",
+	"ip6_addr": "ipv6",
+	"cidr":     "cidr",
+	"cidrv4":   "cidr",
+	"cidrv6":   "cidr",
+	"hostname": "hostname",
+	"fqdn":     "hostname",
+	"datetime": "date-time",
+	"mac":      "mac",
+}
+
+// applyValidationRules adds the constraints of the validate, or else binding, tag of a field to its
+// schema, in the syntax of go-playground/validator: required, min, max, gte, lte, gt, lt, len, oneof,
+// regexp and the formats above, the rules Validate enforces. The rules after dive apply to the items of
+// a slice. It tells whether the field is required.
+func applyValidationRules(schema map[string]interface{}, field reflect.StructField) bool {
+	required, dived := false, false
+	target, t := schema, field.Type
+	for _, rule := range validationRules(field.Tag) {
+		name, param := rule.name, rule.param
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		// a bound is a length for strings and slices and a value for numbers
+		minKey, maxKey := "minimum", "maximum"
+		switch t.Kind() {
+		case reflect.String:
+			minKey, maxKey = "minLength", "maxLength"
+		case reflect.Slice, reflect.Array:
+			minKey, maxKey = "minItems", "maxItems"
+		case reflect.Map:
+			minKey, maxKey = "minProperties", "maxProperties"
+		}
+		number, err := strconv.ParseFloat(param, 64)
+		isNumber := err == nil
+
+		switch name {
+		case "dive":
+			items, ok := target["items"].(map[string]interface{})
+			if !ok {
+				return required
+			}
+			target, t, dived = items, t.Elem(), true
+		case "required":
+			// required after dive is about the items, which a schema cannot say
+			required = required || !dived
+		case "min", "gte":
+			if isNumber {
+				target[minKey] = number
+			}
+		case "max", "lte":
+			if isNumber {
+				target[maxKey] = number
+			}
+		case "gt":
+			if isNumber && minKey == "minimum" {
+				target[minKey] = number
+				target["exclusiveMinimum"] = true
+			} else if isNumber {
+				target[minKey] = number + 1
+			}
+		case "lt":
+			if isNumber && maxKey == "maximum" {
+				target[maxKey] = number
+				target["exclusiveMaximum"] = true
+			} else if isNumber {
+				target[maxKey] = number - 1
+			}
+		case "len":
+			if isNumber {
+				target[minKey] = number
+				target[maxKey] = number
+			}
+		case "oneof":
+			var values []interface{}
+			for _, value := range strings.Fields(param) {
+				if n, err := strconv.ParseFloat(value, 64); err == nil && minKey == "minimum" {
+					values = append(values, n)
+				} else {
+					values = append(values, strings.Trim(value, "'"))
+				}
+			}
+			target["enum"] = values
+		case "regexp":
+			target["pattern"] = param
+		default:
+			if format, ok := validationFormats[name]; ok {
+				target["format"] = format
+			}
+		}
+	}
+	return required
+}
+
+// applyEnumExampleTags reads the enum:"a,b,c" and example:"..." tags of a field, the enum of a slice
+// lists the values of its items. Values are converted to the type of the schema, an example of an
+// array or an object is written as JSON.
+func applyEnumExampleTags(schema map[string]interface{}, field reflect.StructField) {
+	if enum, ok := field.Tag.Lookup("enum"); ok {
+		target := schema
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			target = items
+		}
+		var values []interface{}
+		for _, value := range strings.Split(enum, ",") {
+			values = append(values, schemaValue(target, strings.TrimSpace(value)))
+		}
+		target["enum"] = values
+	}
+	if example, ok := field.Tag.Lookup("example"); ok {
+		schema["example"] = schemaValue(schema, example)
+	}
+}
+
+// schemaValue converts the text of a tag to the type of schema, text that does not convert stays text
+func schemaValue(schema map[string]interface{}, text string) interface{} {
+	switch schema["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case "array", "object":
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err == nil {
+			return value
+		}
+	}
+	return text
+}
+
 func generateMultipartFormSchema(params []MultipartFormParam) map[string]interface{} {
 	properties := make(map[string]interface{})
 	for _, param := range params {
@@ -371,27 +873,88 @@ type OpenAPISchema struct {
 	Tags       []map[string]string      `json:"tags,omitempty"`
 }
 
-func (r ApiPrinter) PublishAPI(mux *http.ServeMux, baseURL, apiURL string) ApiPrinter {
+// PublishAPI serves the spec at apiURL as YAML, or as JSON when the Accept header prefers
+// application/json, and always as JSON at apiURL.json for tools rejecting YAML
+func (r *ApiPrinter) PublishAPI(mux *http.ServeMux, baseURL, apiURL string) *ApiPrinter {
 
 	handler := func(w http.ResponseWriter, req *http.Request) {
+		r.writeSpec(w, acceptsJSON(req.Header.Get("Accept")))
+	}
 
-		obj := r.generateOpenAPISchema(baseURL)
+	r.HandleRaw(mux, http.MethodGet, apiURL, Public, http.HandlerFunc(handler)).
+		HandleRaw(mux, http.MethodGet, apiURL+".json", Public, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r.writeSpec(w, true)
+		}))
 
-		yamlData, err := yaml.Marshal(&obj)
+	r.mu.Lock()
+	r.published = true
+	r.baseURL = baseURL
+	r.mu.Unlock()
+
+	Infof("SWAGGER https://editor.swagger.io/?url=%s%s", baseURL, apiURL)
+
+	return r
+}
+
+// writeSpec writes the spec generated from the current routes, as YAML or JSON
+func (r *ApiPrinter) writeSpec(w http.ResponseWriter, asJSON bool) {
+
+	r.mu.RLock()
+	baseURL := r.baseURL
+	r.mu.RUnlock()
+
+	obj := r.generateOpenAPISchema(baseURL)
+	writeDocument(w, &obj, asJSON)
+}
+
+// writeDocument writes a spec as YAML or JSON, it varies by Accept since both are served at one url
+func writeDocument(w http.ResponseWriter, doc any, asJSON bool) {
+
+	w.Header().Add("Vary", "Accept")
+
+	if asJSON {
+		jsonData, err := json.Marshal(doc)
 		if err != nil {
-			http.Error(w, "Error creating YAML", http.StatusInternalServerError)
+			http.Error(w, "Error creating JSON", http.StatusInternalServerError)
 			return
 		}
-
-		w.Header().Set("Content-Type", "application/x-yaml")
-		w.Write(yamlData)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonData)
+		return
 	}
 
-	mux.HandleFunc("GET "+apiURL, handler)
+	yamlData, err := yaml.Marshal(doc)
+	if err != nil {
+		http.Error(w, "Error creating YAML", http.StatusInternalServerError)
+		return
+	}
 
-	fmt.Printf("\nSWAGGER https://editor.swagger.io/?url=%s%s\n", baseURL, apiURL)
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(yamlData)
+}
 
-	return r
+// acceptsJSON tells whether an Accept header prefers JSON over YAML, a tie keeps YAML which the spec
+// was always served as
+func acceptsJSON(accept string) bool {
+	bestJSON, bestYAML := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && name == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json":
+			bestJSON = max(bestJSON, quality)
+		case "application/x-yaml", "application/yaml", "text/yaml", "text/x-yaml":
+			bestYAML = max(bestYAML, quality)
+		}
+	}
+	return bestJSON > 0 && bestJSON > bestYAML
 }
 
 func NewApiPrinter() *ApiPrinter {
@@ -2,13 +2,14 @@ package utility
 
 import (
 	"context"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"reflect"
 	"shared/core"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,7 +24,7 @@ type Response struct {
 
 func internalServerError(w http.ResponseWriter, err error) {
 	msg := errors.New("internal server error").Error()
-	log.Println(err.Error()) // TODO create separate log here to make alert
+	Errorf("%v", err) // TODO create separate log here to make alert
 	WriteJSON(w, http.StatusInternalServerError, Response{
 		Status: "failed",
 		Error:  &msg,
@@ -32,11 +33,31 @@ func internalServerError(w http.ResponseWriter, err error) {
 }
 
 func badRequestError(w http.ResponseWriter, err error) {
+	failedError(w, http.StatusBadRequest, err)
+}
+
+func notFoundError(w http.ResponseWriter, err error) {
+	failedError(w, http.StatusNotFound, err)
+}
+
+func gatewayTimeoutError(w http.ResponseWriter, err error) {
+	failedError(w, http.StatusGatewayTimeout, err)
+}
+
+func failedError(w http.ResponseWriter, statusCode int, err error) {
 	msg := err.Error()
-	WriteJSON(w, http.StatusBadRequest, Response{
+
+	// errors created with core.NewErrorWithData carry extra detail for the caller
+	var data any
+	var errorWithData core.ErrorWithData
+	if errors.As(err, &errorWithData) {
+		data = errorWithData.Data
+	}
+
+	WriteJSON(w, statusCode, Response{
 		Status: "failed",
 		Error:  &msg,
-		Data:   nil,
+		Data:   data,
 	})
 }
 
@@ -57,6 +78,18 @@ func Fail(w http.ResponseWriter, err error) {
 		return
 	}
 
+	var notFound core.NotFoundError
+	if errors.As(err, &notFound) {
+		notFoundError(w, err)
+		return
+	}
+
+	var gatewayTimeout core.GatewayTimeoutError
+	if errors.As(err, &gatewayTimeout) {
+		gatewayTimeoutError(w, err)
+		return
+	}
+
 	badRequestError(w, err)
 }
 
@@ -65,7 +98,7 @@ func WriteJSON(w http.ResponseWriter, statusCode int, response Response) {
 	w.WriteHeader(statusCode)
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		Errorf("Error encoding response: %v", err)
 	}
 }
 
@@ -105,14 +138,32 @@ func (d *customDecoder) Token() (json.Token, error) {
 	return token, nil
 }
 
+// ParseJSON decodes the body of the request and checks it with Validate, a body that fails is answered
+// with a 400 listing the broken fields
 func ParseJSON[PayloadType any](w http.ResponseWriter, r *http.Request) (PayloadType, bool) {
 	var x PayloadType
 
 	decoder := &customDecoder{json.NewDecoder(r.Body)}
 
 	if err := decoder.Decode(&x); err != nil {
-		// if err := json.NewDecoder(r.Body).Decode(&x); err != nil {
-		badRequestError(w, fmt.Errorf("invalid request body %v", err.Error()))
+		Fail(w, bodyDecodeError(err))
+		return x, false
+	}
+
+	// the payload is the body, only its fields tagged header are read from elsewhere
+	if v := reflect.ValueOf(&x).Elem(); v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if tag := field.Tag.Get("http"); tag == "header" || strings.HasPrefix(tag, "header(") {
+				if !setRequestValue(w, r, tag, field, v.Field(i)) {
+					return x, false
+				}
+			}
+		}
+	}
+
+	if err := Validate(x); err != nil {
+		Fail(w, err)
 		return x, false
 	}
 	return x, true
@@ -164,9 +215,22 @@ func HandleUsecase[A any, B any](ctx context.Context, w http.ResponseWriter, use
 		Fail(w, err)
 		return
 	}
+	if page, ok := any(response).(listResponse); ok {
+		data, metadata := page.ListResponse()
+		WriteJSON(w, http.StatusOK, Response{Status: "success", Data: data, Metadata: metadata})
+		return
+	}
 	Success(w, response)
 }
 
+// listResponse is implemented by core.ListPage, its paging goes into the metadata of the response
+type listResponse interface {
+	ListResponse() (data any, metadata any)
+}
+
+// ExtractRequest fills a request struct from the parts of the request its http tags name: body, path,
+// query, header, list, context, now and func(key). It then checks the struct with Validate, the fields
+// of the body are named without a prefix in the errors.
 func ExtractRequest[RequestType any](w http.ResponseWriter, r *http.Request, url string, f ...func(key string) (any, error)) (RequestType, bool) {
 	var data RequestType
 	t := reflect.TypeOf(data)
@@ -188,13 +252,13 @@ func ExtractRequest[RequestType any](w http.ResponseWriter, r *http.Request, url
 
 		bodyValue := reflect.New(bodyField.Type).Interface()
 		if err := json.NewDecoder(r.Body).Decode(bodyValue); err != nil {
-			Fail(w, fmt.Errorf("failed to parse request body: %v", err))
+			Fail(w, bodyDecodeError(err))
 			return data, false
 		}
 		v.FieldByIndex(bodyField.Index).Set(reflect.ValueOf(bodyValue).Elem())
 	}
 
-	// Handle path, query, and context parameters
+	// Handle path, query, header and context parameters
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		tag := field.Tag.Get("http")
@@ -203,28 +267,22 @@ func ExtractRequest[RequestType any](w http.ResponseWriter, r *http.Request, url
 		}
 
 		switch {
-		case tag == "path":
-			pathValue := r.PathValue(field.Tag.Get("json"))
-			if err := setField(v.Field(i), pathValue); err != nil {
-				Fail(w, fmt.Errorf("failed to set path value: %v", err))
+		case tag == "path", tag == "query", tag == "header", strings.HasPrefix(tag, "header("):
+			if !setRequestValue(w, r, tag, field, v.Field(i)) {
 				return data, false
 			}
-		case tag == "query":
-			queryKey := field.Tag.Get("json")
-			switch field.Type.Kind() {
-			case reflect.Int:
-				value := GetQueryInt(r, queryKey, 0)
-				v.Field(i).SetInt(int64(value))
-			case reflect.Float64:
-				value := GetQueryFloat(r, queryKey, 0)
-				v.Field(i).SetFloat(value)
-			case reflect.String:
-				value := GetQueryString(r, queryKey, "")
-				v.Field(i).SetString(value)
-			default:
-				Fail(w, fmt.Errorf("unsupported type for query parameter: %v", field.Type.Kind()))
+		case tag == "list":
+			if field.Type != reflect.TypeOf(core.ListQuery{}) {
+				Fail(w, fmt.Errorf("field with http:\"list\" tag must be of type core.ListQuery"))
 				return data, false
 			}
+			list, err := listQueryFromRequest(r)
+			if err != nil {
+				Fail(w, err)
+				return data, false
+			}
+			v.Field(i).Set(reflect.ValueOf(list))
+
 		case tag == "context":
 			contextKey := field.Tag.Get("json")
 			contextValue := core.GetDataFromContext[any](r.Context(), core.ContextKey(contextKey))
@@ -238,7 +296,7 @@ func ExtractRequest[RequestType any](w http.ResponseWriter, r *http.Request, url
 				Fail(w, fmt.Errorf("field with http:\"now\" tag must be of type time.Time"))
 				return data, false
 			}
-			v.Field(i).Set(reflect.ValueOf(time.Now()))
+			v.Field(i).Set(reflect.ValueOf(core.GetClockFromContext(r.Context()).Now()))
 
 		case strings.HasPrefix(tag, "func("):
 			funcKey := strings.TrimSuffix(strings.TrimPrefix(tag, "func("), ")")
@@ -260,9 +318,79 @@ func ExtractRequest[RequestType any](w http.ResponseWriter, r *http.Request, url
 
 	}
 
+	if err := Validate(data); err != nil {
+		Fail(w, err)
+		return data, false
+	}
+
 	return data, true
 }
 
+// setRequestValue fills a field tagged path, query or header, a value it cannot hold fails the request
+func setRequestValue(w http.ResponseWriter, r *http.Request, tag string, field reflect.StructField, value reflect.Value) bool {
+	name, values := requestValues(r, tag, field)
+	if err := setField(value, values); err != nil {
+		if errors.Is(err, errUnsupportedField) {
+			Fail(w, core.NewInternalServerError(fmt.Errorf("field %s from %s: %v", field.Name, name, err)))
+		} else {
+			Fail(w, ValidationErrors{name: err.Error()}.err())
+		}
+		return false
+	}
+	return true
+}
+
+// requestValues are the values of the path, query or header parameter of a field, named by its json tag.
+// A header can be named by the tag instead, http:"header(If-None-Match)", header names are case
+// insensitive.
+func requestValues(r *http.Request, tag string, field reflect.StructField) (string, []string) {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch tag {
+	case "path":
+		return name, []string{r.PathValue(name)}
+	case "query":
+		return name, r.URL.Query()[name]
+	}
+	if header, ok := strings.CutPrefix(tag, "header("); ok {
+		name = strings.TrimSuffix(header, ")")
+	}
+	return name, r.Header.Values(name)
+}
+
+// listQueryFromRequest reads page, size, sort and the filter[<field>] parameters, a page or a size that
+// is not a positive integer is refused rather than read as unset
+func listQueryFromRequest(r *http.Request) (core.ListQuery, error) {
+	query := core.ListQuery{Sort: GetQueryString(r, "sort", "")}
+
+	invalid := ValidationErrors{}
+	for name, target := range map[string]*int{"page": &query.Page, "size": &query.Size} {
+		value := strings.TrimSpace(r.URL.Query().Get(name))
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			invalid[name] = "must be a positive integer"
+			continue
+		}
+		*target = n
+	}
+	if len(invalid) > 0 {
+		return query, invalid.err()
+	}
+	for key, values := range r.URL.Query() {
+		field, ok := strings.CutPrefix(key, "filter[")
+		if !ok || !strings.HasSuffix(field, "]") || len(values) == 0 {
+			continue
+		}
+		if query.Filters == nil {
+			query.Filters = map[string]string{}
+		}
+		query.Filters[strings.TrimSuffix(field, "]")] = values[0]
+	}
+	return query, nil
+}
+
 func findTaggedField(t reflect.Type, key, value string) (reflect.StructField, bool) {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
@@ -273,19 +401,117 @@ func findTaggedField(t reflect.Type, key, value string) (reflect.StructField, bo
 	return reflect.StructField{}, false
 }
 
-func setField(field reflect.Value, value string) error {
-	switch field.Kind() {
+// errUnsupportedField is the error of a path, query or header field of a type setField cannot parse
+var errUnsupportedField = errors.New("unsupported field type")
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// setField sets a path, query or header field from the values of its parameter, empty values are ignored and
+// a field without values keeps its zero value. A string, bool, int, uint, float, time.Duration, time.Time
+// (RFC 3339 or a date) or a type implementing encoding.TextUnmarshaler, like netip.Addr or a uuid, reads
+// the first value. A slice reads every value split at commas, so ?port=22,80&port=443 gives three ports.
+// A pointer is only set when there is a value.
+func setField(field reflect.Value, values []string) error {
+	values = slices.DeleteFunc(slices.Clone(values), func(value string) bool { return strings.TrimSpace(value) == "" })
+	if len(values) == 0 {
+		return nil
+	}
+	t := field.Type()
+	value := strings.TrimSpace(values[0])
+
+	switch {
+	case t.Kind() == reflect.Ptr:
+		target := reflect.New(t.Elem())
+		if err := setField(target.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(target)
+		return nil
+
+	case t == timeType:
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				field.Set(reflect.ValueOf(parsed))
+				return nil
+			}
+		}
+		return fmt.Errorf("must be an RFC 3339 time like 2024-01-02T15:04:05Z or a date like 2024-01-02, not %q", value)
+
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		if err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("must be a valid %s, not %q: %v", t, value, err)
+		}
+		return nil
+
+	case t == durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration like 30s or 1m30s, not %q", value)
+		}
+		field.SetInt(int64(duration))
+		return nil
+
+	case t.Kind() == reflect.Slice:
+		var items []string
+		for _, value := range values {
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := setField(slice.Index(i), []string{item}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	switch t.Kind() {
 	case reflect.String:
 		field.SetString(value)
-	case reflect.Int:
-		intValue, err := strconv.Atoi(value)
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "true", "1", "yes", "on":
+			field.SetBool(true)
+		case "false", "0", "no", "off":
+			field.SetBool(false)
+		default:
+			return fmt.Errorf("must be true or false, not %q", value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(value, 10, t.Bits())
+		if errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("must be between %d and %d, not %s", -(int64(1) << (t.Bits() - 1)), int64(1)<<(t.Bits()-1)-1, value)
+		}
 		if err != nil {
-			return err
+			return fmt.Errorf("must be an integer, not %q", value)
 		}
-		field.SetInt(int64(intValue))
-	// Add more cases for other types as needed
+		field.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(value, 10, t.Bits())
+		if errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("must be at most %d, not %s", ^uint64(0)>>(64-t.Bits()), value)
+		}
+		if err != nil {
+			return fmt.Errorf("must be a positive integer, not %q", value)
+		}
+		field.SetUint(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(value, t.Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number, not %q", value)
+		}
+		field.SetFloat(number)
 	default:
-		return fmt.Errorf("unsupported field type: %v", field.Kind())
+		return fmt.Errorf("%w %v", errUnsupportedField, t)
 	}
 	return nil
 }
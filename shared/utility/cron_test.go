@@ -0,0 +1,87 @@
+package utility
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 is a monday
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, second, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{expr: "*/15 * * * *", from: at(1, 0, 0, 30), want: at(1, 0, 15, 0)},
+		{expr: "*/15 * * * *", from: at(1, 0, 15, 0), want: at(1, 0, 30, 0)},
+		{expr: "5/10 * * * *", from: at(1, 0, 0, 30), want: at(1, 0, 5, 0)},
+		{expr: "5/10 * * * *", from: at(1, 0, 5, 0), want: at(1, 0, 15, 0)},
+		{expr: "0 9-17/4 * * *", from: at(1, 0, 0, 0), want: at(1, 9, 0, 0)},
+		{expr: "0 9-17/4 * * *", from: at(1, 9, 0, 0), want: at(1, 13, 0, 0)},
+		{expr: "0 9-17/4 * * *", from: at(1, 17, 0, 0), want: at(2, 9, 0, 0)},
+		{expr: "0,30 8 * * *", from: at(1, 8, 10, 0), want: at(1, 8, 30, 0)},
+		{expr: "30 2 * * 1-5", from: at(5, 3, 0, 0), want: at(8, 2, 30, 0)},
+
+		// a restricted day of month alone, a restricted weekday alone, 7 is sunday like 0
+		{expr: "0 0 1,15 * *", from: at(1, 0, 0, 30), want: at(15, 0, 0, 0)},
+		{expr: "0 0 13 * *", from: at(1, 0, 0, 0), want: at(13, 0, 0, 0)},
+		{expr: "0 0 * * 0", from: at(1, 0, 0, 0), want: at(7, 0, 0, 0)},
+		{expr: "0 0 * * 7", from: at(1, 0, 0, 0), want: at(7, 0, 0, 0)},
+		// both restricted, either one matching is enough: the friday before the 13th, then the 13th
+		{expr: "0 0 13 * 5", from: at(1, 0, 0, 0), want: at(5, 0, 0, 0)},
+		{expr: "0 0 13 * 5", from: at(12, 0, 0, 0), want: at(13, 0, 0, 0)},
+
+		{expr: "@daily", from: at(1, 0, 0, 30), want: at(2, 0, 0, 0)},
+		{expr: "@hourly", from: at(1, 0, 59, 59), want: at(1, 1, 0, 0)},
+		{expr: "@every 90m", from: at(1, 0, 0, 30), want: at(1, 1, 30, 30)},
+
+		// 30 february never comes
+		{expr: "0 0 30 2 *", from: at(1, 0, 0, 0), want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("next after %s: got %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextOfTheZeroValueNeverFires(t *testing.T) {
+	if got := (CronSchedule{}).Next(time.Now()); !got.IsZero() {
+		t.Fatalf("got %s, want the zero time", got)
+	}
+}
+
+func TestParseCronRefusesInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@every 500ms",
+		"@every soon",
+		"@fortnightly",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q): got no error", expr)
+		}
+	}
+}
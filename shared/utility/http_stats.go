@@ -0,0 +1,166 @@
+package utility
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPStats counts requests served by a handler or sent through a transport, counters are cumulative
+type HTTPStats struct {
+	requests atomic.Uint64
+	inFlight atomic.Int64
+	errors   atomic.Uint64    // transport errors, no response was received
+	status   [6]atomic.Uint64 // index 1 to 5 count responses by status class
+	finished atomic.Uint64
+	nanos    atomic.Int64 // total duration of finished requests
+	slowest  atomic.Int64 // longest finished request since the previous snapshot
+}
+
+// HTTPStatsSnapshot is what HTTPStats reports in a stats snapshot
+type HTTPStatsSnapshot struct {
+	Requests uint64            `json:"requests"`
+	InFlight int64             `json:"in_flight"`
+	Errors   uint64            `json:"errors,omitempty"`
+	Status   map[string]uint64 `json:"status"` // e.g. "2xx": 120
+	AvgMs    float64           `json:"avg_ms"`
+	// SlowestMs is the longest request finished since the previous snapshot
+	SlowestMs float64 `json:"slowest_ms"`
+}
+
+func NewHTTPStats() *HTTPStats {
+	return &HTTPStats{}
+}
+
+// Snapshot returns the counters and starts a new window for SlowestMs
+func (h *HTTPStats) Snapshot() HTTPStatsSnapshot {
+	snapshot := HTTPStatsSnapshot{
+		Requests:  h.requests.Load(),
+		InFlight:  h.inFlight.Load(),
+		Errors:    h.errors.Load(),
+		Status:    map[string]uint64{},
+		SlowestMs: float64(h.slowest.Swap(0)) / float64(time.Millisecond),
+	}
+	for class := 1; class < len(h.status); class++ {
+		if n := h.status[class].Load(); n > 0 {
+			snapshot.Status[fmt.Sprintf("%dxx", class)] = n
+		}
+	}
+
+	if finished := h.finished.Load(); finished > 0 {
+		snapshot.AvgMs = float64(h.nanos.Load()) / float64(finished) / float64(time.Millisecond)
+	}
+	return snapshot
+}
+
+func (h *HTTPStats) begin() time.Time {
+	h.requests.Add(1)
+	h.inFlight.Add(1)
+	return time.Now()
+}
+
+func (h *HTTPStats) end(started time.Time, statusCode int) {
+	h.inFlight.Add(-1)
+	h.finished.Add(1)
+
+	elapsed := int64(time.Since(started))
+	h.nanos.Add(elapsed)
+	for {
+		slowest := h.slowest.Load()
+		if elapsed <= slowest || h.slowest.CompareAndSwap(slowest, elapsed) {
+			break
+		}
+	}
+
+	if class := statusCode / 100; class >= 1 && class < len(h.status) {
+		h.status[class].Add(1)
+	} else {
+		h.errors.Add(1)
+	}
+}
+
+// Middleware counts the requests of next, streaming responses keep working because the
+// recorder passes Flush and Hijack through. Requests under one of the exclude path prefixes are
+// not counted, an SSE or WebSocket stream lasts as long as the client stays and would skew the
+// in flight count and the durations.
+func (h *HTTPStats) Middleware(next http.Handler, exclude ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range exclude {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		started := h.begin()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() { h.end(started, recorder.status) }()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// RoundTripper counts the requests sent through next, nil uses http.DefaultTransport
+func (h *HTTPStats) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		started := h.begin()
+		resp, err := next.RoundTrip(r)
+		if err != nil {
+			h.end(started, 0)
+			return nil, err
+		}
+		h.end(started, resp.StatusCode)
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	if !s.wroteHeader {
+		s.status = statusCode
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	// the WebSocket upgrade answers 101 on the raw connection
+	s.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the deadlines of the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
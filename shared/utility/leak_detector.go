@@ -0,0 +1,255 @@
+package utility
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LeakDetectorConfig controls how often the process is sampled and how much growth is a leak
+type LeakDetectorConfig struct {
+	Interval time.Duration // between samples, defaults to 30 seconds
+	// Window is the number of samples a growth is measured over, defaults to 10; history keeps 6 windows
+	Window          int
+	GoroutineGrowth int     // goroutines gained over a window without ever going down that warn, defaults to 50
+	FDGrowth        int     // same for open file descriptors, defaults to 20
+	Logger          *Logger // receives the warnings, defaults to DefaultLogger()
+}
+
+// LeakSample is the state of the process at one instant
+type LeakSample struct {
+	Time       time.Time `json:"time"`
+	Goroutines int       `json:"goroutines"`
+	// OpenFDs is -1 where the platform does not list the descriptors of a process, only Linux does
+	OpenFDs int              `json:"open_fds"`
+	Gauges  map[string]int64 `json:"gauges,omitempty"`
+}
+
+// LeakWarning is a suspected leak, Kind is goroutines, open_fds or the name of a failed check
+type LeakWarning struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Message string    `json:"message"`
+}
+
+// LeakReport is what the detector saw since start, for a debug endpoint or a stats section
+type LeakReport struct {
+	Baseline LeakSample    `json:"baseline"`
+	Latest   LeakSample    `json:"latest"`
+	Samples  []LeakSample  `json:"samples"`
+	Warnings []LeakWarning `json:"warnings"`
+	// Failing are the checks failing right now, by name
+	Failing map[string]string `json:"failing,omitempty"`
+}
+
+// LeakDetector samples the goroutine and descriptor counts of the process and runs consistency checks
+// between components, e.g. clients in a map against the streams actually running, so a soak test tells
+// which part leaks instead of only showing memory going up.
+type LeakDetector struct {
+	config LeakDetectorConfig
+
+	mu       sync.Mutex
+	gauges   map[string]func() int64
+	checks   map[string]func() error
+	failing  map[string]string // checks that failed on the last sample, a failure warns on the second one in a row
+	baseline *LeakSample
+	samples  []LeakSample
+	warnings []LeakWarning
+	// warned stops a steady growth from warning on every sample, it clears once the count goes down
+	warned map[string]bool
+}
+
+func NewLeakDetector(config LeakDetectorConfig) *LeakDetector {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+	if config.Window <= 1 {
+		config.Window = 10
+	}
+	if config.GoroutineGrowth <= 0 {
+		config.GoroutineGrowth = 50
+	}
+	if config.FDGrowth <= 0 {
+		config.FDGrowth = 20
+	}
+	if config.Logger == nil {
+		config.Logger = DefaultLogger()
+	}
+
+	return &LeakDetector{
+		config:  config,
+		gauges:  map[string]func() int64{},
+		checks:  map[string]func() error{},
+		failing: map[string]string{},
+		warned:  map[string]bool{},
+	}
+}
+
+// AddGauge records a count in every sample, such as the running streams, to read next to the goroutines
+func (d *LeakDetector) AddGauge(name string, gauge func() int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.gauges[name] = gauge
+}
+
+// AddCheck registers an invariant returning an error when broken. Counts kept by different goroutines
+// disagree for an instant while a connection opens or closes, so only a failure seen on two samples in
+// a row is a warning.
+func (d *LeakDetector) AddCheck(name string, check func() error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.checks[name] = check
+}
+
+// Run samples every interval until ctx ends
+func (d *LeakDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	d.Sample()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Sample()
+		}
+	}
+}
+
+// Sample takes one sample now, runs the checks and returns the warnings it raised
+func (d *LeakDetector) Sample() []LeakWarning {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sample := LeakSample{
+		Time:       time.Now().UTC(),
+		Goroutines: runtime.NumGoroutine(),
+		OpenFDs:    openFDs(),
+	}
+	if len(d.gauges) > 0 {
+		sample.Gauges = make(map[string]int64, len(d.gauges))
+		for name, gauge := range d.gauges {
+			sample.Gauges[name] = gauge()
+		}
+	}
+
+	if d.baseline == nil {
+		baseline := sample
+		d.baseline = &baseline
+	}
+	d.samples = append(d.samples, sample)
+	if limit := 6 * d.config.Window; len(d.samples) > limit {
+		d.samples = append(d.samples[:0], d.samples[len(d.samples)-limit:]...)
+	}
+
+	var raised []LeakWarning
+	warn := func(kind, format string, args ...any) {
+		warning := LeakWarning{Time: sample.Time, Kind: kind, Message: fmt.Sprintf(format, args...)}
+		d.config.Logger.Warnf("Leak detector: %s", warning.Message)
+		raised = append(raised, warning)
+	}
+
+	if growth, ok := d.growth(func(s LeakSample) int { return s.Goroutines }); ok && growth >= d.config.GoroutineGrowth {
+		if !d.warned["goroutines"] {
+			d.warned["goroutines"] = true
+			warn("goroutines", "goroutines grew by %d over the last %d samples without going down, %d now, %d at start",
+				growth, d.config.Window, sample.Goroutines, d.baseline.Goroutines)
+		}
+	} else {
+		delete(d.warned, "goroutines")
+	}
+	if sample.OpenFDs >= 0 {
+		if growth, ok := d.growth(func(s LeakSample) int { return s.OpenFDs }); ok && growth >= d.config.FDGrowth {
+			if !d.warned["open_fds"] {
+				d.warned["open_fds"] = true
+				warn("open_fds", "open file descriptors grew by %d over the last %d samples without going down, %d now, %d at start",
+					growth, d.config.Window, sample.OpenFDs, d.baseline.OpenFDs)
+			}
+		} else {
+			delete(d.warned, "open_fds")
+		}
+	}
+
+	names := make([]string, 0, len(d.checks))
+	for name := range d.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err := d.checks[name]()
+		if err == nil {
+			delete(d.failing, name)
+			continue
+		}
+		if _, failedBefore := d.failing[name]; failedBefore {
+			warn(name, "%s: %v", name, err)
+		}
+		d.failing[name] = err.Error()
+	}
+
+	d.warnings = append(d.warnings, raised...)
+	if len(d.warnings) > 100 {
+		d.warnings = append(d.warnings[:0], d.warnings[len(d.warnings)-100:]...)
+	}
+	return raised
+}
+
+// growth is the gain of value over the last window, ok is false when the window is not full yet or the
+// value went down inside it; a pool that grows and shrinks with the load is not a leak. mu must be held.
+func (d *LeakDetector) growth(value func(LeakSample) int) (int, bool) {
+	if len(d.samples) < d.config.Window {
+		return 0, false
+	}
+	window := d.samples[len(d.samples)-d.config.Window:]
+	for i := 1; i < len(window); i++ {
+		if value(window[i]) < value(window[i-1]) {
+			return 0, false
+		}
+	}
+	return value(window[len(window)-1]) - value(window[0]), true
+}
+
+// Report returns the samples and warnings kept, reading it does not take a sample so the growth windows
+// stay evenly spaced, except for the first one when Run has not sampled yet
+func (d *LeakDetector) Report() LeakReport {
+	d.mu.Lock()
+	empty := d.baseline == nil
+	d.mu.Unlock()
+	if empty {
+		d.Sample()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := LeakReport{
+		Baseline: *d.baseline,
+		Latest:   d.samples[len(d.samples)-1],
+		Samples:  append([]LeakSample(nil), d.samples...),
+		Warnings: append([]LeakWarning{}, d.warnings...),
+	}
+	if len(d.failing) > 0 {
+		report.Failing = make(map[string]string, len(d.failing))
+		for name, message := range d.failing {
+			report.Failing[name] = message
+		}
+	}
+	return report
+}
+
+// openFDs counts the descriptors of the process, -1 when /proc is not there
+func openFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	// ReadDir itself holds one descriptor on the directory while it lists it
+	return len(entries) - 1
+}
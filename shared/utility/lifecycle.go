@@ -0,0 +1,105 @@
+package utility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StopFunc stops one component, it should return once the component released its resources or ctx ended
+type StopFunc func(ctx context.Context) error
+
+type lifecycleHook struct {
+	name    string
+	timeout time.Duration
+	stop    StopFunc
+}
+
+// Lifecycle stops components in the reverse order they were registered, so a component
+// registered after its dependencies is stopped before them
+type Lifecycle struct {
+	mu             sync.Mutex
+	hooks          []lifecycleHook
+	defaultTimeout time.Duration
+	logger         *Logger
+	stopped        bool
+}
+
+// NewLifecycle creates a lifecycle whose hooks get defaultTimeout each unless registered with their own
+func NewLifecycle(defaultTimeout time.Duration, logger *Logger) *Lifecycle {
+	if defaultTimeout <= 0 {
+		defaultTimeout = 5 * time.Second
+	}
+	if logger == nil {
+		logger = DefaultLogger()
+	}
+	return &Lifecycle{defaultTimeout: defaultTimeout, logger: logger}
+}
+
+// Register adds a stop hook using the default timeout
+func (l *Lifecycle) Register(name string, stop StopFunc) {
+	l.RegisterWithTimeout(name, 0, stop)
+}
+
+// RegisterWithTimeout adds a stop hook with its own budget, zero uses the default timeout
+func (l *Lifecycle) RegisterWithTimeout(name string, timeout time.Duration, stop StopFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timeout <= 0 {
+		timeout = l.defaultTimeout
+	}
+	l.hooks = append(l.hooks, lifecycleHook{name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown runs every hook once in reverse order, a hook that fails or runs out of time does not
+// prevent the next ones from running. The errors of all failed hooks are returned together.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	if l.stopped {
+		l.mu.Unlock()
+		return nil
+	}
+	l.stopped = true
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+
+		start := time.Now()
+		if err := runStopHook(ctx, hook); err != nil {
+			l.logger.Errorf("Stopping %s failed after %s: %v", hook.name, time.Since(start).Round(time.Millisecond), err)
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+			continue
+		}
+		l.logger.Infof("Stopped %s in %s", hook.name, time.Since(start).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+// runStopHook returns once the hook finished or its timeout passed, a hook ignoring ctx is left behind
+func runStopHook(ctx context.Context, hook lifecycleHook) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- hook.stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,196 @@
+package utility
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LogLevel orders log messages by importance, messages below the level of a Logger are dropped
+type LogLevel int32
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+// ParseLogLevel reads debug, info, warn or error, case insensitive
+func ParseLogLevel(value string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return LogDebug, nil
+	case "info":
+		return LogInfo, nil
+	case "warn", "warning":
+		return LogWarn, nil
+	case "error":
+		return LogError, nil
+	}
+	return LogInfo, fmt.Errorf("unknown log level %q, use debug, info, warn or error", value)
+}
+
+// Logger is a leveled logger whose level can change while the process runs, e.g. debug for the
+// next ten minutes while an incident is investigated
+type Logger struct {
+	out   *log.Logger
+	level atomic.Int32
+	// parent owns the level of a logger made by WithPrefix
+	parent *Logger
+	prefix string
+
+	mu        sync.Mutex
+	revert    *time.Timer
+	revertsAt time.Time
+}
+
+func NewLogger(out *log.Logger, level LogLevel) *Logger {
+	logger := &Logger{out: out}
+	logger.level.Store(int32(level))
+	return logger
+}
+
+// WithPrefix is a logger writing through l with prefix before every message, it follows the level
+// of l so a change made on either one applies to both
+func (l *Logger) WithPrefix(prefix string) *Logger {
+	root := l
+	if l.parent != nil {
+		root = l.parent
+	}
+	return &Logger{out: l.out, parent: root, prefix: l.prefix + prefix}
+}
+
+// Level is the current level
+func (l *Logger) Level() LogLevel {
+	if l.parent != nil {
+		return l.parent.Level()
+	}
+	return LogLevel(l.level.Load())
+}
+
+// Enabled tells whether messages of level are written, for callers that build expensive messages
+func (l *Logger) Enabled(level LogLevel) bool {
+	return level >= l.Level()
+}
+
+// SetLevel changes the level until the next change, a pending revert of SetLevelFor is cancelled
+func (l *Logger) SetLevel(level LogLevel) LogLevel {
+	previous, _ := l.SetLevelFor(level, 0)
+	return previous
+}
+
+// SetLevelFor changes the level and goes back to the current one after duration, 0 keeps it.
+// A change during the duration starts from the level in effect, the original one is not restored.
+func (l *Logger) SetLevelFor(level LogLevel, duration time.Duration) (previous LogLevel, revertsAt time.Time) {
+	if l.parent != nil {
+		return l.parent.SetLevelFor(level, duration)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.revert != nil {
+		l.revert.Stop()
+		l.revert = nil
+		l.revertsAt = time.Time{}
+	}
+
+	previous = LogLevel(l.level.Swap(int32(level)))
+	if level != previous {
+		l.out.Printf("Log level changed from %s to %s", previous, level)
+	}
+
+	if duration > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			// a later change owns the level now
+			if l.revert != timer {
+				return
+			}
+			l.revert = nil
+			l.revertsAt = time.Time{}
+			l.level.Store(int32(previous))
+			l.out.Printf("Log level back to %s after %s", previous, duration)
+		})
+		l.revert = timer
+		l.revertsAt = time.Now().Add(duration)
+	}
+	return previous, l.revertsAt
+}
+
+// RevertsAt is when a temporary level ends, zero when the level is not temporary
+func (l *Logger) RevertsAt() time.Time {
+	if l.parent != nil {
+		return l.parent.RevertsAt()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.revertsAt
+}
+
+func (l *Logger) logf(level LogLevel, format string, args ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	// info is the everyday output and stays unmarked, like the plain log lines it replaced
+	if level != LogInfo {
+		message = strings.ToUpper(level.String()) + " " + message
+	}
+	l.out.Print(l.prefix + message)
+}
+
+func (l *Logger) Debugf(format string, args ...any) { l.logf(LogDebug, format, args...) }
+
+func (l *Logger) Infof(format string, args ...any) { l.logf(LogInfo, format, args...) }
+
+func (l *Logger) Warnf(format string, args ...any) { l.logf(LogWarn, format, args...) }
+
+func (l *Logger) Errorf(format string, args ...any) { l.logf(LogError, format, args...) }
+
+var defaultLogger atomic.Pointer[Logger]
+
+func init() {
+	defaultLogger.Store(NewLogger(log.Default(), LogInfo))
+}
+
+// SetDefaultLogger installs the process wide logger used by Debugf, Infof, Warnf and Errorf
+func SetDefaultLogger(logger *Logger) {
+	defaultLogger.Store(logger)
+}
+
+// DefaultLogger is the process wide logger, log.Default() at info unless replaced
+func DefaultLogger() *Logger {
+	return defaultLogger.Load()
+}
+
+func Debugf(format string, args ...any) { DefaultLogger().logf(LogDebug, format, args...) }
+
+func Infof(format string, args ...any) { DefaultLogger().logf(LogInfo, format, args...) }
+
+func Warnf(format string, args ...any) { DefaultLogger().logf(LogWarn, format, args...) }
+
+func Errorf(format string, args ...any) { DefaultLogger().logf(LogError, format, args...) }
@@ -0,0 +1,183 @@
+package utility
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"shared/core"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerResolverConfig describes where candidate servers come from
+type ServerResolverConfig struct {
+	URLs       []string      // Static candidates in priority order
+	SRVName    string        // Optional SRV record, e.g. _nms._tcp.example.com
+	SRVScheme  string        // Scheme used for SRV targets, defaults to https
+	HealthPath string        // Path probed to check a candidate, defaults to "/"
+	Cooldown   time.Duration // How long a failed candidate is skipped, defaults to 30s
+	HTTPClient *http.Client
+	Clock      core.Clock
+}
+
+// ServerResolver picks a healthy server out of static URLs and SRV records and fails over
+// to the next candidate when the active one is marked as failed
+type ServerResolver struct {
+	config ServerResolverConfig
+	mu     sync.RWMutex
+	active string
+	failed map[string]time.Time
+}
+
+func NewServerResolver(config ServerResolverConfig) (*ServerResolver, error) {
+	if len(config.URLs) == 0 && config.SRVName == "" {
+		return nil, fmt.Errorf("at least one server url or SRV name is required")
+	}
+	if config.SRVScheme == "" {
+		config.SRVScheme = "https"
+	}
+	if config.HealthPath == "" {
+		config.HealthPath = "/"
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	if config.Clock == nil {
+		config.Clock = core.RealClock{}
+	}
+
+	for i, u := range config.URLs {
+		config.URLs[i] = strings.TrimRight(strings.TrimSpace(u), "/")
+	}
+
+	return &ServerResolver{
+		config: config,
+		failed: make(map[string]time.Time),
+	}, nil
+}
+
+// Candidates returns static URLs followed by SRV targets ordered by priority and weight
+func (r *ServerResolver) Candidates(ctx context.Context) []string {
+	candidates := append([]string(nil), r.config.URLs...)
+
+	if r.config.SRVName == "" {
+		return candidates
+	}
+
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", r.config.SRVName)
+	if err != nil {
+		return candidates
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Priority != records[j].Priority {
+			return records[i].Priority < records[j].Priority
+		}
+		return records[i].Weight > records[j].Weight
+	})
+
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		candidates = append(candidates, fmt.Sprintf("%s://%s", r.config.SRVScheme, net.JoinHostPort(host, fmt.Sprint(record.Port))))
+	}
+
+	return candidates
+}
+
+// Active returns the currently selected server url, or the first static url when none was picked yet
+func (r *ServerResolver) Active() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.active != "" {
+		return r.active
+	}
+	if len(r.config.URLs) > 0 {
+		return r.config.URLs[0]
+	}
+	return ""
+}
+
+// Pick returns the active server if still usable or selects the first healthy candidate
+func (r *ServerResolver) Pick(ctx context.Context) (string, error) {
+	r.mu.RLock()
+	active := r.active
+	r.mu.RUnlock()
+
+	if active != "" {
+		return active, nil
+	}
+
+	candidates := r.Candidates(ctx)
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no server candidates available")
+	}
+
+	now := r.config.Clock.Now()
+
+	// first pass skips candidates in cooldown, second pass tries everything
+	for pass := 0; pass < 2; pass++ {
+		for _, candidate := range candidates {
+			if pass == 0 && r.inCooldown(candidate, now) {
+				continue
+			}
+
+			if err := r.healthCheck(ctx, candidate); err != nil {
+				r.MarkFailed(candidate)
+				continue
+			}
+
+			r.mu.Lock()
+			r.active = candidate
+			delete(r.failed, candidate)
+			r.mu.Unlock()
+
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no healthy server among %d candidates", len(candidates))
+}
+
+// MarkFailed drops the server as active so the next Pick fails over to another candidate
+func (r *ServerResolver) MarkFailed(serverURL string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failed[serverURL] = r.config.Clock.Now()
+	if r.active == serverURL {
+		r.active = ""
+	}
+}
+
+func (r *ServerResolver) inCooldown(serverURL string, now time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	failedAt, exists := r.failed[serverURL]
+	return exists && now.Sub(failedAt) < r.config.Cooldown
+}
+
+func (r *ServerResolver) healthCheck(ctx context.Context, serverURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL+r.config.HealthPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unhealthy status %d", resp.StatusCode)
+	}
+	return nil
+}
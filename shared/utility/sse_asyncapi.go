@@ -0,0 +1,143 @@
+package utility
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// AsyncAPIConfig describes where the event streams of the catalog are served
+type AsyncAPIConfig struct {
+	Title   string
+	Version string
+	BaseURL string // http(s) address of the server, e.g. http://localhost:8080
+	// Streams are the paths of the SSE streams, a path ending with /ws is the WebSocket form of the stream
+	Streams []string
+}
+
+// AsyncAPI builds an AsyncAPI 2.6 document of the catalog: a channel per stream, the events sent to
+// the clients as what they subscribe to and the events of the clients as what they publish. The
+// acknowledgement, the capabilities and the result endpoint of a command are x- extensions of its message.
+func (c *EventCatalog) AsyncAPI(config AsyncAPIConfig) map[string]any {
+
+	messages := map[string]any{}
+	var toClient, toServer []any
+	for _, spec := range c.Specs() {
+		message := map[string]any{
+			"name":          spec.Type,
+			"title":         spec.Type,
+			"contentType":   "application/json",
+			"x-require-ack": spec.RequireAck,
+		}
+		if spec.Summary != "" {
+			message["summary"] = spec.Summary
+		}
+		if spec.Payload != nil {
+			message["payload"] = generateSchema(reflect.TypeOf(spec.Payload))
+		}
+		if len(spec.Capabilities) > 0 {
+			message["x-required-capabilities"] = spec.Capabilities
+		}
+		if spec.ResultPath != "" {
+			message["x-result-path"] = spec.ResultPath
+			if spec.Result != nil {
+				message["x-result-schema"] = generateSchema(reflect.TypeOf(spec.Result))
+			}
+		}
+		messages[spec.Type] = message
+
+		ref := map[string]any{"$ref": "#/components/messages/" + spec.Type}
+		if spec.Direction == EventToServer {
+			toServer = append(toServer, ref)
+		} else {
+			toClient = append(toClient, ref)
+		}
+	}
+
+	host, scheme := config.BaseURL, "http"
+	if parsed, err := url.Parse(config.BaseURL); err == nil && parsed.Host != "" {
+		host, scheme = parsed.Host, parsed.Scheme
+	}
+	wsScheme := "ws"
+	if scheme == "https" {
+		wsScheme = "wss"
+	}
+
+	servers := map[string]any{
+		"sse": map[string]any{"url": host, "protocol": scheme, "description": "Server-sent events over " + strings.ToUpper(scheme)},
+	}
+	channels := map[string]any{}
+	for _, stream := range config.Streams {
+		server := "sse"
+		if strings.HasSuffix(stream, "/ws") {
+			server = "ws"
+			servers["ws"] = map[string]any{"url": host, "protocol": wsScheme, "description": "The same stream over a WebSocket"}
+		}
+
+		channel := map[string]any{
+			"description": "Stream of a client, it names itself with the client_id query parameter, without one the server makes up an id and sends it in the connected event",
+			"servers":     []string{server},
+		}
+		if len(toClient) > 0 {
+			channel["subscribe"] = map[string]any{
+				"operationId": operationID("receive", stream),
+				"summary":     "Events the server sends to the client",
+				"message":     map[string]any{"oneOf": toClient},
+			}
+		}
+		if len(toServer) > 0 {
+			channel["publish"] = map[string]any{
+				"operationId": operationID("send", stream),
+				"summary":     "Events the client sends to the server",
+				"message":     map[string]any{"oneOf": toServer},
+			}
+		}
+		channels[stream] = channel
+	}
+
+	title := config.Title
+	if title == "" {
+		title = "SSE events"
+	}
+	version := config.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	return map[string]any{
+		"asyncapi":           "2.6.0",
+		"info":               map[string]any{"title": title, "version": version},
+		"defaultContentType": "application/json",
+		"servers":            servers,
+		"channels":           channels,
+		"components":         map[string]any{"messages": messages},
+	}
+}
+
+// operationID names the operation of a stream, e.g. receiveApiSseConnect
+func operationID(verb, stream string) string {
+	var b strings.Builder
+	b.WriteString(verb)
+	for _, part := range strings.FieldsFunc(stream, func(r rune) bool { return r == '/' || r == '-' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// PublishAsyncAPI serves the AsyncAPI document of the catalog at url as YAML, or as JSON when the
+// Accept header prefers application/json, and always as JSON at url.json. The document is built on
+// every request so events registered later are included, both routes are declared public in routes.
+func (c *EventCatalog) PublishAsyncAPI(mux *http.ServeMux, routes *ApiPrinter, url string, config AsyncAPIConfig) *EventCatalog {
+
+	routes.HandleRaw(mux, http.MethodGet, url, Public, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeDocument(w, c.AsyncAPI(config), acceptsJSON(req.Header.Get("Accept")))
+	})).
+		HandleRaw(mux, http.MethodGet, url+".json", Public, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			writeDocument(w, c.AsyncAPI(config), true)
+		}))
+
+	Infof("ASYNCAPI %s%s", config.BaseURL, url)
+
+	return c
+}
@@ -0,0 +1,113 @@
+package utility
+
+import (
+	"context"
+	"sync"
+)
+
+// BrokerEnvelope is what travels between server instances through a Broker
+type BrokerEnvelope struct {
+	Origin    string   `json:"origin"` // Instance ID of the publishing server
+	Message   Message  `json:"message"`
+	ClientIDs []string `json:"client_ids,omitempty"` // Empty with no topic means broadcast
+	Topic     string   `json:"topic,omitempty"`
+	Group     string   `json:"group,omitempty"` // Each instance delivers to its local members of the group
+	Ack       *Ack     `json:"ack,omitempty"`   // Set when the envelope carries an acknowledgement instead of a message
+	// Presence is set when the envelope carries the clients of the origin instead of a message
+	Presence *PresenceSnapshot `json:"presence,omitempty"`
+}
+
+// Broker fans messages out to every SSEServer instance so a client connected to
+// any instance behind a load balancer can be reached
+type Broker interface {
+	Publish(ctx context.Context, envelope BrokerEnvelope) error
+	Subscribe(handler func(envelope BrokerEnvelope)) (unsubscribe func(), err error)
+	Close() error
+}
+
+// InMemoryBroker fans out between SSEServer instances living in the same process
+type InMemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[int]func(BrokerEnvelope)
+	nextID   int
+}
+
+func NewInMemoryBroker() *InMemoryBroker {
+	return &InMemoryBroker{handlers: make(map[int]func(BrokerEnvelope))}
+}
+
+func (b *InMemoryBroker) Publish(ctx context.Context, envelope BrokerEnvelope) error {
+	b.mu.RLock()
+	handlers := make([]func(BrokerEnvelope), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		go handler(envelope)
+	}
+	return nil
+}
+
+func (b *InMemoryBroker) Subscribe(handler func(BrokerEnvelope)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
+
+func (b *InMemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = make(map[int]func(BrokerEnvelope))
+	return nil
+}
+
+// handleBrokerEnvelope delivers a message published by another instance to local clients
+func (s *SSEServer) handleBrokerEnvelope(envelope BrokerEnvelope) {
+	if envelope.Origin == s.instanceID {
+		return
+	}
+
+	if envelope.Ack != nil {
+		s.acks.record(*envelope.Ack)
+		return
+	}
+
+	if envelope.Presence != nil {
+		if s.presence != nil {
+			s.presence.record(envelope.Origin, *envelope.Presence)
+		}
+		return
+	}
+
+	ctx := context.Background()
+
+	clientIDs := envelope.ClientIDs
+	if envelope.Topic != "" {
+		clientIDs = s.GetTopicSubscribers(envelope.Topic)
+		if len(clientIDs) == 0 {
+			return
+		}
+	}
+	if envelope.Group != "" {
+		clientIDs = s.GetGroupMembers(envelope.Group)
+		if len(clientIDs) == 0 {
+			return
+		}
+	}
+
+	if err := s.sendLocal(ctx, envelope.Message, clientIDs, true); err != nil {
+		s.errorLog.Printf("Failed to deliver message from instance %s: %v", envelope.Origin, err)
+	}
+}
@@ -0,0 +1,86 @@
+package utility
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBroker uses Redis pub/sub on a single channel to fan out between instances
+type RedisBroker struct {
+	client  *redis.Client
+	channel string
+	logger  *Logger
+}
+
+type RedisBrokerConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	Channel  string  // Defaults to "sse:broadcast"
+	Logger   *Logger // Defaults to the process wide logger with an [SSE-REDIS] prefix
+}
+
+func NewRedisBroker(config RedisBrokerConfig) (*RedisBroker, error) {
+	if config.Channel == "" {
+		config.Channel = "sse:broadcast"
+	}
+	if config.Logger == nil {
+		config.Logger = DefaultLogger().WithPrefix("[SSE-REDIS] ")
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Addr,
+		Password: config.Password,
+		DB:       config.DB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisBroker{
+		client:  client,
+		channel: config.Channel,
+		logger:  config.Logger,
+	}, nil
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, envelope BrokerEnvelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(handler func(BrokerEnvelope)) (func(), error) {
+	pubsub := b.client.Subscribe(context.Background(), b.channel)
+
+	// Wait for the subscription to be confirmed so no message published right after is missed
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", b.channel, err)
+	}
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			var envelope BrokerEnvelope
+			if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+				b.logger.Warnf("Ignoring invalid envelope: %v", err)
+				continue
+			}
+			handler(envelope)
+		}
+	}()
+
+	return func() { pubsub.Close() }, nil
+}
+
+func (b *RedisBroker) Close() error {
+	return b.client.Close()
+}
@@ -2,61 +2,431 @@ package utility
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"runtime/debug"
+	"shared/core"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // SSEClient adalah struct yang mengelola koneksi SSE dari sisi client
 type SSEClient struct {
-	serverURL    string
+	resolver     *ServerResolver
 	clientID     string
-	handlers     map[string][]EventHandlerFunc
+	handlers     map[string][]EventContextHandlerFunc
+	paused       chan struct{} // tidak nil selama dispatch ditahan, ditutup saat Resume
+	startupQueue chan pendingEvent
+	ready        chan struct{}
+	readyOnce    sync.Once
 	isConnected  bool
 	mu           sync.RWMutex
 	ctx          context.Context
 	cancel       context.CancelFunc
 	disconnected chan struct{}
+	closeOnce    sync.Once
+	topics       []string
+	events       []string
+	headers      map[string]string
+	metadata     map[string]string
+	bearerToken  string
+	clock        core.Clock
+	httpClient   *http.Client
+
+	autoReconnect       bool
+	reconnectMaxRetries int
+	reconnectBackoff    time.Duration
+	onReconnect         func(clientID string)
+	onConnected         func(clientID string)
+	onHandlerError      func(eventType string, err error)
+	onParseError        func(err SSEParseError)
+	maxEventSize        int
+	encodings           string        // ditawarkan ke server saat connect, kosong berarti tidak ada
+	transport           string        // TransportSSE atau TransportWebSocket
+	tlsConfig           *tls.Config   // dipakai koneksi WebSocket, nil berarti default
+	lastEventID         string        // id: dari event terakhir yang diterima
+	serverRetry         time.Duration // retry: terakhir dari server, menggantikan reconnectBackoff
+
+	// hitungan untuk Stats
+	handlerErrors   atomic.Uint64
+	handlerPanics   atomic.Uint64
+	handlerTimeouts atomic.Uint64
+	parseErrors     atomic.Uint64
+
+	// goroutine yang sedang berjalan, lebih dari satu pembaca berarti stream lama tidak pernah ditutup
+	readers      atomic.Int64
+	reconnecting atomic.Int64
+}
+
+// SSEParseError menjelaskan event yang dibuang karena tidak bisa diparse
+type SSEParseError struct {
+	EventType string // bisa kosong jika baris event belum terbaca
+	Reason    string
+}
+
+func (e SSEParseError) Error() string {
+	if e.EventType == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("event %s: %s", e.EventType, e.Reason)
+}
+
+// HandlerPanicError adalah error yang dilaporkan saat handler panic, Stack berisi stack trace goroutine
+type HandlerPanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e HandlerPanicError) Error() string {
+	return fmt.Sprintf("handler panic: %v", e.Value)
+}
+
+// HandlerTimeoutError dilaporkan saat handler melewati batas waktu dari WithHandlerTimeout,
+// context handler sudah dibatalkan dan event berikutnya diproses tanpa menunggu handler itu selesai
+type HandlerTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e HandlerTimeoutError) Error() string {
+	return fmt.Sprintf("handler melewati batas waktu %v", e.Timeout)
+}
+
+// SSEClientStats adalah hitungan kegagalan sejak client dibuat
+type SSEClientStats struct {
+	HandlerErrors   uint64 `json:"handler_errors"` // termasuk panic dan timeout
+	HandlerPanics   uint64 `json:"handler_panics"`
+	HandlerTimeouts uint64 `json:"handler_timeouts"`
+	ParseErrors     uint64 `json:"parse_errors"`
+	// Readers adalah readEvents yang berjalan, Reconnecting adalah reconnect yang sedang mencoba
+	Readers      int64 `json:"readers"`
+	Reconnecting int64 `json:"reconnecting"`
+}
+
+// HandlerOption mengatur handler saat didaftarkan
+type HandlerOption func(handler EventContextHandlerFunc) EventContextHandlerFunc
+
+// WithHandlerTimeout membatasi lama satu pemanggilan handler, handler yang tidak menghormati
+// ctx tetap berjalan di background tapi tidak lagi menahan event berikutnya
+func WithHandlerTimeout(timeout time.Duration) HandlerOption {
+	return func(handler EventContextHandlerFunc) EventContextHandlerFunc {
+		if timeout <= 0 {
+			return handler
+		}
+		return func(ctx context.Context, eventData []byte) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- callHandler(ctx, handler, eventData)
+			}()
+
+			select {
+			case err := <-done:
+				// handler yang berhenti karena ctx habis tetap dihitung sebagai timeout
+				if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return HandlerTimeoutError{Timeout: timeout}
+				}
+				return err
+			case <-ctx.Done():
+				return HandlerTimeoutError{Timeout: timeout}
+			}
+		}
+	}
+}
+
+// pendingEvent adalah event yang sudah dibaca tapi belum didispatch ke handler
+type pendingEvent struct {
+	eventType string
+	data      string
+	id        string
+	ackID     string
+	trace     string // traceparent dari server, kosong jika pesan tidak di-trace
+	encoding  string // encoding data dari field encoding:, kosong berarti JSON apa adanya
+	serverURL string
 }
 
 // EventHandlerFunc adalah function signature untuk handler event
 type EventHandlerFunc func(eventData []byte) error
 
+// EventContextHandlerFunc adalah handler yang menerima context berisi trace dari pesan,
+// dipakai agar pekerjaan handler dan request balik ke server masuk ke trace yang sama
+type EventContextHandlerFunc func(ctx context.Context, eventData []byte) error
+
 // SSEClientConfig berisi konfigurasi untuk SSE client
 type SSEClientConfig struct {
-	ServerURL string
-	ClientID  string // Optional, akan dibuat oleh server jika kosong
+	ServerURL  string
+	ServerURLs []string   // Optional, kandidat server tambahan untuk failover
+	ServerSRV  string     // Optional, nama record SRV untuk discovery server
+	ClientID   string     // Optional, akan dibuat oleh server jika kosong
+	Clock      core.Clock // Optional, default ke waktu sebenarnya
+	SPKIPins   []string   // Optional, hash SPKI (base64 sha256) yang diizinkan untuk koneksi TLS
+	Topics     []string   // Optional, topic yang diminta saat connect
+	Events     []string   // Optional, hanya tipe event ini yang dikirim server, kosong berarti semua
+
+	Headers     map[string]string // Optional, header tambahan untuk request connect (mis. API key)
+	BearerToken string            // Optional, dikirim sebagai header Authorization: Bearer <token>
+	Metadata    map[string]string // Optional, metadata client (hostname, versi agent, label) dikirim sebagai meta_<key>
+
+	// AutoReconnect membuat client menyambung ulang otomatis saat stream putus di tengah jalan
+	AutoReconnect       bool
+	ReconnectMaxRetries int                   // Optional, 0 berarti mencoba terus tanpa batas
+	ReconnectBackoff    time.Duration         // Optional, backoff awal, default 1 detik, diganti retry: dari server
+	OnReconnect         func(clientID string) // Optional, dipanggil setiap kali reconnect berhasil
+	// OnConnected dipanggil setiap kali server mengonfirmasi koneksi lewat event connected, termasuk koneksi
+	// pertama. Dipanggil dari goroutine pembaca stream sebelum event berikutnya dibaca, jadi harus cepat
+	OnConnected func(clientID string)
+
+	// StartupBufferSize > 0 menampung event yang datang sebelum MarkReady dipanggil,
+	// sehingga perintah yang tiba saat wiring belum selesai tidak hilang
+	StartupBufferSize int
+
+	// OnHandlerError dipanggil setiap kali handler mengembalikan error atau panic (sebagai HandlerPanicError),
+	// default hanya mencetak ke stdout
+	OnHandlerError func(eventType string, err error)
+
+	// MaxEventSize membatasi ukuran data satu event, default 1 MiB. Event yang lebih besar
+	// dibuang dan dilaporkan lewat OnParseError, stream tetap tersambung
+	MaxEventSize int
+	OnParseError func(err SSEParseError) // Optional, default hanya mencetak ke stdout
+
+	// DisableCompression membuat client tidak menawarkan gzip saat connect, baik untuk data event maupun
+	// untuk seluruh stream (Accept-Encoding), server lalu mengirim semuanya apa adanya
+	DisableCompression bool
+
+	// Transport memilih TransportSSE (default) atau TransportWebSocket untuk jaringan yang proxy-nya
+	// menahan atau memutus stream SSE, handler dan event yang diterima sama persis
+	Transport string
 }
 
 // NewSSEClient membuat instance baru SSEClient
-func NewSSEClient(config SSEClientConfig) *SSEClient {
+func NewSSEClient(config SSEClientConfig) (*SSEClient, error) {
+	transport, err := NewHTTPTransport(config.SPKIPins)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Clock == nil {
+		config.Clock = core.RealClock{}
+	}
+
+	var serverURLs []string
+	if config.ServerURL != "" {
+		serverURLs = append(serverURLs, config.ServerURL)
+	}
+	serverURLs = append(serverURLs, config.ServerURLs...)
+
+	resolver, err := NewServerResolver(ServerResolverConfig{
+		URLs:       serverURLs,
+		SRVName:    config.ServerSRV,
+		HTTPClient: &http.Client{Transport: transport, Timeout: 5 * time.Second},
+		Clock:      config.Clock,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxEventSize <= 0 {
+		config.MaxEventSize = 1 << 20
+	}
+
+	if config.ReconnectBackoff <= 0 {
+		config.ReconnectBackoff = 1 * time.Second
+	}
+
+	var tlsConfig *tls.Config
+	switch config.Transport {
+	case "":
+		config.Transport = TransportSSE
+	case TransportSSE:
+	case TransportWebSocket:
+		// koneksi WebSocket tidak lewat http.Transport, pin SPKI dipasang langsung di sini
+		if len(config.SPKIPins) > 0 {
+			if tlsConfig, err = NewPinnedTLSConfig(config.SPKIPins); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("transport %q tidak dikenal, pilih %s atau %s", config.Transport, TransportSSE, TransportWebSocket)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &SSEClient{
-		serverURL:    config.ServerURL,
+	client := &SSEClient{
+		resolver:     resolver,
 		clientID:     config.ClientID,
-		handlers:     make(map[string][]EventHandlerFunc),
+		handlers:     make(map[string][]EventContextHandlerFunc),
 		isConnected:  false,
 		ctx:          ctx,
 		cancel:       cancel,
 		disconnected: make(chan struct{}),
+		topics:       config.Topics,
+		events:       config.Events,
+		headers:      config.Headers,
+		metadata:     config.Metadata,
+		bearerToken:  config.BearerToken,
+		clock:        config.Clock,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   0, // Tidak ada timeout untuk koneksi SSE
+		},
+		autoReconnect:       config.AutoReconnect,
+		reconnectMaxRetries: config.ReconnectMaxRetries,
+		reconnectBackoff:    config.ReconnectBackoff,
+		onReconnect:         config.OnReconnect,
+		onConnected:         config.OnConnected,
+		onHandlerError:      config.OnHandlerError,
+		onParseError:        config.OnParseError,
+		maxEventSize:        config.MaxEventSize,
+		transport:           config.Transport,
+		tlsConfig:           tlsConfig,
+	}
+	if !config.DisableCompression {
+		client.encodings = EncodingGzip
+	}
+
+	if config.StartupBufferSize > 0 {
+		client.startupQueue = make(chan pendingEvent, config.StartupBufferSize)
+		client.ready = make(chan struct{})
+		go client.dispatchStartupQueue()
+	}
+
+	return client, nil
+}
+
+// MarkReady menandai semua handler sudah terdaftar, event yang tertampung mulai didispatch
+// sesuai urutan datangnya. Tanpa StartupBufferSize pemanggilan ini tidak berpengaruh.
+func (c *SSEClient) MarkReady() {
+	if c.ready == nil {
+		return
+	}
+	c.readyOnce.Do(func() {
+		close(c.ready)
+	})
+}
+
+// dispatchStartupQueue meneruskan event dari antrian ke handler setelah MarkReady
+func (c *SSEClient) dispatchStartupQueue() {
+	select {
+	case <-c.ready:
+	case <-c.ctx.Done():
+		return
+	}
+
+	for {
+		select {
+		case event := <-c.startupQueue:
+			c.processEvent(event)
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch langsung memproses event, atau memasukkannya ke antrian startup jika aktif.
+// Saat antrian penuh pembacaan stream ikut tertahan sehingga tidak ada event yang dibuang.
+func (c *SSEClient) dispatch(event pendingEvent) {
+	if event.eventType == "connected" {
+		c.captureClientID(event.data)
+	}
+
+	if c.startupQueue == nil {
+		c.processEvent(event)
+		return
+	}
+
+	select {
+	case c.startupQueue <- event:
+	case <-c.ctx.Done():
 	}
 }
 
 // AddEventHandler menambahkan handler untuk event tertentu
-func (c *SSEClient) AddEventHandler(eventType string, handler EventHandlerFunc) {
+func (c *SSEClient) AddEventHandler(eventType string, handler EventHandlerFunc, options ...HandlerOption) {
+	c.AddEventContextHandler(eventType, withoutContext(handler), options...)
+}
+
+// AddEventContextHandler menambahkan handler yang menerima context trace dari pesan
+func (c *SSEClient) AddEventContextHandler(eventType string, handler EventContextHandlerFunc, options ...HandlerOption) {
+	for _, option := range options {
+		handler = option(handler)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.handlers[eventType] == nil {
-		c.handlers[eventType] = []EventHandlerFunc{}
+	c.handlers[eventType] = append(c.handlers[eventType], handler)
+}
+
+func withoutContext(handler EventHandlerFunc) EventContextHandlerFunc {
+	return func(ctx context.Context, eventData []byte) error {
+		return handler(eventData)
 	}
+}
 
-	c.handlers[eventType] = append(c.handlers[eventType], handler)
+// SetHandlers mengganti seluruh handler sekaligus secara atomik,
+// event yang diproses setelahnya hanya melihat set handler yang baru
+func (c *SSEClient) SetHandlers(handlers map[string][]EventHandlerFunc) {
+	replacement := make(map[string][]EventContextHandlerFunc, len(handlers))
+	for eventType, list := range handlers {
+		for _, handler := range list {
+			replacement[eventType] = append(replacement[eventType], withoutContext(handler))
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = replacement
+}
+
+// Pause menahan dispatch event ke handler sampai Resume dipanggil.
+// Stream tetap terbuka dan event berikutnya menunggu, tidak ada yang dibuang.
+func (c *SSEClient) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused == nil {
+		c.paused = make(chan struct{})
+	}
+}
+
+// Resume melanjutkan dispatch event yang tertahan oleh Pause
+func (c *SSEClient) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused != nil {
+		close(c.paused)
+		c.paused = nil
+	}
+}
+
+// waitIfPaused menunggu sampai dispatch dilanjutkan, false jika client ditutup lebih dulu
+func (c *SSEClient) waitIfPaused() bool {
+	c.mu.RLock()
+	paused := c.paused
+	c.mu.RUnlock()
+
+	if paused == nil {
+		return true
+	}
+
+	select {
+	case <-paused:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
 }
 
 // Connect membuat koneksi ke SSE server
@@ -68,34 +438,37 @@ func (c *SSEClient) Connect() error {
 	}
 	c.mu.Unlock()
 
-	return c.connectWithRetry(10, 1*time.Second)
+	return c.connectWithRetry(10, c.retryBackoff())
 }
 
-// connectWithRetry mencoba koneksi dengan backoff eksponensial
+// connectWithRetry mencoba koneksi dengan backoff eksponensial,
+// maxRetries <= 0 berarti mencoba terus sampai client ditutup
 func (c *SSEClient) connectWithRetry(maxRetries int, initialBackoff time.Duration) error {
 	var err error
 	retryCount := 0
 	backoff := initialBackoff
 
-	for retryCount < maxRetries {
+	for maxRetries <= 0 || retryCount < maxRetries {
 		err = c.establishConnection()
 		if err == nil {
 			return nil // Koneksi berhasil
 		}
 
 		retryCount++
-		fmt.Printf("Koneksi gagal (attempt %d/%d): %v. Mencoba kembali dalam %v...\n",
-			retryCount, maxRetries, err, backoff)
+		if maxRetries > 0 {
+			Warnf("Koneksi gagal (attempt %d/%d): %v. Mencoba kembali dalam %v...",
+				retryCount, maxRetries, err, backoff)
+		} else {
+			Warnf("Koneksi gagal (attempt %d): %v. Mencoba kembali dalam %v...",
+				retryCount, err, backoff)
+		}
 
 		select {
 		case <-c.ctx.Done():
 			return c.ctx.Err()
-		case <-time.After(backoff):
-			// Tingkatkan backoff untuk percobaan berikutnya
-			backoff *= 2
-			if backoff > 1*time.Minute {
-				backoff = 1 * time.Minute
-			}
+		case <-c.clock.After(backoff):
+			// Tingkatkan backoff untuk percobaan berikutnya, batas 1 menit kecuali server meminta lebih lama
+			backoff = min(backoff*2, max(1*time.Minute, initialBackoff))
 		}
 	}
 
@@ -104,31 +477,73 @@ func (c *SSEClient) connectWithRetry(maxRetries int, initialBackoff time.Duratio
 
 // establishConnection membuat koneksi ke server SSE
 func (c *SSEClient) establishConnection() error {
-	var sseURL string
-	if c.clientID != "" {
-		sseURL = fmt.Sprintf("%s/api/sse/connect?client_id=%s", c.serverURL, c.clientID)
-	} else {
-		sseURL = fmt.Sprintf("%s/api/sse/connect", c.serverURL)
+	// Pilih server yang sehat, server yang gagal akan dilewati sehingga terjadi failover
+	serverURL, err := c.resolver.Pick(c.ctx)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Menghubungkan ke SSE endpoint: %s\n", sseURL)
+	// clientID yang sama dipakai lagi agar server mengenali sesi sebelumnya
+	clientID := c.GetClientID()
+
+	query := url.Values{}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	if len(c.topics) > 0 {
+		query.Set("topics", strings.Join(c.topics, ","))
+	}
+	if len(c.events) > 0 {
+		query.Set(eventsQueryParam, strings.Join(c.events, ","))
+	}
+	for key, value := range c.metadata {
+		query.Set("meta_"+key, value)
+	}
+	if c.encodings != "" {
+		query.Set(encodingsQueryParam, c.encodings)
+	}
+
+	if c.transport == TransportWebSocket {
+		return c.establishWebSocket(serverURL, query)
+	}
+
+	sseURL := fmt.Sprintf("%s/api/sse/connect", serverURL)
+	if len(query) > 0 {
+		sseURL += "?" + query.Encode()
+	}
+
+	Infof("Menghubungkan ke SSE endpoint: %s", sseURL)
 
 	req, err := http.NewRequestWithContext(c.ctx, "GET", sseURL, nil)
 	if err != nil {
 		return fmt.Errorf("error membuat request: %v", err)
 	}
 
-	client := &http.Client{
-		Timeout: 0, // Tidak ada timeout untuk koneksi SSE
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	// diisi sendiri agar deflate juga ditawarkan, dekompresi dilakukan decompressStream bukan http.Transport
+	if c.encodings != "" {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	} else {
+		req.Header.Set("Accept-Encoding", "identity")
 	}
 
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.resolver.MarkFailed(serverURL)
 		return fmt.Errorf("error menghubungi server: %v", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			c.resolver.MarkFailed(serverURL)
+		}
 		return fmt.Errorf("server mengembalikan status non-OK: %d", resp.StatusCode)
 	}
 
@@ -137,84 +552,296 @@ func (c *SSEClient) establishConnection() error {
 	c.isConnected = true
 	c.mu.Unlock()
 
-	fmt.Println("Koneksi SSE berhasil dibuat")
+	Infof("Koneksi SSE berhasil dibuat")
 
-	// Start goroutine untuk membaca events
-	go c.readEvents(resp)
+	// Start goroutine untuk membaca events, header gzip baru terbaca setelah server menulis frame pertama
+	go func() {
+		body, err := decompressStream(resp)
+		if err != nil {
+			Errorf("Error membaca event: %v", err)
+			resp.Body.Close()
+			c.handleDisconnect()
+			return
+		}
+		c.readEvents(body, serverURL)
+	}()
 
 	return nil
 }
 
-// readEvents membaca event dari respons SSE
-func (c *SSEClient) readEvents(resp *http.Response) {
-	defer resp.Body.Close()
+// readEvents membaca event dari respons SSE sesuai spesifikasi: beberapa baris data digabung
+// dengan newline, dan event yang melebihi maxEventSize dibuang tanpa memutus stream
+func (c *SSEClient) readEvents(body io.ReadCloser, serverURL string) {
+	defer body.Close()
 	defer c.handleDisconnect()
+	// dihitung berhenti sebelum handleDisconnect memulai reconnect
+	c.readers.Add(1)
+	defer c.readers.Add(-1)
+
+	reader := bufio.NewReaderSize(body, 64*1024)
+
+	var event pendingEvent
+	var data strings.Builder
+	hasData := false
+	discard := false // event sedang dibuang karena terlalu besar, tunggu baris kosong berikutnya
+
+	reset := func() {
+		event = pendingEvent{}
+		data.Reset()
+		hasData = false
+		discard = false
+	}
 
-	scanner := bufio.NewScanner(resp.Body)
-	var eventType string
-	var eventData string
+	for {
+		line, tooLong, err := readSSELine(reader, c.maxEventSize)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && c.ctx.Err() == nil {
+				Errorf("Error membaca event: %v", err)
+			}
+			break
+		}
 
-	for scanner.Scan() {
-		select {
-		case <-c.ctx.Done():
+		if c.ctx.Err() != nil {
 			return
-		default:
-			line := scanner.Text()
+		}
+
+		if tooLong {
+			if !discard {
+				discard = true
+				c.reportParseError(SSEParseError{EventType: event.eventType, Reason: fmt.Sprintf("baris melebihi %d byte", c.maxEventSize)})
+			}
+			continue
+		}
 
-			// Skip keepalive comments
-			if strings.HasPrefix(line, ":") {
-				continue
+		// baris kosong menutup event
+		if line == "" {
+			if event.id != "" && !discard {
+				c.mu.Lock()
+				c.lastEventID = event.id
+				c.mu.Unlock()
 			}
+			if hasData && !discard {
+				event.serverURL = serverURL
+				if event.eventType == "" {
+					event.eventType = "message"
+				}
+				if decoded, err := decodeData(event.encoding, data.String(), c.maxEventSize); err != nil {
+					c.reportParseError(SSEParseError{EventType: event.eventType, Reason: err.Error()})
+				} else {
+					event.data = decoded
+					c.dispatch(event)
+				}
+			}
+			reset()
+			continue
+		}
+
+		// Skip keepalive comments
+		if strings.HasPrefix(line, ":") || discard {
+			continue
+		}
 
-			// Parse event type
-			if strings.HasPrefix(line, "event: ") {
-				eventType = strings.TrimPrefix(line, "event: ")
-			} else if strings.HasPrefix(line, "data: ") {
-				eventData = strings.TrimPrefix(line, "data: ")
-			} else if line == "" && eventType != "" && eventData != "" {
-				// Event complete, proses
-				c.processEvent(eventType, eventData)
-				eventType = ""
-				eventData = ""
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.eventType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+			if data.Len() > c.maxEventSize {
+				discard = true
+				c.reportParseError(SSEParseError{EventType: event.eventType, Reason: fmt.Sprintf("data melebihi %d byte", c.maxEventSize)})
+			}
+		case "id":
+			// tidak ikut event yang dibuang, nilai dengan NULL diabaikan sesuai spesifikasi
+			if !strings.Contains(value, "\x00") {
+				event.id = value
 			}
+		case "retry":
+			// hanya angka desimal (milidetik) yang berlaku, nilai lain diabaikan sesuai spesifikasi
+			if ms, err := strconv.ParseUint(value, 10, 32); err == nil && ms > 0 {
+				c.mu.Lock()
+				c.serverRetry = time.Duration(ms) * time.Millisecond
+				c.mu.Unlock()
+			}
+		case "ack-id":
+			event.ackID = value
+		case TraceparentHeader:
+			event.trace = value
+		case "encoding":
+			event.encoding = value
 		}
+		// field lain diabaikan sesuai spesifikasi
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error membaca event: %v\n", err)
+	// Stream putus dari sisi server, koneksi berikutnya akan memilih kandidat lain
+	if c.ctx.Err() == nil {
+		c.resolver.MarkFailed(serverURL)
 	}
 }
 
-// processEvent memproses event dari server
-func (c *SSEClient) processEvent(eventType, eventData string) {
-	// Khusus untuk event connected, simpan clientID
-	if eventType == "connected" {
-		var connectEvent struct {
-			ClientID string `json:"client_id"`
+// readSSELine membaca satu baris tanpa CRLF/LF, baris yang melebihi max dibaca sampai habis lalu dibuang
+func readSSELine(reader *bufio.Reader, max int) (string, bool, error) {
+	var line []byte
+	tooLong := false
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(chunk) > max+2 { // +2 untuk CRLF
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
 		}
-		if err := json.Unmarshal([]byte(eventData), &connectEvent); err == nil {
-			c.mu.Lock()
-			c.clientID = connectEvent.ClientID
-			c.mu.Unlock()
-			fmt.Printf("Terhubung dengan client ID: %s\n", connectEvent.ClientID)
+		if err != nil {
+			return "", false, err
+		}
+		break
+	}
+
+	if tooLong {
+		return "", true, nil
+	}
+	return strings.TrimRight(string(line), "\r\n"), false, nil
+}
+
+func (c *SSEClient) reportParseError(err SSEParseError) {
+	c.parseErrors.Add(1)
+	if c.onParseError != nil {
+		c.onParseError(err)
+		return
+	}
+	Warnf("Event SSE dibuang: %v", err)
+}
+
+// captureClientID menyimpan clientID dari event connected
+func (c *SSEClient) captureClientID(eventData string) {
+	var connectEvent struct {
+		ClientID string `json:"client_id"`
+	}
+	if err := json.Unmarshal([]byte(eventData), &connectEvent); err == nil {
+		c.mu.Lock()
+		c.clientID = connectEvent.ClientID
+		c.mu.Unlock()
+		Infof("Terhubung dengan client ID: %s", connectEvent.ClientID)
+		if c.onConnected != nil {
+			c.onConnected(connectEvent.ClientID)
 		}
 	}
+}
+
+// processEvent memproses event dari server, jika ackID ada maka ack dikirim setelah semua handler sukses
+func (c *SSEClient) processEvent(event pendingEvent) {
+	// clientID sudah disimpan oleh dispatch walau dispatch sedang ditahan
+	if !c.waitIfPaused() {
+		return
+	}
 
 	// Panggil semua handler untuk event ini
 	c.mu.RLock()
-	handlers, exists := c.handlers[eventType]
+	handlers, exists := c.handlers[event.eventType]
 	c.mu.RUnlock()
 
 	if !exists {
-		fmt.Printf("Menerima event tanpa handler: %s\n", eventType)
+		Debugf("Menerima event tanpa handler: %s", event.eventType)
 		return
 	}
 
+	// handler melanjutkan trace pengirim, span hanya dibuat jika pesan membawa traceparent
+	ctx := context.Background()
+	if parent, ok := ParseTraceparent(event.trace); ok {
+		var span *Span
+		ctx, span = StartSpan(ContextWithTrace(ctx, parent), "sse.handle "+event.eventType)
+		span.SetAttribute("sse.client_id", c.GetClientID())
+		defer span.End()
+	}
+
+	succeeded := true
 	for _, handler := range handlers {
-		if err := handler([]byte(eventData)); err != nil {
-			fmt.Printf("Error pada handler untuk event %s: %v\n", eventType, err)
+		if err := callHandler(ctx, handler, []byte(event.data)); err != nil {
+			c.reportHandlerError(event.eventType, err)
+			succeeded = false
 		}
 	}
+
+	if event.ackID != "" && succeeded {
+		go c.sendAck(event.serverURL, event.ackID)
+	}
+}
+
+// callHandler menjalankan handler dan mengubah panic menjadi error agar goroutine pembaca stream tidak ikut mati
+func callHandler(ctx context.Context, handler EventContextHandlerFunc, data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = HandlerPanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return handler(ctx, data)
+}
+
+func (c *SSEClient) reportHandlerError(eventType string, err error) {
+	c.handlerErrors.Add(1)
+	var panicErr HandlerPanicError
+	var timeoutErr HandlerTimeoutError
+	switch {
+	case errors.As(err, &panicErr):
+		c.handlerPanics.Add(1)
+	case errors.As(err, &timeoutErr):
+		c.handlerTimeouts.Add(1)
+	}
+
+	if c.onHandlerError != nil {
+		c.onHandlerError(eventType, err)
+		return
+	}
+	Errorf("Error pada handler untuk event %s: %v", eventType, err)
+}
+
+// sendAck memberi tahu server bahwa pesan sudah diproses
+func (c *SSEClient) sendAck(serverURL, messageID string) {
+	body, err := json.Marshal(Ack{ClientID: c.GetClientID(), MessageID: messageID})
+	if err != nil {
+		Errorf("Error membuat ack untuk pesan %s: %v", messageID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL+"/api/sse/ack", bytes.NewReader(body))
+	if err != nil {
+		Errorf("Error membuat request ack: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		Errorf("Gagal mengirim ack untuk pesan %s: %v", messageID, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		Errorf("Server menolak ack untuk pesan %s: status %d", messageID, resp.StatusCode)
+	}
 }
 
 // handleDisconnect menangani saat koneksi terputus
@@ -223,8 +850,40 @@ func (c *SSEClient) handleDisconnect() {
 	c.isConnected = false
 	c.mu.Unlock()
 
-	fmt.Println("Koneksi SSE terputus")
-	close(c.disconnected)
+	Warnf("Koneksi SSE terputus")
+
+	// Reconnect hanya dilakukan jika stream putus bukan karena Close
+	if c.autoReconnect && c.ctx.Err() == nil {
+		go c.reconnect()
+		return
+	}
+
+	c.markDisconnected()
+}
+
+// reconnect menyambung ulang ke server dengan clientID dan handler yang sama
+func (c *SSEClient) reconnect() {
+	c.reconnecting.Add(1)
+	defer c.reconnecting.Add(-1)
+
+	Infof("Mencoba menyambung ulang ke server SSE...")
+
+	if err := c.connectWithRetry(c.reconnectMaxRetries, c.retryBackoff()); err != nil {
+		Errorf("Gagal menyambung ulang: %v", err)
+		c.markDisconnected()
+		return
+	}
+
+	if c.onReconnect != nil {
+		c.onReconnect(c.GetClientID())
+	}
+}
+
+// markDisconnected menandai client terputus permanen, aman dipanggil berkali-kali
+func (c *SSEClient) markDisconnected() {
+	c.closeOnce.Do(func() {
+		close(c.disconnected)
+	})
 }
 
 // IsConnected mengembalikan status koneksi
@@ -241,7 +900,59 @@ func (c *SSEClient) GetClientID() string {
 	return c.clientID
 }
 
-// WaitForDisconnect menunggu hingga koneksi terputus
+// ActiveServerURL mengembalikan URL server yang sedang dipakai
+func (c *SSEClient) ActiveServerURL() string {
+	return c.resolver.Active()
+}
+
+// retryBackoff adalah backoff awal reconnect: retry: dari server jika pernah dikirim, selain itu ReconnectBackoff
+func (c *SSEClient) retryBackoff() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.serverRetry > 0 {
+		return c.serverRetry
+	}
+	return c.reconnectBackoff
+}
+
+// Stats mengembalikan jumlah error handler dan parse sejak client dibuat
+func (c *SSEClient) Stats() SSEClientStats {
+	return SSEClientStats{
+		HandlerErrors:   c.handlerErrors.Load(),
+		HandlerPanics:   c.handlerPanics.Load(),
+		HandlerTimeouts: c.handlerTimeouts.Load(),
+		ParseErrors:     c.parseErrors.Load(),
+		Readers:         c.readers.Load(),
+		Reconnecting:    c.reconnecting.Load(),
+	}
+}
+
+// LeakCheck memeriksa goroutine koneksi untuk LeakDetector.AddCheck: paling banyak satu readEvents dan
+// satu reconnect, dan tidak ada pembaca selama reconnect karena reconnect baru dimulai setelah pembaca berhenti
+func (c *SSEClient) LeakCheck() error {
+	readers := c.readers.Load()
+	reconnecting := c.reconnecting.Load()
+
+	switch {
+	case readers > 1:
+		return fmt.Errorf("%d readEvents berjalan bersamaan", readers)
+	case reconnecting > 1:
+		return fmt.Errorf("%d reconnect berjalan bersamaan", reconnecting)
+	case readers > 0 && reconnecting > 0 && !c.IsConnected():
+		return fmt.Errorf("readEvents masih berjalan saat reconnect")
+	}
+	return nil
+}
+
+// LastEventID mengembalikan id: dari event terakhir yang diterima, kosong jika server tidak mengirimnya
+func (c *SSEClient) LastEventID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastEventID
+}
+
+// WaitForDisconnect menunggu hingga koneksi terputus permanen
+// (Close dipanggil atau reconnect menyerah)
 func (c *SSEClient) WaitForDisconnect() {
 	<-c.disconnected
 }
@@ -0,0 +1,77 @@
+package utility
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+// Transport yang bisa dipilih lewat SSEClientConfig.Transport
+const (
+	TransportSSE       = "sse"
+	TransportWebSocket = "websocket"
+)
+
+// establishWebSocket membuka stream yang sama dengan /api/sse/connect lewat /api/sse/ws,
+// setiap pesan WebSocket berisi frame SSE sehingga pembacaannya tetap lewat readEvents
+func (c *SSEClient) establishWebSocket(serverURL string, query url.Values) error {
+	location, err := url.Parse(strings.TrimSuffix(serverURL, "/") + "/api/sse/ws")
+	if err != nil {
+		return fmt.Errorf("URL server tidak valid: %v", err)
+	}
+	switch location.Scheme {
+	case "https":
+		location.Scheme = "wss"
+	default:
+		location.Scheme = "ws"
+	}
+	location.RawQuery = query.Encode()
+
+	Infof("Menghubungkan ke WebSocket endpoint: %s", location)
+
+	config, err := websocket.NewConfig(location.String(), serverURL)
+	if err != nil {
+		return fmt.Errorf("error membuat konfigurasi WebSocket: %v", err)
+	}
+	config.TlsConfig = c.tlsConfig
+	for key, value := range c.headers {
+		config.Header.Set(key, value)
+	}
+	if c.bearerToken != "" {
+		config.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	conn, err := config.DialContext(c.ctx)
+	if err != nil {
+		// status handshake tidak terlihat dari error, server dianggap gagal agar failover berjalan
+		c.resolver.MarkFailed(serverURL)
+		return fmt.Errorf("error membuka WebSocket: %v", err)
+	}
+
+	// Update status koneksi
+	c.mu.Lock()
+	c.isConnected = true
+	c.mu.Unlock()
+
+	Infof("Koneksi WebSocket berhasil dibuat")
+
+	// koneksi ditutup saat client di-Close, seperti request SSE yang dibatalkan lewat ctx
+	stop := context.AfterFunc(c.ctx, func() { conn.Close() })
+	go c.readEvents(wsBody{Conn: conn, stop: stop}, serverURL)
+
+	return nil
+}
+
+// wsBody membuat koneksi WebSocket terbaca seperti body respons SSE
+type wsBody struct {
+	*websocket.Conn
+	stop func() bool
+}
+
+func (b wsBody) Close() error {
+	b.stop()
+	return b.Conn.Close()
+}
@@ -0,0 +1,78 @@
+package utility
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Command pairs a server to agent event with its payload and the result the agent posts back, so the
+// event name and both structs are declared once and shared by the server and the agents
+type Command[Req, Res any] struct {
+	Event      string
+	Summary    string
+	RequireAck bool
+	// ResultPath is the endpoint the agent posts Res to, {id} stands for the id given to ResultURL
+	ResultPath string
+	// Capabilities are the metadata keys an agent reports as true when it handles the command
+	Capabilities []string
+	// Aliases are former names of Event, the server keeps writing the command under them for agents
+	// released before the rename, see SSEServer.AliasEvent
+	Aliases []string
+}
+
+func NewCommand[Req, Res any](event, summary, resultPath string) Command[Req, Res] {
+	return Command[Req, Res]{Event: event, Summary: summary, RequireAck: true, ResultPath: resultPath}
+}
+
+// WithCapabilities is the command handled only by agents reporting every one of capabilities
+func (c Command[Req, Res]) WithCapabilities(capabilities ...string) Command[Req, Res] {
+	c.Capabilities = capabilities
+	return c
+}
+
+// WithAliases is the command also written under the former event names aliases
+func (c Command[Req, Res]) WithAliases(aliases ...string) Command[Req, Res] {
+	c.Aliases = aliases
+	return c
+}
+
+// Spec is the catalog entry of the command, the schemas come from Req and Res. Commands without a
+// result path are answered by the ack alone.
+func (c Command[Req, Res]) Spec() EventSpec {
+	var payload Req
+	spec := EventSpec{
+		Type:         c.Event,
+		Direction:    EventToClient,
+		Summary:      c.Summary,
+		Payload:      payload,
+		RequireAck:   c.RequireAck,
+		Capabilities: c.Capabilities,
+	}
+	if c.ResultPath != "" {
+		var result Res
+		spec.Result = result
+		spec.ResultPath = c.ResultPath
+	}
+	return spec
+}
+
+// Message is the message carrying payload, the sender still picks the recipients and the message id
+func (c Command[Req, Res]) Message(payload Req) Message {
+	return Message{EventType: c.Event, Data: payload}
+}
+
+// Decode reads the payload of a received event
+func (c Command[Req, Res]) Decode(data []byte) (Req, error) {
+	var payload Req
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return payload, fmt.Errorf("error parsing %s payload: %v", c.Event, err)
+	}
+	return payload, nil
+}
+
+// ResultURL is the path the result for id is posted to
+func (c Command[Req, Res]) ResultURL(id string) string {
+	return strings.ReplaceAll(c.ResultPath, "{id}", url.PathEscape(id))
+}
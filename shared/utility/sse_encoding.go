@@ -0,0 +1,97 @@
+package utility
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Envelope encodings of the data field. A compressed frame carries an encoding: field and its
+// data is the base64 of the compressed JSON, so it stays a single valid SSE data line.
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+)
+
+const (
+	// encodingsQueryParam lists the encodings a client decodes in order of preference,
+	// browsers cannot set headers on EventSource so the query param is what clients send
+	encodingsQueryParam = "encodings"
+	encodingsHeader     = "X-SSE-Accept-Encoding"
+
+	// EncodingMetaKey is the client metadata key holding the encoding chosen at connect
+	EncodingMetaKey = "sse_encoding"
+)
+
+// negotiateEncoding picks the first encoding of the client preference the server supports,
+// a client that advertises nothing, like a browser or an older agent, gets identity
+func negotiateEncoding(r *http.Request) string {
+	offer := r.URL.Query().Get(encodingsQueryParam)
+	if offer == "" {
+		offer = r.Header.Get(encodingsHeader)
+	}
+
+	for _, encoding := range strings.Split(offer, ",") {
+		switch strings.ToLower(strings.TrimSpace(encoding)) {
+		case EncodingGzip:
+			return EncodingGzip
+		case EncodingIdentity:
+			return EncodingIdentity
+		}
+	}
+	return EncodingIdentity
+}
+
+func encodeData(encoding string, data []byte) ([]byte, error) {
+	if encoding != EncodingGzip {
+		return data, nil
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(compressed.Len()))
+	base64.StdEncoding.Encode(encoded, compressed.Bytes())
+	return encoded, nil
+}
+
+// decodeData reverses encodeData, the decoded data is limited to maxSize bytes
+func decodeData(encoding, data string, maxSize int) (string, error) {
+	switch encoding {
+	case "", EncodingIdentity:
+		return data, nil
+	case EncodingGzip:
+	default:
+		return "", fmt.Errorf("encoding %s tidak didukung", encoding)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("data gzip bukan base64: %w", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("data gzip rusak: %w", err)
+	}
+	defer zr.Close()
+
+	decoded, err := io.ReadAll(io.LimitReader(zr, int64(maxSize)+1))
+	if err != nil {
+		return "", fmt.Errorf("data gzip rusak: %w", err)
+	}
+	if len(decoded) > maxSize {
+		return "", fmt.Errorf("data melebihi %d byte setelah dekompresi", maxSize)
+	}
+	return string(decoded), nil
+}
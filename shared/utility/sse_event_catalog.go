@@ -0,0 +1,120 @@
+package utility
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// EventDirection tells who sends an event
+type EventDirection string
+
+const (
+	EventToClient EventDirection = "server_to_client"
+	EventToServer EventDirection = "client_to_server"
+)
+
+// EventSpec describes one SSE event type, the payload is an example value whose type gives the schema
+type EventSpec struct {
+	Type         string
+	Direction    EventDirection
+	Summary      string
+	Payload      any
+	RequireAck   bool
+	Capabilities []string // metadata keys an agent must report as true to handle the event
+
+	// Result is an example of what the agent posts to ResultPath once it carried out a command
+	Result     any
+	ResultPath string
+}
+
+// EventCatalogEntry is the published form of an EventSpec
+type EventCatalogEntry struct {
+	Type          string         `json:"type"`
+	Direction     EventDirection `json:"direction"`
+	Summary       string         `json:"summary,omitempty"`
+	PayloadSchema map[string]any `json:"payload_schema,omitempty"`
+	RequireAck    bool           `json:"require_ack"`
+	Capabilities  []string       `json:"required_capabilities,omitempty"`
+	ResultSchema  map[string]any `json:"result_schema,omitempty"`
+	ResultPath    string         `json:"result_path,omitempty"`
+}
+
+// EventCatalog is the registry of the event contract between server and agents,
+// it starts with the events the SSE server sends by itself
+type EventCatalog struct {
+	mu    sync.RWMutex
+	specs map[string]EventSpec
+}
+
+func NewEventCatalog() *EventCatalog {
+	c := &EventCatalog{specs: map[string]EventSpec{}}
+
+	c.Add(EventSpec{
+		Type:      "connected",
+		Direction: EventToClient,
+		Summary:   "First event of every stream, carries the client id to reconnect with",
+		Payload: struct {
+			ClientID string `json:"client_id"`
+		}{},
+	})
+	c.Add(EventSpec{
+		Type:      "server_shutdown",
+		Direction: EventToClient,
+		Summary:   "The instance is going away, reconnect to another one",
+		Payload: struct {
+			InstanceID string `json:"instance_id"`
+		}{},
+	})
+	return c
+}
+
+// Add registers an event type, a later spec for the same type replaces the earlier one
+func (c *EventCatalog) Add(spec EventSpec) *EventCatalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.specs[spec.Type] = spec
+	return c
+}
+
+// Specs returns the registered specs sorted by event type
+func (c *EventCatalog) Specs() []EventSpec {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	specs := make([]EventSpec, 0, len(c.specs))
+	for _, spec := range c.specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Type < specs[j].Type })
+	return specs
+}
+
+// Entries returns the catalog sorted by event type
+func (c *EventCatalog) Entries() []EventCatalogEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make([]EventCatalogEntry, 0, len(c.specs))
+	for _, spec := range c.specs {
+		entry := EventCatalogEntry{
+			Type:         spec.Type,
+			Direction:    spec.Direction,
+			Summary:      spec.Summary,
+			RequireAck:   spec.RequireAck,
+			Capabilities: spec.Capabilities,
+			ResultPath:   spec.ResultPath,
+		}
+		if spec.Payload != nil {
+			entry.PayloadSchema = generateSchema(reflect.TypeOf(spec.Payload))
+		}
+		if spec.Result != nil {
+			entry.ResultSchema = generateSchema(reflect.TypeOf(spec.Result))
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Type < entries[j].Type })
+	return entries
+}
@@ -0,0 +1,221 @@
+package utility
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FieldCase is the casing of the object keys in SSE payloads
+type FieldCase string
+
+const (
+	FieldCaseAsIs  FieldCase = ""      // keys as the json tags of the Go structs write them
+	FieldCaseSnake FieldCase = "snake" // client_id
+	FieldCaseCamel FieldCase = "camel" // clientId
+)
+
+// PayloadMarshaler encodes the data of every message a server sends, so one set of structs serves
+// agents reading snake_case and browsers expecting camelCase. The zero value is plain encoding/json.
+type PayloadMarshaler struct {
+	// Case rewrites the keys of the struct fields of the payload. Keys of maps, such as counts per
+	// status or data sent by a user, are kept as they are, so are values with their own MarshalJSON.
+	// A payload that came through a broker lost its structs and is sent with its keys as they are.
+	Case FieldCase
+	// Envelope wraps the payload as {"event_type": ..., "id": ..., "data": ...} with keys in Case,
+	// for consumers that read every event from one handler
+	Envelope bool
+}
+
+func (p PayloadMarshaler) validate() error {
+	switch p.Case {
+	case FieldCaseAsIs, FieldCaseSnake, FieldCaseCamel:
+		return nil
+	}
+	return fmt.Errorf("unknown payload case %q, use snake or camel", p.Case)
+}
+
+// Marshal returns the JSON written to the data field of msg
+func (p PayloadMarshaler) Marshal(msg Message) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	rename := func(key string) string { return key }
+	switch p.Case {
+	case FieldCaseSnake:
+		rename = snakeCase
+	case FieldCaseCamel:
+		rename = camelCase
+	}
+
+	var data any = msg.Data
+	if p.Case != FieldCaseAsIs {
+		var err error
+		if data, err = renamePayload(msg.Data, rename); err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.Envelope {
+		return json.Marshal(data)
+	}
+	envelope := map[string]any{rename("event_type"): msg.EventType, rename("data"): data}
+	if msg.ID != "" {
+		envelope[rename("id")] = msg.ID
+	}
+	return json.Marshal(envelope)
+}
+
+// renamePayload encodes data once so its tags, omitempty and marshalers decide what is written, then
+// renames the keys of its struct fields by walking data next to the decoded JSON. Numbers are kept as
+// written, a round trip through float64 would lose large ids.
+func renamePayload(data any, rename func(string) string) (any, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return renameFields(reflect.ValueOf(data), decoded, rename), nil
+}
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// renameFields returns decoded, the JSON of value, with the keys of struct fields renamed
+func renameFields(value reflect.Value, decoded any, rename func(string) string) any {
+	if !value.IsValid() || value.Type().Implements(jsonMarshalerType) {
+		return decoded
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return decoded
+		}
+		return renameFields(value.Elem(), decoded, rename)
+
+	case reflect.Struct:
+		object, ok := decoded.(map[string]any)
+		if !ok {
+			return decoded
+		}
+		renamed := make(map[string]any, len(object))
+		renameStructFields(value, object, renamed, rename)
+		return renamed
+
+	case reflect.Map:
+		object, ok := decoded.(map[string]any)
+		if !ok || value.Type().Key().Kind() != reflect.String {
+			return decoded
+		}
+		// the keys stay, the values may hold structs
+		for iter := value.MapRange(); iter.Next(); {
+			key := iter.Key().String()
+			if item, ok := object[key]; ok {
+				object[key] = renameFields(iter.Value(), item, rename)
+			}
+		}
+		return object
+
+	case reflect.Slice, reflect.Array:
+		list, ok := decoded.([]any)
+		if !ok || len(list) != value.Len() {
+			return decoded
+		}
+		for i := range list {
+			list[i] = renameFields(value.Index(i), list[i], rename)
+		}
+		return list
+	}
+
+	return decoded
+}
+
+// renameStructFields copies the keys of the fields of value from object into renamed, the fields of
+// an embedded struct without a name are promoted like encoding/json does
+func renameStructFields(value reflect.Value, object, renamed map[string]any, rename func(string) string) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := value.Field(i)
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !embedded.Type().Implements(jsonMarshalerType) {
+				renameStructFields(embedded, object, renamed, rename)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if item, ok := object[name]; ok {
+			renamed[rename(name)] = renameFields(value.Field(i), item, rename)
+		}
+	}
+}
+
+// camelCase turns client_id into clientId, keys without underscores are left alone
+func camelCase(key string) string {
+	if !strings.Contains(key, "_") {
+		return key
+	}
+
+	var b strings.Builder
+	upper := false
+	for i, r := range key {
+		if r == '_' && i > 0 {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// snakeCase turns clientId into client_id and HTTPStatus into http_status
+func snakeCase(key string) string {
+	runes := []rune(key)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && runes[i-1] != '_' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+			if startsWord {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
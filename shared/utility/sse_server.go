@@ -1,43 +1,141 @@
 package utility
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"maps"
 	"net/http"
+	"shared/core"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Client represents a single SSE client connection
 type Client struct {
-	ID string // Added client identifier
-	w  http.ResponseWriter
-	f  http.Flusher
-	mu sync.Mutex
+	ID     string        // Added client identifier
+	stream clientStream  // SSE response or WebSocket connection, see HandleWS
+	bw     *bufio.Writer // buffers frames in front of stream, guarded by mu
+	mu     sync.Mutex
+	// flushStop is open while a coalesced flush is pending and closed to cancel it, guarded by mu
+	flushStop chan struct{}
+	// queue holds frames waiting for the connection writer, see SSEConfig.OverflowPolicy
+	queue   chan queuedFrame
+	dropped atomic.Uint64 // frames lost to the overflow policy since the writer last logged
+	// encoding of the data field negotiated at connect, see negotiateEncoding
+	encoding string
+	// event types the client subscribed to at connect, nil receives every event, see wantsEvent
+	events map[string]struct{}
+	// write timeout, queue size and keepalive of this connection, see SSEConfig.QoSClasses
+	qos clientQoS
+	// topics the client may subscribe to, checked by Subscribe, see SSEConfig.TopicScope
+	topicScope TopicScope
 	// Add done channel for cleanup
 	done chan struct{}
+	meta ClientMeta // guarded by the SSEServer mutex
 }
 
 // SSE represents the SSE server
 type SSEServer struct {
-	clients          map[string]*Client // Changed to use string keys
-	mu               sync.RWMutex       // Single mutex for the SSE struct
-	maxConns         int                // Maximum allowed connections
-	keepAlive        time.Duration      // Keepalive interval
-	origins          []string           // Allowed CORS origins
-	broadcastTimeout time.Duration      // Timeout for broadcast operations
-	logger           *log.Logger        // Logger for SSE server
+	clients          map[string]*Client             // Changed to use string keys
+	topics           map[string]map[string]struct{} // Topic to subscribed client IDs
+	groups           map[string]map[string]struct{} // Group to member client IDs, kept across reconnects
+	mu               sync.RWMutex                   // Single mutex for the SSE struct
+	maxConns         int                            // Maximum allowed connections
+	keepAlive        time.Duration                  // Keepalive interval
+	retryHint        time.Duration                  // Reconnect delay advertised to clients
+	compressMinSize  int                            // Smaller data is never compressed
+	compressStream   bool                           // Compress whole SSE responses when the client accepts it
+	queueSize        int                            // Frames buffered per client
+	overflowPolicy   OverflowPolicy                 // What a full client queue does to new frames
+	coalesceWindow   time.Duration                  // Delay before flushing buffered frames
+	origins          []string                       // Allowed CORS origins
+	broadcastTimeout time.Duration                  // Write deadline of a single frame
+	qosClasses       map[string]QoSClass            // Per-client overrides selected at connect
+	logger           *Logger                        // Logger for SSE server
+	errorLog         *logThrottler                  // Logger for errors that repeat per client
+	clock            core.Clock                     // Time source for keepalives
+	idGenerator      core.IDGenerator               // Generator for client IDs
+	authenticator    func(r *http.Request) error
+	clientIdentity   func(r *http.Request) (string, error)
+	topicScope       func(r *http.Request) (TopicScope, error)
+	audit            *rejectionAudit     // Recent handshake rejections
+	instanceID       string              // Identifies this instance on the broker
+	broker           Broker              // Fan out to other instances
+	remoteFanOut     bool                // True when a broker was configured explicitly
+	presence         *clusterPresence    // Clients of the other instances, nil without a broker
+	presenceStop     chan struct{}       // Closed by Shutdown to stop republishing the presence
+	acks             *ackTracker         // Acknowledgements received from clients
+	offlineStore     OfflineStore        // Keeps targeted messages for offline clients, may be nil
+	shuttingDown     bool                // Set by Shutdown, new connections are refused
+	connections      sync.WaitGroup      // Running HandleSSE calls and their keepalive goroutines
+	sequences        *eventSequences     // Last id: written per client
+	middlewares      []Middleware        // Wrap the connect handler, see Use
+	connectHooks     []ConnectHook       // Told about every accepted connection, see OnConnect
+	eventAliases     map[string][]string // Former names of renamed event types, see AliasEvent
+	payload          PayloadMarshaler    // Encodes the data field of every message
+	framesQueued     atomic.Uint64       // Frames accepted by client queues since start, see Stats
+	framesDropped    atomic.Uint64       // Frames lost to the overflow policy since start
+	streams          atomic.Int64        // serveClient calls running, see LeakCheck
+	keepalives       atomic.Int64        // Keepalive goroutines running
 }
 
 // SSEConfig holds configuration for the SSE server
 type SSEConfig struct {
 	MaxConnections   int
 	KeepAlive        time.Duration
-	Origins          []string // Allowed CORS origins
-	BroadcastTimeout time.Duration
-	Logger           *log.Logger
+	Origins          []string         // Allowed CORS origins
+	BroadcastTimeout time.Duration    // Write deadline of a frame, a client that stops reading is dropped after it
+	Logger           *Logger          // Defaults to the process wide logger with an [SSE] prefix
+	Clock            core.Clock       // Defaults to the real clock
+	IDGenerator      core.IDGenerator // Defaults to ULID so generated client IDs are sortable
+	// Authenticator is called before accepting a connection, a non nil error rejects it
+	Authenticator func(r *http.Request) error
+	// ClientIdentity resolves the client ID a request is authenticated as, e.g. the subject of its token.
+	// A connect then takes that ID, a different client_id is rejected as auth_failed, and an ack is only
+	// recorded for it. Nil trusts the client_id the client sends.
+	ClientIdentity func(r *http.Request) (string, error)
+	// TopicScope resolves the topics a connecting client may subscribe to, e.g. from the roles of its
+	// token, and is enforced by Subscribe. An error rejects the connection as auth_failed and a topic
+	// outside the scope requested on connect as topic_forbidden. Nil lets every client subscribe to anything.
+	TopicScope func(r *http.Request) (TopicScope, error)
+	// RejectionHistory is the number of recent rejections kept for inspection
+	RejectionHistory int
+	// Broker fans messages out to other instances, defaults to a local in-memory broker
+	Broker Broker
+	// PresenceInterval is how often an instance tells the others through the broker which clients it
+	// holds, defaults to 10 seconds. An instance not heard from for three intervals counts as gone.
+	PresenceInterval time.Duration
+	// AckRetention is how long acknowledgements are kept for WaitForAck, defaults to 5 minutes
+	AckRetention time.Duration
+	// OfflineStore keeps targeted messages for clients that are not connected, see SetOfflineStore
+	OfflineStore OfflineStore
+	// ClientQueueSize is the number of frames buffered per client before OverflowPolicy applies, defaults to 256
+	ClientQueueSize int
+	// OverflowPolicy decides what happens to a frame sent to a client whose queue is full, defaults to disconnect
+	OverflowPolicy OverflowPolicy
+	// CoalesceWindow > 0 delays the flush of each client stream so events sent within the window
+	// share one Flush, trading that much latency for fewer syscalls under bursts
+	CoalesceWindow time.Duration
+	// CompressMinSize is the smallest data, in bytes, compressed for clients that negotiated gzip, defaults to 1 KiB
+	CompressMinSize int
+	// CompressStream compresses the whole SSE response with gzip or deflate when Accept-Encoding allows it,
+	// events then carry their data as is. Proxies that buffer compressed responses delay events, keep it off there.
+	CompressStream bool
+	// LogThrottleWindow collapses repeated errors of the same kind, such as broken pipes on a mass
+	// disconnect, into one summary per window, defaults to 10 seconds, negative logs every error
+	LogThrottleWindow time.Duration
+	// QoSClasses are selected per client with ?qos=<name> or a meta_qos label and override
+	// BroadcastTimeout, ClientQueueSize and KeepAlive for that client only
+	QoSClasses map[string]QoSClass
+	// ClientRetryHint is sent as retry: on connect so clients space their reconnects, zero sends nothing
+	ClientRetryHint time.Duration
+	// Payload sets the key casing and envelope of the data field, the zero value sends the json tags as is
+	Payload PayloadMarshaler
 }
 
 // NewSSEDefault creates a new SSE instance with default configuration
@@ -57,24 +155,111 @@ func NewSSEServer(config SSEConfig) *SSEServer {
 		config.BroadcastTimeout = 5 * time.Second // Default broadcast timeout
 	}
 	if config.Logger == nil {
-		config.Logger = log.New(log.Writer(), "[SSE] ", log.LstdFlags)
+		config.Logger = DefaultLogger().WithPrefix("[SSE] ")
+	}
+	if config.Clock == nil {
+		config.Clock = core.RealClock{}
+	}
+	if config.RejectionHistory <= 0 {
+		config.RejectionHistory = 100
+	}
+	if config.IDGenerator == nil {
+		config.IDGenerator = core.NewULIDGenerator(config.Clock)
+	}
+	if config.LogThrottleWindow == 0 {
+		config.LogThrottleWindow = 10 * time.Second
+	}
+	if config.CompressMinSize <= 0 {
+		config.CompressMinSize = 1024
+	}
+	if config.ClientQueueSize <= 0 {
+		config.ClientQueueSize = 256
+	}
+	if config.OverflowPolicy == "" {
+		config.OverflowPolicy = OverflowDisconnect
+	}
+	if config.PresenceInterval <= 0 {
+		config.PresenceInterval = 10 * time.Second
+	}
+	if config.AckRetention <= 0 {
+		config.AckRetention = 5 * time.Minute
+	}
+
+	if err := config.Payload.validate(); err != nil {
+		config.Logger.Warnf("%v, payloads are sent as is", err)
+		config.Payload = PayloadMarshaler{}
 	}
 
-	return &SSEServer{
+	remoteFanOut := config.Broker != nil
+	if config.Broker == nil {
+		config.Broker = NewInMemoryBroker()
+	}
+
+	server := &SSEServer{
 		clients:          make(map[string]*Client),
+		topics:           make(map[string]map[string]struct{}),
+		groups:           make(map[string]map[string]struct{}),
 		maxConns:         config.MaxConnections,
 		keepAlive:        config.KeepAlive,
+		retryHint:        config.ClientRetryHint,
+		compressMinSize:  config.CompressMinSize,
+		compressStream:   config.CompressStream,
+		queueSize:        config.ClientQueueSize,
+		overflowPolicy:   config.OverflowPolicy,
+		coalesceWindow:   config.CoalesceWindow,
 		origins:          config.Origins,
 		broadcastTimeout: config.BroadcastTimeout,
+		qosClasses:       config.QoSClasses,
 		logger:           config.Logger,
+		errorLog:         newLogThrottler(config.Logger, config.Clock, config.LogThrottleWindow),
+		clock:            config.Clock,
+		idGenerator:      config.IDGenerator,
+		authenticator:    config.Authenticator,
+		clientIdentity:   config.ClientIdentity,
+		topicScope:       config.TopicScope,
+		audit:            newRejectionAudit(config.RejectionHistory),
+		instanceID:       config.IDGenerator.NewID(),
+		broker:           config.Broker,
+		remoteFanOut:     remoteFanOut,
+		acks:             newAckTracker(config.AckRetention, config.Clock),
+		offlineStore:     config.OfflineStore,
+		sequences:        newEventSequences(),
+		payload:          config.Payload,
+	}
+
+	if remoteFanOut {
+		if _, err := server.broker.Subscribe(server.handleBrokerEnvelope); err != nil {
+			server.logger.Errorf("Failed to subscribe to broker, fan out disabled: %v", err)
+			server.remoteFanOut = false
+		}
 	}
+	if server.remoteFanOut {
+		server.presence = newClusterPresence(config.Clock, 3*config.PresenceInterval)
+		server.presenceStop = make(chan struct{})
+		go server.runPresence(config.PresenceInterval)
+	}
+
+	return server
 }
 
 // Message represents an SSE message with both SSE-standard and embedded formats
 type Message struct {
+	// ID is optional, when set it is sent as an ack-id field and the client acknowledges it
+	// through /api/sse/ack once its handlers succeed, see WaitForAck
+	ID string `json:"id,omitempty"`
+	// Trace is the traceparent of the span that sent the message, filled from the send context
+	// so the client handler continues the same distributed trace
+	Trace string `json:"traceparent,omitempty"`
 	// Internal structure for JSON data
 	EventType string `json:"event_type"`
 	Data      any    `json:"data"`
+	// Volatile messages only reach connected clients, the offline store never keeps them,
+	// for payloads such as credentials that must not be written to the database
+	Volatile bool `json:"-"`
+	// Requires are the commands a client must have announced in its commands metadata to receive the
+	// message, and the capabilities, the cap_ metadata keys, it must report true. A broadcast or a group
+	// skips the clients lacking one, a named client fails the send.
+	Requires []string `json:"requires,omitempty"`
 }
 
 // enableCors enables CORS for the response with proper origin validation
@@ -103,6 +288,20 @@ func enableCors(w http.ResponseWriter, origins []string, requestOrigin string) {
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 }
 
+// isOriginAllowed reports whether a browser origin may connect, requests without Origin are not browsers
+func isOriginAllowed(origins []string, requestOrigin string) bool {
+	if len(origins) == 0 || requestOrigin == "" {
+		return true
+	}
+
+	for _, allowedOrigin := range origins {
+		if allowedOrigin == requestOrigin || allowedOrigin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
 // validateMessage validates a message for required fields
 func (s *SSEServer) validateMessage(msg Message) error {
 	if msg.EventType == "" {
@@ -119,30 +318,45 @@ func (s *SSEServer) addClient(client *Client) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Check max connections
-	if len(s.clients) >= s.maxConns {
-		return fmt.Errorf("maximum connections (%d) reached", s.maxConns)
+	previous, exists := s.clients[client.ID]
+
+	// Check max connections, a reconnect replacing its previous connection adds none
+	if !exists && len(s.clients) >= s.maxConns {
+		return rejectError{reason: RejectMaxConnections, detail: fmt.Sprintf("maximum connections (%d) reached", s.maxConns)}
+	}
+
+	if exists {
+		// Without ClientIdentity anyone can claim the ID, refuse to silently replace a live connection
+		if s.clientIdentity == nil {
+			return rejectError{reason: RejectDuplicateClientID, detail: fmt.Sprintf("client %s is already connected", client.ID)}
+		}
+		// The same authenticated client reconnected before its previous stream was noticed as dead,
+		// the new one takes over and the old handler returns on done
+		s.unsubscribeAllLocked(client.ID)
+		close(previous.done)
+		s.logger.Infof("Client %s reconnected, its previous connection is closed", client.ID)
 	}
 
 	s.clients[client.ID] = client
 	return nil
 }
 
-// removeClient removes a client from the SSE instance
-func (s *SSEServer) removeClient(clientID string) {
-	var client *Client
-	var exists bool
-
+// removeClient removes a client from the SSE instance, a connection already replaced by a reconnect
+// of the same client leaves the new one alone
+func (s *SSEServer) removeClient(client *Client) {
 	s.mu.Lock()
-	client, exists = s.clients[clientID]
+	current, exists := s.clients[client.ID]
+	exists = exists && current == client
 	if exists {
-		delete(s.clients, clientID)
+		delete(s.clients, client.ID)
+		s.unsubscribeAllLocked(client.ID)
 	}
 	s.mu.Unlock()
 
 	if exists {
 		close(client.done)
-		s.logger.Printf("Client %s disconnected", clientID)
+		s.logger.Infof("Client %s disconnected", client.ID)
+		s.publishPresence(false)
 	}
 }
 
@@ -177,15 +391,59 @@ func (s *SSEServer) GetConnectedClientCount() int {
 	return len(s.clients)
 }
 
-// SendToClients sends a message to specific clients or all clients if clientIDs is empty
+// startSendSpan starts the span of a send when ctx is traced and stamps the message with it, so the
+// client, the broker and the offline store carry it on. The span is nil otherwise, ending it is a no-op.
+func startSendSpan(ctx context.Context, msg *Message, key, value string) (context.Context, *Span) {
+	if _, traced := TraceFromContext(ctx); !traced || msg.Trace != "" {
+		return ctx, nil
+	}
+	ctx, span := StartSpan(ctx, "sse.send "+msg.EventType)
+	span.SetAttribute(key, value)
+	msg.Trace = span.Context().Traceparent()
+	return ctx, span
+}
+
+// SendToClients sends a message to specific clients or all clients if clientIDs is empty.
+// When a broker is configured the message also reaches clients connected to other instances.
+// With an offline store, targeted clients that are not connected get the message on reconnect.
 func (s *SSEServer) SendToClients(ctx context.Context, msg Message, clientIDs ...string) error {
 	// Validate message
 	if err := s.validateMessage(msg); err != nil {
 		return err
 	}
 
+	ctx, span := startSendSpan(ctx, &msg, "sse.client_ids", strings.Join(clientIDs, ","))
+	defer span.End()
+
+	if s.remoteFanOut {
+		if err := s.broker.Publish(ctx, BrokerEnvelope{Origin: s.instanceID, Message: msg, ClientIDs: clientIDs}); err != nil {
+			return fmt.Errorf("failed to publish to broker: %w", err)
+		}
+	}
+
+	// With remote fan out a client missing here may be connected to another instance
+	if s.remoteFanOut {
+		return s.sendLocal(ctx, msg, clientIDs, true)
+	}
+
+	if store := s.getOfflineStore(); store != nil && len(clientIDs) > 0 && !msg.Volatile {
+		online, err := s.storeOffline(ctx, store, msg, clientIDs)
+		if err != nil {
+			return err
+		}
+		if len(online) == 0 {
+			return nil
+		}
+		clientIDs = online
+	}
+
+	return s.sendLocal(ctx, msg, clientIDs, false)
+}
+
+// sendLocal writes the message to clients connected to this instance
+func (s *SSEServer) sendLocal(ctx context.Context, msg Message, clientIDs []string, allowMissing bool) error {
 	// Marshal the message data to JSON (do this once for all clients)
-	dataBytes, err := json.Marshal(msg.Data)
+	dataBytes, err := s.payload.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message data: %w", err)
 	}
@@ -195,6 +453,7 @@ func (s *SSEServer) SendToClients(ctx context.Context, msg Message, clientIDs ..
 
 	// Get list of clients to send to
 	var clients []*Client
+	unsupported := map[string][]string{} // client to the required commands it did not announce
 
 	s.mu.RLock()
 	if isBroadcast {
@@ -212,80 +471,82 @@ func (s *SSEServer) SendToClients(ctx context.Context, msg Message, clientIDs ..
 			}
 		}
 	}
+	// the metadata can change with SetClientMeta, it is read under the lock
+	for _, client := range clients {
+		if missing := missingCommands(client.meta, msg.Requires); len(missing) > 0 {
+			unsupported[client.ID] = missing
+		}
+	}
 	s.mu.RUnlock()
 
 	// If no clients found, handle accordingly
 	if len(clients) == 0 {
-		if isBroadcast {
+		if isBroadcast || allowMissing {
 			return nil // No clients to broadcast to, not an error
 		}
 		return fmt.Errorf("no clients found from the specified IDs")
 	}
 
-	// Use a timeout context for the operation
-	sendCtx, cancel := context.WithTimeout(ctx, s.broadcastTimeout)
-	defer cancel()
-
-	// Helper function to send message to a single client
-	sendToClient := func(client *Client) error {
-		client.mu.Lock()
-		defer client.mu.Unlock()
+	// Clients that filtered this event type out at connect are skipped, targeted or not. A client
+	// named by the sender counts as a failed recipient so the send does not look delivered.
+	var errs []error
+	targets := len(clients)
+	subscribed := clients[:0]
+	for _, client := range clients {
+		missing, lacking := unsupported[client.ID]
+		switch {
+		case len(s.eventNamesFor(client, msg.EventType)) > 0 && !lacking:
+			subscribed = append(subscribed, client)
+		case isBroadcast || allowMissing:
+		case lacking:
+			errs = append(errs, fmt.Errorf("%w: %s did not announce %s", ErrCommandUnsupported, client.ID, strings.Join(missing, ", ")))
+		default:
+			errs = append(errs, fmt.Errorf("%w: %s left %s out", ErrEventFiltered, client.ID, msg.EventType))
+		}
+	}
+	clients = subscribed
 
-		_, err := fmt.Fprintf(client.w, "event: %s\ndata: %s\n\n", msg.EventType, dataBytes)
+	// The frame is serialized once and shared by every recipient, only the id line differs per client
+	// small payloads are sent as is, compressing them would only add the gzip header and base64
+	bodies := map[[2]string][]byte{}
+	bodyFor := func(encoding, eventType string) ([]byte, error) {
+		if len(dataBytes) < s.compressMinSize {
+			encoding = EncodingIdentity
+		}
+		key := [2]string{encoding, eventType}
+		if body, ok := bodies[key]; ok {
+			return body, nil
+		}
+		data, err := encodeData(encoding, dataBytes)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to encode message data as %s: %w", encoding, err)
 		}
-		client.f.Flush()
-		return nil
+		named := msg
+		named.EventType = eventType
+		bodies[key] = buildFrameBody(named, data, encoding)
+		return bodies[key], nil
 	}
 
-	// For a single client, handle synchronously for simplicity
-	if len(clients) == 1 {
-		select {
-		case <-sendCtx.Done():
-			return sendCtx.Err()
-		default:
-			err := sendToClient(clients[0])
+	// Frames are queued per client and written by the connection's own writer, a slow consumer
+	// only fills its own queue and the overflow policy decides what happens to it
+	for _, client := range clients {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// a renamed event is also written under the former names, with the same ack id, for the
+		// clients still handling those
+		for _, eventType := range s.eventNamesFor(client, msg.EventType) {
+			body, err := bodyFor(client.encoding, eventType)
 			if err != nil {
-				s.logger.Printf("Failed to send to client %s: %v", clients[0].ID, err)
-				s.removeClient(clients[0].ID)
 				return err
 			}
-			return nil
-		}
-	}
-
-	// For multiple clients, handle concurrently
-	var wg sync.WaitGroup
-	errors := make(chan error, len(clients))
-
-	for _, client := range clients {
-		wg.Add(1)
-		go func(c *Client) {
-			defer wg.Done()
-
-			select {
-			case <-sendCtx.Done():
-				errors <- sendCtx.Err()
-				return
-			default:
-				err := sendToClient(c)
-				if err != nil {
-					s.logger.Printf("Failed to send to client %s: %v", c.ID, err)
-					s.removeClient(c.ID)
-					errors <- err
-				}
+			// the sender waits for the ack of the frame, it must not sit in a buffer the stream may end with
+			if err := s.enqueueFrame(client, queuedFrame{body: body, flush: msg.ID != ""}); err != nil {
+				errs = append(errs, err)
+				break
 			}
-		}(client)
-	}
-
-	wg.Wait()
-	close(errors)
-
-	// Collect errors
-	var errs []error
-	for err := range errors {
-		errs = append(errs, err)
+		}
 	}
 
 	if len(errs) > 0 {
@@ -293,39 +554,90 @@ func (s *SSEServer) SendToClients(ctx context.Context, msg Message, clientIDs ..
 			return fmt.Errorf("failed to broadcast to %d/%d clients: %v",
 				len(errs), len(clients), errs[0])
 		}
-		return fmt.Errorf("failed to send to %d/%d specified clients: %v",
-			len(errs), len(clients), errs[0])
+		return fmt.Errorf("failed to send to %d/%d specified clients: %w",
+			len(errs), targets, errs[0])
 	}
 
 	return nil
 }
 
 // setupClientConnection creates and initializes a new client connection
-func (s *SSEServer) setupClientConnection(w http.ResponseWriter, r *http.Request) (*Client, error) {
-	// Check if client supports flushing
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		return nil, fmt.Errorf("streaming unsupported")
-	}
+func (s *SSEServer) setupClientConnection(stream clientStream, r *http.Request) (*Client, error) {
 
-	// Get client ID from query parameter or generate a new one
-	clientID := r.URL.Query().Get("client_id")
+	// Get client ID from the authenticated identity or the query parameter, or generate a new one
+	clientID, err := s.authenticatedClientID(r, r.URL.Query().Get("client_id"))
+	if err != nil {
+		return nil, rejectError{reason: RejectAuthFailed, detail: err.Error()}
+	}
 	if clientID == "" {
-		clientID = fmt.Sprintf("client-%d", time.Now().UnixNano())
+		clientID = "client-" + s.idGenerator.NewID()
+	}
+
+	topicScope, err := s.resolveTopicScope(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// refuse the whole connection, a feed silently missing a topic looks like a quiet topic
+	topics := topicsFromRequest(r)
+	for _, topic := range topics {
+		if !topicScope.Allows(topic) {
+			return nil, rejectError{reason: RejectTopicForbidden, detail: fmt.Sprintf("topic %s is not allowed", topic)}
+		}
+	}
+
+	meta := clientMetaFromRequest(r)
+	qos := s.resolveQoS(r, meta)
+
+	// a compressed response already shrinks the data, compressing it twice gains nothing
+	encoding := negotiateEncoding(r)
+	if _, compressed := stream.(*compressedStream); compressed {
+		encoding = EncodingIdentity
 	}
 
 	// Create new client
 	client := &Client{
-		ID:   clientID,
-		w:    w,
-		f:    flusher,
-		done: make(chan struct{}),
+		ID:       clientID,
+		stream:   stream,
+		bw:       bufio.NewWriter(stream),
+		queue:    make(chan queuedFrame, qos.queueSize),
+		done:     make(chan struct{}),
+		meta:     meta,
+		encoding: encoding,
+		events:   eventFilterFromRequest(r),
+		qos:      qos,
+
+		topicScope: topicScope,
+	}
+	client.meta[EncodingMetaKey] = client.encoding
+	if qos.class != "" {
+		client.meta[QoSMetaKey] = qos.class
+	}
+	if client.events != nil {
+		client.meta[EventsMetaKey] = formatEventFilter(client.events)
 	}
 
 	// Add client to broadcast list
 	if err := s.addClient(client); err != nil {
 		return nil, err
 	}
+	s.publishPresence(false)
+
+	// Subscribe to topics requested on connect
+	for _, topic := range topics {
+		if err := s.Subscribe(client.ID, topic); err != nil {
+			s.errorLog.Printf("Failed to subscribe client %s to %s: %v", client.ID, topic, err)
+		}
+	}
+
+	// the metadata is copied under the lock, SetClientMeta may already change it
+	s.mu.RLock()
+	hooks := s.connectHooks
+	meta = maps.Clone(client.meta)
+	s.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(client.ID, maps.Clone(meta))
+	}
 
 	return client, nil
 }
@@ -342,20 +654,32 @@ func (s *SSEServer) sendConnectedEvent(client *Client) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	// Send the connected event using SendToClients
-	err := s.SendToClients(ctx, connectMsg, client.ID)
+	// Send the connected event only to this instance, it never needs the broker
+	err := s.sendLocal(ctx, connectMsg, []string{client.ID}, false)
 
 	if err != nil {
 		return fmt.Errorf("failed to send connected event: %w", err)
 	}
 
-	s.logger.Printf("Client %s connected", client.ID)
+	s.logger.Infof("Client %s connected", client.ID)
 	return nil
 }
 
+// sendRetryHint writes the retry: field on its own, without data it dispatches no event on the client
+func (s *SSEServer) sendRetryHint(client *Client) {
+	if s.retryHint <= 0 {
+		return
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	fmt.Fprintf(client.bw, "retry: %d\n\n", s.retryHint.Milliseconds())
+	client.flushLocked()
+}
+
 // startKeepalive starts the keepalive goroutine for a client
 func (s *SSEServer) startKeepalive(client *Client, ctx context.Context) {
-	ticker := time.NewTicker(s.keepAlive)
+	ticker := s.clock.NewTicker(client.qos.keepAlive)
 	defer ticker.Stop()
 
 	for {
@@ -364,10 +688,10 @@ func (s *SSEServer) startKeepalive(client *Client, ctx context.Context) {
 			return
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			client.mu.Lock()
-			fmt.Fprintf(client.w, ": keepalive\n\n")
-			client.f.Flush()
+			client.bw.WriteString(": keepalive\n\n")
+			client.flushLocked()
 			client.mu.Unlock()
 		}
 	}
@@ -382,8 +706,15 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !s.admitConnection(w, r) {
+		return
+	}
+	defer s.connections.Done()
+
+	// Check if client supports flushing
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.reject(w, r, r.URL.Query().Get("client_id"), RejectStreamingUnsupported, "streaming unsupported")
 		return
 	}
 
@@ -395,27 +726,175 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 
 	// Setup client connection
-	client, err := s.setupClientConnection(w, r)
+	var stream clientStream = newHTTPStream(w, flusher)
+	streamEncoding := ""
+	if s.compressStream {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if streamEncoding = negotiateStreamEncoding(r.Header.Get("Accept-Encoding")); streamEncoding != "" {
+			stream = newCompressedStream(stream.(*httpStream), streamEncoding)
+		}
+	}
+
+	client, err := s.setupClientConnection(stream, r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		s.rejectSetup(w, r, err)
+		return
+	}
+
+	// set once the client is accepted, a rejection is answered uncompressed
+	if streamEncoding != "" {
+		w.Header().Set("Content-Encoding", streamEncoding)
+		defer func() {
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			stream.(*compressedStream).Close()
+		}()
+	}
+
+	s.serveClient(client, r.Context())
+}
+
+// admitConnection runs the checks shared by every transport before a client is set up, on success
+// the connection is counted as running and the caller must call s.connections.Done when it ends
+func (s *SSEServer) admitConnection(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != "GET" {
+		s.reject(w, r, r.URL.Query().Get("client_id"), RejectMethodNotAllowed, "Method not allowed")
+		return false
+	}
+
+	if !isOriginAllowed(s.origins, r.Header.Get("Origin")) {
+		s.reject(w, r, r.URL.Query().Get("client_id"), RejectOriginNotAllowed, "origin not allowed")
+		return false
+	}
+
+	if s.authenticator != nil {
+		if err := s.authenticator(r); err != nil {
+			s.reject(w, r, r.URL.Query().Get("client_id"), RejectAuthFailed, err.Error())
+			return false
+		}
+	}
+
+	// Refuse new streams once Shutdown started, the client reconnects elsewhere
+	if !s.beginConnection() {
+		s.reject(w, r, r.URL.Query().Get("client_id"), RejectShuttingDown, "server is shutting down")
+		return false
+	}
+	return true
+}
+
+// rejectSetup answers a connection whose client could not be set up
+func (s *SSEServer) rejectSetup(w http.ResponseWriter, r *http.Request, err error) {
+	var rejectErr rejectError
+	if errors.As(err, &rejectErr) {
+		s.reject(w, r, r.URL.Query().Get("client_id"), rejectErr.reason, rejectErr.detail)
 		return
 	}
-	defer s.removeClient(client.ID)
+	http.Error(w, err.Error(), http.StatusServiceUnavailable)
+}
+
+// serveClient streams to a client set up by setupClientConnection until ctx ends or the client is closed
+func (s *SSEServer) serveClient(client *Client, ctx context.Context) {
+	s.streams.Add(1)
+	defer s.streams.Add(-1)
+	defer s.forgetSequence(client)
+	defer s.removeClient(client)
+	// runs before removeClient so a coalesced frame, such as the shutdown notice, still goes out
+	defer s.flushClient(client)
+
+	// Advertise the reconnect delay before any event so it applies even if the stream drops right away
+	s.sendRetryHint(client)
+
+	// Only the writer touches the stream from now on, the handler must not return before it stopped
+	writerCtx, stopWriter := context.WithCancel(ctx)
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.runWriter(writerCtx, client)
+	}()
+	defer func() {
+		stopWriter()
+		<-writerDone
+	}()
 
 	// Send connected event
 	if err := s.sendConnectedEvent(client); err != nil {
-		s.logger.Printf("Failed to send connected event: %v", err)
+		s.errorLog.Printf("Failed to send connected event: %v", err)
 		return
 	}
 
+	// Deliver whatever was sent while this client was offline
+	s.flushOffline(client)
+
 	// Start keepalive goroutine
-	go s.startKeepalive(client, r.Context())
+	s.connections.Add(1)
+	s.keepalives.Add(1)
+	go func() {
+		defer s.connections.Done()
+		defer s.keepalives.Add(-1)
+		s.startKeepalive(client, ctx)
+	}()
 
 	// Wait for client disconnect
 	select {
-	case <-r.Context().Done():
-		s.logger.Printf("Client %s connection context done: %v", client.ID, r.Context().Err())
+	case <-ctx.Done():
+		s.logger.Debugf("Client %s connection context done: %v", client.ID, ctx.Err())
 	case <-client.done:
-		s.logger.Printf("Client %s connection closed", client.ID)
+		s.logger.Debugf("Client %s connection closed", client.ID)
+	}
+}
+
+// beginConnection registers a running handler, it returns false once Shutdown was called
+func (s *SSEServer) beginConnection() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shuttingDown {
+		return false
+	}
+	s.connections.Add(1)
+	return true
+}
+
+// Shutdown stops accepting connections, tells every client the server is going away with a
+// server_shutdown event, closes their streams and waits for the handlers to return or ctx to end.
+// Call it before http.Server.Shutdown, which would otherwise wait for the long lived streams forever.
+func (s *SSEServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	alreadyShuttingDown := s.shuttingDown
+	s.shuttingDown = true
+	s.mu.Unlock()
+
+	// Best effort, a client that cannot be written to is dropped by sendLocal anyway
+	shutdownMsg := Message{
+		EventType: "server_shutdown",
+		Data:      map[string]string{"instance_id": s.instanceID},
+	}
+	if err := s.sendLocal(ctx, shutdownMsg, nil, true); err != nil {
+		s.errorLog.Printf("Failed to notify clients about shutdown: %v", err)
+	}
+
+	s.mu.RLock()
+	clients := slices.Collect(maps.Values(s.clients))
+	s.mu.RUnlock()
+	for _, client := range clients {
+		s.removeClient(client)
+	}
+	if s.presence != nil && !alreadyShuttingDown {
+		close(s.presenceStop)
+		s.publishPresence(true)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.connections.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Infof("SSE server shut down")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sse shutdown: %w", ctx.Err())
 	}
 }
@@ -0,0 +1,209 @@
+package utility
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"shared/core"
+	"sync"
+	"time"
+)
+
+// Ack is posted by a client to /api/sse/ack after its handlers processed a message
+type Ack struct {
+	ClientID  string `json:"client_id"`
+	MessageID string `json:"message_id"`
+}
+
+// ackEntry holds the clients that acknowledged one message, changed is closed on every new ack
+type ackEntry struct {
+	clients map[string]struct{}
+	changed chan struct{}
+	created time.Time
+}
+
+// ackTracker remembers acknowledgements for a while so an ack arriving before WaitForAck is not lost
+type ackTracker struct {
+	mu        sync.Mutex
+	entries   map[string]*ackEntry
+	retention time.Duration
+	clock     core.Clock
+}
+
+func newAckTracker(retention time.Duration, clock core.Clock) *ackTracker {
+	return &ackTracker{
+		entries:   make(map[string]*ackEntry),
+		retention: retention,
+		clock:     clock,
+	}
+}
+
+// entryLocked returns the entry for a message, creating it and pruning expired ones
+func (t *ackTracker) entryLocked(messageID string) *ackEntry {
+	now := t.clock.Now()
+	for id, entry := range t.entries {
+		if now.Sub(entry.created) > t.retention {
+			delete(t.entries, id)
+		}
+	}
+
+	entry, exists := t.entries[messageID]
+	if !exists {
+		entry = &ackEntry{
+			clients: make(map[string]struct{}),
+			changed: make(chan struct{}),
+			created: now,
+		}
+		t.entries[messageID] = entry
+	}
+	return entry
+}
+
+func (t *ackTracker) record(ack Ack) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entryLocked(ack.MessageID)
+	if _, exists := entry.clients[ack.ClientID]; exists {
+		return
+	}
+	entry.clients[ack.ClientID] = struct{}{}
+
+	close(entry.changed)
+	entry.changed = make(chan struct{})
+}
+
+// pending returns the clients that have not acknowledged yet and a channel closed on the next ack.
+// With no clientIDs the message only needs a single ack from anyone.
+func (t *ackTracker) pending(messageID string, clientIDs []string) ([]string, <-chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entryLocked(messageID)
+
+	if len(clientIDs) == 0 {
+		if len(entry.clients) > 0 {
+			return nil, entry.changed
+		}
+		return []string{"*"}, entry.changed
+	}
+
+	var missing []string
+	for _, id := range clientIDs {
+		if _, exists := entry.clients[id]; !exists {
+			missing = append(missing, id)
+		}
+	}
+	return missing, entry.changed
+}
+
+func (t *ackTracker) clients(messageID string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, exists := t.entries[messageID]
+	if !exists {
+		return []string{}
+	}
+
+	ids := make([]string, 0, len(entry.clients))
+	for id := range entry.clients {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// NewMessageID returns an ID to put in Message.ID when the sender wants to wait for acknowledgements
+func (s *SSEServer) NewMessageID() string {
+	return "msg-" + s.idGenerator.NewID()
+}
+
+// WaitForAck blocks until every listed client acknowledged the message, or any client when none are listed.
+// It returns an error naming the missing clients when ctx is done first.
+func (s *SSEServer) WaitForAck(ctx context.Context, messageID string, clientIDs ...string) error {
+	for {
+		missing, changed := s.acks.pending(messageID, clientIDs)
+		if len(missing) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if len(clientIDs) == 0 {
+				return fmt.Errorf("message %s was not acknowledged by any client: %w", messageID, ctx.Err())
+			}
+			return fmt.Errorf("message %s not acknowledged by %v: %w", messageID, missing, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// GetAcks returns the clients that acknowledged a message so far
+func (s *SSEServer) GetAcks(messageID string) []string {
+	return s.acks.clients(messageID)
+}
+
+// recordAck stores an ack and shares it with the other instances, the client may ack on a different one
+func (s *SSEServer) recordAck(ctx context.Context, ack Ack) error {
+	s.acks.record(ack)
+
+	if s.remoteFanOut {
+		if err := s.broker.Publish(ctx, BrokerEnvelope{Origin: s.instanceID, Ack: &ack}); err != nil {
+			return fmt.Errorf("failed to publish ack to broker: %w", err)
+		}
+	}
+	return nil
+}
+
+// authenticatedClientID checks the client ID a request claims against ClientIdentity, an empty claim
+// takes the authenticated ID. Without ClientIdentity the claim is returned as it is.
+func (s *SSEServer) authenticatedClientID(r *http.Request, claimed string) (string, error) {
+	if s.clientIdentity == nil {
+		return claimed, nil
+	}
+
+	identity, err := s.clientIdentity(r)
+	if err != nil {
+		return "", err
+	}
+	if claimed != "" && claimed != identity {
+		return "", fmt.Errorf("client_id %s is not the authenticated client", claimed)
+	}
+	return identity, nil
+}
+
+// HandleAck handles POST /api/sse/ack from clients, with ClientIdentity the ack counts for the
+// authenticated client only
+func (s *SSEServer) HandleAck(w http.ResponseWriter, r *http.Request) {
+	if s.authenticator != nil {
+		if err := s.authenticator(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var ack Ack
+	if err := json.NewDecoder(r.Body).Decode(&ack); err != nil {
+		http.Error(w, fmt.Sprintf("invalid ack: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	clientID, err := s.authenticatedClientID(r, ack.ClientID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	ack.ClientID = clientID
+
+	if ack.ClientID == "" || ack.MessageID == "" {
+		http.Error(w, "client_id and message_id are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.recordAck(r.Context(), ack); err != nil {
+		s.errorLog.Printf("Failed to share ack for %s: %v", ack.MessageID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
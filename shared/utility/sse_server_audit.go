@@ -0,0 +1,132 @@
+package utility
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RejectReason is a machine readable code explaining why an SSE handshake was refused
+type RejectReason string
+
+const (
+	RejectMaxConnections       RejectReason = "max_connections"
+	RejectOriginNotAllowed     RejectReason = "origin_not_allowed"
+	RejectAuthFailed           RejectReason = "auth_failed"
+	RejectDuplicateClientID    RejectReason = "duplicate_client_id"
+	RejectMethodNotAllowed     RejectReason = "method_not_allowed"
+	RejectStreamingUnsupported RejectReason = "streaming_unsupported"
+	RejectShuttingDown         RejectReason = "shutting_down"
+	RejectTopicForbidden       RejectReason = "topic_forbidden"
+)
+
+// statusCode maps a reject reason to the HTTP status returned to the client
+func (r RejectReason) statusCode() int {
+	switch r {
+	case RejectMaxConnections, RejectShuttingDown:
+		return http.StatusServiceUnavailable
+	case RejectOriginNotAllowed, RejectTopicForbidden:
+		return http.StatusForbidden
+	case RejectAuthFailed:
+		return http.StatusUnauthorized
+	case RejectDuplicateClientID:
+		return http.StatusConflict
+	case RejectMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Rejection records a single refused SSE handshake
+type Rejection struct {
+	Time       time.Time    `json:"time"`
+	Reason     RejectReason `json:"reason"`
+	ClientID   string       `json:"client_id,omitempty"`
+	RemoteAddr string       `json:"remote_addr"`
+	Origin     string       `json:"origin,omitempty"`
+	Detail     string       `json:"detail"`
+}
+
+// rejectError is returned internally so the handler knows which reason to record
+type rejectError struct {
+	reason RejectReason
+	detail string
+}
+
+func (e rejectError) Error() string {
+	return e.detail
+}
+
+// rejectionAudit keeps a ring buffer of recent rejections and per reason counters
+type rejectionAudit struct {
+	mu     sync.Mutex
+	recent []Rejection
+	next   int
+	full   bool
+	counts map[RejectReason]int64
+}
+
+func newRejectionAudit(size int) *rejectionAudit {
+	return &rejectionAudit{
+		recent: make([]Rejection, size),
+		counts: make(map[RejectReason]int64),
+	}
+}
+
+func (a *rejectionAudit) record(rejection Rejection) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.counts[rejection.Reason]++
+	a.recent[a.next] = rejection
+	a.next = (a.next + 1) % len(a.recent)
+	if a.next == 0 {
+		a.full = true
+	}
+}
+
+// snapshot returns recent rejections newest first
+func (a *rejectionAudit) snapshot() ([]Rejection, map[RejectReason]int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	n := a.next
+	if a.full {
+		n = len(a.recent)
+	}
+
+	result := make([]Rejection, 0, n)
+	for i := 1; i <= n; i++ {
+		idx := (a.next - i + len(a.recent)) % len(a.recent)
+		result = append(result, a.recent[idx])
+	}
+
+	counts := make(map[RejectReason]int64, len(a.counts))
+	for k, v := range a.counts {
+		counts[k] = v
+	}
+
+	return result, counts
+}
+
+// reject logs, counts and answers a refused handshake
+func (s *SSEServer) reject(w http.ResponseWriter, r *http.Request, clientID string, reason RejectReason, detail string) {
+	s.audit.record(Rejection{
+		Time:       s.clock.Now(),
+		Reason:     reason,
+		ClientID:   clientID,
+		RemoteAddr: r.RemoteAddr,
+		Origin:     r.Header.Get("Origin"),
+		Detail:     detail,
+	})
+
+	s.errorLog.Printf("Rejected SSE connection from %s (client_id=%q, reason=%s): %s", r.RemoteAddr, clientID, reason, detail)
+
+	http.Error(w, detail, reason.statusCode())
+}
+
+// GetRejections returns recent handshake rejections (newest first) and the total count per reason
+func (s *SSEServer) GetRejections() ([]Rejection, map[RejectReason]int64) {
+	return s.audit.snapshot()
+}
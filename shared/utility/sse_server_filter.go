@@ -0,0 +1,97 @@
+package utility
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// eventsQueryParam lists the event types a client wants, ?events=scan_icmp,config_update,
+	// a client that sends nothing receives every event
+	eventsQueryParam = "events"
+
+	// EventsMetaKey is the client metadata key holding the event types the client subscribed to at connect
+	EventsMetaKey = "sse_events"
+)
+
+// ErrEventFiltered is returned for a targeted send to a client that left the event type out of its
+// filter, the caller learns the command never reached it instead of waiting for an ack
+var ErrEventFiltered = errors.New("client did not subscribe to the event")
+
+// controlEvents are delivered whatever the client filter, the client needs them to keep its session
+var controlEvents = map[string]struct{}{
+	"connected":       {},
+	"server_shutdown": {},
+}
+
+// eventFilterFromRequest reads the event types of ?events=, nil means no filter
+func eventFilterFromRequest(r *http.Request) map[string]struct{} {
+	var events map[string]struct{}
+	for _, value := range r.URL.Query()[eventsQueryParam] {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType == "" {
+				continue
+			}
+			if events == nil {
+				events = make(map[string]struct{})
+			}
+			events[eventType] = struct{}{}
+		}
+	}
+	return events
+}
+
+// formatEventFilter joins the filter for the client metadata, sorted so it reads the same on every connect
+func formatEventFilter(events map[string]struct{}) string {
+	list := make([]string, 0, len(events))
+	for eventType := range events {
+		list = append(list, eventType)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// wantsEvent reports whether the client subscribed to eventType, the filter is fixed at connect
+func (c *Client) wantsEvent(eventType string) bool {
+	if c.events == nil {
+		return true
+	}
+	if _, ok := controlEvents[eventType]; ok {
+		return true
+	}
+	_, ok := c.events[eventType]
+	return ok
+}
+
+// AliasEvent keeps clients written against the former names of a renamed event type receiving it. A
+// message of eventType is also written under every alias the client subscribed to, or under all of
+// them for a client without a filter, with the same ack id, so whichever name the client handles
+// acknowledges the message. Register it at startup on every instance, a later call for the same
+// eventType replaces its aliases.
+func (s *SSEServer) AliasEvent(eventType string, aliases ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.eventAliases == nil {
+		s.eventAliases = map[string][]string{}
+	}
+	s.eventAliases[eventType] = aliases
+}
+
+// eventNamesFor are the names a message of eventType is written to the client under, empty when the
+// client subscribed to none of them
+func (s *SSEServer) eventNamesFor(client *Client, eventType string) []string {
+	s.mu.RLock()
+	aliases := s.eventAliases[eventType]
+	s.mu.RUnlock()
+
+	var names []string
+	for _, name := range append([]string{eventType}, aliases...) {
+		if client.wantsEvent(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
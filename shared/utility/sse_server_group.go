@@ -0,0 +1,128 @@
+package utility
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// JoinGroup adds a client to a group. Unlike topics, membership is kept by client ID across
+// reconnects and does not require the client to be connected, so the server can assign it up front.
+// Only the server assigns groups, a client cannot pick its own through its connect metadata.
+func (s *SSEServer) JoinGroup(clientID, group string) error {
+	if clientID == "" || group == "" {
+		return fmt.Errorf("client id and group cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, exists := s.groups[group]
+	if !exists {
+		members = make(map[string]struct{})
+		s.groups[group] = members
+	}
+	members[clientID] = struct{}{}
+
+	return nil
+}
+
+// SetClientGroups makes the groups the only ones a client is a member of, it leaves the others and
+// an empty list removes the client from every group. Groups left without members are dropped.
+func (s *SSEServer) SetClientGroups(clientID string, groups []string) error {
+	if clientID == "" {
+		return fmt.Errorf("client id cannot be empty")
+	}
+	if slices.Contains(groups, "") {
+		return fmt.Errorf("group cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for group, members := range s.groups {
+		if _, ok := members[clientID]; !ok || slices.Contains(groups, group) {
+			continue
+		}
+		delete(members, clientID)
+		if len(members) == 0 {
+			delete(s.groups, group)
+		}
+	}
+	for _, group := range groups {
+		members, exists := s.groups[group]
+		if !exists {
+			members = make(map[string]struct{})
+			s.groups[group] = members
+		}
+		members[clientID] = struct{}{}
+	}
+
+	return nil
+}
+
+// LeaveGroup removes a client from a group
+func (s *SSEServer) LeaveGroup(clientID, group string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, exists := s.groups[group]
+	if !exists {
+		return
+	}
+
+	delete(members, clientID)
+	if len(members) == 0 {
+		delete(s.groups, group)
+	}
+}
+
+// GetGroupMembers returns the sorted IDs of the members of a group, connected or not
+func (s *SSEServer) GetGroupMembers(group string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.groups[group]))
+	for id := range s.groups[group] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SendToGroup sends a message to every member of a group, an empty group is not an error.
+// Other instances resolve their own members, with an offline store members that are not
+// connected get the message on reconnect.
+func (s *SSEServer) SendToGroup(ctx context.Context, group string, msg Message) error {
+	if err := s.validateMessage(msg); err != nil {
+		return err
+	}
+
+	ctx, span := startSendSpan(ctx, &msg, "sse.group", group)
+	defer span.End()
+
+	if s.remoteFanOut {
+		if err := s.broker.Publish(ctx, BrokerEnvelope{Origin: s.instanceID, Message: msg, Group: group}); err != nil {
+			return fmt.Errorf("failed to publish to broker: %w", err)
+		}
+	}
+
+	members := s.GetGroupMembers(group)
+	if len(members) == 0 {
+		return nil
+	}
+
+	if store := s.getOfflineStore(); store != nil && !s.remoteFanOut && !msg.Volatile {
+		online, err := s.storeOffline(ctx, store, msg, members)
+		if err != nil {
+			return err
+		}
+		if len(online) == 0 {
+			return nil
+		}
+		members = online
+	}
+
+	return s.sendLocal(ctx, msg, members, true)
+}
@@ -0,0 +1,70 @@
+package utility
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"shared/core"
+)
+
+// logThrottler collapses repeated errors of the same kind, the first one of a window is logged
+// as is and the rest are summarized with a count when the window ends
+type logThrottler struct {
+	logger *Logger
+	clock  core.Clock
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*throttledLog
+}
+
+type throttledLog struct {
+	suppressed int
+	last       string
+}
+
+func newLogThrottler(logger *Logger, clock core.Clock, window time.Duration) *logThrottler {
+	return &logThrottler{
+		logger:  logger,
+		clock:   clock,
+		window:  window,
+		entries: make(map[string]*throttledLog),
+	}
+}
+
+// Printf logs an error, messages sharing a format are the same kind of error whatever their arguments
+func (t *logThrottler) Printf(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if t.window <= 0 {
+		t.logger.Errorf("%s", message)
+		return
+	}
+
+	t.mu.Lock()
+	if entry, exists := t.entries[format]; exists {
+		entry.suppressed++
+		entry.last = message
+		t.mu.Unlock()
+		return
+	}
+	t.entries[format] = &throttledLog{}
+	t.mu.Unlock()
+
+	t.logger.Errorf("%s", message)
+	go t.summarize(format)
+}
+
+// summarize ends the window of one kind of error and reports what was held back during it
+func (t *logThrottler) summarize(format string) {
+	<-t.clock.After(t.window)
+
+	t.mu.Lock()
+	entry := t.entries[format]
+	delete(t.entries, format)
+	t.mu.Unlock()
+
+	if entry.suppressed > 0 {
+		t.logger.Errorf("%d more like the above in the last %s, last one: %s", entry.suppressed, t.window, entry.last)
+	}
+}
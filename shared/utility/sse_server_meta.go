@@ -0,0 +1,167 @@
+package utility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ClientMeta is free form information an agent attaches to its connection (hostname, agent version, labels)
+type ClientMeta map[string]string
+
+// Keys of the metadata an agent announces its capabilities with
+const (
+	CommandsMetaKey       = "commands"        // comma separated commands the agent carries out, e.g. icmp,tcp,snmp,nmap
+	MaxConcurrencyMetaKey = "max_concurrency" // probe workers the agent runs at most for one scan
+	// CapabilityMetaPrefix starts the keys an agent reports "true" for what it can do, e.g. cap_nmap,
+	// see Command.Capabilities
+	CapabilityMetaPrefix = "cap_"
+)
+
+// ErrCommandUnsupported is returned for a send to a named client that did not announce a command the
+// message requires, see Message.Requires
+var ErrCommandUnsupported = errors.New("client does not support the command")
+
+// missingCommands are the commands the client did not announce, a client that announced none is
+// trusted with every command like an agent older than the announcement. A required capability is
+// never trusted, the client must report it true.
+func missingCommands(meta ClientMeta, required []string) []string {
+	announced, ok := meta[CommandsMetaKey]
+	commands := strings.Split(strings.ToLower(announced), ",")
+	for i := range commands {
+		commands[i] = strings.TrimSpace(commands[i])
+	}
+
+	var missing []string
+	for _, command := range required {
+		switch {
+		case strings.HasPrefix(command, CapabilityMetaPrefix):
+			if meta[command] != "true" {
+				missing = append(missing, command)
+			}
+		case ok && !slices.Contains(commands, command):
+			missing = append(missing, command)
+		}
+	}
+	return missing
+}
+
+const (
+	clientMetaQueryPrefix  = "meta_"
+	clientMetaHeaderPrefix = "X-Client-Meta-"
+)
+
+// clientMetaFromRequest reads meta_<key> query params and X-Client-Meta-<Key> headers,
+// keys are lower cased with dashes turned into underscores so both sources agree
+func clientMetaFromRequest(r *http.Request) ClientMeta {
+	meta := ClientMeta{}
+
+	for name, values := range r.Header {
+		if !strings.HasPrefix(name, clientMetaHeaderPrefix) || len(values) == 0 {
+			continue
+		}
+		meta[normalizeMetaKey(strings.TrimPrefix(name, clientMetaHeaderPrefix))] = values[0]
+	}
+
+	// query params win over headers, they are what the SSE client sends by default
+	for name, values := range r.URL.Query() {
+		if !strings.HasPrefix(name, clientMetaQueryPrefix) || len(values) == 0 {
+			continue
+		}
+		meta[normalizeMetaKey(strings.TrimPrefix(name, clientMetaQueryPrefix))] = values[0]
+	}
+
+	return meta
+}
+
+func normalizeMetaKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
+
+// ConnectHook is told about an accepted connection with a copy of the metadata of the client
+type ConnectHook func(clientID string, meta ClientMeta)
+
+// OnConnect registers a hook run for every accepted connection before the connected event is sent,
+// a slow hook delays the stream
+func (s *SSEServer) OnConnect(hook ConnectHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connectHooks = append(s.connectHooks, hook)
+}
+
+// GetClientMeta returns a copy of the metadata of a connected client
+func (s *SSEServer) GetClientMeta(clientID string) (ClientMeta, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	client, exists := s.clients[clientID]
+	if !exists {
+		return nil, false
+	}
+
+	meta := make(ClientMeta, len(client.meta))
+	for key, value := range client.meta {
+		meta[key] = value
+	}
+	return meta, true
+}
+
+// SetClientMeta sets or, with an empty value, removes one metadata key of a connected client
+func (s *SSEServer) SetClientMeta(clientID, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, exists := s.clients[clientID]
+	if !exists {
+		return fmt.Errorf("client %s is not connected", clientID)
+	}
+
+	key = normalizeMetaKey(key)
+	if value == "" {
+		delete(client.meta, key)
+		return nil
+	}
+	client.meta[key] = value
+	return nil
+}
+
+// FindClients returns the connected clients whose metadata matches the predicate
+func (s *SSEServer) FindClients(match func(clientID string, meta ClientMeta) bool) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0)
+	for id, client := range s.clients {
+		if match(id, client.meta) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SendToClientsWhere sends a message to the local clients matching the predicate.
+// The predicate only sees clients of this instance, it is not forwarded through the broker.
+func (s *SSEServer) SendToClientsWhere(ctx context.Context, msg Message, match func(clientID string, meta ClientMeta) bool) error {
+	if err := s.validateMessage(msg); err != nil {
+		return err
+	}
+
+	clientIDs := s.FindClients(match)
+	if len(clientIDs) == 0 {
+		return nil
+	}
+
+	return s.sendLocal(ctx, msg, clientIDs, true)
+}
+
+// MetaEquals is a predicate for SendToClientsWhere matching clients whose key has exactly value
+func MetaEquals(key, value string) func(clientID string, meta ClientMeta) bool {
+	key = normalizeMetaKey(key)
+	return func(clientID string, meta ClientMeta) bool {
+		return meta[key] == value
+	}
+}
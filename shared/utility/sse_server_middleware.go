@@ -0,0 +1,50 @@
+package utility
+
+import (
+	"net/http"
+)
+
+// Middleware wraps an http.Handler, the usual shape of authentication, rate limiting and logging handlers
+type Middleware func(http.Handler) http.Handler
+
+// Use appends middlewares wrapping the connect handler returned by Handler, the first one added
+// runs first. They apply to connections accepted after the call.
+func (s *SSEServer) Use(middlewares ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
+// Handler returns HandleSSE wrapped in the middlewares registered with Use
+func (s *SSEServer) Handler() http.Handler {
+	return s.withMiddlewares(http.HandlerFunc(s.HandleSSE))
+}
+
+// WSHandler returns HandleWS wrapped in the middlewares registered with Use
+func (s *SSEServer) WSHandler() http.Handler {
+	return s.withMiddlewares(http.HandlerFunc(s.HandleWS))
+}
+
+func (s *SSEServer) withMiddlewares(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		middlewares := s.middlewares
+		s.mu.RUnlock()
+
+		chain(handler, middlewares).ServeHTTP(w, r)
+	})
+}
+
+// HandleSSEWith returns HandleSSE wrapped in the given middlewares only, for a mount that
+// needs a different chain than the one set up with Use
+func (s *SSEServer) HandleSSEWith(middlewares ...Middleware) http.Handler {
+	return chain(http.HandlerFunc(s.HandleSSE), middlewares)
+}
+
+func chain(handler http.Handler, middlewares []Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
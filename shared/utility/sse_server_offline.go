@@ -0,0 +1,101 @@
+package utility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StoredMessage is a message waiting in an OfflineStore for its client to reconnect
+type StoredMessage struct {
+	ID       uint
+	ClientID string
+	Message  Message
+	StoredAt time.Time
+}
+
+// OfflineStore persists messages addressed to clients that are not connected,
+// they are flushed in order when the client connects again
+type OfflineStore interface {
+	Save(ctx context.Context, clientID string, msg Message) error
+	Pending(ctx context.Context, clientID string) ([]StoredMessage, error)
+	Delete(ctx context.Context, ids []uint) error
+}
+
+// SetOfflineStore enables store-and-forward for targeted messages, nil disables it.
+// It is ignored when a broker fans out to other instances because a client missing here
+// may be connected elsewhere.
+func (s *SSEServer) SetOfflineStore(store OfflineStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offlineStore = store
+}
+
+func (s *SSEServer) getOfflineStore() OfflineStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.remoteFanOut {
+		return nil
+	}
+	return s.offlineStore
+}
+
+// storeOffline saves the message for every client that is not connected and returns the connected ones
+func (s *SSEServer) storeOffline(ctx context.Context, store OfflineStore, msg Message, clientIDs []string) ([]string, error) {
+	online := make([]string, 0, len(clientIDs))
+	for _, id := range clientIDs {
+		if s.IsClientConnected(id) {
+			online = append(online, id)
+			continue
+		}
+
+		if err := store.Save(ctx, id, msg); err != nil {
+			return nil, fmt.Errorf("failed to store message for offline client %s: %w", id, err)
+		}
+		s.logger.Debugf("Client %s offline, %s stored for later delivery", id, msg.EventType)
+	}
+	return online, nil
+}
+
+// flushOffline delivers stored messages to a client that just connected, oldest first.
+// Delivery stops at the first failure so the rest stays queued for the next connection.
+func (s *SSEServer) flushOffline(client *Client) {
+	store := s.getOfflineStore()
+	if store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.broadcastTimeout)
+	defer cancel()
+
+	pending, err := store.Pending(ctx, client.ID)
+	if err != nil {
+		s.errorLog.Printf("Failed to load stored messages for client %s: %v", client.ID, err)
+		return
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	delivered := make([]uint, 0, len(pending))
+	for _, stored := range pending {
+		err := s.sendLocal(ctx, stored.Message, []string{client.ID}, false)
+		if errors.Is(err, ErrEventFiltered) || errors.Is(err, ErrCommandUnsupported) {
+			// kept for a later connection that subscribes to the event or announces the command again
+			continue
+		}
+		if err != nil {
+			s.errorLog.Printf("Failed to deliver stored message %d to client %s: %v", stored.ID, client.ID, err)
+			break
+		}
+		delivered = append(delivered, stored.ID)
+	}
+
+	if err := store.Delete(ctx, delivered); err != nil {
+		s.errorLog.Printf("Failed to delete delivered messages for client %s: %v", client.ID, err)
+	}
+
+	s.logger.Infof("Delivered %d/%d stored messages to client %s", len(delivered), len(pending), client.ID)
+}
@@ -0,0 +1,144 @@
+package utility
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"shared/core"
+)
+
+// PresenceSnapshot is the full list of clients connected to the instance publishing it, sent through
+// the broker so every instance can tell whether a client is connected anywhere in the cluster
+type PresenceSnapshot struct {
+	ClientIDs []string `json:"client_ids"`
+	Leaving   bool     `json:"leaving,omitempty"` // the instance is shutting down, forget its clients
+}
+
+// clusterPresence keeps the last snapshot of every other instance. Each instance republishes its own
+// every interval, a snapshot older than ttl belongs to an instance that died and is ignored.
+type clusterPresence struct {
+	clock core.Clock
+	ttl   time.Duration
+
+	mu        sync.RWMutex
+	instances map[string]remoteInstance
+}
+
+type remoteInstance struct {
+	clients map[string]struct{}
+	seenAt  time.Time
+}
+
+func newClusterPresence(clock core.Clock, ttl time.Duration) *clusterPresence {
+	return &clusterPresence{clock: clock, ttl: ttl, instances: make(map[string]remoteInstance)}
+}
+
+func (p *clusterPresence) record(origin string, snapshot PresenceSnapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if snapshot.Leaving {
+		delete(p.instances, origin)
+		return
+	}
+	clients := make(map[string]struct{}, len(snapshot.ClientIDs))
+	for _, id := range snapshot.ClientIDs {
+		clients[id] = struct{}{}
+	}
+	p.instances[origin] = remoteInstance{clients: clients, seenAt: p.clock.Now()}
+}
+
+// clientIDs lists the clients of the instances heard from within ttl, stale instances are dropped
+func (p *clusterPresence) clientIDs() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	var ids []string
+	for origin, instance := range p.instances {
+		if now.Sub(instance.seenAt) > p.ttl {
+			delete(p.instances, origin)
+			continue
+		}
+		for id := range instance.clients {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// GetClusterClientIDs lists the clients connected to this instance or, with a broker, to any other
+// instance, each once. Without a broker it is GetConnectedClientIDs.
+func (s *SSEServer) GetClusterClientIDs() []string {
+	ids := s.GetConnectedClientIDs()
+	if s.presence == nil {
+		return ids
+	}
+
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		seen[id] = struct{}{}
+	}
+	for _, id := range s.presence.clientIDs() {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// IsClientConnectedInCluster checks whether a client is connected to any instance
+func (s *SSEServer) IsClientConnectedInCluster(clientID string) bool {
+	if s.IsClientConnected(clientID) {
+		return true
+	}
+	if s.presence == nil {
+		return false
+	}
+	for _, id := range s.presence.clientIDs() {
+		if id == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// publishPresence sends the clients of this instance to the others, a connect or a disconnect calls
+// it right away so they do not wait for the next interval
+func (s *SSEServer) publishPresence(leaving bool) {
+	if s.presence == nil {
+		return
+	}
+	// Shutdown removes every client, the others learn it from the one leaving snapshot
+	s.mu.RLock()
+	shuttingDown := s.shuttingDown
+	s.mu.RUnlock()
+	if shuttingDown && !leaving {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	snapshot := &PresenceSnapshot{ClientIDs: s.GetConnectedClientIDs(), Leaving: leaving}
+	if err := s.broker.Publish(ctx, BrokerEnvelope{Origin: s.instanceID, Presence: snapshot}); err != nil {
+		s.errorLog.Printf("Failed to publish presence: %v", err)
+	}
+}
+
+// runPresence republishes the presence of this instance every interval until Shutdown
+func (s *SSEServer) runPresence(interval time.Duration) {
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.presenceStop:
+			return
+		case <-ticker.C():
+			s.publishPresence(false)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package utility
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// qosQueryParam names the QoS class of a client on connect, ?qos=satellite
+	qosQueryParam = "qos"
+
+	// QoSMetaKey is the client metadata key holding the QoS class, a meta_qos label selects it too
+	QoSMetaKey = "qos"
+)
+
+// QoSClass overrides the connection settings of the clients marked with it, zero fields keep
+// the server defaults. Agents behind slow links get lenient settings, LAN dashboards strict ones.
+type QoSClass struct {
+	WriteTimeout time.Duration // Write deadline of a frame, replaces BroadcastTimeout
+	QueueSize    int           // Frames buffered before the overflow policy applies, replaces ClientQueueSize
+	KeepAlive    time.Duration // Keepalive interval, replaces KeepAlive
+}
+
+// clientQoS is the resolved set of settings a connection runs with
+type clientQoS struct {
+	class        string
+	writeTimeout time.Duration
+	queueSize    int
+	keepAlive    time.Duration
+}
+
+// resolveQoS picks the class from ?qos= or the qos label, an unknown class runs with the defaults
+func (s *SSEServer) resolveQoS(r *http.Request, meta ClientMeta) clientQoS {
+	qos := clientQoS{
+		writeTimeout: s.broadcastTimeout,
+		queueSize:    s.queueSize,
+		keepAlive:    s.keepAlive,
+	}
+
+	name := r.URL.Query().Get(qosQueryParam)
+	if name == "" {
+		name = meta[QoSMetaKey]
+	}
+	if name == "" {
+		return qos
+	}
+
+	class, ok := s.qosClasses[name]
+	if !ok {
+		s.errorLog.Printf("Unknown QoS class %q requested, using the defaults", name)
+		return qos
+	}
+
+	qos.class = name
+	if class.WriteTimeout > 0 {
+		qos.writeTimeout = class.WriteTimeout
+	}
+	if class.QueueSize > 0 {
+		qos.queueSize = class.QueueSize
+	}
+	if class.KeepAlive > 0 {
+		qos.keepAlive = class.KeepAlive
+	}
+	return qos
+}
@@ -0,0 +1,70 @@
+package utility
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrTopicForbidden is returned by Subscribe for a topic outside the client's TopicScope
+var ErrTopicForbidden = errors.New("topic not allowed for this client")
+
+// TopicScope is the set of topics a client may subscribe to, resolved once at connect, see SSEConfig.TopicScope.
+// The zero value allows no topic at all.
+type TopicScope struct {
+	all      bool
+	patterns []string
+}
+
+// AllTopics allows every topic, the scope of clients when SSEConfig.TopicScope is not set
+func AllTopics() TopicScope {
+	return TopicScope{all: true}
+}
+
+// NewTopicScope allows the given topics, a pattern ending in * allows every topic with that prefix
+// (site:* allows site:1 and site:2) and * alone allows every topic
+func NewTopicScope(patterns ...string) TopicScope {
+	scope := TopicScope{}
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if pattern == "*" {
+			return AllTopics()
+		}
+		scope.patterns = append(scope.patterns, pattern)
+	}
+	return scope
+}
+
+// Allows reports whether a client with this scope may subscribe to topic
+func (t TopicScope) Allows(topic string) bool {
+	if t.all {
+		return true
+	}
+	for _, pattern := range t.patterns {
+		if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard {
+			if strings.HasPrefix(topic, prefix) {
+				return true
+			}
+			continue
+		}
+		if pattern == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTopicScope returns the scope of a connecting client, a resolver error rejects the connection as auth_failed
+func (s *SSEServer) resolveTopicScope(r *http.Request) (TopicScope, error) {
+	if s.topicScope == nil {
+		return AllTopics(), nil
+	}
+
+	scope, err := s.topicScope(r)
+	if err != nil {
+		return TopicScope{}, rejectError{reason: RejectAuthFailed, detail: err.Error()}
+	}
+	return scope, nil
+}
@@ -0,0 +1,56 @@
+package utility
+
+import "sync"
+
+// eventSequences numbers the frames written to each client. The counter is kept by client id
+// so a stream replacing the previous one of the same id continues where it stopped, it is
+// forgotten once the client disconnects.
+type eventSequences struct {
+	mu   sync.Mutex
+	last map[string]uint64
+}
+
+func newEventSequences() *eventSequences {
+	return &eventSequences{last: make(map[string]uint64)}
+}
+
+// next reserves the id of the next frame for a client, ids start at 1
+func (q *eventSequences) next(clientID string) uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.last[clientID]++
+	return q.last[clientID]
+}
+
+func (q *eventSequences) forget(clientID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.last, clientID)
+}
+
+func (q *eventSequences) get(clientID string) (uint64, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	id, ok := q.last[clientID]
+	return id, ok
+}
+
+// forgetSequence drops the counter of a client once its stream ended and its writer stopped, unless
+// a stream of the same id replaced it meanwhile
+func (s *SSEServer) forgetSequence(client *Client) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, connected := s.clients[client.ID]; !connected {
+		s.sequences.forget(client.ID)
+	}
+}
+
+// LastEventID returns the id of the last frame written to a client connected to this instance,
+// false when nothing was sent to it since it connected
+func (s *SSEServer) LastEventID(clientID string) (uint64, bool) {
+	return s.sequences.get(clientID)
+}
@@ -0,0 +1,60 @@
+package utility
+
+import "fmt"
+
+// SSEServerStats is a point in time view of the server, counters are cumulative since start
+type SSEServerStats struct {
+	ConnectedClients int `json:"connected_clients"`
+	Topics           int `json:"topics"`
+	Groups           int `json:"groups"`
+	// Streams and Keepalives are the goroutines serving connections, both match ConnectedClients unless one leaks
+	Streams    int64 `json:"streams"`
+	Keepalives int64 `json:"keepalives"`
+	// QueuedFrames are waiting in client queues right now, a high value points at slow readers
+	QueuedFrames int `json:"queued_frames"`
+	// FramesQueued counts the frames accepted by client queues, a frame dropped from a queue later is
+	// counted in FramesDropped as well
+	FramesQueued  uint64                 `json:"frames_queued"`
+	FramesDropped uint64                 `json:"frames_dropped"`
+	Rejections    map[RejectReason]int64 `json:"rejections"`
+}
+
+// Stats returns the connection, queue and rejection counters of this instance
+func (s *SSEServer) Stats() SSEServerStats {
+	s.mu.RLock()
+	stats := SSEServerStats{
+		ConnectedClients: len(s.clients),
+		Topics:           len(s.topics),
+		Groups:           len(s.groups),
+	}
+	for _, client := range s.clients {
+		stats.QueuedFrames += len(client.queue)
+	}
+	s.mu.RUnlock()
+
+	stats.Streams = s.streams.Load()
+	stats.Keepalives = s.keepalives.Load()
+	stats.FramesQueued = s.framesQueued.Load()
+	stats.FramesDropped = s.framesDropped.Load()
+	_, stats.Rejections = s.audit.snapshot()
+	return stats
+}
+
+// LeakCheck cross-checks the clients map against the goroutines serving them, for LeakDetector.AddCheck.
+// A client left in the map after its stream ended keeps receiving frames nobody reads, a keepalive without
+// a stream is a goroutine that never stops.
+func (s *SSEServer) LeakCheck() error {
+	s.mu.RLock()
+	clients := len(s.clients)
+	s.mu.RUnlock()
+	streams := s.streams.Load()
+	keepalives := s.keepalives.Load()
+
+	switch {
+	case int64(clients) > streams:
+		return fmt.Errorf("%d clients registered but %d streams running", clients, streams)
+	case keepalives > streams:
+		return fmt.Errorf("%d keepalive goroutines for %d streams", keepalives, streams)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package utility
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Subscribe adds a connected client to a topic, a topic outside the client's TopicScope returns ErrTopicForbidden
+func (s *SSEServer) Subscribe(clientID, topic string) error {
+	if topic == "" {
+		return fmt.Errorf("topic cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, exists := s.clients[clientID]
+	if !exists {
+		return fmt.Errorf("client %s is not connected", clientID)
+	}
+	if !client.topicScope.Allows(topic) {
+		return fmt.Errorf("%w: %s", ErrTopicForbidden, topic)
+	}
+
+	subscribers, exists := s.topics[topic]
+	if !exists {
+		subscribers = make(map[string]struct{})
+		s.topics[topic] = subscribers
+	}
+	subscribers[clientID] = struct{}{}
+
+	return nil
+}
+
+// Unsubscribe removes a client from a topic
+func (s *SSEServer) Unsubscribe(clientID, topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unsubscribeLocked(clientID, topic)
+}
+
+// unsubscribeLocked expects s.mu to be held
+func (s *SSEServer) unsubscribeLocked(clientID, topic string) {
+	subscribers, exists := s.topics[topic]
+	if !exists {
+		return
+	}
+
+	delete(subscribers, clientID)
+	if len(subscribers) == 0 {
+		delete(s.topics, topic)
+	}
+}
+
+// unsubscribeAllLocked drops a client from every topic, expects s.mu to be held
+func (s *SSEServer) unsubscribeAllLocked(clientID string) {
+	for topic := range s.topics {
+		s.unsubscribeLocked(clientID, topic)
+	}
+}
+
+// GetTopicSubscribers returns the IDs of clients subscribed to a topic
+func (s *SSEServer) GetTopicSubscribers(topic string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.topics[topic]))
+	for id := range s.topics[topic] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// PublishToTopic sends a message to every client subscribed to topic, no subscribers is not an error
+func (s *SSEServer) PublishToTopic(ctx context.Context, topic string, msg Message) error {
+	if err := s.validateMessage(msg); err != nil {
+		return err
+	}
+
+	ctx, span := startSendSpan(ctx, &msg, "sse.topic", topic)
+	defer span.End()
+
+	// Other instances resolve their own subscribers
+	if s.remoteFanOut {
+		if err := s.broker.Publish(ctx, BrokerEnvelope{Origin: s.instanceID, Message: msg, Topic: topic}); err != nil {
+			return fmt.Errorf("failed to publish to broker: %w", err)
+		}
+	}
+
+	ids := s.GetTopicSubscribers(topic)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.sendLocal(ctx, msg, ids, true)
+}
+
+// topicsFromRequest reads topics from ?topics=a,b and/or repeated ?topic=a
+func topicsFromRequest(r *http.Request) []string {
+	var topics []string
+
+	query := r.URL.Query()
+	for _, value := range query["topics"] {
+		topics = append(topics, strings.Split(value, ",")...)
+	}
+	topics = append(topics, query["topic"]...)
+
+	result := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			result = append(result, topic)
+		}
+	}
+	return result
+}
@@ -0,0 +1,266 @@
+package utility
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// buildFrameBody serializes the part of an SSE frame shared by every recipient of one encoding,
+// optional fields go before event and data so clients ignore the ones they do not know
+func buildFrameBody(msg Message, data []byte, encoding string) []byte {
+	size := len("event: \ndata: \n\n") + len(msg.EventType) + len(data) + len("encoding: \n") + len(encoding)
+	if msg.Trace != "" {
+		size += len(TraceparentHeader) + len(msg.Trace) + 3
+	}
+	if msg.ID != "" {
+		size += len("ack-id: \n") + len(msg.ID)
+	}
+
+	body := make([]byte, 0, size)
+	if msg.Trace != "" {
+		body = append(body, TraceparentHeader+": "...)
+		body = append(body, msg.Trace...)
+		body = append(body, '\n')
+	}
+	if msg.ID != "" {
+		body = append(body, "ack-id: "...)
+		body = append(body, msg.ID...)
+		body = append(body, '\n')
+	}
+	if encoding != EncodingIdentity {
+		body = append(body, "encoding: "...)
+		body = append(body, encoding...)
+		body = append(body, '\n')
+	}
+	body = append(body, "event: "...)
+	body = append(body, msg.EventType...)
+	body = append(body, "\ndata: "...)
+	body = append(body, data...)
+	body = append(body, "\n\n"...)
+	return body
+}
+
+// OverflowPolicy decides what happens to a frame sent to a client whose queue is full
+type OverflowPolicy string
+
+const (
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // make room by discarding the oldest queued frame
+	OverflowDropNewest OverflowPolicy = "drop_newest" // discard the frame being sent
+	OverflowDisconnect OverflowPolicy = "disconnect"  // drop the client, it reconnects and starts over
+)
+
+// queuedFrame is a frame waiting for the client writer, flush skips the coalesce window
+type queuedFrame struct {
+	body  []byte
+	flush bool
+}
+
+// enqueueFrame hands a frame to the client writer without waiting for the connection
+func (s *SSEServer) enqueueFrame(client *Client, frame queuedFrame) error {
+	select {
+	case client.queue <- frame:
+		s.framesQueued.Add(1)
+		return nil
+	default:
+	}
+
+	switch s.overflowPolicy {
+	case OverflowDropNewest:
+		client.dropped.Add(1)
+		s.framesDropped.Add(1)
+		return nil
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-client.queue:
+				client.dropped.Add(1)
+				s.framesDropped.Add(1)
+			default:
+			}
+			select {
+			case client.queue <- frame:
+				s.framesQueued.Add(1)
+				return nil
+			default: // another sender took the slot, try again
+			}
+		}
+
+	default:
+		s.removeClient(client)
+		return fmt.Errorf("client %s is too slow, its queue of %d frames is full", client.ID, cap(client.queue))
+	}
+}
+
+// runWriter writes queued frames until the client is removed or ctx ends, frames already
+// queued by then, such as the shutdown notice, are still written
+func (s *SSEServer) runWriter(ctx context.Context, client *Client) {
+	for {
+		select {
+		case frame := <-client.queue:
+			if !s.writeQueued(client, frame) {
+				return
+			}
+		case <-client.done:
+			s.drainQueue(client)
+			return
+		case <-ctx.Done():
+			s.drainQueue(client)
+			return
+		}
+	}
+}
+
+func (s *SSEServer) drainQueue(client *Client) {
+	for {
+		select {
+		case frame := <-client.queue:
+			if !s.writeQueued(client, frame) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// writeQueued writes one frame and drops the client when the connection fails, false stops the writer
+func (s *SSEServer) writeQueued(client *Client, frame queuedFrame) bool {
+	if dropped := client.dropped.Swap(0); dropped > 0 {
+		s.errorLog.Printf("Dropped %d frame(s) for slow client %s", dropped, client.ID)
+	}
+
+	if err := s.writeFrame(client, frame); err != nil {
+		s.errorLog.Printf("Failed to send to client %s: %v", client.ID, err)
+		s.removeClient(client)
+		return false
+	}
+	return true
+}
+
+// writeFrame buffers one frame for the client, the id is taken under the client lock so ids
+// reach the stream in increasing order. A frame to flush goes out at once with whatever is buffered.
+func (s *SSEServer) writeFrame(client *Client, frame queuedFrame) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	// a consumer that stopped reading fails the write instead of holding the writer forever
+	defer s.armWriteDeadline(client)()
+
+	var id [len("id: \n") + 20]byte
+	line := strconv.AppendUint(append(id[:0], "id: "...), s.sequences.next(client.ID), 10)
+	if _, err := client.bw.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if _, err := client.bw.Write(frame.body); err != nil {
+		return err
+	}
+
+	if s.coalesceWindow <= 0 || frame.flush {
+		return client.flushLocked()
+	}
+	s.scheduleFlushLocked(client)
+	return nil
+}
+
+// armWriteDeadline fails the writes to the client that are still blocked once the write timeout
+// passed, the returned func clears the deadline and must be called before the next write
+func (s *SSEServer) armWriteDeadline(client *Client) (disarm func()) {
+	client.stream.SetWriteDeadline(s.clock.Now().Add(client.qos.writeTimeout))
+	return func() {
+		client.stream.SetWriteDeadline(time.Time{})
+	}
+}
+
+// scheduleFlushLocked flushes the client once the coalesce window passed, frames written
+// meanwhile ride along with the same Flush. client.mu must be held.
+func (s *SSEServer) scheduleFlushLocked(client *Client) {
+	if client.flushStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	client.flushStop = stop
+
+	go func() {
+		select {
+		case <-stop:
+			return
+		case <-client.done:
+			return
+		case <-s.clock.After(s.coalesceWindow):
+		}
+
+		client.mu.Lock()
+		defer client.mu.Unlock()
+
+		// flushClient cancelled it under the lock, the response may be finished already
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		client.flushStop = nil
+
+		defer s.armWriteDeadline(client)()
+		if err := client.flushLocked(); err != nil {
+			s.errorLog.Printf("Failed to flush client %s: %v", client.ID, err)
+			go s.removeClient(client)
+		}
+	}()
+}
+
+// flushClient writes out whatever is still buffered and cancels the pending coalesced flush, used
+// when the stream ends so nothing is written to it once the handler returned
+func (s *SSEServer) flushClient(client *Client) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.flushStop != nil {
+		close(client.flushStop)
+		client.flushStop = nil
+	}
+
+	defer s.armWriteDeadline(client)()
+	client.flushLocked()
+}
+
+// flushLocked pushes the buffered frames to the connection, client.mu must be held
+func (c *Client) flushLocked() error {
+	if err := c.bw.Flush(); err != nil {
+		return err
+	}
+	return c.stream.Flush()
+}
+
+// clientStream is the connection a client is written to, the frames are the same on every transport
+type clientStream interface {
+	io.Writer
+	Flush() error
+	SetWriteDeadline(deadline time.Time) error
+}
+
+// httpStream is the response of an SSE request
+type httpStream struct {
+	w          http.ResponseWriter
+	flusher    http.Flusher
+	controller *http.ResponseController
+}
+
+func newHTTPStream(w http.ResponseWriter, flusher http.Flusher) *httpStream {
+	return &httpStream{w: w, flusher: flusher, controller: http.NewResponseController(w)}
+}
+
+func (h *httpStream) Write(p []byte) (int, error) { return h.w.Write(p) }
+
+func (h *httpStream) Flush() error {
+	h.flusher.Flush()
+	return nil
+}
+
+func (h *httpStream) SetWriteDeadline(deadline time.Time) error {
+	return h.controller.SetWriteDeadline(deadline)
+}
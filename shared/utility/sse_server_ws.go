@@ -0,0 +1,67 @@
+package utility
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// HandleWS serves the stream of HandleSSE over a WebSocket, for networks whose proxies buffer
+// or cut long lived SSE responses. Every text message carries SSE frames, so ids, encodings,
+// keepalives and the event filter work the same and SendToClients reaches clients of both transports.
+func (s *SSEServer) HandleWS(w http.ResponseWriter, r *http.Request) {
+	if !s.admitConnection(w, r) {
+		return
+	}
+	defer s.connections.Done()
+
+	// the client is set up before the upgrade so a full server still answers with a plain HTTP error
+	stream := &wsStream{}
+	client, err := s.setupClientConnection(stream, r)
+	if err != nil {
+		s.rejectSetup(w, r, err)
+		return
+	}
+
+	served := false
+	websocket.Server{
+		// the origin was checked by admitConnection against the same list as SSE
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			served = true
+			stream.conn = conn
+
+			// a hijacked connection no longer cancels the request context, reading is what notices
+			// the client went away, anything it sends is ignored
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			go func() {
+				defer cancel()
+				io.Copy(io.Discard, conn)
+			}()
+
+			s.serveClient(client, ctx)
+		},
+	}.ServeHTTP(w, r)
+
+	// the handshake failed, nothing was streamed to this client
+	if !served {
+		s.removeClient(client)
+	}
+}
+
+// wsStream writes each flush of the client buffer as one text message
+type wsStream struct {
+	conn *websocket.Conn // set once the handshake completed, before anything is written
+}
+
+func (ws *wsStream) Write(p []byte) (int, error) { return ws.conn.Write(p) }
+
+func (ws *wsStream) Flush() error { return nil }
+
+func (ws *wsStream) SetWriteDeadline(deadline time.Time) error {
+	return ws.conn.SetWriteDeadline(deadline)
+}
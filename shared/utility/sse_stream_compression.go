@@ -0,0 +1,107 @@
+package utility
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Content codings of the whole SSE response, negotiated with Accept-Encoding when SSEConfig.CompressStream is set
+const (
+	StreamEncodingGzip    = "gzip"
+	StreamEncodingDeflate = "deflate"
+)
+
+// negotiateStreamEncoding picks gzip or deflate from Accept-Encoding, q=0 refuses a coding,
+// an empty result keeps the response uncompressed
+func negotiateStreamEncoding(acceptEncoding string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(strings.TrimSpace(params), " ", "") == "q=0" {
+			continue
+		}
+		accepted[strings.ToLower(strings.TrimSpace(coding))] = true
+	}
+
+	switch {
+	case accepted[StreamEncodingGzip]:
+		return StreamEncodingGzip
+	case accepted[StreamEncodingDeflate]:
+		return StreamEncodingDeflate
+	}
+	return ""
+}
+
+// compressedStream compresses the SSE response, every Flush emits a sync flush so the events
+// already written can be decoded by the client right away
+type compressedStream struct {
+	*httpStream
+	zw interface {
+		io.WriteCloser
+		Flush() error
+	}
+	closed bool
+}
+
+func newCompressedStream(stream *httpStream, encoding string) *compressedStream {
+	if encoding == StreamEncodingDeflate {
+		zw, _ := flate.NewWriter(stream.w, flate.DefaultCompression) // only fails on an invalid level
+		return &compressedStream{httpStream: stream, zw: zw}
+	}
+	return &compressedStream{httpStream: stream, zw: gzip.NewWriter(stream.w)}
+}
+
+func (c *compressedStream) Write(p []byte) (int, error) {
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return c.zw.Write(p)
+}
+
+func (c *compressedStream) Flush() error {
+	if c.closed {
+		return io.ErrClosedPipe
+	}
+	if err := c.zw.Flush(); err != nil {
+		return err
+	}
+	return c.httpStream.Flush()
+}
+
+// Close writes the end of the compressed stream, later writes fail, the caller holds the client lock
+func (c *compressedStream) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.zw.Close()
+}
+
+// decompressStream membuka body respons SSE sesuai Content-Encoding yang dipilih server
+func decompressStream(resp *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "", "identity":
+		return resp.Body, nil
+	case StreamEncodingGzip:
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("stream gzip rusak: %w", err)
+		}
+		return readCloser{Reader: zr, closer: resp.Body}, nil
+	case StreamEncodingDeflate:
+		return readCloser{Reader: flate.NewReader(resp.Body), closer: resp.Body}, nil
+	}
+	return nil, fmt.Errorf("Content-Encoding %s tidak didukung", resp.Header.Get("Content-Encoding"))
+}
+
+// readCloser membaca dari decompressor tapi menutup body aslinya
+type readCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (r readCloser) Close() error { return r.closer.Close() }
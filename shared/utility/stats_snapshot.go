@@ -0,0 +1,292 @@
+package utility
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatsSource reports one section of a stats snapshot, it runs on every snapshot so it should be cheap
+type StatsSource func(ctx context.Context) (any, error)
+
+// StatsRegistry collects the sections of a stats snapshot from the components owning them
+type StatsRegistry struct {
+	mu      sync.Mutex
+	sources map[string]StatsSource
+	started time.Time
+}
+
+func NewStatsRegistry() *StatsRegistry {
+	return &StatsRegistry{sources: map[string]StatsSource{}, started: time.Now()}
+}
+
+// Add registers the section name, a second source with the same name replaces the first
+func (r *StatsRegistry) Add(name string, source StatsSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sources[name] = source
+}
+
+// Snapshot collects every section, a failing source is reported as {"error": ...} under its name
+// instead of failing the whole snapshot
+func (r *StatsRegistry) Snapshot(ctx context.Context) map[string]any {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.sources))
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sources := make(map[string]StatsSource, len(r.sources))
+	for name, source := range r.sources {
+		sources[name] = source
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	now := time.Now()
+	snapshot := map[string]any{
+		"time":           now.UTC(),
+		"uptime_seconds": int64(now.Sub(r.started).Seconds()),
+	}
+	for _, name := range names {
+		section, err := sources[name](ctx)
+		if err != nil {
+			section = map[string]string{"error": err.Error()}
+		}
+		snapshot[name] = section
+	}
+	return snapshot
+}
+
+// RuntimeStats is the runtime section of a snapshot: goroutines, heap and garbage collection
+func RuntimeStats(ctx context.Context) (any, error) {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	return map[string]any{
+		"goroutines":    runtime.NumGoroutine(),
+		"heap_alloc_mb": float64(memory.HeapAlloc) / (1 << 20),
+		"sys_mb":        float64(memory.Sys) / (1 << 20),
+		"gc_cycles":     memory.NumGC,
+		"gc_pause_ms":   float64(memory.PauseTotalNs) / float64(time.Millisecond),
+	}, nil
+}
+
+// StatsFileConfig controls where snapshots are written and how much history is kept
+type StatsFileConfig struct {
+	// Dir receives <Name>.jsonl, older files are rotated to <Name>.1.jsonl, <Name>.2.jsonl and so on
+	Dir          string
+	Name         string        // defaults to "stats"
+	Interval     time.Duration // defaults to 1 minute
+	MaxFileBytes int64         // the current file is rotated before it grows past this, defaults to 10 MiB
+	MaxFiles     int           // rotated files kept besides the current one, defaults to 5
+}
+
+// StatsFileConfigFromEnv reads STATS_DIR, STATS_INTERVAL, STATS_MAX_FILE_MB and STATS_MAX_FILES, ok is false
+// when STATS_DIR is empty and no snapshot should be written. An unset limit keeps its default, an invalid one
+// is an error rather than silently ignored.
+func StatsFileConfigFromEnv(name string) (config StatsFileConfig, ok bool, err error) {
+	config = StatsFileConfig{Dir: os.Getenv("STATS_DIR"), Name: name}
+	if config.Dir == "" {
+		return config, false, nil
+	}
+
+	if value := os.Getenv("STATS_INTERVAL"); value != "" {
+		if config.Interval, err = time.ParseDuration(value); err != nil {
+			return config, false, fmt.Errorf("STATS_INTERVAL: %w", err)
+		}
+	}
+	if value := os.Getenv("STATS_MAX_FILE_MB"); value != "" {
+		maxMB, err := strconv.Atoi(value)
+		if err != nil {
+			return config, false, fmt.Errorf("STATS_MAX_FILE_MB: %w", err)
+		}
+		config.MaxFileBytes = int64(maxMB) << 20
+	}
+	if value := os.Getenv("STATS_MAX_FILES"); value != "" {
+		if config.MaxFiles, err = strconv.Atoi(value); err != nil {
+			return config, false, fmt.Errorf("STATS_MAX_FILES: %w", err)
+		}
+	}
+	return config, true, nil
+}
+
+// StartStatsFileWriter runs a StatsFileWriter in the background until the lifecycle stops it, the stop
+// waits for the last snapshot to be written
+func StartStatsFileWriter(registry *StatsRegistry, config StatsFileConfig, lifecycle *Lifecycle) error {
+	writer, err := NewStatsFileWriter(registry, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.Run(ctx)
+	}()
+
+	lifecycle.Register("stats-snapshot", func(ctx context.Context) error {
+		stop()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	return nil
+}
+
+// StatsFileWriter appends one JSON line per snapshot to a size rotated file, so installs without a
+// monitoring stack still have the history of the process for post-incident analysis
+type StatsFileWriter struct {
+	registry *StatsRegistry
+	config   StatsFileConfig
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewStatsFileWriter(registry *StatsRegistry, config StatsFileConfig) (*StatsFileWriter, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("stats directory is required")
+	}
+	if config.Name == "" {
+		config.Name = "stats"
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.MaxFileBytes <= 0 {
+		config.MaxFileBytes = 10 << 20
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = 5
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	w := &StatsFileWriter{registry: registry, config: config}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run writes a snapshot every interval until ctx ends, then writes a last one and closes the file
+func (w *StatsFileWriter) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the last snapshot shows the state right before the process stopped
+			if err := w.WriteSnapshot(context.WithoutCancel(ctx)); err != nil {
+				Errorf("Stats snapshot: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				Errorf("Stats snapshot: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := w.WriteSnapshot(ctx); err != nil {
+				Errorf("Stats snapshot: %v", err)
+			}
+		}
+	}
+}
+
+// WriteSnapshot collects a snapshot and appends it as one line, rotating the file first when full
+func (w *StatsFileWriter) WriteSnapshot(ctx context.Context) error {
+	// a source stuck on a lock or a database must not stop the next snapshots
+	ctx, cancel := context.WithTimeout(ctx, w.config.Interval/2+time.Second)
+	defer cancel()
+
+	line, err := json.Marshal(w.registry.Snapshot(ctx))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return fmt.Errorf("stats file is closed")
+	}
+	if w.size > 0 && w.size+int64(len(line)) > w.config.MaxFileBytes {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// Close closes the current file, later snapshots fail
+func (w *StatsFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *StatsFileWriter) path(index int) string {
+	if index == 0 {
+		return filepath.Join(w.config.Dir, w.config.Name+".jsonl")
+	}
+	return filepath.Join(w.config.Dir, fmt.Sprintf("%s.%d.jsonl", w.config.Name, index))
+}
+
+// open appends to the current file, a restart continues the file it left
+func (w *StatsFileWriter) open() error {
+	file, err := os.OpenFile(w.path(0), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate shifts every file one index up, dropping the oldest, and starts an empty current file, mu must be held
+func (w *StatsFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	if err := os.Remove(w.path(w.config.MaxFiles)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for index := w.config.MaxFiles - 1; index >= 0; index-- {
+		if err := os.Rename(w.path(index), w.path(index+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return w.open()
+}
@@ -0,0 +1,16 @@
+window.onload = function() {
+  // the spec is served next to this page by PublishSwaggerUI
+  window.ui = SwaggerUIBundle({
+    url: new URL("openapi.json", window.location.href).href,
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
@@ -0,0 +1,70 @@
+package utility
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SPKIFingerprint returns the base64 encoded SHA-256 of the certificate's SubjectPublicKeyInfo
+func SPKIFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// NewPinnedTLSConfig returns a tls.Config that, on top of normal chain verification, requires
+// at least one certificate in the verified chain to match one of the SPKI pins.
+// Pins are base64 SHA-256 SPKI hashes, optionally prefixed with "sha256/".
+func NewPinnedTLSConfig(pins []string) (*tls.Config, error) {
+	allowed := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+		if pin == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid SPKI pin %q", pin)
+		}
+		allowed[pin] = true
+	}
+
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("at least one SPKI pin is required")
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			for _, chain := range cs.VerifiedChains {
+				for _, cert := range chain {
+					if allowed[SPKIFingerprint(cert)] {
+						return nil
+					}
+				}
+			}
+			return fmt.Errorf("certificate does not match any pinned public key")
+		},
+	}, nil
+}
+
+// NewHTTPTransport returns the default transport, pinned when pins are given
+func NewHTTPTransport(pins []string) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if len(pins) == 0 {
+		return transport, nil
+	}
+
+	tlsConfig, err := NewPinnedTLSConfig(pins)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
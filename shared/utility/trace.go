@@ -0,0 +1,240 @@
+package utility
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"shared/core"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader carries the W3C trace context on HTTP requests and SSE messages,
+// the format is understood by OpenTelemetry so spans can be joined by any collector.
+//
+// The OpenTelemetry SDK is not linked here on purpose: the client and the server both build shared, and
+// the agent runs on small hosts where the SDK and its exporters would grow the binary for a feature most
+// deployments leave off. The wire format is the W3C one OpenTelemetry speaks, and a binary that wants
+// the SDK plugs it in behind SpanExporter.
+const TraceparentHeader = "traceparent"
+
+const traceContextKey core.ContextKey = "TRACE_CONTEXT"
+
+// TraceContext identifies a span within a distributed trace
+type TraceContext struct {
+	TraceID string // 32 lowercase hex characters
+	SpanID  string // 16 lowercase hex characters
+	Sampled bool
+}
+
+// NewTraceContext starts a new sampled trace
+func NewTraceContext() TraceContext {
+	return TraceContext{TraceID: randomHex(16), SpanID: randomHex(8), Sampled: true}
+}
+
+// Child returns a new span within the same trace
+func (t TraceContext) Child() TraceContext {
+	return TraceContext{TraceID: t.TraceID, SpanID: randomHex(8), Sampled: t.Sampled}
+}
+
+func (t TraceContext) IsValid() bool {
+	return len(t.TraceID) == 32 && len(t.SpanID) == 16 &&
+		t.TraceID != strings.Repeat("0", 32) && t.SpanID != strings.Repeat("0", 16)
+}
+
+// Traceparent formats the context as a version 00 traceparent value
+func (t TraceContext) Traceparent() string {
+	flags := "00"
+	if t.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", t.TraceID, t.SpanID, flags)
+}
+
+// ParseTraceparent reads a traceparent value, false when it is missing or malformed
+func ParseTraceparent(value string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return TraceContext{}, false
+	}
+
+	for _, part := range parts[:4] {
+		if _, err := hex.DecodeString(part); err != nil || part != strings.ToLower(part) {
+			return TraceContext{}, false
+		}
+	}
+
+	flags, _ := hex.DecodeString(parts[3])
+	t := TraceContext{TraceID: parts[1], SpanID: parts[2], Sampled: flags[0]&1 == 1}
+	if !t.IsValid() {
+		return TraceContext{}, false
+	}
+	return t, true
+}
+
+// ContextWithTrace attaches a trace context so the next span started from ctx becomes its child
+func ContextWithTrace(ctx context.Context, t TraceContext) context.Context {
+	return core.AttachDataToContext(ctx, traceContextKey, t)
+}
+
+// TraceFromContext returns the current span of ctx, false when ctx is not traced
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	t := core.GetDataFromContext[TraceContext](ctx, traceContextKey)
+	return t, t.IsValid()
+}
+
+// SpanRecord is a finished span handed to the SpanExporter
+type SpanRecord struct {
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string // empty for the root span
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	Error        string
+}
+
+// SpanExporter receives finished spans, an OpenTelemetry exporter can be plugged in behind it
+type SpanExporter interface {
+	ExportSpan(span SpanRecord)
+}
+
+var (
+	spanExporterMu sync.RWMutex
+	spanExporter   SpanExporter
+)
+
+// SetSpanExporter installs the process wide exporter, nil disables span export while propagation keeps working
+func SetSpanExporter(exporter SpanExporter) {
+	spanExporterMu.Lock()
+	defer spanExporterMu.Unlock()
+	spanExporter = exporter
+}
+
+func getSpanExporter() SpanExporter {
+	spanExporterMu.RLock()
+	defer spanExporterMu.RUnlock()
+	return spanExporter
+}
+
+// LogSpanExporter writes every finished span as one log line
+type LogSpanExporter struct {
+	Logger *log.Logger // optional, default log.Default()
+}
+
+func (e LogSpanExporter) ExportSpan(span SpanRecord) {
+	logger := e.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	var attrs strings.Builder
+	for key, value := range span.Attributes {
+		fmt.Fprintf(&attrs, " %s=%s", key, value)
+	}
+	if span.Error != "" {
+		fmt.Fprintf(&attrs, " error=%q", span.Error)
+	}
+
+	logger.Printf("span trace=%s span=%s parent=%s name=%q duration=%s%s",
+		span.TraceID, span.SpanID, span.ParentSpanID, span.Name, span.End.Sub(span.Start), attrs.String())
+}
+
+// Span is an operation in progress, End must be called once
+type Span struct {
+	record SpanRecord
+	trace  TraceContext
+	mu     sync.Mutex
+}
+
+// StartSpan starts a span as child of the span in ctx, or a new trace when ctx is not traced
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent, ok := TraceFromContext(ctx)
+
+	t := NewTraceContext()
+	parentSpanID := ""
+	if ok {
+		t = parent.Child()
+		parentSpanID = parent.SpanID
+	}
+
+	span := &Span{
+		trace: t,
+		record: SpanRecord{
+			Name:         name,
+			TraceID:      t.TraceID,
+			SpanID:       t.SpanID,
+			ParentSpanID: parentSpanID,
+			Start:        time.Now(),
+			Attributes:   map[string]string{},
+		},
+	}
+	return ContextWithTrace(ctx, t), span
+}
+
+func (s *Span) Context() TraceContext { return s.trace }
+
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Attributes[key] = value
+}
+
+// SetError marks the span as failed, nil is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Error = err.Error()
+}
+
+// End exports the span, a nil span, as returned for an untraced send, does nothing
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.record.End = time.Now()
+	record := s.record
+	s.mu.Unlock()
+
+	if exporter := getSpanExporter(); exporter != nil && s.trace.Sampled {
+		exporter.ExportSpan(record)
+	}
+}
+
+// TraceHTTP continues the trace of an incoming traceparent header, or starts one, with a span per request
+// and echoes the server span back in the response so callers can find the trace
+func TraceHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+		if parent, ok := ParseTraceparent(r.Header.Get(TraceparentHeader)); ok {
+			ctx = ContextWithTrace(ctx, parent)
+		}
+
+		ctx, span := StartSpan(ctx, r.Method+" "+r.URL.Path)
+		defer span.End()
+
+		w.Header().Set(TraceparentHeader, span.Context().Traceparent())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms
+	}
+	return hex.EncodeToString(b)
+}
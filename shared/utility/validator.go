@@ -0,0 +1,435 @@
+package utility
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"shared/core"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationErrors are the fields of a request that broke their rules, by the path of the field in the
+// json of the request, e.g. name, events[1] or limits.max_cpu_percent. It is the data of the 400
+// response.
+type ValidationErrors map[string]string
+
+// validationRule is one rule of a validate tag, like min=1
+type validationRule struct {
+	name  string
+	param string
+}
+
+// validationRules reads the validate, or else binding, tag of a field. A regexp rule takes the rest of
+// the tag as its pattern, commas included, so it must be the last rule.
+func validationRules(tag reflect.StructTag) []validationRule {
+	text, ok := tag.Lookup("validate")
+	if !ok {
+		text = tag.Get("binding")
+	}
+
+	var rules []validationRule
+	for text != "" {
+		rule := text
+		if !strings.HasPrefix(strings.TrimSpace(text), "regexp=") {
+			rule, text, _ = strings.Cut(text, ",")
+		} else {
+			text = ""
+		}
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name != "" {
+			rules = append(rules, validationRule{name: name, param: param})
+		}
+	}
+	return rules
+}
+
+// Validate checks the fields of a request against their validate, or else binding, tags, in the syntax
+// of go-playground/validator: omitempty, required, min, max, gte, lte, gt, lt, len, oneof, dive,
+// regexp and the formats of validationFormats. Nested structs, and the structs in slices and maps, are
+// checked too, the fields of an embedded struct or of an http:"body" field are named without a prefix.
+// A rule that does not apply to the type of its field is ignored.
+//
+// The error is made with core.NewErrorWithData and carries the ValidationErrors, a rule with a broken
+// parameter is an internal server error.
+func Validate(request any) error {
+	v := validator{errors: ValidationErrors{}}
+	if err := v.nested("", reflect.ValueOf(request)); err != nil {
+		return core.NewInternalServerError(err)
+	}
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors.err()
+}
+
+// err is the error with the message of every field, sorted by path
+func (e ValidationErrors) err() error {
+	paths := make([]string, 0, len(e))
+	for path := range e {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	messages := make([]string, len(paths))
+	for i, path := range paths {
+		messages[i] = path + " " + e[path]
+	}
+	return core.NewErrorWithData(fmt.Errorf("invalid request: %s", strings.Join(messages, "; ")), e)
+}
+
+type validator struct {
+	errors ValidationErrors
+}
+
+// nested walks into the structs of a value and checks their fields
+func (v *validator) nested(path string, value reflect.Value) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := validationPath(path, field)
+			if err := v.check(fieldPath, value.Field(i), validationRules(field.Tag)); err != nil {
+				return err
+			}
+			if err := v.nested(fieldPath, value.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.nested(fmt.Sprintf("%s[%d]", path, i), value.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			if err := v.nested(fmt.Sprintf("%s[%v]", path, key), value.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validationPath is the path of a field in the json of the request
+func validationPath(parent string, field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if field.Tag.Get("http") == "body" || (field.Anonymous && name == "") {
+		return parent
+	}
+	if header, ok := strings.CutPrefix(field.Tag.Get("http"), "header("); ok {
+		name = strings.TrimSuffix(header, ")")
+	}
+	if name == "" || name == "-" {
+		name = field.Name
+	}
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// check applies the rules of a field to its value, only the first broken rule is reported
+func (v *validator) check(path string, value reflect.Value, rules []validationRule) error {
+	for i, rule := range rules {
+		switch rule.name {
+		case "omitempty":
+			if validationEmpty(value) {
+				return nil
+			}
+			continue
+		case "required":
+			if validationEmpty(value) {
+				v.errors[path] = "is required"
+				return nil
+			}
+			continue
+		}
+
+		// the other rules are about the value a pointer points to
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return nil
+			}
+			value = value.Elem()
+		}
+
+		if rule.name == "dive" {
+			switch value.Kind() {
+			case reflect.Slice, reflect.Array:
+				for j := 0; j < value.Len(); j++ {
+					if err := v.check(fmt.Sprintf("%s[%d]", path, j), value.Index(j), rules[i+1:]); err != nil {
+						return err
+					}
+				}
+			case reflect.Map:
+				for _, key := range value.MapKeys() {
+					if err := v.check(fmt.Sprintf("%s[%v]", path, key), value.MapIndex(key), rules[i+1:]); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		message, err := validationMessage(value, rule)
+		if err != nil {
+			return fmt.Errorf("validate rule %s of %s: %v", rule.name, path, err)
+		}
+		if message != "" {
+			v.errors[path] = message
+			return nil
+		}
+	}
+	return nil
+}
+
+// validationEmpty tells whether a value is missing: nil, zero or an empty slice or map
+func validationEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// validationBounds are the words of the messages of the bound rules
+var validationBounds = map[string]string{
+	"min": "at least",
+	"gte": "at least",
+	"max": "at most",
+	"lte": "at most",
+	"gt":  "more than",
+	"lt":  "less than",
+	"len": "exactly",
+}
+
+// validationMessage tells how a value breaks a rule, it is empty when the value keeps it
+func validationMessage(value reflect.Value, rule validationRule) (string, error) {
+
+	if bound, ok := validationBounds[rule.name]; ok {
+		limit, err := strconv.ParseFloat(rule.param, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a number", rule.param)
+		}
+		size, unit, ok := validationSize(value)
+		if !ok {
+			return "", nil
+		}
+		kept := map[string]bool{
+			"min": size >= limit, "gte": size >= limit,
+			"max": size <= limit, "lte": size <= limit,
+			"gt": size > limit, "lt": size < limit,
+			"len": size == limit,
+		}[rule.name]
+		if kept {
+			return "", nil
+		}
+		limitText := strconv.FormatFloat(limit, 'f', -1, 64)
+		switch unit {
+		case "characters":
+			return fmt.Sprintf("must be %s %s characters long", bound, limitText), nil
+		case "items":
+			return fmt.Sprintf("must have %s %s items", bound, limitText), nil
+		}
+		return fmt.Sprintf("must be %s %s", bound, limitText), nil
+	}
+
+	switch rule.name {
+	case "oneof":
+		allowed := strings.Fields(rule.param)
+		for i := range allowed {
+			allowed[i] = strings.Trim(allowed[i], "'")
+		}
+		if slices.Contains(allowed, fmt.Sprint(value.Interface())) {
+			return "", nil
+		}
+		return "must be one of " + strings.Join(allowed, ", "), nil
+
+	case "regexp":
+		if value.Kind() != reflect.String {
+			return "", nil
+		}
+		pattern, err := validationPattern(rule.param)
+		if err != nil {
+			return "", err
+		}
+		if pattern.MatchString(value.String()) {
+			return "", nil
+		}
+		return "must match " + rule.param, nil
+	}
+
+	check, ok := validationFormatChecks[rule.name]
+	if !ok || value.Kind() != reflect.String {
+		return "", nil
+	}
+	if check.valid(value.String(), rule.param) {
+		return "", nil
+	}
+	return "must be " + check.description, nil
+}
+
+// validationSize is the length of a string or a collection, or the value of a number
+func validationSize(value reflect.Value) (size float64, unit string, ok bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), "characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), "items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), "", true
+	}
+	return 0, "", false
+}
+
+// validationPatterns caches the compiled patterns of the regexp rules
+var validationPatterns sync.Map
+
+func validationPattern(text string) (*regexp.Regexp, error) {
+	if pattern, ok := validationPatterns.Load(text); ok {
+		return pattern.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(text)
+	if err != nil {
+		return nil, err
+	}
+	validationPatterns.Store(text, pattern)
+	return pattern, nil
+}
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+)
+
+// validationFormatCheck checks a string format, param is the parameter of the rule, if any
+type validationFormatCheck struct {
+	description string
+	valid       func(value, param string) bool
+}
+
+// validationFormatChecks check the formats of validationFormats
+var validationFormatChecks = map[string]validationFormatCheck{
+	"email": {"an email address", func(value, _ string) bool {
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	}},
+	"url": {"an absolute url", func(value, _ string) bool {
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != ""
+	}},
+	"http_url": {"an http or https url", func(value, _ string) bool {
+		parsed, err := url.Parse(value)
+		return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+	}},
+	"uri": {"a uri", func(value, _ string) bool {
+		_, err := url.ParseRequestURI(value)
+		return err == nil
+	}},
+	"uuid": {"a uuid", func(value, _ string) bool { return uuidPattern.MatchString(value) }},
+	"ip": {"an ip address", func(value, _ string) bool {
+		_, err := netip.ParseAddr(value)
+		return err == nil
+	}},
+	"ipv4":     {"an ipv4 address", validIPv4},
+	"ip4_addr": {"an ipv4 address", validIPv4},
+	"ipv6":     {"an ipv6 address", validIPv6},
+	"ip6_addr": {"an ipv6 address", validIPv6},
+	"cidr": {"a cidr", func(value, _ string) bool {
+		_, err := netip.ParsePrefix(value)
+		return err == nil
+	}},
+	"cidrv4": {"an ipv4 cidr", func(value, _ string) bool {
+		prefix, err := netip.ParsePrefix(value)
+		return err == nil && prefix.Addr().Is4()
+	}},
+	"cidrv6": {"an ipv6 cidr", func(value, _ string) bool {
+		prefix, err := netip.ParsePrefix(value)
+		return err == nil && prefix.Addr().Is6()
+	}},
+	"hostname": {"a host name", func(value, _ string) bool { return hostnamePattern.MatchString(value) }},
+	"fqdn": {"a fully qualified domain name", func(value, _ string) bool {
+		value = strings.TrimSuffix(value, ".")
+		return strings.Contains(value, ".") && hostnamePattern.MatchString(value)
+	}},
+	"datetime": {"a date and time", func(value, layout string) bool {
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		_, err := time.Parse(layout, value)
+		return err == nil
+	}},
+	"mac": {"a mac address", func(value, _ string) bool {
+		_, err := net.ParseMAC(value)
+		return err == nil
+	}},
+}
+
+func validIPv4(value, _ string) bool {
+	address, err := netip.ParseAddr(value)
+	return err == nil && address.Is4()
+}
+
+func validIPv6(value, _ string) bool {
+	address, err := netip.ParseAddr(value)
+	return err == nil && address.Is6()
+}
+
+// bodyDecodeError is the error of a request body that is not the json of its struct, a value of the
+// wrong type is reported on its field
+func bodyDecodeError(err error) error {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		message := "must be a " + jsonTypeName(typeError.Type)
+		return core.NewErrorWithData(fmt.Errorf("invalid request body: %s %s", typeError.Field, message), ValidationErrors{typeError.Field: message})
+	}
+	return fmt.Errorf("invalid request body: %v", err)
+}
+
+// jsonTypeName is the json name of the values a go type decodes from
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}